@@ -3,6 +3,7 @@ package DDBOT
 import (
 	"fmt"
 	"github.com/Sora233/DDBOT/lsp"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/warn"
 	"github.com/Sora233/MiraiGo-Template/bot"
 	"github.com/Sora233/MiraiGo-Template/config"
@@ -20,9 +21,15 @@ import (
 
 	_ "github.com/Sora233/DDBOT/logging"
 	_ "github.com/Sora233/DDBOT/lsp/acfun"
+	_ "github.com/Sora233/DDBOT/lsp/douyin"
 	_ "github.com/Sora233/DDBOT/lsp/douyu"
 	_ "github.com/Sora233/DDBOT/lsp/huya"
+	_ "github.com/Sora233/DDBOT/lsp/netease"
+	_ "github.com/Sora233/DDBOT/lsp/pixiv"
+	_ "github.com/Sora233/DDBOT/lsp/steam"
 	_ "github.com/Sora233/DDBOT/lsp/twitcasting"
+	_ "github.com/Sora233/DDBOT/lsp/twitch"
+	_ "github.com/Sora233/DDBOT/lsp/twitter"
 	_ "github.com/Sora233/DDBOT/lsp/weibo"
 	_ "github.com/Sora233/DDBOT/lsp/youtube"
 	_ "github.com/Sora233/DDBOT/msg-marker"
@@ -103,7 +110,7 @@ func Run() {
 		warn.Warn(fmt.Sprintf("读取配置文件失败！请检查配置文件格式是否正确 - %v", err))
 		os.Exit(1)
 	}
-	config.GlobalConfig.WatchConfig()
+	cfg.WatchConfig()
 
 	// 快速初始化
 	bot.Init()
@@ -111,17 +118,28 @@ func Run() {
 	// 初始化 Modules
 	bot.StartService()
 
-	// 登录
-	bot.Login()
+	if cfg.GetOneBotEnable() {
+		// 通过OneBot协议连接go-cqhttp等实现，不使用MiraiGo登录
+		if err := lsp.Instance.ConnectOneBot(); err != nil {
+			warn.Warn(fmt.Sprintf("连接OneBot失败，请检查onebot配置及go-cqhttp是否已经启动 - %v", err))
+			os.Exit(1)
+		}
+	} else {
+		// 登录
+		bot.Login()
 
-	// 刷新好友列表，群列表
-	bot.RefreshList()
+		// 刷新好友列表，群列表
+		bot.RefreshList()
+	}
 
 	lsp.Instance.PostStart(bot.Instance)
 
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
-	<-ch
+	select {
+	case <-ch:
+	case <-lsp.Instance.ShutdownRequested():
+	}
 	bot.Stop()
 }
 
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/alecthomas/kong"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dbCmd ddbot db 子命令，直接打开数据库文件进行检查和修复，不会启动BOT
+type dbCmd struct {
+	Keys struct {
+		Pattern string `arg:"" optional:"" default:"*" help:"key的匹配模式，例如 CurrentLive:*"`
+		Limit   int    `optional:"" short:"n" default:"100" help:"最多显示的数量，设置为0时显示全部"`
+	} `cmd:"" help:"列出匹配的key"`
+	Get struct {
+		Key string `arg:"" help:"要查看的key"`
+	} `cmd:"" help:"查看key的值和剩余的过期时间"`
+	Del struct {
+		Keys []string `arg:"" help:"要删除的key，可以一次填多个"`
+	} `cmd:"" help:"删除key"`
+	Index struct {
+		Patterns []string `arg:"" optional:"" help:"索引的匹配模式，不填时为数据库中每种key各创建一个索引"`
+	} `cmd:"" help:"重新创建索引并检查每个索引中key的数量"`
+	Shrink struct {
+	} `cmd:"" help:"重写数据库文件，删除已经过期或者被覆盖的数据"`
+	Verify struct {
+		Pattern string `arg:"" optional:"" default:"*" help:"key的匹配模式"`
+	} `cmd:"" help:"检查已知类型的key能否正常解析"`
+}
+
+// runDbCmd 执行 ddbot db 子命令，数据库需要已经初始化
+func runDbCmd(ctx *kong.Context, cmd *dbCmd) error {
+	switch strings.Split(ctx.Command(), " ")[1] {
+	case "keys":
+		keys, err := localdb.ListKeys(cmd.Keys.Pattern, cmd.Keys.Limit)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Println(key)
+		}
+		if cmd.Keys.Limit > 0 && len(keys) >= cmd.Keys.Limit {
+			fmt.Printf("只显示了前%v个key，可以使用 -n 0 显示全部\n", cmd.Keys.Limit)
+		}
+	case "get":
+		var ttl time.Duration
+		value, err := localdb.Get(cmd.Get.Key, localdb.GetTTLOpt(&ttl))
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		if ttl > 0 {
+			fmt.Printf("剩余过期时间：%v\n", ttl.Truncate(time.Second))
+		} else {
+			fmt.Println("剩余过期时间：永不过期")
+		}
+	case "del":
+		for _, key := range cmd.Del.Keys {
+			if _, err := localdb.Delete(key); err != nil {
+				fmt.Printf("删除%v失败：%v\n", key, err)
+			} else {
+				fmt.Printf("已删除%v\n", key)
+			}
+		}
+	case "index":
+		patterns := cmd.Index.Patterns
+		if len(patterns) == 0 {
+			names, err := localdb.KeyNames()
+			if err != nil {
+				return err
+			}
+			for name := range names {
+				patterns = append(patterns, name+":*")
+			}
+			sort.Strings(patterns)
+		}
+		stats, err := localdb.RebuildIndex(patterns...)
+		if err != nil {
+			return err
+		}
+		for _, stat := range stats {
+			fmt.Printf("%v：%v\n", stat.Name, stat.Count)
+		}
+	case "shrink":
+		before, err := localdb.DBFileSize()
+		if err != nil {
+			return err
+		}
+		if err = localdb.Shrink(); err != nil {
+			return err
+		}
+		after, err := localdb.DBFileSize()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("数据库文件大小：%v -> %v\n", before, after)
+	case "verify":
+		checked, invalid, err := localdb.VerifyJson(cmd.Verify.Pattern)
+		if err != nil {
+			return err
+		}
+		for _, result := range invalid {
+			fmt.Println(result)
+		}
+		fmt.Printf("共检查%v个key，%v个无法解析\n", checked, len(invalid))
+		if len(invalid) > 0 {
+			fmt.Println("可以使用 ddbot db del 删除无法解析的key")
+		}
+	}
+	return nil
+}
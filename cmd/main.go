@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Sora233/DDBOT"
 	_ "github.com/Sora233/DDBOT/logging"
@@ -8,9 +9,16 @@ import (
 	_ "github.com/Sora233/DDBOT/lsp/acfun"
 	"github.com/Sora233/DDBOT/lsp/bilibili"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	_ "github.com/Sora233/DDBOT/lsp/douyin"
 	_ "github.com/Sora233/DDBOT/lsp/douyu"
 	_ "github.com/Sora233/DDBOT/lsp/huya"
+	_ "github.com/Sora233/DDBOT/lsp/netease"
 	"github.com/Sora233/DDBOT/lsp/permission"
+	_ "github.com/Sora233/DDBOT/lsp/pixiv"
+	_ "github.com/Sora233/DDBOT/lsp/steam"
+	_ "github.com/Sora233/DDBOT/lsp/twitch"
+	_ "github.com/Sora233/DDBOT/lsp/twitter"
+	"github.com/Sora233/DDBOT/lsp/version"
 	_ "github.com/Sora233/DDBOT/lsp/weibo"
 	_ "github.com/Sora233/DDBOT/lsp/youtube"
 	_ "github.com/Sora233/DDBOT/msg-marker"
@@ -21,17 +29,23 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"runtime"
+	"strings"
 )
 
 func main() {
 	var cli struct {
-		Play         bool  `optional:"" help:"运行play函数，适用于测试和开发"`
-		Debug        bool  `optional:"" help:"启动debug模式"`
-		SetAdmin     int64 `optional:"" xor:"c" help:"设置admin权限"`
-		Version      bool  `optional:"" xor:"c" short:"v" help:"打印版本信息"`
-		SyncBilibili bool  `optional:"" xor:"c" help:"同步b站帐号的关注，适用于更换或迁移b站帐号的时候"`
+		Play          bool   `optional:"" help:"运行play函数，适用于测试和开发"`
+		Debug         bool   `optional:"" help:"启动debug模式"`
+		SetAdmin      int64  `optional:"" xor:"c" help:"设置admin权限"`
+		Version       bool   `optional:"" xor:"c" short:"v" help:"打印版本信息"`
+		SyncBilibili  bool   `optional:"" xor:"c" help:"同步b站帐号的关注，适用于更换或迁移b站帐号的时候"`
+		MigrateDryRun bool   `optional:"" xor:"c" help:"检查数据库迁移能否成功执行，不会修改数据库"`
+		Restore       string `optional:"" type:"existingfile" help:"启动前使用备份文件恢复数据库，原数据库文件会被重命名保留"`
+
+		Run struct{} `cmd:"" default:"1" hidden:"" help:"启动BOT"`
+		Db  dbCmd    `cmd:"" help:"检查和修复数据库，不会启动BOT，请先停止BOT再使用"`
 	}
-	kong.Parse(&cli)
+	ctx := kong.Parse(&cli)
 
 	if cli.Version {
 		fmt.Printf("Tags: %v\n", lsp.Tags)
@@ -40,8 +54,24 @@ func main() {
 		os.Exit(0)
 	}
 
-	if err := localdb.InitBuntDB(""); err != nil {
-		if err == localdb.ErrLockNotHold {
+	storageName, dbpath := readStorageConfig()
+
+	if cli.Restore != "" {
+		if storageName != "" && !strings.EqualFold(storageName, localdb.StorageBuntDB) {
+			warn.Warn(fmt.Sprintf("恢复数据库失败：只支持%v存储", localdb.StorageBuntDB))
+			return
+		}
+		if err := localdb.Restore(cli.Restore, dbpath); err != nil {
+			warn.Warn(fmt.Sprintf("恢复数据库失败：%v", err))
+			return
+		}
+		fmt.Printf("已使用%v恢复数据库\n", cli.Restore)
+	}
+
+	if err := localdb.InitStorage(storageName, dbpath); err != nil {
+		if errors.Is(err, localdb.ErrStorageNotFound) {
+			warn.Warn(fmt.Sprintf("无法正常初始化数据库！请检查db.storage配置 - %v", err))
+		} else if err == localdb.ErrLockNotHold {
 			warn.Warn("tryLock数据库失败：您可能重复启动了这个BOT！\n如果您确认没有重复启动，请删除.lsp.db.lock文件并重新运行。")
 		} else {
 			warn.Warn("无法正常初始化数据库！请检查.lsp.db文件权限是否正确，如无问题则为数据库文件损坏，请阅读文档获得帮助。")
@@ -49,6 +79,8 @@ func main() {
 		return
 	}
 
+	localdb.EnableCache(config.GlobalConfig.GetInt("db.cacheSize"))
+
 	if runtime.GOOS == "windows" {
 		if err := exitHook(func() {
 			localdb.Close()
@@ -61,6 +93,13 @@ func main() {
 		defer localdb.Close()
 	}
 
+	if strings.HasPrefix(ctx.Command(), "db ") {
+		if err := runDbCmd(ctx, &cli.Db); err != nil {
+			fmt.Printf("执行失败 %v\n", err)
+		}
+		return
+	}
+
 	if cli.SetAdmin != 0 {
 		sm := permission.NewStateManager()
 		err := sm.GrantRole(cli.SetAdmin, permission.Admin)
@@ -70,6 +109,23 @@ func main() {
 		return
 	}
 
+	if cli.MigrateDryRun {
+		pending, err := version.PendingModules()
+		if err != nil {
+			fmt.Printf("检查数据库版本失败 %v\n", err)
+			return
+		}
+		for _, m := range pending {
+			fmt.Printf("<%v> 需要从 %v 迁移到 %v\n", m.Name, version.GetCurrentVersion(m.Name), m.SupportVersion)
+		}
+		if err := version.MigrateAll(true); err != nil {
+			fmt.Printf("数据库迁移失败 %v\n", err)
+		} else {
+			fmt.Println("数据库迁移检查完毕，数据库未被修改")
+		}
+		return
+	}
+
 	if cli.SyncBilibili {
 		config.Init()
 		c := bilibili.NewConcern(nil)
@@ -95,3 +151,16 @@ func main() {
 
 	DDBOT.Run()
 }
+
+// readStorageConfig 数据库需要在读取完整配置之前初始化，所以提前读取一次存储配置
+// 配置文件不存在或者读取失败时使用默认的buntdb存储引擎
+func readStorageConfig() (string, string) {
+	config.GlobalConfig.SetConfigName("application")
+	config.GlobalConfig.SetConfigType("yaml")
+	config.GlobalConfig.AddConfigPath(".")
+	config.GlobalConfig.AddConfigPath("./config")
+	if err := config.GlobalConfig.ReadInConfig(); err != nil {
+		return localdb.StorageBuntDB, ""
+	}
+	return config.GlobalConfig.GetString("db.storage"), config.GlobalConfig.GetString("db.path")
+}
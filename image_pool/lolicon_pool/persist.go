@@ -3,7 +3,6 @@ package lolicon_pool
 import (
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/tidwall/buntdb"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -16,10 +15,10 @@ func (pool *LoliconPool) load() {
 
 	for k, v := range pool.cache {
 		var img []*Setu
-		err := localdb.RCoverTx(func(tx *buntdb.Tx) error {
+		err := localdb.RCoverTx(func(tx localdb.Tx) error {
 			key := localdb.LoliconPoolStoreKey(k.String())
 			val, err := tx.Get(key)
-			if err == buntdb.ErrNotFound {
+			if err == localdb.ErrNotFound {
 				return nil
 			} else if err != nil {
 				return err
@@ -70,7 +69,7 @@ func (pool *LoliconPool) store() {
 				break
 			}
 		}
-		err := localdb.RWCoverTx(func(tx *buntdb.Tx) error {
+		err := localdb.RWCoverTx(func(tx localdb.Tx) error {
 			key := localdb.LoliconPoolStoreKey(k.String())
 			b, err := json.Marshal(img)
 			if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/MiraiGo-Template/bot"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"testing"
 )
 
@@ -75,7 +76,12 @@ var (
 	}
 )
 
+// InitBuntdb 初始化内存数据库，设置环境变量 DDBOT_TEST_STORAGE 时使用对应的存储引擎，例如 snapshot
 func InitBuntdb(t *testing.T) {
+	if storage := os.Getenv("DDBOT_TEST_STORAGE"); len(storage) > 0 {
+		assert.Nil(t, localdb.InitStorage(storage, localdb.MEMORYDB))
+		return
+	}
 	assert.Nil(t, localdb.InitBuntDB(localdb.MEMORYDB))
 }
 func CloseBuntdb(t *testing.T) {
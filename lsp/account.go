@@ -0,0 +1,280 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/client"
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/Sora233/MiraiGo-Template/bot"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"os"
+	"sync"
+	"time"
+)
+
+var accountLogger = utils.GetModuleLogger("account")
+
+// Account 可以用来发送消息的QQ账号
+type Account interface {
+	GetUin() int64
+	IsOnline() bool
+	FindGroup(groupCode int64) *client.GroupInfo
+	FindFriend(uin int64) *client.FriendInfo
+	SendGroupMessage(groupCode int64, m *message.SendingMessage) *message.GroupMessage
+	SendPrivateMessage(uin int64, m *message.SendingMessage) *message.PrivateMessage
+}
+
+// mainAccount 主账号，总是使用当前的 bot.Instance ，bot.Instance 在重新登录时可能会变化
+type mainAccount struct{}
+
+func (m *mainAccount) valid() bool {
+	return bot.Instance != nil && bot.Instance.QQClient != nil
+}
+
+func (m *mainAccount) GetUin() int64 {
+	if !m.valid() {
+		return 0
+	}
+	return bot.Instance.Uin
+}
+
+func (m *mainAccount) IsOnline() bool {
+	return m.valid() && bot.Instance.Online.Load()
+}
+
+func (m *mainAccount) FindGroup(groupCode int64) *client.GroupInfo {
+	if !m.valid() {
+		return nil
+	}
+	return bot.Instance.FindGroup(groupCode)
+}
+
+func (m *mainAccount) FindFriend(uin int64) *client.FriendInfo {
+	if !m.valid() {
+		return nil
+	}
+	return bot.Instance.FindFriend(uin)
+}
+
+func (m *mainAccount) SendGroupMessage(groupCode int64, msg *message.SendingMessage) *message.GroupMessage {
+	return bot.Instance.SendGroupMessage(groupCode, msg)
+}
+
+func (m *mainAccount) SendPrivateMessage(uin int64, msg *message.SendingMessage) *message.PrivateMessage {
+	return bot.Instance.SendPrivateMessage(uin, msg)
+}
+
+// clientAccount 通过 bot.accounts 配置的额外账号
+type clientAccount struct {
+	*client.QQClient
+}
+
+func (c *clientAccount) GetUin() int64 {
+	return c.Uin
+}
+
+func (c *clientAccount) IsOnline() bool {
+	return c.Online.Load()
+}
+
+// AccountPool 管理所有可以发送消息的账号，第一个账号总是主账号
+type AccountPool struct {
+	mu       sync.Mutex
+	accounts []Account
+	lastSend map[Account]time.Time
+}
+
+func NewAccountPool(main Account) *AccountPool {
+	return &AccountPool{
+		accounts: []Account{main},
+		lastSend: make(map[Account]time.Time),
+	}
+}
+
+// Add 添加一个额外账号
+func (p *AccountPool) Add(account Account) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accounts = append(p.accounts, account)
+}
+
+// SetMain 替换主账号，例如使用OneBot协议时
+func (p *AccountPool) SetMain(account Account) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accounts[0] = account
+}
+
+// Main 返回主账号
+func (p *AccountPool) Main() Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.accounts[0]
+}
+
+// List 返回所有账号
+func (p *AccountPool) List() []Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var result = make([]Account, len(p.accounts))
+	copy(result, p.accounts)
+	return result
+}
+
+// PickGroup 选择一个账号发送群消息，只会选择在线、在群内并且没有被 skip 排除的账号，
+// 有多个账号可以选择时选择最久没有发送过消息的账号，以分散每个账号的发送量。
+// 所有账号都不在群内时（例如群列表还没有刷新）会尝试使用主账号，没有可用的账号时返回nil
+func (p *AccountPool) PickGroup(groupCode int64, skip func(uin int64) bool) Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var result Account
+	var member bool
+	for _, account := range p.accounts {
+		if !account.IsOnline() || account.FindGroup(groupCode) == nil {
+			continue
+		}
+		member = true
+		if skip != nil && skip(account.GetUin()) {
+			continue
+		}
+		if result == nil || p.lastSend[account].Before(p.lastSend[result]) {
+			result = account
+		}
+	}
+	if result == nil {
+		main := p.accounts[0]
+		if member || !main.IsOnline() || (skip != nil && skip(main.GetUin())) {
+			return nil
+		}
+		result = main
+	}
+	p.lastSend[result] = time.Now()
+	return result
+}
+
+// PickPrivate 选择一个账号发送私聊消息，私聊消息通常是命令的回复，所以优先使用主账号，
+// 主账号不可用时选择一个与对方是好友的额外账号
+func (p *AccountPool) PickPrivate(uin int64) Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if main := p.accounts[0]; main.IsOnline() {
+		return main
+	}
+	for _, account := range p.accounts[1:] {
+		if account.IsOnline() && account.FindFriend(uin) != nil {
+			return account
+		}
+	}
+	return nil
+}
+
+// LoginAccounts 登录 bot.accounts 中配置的额外账号，额外账号只用于发送推送，不会响应命令。
+// 额外账号无法处理验证码与短信验证，需要验证时请先在其他设备上登录一次
+func (l *Lsp) LoginAccounts() {
+	for _, acc := range cfg.GetBotAccounts() {
+		if acc == nil || acc.Account == 0 {
+			continue
+		}
+		log := accountLogger.WithFields(localutils.FriendLogFields(acc.Account))
+		if bot.Instance != nil && acc.Account == bot.Instance.Uin {
+			log.Warn("额外账号与主账号相同，已跳过")
+			continue
+		}
+		cli, err := loginAccount(acc)
+		if err != nil {
+			log.Errorf("额外账号登录失败 %v", err)
+			continue
+		}
+		cli.GroupMuteEvent.Subscribe(func(qqClient *client.QQClient, event *client.GroupMuteEvent) {
+			if err := l.LspStateManager.Muted(event.GroupCode, event.TargetUin, event.Time); err != nil {
+				accountLogger.Errorf("Muted failed %v", err)
+			}
+		})
+		cli.DisconnectedEvent.Subscribe(func(qqClient *client.QQClient, event *client.ClientDisconnectedEvent) {
+			log.Errorf("额外账号已离线 %v，尝试重连", event.Message)
+			if err := tokenLogin(qqClient); err != nil {
+				log.Errorf("额外账号重连失败 %v，推送将由其他账号发送", err)
+			}
+		})
+		l.accounts.Add(&clientAccount{cli})
+		log.WithField("group_count", len(cli.GroupList)).Info("额外账号登录成功")
+	}
+}
+
+// LogoutAccounts 断开所有额外账号
+func (l *Lsp) LogoutAccounts() {
+	for _, account := range l.accounts.List() {
+		if c, ok := account.(*clientAccount); ok {
+			c.Disconnect()
+		}
+	}
+}
+
+func accountDeviceFile(uin int64) string {
+	return fmt.Sprintf("device-%v.json", uin)
+}
+
+func accountTokenFile(uin int64) string {
+	return fmt.Sprintf("session-%v.token", uin)
+}
+
+// loginAccount 优先使用会话缓存登录，失败时使用密码登录，设备信息不存在时会随机生成
+func loginAccount(acc *cfg.BotAccount) (*client.QQClient, error) {
+	var device = client.GenRandomDevice()
+	if b, err := os.ReadFile(accountDeviceFile(acc.Account)); err == nil {
+		if err = device.ReadJson(b); err != nil {
+			return nil, fmt.Errorf("读取%v失败 %v", accountDeviceFile(acc.Account), err)
+		}
+	} else if err = os.WriteFile(accountDeviceFile(acc.Account), device.ToJson(), 0644); err != nil {
+		return nil, err
+	}
+	if bot.Instance != nil && bot.Instance.QQClient != nil {
+		device.Protocol = bot.Instance.Device().Protocol
+	}
+	cli := client.NewClient(acc.Account, acc.Password)
+	cli.UseDevice(device)
+	if err := tokenLogin(cli); err != nil {
+		res, err := cli.Login()
+		if err != nil {
+			return nil, err
+		}
+		if !res.Success {
+			return nil, fmt.Errorf("code %v %v", res.Error, res.ErrorMessage)
+		}
+		_ = os.WriteFile(accountTokenFile(acc.Account), cli.GenToken(), 0600)
+	}
+	if err := cli.ReloadGroupList(); err != nil {
+		accountLogger.Errorf("ReloadGroupList error %v", err)
+	}
+	if err := cli.ReloadFriendList(); err != nil {
+		accountLogger.Errorf("ReloadFriendList error %v", err)
+	}
+	return cli, nil
+}
+
+func tokenLogin(cli *client.QQClient) error {
+	token, err := os.ReadFile(accountTokenFile(cli.Uin))
+	if err != nil {
+		return err
+	}
+	if err = cli.TokenLogin(token); err != nil {
+		return err
+	}
+	_ = os.WriteFile(accountTokenFile(cli.Uin), cli.GenToken(), 0600)
+	return nil
+}
+
+// isGroupMuted 主账号与所有在群内的额外账号都被禁言时返回true
+func (l *Lsp) isGroupMuted(groupCode int64) bool {
+	if !l.LspStateManager.IsMuted(groupCode, localutils.GetBot().GetUin()) {
+		return false
+	}
+	for _, account := range l.accounts.List()[1:] {
+		if account.IsOnline() && account.FindGroup(groupCode) != nil &&
+			!l.LspStateManager.IsMuted(groupCode, account.GetUin()) {
+			return false
+		}
+	}
+	return true
+}
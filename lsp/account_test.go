@@ -0,0 +1,141 @@
+package lsp
+
+import (
+	"github.com/Mrs4s/MiraiGo/client"
+	"github.com/Mrs4s/MiraiGo/message"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testAccount struct {
+	uin     int64
+	online  bool
+	groups  []int64
+	friends []int64
+	sent    int
+}
+
+func (a *testAccount) GetUin() int64 {
+	return a.uin
+}
+
+func (a *testAccount) IsOnline() bool {
+	return a.online
+}
+
+func (a *testAccount) FindGroup(groupCode int64) *client.GroupInfo {
+	for _, g := range a.groups {
+		if g == groupCode {
+			return &client.GroupInfo{Code: groupCode}
+		}
+	}
+	return nil
+}
+
+func (a *testAccount) FindFriend(uin int64) *client.FriendInfo {
+	for _, f := range a.friends {
+		if f == uin {
+			return &client.FriendInfo{Uin: uin}
+		}
+	}
+	return nil
+}
+
+func (a *testAccount) SendGroupMessage(groupCode int64, m *message.SendingMessage) *message.GroupMessage {
+	a.sent++
+	return &message.GroupMessage{Id: 1, GroupCode: groupCode, Elements: m.Elements}
+}
+
+func (a *testAccount) SendPrivateMessage(uin int64, m *message.SendingMessage) *message.PrivateMessage {
+	a.sent++
+	return &message.PrivateMessage{Id: 1, Target: uin, Elements: m.Elements}
+}
+
+func TestAccountPool_PickGroup(t *testing.T) {
+	var main = &testAccount{uin: 1, online: true, groups: []int64{100, 200}}
+	var a2 = &testAccount{uin: 2, online: true, groups: []int64{100, 300}}
+	var a3 = &testAccount{uin: 3, online: false, groups: []int64{100}}
+	p := NewAccountPool(main)
+	p.Add(a2)
+	p.Add(a3)
+
+	assert.Len(t, p.List(), 3)
+	assert.Equal(t, main, p.Main())
+
+	// 只有额外账号在群内
+	assert.Equal(t, a2, p.PickGroup(300, nil))
+	// 只有主账号在群内
+	assert.Equal(t, main, p.PickGroup(200, nil))
+
+	// 两个账号都在群内时轮流发送
+	var picked = map[int64]int{}
+	for i := 0; i < 10; i++ {
+		picked[p.PickGroup(100, nil).GetUin()]++
+	}
+	assert.Equal(t, 5, picked[1])
+	assert.Equal(t, 5, picked[2])
+	assert.Zero(t, picked[3])
+
+	// 被禁言的账号不会被选择
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, a2, p.PickGroup(100, func(uin int64) bool { return uin == 1 }))
+	}
+	assert.Nil(t, p.PickGroup(300, func(uin int64) bool { return uin == 2 }))
+
+	// 没有账号在群内时尝试使用主账号
+	assert.Equal(t, main, p.PickGroup(400, nil))
+	assert.Nil(t, p.PickGroup(400, func(uin int64) bool { return uin == 1 }))
+
+	main.online = false
+	assert.Nil(t, p.PickGroup(200, nil))
+	assert.Nil(t, p.PickGroup(400, nil))
+	assert.Equal(t, a2, p.PickGroup(100, nil))
+}
+
+func TestAccountPool_PickPrivate(t *testing.T) {
+	var main = &testAccount{uin: 1, online: true}
+	var a2 = &testAccount{uin: 2, online: true, friends: []int64{10}}
+	p := NewAccountPool(main)
+	p.Add(a2)
+
+	assert.Equal(t, main, p.PickPrivate(10))
+	assert.Equal(t, main, p.PickPrivate(20))
+
+	main.online = false
+	assert.Equal(t, a2, p.PickPrivate(10))
+	assert.Nil(t, p.PickPrivate(20))
+
+	var a3 = &testAccount{uin: 3, online: true}
+	p.SetMain(a3)
+	assert.Equal(t, a3, p.Main())
+	assert.Equal(t, a3, p.PickPrivate(20))
+	assert.Len(t, p.List(), 2)
+}
+
+func TestLsp_isGroupMuted(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	var accounts = Instance.accounts
+	defer func() {
+		Instance.accounts = accounts
+	}()
+
+	var a2 = &testAccount{uin: 2, online: true, groups: []int64{100}}
+	Instance.accounts = NewAccountPool(new(mainAccount))
+	Instance.accounts.Add(a2)
+
+	var mainUin = localutils.GetBot().GetUin()
+	assert.False(t, Instance.isGroupMuted(100))
+	assert.Nil(t, Instance.LspStateManager.Muted(100, mainUin, 3600))
+	assert.False(t, Instance.isGroupMuted(100))
+	assert.Nil(t, Instance.LspStateManager.Muted(100, 2, 3600))
+	assert.True(t, Instance.isGroupMuted(100))
+
+	msg := message.NewSendingMessage().Append(message.NewText("test"))
+	assert.EqualValues(t, -1, Instance.sendGroupMessage(100, msg).Id)
+	assert.Nil(t, Instance.LspStateManager.Muted(100, 2, 0))
+	assert.EqualValues(t, 1, Instance.sendGroupMessage(100, msg).Id)
+	assert.Equal(t, 1, a2.sent)
+}
@@ -34,6 +34,7 @@ func ApiChannelList(count int32, pcursor string) (*ApiChannelListResponse, error
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.AddUAOption(),
 		requests.TimeoutOption(time.Second*10),
 	)
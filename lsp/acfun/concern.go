@@ -4,13 +4,11 @@ import (
 	"context"
 	"fmt"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
-	localutils "github.com/Sora233/DDBOT/utils"
-	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/Sora233/MiraiGo-Template/utils"
-	"github.com/tidwall/buntdb"
 	"strconv"
 	"strings"
 	"time"
@@ -35,7 +33,16 @@ func (c *Concern) Types() []concern_type.Type {
 	return []concern_type.Type{Live}
 }
 
+// ParseId 支持直接使用uid，也支持直播间链接 https://live.acfun.cn/live/<uid> 与个人主页链接 https://www.acfun.cn/u/<uid>
 func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://", "http://", "www.", "live.acfun.cn/live/", "acfun.cn/u/"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	if idx := strings.IndexAny(s, "?#"); idx >= 0 {
+		s = s[:idx]
+	}
+	s = strings.TrimSuffix(s, "/")
 	return strconv.ParseInt(s, 10, 64)
 }
 
@@ -54,16 +61,16 @@ func (c *Concern) Stop() {
 }
 
 func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, ievent concern.Event) (result []concern.Notify) {
+	return func(target mmsg.TargetId, ievent concern.Event) (result []concern.Notify) {
 		log := ievent.Logger()
 		switch event := ievent.(type) {
 		case *LiveInfo:
-			notify := NewConcernLiveNotify(groupCode, event)
+			notify := NewConcernLiveNotify(target, event)
 			result = append(result, notify)
 			if event.Living() {
-				log.WithFields(localutils.GroupLogFields(groupCode)).Trace("living notify")
+				log.WithFields(mmsg.TargetLogFields(target)).Trace("living notify")
 			} else {
-				log.WithFields(localutils.GroupLogFields(groupCode)).Trace("noliving notify")
+				log.WithFields(mmsg.TargetLogFields(target)).Trace("noliving notify")
 			}
 		default:
 			log.Errorf("unknown concern_type %v", ievent.Type().String())
@@ -75,24 +82,18 @@ func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
 func (c *Concern) fresh() concern.FreshFunc {
 	return func(ctx context.Context, eventChan chan<- concern.Event) {
 		t := time.NewTimer(time.Second * 3)
-		var interval time.Duration
-		if config.GlobalConfig != nil {
-			interval = config.GlobalConfig.GetDuration("acfun.interval")
-		}
-		if interval == 0 {
-			interval = time.Second * 20
-		}
 		for {
 			select {
 			case <-t.C:
 			case <-ctx.Done():
 				return
 			}
+			interval := cfg.GetAcfunInterval()
 			var start = time.Now()
 			err := func() error {
 				defer func() { logger.WithField("cost", time.Now().Sub(start)).Tracef("watchCore live fresh done") }()
 
-				_, ids, types, err := c.StateManager.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+				_, ids, types, err := c.StateManager.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 					return p.ContainAny(Live)
 				})
 				if err != nil {
@@ -193,12 +194,12 @@ func (c *Concern) fresh() concern.FreshFunc {
 	}
 }
 
-func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	var err error
 	var uid = id.(int64)
-	log := logger.WithFields(localutils.GroupLogFields(groupCode)).WithField("id", id)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
 
-	err = c.StateManager.CheckGroupConcern(groupCode, id, ctype)
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +210,7 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype c
 		log.Errorf("FindOrLoadUserInfo error %v", err)
 		return nil, fmt.Errorf("查询用户信息失败 %v - %v", id, err)
 	}
-	_, err = c.StateManager.AddGroupConcern(groupCode, id, ctype)
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -221,7 +222,7 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype c
 		// 其他群关注了同一uid，并且推送过Living，那么给新watch的群也推一份
 		if liveInfo != nil && liveInfo.Living() {
 			if ctx.GetTarget().TargetType().IsGroup() {
-				defer c.GroupWatchNotify(groupCode, uid)
+				defer c.GroupWatchNotify(target, uid)
 			}
 			if ctx.GetTarget().TargetType().IsPrivate() {
 				defer ctx.Send(mmsg.NewText("检测到该用户正在直播，但由于您目前处于私聊模式，" +
@@ -232,14 +233,14 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype c
 	return concern.NewIdentity(userInfo.Uid, userInfo.GetName()), nil
 }
 
-func (c *Concern) Remove(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	mid := id.(int64)
 	var identityInfo concern.IdentityInfo
 	var allCtype concern_type.Type
-	err := c.StateManager.RWCoverTx(func(tx *buntdb.Tx) error {
+	err := c.StateManager.RWCoverTx(func(tx localdb.Tx) error {
 		var err error
 		identityInfo, _ = c.Get(mid)
-		_, err = c.StateManager.RemoveGroupConcern(groupCode, mid, ctype)
+		_, err = c.StateManager.RemoveGroupConcern(target, mid, ctype)
 		if err != nil {
 			return err
 		}
@@ -251,7 +252,7 @@ func (c *Concern) Remove(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctyp
 		// 如果此时liveinfo是living状态，则此状态会一直保留，下次watch时会以为在living错误推送
 		if !allCtype.ContainAll(Live) {
 			err = c.StateManager.DeleteLiveInfo(mid)
-			if err == buntdb.ErrNotFound {
+			if err == localdb.ErrNotFound {
 				err = nil
 			}
 			if err != nil {
@@ -303,11 +304,11 @@ func (c *Concern) FindOrLoadUserInfo(uid int64) (*UserInfo, error) {
 	return userInfo, nil
 }
 
-func (c *Concern) GroupWatchNotify(groupCode, mid int64) {
+func (c *Concern) GroupWatchNotify(target mmsg.TargetId, mid int64) {
 	liveInfo, _ := c.GetLiveInfo(mid)
 	if liveInfo.Living() {
 		liveInfo.liveStatusChanged = true
-		c.notify <- NewConcernLiveNotify(groupCode, liveInfo)
+		c.notify <- NewConcernLiveNotify(target, liveInfo)
 	}
 }
 
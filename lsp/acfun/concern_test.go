@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/Sora233/DDBOT/internal/test"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -41,6 +42,18 @@ func TestNewConcern(t *testing.T) {
 	assert.Nil(t, err)
 	assert.EqualValues(t, 123, _id)
 
+	for _, s := range []string{
+		"https://live.acfun.cn/live/123",
+		"https://www.acfun.cn/u/123?from=live",
+		"live.acfun.cn/live/123/",
+	} {
+		_id, err = c.ParseId(s)
+		assert.Nil(t, err)
+		assert.EqualValues(t, 123, _id)
+	}
+	_, err = c.ParseId("https://live.acfun.cn/live/abc")
+	assert.NotNil(t, err)
+
 	origUserInfo := &UserInfo{
 		Uid:  test.UID1,
 		Name: test.NAME1,
@@ -63,7 +76,7 @@ func TestNewConcern(t *testing.T) {
 	case <-time.After(time.Second):
 	}
 
-	_, err = c.StateManager.AddGroupConcern(test.G1, test.UID1, Live)
+	_, err = c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, Live)
 	assert.Nil(t, err)
 	assert.Nil(t, c.StateManager.AddLiveInfo(origLiveInfo))
 
@@ -77,14 +90,14 @@ func TestNewConcern(t *testing.T) {
 	case notify := <-testNotifyChan:
 		assert.NotNil(t, notify)
 		assert.EqualValues(t, test.UID1, notify.GetUid())
-		assert.EqualValues(t, test.G1, notify.GetGroupCode())
+		assert.EqualValues(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
 	case <-time.After(time.Second):
 		assert.Fail(t, "no item received")
 	}
 
-	_, err = c.StateManager.AddGroupConcern(test.G2, test.UID1, Live)
+	_, err = c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, Live)
 	assert.Nil(t, err)
-	_, err = c.StateManager.AddGroupConcern(test.G2, test.UID2, Live)
+	_, err = c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID2, Live)
 	assert.Nil(t, err)
 	err = c.StateManager.AddUserInfo(&UserInfo{
 		Uid:  test.UID2,
@@ -103,18 +116,18 @@ func TestNewConcern(t *testing.T) {
 		case notify := <-testNotifyChan:
 			assert.NotNil(t, notify)
 			assert.EqualValues(t, test.UID1, notify.GetUid())
-			assert.True(t, notify.GetGroupCode() == test.G1 || notify.GetGroupCode() == test.G2)
+			assert.True(t, notify.GetTarget() == mmsg.NewGroupTargetId(test.G1) || notify.GetTarget() == mmsg.NewGroupTargetId(test.G2))
 		case <-time.After(time.Second):
 			assert.Fail(t, "no item received")
 		}
 	}
 
-	go c.GroupWatchNotify(test.G2, test.UID1)
+	go c.GroupWatchNotify(mmsg.NewGroupTargetId(test.G2), test.UID1)
 	select {
 	case notify := <-testNotifyChan:
 		assert.NotNil(t, notify)
 		assert.EqualValues(t, test.UID1, notify.GetUid())
-		assert.EqualValues(t, test.G2, notify.GetGroupCode())
+		assert.EqualValues(t, mmsg.NewGroupTargetId(test.G2), notify.GetTarget())
 		assert.NotNil(t, notify.Logger())
 		assert.NotNil(t, notify.ToMessage())
 	case <-time.After(time.Second):
@@ -136,12 +149,12 @@ func TestNewConcern2(t *testing.T) {
 
 	const testId int64 = 1
 
-	info, err := c.Add(nil, test.G1, testId, Live)
+	info, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), testId, Live)
 	assert.Nil(t, err)
 	assert.EqualValues(t, "admin", info.GetName())
 	assert.EqualValues(t, testId, info.GetUid())
 
-	info, err = c.Remove(nil, test.G1, testId, Live)
+	info, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), testId, Live)
 	assert.Nil(t, err)
 	assert.EqualValues(t, "admin", info.GetName())
 	assert.EqualValues(t, testId, info.GetUid())
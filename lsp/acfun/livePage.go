@@ -22,6 +22,7 @@ func LivePage(uid int64) (*LivePageResponse, error) {
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.AddUAOption(),
 		requests.TimeoutOption(time.Second*10),
 	)
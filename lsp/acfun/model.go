@@ -4,7 +4,6 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/template"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/sirupsen/logrus"
 	"sync"
 )
@@ -93,12 +92,12 @@ func (l *LiveInfo) GetMSG() *mmsg.MSG {
 }
 
 type ConcernLiveNotify struct {
-	GroupCode int64
+	Target mmsg.TargetId
 	*LiveInfo
 }
 
-func (notify *ConcernLiveNotify) GetGroupCode() int64 {
-	return notify.GroupCode
+func (notify *ConcernLiveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
 }
 
 func (notify *ConcernLiveNotify) ToMessage() (m *mmsg.MSG) {
@@ -109,12 +108,12 @@ func (notify *ConcernLiveNotify) Logger() *logrus.Entry {
 	if notify == nil {
 		return logger
 	}
-	return notify.LiveInfo.Logger().WithFields(localutils.GroupLogFields(notify.GroupCode))
+	return notify.LiveInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
 }
 
-func NewConcernLiveNotify(groupCode int64, info *LiveInfo) *ConcernLiveNotify {
+func NewConcernLiveNotify(target mmsg.TargetId, info *LiveInfo) *ConcernLiveNotify {
 	return &ConcernLiveNotify{
-		GroupCode: groupCode,
-		LiveInfo:  info,
+		Target:   target,
+		LiveInfo: info,
 	}
 }
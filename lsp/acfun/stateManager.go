@@ -4,7 +4,7 @@ import (
 	"errors"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
-	"github.com/tidwall/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 )
 
 type StateManager struct {
@@ -12,8 +12,8 @@ type StateManager struct {
 	extraKey
 }
 
-func (s *StateManager) GetGroupConcernConfig(groupCode int64, id interface{}) (concernConfig concern.IConfig) {
-	return NewGroupConcernConfig(s.StateManager.GetGroupConcernConfig(groupCode, id))
+func (s *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(s.StateManager.GetGroupConcernConfig(target, id))
 }
 
 func NewStateManager(notify chan<- concern.Notify) *StateManager {
@@ -59,7 +59,7 @@ func (s *StateManager) GetLiveInfo(uid int64) (*LiveInfo, error) {
 }
 
 func (s *StateManager) DeleteLiveInfo(uid int64) error {
-	return s.RWCoverTx(func(tx *buntdb.Tx) error {
+	return s.RWCoverTx(func(tx localdb.Tx) error {
 		_, err := tx.Delete(s.LiveInfoKey(uid))
 		return err
 	})
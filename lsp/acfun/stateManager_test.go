@@ -4,15 +4,15 @@ import (
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"testing"
 )
 
 func initStateManager(t *testing.T, notifyChan chan<- concern.Notify) *StateManager {
 	sm := NewStateManager(notifyChan)
 	assert.NotNil(t, sm)
-	sm.FreshIndex(test.G1, test.G2)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
 	return sm
 }
 
@@ -26,7 +26,7 @@ func TestStateManager(t *testing.T) {
 	assert.NotNil(t, sm)
 	defer sm.Stop()
 
-	assert.NotNil(t, sm.GetGroupConcernConfig(test.G1, test.UID1))
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1))
 
 	userInfo := UserInfo{
 		Uid:  test.UID1,
@@ -61,7 +61,7 @@ func TestStateManager(t *testing.T) {
 	_, err = sm.GetLiveInfo(test.UID1)
 	assert.NotNil(t, err)
 
-	err = localdb.RWCoverTx(func(tx *buntdb.Tx) error {
+	err = localdb.RWCoverTx(func(tx localdb.Tx) error {
 		_, _, err := tx.Set(sm.NotLiveKey(test.UID1), "wrong", nil)
 		return err
 	})
@@ -0,0 +1,386 @@
+package lsp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var adminApiLogger = logger.WithField("sub_module", "admin_api")
+
+// AdminApi 内置的HTTP管理接口，配置 adminApi.addr 与 adminApi.token 后启用，
+// 可以不通过QQ命令查看与管理订阅，所有 /api/ 请求都需要携带 Authorization: Bearer <token>
+type AdminApi struct {
+	l      *Lsp
+	token  string
+	server *http.Server
+}
+
+type adminApiConcern struct {
+	// GroupCode 推送目标是QQ群时为群号码
+	GroupCode int64 `json:"group_code,omitempty"`
+	// Target 推送目标，格式与 mmsg.TargetId.Key 相同
+	Target string      `json:"target"`
+	Site   string      `json:"site"`
+	Id     interface{} `json:"id"`
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+}
+
+type adminApiState struct {
+	Site   string               `json:"site"`
+	Id     interface{}          `json:"id"`
+	Name   string               `json:"name"`
+	Type   string               `json:"type"`
+	Groups []*adminApiConcern   `json:"groups"`
+	Info   concern.IdentityInfo `json:"info"`
+}
+
+type adminApiError struct {
+	Error string `json:"error"`
+}
+
+// Handler 返回管理接口的 http.Handler
+//
+//	GET    /api/concern         ?group=&site=              查询订阅，参数均可省略
+//	POST   /api/concern         group=&site=&id=&type=     添加订阅
+//	DELETE /api/concern         ?group=&site=&id=&type=    删除订阅
+//	GET    /api/concern/config  ?group=&site=&id=          查询订阅配置
+//	GET    /api/concern/state   ?site=&id=                 查询id的状态以及订阅的群
+//	POST   /api/concern/fresh   site=&id=                  立即刷新id
+//	GET    /api/dashboard                                  网页面板数据
+//	GET    /dashboard                                      网页面板，页面本身不需要token
+func (a *AdminApi) Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("/api/concern", a.handleConcern)
+	api.HandleFunc("/api/concern/config", a.method(http.MethodGet, a.handleConcernConfig))
+	api.HandleFunc("/api/concern/state", a.method(http.MethodGet, a.handleConcernState))
+	api.HandleFunc("/api/concern/fresh", a.method(http.MethodPost, a.handleConcernFresh))
+	api.HandleFunc("/api/dashboard", a.method(http.MethodGet, a.handleDashboard))
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", a.auth(api))
+	mux.HandleFunc("/dashboard", a.method(http.MethodGet, a.handleDashboardPage))
+	return mux
+}
+
+// Start 在后台启动HTTP服务
+func (a *AdminApi) Start(addr string) {
+	a.server = &http.Server{
+		Addr:              addr,
+		Handler:           a.Handler(),
+		ReadHeaderTimeout: time.Second * 10,
+	}
+	go func() {
+		adminApiLogger.Infof("HTTP管理接口已启动：%v", addr)
+		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			adminApiLogger.Errorf("HTTP管理接口启动失败 %v", err)
+		}
+	}()
+}
+
+// Stop 停止HTTP服务
+func (a *AdminApi) Stop() {
+	if a.server != nil {
+		a.server.Close()
+	}
+}
+
+func (a *AdminApi) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			a.writeError(w, http.StatusUnauthorized, errors.New("token错误"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *AdminApi) method(method string, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			a.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("不支持的请求方法 %v", r.Method))
+			return
+		}
+		f(w, r)
+	}
+}
+
+func (a *AdminApi) handleConcern(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.handleConcernList(w, r)
+	case http.MethodPost:
+		a.handleConcernWatch(w, r, false)
+	case http.MethodDelete:
+		a.handleConcernWatch(w, r, true)
+	default:
+		a.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("不支持的请求方法 %v", r.Method))
+	}
+}
+
+func (a *AdminApi) handleConcernList(w http.ResponseWriter, r *http.Request) {
+	var groupCode int64
+	if g := r.FormValue("group"); len(g) > 0 {
+		var err error
+		groupCode, err = strconv.ParseInt(g, 10, 64)
+		if err != nil {
+			a.writeError(w, http.StatusBadRequest, errors.New("group格式错误"))
+			return
+		}
+	}
+	var targetCM []concern.Concern
+	if site := r.FormValue("site"); len(site) > 0 {
+		cm, err := concern.GetConcernByParseSite(site)
+		if err != nil {
+			a.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		targetCM = append(targetCM, cm)
+	} else {
+		targetCM = concern.ListConcern()
+	}
+	var result = make([]*adminApiConcern, 0)
+	for _, cm := range targetCM {
+		targets, ids, ctypes, err := cm.GetStateManager().ListConcernState(func(target mmsg.TargetId, _ interface{}, _ concern_type.Type) bool {
+			return groupCode == 0 || target == mmsg.NewGroupTargetId(groupCode)
+		})
+		if err != nil {
+			a.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for index := range ids {
+			result = append(result, a.newConcern(cm, targets[index], ids[index], ctypes[index]))
+		}
+	}
+	a.writeJson(w, http.StatusOK, result)
+}
+
+func (a *AdminApi) handleConcernWatch(w http.ResponseWriter, r *http.Request, remove bool) {
+	groupCode, err := strconv.ParseInt(r.FormValue("group"), 10, 64)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, errors.New("group格式错误"))
+		return
+	}
+	cm, _, ctype, err := concern.GetConcernByParseSiteAndType(r.FormValue("site"), r.FormValue("type"))
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := cm.ParseId(r.FormValue("id"))
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, fmt.Errorf("解析%v id格式错误", cm.Site()))
+		return
+	}
+	log := adminApiLogger.WithFields(localutils.GroupLogFields(groupCode)).
+		WithField("site", cm.Site()).WithField("id", id).WithField("remove", remove)
+	ctx := a.newMessageContext(groupCode, log)
+	target := mmsg.NewGroupTargetId(groupCode)
+	if remove {
+		if _, err = cm.Remove(ctx, target, id, ctype); err != nil {
+			log.Errorf("remove failed %v", err)
+			if err == localdb.ErrNotFound {
+				a.writeError(w, http.StatusNotFound, errors.New("未找到该订阅"))
+			} else {
+				a.writeError(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		log.Info("HTTP管理接口删除订阅")
+		a.writeJson(w, http.StatusOK, a.newConcern(cm, target, id, ctype))
+		return
+	}
+	if localutils.GetBot().FindGroup(groupCode) == nil {
+		a.writeError(w, http.StatusNotFound, errors.New("未找到该群"))
+		return
+	}
+	if a.l.LspStateManager.CheckGroupModuleDisabled(groupCode, cm.Site()) {
+		a.writeError(w, http.StatusForbidden, fmt.Errorf("%v订阅已在本群禁用", cm.Site()))
+		return
+	}
+	if a.l.LspStateManager.CheckBlocklistId(cm.Site(), id) {
+		a.writeError(w, http.StatusForbidden, errors.New("该id已被管理员禁止订阅"))
+		return
+	}
+	if _, err = cm.Add(ctx, target, id, ctype); err != nil {
+		log.Errorf("add failed %v", err)
+		if err == concern.ErrAlreadyExists {
+			a.writeError(w, http.StatusConflict, errors.New("已经订阅过了"))
+		} else {
+			a.writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	log.Info("HTTP管理接口添加订阅")
+	a.writeJson(w, http.StatusOK, a.newConcern(cm, target, id, ctype))
+}
+
+func (a *AdminApi) handleConcernConfig(w http.ResponseWriter, r *http.Request) {
+	groupCode, err := strconv.ParseInt(r.FormValue("group"), 10, 64)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, errors.New("group格式错误"))
+		return
+	}
+	cm, id, ok := a.parseSiteAndId(w, r)
+	if !ok {
+		return
+	}
+	target := mmsg.NewGroupTargetId(groupCode)
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, id); err != nil || ctype.Empty() {
+		a.writeError(w, http.StatusNotFound, errors.New("未找到该订阅"))
+		return
+	}
+	config := cm.GetStateManager().GetGroupConcernConfig(target, id)
+	a.writeJson(w, http.StatusOK, map[string]interface{}{
+		"group_concern_at":     config.GetGroupConcernAt(),
+		"group_concern_notify": config.GetGroupConcernNotify(),
+		"group_concern_filter": config.GetGroupConcernFilter(),
+	})
+}
+
+func (a *AdminApi) handleConcernState(w http.ResponseWriter, r *http.Request) {
+	cm, id, ok := a.parseSiteAndId(w, r)
+	if !ok {
+		return
+	}
+	ctype, err := cm.GetStateManager().GetConcern(id)
+	if err != nil || ctype.Empty() {
+		a.writeError(w, http.StatusNotFound, errors.New("未找到该订阅"))
+		return
+	}
+	targets, ids, ctypes, err := cm.GetStateManager().ListConcernState(func(_ mmsg.TargetId, _id interface{}, _ concern_type.Type) bool {
+		return _id == id
+	})
+	if err != nil {
+		a.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	state := &adminApiState{
+		Site:   cm.Site(),
+		Id:     id,
+		Type:   ctype.String(),
+		Groups: make([]*adminApiConcern, 0),
+	}
+	if info, err := cm.Get(id); err == nil {
+		state.Info = info
+		state.Name = info.GetName()
+	}
+	for index := range ids {
+		state.Groups = append(state.Groups, &adminApiConcern{
+			GroupCode: targets[index].GroupCode(),
+			Target:    targets[index].Key(),
+			Site:      cm.Site(),
+			Id:        id,
+			Name:      state.Name,
+			Type:      ctypes[index].String(),
+		})
+	}
+	a.writeJson(w, http.StatusOK, state)
+}
+
+func (a *AdminApi) handleConcernFresh(w http.ResponseWriter, r *http.Request) {
+	cm, id, ok := a.parseSiteAndId(w, r)
+	if !ok {
+		return
+	}
+	sm, ok := cm.GetStateManager().(interface{ FreshNow(id interface{}) error })
+	if !ok {
+		a.writeError(w, http.StatusNotImplemented, fmt.Errorf("%v不支持手动刷新", cm.Site()))
+		return
+	}
+	if err := sm.FreshNow(id); err != nil {
+		switch err {
+		case localdb.ErrNotFound:
+			a.writeError(w, http.StatusNotFound, errors.New("未找到该订阅"))
+		case concern.ErrEmitQueueNotInit:
+			a.writeError(w, http.StatusNotImplemented, fmt.Errorf("%v不支持手动刷新", cm.Site()))
+		default:
+			a.writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	adminApiLogger.WithField("site", cm.Site()).WithField("id", id).Info("HTTP管理接口手动刷新")
+	a.writeJson(w, http.StatusOK, map[string]interface{}{
+		"site": cm.Site(),
+		"id":   id,
+	})
+}
+
+func (a *AdminApi) parseSiteAndId(w http.ResponseWriter, r *http.Request) (concern.Concern, interface{}, bool) {
+	cm, err := concern.GetConcernByParseSite(r.FormValue("site"))
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, err)
+		return nil, nil, false
+	}
+	id, err := cm.ParseId(r.FormValue("id"))
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, fmt.Errorf("解析%v id格式错误", cm.Site()))
+		return nil, nil, false
+	}
+	return cm, id, true
+}
+
+func (a *AdminApi) newConcern(cm concern.Concern, target mmsg.TargetId, id interface{}, ctype concern_type.Type) *adminApiConcern {
+	var name = "unknown"
+	if info, err := cm.Get(id); err == nil {
+		name = info.GetName()
+	}
+	return &adminApiConcern{
+		GroupCode: target.GroupCode(),
+		Target:    target.Key(),
+		Site:      cm.Site(),
+		Id:        id,
+		Name:      name,
+		Type:      ctype.String(),
+	}
+}
+
+// newMessageContext 订阅模块的Add与Remove需要一个 mmsg.IMsgCtx，这里的回复只会记录到日志中
+func (a *AdminApi) newMessageContext(groupCode int64, log *logrus.Entry) *MessageContext {
+	return newLogMessageContext(a.l, mmsg.NewGroupTargetId(groupCode), log)
+}
+
+func (a *AdminApi) writeJson(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		adminApiLogger.Errorf("write response error %v", err)
+	}
+}
+
+func (a *AdminApi) writeError(w http.ResponseWriter, code int, err error) {
+	a.writeJson(w, code, &adminApiError{Error: err.Error()})
+}
+
+// NewAdminApi 创建HTTP管理接口，token不能为空
+func NewAdminApi(l *Lsp, token string) *AdminApi {
+	return &AdminApi{l: l, token: token}
+}
+
+// StartAdminApi 根据配置启动HTTP管理接口，未配置 adminApi.addr 时不启动
+func (l *Lsp) StartAdminApi() {
+	addr := cfg.GetAdminApiAddr()
+	if len(addr) == 0 {
+		return
+	}
+	token := cfg.GetAdminApiToken()
+	if len(token) == 0 {
+		adminApiLogger.Errorf("HTTP管理接口没有配置adminApi.token，为了安全将不会启动")
+		return
+	}
+	registerErrorCollector()
+	l.adminApi = NewAdminApi(l, token)
+	l.adminApi.Start(addr)
+}
@@ -0,0 +1,132 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testAdminApiToken = "test-token"
+
+func adminApiRequest(t *testing.T, h http.Handler, method string, path string, params url.Values, token string) (int, map[string]interface{}, []interface{}) {
+	var req *http.Request
+	if method == http.MethodPost {
+		req = httptest.NewRequest(method, path, strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, path+"?"+params.Encode(), nil)
+	}
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var obj interface{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &obj))
+	switch r := obj.(type) {
+	case map[string]interface{}:
+		return rec.Code, r, nil
+	case []interface{}:
+		return rec.Code, nil, r
+	}
+	return rec.Code, nil, nil
+}
+
+func TestAdminApi(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	localutils.GetBot().TESTAddGroup(test.G1)
+
+	h := NewAdminApi(Instance, testAdminApiToken).Handler()
+	g1 := strconv.FormatInt(test.G1, 10)
+
+	code, _, _ := adminApiRequest(t, h, http.MethodGet, "/api/concern", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _, _ = adminApiRequest(t, h, http.MethodGet, "/api/concern", nil, "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _, list := adminApiRequest(t, h, http.MethodGet, "/api/concern", nil, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, list, 0)
+
+	var watch = url.Values{"group": {g1}, "site": {test.Site1}, "id": {test.NAME1}, "type": {test.T1.String()}}
+	code, obj, _ := adminApiRequest(t, h, http.MethodPost, "/api/concern", watch, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, test.NAME1, obj["id"])
+
+	code, _, _ = adminApiRequest(t, h, http.MethodPost, "/api/concern", watch, testAdminApiToken)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, _, _ = adminApiRequest(t, h, http.MethodPost, "/api/concern",
+		url.Values{"group": {strconv.FormatInt(test.G2, 10)}, "site": {test.Site1}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _, _ = adminApiRequest(t, h, http.MethodPost, "/api/concern",
+		url.Values{"group": {g1}, "site": {"unknown"}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _, list = adminApiRequest(t, h, http.MethodGet, "/api/concern", url.Values{"group": {g1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, list, 1) {
+		item := list[0].(map[string]interface{})
+		assert.EqualValues(t, test.G1, item["group_code"])
+		assert.EqualValues(t, test.Site1, item["site"])
+		assert.EqualValues(t, test.T1.String(), item["type"])
+	}
+
+	code, _, list = adminApiRequest(t, h, http.MethodGet, "/api/concern",
+		url.Values{"group": {strconv.FormatInt(test.G2, 10)}}, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, list, 0)
+
+	code, obj, _ = adminApiRequest(t, h, http.MethodGet, "/api/concern/config",
+		url.Values{"group": {g1}, "site": {test.Site1}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, obj, "group_concern_at")
+	assert.Contains(t, obj, "group_concern_notify")
+	assert.Contains(t, obj, "group_concern_filter")
+
+	code, _, _ = adminApiRequest(t, h, http.MethodGet, "/api/concern/config",
+		url.Values{"group": {g1}, "site": {test.Site1}, "id": {test.NAME2}}, testAdminApiToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, obj, _ = adminApiRequest(t, h, http.MethodGet, "/api/concern/state",
+		url.Values{"site": {test.Site1}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, test.NAME1, obj["name"])
+	assert.Len(t, obj["groups"], 1)
+
+	code, _, _ = adminApiRequest(t, h, http.MethodPost, "/api/concern/state",
+		url.Values{"site": {test.Site1}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	// 测试用的订阅没有使用EmitQueue
+	code, _, _ = adminApiRequest(t, h, http.MethodPost, "/api/concern/fresh",
+		url.Values{"site": {test.Site1}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusNotImplemented, code)
+
+	code, _, _ = adminApiRequest(t, h, http.MethodDelete, "/api/concern", watch, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _, _ = adminApiRequest(t, h, http.MethodDelete, "/api/concern", watch, testAdminApiToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _, _ = adminApiRequest(t, h, http.MethodGet, "/api/concern/state",
+		url.Values{"site": {test.Site1}, "id": {test.NAME1}}, testAdminApiToken)
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,90 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"strings"
+	"time"
+)
+
+// 一次最多查看的审计日志条数
+const maxAuditLogLimit = 50
+
+// AuditLog 一条修改了bot状态的命令记录，例如订阅、取消订阅、修改配置、授予权限
+type AuditLog struct {
+	Time int64 `json:"time"`
+	// Operator 执行命令的QQ号
+	Operator int64 `json:"operator"`
+	// GroupCode 命令操作的QQ群，私聊修改全局状态时为0
+	GroupCode int64  `json:"group_code,omitempty"`
+	Command   string `json:"command"`
+}
+
+// AddAuditLog 追加一条审计日志，超过 cfg.GetAuditLogRetention 的记录会被自动删除
+func (s *StateManager) AddAuditLog(item *AuditLog) error {
+	if item.Time == 0 {
+		item.Time = time.Now().Unix()
+	}
+	return s.SetJson(s.AuditLogKey(time.Now().UnixNano()), item, localdb.SetExpireOpt(cfg.GetAuditLogRetention()))
+}
+
+// ListAuditLog 按时间从新到旧列出至多limit条审计日志
+func (s *StateManager) ListAuditLog(limit int) (result []*AuditLog, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.DescendKeys(s.AuditLogKey("*"), func(key, value string) bool {
+			if len(result) >= limit {
+				return false
+			}
+			var item = new(AuditLog)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			result = append(result, item)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// audit 命令执行成功后记录审计日志，记录失败不影响命令本身
+func (c *MessageContext) audit(groupCode int64) {
+	if c.Lsp == nil || c.Lsp.LspStateManager == nil || c.Sender == nil || len(c.Command) == 0 {
+		return
+	}
+	err := c.Lsp.LspStateManager.AddAuditLog(&AuditLog{
+		Operator:  c.Sender.Uin,
+		GroupCode: groupCode,
+		Command:   c.Command,
+	})
+	if err != nil {
+		c.GetLog().Errorf("AddAuditLog error %v", err)
+	}
+}
+
+func formatAuditLog(logs []*AuditLog) string {
+	if len(logs) == 0 {
+		return "暂无记录"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("最近%v条操作记录：", len(logs)))
+	for _, item := range logs {
+		sb.WriteString("\n")
+		sb.WriteString(time.Unix(item.Time, 0).Format("2006-01-02 15:04:05"))
+		sb.WriteString(fmt.Sprintf(" %v", item.Operator))
+		if item.GroupCode != 0 {
+			sb.WriteString(fmt.Sprintf(" 群%v", item.GroupCode))
+		}
+		sb.WriteString(" ")
+		sb.WriteString(item.Command)
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateManager_AuditLog(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+
+	logs, err := sm.ListAuditLog(10)
+	assert.Nil(t, err)
+	assert.Empty(t, logs)
+	assert.Equal(t, "暂无记录", formatAuditLog(logs))
+
+	assert.Nil(t, sm.AddAuditLog(&AuditLog{Time: test.TIMESTAMP1, Operator: test.UID1, GroupCode: test.G1, Command: "/watch 1"}))
+	assert.Nil(t, sm.AddAuditLog(&AuditLog{Time: test.TIMESTAMP2, Operator: test.UID2, Command: "/grant -r admin 2"}))
+	assert.Nil(t, sm.AddAuditLog(&AuditLog{Operator: test.UID1, GroupCode: test.G2, Command: "/unwatch 1"}))
+
+	logs, err = sm.ListAuditLog(10)
+	assert.Nil(t, err)
+	if assert.Len(t, logs, 3) {
+		assert.Equal(t, "/unwatch 1", logs[0].Command)
+		assert.NotZero(t, logs[0].Time)
+		assert.Equal(t, "/grant -r admin 2", logs[1].Command)
+		assert.EqualValues(t, test.UID2, logs[1].Operator)
+		assert.Equal(t, "/watch 1", logs[2].Command)
+		assert.EqualValues(t, test.G1, logs[2].GroupCode)
+	}
+
+	logs, err = sm.ListAuditLog(2)
+	assert.Nil(t, err)
+	assert.Len(t, logs, 2)
+
+	s := formatAuditLog(logs)
+	assert.Contains(t, s, "最近2条操作记录")
+	assert.Contains(t, s, "群")
+	assert.Contains(t, s, "/grant -r admin 2")
+}
+
+func TestMessageContext_audit(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	ctx.Command = "/watch -s " + test.Site1 + " " + test.NAME1
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	// 没有权限，失败的命令不记录
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	<-msgChan
+	logs, err := Instance.LspStateManager.ListAuditLog(10)
+	assert.Nil(t, err)
+	assert.Empty(t, logs)
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	<-msgChan
+	ctx.Command = "/config title_notify -s " + test.Site1 + " " + test.NAME1 + " on"
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
+	<-msgChan
+	// 重复配置失败，不记录
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
+	<-msgChan
+
+	logs, err = Instance.LspStateManager.ListAuditLog(10)
+	assert.Nil(t, err)
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, ctx.Command, logs[0].Command)
+		assert.EqualValues(t, test.Sender1.Uin, logs[0].Operator)
+		assert.EqualValues(t, test.G1, logs[0].GroupCode)
+		assert.Contains(t, logs[1].Command, "/watch")
+	}
+}
@@ -0,0 +1,45 @@
+package lsp
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/sirupsen/logrus"
+)
+
+var backupLog = logrus.WithField("module", "backup")
+
+type backupJob struct{}
+
+func (backupJob) Run() {
+	_, _ = doBackup()
+}
+
+// doBackup 备份数据库到 backup.dir 目录，并删除超过 backup.keep 数量的旧备份
+func doBackup() (string, error) {
+	var dir = cfg.GetBackupDir()
+	path, err := localdb.Backup(dir)
+	if err != nil {
+		backupLog.WithField("dir", dir).Errorf("备份数据库失败：%v", err)
+		return "", err
+	}
+	backupLog.WithField("path", path).Info("备份数据库成功")
+	removed, err := localdb.CleanBackup(dir, cfg.GetBackupKeep())
+	if err != nil {
+		backupLog.WithField("dir", dir).Errorf("删除旧的备份失败：%v", err)
+	}
+	for _, p := range removed {
+		backupLog.WithField("path", p).Debug("已删除旧的备份")
+	}
+	return path, nil
+}
+
+// backupReload 根据 backup.cron 添加自动备份的定时任务，需要在清空定时任务后调用
+func (l *Lsp) backupReload() {
+	var spec = cfg.GetBackupCron()
+	if spec == "" {
+		return
+	}
+	if _, err := l.cron.AddJob(spec, backupJob{}); err != nil {
+		backupLog.WithField("cron_exp", spec).Errorf("添加自动备份定时任务失败：%v", err)
+	}
+}
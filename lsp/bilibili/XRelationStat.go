@@ -30,6 +30,7 @@ func XRelationStat(mid int64) (*XRelationStatResponse, error) {
 	}
 	var opts = []requests.Option{
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.TimeoutOption(time.Second * 15),
 		AddUAOption(),
 		delete412ProxyOption,
@@ -57,6 +57,7 @@ func XSpaceAccInfo(mid int64) (*XSpaceAccInfoResponse, error) {
 	signWbi(params)
 	var opts = []requests.Option{
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.TimeoutOption(time.Second * 15),
 		AddUAOption(),
 		requests.HeaderOption("accept", "application/json"),
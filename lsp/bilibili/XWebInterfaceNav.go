@@ -84,6 +84,7 @@ func XWebInterfaceNav(login bool) (*WebInterfaceNavResponse, error) {
 	path := BPath(PathXWebInterfaceNav)
 	var opts = []requests.Option{
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.TimeoutOption(time.Second * 15),
 		AddUAOption(),
 		delete412ProxyOption,
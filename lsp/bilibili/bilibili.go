@@ -3,6 +3,7 @@ package bilibili
 import (
 	"errors"
 	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/proxy_pool"
 	"github.com/Sora233/DDBOT/requests"
 	"github.com/Sora233/MiraiGo-Template/config"
@@ -42,6 +43,17 @@ var BasePath = map[string]string{
 	PathXRelationStat:            BaseHost,
 	PathXWebInterfaceNav:         BaseHost,
 	PathDynamicSrvDynamicHistory: BaseVCHost,
+	PathGetDanmuInfo:             BaseLiveHost,
+	PathRoomPlayUrl:              BaseLiveHost,
+	PathRoomGetStatusInfoByUids:  BaseLiveHost,
+	PathPassportQRCodeGenerate:   PassportHost,
+	PathPassportQRCodePoll:       PassportHost,
+	PathPassportCookieInfo:       PassportHost,
+	PathPassportCookieRefresh:    PassportHost,
+	PathPassportConfirmRefresh:   PassportHost,
+	PathGuardTopList:             BaseLiveHost,
+	PathFansMembersRank:          BaseLiveHost,
+	PathXWebInterfaceSearchType:  BaseHost,
 }
 
 type VerifyInfo struct {
@@ -81,11 +93,29 @@ var (
 	}
 )
 
+// isRiskControlCode 判断b站接口返回的错误码是否为风控或者请求过于频繁
+func isRiskControlCode(code int32) bool {
+	return code == -412 || code == 412 || code == -352 || code == -509
+}
+
+// codeError 把b站接口返回的错误码转换成error，风控相关的错误码会包装 concern.ErrRateLimited
+func codeError(api string, code int32, msg string) error {
+	if isRiskControlCode(code) {
+		return fmt.Errorf("%v failed %v - %v: %w", api, code, msg, concern.ErrRateLimited)
+	}
+	return fmt.Errorf("%v failed %v - %v", api, code, msg)
+}
+
 func Init() {
 	var (
 		SESSDATA = config.GlobalConfig.GetString("bilibili.SESSDATA")
 		biliJct  = config.GlobalConfig.GetString("bilibili.bili_jct")
 	)
+	if info, err := GetQRCodeLoginInfo(); err == nil {
+		// 扫码登陆的cookie会自动刷新，优先于配置中的cookie使用
+		SESSDATA, biliJct, _ = parseCookieInfo(info.GetCookieInfo())
+		logger.Info("使用扫码登陆的b站cookie")
+	}
 	if len(SESSDATA) != 0 && len(biliJct) != 0 {
 		SetVerify(SESSDATA, biliJct)
 		FreshSelfInfo()
@@ -158,31 +188,14 @@ func GetVerifyInfo() *VerifyInfo {
 		logger.Trace("GetVerifyInfo error - 未设置cookie和帐号")
 		return nil
 	} else {
-		var (
-			SESSDATA string
-			biliJct  string
-			expire   int64 = -1
-			ok       bool
-		)
+		var ok bool
 		logger.Debug("GetVerifyInfo 使用帐号刷新cookie")
 		cookieInfo, err := freshAccountCookieInfo()
 		if err != nil {
 			logger.Errorf("b站登陆失败，请手动指定cookie配置 - freshAccountCookieInfo error %v", err)
 		} else {
 			logger.Debug("b站登陆成功 - freshAccountCookieInfo ok")
-			for _, cookie := range cookieInfo.GetCookies() {
-				if expire == -1 || expire > cookie.GetExpires() {
-					expire = cookie.GetExpires()
-				}
-				if cookie.GetName() == "SESSDATA" {
-					SESSDATA = cookie.GetValue()
-					logger.Debug("使用cookieInfo设置 SESSDATA")
-				}
-				if cookie.GetName() == "bili_jct" {
-					biliJct = cookie.GetValue()
-					logger.Debug("使用cookieInfo设置 bili_jct")
-				}
-			}
+			SESSDATA, biliJct, expire := parseCookieInfo(cookieInfo)
 			if len(SESSDATA) == 0 || len(biliJct) == 0 {
 				logger.Errorf("b站登陆成功，但是设置cookie失败，如果发现这个问题，请反馈给开发者。")
 			} else {
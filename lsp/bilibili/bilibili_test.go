@@ -1,6 +1,8 @@
 package bilibili
 
 import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -58,3 +60,24 @@ func TestSetVerify(t *testing.T) {
 	SetVerify("wrong", "wrong")
 	assert.EqualValues(t, "wrong", GetVerifyBiliJct())
 }
+
+func TestCodeError(t *testing.T) {
+	assert.True(t, concern.IsRateLimited(codeError("test", -412, "请求被拦截")))
+	assert.True(t, concern.IsRateLimited(codeError("test", -352, "")))
+	assert.False(t, concern.IsRateLimited(codeError("test", -400, "")))
+	assert.EqualError(t, codeError("test", -400, "msg"), "test failed -400 - msg")
+}
+
+func TestConcern_Diagnose(t *testing.T) {
+	defer atomicVerifyInfo.Store(new(VerifyInfo))
+	defer accountUid.Store(0)
+
+	c := new(Concern)
+	assert.Contains(t, c.Diagnose()[0], "未配置")
+
+	SetVerify("wrong", "wrong")
+	assert.Contains(t, c.Diagnose()[0], "cookie无效")
+
+	accountUid.Store(test.UID1)
+	assert.Contains(t, c.Diagnose()[0], "已登录")
+}
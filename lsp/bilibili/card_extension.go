@@ -2,6 +2,8 @@ package bilibili
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 var ErrCardTypeMismatch = errors.New("card type mismatch")
@@ -33,6 +35,63 @@ func (m *Card) GetCardWithVideo() (*CardWithVideo, error) {
 	return nil, ErrCardTypeMismatch
 }
 
+// VideoExtra 视频动态中 CardWithVideo 没有包含的字段
+type VideoExtra struct {
+	Dimension struct {
+		Width  int64 `json:"width"`
+		Height int64 `json:"height"`
+		// Rotate 为1时表示宽高需要交换
+		Rotate int64 `json:"rotate"`
+	} `json:"dimension"`
+	FirstFrame string `json:"first_frame"`
+}
+
+// Resolution 返回视频的分辨率，例如 1920x1080，没有分辨率信息时返回空字符串
+func (v *VideoExtra) Resolution() string {
+	if v == nil || v.Dimension.Width <= 0 || v.Dimension.Height <= 0 {
+		return ""
+	}
+	if v.Dimension.Rotate == 1 {
+		return fmt.Sprintf("%vx%v", v.Dimension.Height, v.Dimension.Width)
+	}
+	return fmt.Sprintf("%vx%v", v.Dimension.Width, v.Dimension.Height)
+}
+
+func (m *Card) GetVideoExtra() (*VideoExtra, error) {
+	if m.GetDesc().GetType() == DynamicDescType_WithVideo {
+		var extra = new(VideoExtra)
+		err := json.Unmarshal([]byte(m.GetCard()), extra)
+		return extra, err
+	}
+	return nil, ErrCardTypeMismatch
+}
+
+// formatVideoDuration 把视频时长格式化为 mm:ss ，超过一小时时为 h:mm:ss
+func formatVideoDuration(seconds int32) string {
+	if seconds <= 0 {
+		return ""
+	}
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// videoDetail 返回视频的时长、分区和分辨率，例如 "时长：03:20 分区：单机游戏 分辨率：1920x1080"
+func videoDetail(cardVideo *CardWithVideo, extra *VideoExtra) string {
+	var parts []string
+	if d := formatVideoDuration(cardVideo.GetDuration()); d != "" {
+		parts = append(parts, "时长："+d)
+	}
+	if len(cardVideo.GetTname()) > 0 {
+		parts = append(parts, "分区："+cardVideo.GetTname())
+	}
+	if r := extra.Resolution(); r != "" {
+		parts = append(parts, "分辨率："+r)
+	}
+	return strings.Join(parts, " ")
+}
+
 func (m *Card) GetCardTextOnly() (*CardTextOnly, error) {
 	if m.GetDesc().GetType() == DynamicDescType_TextOnly {
 		var card = new(CardTextOnly)
@@ -36,3 +36,28 @@ func TestCard_GetCard(t *testing.T) {
 	_, err = getCard(DynamicDescType_WithCourse).GetCardWithCourse()
 	assert.Nil(t, err)
 }
+
+func TestCard_GetVideoExtra(t *testing.T) {
+	_, err := getCard(DynamicDescType_WithImage).GetVideoExtra()
+	assert.Equal(t, ErrCardTypeMismatch, err)
+
+	card := getCard(DynamicDescType_WithVideo)
+	card.Card = `{"duration":200,"tname":"单机游戏","dimension":{"width":1080,"height":1920,"rotate":1}}`
+	extra, err := card.GetVideoExtra()
+	assert.Nil(t, err)
+	assert.Equal(t, "1920x1080", extra.Resolution())
+	extra.Dimension.Rotate = 0
+	assert.Equal(t, "1080x1920", extra.Resolution())
+
+	cardVideo, err := card.GetCardWithVideo()
+	assert.Nil(t, err)
+	assert.Equal(t, "时长：03:20 分区：单机游戏 分辨率：1080x1920", videoDetail(cardVideo, extra))
+	assert.Equal(t, "", videoDetail(&CardWithVideo{}, nil))
+}
+
+func TestFormatVideoDuration(t *testing.T) {
+	assert.Equal(t, "", formatVideoDuration(0))
+	assert.Equal(t, "00:59", formatVideoDuration(59))
+	assert.Equal(t, "59:59", formatVideoDuration(3599))
+	assert.Equal(t, "1:00:01", formatVideoDuration(3601))
+}
@@ -7,12 +7,13 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/recorder"
+	"github.com/Sora233/DDBOT/requests"
 	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/DDBOT/utils/expirable"
-	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/Sora233/MiraiGo-Template/utils"
-	"github.com/tidwall/buntdb"
 	"go.uber.org/atomic"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -23,16 +24,31 @@ var logger = utils.GetModuleLogger("bilibili-concern")
 const (
 	Live concern_type.Type = "live"
 	News concern_type.Type = "news"
+	// Guard 舰长数和粉丝团人数达成里程碑时推送，需要订阅时指定
+	Guard concern_type.Type = "guard"
+	// Video 只推送视频投稿，同时订阅了动态的群只会收到一次推送
+	Video concern_type.Type = "video"
 )
 
 type Concern struct {
 	*StateManager
 	attentionListExpirable *expirable.Expirable
 	unsafeStart            atomic.Bool
-	notify                 chan<- concern.Notify
-	stop                   chan interface{}
-	wg                     sync.WaitGroup
-	cacheStartTs           int64
+	// batchLive 为true时直播状态由 batchLiveFresher 批量刷新，EmitQueue只刷新动态
+	batchLive    bool
+	notify       chan<- concern.Notify
+	stop         chan interface{}
+	wg           sync.WaitGroup
+	cacheStartTs int64
+	danmakuRelay *danmakuRelay
+	// unsubscribeRecord 取消录制在事件总线中的订阅
+	unsubscribeRecord func()
+	// unsubscribeReserve 取消直播预约在事件总线中的订阅
+	unsubscribeReserve func()
+	// unsubscribeStats 取消直播记录在事件总线中的订阅
+	unsubscribeStats func()
+	// liveOnline 最近一次刷新到的直播人气，mid -> int64
+	liveOnline sync.Map
 }
 
 func (c *Concern) Site() string {
@@ -40,7 +56,7 @@ func (c *Concern) Site() string {
 }
 
 func (c *Concern) Types() []concern_type.Type {
-	return []concern_type.Type{Live, News}
+	return []concern_type.Type{Live, News, Guard, Video}
 }
 
 func (c *Concern) ParseId(s string) (interface{}, error) {
@@ -74,6 +90,13 @@ func NewConcern(notify chan<- concern.Notify) *Concern {
 		}),
 	}
 	c.StateManager = NewStateManager(c)
+	c.danmakuRelay = newDanmakuRelay(notify, func(target mmsg.TargetId, mid int64) bool {
+		return c.CheckGroupConcern(target, mid, Live) == concern.ErrAlreadyExists &&
+			c.GetGroupConcernConfig(target, mid).GetGroupConcernNotify().CheckDanmakuRelay()
+	}, func(target mmsg.TargetId, mid int64) bool {
+		return c.CheckGroupConcern(target, mid, Live) == concern.ErrAlreadyExists &&
+			c.GetGroupConcernConfig(target, mid).GetGroupConcernNotify().CheckDanmakuAlert()
+	})
 	return c
 }
 
@@ -82,6 +105,16 @@ func (c *Concern) Stop() {
 	if c.stop != nil {
 		close(c.stop)
 	}
+	c.danmakuRelay.Stop()
+	if c.unsubscribeRecord != nil {
+		c.unsubscribeRecord()
+	}
+	if c.unsubscribeReserve != nil {
+		c.unsubscribeReserve()
+	}
+	if c.unsubscribeStats != nil {
+		c.unsubscribeStats()
+	}
 	logger.Trace("正在停止bilibili StateManager")
 	c.StateManager.Stop()
 	logger.Trace("bilibili StateManager已停止")
@@ -90,6 +123,12 @@ func (c *Concern) Stop() {
 }
 
 func (c *Concern) Start() error {
+	select {
+	case <-c.stop:
+		// 停止后重新启动
+		c.stop = make(chan interface{})
+	default:
+	}
 	Init()
 	lastFresh, _ := c.GetLastFreshTime()
 	if lastFresh > 0 && time.Now().Sub(time.Unix(lastFresh, 0)) > time.Minute*30 {
@@ -100,12 +139,31 @@ func (c *Concern) Start() error {
 		})
 	}
 	c.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.unsubscribeRecord = concern.Subscribe(Site+".record", c.onRecordEvent,
+		concern.TopicLiveStart, concern.TopicLiveTitleChange, concern.TopicLiveStop)
+	c.unsubscribeReserve = concern.Subscribe(Site+".reserve", c.onReserveEvent, concern.TopicLiveStart)
+	c.unsubscribeStats = concern.Subscribe(Site+".stats", c.onStatsEvent, concern.TopicLiveStart, concern.TopicLiveStop)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		tick := time.NewTicker(time.Hour)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				checkCookieRefresh()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
 	if !IsVerifyGiven() {
-		logger.Warnf("未设置B站账户，将使用慢速模式，推荐订阅数量不超过5个，否则推送将出现较长延迟，如需更多订阅，推荐您配置使用B站账号，最高可支持2000订阅。")
+		logger.Warnf("未设置B站账户，将使用慢速模式，直播状态使用批量接口刷新，动态需要逐个刷新，推荐动态订阅数量不超过5个，否则推送将出现较长延迟，如需更多订阅，推荐您配置使用B站账号，最高可支持2000订阅。")
 		c.UseEmitQueue()
-		c.UseFreshFunc(c.emitQueueFresher())
+		c.batchLive = true
+		c.UseFreshFunc(c.withStatsFresher(c.withReserveFresher(c.withGuardFresher(c.slowModeFresher()))))
 	} else {
-		c.UseFreshFunc(c.fresh())
+		c.UseFreshFunc(c.withStatsFresher(c.withReserveFresher(c.withGuardFresher(c.fresh()))))
 		go func() {
 			c.wg.Add(1)
 			defer c.wg.Done()
@@ -125,14 +183,14 @@ func (c *Concern) Start() error {
 }
 
 func (c *Concern) Add(ctx mmsg.IMsgCtx,
-	groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	mid := _id.(int64)
 	selfUid := accountUid.Load()
 	var watchSelf = selfUid != 0 && selfUid == mid
 	var err error
-	log := logger.WithFields(localutils.GroupLogFields(groupCode)).WithField("mid", mid)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("mid", mid)
 
-	err = c.StateManager.CheckGroupConcern(groupCode, mid, ctype)
+	err = c.StateManager.CheckGroupConcern(target, mid, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +277,7 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx,
 		}
 	}
 
-	_, err = c.StateManager.AddGroupConcern(groupCode, mid, ctype)
+	_, err = c.StateManager.AddGroupConcern(target, mid, ctype)
 	if err != nil {
 		log.Errorf("AddGroupConcern error %v", err)
 		return nil, fmt.Errorf("关注用户失败 - 内部错误")
@@ -233,7 +291,7 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx,
 		// 其他群关注了同一uid，并且推送过Living，那么给新watch的群也推一份
 		if liveInfo != nil && liveInfo.Living() {
 			if ctx.GetTarget().TargetType().IsGroup() {
-				defer c.GroupWatchNotify(groupCode, mid)
+				defer c.GroupWatchNotify(target, mid)
 			}
 			if ctx.GetTarget().TargetType().IsPrivate() {
 				defer ctx.Send(mmsg.NewText("检测到该用户正在直播，但由于您目前处于私聊模式，" +
@@ -253,14 +311,14 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx,
 }
 
 func (c *Concern) Remove(ctx mmsg.IMsgCtx,
-	groupCode int64, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	mid := id.(int64)
 	var identityInfo concern.IdentityInfo
 	var allCtype concern_type.Type
-	err := c.StateManager.RWCoverTx(func(tx *buntdb.Tx) error {
+	err := c.StateManager.RWCoverTx(func(tx localdb.Tx) error {
 		var err error
 		identityInfo, _ = c.Get(mid)
-		_, err = c.StateManager.RemoveGroupConcern(groupCode, mid, ctype)
+		_, err = c.StateManager.RemoveGroupConcern(target, mid, ctype)
 		if err != nil {
 			return err
 		}
@@ -272,12 +330,23 @@ func (c *Concern) Remove(ctx mmsg.IMsgCtx,
 		// 如果此时liveinfo是living状态，则此状态会一直保留，下次watch时会以为在living错误推送
 		if !allCtype.ContainAll(Live) {
 			err = c.StateManager.DeleteLiveInfo(mid)
-			if err == buntdb.ErrNotFound {
+			if err == localdb.ErrNotFound {
 				err = nil
 			}
 			if err != nil {
 				return err
 			}
+			if err = c.StateManager.DeleteReserve(mid); err != nil {
+				return err
+			}
+			// 不再刷新直播状态，结束还在记录的直播
+			if err = c.StateManager.EndLiveSession(mid, time.Now()); err != nil {
+				return err
+			}
+		}
+		// 下次订阅时重新记录快照，避免把取消订阅期间的变化当作达成里程碑
+		if !allCtype.ContainAll(Guard) {
+			return c.StateManager.DeleteGuardStat(mid)
 		}
 		return nil
 	})
@@ -294,22 +363,78 @@ func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
 	return c.FindUser(id.(int64), false)
 }
 
+// Search 实现 concern.SearchExt ，使用b站的用户搜索
+func (c *Concern) Search(keyword string, n int) ([]*concern.SearchResult, error) {
+	resp, err := SearchUser(keyword)
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetCode() != 0 {
+		return nil, codeError("SearchUser", resp.GetCode(), resp.Message)
+	}
+	var result []*concern.SearchResult
+	for _, user := range resp.Data.Result {
+		if len(result) >= n {
+			break
+		}
+		result = append(result, &concern.SearchResult{
+			Id:        user.Mid,
+			Name:      cleanSearchHighlight(user.Uname),
+			Followers: user.Fans,
+		})
+	}
+	return result, nil
+}
+
+// Diagnose 实现 concern.DiagnoseExt ，显示b站账号的登录状态
+func (c *Concern) Diagnose() []string {
+	if !IsVerifyGiven() {
+		return []string{"b站账号：未配置cookie或账号，部分功能无法使用"}
+	}
+	if uid := accountUid.Load(); uid != 0 {
+		return []string{fmt.Sprintf("b站账号：已登录 UID:%v", uid)}
+	}
+	return []string{"b站账号：cookie无效或者登录失败"}
+}
+
 func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, ievent concern.Event) (result []concern.Notify) {
+	return func(target mmsg.TargetId, ievent concern.Event) (result []concern.Notify) {
 		log := ievent.Logger()
 		switch event := ievent.(type) {
 		case *LiveInfo:
 			if event.Status == LiveStatus_Living {
-				log.WithFields(localutils.GroupLogFields(groupCode)).Trace("living notify")
+				log.WithFields(mmsg.TargetLogFields(target)).Trace("living notify")
 			} else if event.Status == LiveStatus_NoLiving {
-				log.WithFields(localutils.GroupLogFields(groupCode)).Trace("noliving notify")
+				log.WithFields(mmsg.TargetLogFields(target)).Trace("noliving notify")
 			} else {
-				log.WithFields(localutils.GroupLogFields(groupCode)).Error("unknown live status")
+				log.WithFields(mmsg.TargetLogFields(target)).Error("unknown live status")
+			}
+			c.checkDanmakuRelay(target, event)
+			result = append(result, NewConcernLiveNotify(target, event))
+		case *GuardInfo:
+			log.WithFields(mmsg.TargetLogFields(target)).Trace("guard notify")
+			result = append(result, NewConcernGuardNotify(target, event))
+		case *ReserveInfo:
+			// 订阅了动态的群已经在动态推送中看到了预约，只推送开播前的提醒
+			if !event.Remind && c.CheckGroupConcern(target, event.Mid, News) == concern.ErrAlreadyExists {
+				return
+			}
+			log.WithFields(mmsg.TargetLogFields(target)).Trace("reserve notify")
+			result = append(result, NewConcernReserveNotify(target, event))
+		case *VideoInfo:
+			// 订阅了动态的群已经通过 NewsInfo 推送过了
+			if c.CheckGroupConcern(target, event.Mid, News) == concern.ErrAlreadyExists {
+				return
+			}
+			notifies := NewConcernVideoNotify(target, event, c)
+			log.WithFields(mmsg.TargetLogFields(target)).
+				WithField("Size", len(notifies)).Trace("video notify")
+			for _, notify := range notifies {
+				result = append(result, notify)
 			}
-			result = append(result, NewConcernLiveNotify(groupCode, event))
 		case *NewsInfo:
-			notifies := NewConcernNewsNotify(groupCode, event, c)
-			log.WithFields(localutils.GroupLogFields(groupCode)).
+			notifies := NewConcernNewsNotify(target, event, c)
+			log.WithFields(mmsg.TargetLogFields(target)).
 				WithField("Size", len(notifies)).Trace("news notify")
 			for _, notify := range notifies {
 				result = append(result, notify)
@@ -319,6 +444,61 @@ func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
 	}
 }
 
+// checkDanmakuRelay 开播时按照群配置开始转发弹幕和提醒弹幕，下播时停止
+func (c *Concern) checkDanmakuRelay(target mmsg.TargetId, liveInfo *LiveInfo) {
+	if liveInfo.Status != LiveStatus_Living {
+		c.danmakuRelay.StopRoom(liveInfo.RoomId)
+		return
+	}
+	notifyConfig := c.GetGroupConcernConfig(target, liveInfo.Mid).GetGroupConcernNotify()
+	if notifyConfig.CheckDanmakuRelay() {
+		c.danmakuRelay.Join(target, &liveInfo.UserInfo, *notifyConfig.DanmakuRelay)
+	} else {
+		c.danmakuRelay.Leave(target, liveInfo.RoomId)
+	}
+	if notifyConfig.CheckDanmakuAlert() {
+		c.danmakuRelay.JoinAlert(target, &liveInfo.UserInfo, *notifyConfig.DanmakuAlert)
+	} else {
+		c.danmakuRelay.LeaveAlert(target, liveInfo.RoomId)
+	}
+}
+
+// onRecordEvent 是录制在事件总线中的订阅者，开播时如果有群开启了录制则开始录制，下播时停止
+// 同一个直播间只会录制一份，关闭录制配置不会停止正在进行的录制
+func (c *Concern) onRecordEvent(e *concern.BusEvent) {
+	liveInfo, ok := e.Event.(*LiveInfo)
+	if !ok {
+		return
+	}
+	if liveInfo.Status != LiveStatus_Living {
+		recorder.Stop(Site, strconv.FormatInt(liveInfo.Mid, 10))
+		return
+	}
+	var record bool
+	for _, target := range e.Targets {
+		if c.GetGroupConcernConfig(target, liveInfo.Mid).GetGroupConcernNotify().CheckRecord() {
+			record = true
+			break
+		}
+	}
+	if !record {
+		return
+	}
+	roomId := liveInfo.RoomId
+	recorder.Start(&recorder.Task{
+		Site: Site,
+		Id:   strconv.FormatInt(liveInfo.Mid, 10),
+		Name: liveInfo.GetName(),
+		StreamUrl: func() (string, error) {
+			return GetStreamUrl(roomId)
+		},
+		Header: map[string]string{
+			"Referer":    "https://live.bilibili.com/",
+			"User-Agent": requests.RandomUA(requests.Computer),
+		},
+	})
+}
+
 func (c *Concern) FindUser(mid int64, load bool) (*UserInfo, error) {
 	if load {
 		resp, err := XSpaceAccInfo(mid)
@@ -326,7 +506,7 @@ func (c *Concern) FindUser(mid int64, load bool) (*UserInfo, error) {
 			return nil, err
 		}
 		if resp.Code != 0 {
-			return nil, fmt.Errorf("code:%v %v", resp.Code, resp.Message)
+			return nil, codeError("XSpaceAccInfo", resp.Code, resp.Message)
 		}
 		newUserInfo := NewUserInfo(mid,
 			resp.GetData().GetLiveRoom().GetRoomid(),
@@ -338,6 +518,7 @@ func (c *Concern) FindUser(mid int64, load bool) (*UserInfo, error) {
 			resp.GetData().GetLiveRoom().GetCover(),
 			resp.GetData().GetLiveRoom().GetLiveStatus(),
 		)
+		newLiveInfo.UserCover = resp.GetData().GetLiveRoom().GetCover()
 		// AddLiveInfo 会顺便添加UserInfo
 		err = c.StateManager.AddLiveInfo(newLiveInfo)
 		if err != nil {
@@ -407,7 +588,7 @@ func (c *Concern) SyncSub() {
 	}
 	var midSet = make(map[int64]bool)
 	var attentionMidSet = make(map[int64]bool)
-	_, _, _, err = c.StateManager.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+	_, _, _, err = c.StateManager.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 		midSet[id.(int64)] = true
 		return true
 	})
@@ -421,11 +602,11 @@ func (c *Concern) SyncSub() {
 	}
 
 	var disableSub = false
-	if config.GlobalConfig.GetBool("bilibili.disableSub") {
+	if cfg.GetBilibiliDisableSub() {
 		disableSub = true
 	}
 	var actType = ActSub
-	if config.GlobalConfig.GetBool("bilibili.hiddenSub") {
+	if cfg.GetBilibiliHiddenSub() {
 		actType = ActHiddenSub
 	}
 
@@ -495,7 +676,7 @@ func (c *Concern) FindUserNews(mid int64, load bool) (*NewsInfo, error) {
 			return nil, err
 		}
 		if history.Code != 0 {
-			return nil, fmt.Errorf("code:%v %v", history.Code, history.Message)
+			return nil, codeError("DynamicSrvSpaceHistory", history.Code, history.Message)
 		}
 		newsInfo = NewNewsInfoWithDetail(userInfo, history.GetData().GetCards())
 		_ = c.StateManager.AddNewsInfo(newsInfo)
@@ -506,16 +687,16 @@ func (c *Concern) FindUserNews(mid int64, load bool) (*NewsInfo, error) {
 	return c.StateManager.GetNewsInfo(mid)
 }
 
-func (c *Concern) GroupWatchNotify(groupCode, mid int64) {
+func (c *Concern) GroupWatchNotify(target mmsg.TargetId, mid int64) {
 	liveInfo, _ := c.GetLiveInfo(mid)
 	if liveInfo.Living() {
 		liveInfo.liveStatusChanged = true
-		c.notify <- NewConcernLiveNotify(groupCode, liveInfo)
+		c.notify <- NewConcernLiveNotify(target, liveInfo)
 	}
 }
 
-func (c *Concern) RemoveAllByGroupCode(groupCode int64) ([]string, error) {
-	keys, err := c.StateManager.RemoveAllByGroupCode(groupCode)
+func (c *Concern) RemoveAllByGroupCode(target mmsg.TargetId) ([]string, error) {
+	keys, err := c.StateManager.RemoveAllByGroupCode(target)
 	if cfg.GetBilibiliUnsub() {
 		var changedIdSet = make(map[int64]interface{})
 		if err == nil {
@@ -0,0 +1,171 @@
+package bilibili
+
+import (
+	"context"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/metrics"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+// shardUids 把uid按size分成多批
+func shardUids(uids []int64, size int) [][]int64 {
+	var result [][]int64
+	for len(uids) > size {
+		result = append(result, uids[:size])
+		uids = uids[size:]
+	}
+	if len(uids) > 0 {
+		result = append(result, uids)
+	}
+	return result
+}
+
+// freshLiveByUids 分批查询uid的直播状态，某一批失败时跳过这一批继续查询，
+// 返回所有成功查询到的结果，以及最后一次失败的错误，触发风控时不再查询剩余的批次
+func (c *Concern) freshLiveByUids(uids []int64) (map[int64]*LiveInfo, error) {
+	var result = make(map[int64]*LiveInfo)
+	var lastErr error
+	for _, batch := range shardUids(uids, cfg.GetBilibiliBatchSize()) {
+		resp, err := RoomGetStatusInfoByUids(batch)
+		if err == nil && resp.GetCode() != 0 {
+			err = codeError("RoomGetStatusInfoByUids", resp.GetCode(), resp.Message)
+		}
+		if err != nil {
+			logger.WithField("Size", len(batch)).Errorf("RoomGetStatusInfoByUids error %v", err)
+			lastErr = err
+			if concern.IsRateLimited(err) {
+				break
+			}
+			continue
+		}
+		for _, info := range resp.Data {
+			result[info.Uid] = info.LiveInfo()
+		}
+		// 没有直播间的uid不会出现在结果中，这里当作未直播处理，保证每个成功查询的uid都有结果
+		for _, uid := range batch {
+			if _, found := result[uid]; !found {
+				if userInfo, _ := c.GetUserInfo(uid); userInfo != nil {
+					result[uid] = NewLiveInfo(userInfo, "", "", LiveStatus_NoLiving)
+				}
+			}
+		}
+	}
+	return result, lastErr
+}
+
+// batchLiveFresher 未设置b站账号时，使用批量接口刷新所有订阅了直播的uid，
+// 一次请求可以查询 cfg.GetBilibiliBatchSize 个uid，比逐个查询的请求数少很多
+func (c *Concern) batchLiveFresher(ctx context.Context, eventChan chan<- concern.Event) {
+	t := time.NewTimer(time.Second * 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		interval := c.FreshInterval(cfg.GetBilibiliInterval())
+		if c.InFreshBackoff() {
+			t.Reset(localutils.Jitter(interval, cfg.GetFreshJitter()))
+			continue
+		}
+		start := time.Now()
+		err := c.batchFreshLive(eventChan)
+		metrics.ObserveFresh(Site, start, err)
+		if err == nil {
+			c.SetLastFreshTime(time.Now().Unix())
+		}
+		if backoff := c.FreshBackoff(err); backoff > 0 {
+			t.Reset(backoff)
+		} else {
+			t.Reset(localutils.Jitter(interval, cfg.GetFreshJitter()))
+		}
+	}
+}
+
+func (c *Concern) batchFreshLive(eventChan chan<- concern.Event) error {
+	_, ids, types, err := c.StateManager.ListConcernState(
+		func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+			return p.ContainAny(Live)
+		})
+	if err != nil {
+		logger.Errorf("ListConcernState error %v", err)
+		return err
+	}
+	ids, _, err = c.GroupTypeById(ids, types)
+	if err != nil {
+		logger.Errorf("GroupTypeById error %v", err)
+		return err
+	}
+	var uids []int64
+	for _, id := range ids {
+		uids = append(uids, id.(int64))
+	}
+	liveInfoMap, freshErr := c.freshLiveByUids(uids)
+	for _, uid := range uids {
+		newInfo, found := liveInfoMap[uid]
+		if !found {
+			// 查询失败的uid保持原来的状态，下一轮再刷新
+			continue
+		}
+		oldInfo, _ := c.GetLiveInfo(uid)
+		if oldInfo == nil {
+			// 第一次刷新只推送正在直播的
+			newInfo.liveStatusChanged = newInfo.Living()
+		} else {
+			if oldInfo.Living() != newInfo.Living() {
+				newInfo.liveStatusChanged = true
+			}
+			if newInfo.Living() && oldInfo.LiveTitle != newInfo.LiveTitle {
+				newInfo.liveTitleChanged = true
+			}
+			newInfo.checkLiveChange(oldInfo)
+		}
+		if len(newInfo.Name) == 0 && oldInfo != nil {
+			newInfo.Name = oldInfo.Name
+		}
+		if err := c.AddLiveInfo(newInfo); err != nil {
+			// 如果因为系统原因add失败，会造成重复推送
+			// 按照ddbot的原则，选择不推送，而非重复推送
+			logger.WithField("mid", uid).Errorf("add live info error %v", err)
+			continue
+		}
+		if newInfo.Living() {
+			_ = c.MarkLatestActive(uid, time.Now().Unix())
+			c.liveOnline.Store(uid, newInfo.Online)
+		}
+		if newInfo.liveStatusChanged || newInfo.liveTitleChanged || newInfo.liveCoverChanged || newInfo.liveAreaChanged {
+			logger.WithField("mid", uid).
+				WithField("Living", newInfo.Living()).
+				WithField("Title", newInfo.LiveTitle).
+				Trace("batch fresh live changed")
+			eventChan <- newInfo
+		}
+	}
+	logger.WithField("Size", len(uids)).
+		WithField("Success", len(liveInfoMap)).
+		Tracef("batchFreshLive done")
+	if freshErr != nil {
+		logger.WithField("FailedSize", len(uids)-len(liveInfoMap)).
+			Errorf("部分uid的直播状态刷新失败 %v", freshErr)
+	}
+	return freshErr
+}
+
+// slowModeFresher 未设置b站账号时使用，直播状态使用批量接口刷新，动态仍然通过EmitQueue逐个刷新，
+// 因为批量获取动态的 dynamic_new 接口需要登陆
+func (c *Concern) slowModeFresher() concern.FreshFunc {
+	emitFresher := c.emitQueueFresher()
+	return func(ctx context.Context, eventChan chan<- concern.Event) {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.batchLiveFresher(ctx, eventChan)
+		}()
+		emitFresher(ctx, eventChan)
+	}
+}
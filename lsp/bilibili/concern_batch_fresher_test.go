@@ -0,0 +1,106 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestShardUids(t *testing.T) {
+	assert.Len(t, shardUids(nil, 2), 0)
+	assert.EqualValues(t, [][]int64{{1, 2}, {3, 4}, {5}}, shardUids([]int64{1, 2, 3, 4, 5}, 2))
+	assert.EqualValues(t, [][]int64{{1, 2}}, shardUids([]int64{1, 2}, 2))
+}
+
+func TestRoomStatusInfoResponse(t *testing.T) {
+	var resp = new(RoomStatusInfoResponse)
+	assert.Nil(t, json.Unmarshal([]byte(`{"code":0,"message":"success","data":[]}`), resp))
+	assert.Len(t, resp.Data, 0)
+
+	resp = new(RoomStatusInfoResponse)
+	assert.Nil(t, json.Unmarshal([]byte(`{"code":0,"message":"success","data":{"1":{"uid":1,"room_id":10,"uname":"name","title":"title","live_status":2,"keyframe":"k","area_v2_id":216,"area_v2_name":"我的世界"}}}`), resp))
+	if assert.Len(t, resp.Data, 1) {
+		info := resp.Data["1"].LiveInfo()
+		assert.EqualValues(t, 1, info.Mid)
+		assert.EqualValues(t, 10, info.RoomId)
+		assert.Equal(t, "name", info.Name)
+		assert.Equal(t, "k", info.Cover)
+		assert.Empty(t, info.UserCover)
+		assert.EqualValues(t, 216, info.AreaId)
+		assert.Equal(t, "我的世界", info.AreaName)
+		// 轮播视为未直播
+		assert.False(t, info.Living())
+	}
+}
+
+func TestConcern_batchFreshLive(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var requestCount int
+	var fail bool
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		var req struct {
+			Uids []int64 `json:"uids"`
+		}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		if fail && requestCount%2 == 0 {
+			w.Write([]byte(`{"code":-400,"message":"error"}`))
+			return
+		}
+		var data = make(map[string]*RoomStatusInfo)
+		for _, uid := range req.Uids {
+			data[strconv.FormatInt(uid, 10)] = &RoomStatusInfo{
+				Uid:        uid,
+				RoomId:     uid * 10,
+				Uname:      "name",
+				Title:      "title",
+				LiveStatus: int32(LiveStatus_Living),
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}))
+	defer s.Close()
+	oldPath := BasePath[PathRoomGetStatusInfoByUids]
+	BasePath[PathRoomGetStatusInfoByUids] = s.URL
+	defer func() { BasePath[PathRoomGetStatusInfoByUids] = oldPath }()
+
+	c := initConcern(t)
+	defer c.Stop()
+
+	var uids []int64
+	for i := int64(1); i <= 120; i++ {
+		uids = append(uids, i)
+		_, err := c.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), i, Live)
+		assert.Nil(t, err)
+	}
+
+	eventChan := make(chan concern.Event, 200)
+	assert.Nil(t, c.batchFreshLive(eventChan))
+	// 默认每批50个uid
+	assert.Equal(t, 3, requestCount)
+	assert.Len(t, eventChan, 120)
+	for len(eventChan) > 0 {
+		info := (<-eventChan).(*LiveInfo)
+		assert.True(t, info.Living())
+		assert.True(t, info.liveStatusChanged)
+	}
+
+	// 状态没有变化时不推送
+	assert.Nil(t, c.batchFreshLive(eventChan))
+	assert.Len(t, eventChan, 0)
+
+	// 部分批次失败时返回错误，成功的批次正常处理
+	requestCount = 0
+	fail = true
+	liveInfoMap, err := c.freshLiveByUids(uids)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, requestCount)
+	assert.Len(t, liveInfoMap, 70)
+}
@@ -12,9 +12,12 @@ func (c *Concern) emitQueueFresher() concern.FreshFunc {
 		mid := id.(int64)
 		var result []concern.Event
 		for _, subType := range p.Split() {
-			if subType.ContainAny(Live) {
+			if subType.ContainAny(Live) && !c.batchLive {
 				oldInfo, _ := c.FindUserLiving(mid, false)
 				newInfo, err := c.FindUserLiving(mid, true)
+				if concern.IsRateLimited(err) {
+					return result, err
+				}
 				if err != nil {
 					logger.WithField("mid", mid).Errorf("FindUserLiving error %v", err)
 					continue
@@ -29,27 +32,40 @@ func (c *Concern) emitQueueFresher() concern.FreshFunc {
 					if oldInfo.LiveTitle != newInfo.LiveTitle {
 						newInfo.liveTitleChanged = true
 					}
+					newInfo.checkLiveChange(oldInfo)
 				}
 				if newInfo.Living() {
 					c.MarkLatestActive(mid, time.Now().Unix())
 				}
 				result = append(result, newInfo)
 			}
-			if subType.ContainAny(News) {
-				newsInfo, err := c.FindUserNews(mid, true)
-				if err != nil {
-					logger.WithField("mid", mid).Errorf("FindUserNews error %v", err)
-					continue
-				}
-				var cards []*Card
-				for _, card := range newsInfo.Cards {
-					if c.filterCard(card) {
-						cards = append(cards, card)
-					}
+		}
+		if p.ContainAny(News.Add(Video)) {
+			newsInfo, err := c.FindUserNews(mid, true)
+			if concern.IsRateLimited(err) {
+				return result, err
+			}
+			if err != nil {
+				logger.WithField("mid", mid).Errorf("FindUserNews error %v", err)
+				return result, nil
+			}
+			// filterCard 会标记动态，同时订阅动态和视频时只能处理一次
+			var cards []*Card
+			for _, card := range newsInfo.Cards {
+				if c.filterCard(card) {
+					cards = append(cards, card)
 				}
-				newsInfo.Cards = cards
+			}
+			newsInfo.Cards = cards
+			for _, reserve := range c.saveReserve(newsInfo) {
+				result = append(result, reserve)
+			}
+			if p.ContainAny(News) {
 				result = append(result, newsInfo)
 			}
+			if video := NewVideoInfo(newsInfo); video != nil && p.ContainAny(Video) {
+				result = append(result, video)
+			}
 		}
 		return result, nil
 	})
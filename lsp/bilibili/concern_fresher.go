@@ -2,13 +2,14 @@ package bilibili
 
 import (
 	"context"
-	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
-	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/metrics"
+	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
 	"go.uber.org/atomic"
 	"golang.org/x/sync/errgroup"
 	"strconv"
@@ -20,13 +21,6 @@ import (
 func (c *Concern) fresh() concern.FreshFunc {
 	return func(ctx context.Context, eventChan chan<- concern.Event) {
 		t := time.NewTimer(time.Second * 3)
-		var interval time.Duration
-		if config.GlobalConfig != nil {
-			interval = config.GlobalConfig.GetDuration("bilibili.interval")
-		}
-		if interval == 0 {
-			interval = time.Second * 20
-		}
 		var freshCount atomic.Int32
 		if !cfg.GetBilibiliOnlyOnlineNotify() {
 			freshCount.Store(1000)
@@ -37,6 +31,11 @@ func (c *Concern) fresh() concern.FreshFunc {
 			case <-ctx.Done():
 				return
 			}
+			interval := c.FreshInterval(cfg.GetBilibiliInterval())
+			if c.InFreshBackoff() {
+				t.Reset(localutils.Jitter(interval, cfg.GetFreshJitter()))
+				continue
+			}
 			start := time.Now()
 			var errGroup errgroup.Group
 
@@ -52,6 +51,12 @@ func (c *Concern) fresh() concern.FreshFunc {
 				} else {
 					for _, news := range newsList {
 						eventChan <- news
+						for _, reserve := range c.saveReserve(news) {
+							eventChan <- reserve
+						}
+						if video := NewVideoInfo(news); video != nil {
+							eventChan <- video
+						}
 					}
 				}
 				return nil
@@ -71,10 +76,11 @@ func (c *Concern) fresh() concern.FreshFunc {
 				var liveInfoMap = make(map[int64]*LiveInfo)
 				for _, info := range liveInfo {
 					liveInfoMap[info.Mid] = info
+					c.liveOnline.Store(info.Mid, info.Online)
 				}
 
 				_, ids, types, err := c.StateManager.ListConcernState(
-					func(groupCode int64, id interface{}, p concern_type.Type) bool {
+					func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 						return p.ContainAny(Live)
 					})
 				if err != nil {
@@ -118,6 +124,7 @@ func (c *Concern) fresh() concern.FreshFunc {
 							liveRoom.GetCover(),
 							liveRoom.GetLiveStatus(),
 						)
+						selfLiveInfo.UserCover = liveRoom.GetCover()
 						if selfLiveInfo.Living() {
 							liveInfoMap[selfUid] = selfLiveInfo
 						}
@@ -181,6 +188,9 @@ func (c *Concern) fresh() concern.FreshFunc {
 							if newInfo.LiveTitle != oldInfo.LiveTitle {
 								// live title change
 								newInfo.liveTitleChanged = true
+							}
+							newInfo.checkLiveChange(oldInfo)
+							if newInfo.liveTitleChanged || newInfo.liveCoverChanged || newInfo.liveAreaChanged {
 								sendLiveInfo(newInfo)
 							}
 						}
@@ -189,6 +199,7 @@ func (c *Concern) fresh() concern.FreshFunc {
 				return nil
 			})
 			err := errGroup.Wait()
+			metrics.ObserveFresh(Site, start, err)
 			freshCount.Inc()
 			end := time.Now()
 			if err == nil {
@@ -197,7 +208,11 @@ func (c *Concern) fresh() concern.FreshFunc {
 			} else {
 				logger.WithField("cost", end.Sub(start)).Errorf("watchCore error %v", err)
 			}
-			t.Reset(interval)
+			if backoff := c.FreshBackoff(err); backoff > 0 {
+				t.Reset(backoff)
+			} else {
+				t.Reset(localutils.Jitter(interval, cfg.GetFreshJitter()))
+			}
 		}
 	}
 }
@@ -214,7 +229,7 @@ func (c *Concern) freshDynamicNew() ([]*NewsInfo, error) {
 		logger.WithField("RespCode", resp.GetCode()).
 			WithField("RespMsg", resp.GetMessage()).
 			Errorf("DynamicSvrDynamicNew failed")
-		return nil, fmt.Errorf("DynamicSvrDynamicNew failed %v - %v", resp.GetCode(), resp.GetMessage())
+		return nil, codeError("DynamicSvrDynamicNew", resp.GetCode(), resp.GetMessage())
 	}
 	var cards []*Card
 	cards = append(cards, resp.GetData().GetCards()...)
@@ -236,8 +251,7 @@ func (c *Concern) freshDynamicNew() ([]*NewsInfo, error) {
 				logger.WithField("RespCode", resp.GetCode()).
 					WithField("RespMsg", resp.GetMessage()).
 					Errorf("DynamicSvrDynamicHistory failed")
-				return nil, fmt.Errorf("DynamicSvrDynamicHistory failed %v - %v",
-					historyResp.GetCode(), historyResp.GetMessage())
+				return nil, codeError("DynamicSvrDynamicHistory", historyResp.GetCode(), historyResp.GetMessage())
 			}
 			cards = append(cards, historyResp.GetData().GetCards()...)
 			if len(historyResp.GetData().GetCards()) > 0 {
@@ -260,7 +274,7 @@ func (c *Concern) freshDynamicNew() ([]*NewsInfo, error) {
 	var result []*NewsInfo
 	for uid, cards := range newsMap {
 		userInfo, err := c.StateManager.GetUserInfo(uid)
-		if err == buntdb.ErrNotFound {
+		if err == localdb.ErrNotFound {
 			continue
 		} else if err != nil {
 			logger.WithField("mid", uid).Debugf("find user info error %v", err)
@@ -279,6 +293,9 @@ func (c *Concern) freshDynamicNew() ([]*NewsInfo, error) {
 	for _, news := range result {
 		_ = c.MarkLatestActive(news.Mid, news.Timestamp)
 		_ = c.AddUserInfo(&news.UserInfo)
+		if err := c.AddNewsHistory(news); err != nil {
+			logger.WithField("mid", news.Mid).Errorf("AddNewsHistory error %v", err)
+		}
 	}
 	logger.WithField("cost", time.Now().Sub(start)).
 		WithField("NewsInfo Size", len(result)).
@@ -309,7 +326,7 @@ func (c *Concern) freshLive() ([]*LiveInfo, error) {
 			} else {
 				logger.Errorf("freshLive FeedList code %v msg %v", resp.GetCode(), resp.GetMessage())
 			}
-			return nil, fmt.Errorf("freshLive FeedList error code %v msg %v", resp.GetCode(), resp.GetMessage())
+			return nil, codeError("freshLive FeedList", resp.GetCode(), resp.GetMessage())
 		}
 		var (
 			dataSize    = len(resp.GetData().GetList())
@@ -338,6 +355,9 @@ func (c *Concern) freshLive() ([]*LiveInfo, error) {
 				l.GetPic(),
 				LiveStatus_Living,
 			)
+			info.UserCover = l.GetCover()
+			info.AreaId = l.GetAreaId()
+			info.Online = l.GetOnline()
 			if info.Cover == "" {
 				info.Cover = l.GetCover()
 			}
@@ -0,0 +1,131 @@
+package bilibili
+
+import (
+	"context"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"time"
+)
+
+// crossedMilestone 返回从old增长到new时越过的最大的里程碑，没有越过时返回0
+func crossedMilestone(old, new int64, milestones []int64) int64 {
+	var result int64
+	for _, m := range milestones {
+		if old < m && m <= new && m > result {
+			result = m
+		}
+	}
+	return result
+}
+
+// newGuardInfo 比较新旧快照，舰长数或者粉丝团人数达成里程碑时返回 GuardInfo ，否则返回nil
+func newGuardInfo(userInfo *UserInfo, oldStat, newStat *GuardStat) *GuardInfo {
+	guardMilestone := crossedMilestone(oldStat.GuardNum, newStat.GuardNum, cfg.GetBilibiliGuardMilestones())
+	fansClubMilestone := crossedMilestone(oldStat.FansClubNum, newStat.FansClubNum, cfg.GetBilibiliFansClubMilestones())
+	if guardMilestone == 0 && fansClubMilestone == 0 {
+		return nil
+	}
+	return &GuardInfo{
+		UserInfo:          *userInfo,
+		GuardNum:          newStat.GuardNum,
+		FansClubNum:       newStat.FansClubNum,
+		GuardMilestone:    guardMilestone,
+		FansClubMilestone: fansClubMilestone,
+	}
+}
+
+// guardFresher 按照 cfg.GetBilibiliGuardInterval 查询订阅了舰长推送的主播，
+// 这两个接口变化很慢，所以不跟随直播和动态一起刷新
+func (c *Concern) guardFresher(ctx context.Context, eventChan chan<- concern.Event) {
+	t := time.NewTimer(time.Second * 10)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		if c.InFreshBackoff() {
+			t.Reset(cfg.GetBilibiliGuardInterval())
+			continue
+		}
+		if err := c.freshGuard(ctx, eventChan); err != nil {
+			logger.Errorf("freshGuard error %v", err)
+			if concern.IsRateLimited(err) {
+				c.FreshBackoff(err)
+			}
+		}
+		t.Reset(cfg.GetBilibiliGuardInterval())
+	}
+}
+
+func (c *Concern) freshGuard(ctx context.Context, eventChan chan<- concern.Event) error {
+	_, ids, types, err := c.StateManager.ListConcernState(
+		func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+			return p.ContainAny(Guard)
+		})
+	if err != nil {
+		return err
+	}
+	ids, _, err = c.GroupTypeById(ids, types)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		mid := id.(int64)
+		log := logger.WithField("mid", mid)
+		userInfo, err := c.GetUserInfo(mid)
+		if err != nil || userInfo.RoomId == 0 {
+			log.Debug("没有直播间信息，跳过舰长查询")
+			continue
+		}
+		log = log.WithField("name", userInfo.GetName())
+		newStat, err := GetGuardStat(mid, userInfo.RoomId)
+		if err != nil {
+			if concern.IsRateLimited(err) {
+				return err
+			}
+			log.Errorf("GetGuardStat error %v", err)
+			continue
+		}
+		oldStat, err := c.GetGuardStat(mid)
+		if err != nil && err != localdb.ErrNotFound {
+			log.Errorf("GetGuardStat from db error %v", err)
+			continue
+		}
+		if err := c.AddGuardStat(newStat); err != nil {
+			// 保存失败时不推送，避免下次重复推送
+			log.Errorf("AddGuardStat error %v", err)
+			continue
+		}
+		if oldStat == nil {
+			// 第一次查询只记录快照
+			continue
+		}
+		if info := newGuardInfo(userInfo, oldStat, newStat); info != nil {
+			info.Logger().Debug("guard milestone reached")
+			eventChan <- info
+		}
+	}
+	return nil
+}
+
+// withGuardFresher 在原来的 concern.FreshFunc 之外启动 guardFresher
+func (c *Concern) withGuardFresher(fresher concern.FreshFunc) concern.FreshFunc {
+	return func(ctx context.Context, eventChan chan<- concern.Event) {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.guardFresher(ctx, eventChan)
+		}()
+		fresher(ctx, eventChan)
+	}
+}
@@ -0,0 +1,146 @@
+package bilibili
+
+import (
+	"context"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"time"
+)
+
+// reserveStypeLive 直播预约的stype，视频预约为1
+const reserveStypeLive = 2
+
+const (
+	// reserveCheckInterval 检查直播预约是否到达提醒时间的间隔
+	reserveCheckInterval = time.Second * 30
+	// reserveKeep 超过预约的开播时间这么久仍然没有开播时不再提醒
+	reserveKeep = time.Minute * 30
+)
+
+// newReserveInfos 从动态附加的卡片中找出直播预约，转发的动态中的预约属于原动态的用户，不会被处理
+func newReserveInfos(userInfo *UserInfo, cards []*Card) []*ReserveInfo {
+	var result []*ReserveInfo
+	for _, card := range cards {
+		for _, addon := range card.GetDisplay().GetAddOnCardInfo() {
+			if addon.GetAddOnCardShowType() != AddOnCardShowType_reserve {
+				continue
+			}
+			reserve := addon.GetReserveAttachCard()
+			if reserve.GetStype() != reserveStypeLive || reserve.GetLivePlanStartTime() <= 0 || len(reserve.GetOidStr()) == 0 {
+				continue
+			}
+			result = append(result, &ReserveInfo{
+				UserInfo:  *userInfo,
+				Sid:       reserve.GetOidStr(),
+				Title:     reserve.GetTitle(),
+				StartTime: reserve.GetLivePlanStartTime(),
+				DynamicId: card.GetDesc().GetDynamicIdStr(),
+			})
+		}
+	}
+	return result
+}
+
+// saveReserve 保存动态中新的直播预约，返回需要推送的预约公告，没有群订阅直播时不处理
+func (c *Concern) saveReserve(news *NewsInfo) []*ReserveInfo {
+	var result []*ReserveInfo
+	reserves := newReserveInfos(&news.UserInfo, news.Cards)
+	if len(reserves) == 0 {
+		return nil
+	}
+	ctype, err := c.GetConcern(news.Mid)
+	if err != nil || !ctype.ContainAny(Live) {
+		return nil
+	}
+	for _, reserve := range reserves {
+		err := c.AddReserve(reserve)
+		if localdb.IsRollback(err) {
+			continue
+		}
+		if err != nil {
+			reserve.Logger().Errorf("AddReserve error %v", err)
+			continue
+		}
+		result = append(result, reserve)
+	}
+	return result
+}
+
+// reserveFresher 定期检查保存的直播预约，在预约的开播时间前 cfg.GetBilibiliReserveRemindBefore 提醒
+func (c *Concern) reserveFresher(ctx context.Context, eventChan chan<- concern.Event) {
+	t := time.NewTicker(reserveCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		c.checkReserve(time.Now(), eventChan)
+	}
+}
+
+// checkReserve 提醒到达提醒时间的直播预约，每个预约只会提醒一次，已经开播的预约不会提醒
+func (c *Concern) checkReserve(now time.Time, eventChan chan<- concern.Event) {
+	remindBefore := cfg.GetBilibiliReserveRemindBefore()
+	if remindBefore <= 0 {
+		return
+	}
+	reserves, err := c.ListReserve()
+	if err != nil {
+		logger.Errorf("ListReserve error %v", err)
+		return
+	}
+	for _, reserve := range reserves {
+		if reserve.Reminded || now.Before(time.Unix(reserve.StartTime, 0).Add(-remindBefore)) {
+			continue
+		}
+		log := reserve.Logger()
+		marked, err := c.MarkReserveReminded(reserve.Mid, reserve.Sid)
+		if err != nil {
+			log.Errorf("MarkReserveReminded error %v", err)
+			continue
+		}
+		if len(marked) == 0 {
+			continue
+		}
+		if liveInfo, err := c.GetLiveInfo(reserve.Mid); err == nil && liveInfo.Living() {
+			log.Debug("已经开播，跳过直播预约提醒")
+			continue
+		}
+		log.Debug("reserve remind")
+		eventChan <- &ReserveInfo{
+			UserInfo:  reserve.UserInfo,
+			Sid:       reserve.Sid,
+			Title:     reserve.Title,
+			StartTime: reserve.StartTime,
+			DynamicId: reserve.DynamicId,
+			Reminded:  true,
+			Remind:    true,
+		}
+	}
+}
+
+// onReserveEvent 是直播预约在事件总线中的订阅者，开播后不再提醒这个用户的直播预约
+func (c *Concern) onReserveEvent(e *concern.BusEvent) {
+	liveInfo, ok := e.Event.(*LiveInfo)
+	if !ok || !liveInfo.Living() {
+		return
+	}
+	if _, err := c.MarkReserveReminded(liveInfo.Mid, "*"); err != nil {
+		liveInfo.Logger().Errorf("MarkReserveReminded error %v", err)
+	}
+}
+
+// withReserveFresher 在原来的 concern.FreshFunc 之外启动 reserveFresher
+func (c *Concern) withReserveFresher(fresher concern.FreshFunc) concern.FreshFunc {
+	return func(ctx context.Context, eventChan chan<- concern.Event) {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.reserveFresher(ctx, eventChan)
+		}()
+		fresher(ctx, eventChan)
+	}
+}
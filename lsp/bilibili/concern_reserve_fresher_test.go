@@ -0,0 +1,89 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newReserveCard(dynamicId string, stype int64, sid string, start time.Time) *Card {
+	return &Card{
+		Desc: &Card_Desc{DynamicIdStr: dynamicId},
+		Display: &Card_Display{
+			AddOnCardInfo: []*Card_Display_AddOnCardInfo{
+				{
+					AddOnCardShowType: AddOnCardShowType_reserve,
+					ReserveAttachCard: &Card_Display_AddOnCardInfo_ReserveAttachCard{
+						Title:             "直播预约：测试",
+						OidStr:            sid,
+						Stype:             stype,
+						LivePlanStartTime: start.Unix(),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewReserveInfos(t *testing.T) {
+	userInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
+	start := time.Now().Add(time.Hour)
+	reserves := newReserveInfos(userInfo, []*Card{
+		newReserveCard("100", reserveStypeLive, "1", start),
+		// 视频预约
+		newReserveCard("101", 1, "2", start),
+		{Desc: &Card_Desc{DynamicIdStr: "102"}},
+	})
+	assert.Len(t, reserves, 1)
+	assert.EqualValues(t, "1", reserves[0].Sid)
+	assert.EqualValues(t, "100", reserves[0].DynamicId)
+	assert.EqualValues(t, start.Unix(), reserves[0].StartTime)
+	assert.EqualValues(t, test.UID1, reserves[0].Mid)
+	assert.False(t, reserves[0].Remind)
+}
+
+func TestConcern_Reserve(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	c := initConcern(t)
+	userInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
+	start := time.Now().Add(time.Hour)
+	news := NewNewsInfoWithDetail(userInfo, []*Card{newReserveCard("100", reserveStypeLive, "1", start)})
+
+	// 没有群订阅直播时不处理
+	assert.Empty(t, c.saveReserve(news))
+
+	_, err := c.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, Live)
+	assert.Nil(t, err)
+	assert.Len(t, c.saveReserve(news), 1)
+	assert.Empty(t, c.saveReserve(news))
+
+	eventChan := make(chan concern.Event, 10)
+	c.checkReserve(time.Now(), eventChan)
+	assert.Empty(t, eventChan)
+
+	c.checkReserve(start.Add(-time.Minute), eventChan)
+	assert.Len(t, eventChan, 1)
+	if len(eventChan) == 0 {
+		return
+	}
+	reserve := (<-eventChan).(*ReserveInfo)
+	assert.True(t, reserve.Remind)
+	assert.EqualValues(t, "1", reserve.Sid)
+	assert.Contains(t, reserve.TemplateData()["start_time"], start.Format("15:04"))
+
+	// 只提醒一次
+	c.checkReserve(start.Add(-time.Minute), eventChan)
+	assert.Empty(t, eventChan)
+
+	// 开播后不再提醒
+	news = NewNewsInfoWithDetail(userInfo, []*Card{newReserveCard("101", reserveStypeLive, "2", start)})
+	assert.Len(t, c.saveReserve(news), 1)
+	c.onReserveEvent(&concern.BusEvent{Event: NewLiveInfo(userInfo, "", "", LiveStatus_Living)})
+	c.checkReserve(start.Add(-time.Minute), eventChan)
+	assert.Empty(t, eventChan)
+}
@@ -0,0 +1,139 @@
+package bilibili
+
+import (
+	"context"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"time"
+)
+
+// statsDisabledCheckInterval 关闭记录时检查配置是否重新开启的间隔
+const statsDisabledCheckInterval = time.Minute * 5
+
+// statsFresher 按照 cfg.GetBilibiliStatsInterval 记录所有订阅的用户的粉丝数和直播人气，
+// 用于 /stats 命令查看最近的变化
+func (c *Concern) statsFresher(ctx context.Context) {
+	t := time.NewTimer(time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		interval := cfg.GetBilibiliStatsInterval()
+		if interval <= 0 {
+			t.Reset(statsDisabledCheckInterval)
+			continue
+		}
+		if c.InFreshBackoff() {
+			t.Reset(interval)
+			continue
+		}
+		if err := c.freshStats(ctx, time.Now()); err != nil {
+			logger.Errorf("freshStats error %v", err)
+			if concern.IsRateLimited(err) {
+				c.FreshBackoff(err)
+			}
+		}
+		t.Reset(interval)
+	}
+}
+
+// freshStats 查询所有订阅的用户的粉丝数，正在直播时同时记录最近一次刷新到的直播人气
+func (c *Concern) freshStats(ctx context.Context, now time.Time) error {
+	_, ids, types, err := c.StateManager.ListConcernState(
+		func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+			return true
+		})
+	if err != nil {
+		return err
+	}
+	ids, _, err = c.GroupTypeById(ids, types)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		mid := id.(int64)
+		log := logger.WithField("mid", mid)
+		var sample = &concern.StatSample{
+			Time:     now.Unix(),
+			Follower: -1,
+		}
+		resp, err := XRelationStat(mid)
+		if err == nil && resp.GetCode() != 0 {
+			err = codeError("XRelationStat", resp.GetCode(), resp.GetMessage())
+		}
+		if err != nil {
+			if concern.IsRateLimited(err) {
+				return err
+			}
+			log.Errorf("XRelationStat error %v", err)
+		} else {
+			sample.Follower = resp.GetData().GetFollower()
+			_ = c.AddUserStat(NewUserStat(mid, resp.GetData().GetFollowing(), sample.Follower), time.Second*20)
+		}
+		if liveInfo, _ := c.GetLiveInfo(mid); liveInfo != nil && liveInfo.Living() {
+			if online, ok := c.liveOnline.Load(mid); ok {
+				sample.Online = online.(int64)
+			}
+			if err := c.UpdateLiveSessionOnline(mid, sample.Online); err != nil {
+				log.Errorf("UpdateLiveSessionOnline error %v", err)
+			}
+		}
+		if sample.Follower < 0 && sample.Online == 0 {
+			continue
+		}
+		if err := c.AddStatSample(mid, sample); err != nil {
+			log.Errorf("AddStatSample error %v", err)
+		}
+	}
+	return nil
+}
+
+// onStatsEvent 是直播记录在事件总线中的订阅者，开播时开始记录，下播时结束记录
+func (c *Concern) onStatsEvent(e *concern.BusEvent) {
+	liveInfo, ok := e.Event.(*LiveInfo)
+	if !ok {
+		return
+	}
+	var err error
+	if liveInfo.Living() {
+		// 没有收到下播事件时，上一次直播在这次开播时结束
+		if err = c.EndLiveSession(liveInfo.Mid, e.Time); err == nil {
+			err = c.StartLiveSession(liveInfo.Mid, &concern.LiveSession{
+				Start:     e.Time.Unix(),
+				Title:     liveInfo.LiveTitle,
+				MaxOnline: liveInfo.Online,
+			})
+		}
+		if localdb.IsRollback(err) {
+			err = nil
+		}
+	} else {
+		err = c.EndLiveSession(liveInfo.Mid, e.Time)
+	}
+	if err != nil {
+		liveInfo.Logger().Errorf("record live session error %v", err)
+	}
+}
+
+// withStatsFresher 在原来的 concern.FreshFunc 之外启动 statsFresher
+func (c *Concern) withStatsFresher(fresher concern.FreshFunc) concern.FreshFunc {
+	return func(ctx context.Context, eventChan chan<- concern.Event) {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.statsFresher(ctx)
+		}()
+		fresher(ctx, eventChan)
+	}
+}
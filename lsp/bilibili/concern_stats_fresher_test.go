@@ -0,0 +1,82 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStateManager_Stats(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	c := initStateManager(t)
+	now := time.Now()
+
+	assert.NotNil(t, c.AddStatSample(test.UID1, nil))
+	assert.Nil(t, c.AddStatSample(test.UID1, &concern.StatSample{Time: now.Add(-time.Hour * 48).Unix(), Follower: 10}))
+	assert.Nil(t, c.AddStatSample(test.UID1, &concern.StatSample{Time: now.Unix(), Follower: 20, Online: 100}))
+	assert.Nil(t, c.AddStatSample(test.UID2, &concern.StatSample{Time: now.Unix(), Follower: 30}))
+
+	assert.Nil(t, c.StartLiveSession(test.UID1, &concern.LiveSession{Start: now.Add(-time.Hour * 3).Unix(), Title: "a"}))
+	assert.Nil(t, c.UpdateLiveSessionOnline(test.UID1, 50))
+	assert.Nil(t, c.EndLiveSession(test.UID1, now.Add(-time.Hour*2)))
+	// 结束后不再更新
+	assert.Nil(t, c.UpdateLiveSessionOnline(test.UID1, 80))
+	assert.Nil(t, c.StartLiveSession(test.UID1, &concern.LiveSession{Start: now.Add(-time.Hour).Unix(), Title: "b"}))
+	assert.Nil(t, c.UpdateLiveSessionOnline(test.UID1, 100))
+
+	samples, sessions, err := c.GetStats(test.UID1, now.Add(-time.Hour*72))
+	assert.Nil(t, err)
+	assert.Len(t, samples, 2)
+	assert.EqualValues(t, 10, samples[0].Follower)
+	if assert.Len(t, sessions, 2) {
+		assert.Equal(t, "a", sessions[0].Title)
+		assert.EqualValues(t, 50, sessions[0].MaxOnline)
+		assert.EqualValues(t, now.Add(-time.Hour*2).Unix(), sessions[0].End)
+		assert.Zero(t, sessions[1].End)
+		assert.EqualValues(t, 100, sessions[1].MaxOnline)
+	}
+
+	samples, sessions, err = c.GetStats(test.UID1, now.Add(-time.Hour*24))
+	assert.Nil(t, err)
+	assert.Len(t, samples, 1)
+	assert.Len(t, sessions, 2)
+}
+
+func TestConcern_StatsEvent(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	c := initConcern(t)
+	var _ concern.StatsExt = c
+
+	userInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
+	start := time.Now().Add(-time.Hour)
+	living := NewLiveInfo(userInfo, "title", "", LiveStatus_Living)
+	living.Online = 10
+	c.onStatsEvent(&concern.BusEvent{Event: living, Time: start})
+	// 重复的开播事件只记录一次
+	c.onStatsEvent(&concern.BusEvent{Event: living, Time: start})
+	c.onStatsEvent(&concern.BusEvent{Event: NewLiveInfo(userInfo, "", "", LiveStatus_NoLiving), Time: start.Add(time.Minute * 30)})
+
+	_, sessions, err := c.GetStats(test.UID1, start.Add(-time.Hour))
+	assert.Nil(t, err)
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, "title", sessions[0].Title)
+		assert.EqualValues(t, 10, sessions[0].MaxOnline)
+		assert.EqualValues(t, start.Add(time.Minute*30).Unix(), sessions[0].End)
+	}
+
+	// 没有收到下播事件时，再次开播会结束上一次直播
+	c.onStatsEvent(&concern.BusEvent{Event: living, Time: start.Add(time.Minute * 40)})
+	c.onStatsEvent(&concern.BusEvent{Event: living, Time: start.Add(time.Minute * 50)})
+	_, sessions, err = c.GetStats(test.UID1, start.Add(-time.Hour))
+	assert.Nil(t, err)
+	if assert.Len(t, sessions, 3) {
+		assert.EqualValues(t, start.Add(time.Minute*50).Unix(), sessions[1].End)
+		assert.Zero(t, sessions[2].End)
+	}
+}
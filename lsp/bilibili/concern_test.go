@@ -3,10 +3,11 @@ package bilibili
 import (
 	"context"
 	"github.com/Sora233/DDBOT/internal/test"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/utils/msgstringer"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"testing"
 	"time"
 )
@@ -14,7 +15,7 @@ import (
 func initConcern(t *testing.T) *Concern {
 	c := NewConcern(nil)
 	assert.NotNil(t, c)
-	c.StateManager.FreshIndex(test.G1, test.G2)
+	c.StateManager.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
 	return c
 }
 
@@ -43,11 +44,20 @@ func TestConcern_Remove(t *testing.T) {
 
 	origUserInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
 	assert.NotNil(t, origUserInfo)
-	_, err := c.AddGroupConcern(test.G1, test.UID1, test.BibiliLive)
+	_, err := c.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive)
 	assert.Nil(t, err)
 
-	_, err = c.Remove(nil, test.G1, test.UID1, test.BibiliLive)
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive)
 	assert.Nil(t, err)
+
+	// 取消舰长订阅时删除快照
+	_, err = c.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, Guard)
+	assert.Nil(t, err)
+	assert.Nil(t, c.AddGuardStat(NewGuardStat(test.UID1, 1, 2)))
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), test.UID1, Guard)
+	assert.Nil(t, err)
+	_, err = c.GetGuardStat(test.UID1)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
 }
 
 func TestConcern_FindUserLiving(t *testing.T) {
@@ -120,7 +130,7 @@ func TestConcern_FindUserNews(t *testing.T) {
 	assert.EqualValues(t, userInfo, userInfo2)
 
 	newsInfo, err = c.FindUserNews(testMid, false)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 
 	newsInfo, err = c.FindUserNews(testMid, true)
 	assert.Nil(t, err)
@@ -169,9 +179,9 @@ func TestConcernNotify(t *testing.T) {
 	defer c.Stop()
 	defer close(testEventChan)
 
-	_, err := c.StateManager.AddGroupConcern(test.G1, test.UID1, Live.Add(News))
+	_, err := c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, Live.Add(News))
 	assert.Nil(t, err)
-	_, err = c.StateManager.AddGroupConcern(test.G2, test.UID1, News)
+	_, err = c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, News)
 	assert.Nil(t, err)
 
 	origUserInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
@@ -188,7 +198,7 @@ func TestConcernNotify(t *testing.T) {
 	case notify := <-testNotifyChan:
 		assert.NotNil(t, notify)
 		assert.EqualValues(t, test.UID1, notify.GetUid())
-		assert.EqualValues(t, test.G1, notify.GetGroupCode())
+		assert.EqualValues(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
 		assert.Contains(t, msgstringer.MsgToString(notify.ToMessage().Elements()), "mytitle")
 	case <-time.After(time.Second):
 		assert.Fail(t, "no item received")
@@ -206,7 +216,7 @@ func TestConcernNotify(t *testing.T) {
 		case notify := <-testNotifyChan:
 			assert.NotNil(t, notify)
 			assert.EqualValues(t, test.UID1, notify.GetUid())
-			assert.True(t, notify.GetGroupCode() == test.G1 || notify.GetGroupCode() == test.G2)
+			assert.True(t, notify.GetTarget() == mmsg.NewGroupTargetId(test.G1) || notify.GetTarget() == mmsg.NewGroupTargetId(test.G2))
 		case <-time.After(time.Second):
 			assert.Fail(t, "no item received")
 		}
@@ -238,7 +248,7 @@ func TestConcern_GroupWatchNotify(t *testing.T) {
 	defer c.Stop()
 	defer close(testEventChan)
 
-	_, err := c.StateManager.AddGroupConcern(test.G1, test.UID1, Live.Add(News))
+	_, err := c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, Live.Add(News))
 	assert.Nil(t, err)
 
 	origUserInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
@@ -247,12 +257,12 @@ func TestConcern_GroupWatchNotify(t *testing.T) {
 
 	assert.Nil(t, c.AddLiveInfo(origLiveInfo))
 
-	go c.GroupWatchNotify(test.G2, test.UID1)
+	go c.GroupWatchNotify(mmsg.NewGroupTargetId(test.G2), test.UID1)
 	select {
 	case notify := <-testNotifyChan:
 		assert.NotNil(t, notify)
 		assert.EqualValues(t, test.UID1, notify.GetUid())
-		assert.EqualValues(t, test.G2, notify.GetGroupCode())
+		assert.EqualValues(t, mmsg.NewGroupTargetId(test.G2), notify.GetTarget())
 	case <-time.After(time.Second):
 		assert.Fail(t, "no item received")
 	}
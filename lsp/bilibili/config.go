@@ -29,33 +29,51 @@ func (g *GroupConcernConfig) Validate() error {
 			return nil
 		}
 	}
+	if g.GetGroupConcernNotify().CheckDanmakuRelay() || g.GetGroupConcernNotify().CheckDanmakuAlert() ||
+		g.GetGroupConcernNotify().CheckRecord() {
+		// b站支持弹幕转发、弹幕提醒和直播录制，默认的Validate会拒绝，所以这里只检查过滤器和推送模板
+		if err := g.GetGroupConcernTemplate().Validate(); err != nil {
+			return err
+		}
+		if !g.GetGroupConcernFilter().Empty() {
+			if g.GetGroupConcernFilter().Type != concern.FilterTypeText {
+				return concern.ErrConfigNotSupported
+			}
+			textFilter, err := g.GetGroupConcernFilter().GetFilterByText()
+			if err != nil {
+				return err
+			}
+			return textFilter.Validate()
+		}
+		return nil
+	}
 	return g.IConfig.Validate()
 }
 
 func (g *GroupConcernConfig) NotifyBeforeCallback(inotify concern.Notify) {
-	if inotify.Type() != News {
+	notify, ok := inotify.(*ConcernNewsNotify)
+	if !ok {
 		return
 	}
-	notify := inotify.(*ConcernNewsNotify)
 	switch notify.Card.GetDesc().GetType() {
 	case DynamicDescType_WithVideo:
 		// 解决联合投稿的时候刷屏
 		notify.compactKey = notify.Card.GetDesc().GetBvid()
-		err := g.concern.SetGroupCompactMarkIfNotExist(notify.GetGroupCode(), notify.compactKey)
+		err := g.concern.SetGroupCompactMarkIfNotExist(notify.GetTarget(), notify.compactKey)
 		if localdb.IsRollback(err) {
 			notify.shouldCompact = true
 		}
 	case DynamicDescType_WithOrigin:
 		// 解决一起转发的时候刷屏
 		notify.compactKey = notify.Card.GetDesc().GetOrigDyIdStr()
-		err := g.concern.SetGroupCompactMarkIfNotExist(notify.GetGroupCode(), notify.compactKey)
+		err := g.concern.SetGroupCompactMarkIfNotExist(notify.GetTarget(), notify.compactKey)
 		if localdb.IsRollback(err) {
 			notify.shouldCompact = true
 		}
 	default:
 		// 其他动态也设置一下
 		notify.compactKey = notify.Card.GetDesc().GetDynamicIdStr()
-		err := g.concern.SetGroupCompactMarkIfNotExist(notify.GetGroupCode(), notify.Card.GetDesc().GetDynamicIdStr())
+		err := g.concern.SetGroupCompactMarkIfNotExist(notify.GetTarget(), notify.Card.GetDesc().GetDynamicIdStr())
 		if err != nil && !localdb.IsRollback(err) {
 			logger.Errorf("SetGroupOriginMarkIfNotExist error %v", err)
 		}
@@ -63,14 +81,14 @@ func (g *GroupConcernConfig) NotifyBeforeCallback(inotify concern.Notify) {
 }
 
 func (g *GroupConcernConfig) NotifyAfterCallback(inotify concern.Notify, msg *message.GroupMessage) {
-	if inotify.Type() != News || msg == nil || msg.Id == -1 {
+	notify, ok := inotify.(*ConcernNewsNotify)
+	if !ok || msg == nil || msg.Id == -1 {
 		return
 	}
-	notify := inotify.(*ConcernNewsNotify)
 	if notify.shouldCompact || len(notify.compactKey) == 0 {
 		return
 	}
-	err := g.concern.SetNotifyMsg(notify.compactKey, msg)
+	err := g.concern.SetNotifyMsg(notify.GetTarget(), notify.compactKey, msg)
 	if err != nil && !localdb.IsRollback(err) {
 		notify.Logger().Errorf("set notify msg error %v", err)
 	}
@@ -78,6 +96,14 @@ func (g *GroupConcernConfig) NotifyAfterCallback(inotify concern.Notify, msg *me
 
 func (g *GroupConcernConfig) AtBeforeHook(notify concern.Notify) (hook *concern.HookResult) {
 	hook = new(concern.HookResult)
+	switch notify.(type) {
+	case *ConcernDanmakuNotify:
+		hook.Reason = "danmaku relay notify"
+		return
+	case *ConcernDanmakuAlertNotify:
+		hook.Reason = "danmaku alert notify"
+		return
+	}
 	if g.concern != nil && g.concern.unsafeStart.Load() {
 		hook.Reason = "bilibili unsafe start status"
 		return
@@ -85,10 +111,12 @@ func (g *GroupConcernConfig) AtBeforeHook(notify concern.Notify) (hook *concern.
 	return g.IConfig.AtBeforeHook(notify)
 }
 
+// FilterHook 在默认的关键字过滤之外，支持按动态类型过滤
+// 动态在event阶段已经通过 MarkDynamicId 标记过，被过滤的动态不会被重复处理
 func (g *GroupConcernConfig) FilterHook(notify concern.Notify) (hook *concern.HookResult) {
 	hook = new(concern.HookResult)
 	switch n := notify.(type) {
-	case *ConcernLiveNotify:
+	case *ConcernLiveNotify, *ConcernGuardNotify, *ConcernReserveNotify, *ConcernDanmakuNotify, *ConcernDanmakuAlertNotify:
 		hook.Pass = true
 		return
 	case *ConcernNewsNotify:
@@ -107,41 +135,20 @@ func (g *GroupConcernConfig) FilterHook(notify concern.Notify) (hook *concern.Ho
 					Errorf("get type filter error %v", err)
 				hook.Pass = true
 			} else {
-				var convTypes []DynamicDescType
+				var matched bool
 				for _, tp := range typeFilter.Type {
-					if types, _ := PredefinedType[tp]; types != nil {
-						convTypes = append(convTypes, types...)
-					} else {
-						if t, err := strconv.ParseInt(tp, 10, 32); err == nil {
-							convTypes = append(convTypes, DynamicDescType(t))
-						}
-					}
-				}
-
-				var ok bool
-				switch g.GetGroupConcernFilter().Type {
-				case concern.FilterTypeType:
-					ok = false
-					for _, tp := range convTypes {
-						if n.Card.GetDesc().GetType() == tp {
-							ok = true
-							break
-						}
-					}
-				case concern.FilterTypeNotType:
-					ok = true
-					for _, tp := range convTypes {
-						if n.Card.GetDesc().GetType() == tp {
-							ok = false
-							break
-						}
+					if MatchDynamicType(tp, n.Card.Card) {
+						matched = true
+						break
 					}
 				}
+				// type 需要匹配任意一个种类，not_type 需要全部不匹配
+				var ok = matched == (g.GetGroupConcernFilter().Type == concern.FilterTypeType)
 				if ok {
 					logger.Debugf("news notify FilterHook pass")
 					hook.Pass = true
 				} else {
-					logger.WithField("TypeFilter", convTypes).
+					logger.WithField("TypeFilter", typeFilter.Type).
 						Debug("news notify FilterHook filtered")
 					hook.Reason = "filtered by TypeFilter"
 				}
@@ -167,6 +174,9 @@ const (
 	Wenzi         = "文字"
 	Tupian        = "图片"
 	Zhibofenxiang = "直播分享"
+	Shipin        = "视频"
+	Choujiang     = "抽奖"
+	Yuyue         = "预约"
 )
 
 var PredefinedType = map[string][]DynamicDescType{
@@ -176,11 +186,60 @@ var PredefinedType = map[string][]DynamicDescType{
 	Wenzi:         {DynamicDescType_TextOnly},
 	Tupian:        {DynamicDescType_WithImage},
 	Zhibofenxiang: {DynamicDescType_WithLive, DynamicDescType_WithLiveV2},
+	Shipin:        {DynamicDescType_WithVideo},
+}
+
+// PredefinedMatcher 无法通过动态类型区分的种类，需要检查动态内容
+var PredefinedMatcher = map[string]func(card *Card) bool{
+	Choujiang: isLotteryCard,
+	Yuyue:     isReserveCard,
+}
+
+// isLotteryCard 互动抽奖动态的正文里会带有“互动抽奖”的链接，预约抽奖会附加抽奖信息
+func isLotteryCard(card *Card) bool {
+	if strings.Contains(card.GetCard(), "互动抽奖") {
+		return true
+	}
+	for _, addOn := range card.GetDisplay().GetAddOnCardInfo() {
+		if addOn.GetReserveAttachCard().GetReserveLottery() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isReserveCard 附加了直播或者视频预约的动态
+func isReserveCard(card *Card) bool {
+	for _, addOn := range card.GetDisplay().GetAddOnCardInfo() {
+		if addOn.GetReserveAttachCard() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchDynamicType 判断动态是否属于指定的种类，种类可以是预定义的名字，也可以是动态类型的数字
+func MatchDynamicType(tp string, card *Card) bool {
+	if matcher := PredefinedMatcher[tp]; matcher != nil {
+		return matcher(card)
+	}
+	if types := PredefinedType[tp]; types != nil {
+		for _, t := range types {
+			if card.GetDesc().GetType() == t {
+				return true
+			}
+		}
+		return false
+	}
+	if t, err := strconv.ParseInt(tp, 10, 32); err == nil {
+		return card.GetDesc().GetType() == DynamicDescType(t)
+	}
+	return false
 }
 
 func CheckTypeDefine(types []string) (invalid []string) {
 	for _, t := range types {
-		if PredefinedType[t] != nil {
+		if PredefinedType[t] != nil || PredefinedMatcher[t] != nil {
 			continue
 		}
 		tp, err := strconv.ParseInt(t, 10, 32)
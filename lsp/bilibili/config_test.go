@@ -6,6 +6,7 @@ import (
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/stretchr/testify/assert"
 	"strconv"
 	"testing"
@@ -55,7 +56,7 @@ func TestNewGroupConcernConfig(t *testing.T) {
 
 	c := initConcern(t)
 
-	g := c.GetGroupConcernConfig(test.G1, test.UID1)
+	g := c.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
 
 	assert.NotNil(t, g)
 	assert.Nil(t, g.Validate())
@@ -70,16 +71,16 @@ func TestNewGroupConcernConfig(t *testing.T) {
 	g.GetGroupConcernFilter().Type = ""
 	assert.Nil(t, g.Validate())
 
-	g = c.GetGroupConcernConfig(test.G1, test.UID1)
-	err := c.OperateGroupConcernConfig(test.G1, test.UID1, g, func(concernConfig concern.IConfig) bool {
+	g = c.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
+	err := c.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1, g, func(concernConfig concern.IConfig) bool {
 		concernConfig.GetGroupConcernFilter().Type = concern.FilterTypeNotType
 		concernConfig.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: []string{"wrong"}}).ToString()
 		return true
 	})
 	assert.NotNil(t, err)
 
-	g = c.GetGroupConcernConfig(test.G1, test.UID1)
-	err = c.OperateGroupConcernConfig(test.G1, test.UID1, g, func(concernConfig concern.IConfig) bool {
+	g = c.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
+	err = c.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1, g, func(concernConfig concern.IConfig) bool {
 		concernConfig.GetGroupConcernFilter().Type = concern.FilterTypeNotType
 		concernConfig.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: []string{Tougao}}).ToString()
 		return true
@@ -301,18 +302,79 @@ func TestGroupConcernConfig_NewsFilterHook(t *testing.T) {
 }
 
 func TestCheckTypeDefine(t *testing.T) {
-	result := CheckTypeDefine([]string{"invalid", Zhuanlan, "1024", "0", "9"})
+	result := CheckTypeDefine([]string{"invalid", Zhuanlan, "1024", "0", "9", Choujiang, Shipin})
 	assert.Len(t, result, 3)
 	assert.EqualValues(t, []string{"invalid", "0", "9"}, result)
 }
 
+func TestMatchDynamicType(t *testing.T) {
+	video := &Card{Desc: &Card_Desc{Type: DynamicDescType_WithVideo}}
+	music := &Card{Desc: &Card_Desc{Type: DynamicDescType_WithMusic}}
+	lottery := &Card{
+		Card: `{"item":{"description":"互动抽奖 转发抽一位送礼物"}}`,
+		Desc: &Card_Desc{Type: DynamicDescType_TextOnly},
+	}
+	reserve := &Card{
+		Desc: &Card_Desc{Type: DynamicDescType_WithImage},
+		Display: &Card_Display{
+			AddOnCardInfo: []*Card_Display_AddOnCardInfo{
+				{ReserveAttachCard: &Card_Display_AddOnCardInfo_ReserveAttachCard{}},
+			},
+		},
+	}
+	reserveLottery := &Card{
+		Desc: &Card_Desc{Type: DynamicDescType_WithImage},
+		Display: &Card_Display{
+			AddOnCardInfo: []*Card_Display_AddOnCardInfo{
+				{ReserveAttachCard: &Card_Display_AddOnCardInfo_ReserveAttachCard{
+					ReserveLottery: &Card_Display_AddOnCardInfo_ReserveAttachCard_ReserveLottery{},
+				}},
+			},
+		},
+	}
+
+	assert.True(t, MatchDynamicType(Shipin, video))
+	assert.False(t, MatchDynamicType(Shipin, music))
+	assert.True(t, MatchDynamicType(Tougao, music))
+	assert.True(t, MatchDynamicType("8", video))
+	assert.False(t, MatchDynamicType("invalid", video))
+
+	assert.False(t, MatchDynamicType(Choujiang, video))
+	assert.True(t, MatchDynamicType(Choujiang, lottery))
+	assert.True(t, MatchDynamicType(Wenzi, lottery))
+	assert.False(t, MatchDynamicType(Choujiang, reserve))
+	assert.True(t, MatchDynamicType(Choujiang, reserveLottery))
+
+	assert.True(t, MatchDynamicType(Yuyue, reserve))
+	assert.True(t, MatchDynamicType(Yuyue, reserveLottery))
+	assert.False(t, MatchDynamicType(Yuyue, lottery))
+}
+
+func TestGroupConcernConfig_NotTypeLottery(t *testing.T) {
+	var g = NewGroupConcernConfig(&concern.GroupConcernConfig{
+		GroupConcernFilter: concern.GroupConcernFilterConfig{
+			Type: concern.FilterTypeNotType,
+			Config: (&concern.GroupConcernFilterConfigByType{
+				Type: []string{Choujiang, Zhuanfa},
+			}).ToString(),
+		},
+	}, nil)
+	assert.Nil(t, g.Validate())
+
+	notifies := newNewsInfo(test.UID1, DynamicDescType_TextOnly, DynamicDescType_TextOnly, DynamicDescType_WithOrigin)
+	notifies[1].Card.Card.Card = `{"item":{"content":"互动抽奖"}}`
+	assert.True(t, g.FilterHook(notifies[0]).Pass)
+	assert.False(t, g.FilterHook(notifies[1]).Pass)
+	assert.False(t, g.FilterHook(notifies[2]).Pass)
+}
+
 func TestGroupConcernConfig_NotifyBeforeCallback(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
 
 	c := initConcern(t)
 
-	_, err := c.GetNotifyMsg(test.G1, test.BVID1)
+	_, err := c.GetNotifyMsg(mmsg.NewGroupTargetId(test.G1), test.BVID1)
 	assert.True(t, localdb.IsNotFound(err))
 
 	var notify = newNewsInfo(test.UID1, DynamicDescType_WithOrigin)[0]
@@ -351,7 +413,7 @@ func TestGroupConcernConfig_NotifyAfterCallback(t *testing.T) {
 
 	c := initConcern(t)
 
-	_, err := c.GetNotifyMsg(test.G1, test.BVID1)
+	_, err := c.GetNotifyMsg(mmsg.NewGroupTargetId(test.G1), test.BVID1)
 	assert.True(t, localdb.IsNotFound(err))
 
 	var notify = newNewsInfo(test.UID1, DynamicDescType_WithOrigin)[0]
@@ -368,7 +430,7 @@ func TestGroupConcernConfig_NotifyAfterCallback(t *testing.T) {
 
 	g.NotifyAfterCallback(notify, msg)
 
-	msg2, err := c.GetNotifyMsg(test.G1, test.BVID1)
+	msg2, err := c.GetNotifyMsg(mmsg.NewGroupTargetId(test.G1), test.BVID1)
 	assert.Nil(t, err)
 	assert.EqualValues(t, msg, msg2)
 
@@ -0,0 +1,314 @@
+package bilibili
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/websocket"
+	"io"
+	"time"
+)
+
+const (
+	PathGetDanmuInfo = "/xlive/web-room/v1/index/getDanmuInfo"
+)
+
+// 弹幕协议的包头长度固定为16
+const danmakuHeaderLen = 16
+
+// 弹幕协议的协议版本
+const (
+	danmakuProtoJson uint16 = 0
+	danmakuProtoInt  uint16 = 1
+	danmakuProtoZlib uint16 = 2
+)
+
+// 弹幕协议的操作码
+const (
+	danmakuOpHeartbeat      uint32 = 2
+	danmakuOpHeartbeatReply uint32 = 3
+	danmakuOpMessage        uint32 = 5
+	danmakuOpAuth           uint32 = 7
+	danmakuOpAuthReply      uint32 = 8
+)
+
+type DanmakuType int
+
+const (
+	DanmakuTypeText DanmakuType = iota
+	DanmakuTypeSuperChat
+	DanmakuTypeGuard
+)
+
+// DanmakuMessage 直播间里的一条弹幕，醒目留言或者上舰消息
+type DanmakuMessage struct {
+	Type    DanmakuType
+	Uid     int64
+	Name    string
+	Content string
+	// Price 醒目留言的金额，单位为元
+	Price int64
+}
+
+type GetDanmuInfoRequest struct {
+	Id   int64 `json:"id"`
+	Type int   `json:"type"`
+}
+
+type GetDanmuInfoResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Token    string `json:"token"`
+		HostList []struct {
+			Host    string `json:"host"`
+			WssPort int    `json:"wss_port"`
+		} `json:"host_list"`
+	} `json:"data"`
+}
+
+func (r *GetDanmuInfoResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+func GetDanmuInfo(roomId int64) (*GetDanmuInfoResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := BPath(PathGetDanmuInfo)
+	params, err := utils.ToParams(&GetDanmuInfoRequest{
+		Id: roomId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 15),
+		AddUAOption(),
+		delete412ProxyOption,
+	}
+	opts = append(opts, GetVerifyOption()...)
+	resp := new(GetDanmuInfoResponse)
+	err = requests.Get(url, params, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type danmakuPacket struct {
+	Protover uint16
+	Op       uint32
+	Body     []byte
+}
+
+func encodeDanmakuPacket(op uint32, body []byte) []byte {
+	var buf = make([]byte, danmakuHeaderLen+len(body))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(buf)))
+	binary.BigEndian.PutUint16(buf[4:], danmakuHeaderLen)
+	binary.BigEndian.PutUint16(buf[6:], danmakuProtoInt)
+	binary.BigEndian.PutUint32(buf[8:], op)
+	binary.BigEndian.PutUint32(buf[12:], 1)
+	copy(buf[danmakuHeaderLen:], body)
+	return buf
+}
+
+// decodeDanmakuPacket 解析一段数据里的所有包，压缩过的包会被解压后展开
+func decodeDanmakuPacket(data []byte) ([]*danmakuPacket, error) {
+	var result []*danmakuPacket
+	for len(data) > 0 {
+		if len(data) < danmakuHeaderLen {
+			return nil, errors.New("invalid packet header")
+		}
+		packetLen := binary.BigEndian.Uint32(data[0:])
+		headerLen := binary.BigEndian.Uint16(data[4:])
+		if packetLen < uint32(headerLen) || uint32(len(data)) < packetLen {
+			return nil, errors.New("invalid packet length")
+		}
+		packet := &danmakuPacket{
+			Protover: binary.BigEndian.Uint16(data[6:]),
+			Op:       binary.BigEndian.Uint32(data[8:]),
+			Body:     data[headerLen:packetLen],
+		}
+		data = data[packetLen:]
+		if packet.Op == danmakuOpMessage && packet.Protover == danmakuProtoZlib {
+			r, err := zlib.NewReader(bytes.NewReader(packet.Body))
+			if err != nil {
+				return nil, err
+			}
+			b, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+			inner, err := decodeDanmakuPacket(b)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, inner...)
+			continue
+		}
+		result = append(result, packet)
+	}
+	return result, nil
+}
+
+// parseDanmakuMessage 解析一条json消息，不关心的消息返回nil
+func parseDanmakuMessage(body []byte) *DanmakuMessage {
+	if !gjson.ValidBytes(body) {
+		return nil
+	}
+	root := gjson.ParseBytes(body)
+	switch root.Get("cmd").String() {
+	case "DANMU_MSG":
+		return &DanmakuMessage{
+			Type:    DanmakuTypeText,
+			Uid:     root.Get("info.2.0").Int(),
+			Name:    root.Get("info.2.1").String(),
+			Content: root.Get("info.1").String(),
+		}
+	case "SUPER_CHAT_MESSAGE":
+		return &DanmakuMessage{
+			Type:    DanmakuTypeSuperChat,
+			Uid:     root.Get("data.uid").Int(),
+			Name:    root.Get("data.user_info.uname").String(),
+			Content: root.Get("data.message").String(),
+			Price:   root.Get("data.price").Int(),
+		}
+	case "GUARD_BUY":
+		return &DanmakuMessage{
+			Type:    DanmakuTypeGuard,
+			Uid:     root.Get("data.uid").Int(),
+			Name:    root.Get("data.username").String(),
+			Content: fmt.Sprintf("%v×%v", root.Get("data.gift_name").String(), root.Get("data.num").Int()),
+		}
+	default:
+		return nil
+	}
+}
+
+// DanmakuClient 连接一个直播间的弹幕服务器，断线后会自动重连，直到ctx结束
+type DanmakuClient struct {
+	roomId  int64
+	handler func(*DanmakuMessage)
+}
+
+func NewDanmakuClient(roomId int64, handler func(*DanmakuMessage)) *DanmakuClient {
+	return &DanmakuClient{
+		roomId:  roomId,
+		handler: handler,
+	}
+}
+
+func (d *DanmakuClient) Run(ctx context.Context) {
+	log := logger.WithField("RoomId", d.roomId)
+	for {
+		err := d.connect(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		log.Errorf("danmaku connection closed %v, reconnect later", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second * 10):
+		}
+	}
+}
+
+func (d *DanmakuClient) connect(ctx context.Context) error {
+	info, err := GetDanmuInfo(d.roomId)
+	if err != nil {
+		return err
+	}
+	if info.GetCode() != 0 {
+		return fmt.Errorf("GetDanmuInfo error %v - %v", info.GetCode(), info.Message)
+	}
+	var host = "broadcastlv.chat.bilibili.com"
+	var port = 443
+	if len(info.Data.HostList) > 0 {
+		host = info.Data.HostList[0].Host
+		port = info.Data.HostList[0].WssPort
+	}
+	ws, err := websocket.Dial(fmt.Sprintf("wss://%v:%v/sub", host, port), "", "https://live.bilibili.com")
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	auth, _ := json.Marshal(map[string]interface{}{
+		"uid":      accountUid.Load(),
+		"roomid":   d.roomId,
+		"protover": danmakuProtoZlib,
+		"platform": "web",
+		"type":     2,
+		"key":      info.Data.Token,
+	})
+	if err = websocket.Message.Send(ws, encodeDanmakuPacket(danmakuOpAuth, auth)); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(time.Second * 30)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// 关闭连接让Receive返回
+				ws.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := websocket.Message.Send(ws, encodeDanmakuPacket(danmakuOpHeartbeat, nil)); err != nil {
+					ws.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var data []byte
+		if err = websocket.Message.Receive(ws, &data); err != nil {
+			return err
+		}
+		packets, err := decodeDanmakuPacket(data)
+		if err != nil {
+			return err
+		}
+		for _, packet := range packets {
+			switch packet.Op {
+			case danmakuOpAuthReply:
+				if gjson.GetBytes(packet.Body, "code").Int() != 0 {
+					return fmt.Errorf("danmaku auth failed %v", string(packet.Body))
+				}
+			case danmakuOpMessage:
+				if packet.Protover != danmakuProtoJson {
+					continue
+				}
+				if m := parseDanmakuMessage(packet.Body); m != nil && d.handler != nil {
+					d.handler(m)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 同一个群同一个关键字（或者用户）两次提醒的最小间隔，避免刷屏
+const danmakuAlertCooldown = time.Minute
+
+// ConcernDanmakuAlertNotify 直播弹幕提醒，弹幕包含关键字或者指定的用户发言时立即推送
+type ConcernDanmakuAlertNotify struct {
+	Target mmsg.TargetId `json:"target"`
+	*UserInfo
+	Danmaku *DanmakuMessage
+	// Reason 匹配到的关键字或者用户
+	Reason string
+}
+
+func (notify *ConcernDanmakuAlertNotify) Site() string {
+	return Site
+}
+
+func (notify *ConcernDanmakuAlertNotify) Type() concern_type.Type {
+	return Live
+}
+
+func (notify *ConcernDanmakuAlertNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernDanmakuAlertNotify) Logger() *logrus.Entry {
+	return logger.WithFields(mmsg.TargetLogFields(notify.Target)).WithFields(logrus.Fields{
+		"Site":   Site,
+		"Mid":    notify.Mid,
+		"Name":   notify.Name,
+		"RoomId": notify.RoomId,
+		"Reason": notify.Reason,
+		"Type":   "danmaku_alert",
+	})
+}
+
+func (notify *ConcernDanmakuAlertNotify) ToMessage() *mmsg.MSG {
+	m := mmsg.NewMSG()
+	m.Textf("%v的直播间弹幕提醒【%v】：\n", notify.Name, notify.Reason)
+	switch notify.Danmaku.Type {
+	case DanmakuTypeSuperChat:
+		m.Textf("[SC ￥%v] %v：%v", notify.Danmaku.Price, notify.Danmaku.Name, notify.Danmaku.Content)
+	case DanmakuTypeGuard:
+		m.Textf("[上舰] %v：%v", notify.Danmaku.Name, notify.Danmaku.Content)
+	default:
+		m.Textf("%v：%v", notify.Danmaku.Name, notify.Danmaku.Content)
+	}
+	if notify.RoomUrl != "" {
+		m.Textf("\n%v", notify.RoomUrl)
+	}
+	return m
+}
+
+// matchDanmakuAlert 发送者的uid或者用户名在配置中，或者弹幕包含任意一个关键字时，返回匹配到的用户或者关键字
+func matchDanmakuAlert(alertConfig *concern.GroupConcernDanmakuAlertConfig, d *DanmakuMessage) (string, bool) {
+	for _, user := range alertConfig.Users {
+		if user == d.Name || user == strconv.FormatInt(d.Uid, 10) {
+			return user, true
+		}
+	}
+	for _, keyword := range alertConfig.Keywords {
+		if strings.Contains(d.Content, keyword) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,357 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 每次合并转发的最大条数，超过的部分会被丢弃
+const danmakuRelayBatchLimit = 20
+
+// ConcernDanmakuNotify 是一批需要转发到群内的直播弹幕
+type ConcernDanmakuNotify struct {
+	Target mmsg.TargetId `json:"target"`
+	*UserInfo
+	Danmaku []*DanmakuMessage
+	Dropped int
+}
+
+func (notify *ConcernDanmakuNotify) Site() string {
+	return Site
+}
+
+func (notify *ConcernDanmakuNotify) Type() concern_type.Type {
+	return Live
+}
+
+func (notify *ConcernDanmakuNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernDanmakuNotify) Logger() *logrus.Entry {
+	return logger.WithFields(mmsg.TargetLogFields(notify.Target)).WithFields(logrus.Fields{
+		"Site":        Site,
+		"Mid":         notify.Mid,
+		"Name":        notify.Name,
+		"RoomId":      notify.RoomId,
+		"DanmakuSize": len(notify.Danmaku),
+		"Type":        "danmaku",
+	})
+}
+
+func (notify *ConcernDanmakuNotify) ToMessage() *mmsg.MSG {
+	m := mmsg.NewMSG()
+	m.Textf("%v的直播间弹幕：", notify.Name)
+	for _, d := range notify.Danmaku {
+		switch d.Type {
+		case DanmakuTypeSuperChat:
+			m.Textf("\n[SC ￥%v] %v：%v", d.Price, d.Name, d.Content)
+		case DanmakuTypeGuard:
+			m.Textf("\n[上舰] %v：%v", d.Name, d.Content)
+		default:
+			m.Textf("\n%v：%v", d.Name, d.Content)
+		}
+	}
+	if notify.Dropped > 0 {
+		m.Textf("\n另有%v条弹幕未显示", notify.Dropped)
+	}
+	return m
+}
+
+// matchDanmakuRelay 醒目留言和上舰消息总是转发，普通弹幕需要包含任意一个关键字
+func matchDanmakuRelay(relayConfig *concern.GroupConcernDanmakuRelayConfig, d *DanmakuMessage) bool {
+	if d.Type != DanmakuTypeText {
+		return true
+	}
+	for _, keyword := range relayConfig.Keywords {
+		if strings.Contains(d.Content, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+type danmakuRelayRoom struct {
+	info   *UserInfo
+	cancel context.CancelFunc
+	// alert 弹幕提醒不合并，匹配后立即调用
+	alert func(notify *ConcernDanmakuAlertNotify)
+
+	mu        sync.Mutex
+	groups    map[mmsg.TargetId]*concern.GroupConcernDanmakuRelayConfig
+	buffer    map[mmsg.TargetId][]*DanmakuMessage
+	alerts    map[mmsg.TargetId]*concern.GroupConcernDanmakuAlertConfig
+	lastAlert map[string]time.Time
+}
+
+func newDanmakuRelayRoom(info *UserInfo, cancel context.CancelFunc, alert func(notify *ConcernDanmakuAlertNotify)) *danmakuRelayRoom {
+	return &danmakuRelayRoom{
+		info:      info,
+		cancel:    cancel,
+		alert:     alert,
+		groups:    make(map[mmsg.TargetId]*concern.GroupConcernDanmakuRelayConfig),
+		buffer:    make(map[mmsg.TargetId][]*DanmakuMessage),
+		alerts:    make(map[mmsg.TargetId]*concern.GroupConcernDanmakuAlertConfig),
+		lastAlert: make(map[string]time.Time),
+	}
+}
+
+func (r *danmakuRelayRoom) empty() bool {
+	return len(r.groups) == 0 && len(r.alerts) == 0
+}
+
+func (r *danmakuRelayRoom) onDanmaku(d *DanmakuMessage) {
+	for _, notify := range r.match(d) {
+		if r.alert != nil {
+			r.alert(notify)
+		}
+	}
+}
+
+// match 把需要转发的弹幕放入缓存，返回需要立即发送的弹幕提醒
+func (r *danmakuRelayRoom) match(d *DanmakuMessage) (result []*ConcernDanmakuAlertNotify) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for target, relayConfig := range r.groups {
+		if matchDanmakuRelay(relayConfig, d) {
+			r.buffer[target] = append(r.buffer[target], d)
+		}
+	}
+	for target, alertConfig := range r.alerts {
+		reason, ok := matchDanmakuAlert(alertConfig, d)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%v:%v", target, reason)
+		if time.Since(r.lastAlert[key]) < danmakuAlertCooldown {
+			continue
+		}
+		r.lastAlert[key] = time.Now()
+		result = append(result, &ConcernDanmakuAlertNotify{
+			Target:   target,
+			UserInfo: r.info,
+			Danmaku:  d,
+			Reason:   reason,
+		})
+	}
+	return
+}
+
+func (r *danmakuRelayRoom) flush() (result []*ConcernDanmakuNotify) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for target, danmaku := range r.buffer {
+		if len(danmaku) == 0 {
+			continue
+		}
+		notify := &ConcernDanmakuNotify{
+			Target:   target,
+			UserInfo: r.info,
+		}
+		if len(danmaku) > danmakuRelayBatchLimit {
+			// 优先保留醒目留言和上舰消息
+			for _, d := range danmaku {
+				if d.Type != DanmakuTypeText && len(notify.Danmaku) < danmakuRelayBatchLimit {
+					notify.Danmaku = append(notify.Danmaku, d)
+				}
+			}
+			for _, d := range danmaku {
+				if d.Type == DanmakuTypeText && len(notify.Danmaku) < danmakuRelayBatchLimit {
+					notify.Danmaku = append(notify.Danmaku, d)
+				}
+			}
+			notify.Dropped = len(danmaku) - len(notify.Danmaku)
+		} else {
+			notify.Danmaku = danmaku
+		}
+		result = append(result, notify)
+	}
+	r.buffer = make(map[mmsg.TargetId][]*DanmakuMessage)
+	return
+}
+
+// danmakuRelay 管理所有正在转发弹幕或者提醒弹幕的直播间，每个直播间只会建立一个连接
+type danmakuRelay struct {
+	notify chan<- concern.Notify
+	// enabled 检查群内是否仍然开启了弹幕转发，关闭配置或者取消订阅后，在下一次转发时停止
+	enabled func(target mmsg.TargetId, mid int64) bool
+	// alertEnabled 检查群内是否仍然开启了弹幕提醒，关闭配置或者取消订阅后，在下一次提醒时停止
+	alertEnabled func(target mmsg.TargetId, mid int64) bool
+	mu           sync.Mutex
+	rooms        map[int64]*danmakuRelayRoom
+	wg           sync.WaitGroup
+}
+
+func newDanmakuRelay(notify chan<- concern.Notify, enabled func(target mmsg.TargetId, mid int64) bool, alertEnabled func(target mmsg.TargetId, mid int64) bool) *danmakuRelay {
+	return &danmakuRelay{
+		notify:       notify,
+		enabled:      enabled,
+		alertEnabled: alertEnabled,
+		rooms:        make(map[int64]*danmakuRelayRoom),
+	}
+}
+
+// Join 开始向群内转发直播间弹幕，如果直播间还没有连接则会建立连接
+func (d *danmakuRelay) Join(target mmsg.TargetId, info *UserInfo, relayConfig concern.GroupConcernDanmakuRelayConfig) {
+	if info == nil || info.RoomId == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	room := d.room(info)
+	room.mu.Lock()
+	room.groups[target] = &relayConfig
+	room.mu.Unlock()
+}
+
+// JoinAlert 开始在群内提醒直播间弹幕，如果直播间还没有连接则会建立连接
+func (d *danmakuRelay) JoinAlert(target mmsg.TargetId, info *UserInfo, alertConfig concern.GroupConcernDanmakuAlertConfig) {
+	if info == nil || info.RoomId == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	room := d.room(info)
+	room.mu.Lock()
+	room.alerts[target] = &alertConfig
+	room.mu.Unlock()
+}
+
+// room 返回直播间，还没有连接时建立连接，调用时需要持有 d.mu
+func (d *danmakuRelay) room(info *UserInfo) *danmakuRelayRoom {
+	room, found := d.rooms[info.RoomId]
+	if !found {
+		ctx, cancel := context.WithCancel(context.Background())
+		room = newDanmakuRelayRoom(info, cancel, d.sendAlert)
+		d.rooms[info.RoomId] = room
+		d.wg.Add(2)
+		go func() {
+			defer d.wg.Done()
+			NewDanmakuClient(info.RoomId, room.onDanmaku).Run(ctx)
+		}()
+		go func() {
+			defer d.wg.Done()
+			d.flushLoop(ctx, room)
+		}()
+		logger.WithFields(logrus.Fields{
+			"Mid":    info.Mid,
+			"Name":   info.Name,
+			"RoomId": info.RoomId,
+		}).Debug("danmaku relay started")
+	}
+	return room
+}
+
+// Leave 停止向群内转发，当直播间没有群需要转发或者提醒时会断开连接
+func (d *danmakuRelay) Leave(target mmsg.TargetId, roomId int64) {
+	d.leave(roomId, func(room *danmakuRelayRoom) {
+		delete(room.groups, target)
+		delete(room.buffer, target)
+	})
+}
+
+// LeaveAlert 停止在群内提醒，当直播间没有群需要转发或者提醒时会断开连接
+func (d *danmakuRelay) LeaveAlert(target mmsg.TargetId, roomId int64) {
+	d.leave(roomId, func(room *danmakuRelayRoom) {
+		delete(room.alerts, target)
+	})
+}
+
+func (d *danmakuRelay) leave(roomId int64, f func(room *danmakuRelayRoom)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	room, found := d.rooms[roomId]
+	if !found {
+		return
+	}
+	room.mu.Lock()
+	f(room)
+	empty := room.empty()
+	room.mu.Unlock()
+	if empty {
+		room.cancel()
+		delete(d.rooms, roomId)
+	}
+}
+
+// StopRoom 下播时断开连接，已经缓存的弹幕会在断开前发送
+func (d *danmakuRelay) StopRoom(roomId int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if room, found := d.rooms[roomId]; found {
+		room.cancel()
+		delete(d.rooms, roomId)
+	}
+}
+
+// StopMid 断开用户的直播间连接，直播信息被删除时调用
+func (d *danmakuRelay) StopMid(mid int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for roomId, room := range d.rooms {
+		if room.info.Mid == mid {
+			room.cancel()
+			delete(d.rooms, roomId)
+		}
+	}
+}
+
+func (d *danmakuRelay) Stop() {
+	d.mu.Lock()
+	for roomId, room := range d.rooms {
+		room.cancel()
+		delete(d.rooms, roomId)
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+func (d *danmakuRelay) flushLoop(ctx context.Context, room *danmakuRelayRoom) {
+	ticker := time.NewTicker(cfg.GetBilibiliDanmakuRelayInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			d.send(room.flush())
+			return
+		case <-ticker.C:
+			d.send(room.flush())
+		}
+	}
+}
+
+func (d *danmakuRelay) send(notifies []*ConcernDanmakuNotify) {
+	for _, notify := range notifies {
+		if d.enabled != nil && !d.enabled(notify.Target, notify.Mid) {
+			notify.Logger().Debug("danmaku relay disabled, leave")
+			d.Leave(notify.Target, notify.RoomId)
+			continue
+		}
+		select {
+		case d.notify <- notify:
+		case <-time.After(time.Second * 5):
+			notify.Logger().Warn("notify channel is full, danmaku dropped")
+		}
+	}
+}
+
+func (d *danmakuRelay) sendAlert(notify *ConcernDanmakuAlertNotify) {
+	if d.alertEnabled != nil && !d.alertEnabled(notify.Target, notify.Mid) {
+		notify.Logger().Debug("danmaku alert disabled, leave")
+		d.LeaveAlert(notify.Target, notify.RoomId)
+		return
+	}
+	select {
+	case d.notify <- notify:
+	case <-time.After(time.Second * 5):
+		notify.Logger().Warn("notify channel is full, danmaku alert dropped")
+	}
+}
@@ -0,0 +1,183 @@
+package bilibili
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestDanmakuPacket(protover uint16, op uint32, body []byte) []byte {
+	b := encodeDanmakuPacket(op, body)
+	binary.BigEndian.PutUint16(b[6:], protover)
+	return b
+}
+
+func TestDecodeDanmakuPacket(t *testing.T) {
+	heartbeat := encodeDanmakuPacket(danmakuOpHeartbeat, nil)
+	packets, err := decodeDanmakuPacket(heartbeat)
+	assert.Nil(t, err)
+	assert.Len(t, packets, 1)
+	assert.Equal(t, danmakuOpHeartbeat, packets[0].Op)
+	assert.Empty(t, packets[0].Body)
+
+	var inner []byte
+	inner = append(inner, newTestDanmakuPacket(danmakuProtoJson, danmakuOpMessage, []byte(`{"cmd":"DANMU_MSG"}`))...)
+	inner = append(inner, newTestDanmakuPacket(danmakuProtoJson, danmakuOpMessage, []byte(`{"cmd":"GUARD_BUY"}`))...)
+
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err = w.Write(inner)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+
+	data := newTestDanmakuPacket(danmakuProtoZlib, danmakuOpMessage, buf.Bytes())
+	data = append(data, newTestDanmakuPacket(danmakuProtoInt, danmakuOpHeartbeatReply, []byte{0, 0, 0, 1})...)
+
+	packets, err = decodeDanmakuPacket(data)
+	assert.Nil(t, err)
+	assert.Len(t, packets, 3)
+	assert.Equal(t, `{"cmd":"DANMU_MSG"}`, string(packets[0].Body))
+	assert.Equal(t, `{"cmd":"GUARD_BUY"}`, string(packets[1].Body))
+	assert.Equal(t, danmakuOpHeartbeatReply, packets[2].Op)
+
+	_, err = decodeDanmakuPacket(data[:10])
+	assert.NotNil(t, err)
+	_, err = decodeDanmakuPacket(data[:20])
+	assert.NotNil(t, err)
+}
+
+func TestParseDanmakuMessage(t *testing.T) {
+	assert.Nil(t, parseDanmakuMessage([]byte(`wrong`)))
+	assert.Nil(t, parseDanmakuMessage([]byte(`{"cmd":"INTERACT_WORD"}`)))
+
+	m := parseDanmakuMessage([]byte(`{"cmd":"DANMU_MSG","info":[[0],"hello",[777,"name1"]]}`))
+	assert.NotNil(t, m)
+	assert.Equal(t, DanmakuTypeText, m.Type)
+	assert.Equal(t, "hello", m.Content)
+	assert.Equal(t, test.UID1, m.Uid)
+	assert.Equal(t, test.NAME1, m.Name)
+
+	m = parseDanmakuMessage([]byte(`{"cmd":"SUPER_CHAT_MESSAGE","data":{"uid":777,"message":"sc","price":30,"user_info":{"uname":"name1"}}}`))
+	assert.NotNil(t, m)
+	assert.Equal(t, DanmakuTypeSuperChat, m.Type)
+	assert.Equal(t, "sc", m.Content)
+	assert.EqualValues(t, 30, m.Price)
+	assert.Equal(t, test.NAME1, m.Name)
+
+	m = parseDanmakuMessage([]byte(`{"cmd":"GUARD_BUY","data":{"uid":777,"username":"name1","gift_name":"舰长","num":1}}`))
+	assert.NotNil(t, m)
+	assert.Equal(t, DanmakuTypeGuard, m.Type)
+	assert.Equal(t, "舰长×1", m.Content)
+}
+
+func TestDanmakuRelayRoom(t *testing.T) {
+	room := &danmakuRelayRoom{
+		info: &UserInfo{Mid: test.UID1, Name: test.NAME1, RoomId: test.ROOMID1},
+		groups: map[mmsg.TargetId]*concern.GroupConcernDanmakuRelayConfig{
+			mmsg.NewGroupTargetId(test.G1): {Enable: true},
+			mmsg.NewGroupTargetId(test.G2): {Enable: true, Keywords: []string{"kw"}},
+		},
+		buffer: make(map[mmsg.TargetId][]*DanmakuMessage),
+	}
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Content: "no"})
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Content: "has kw"})
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeSuperChat, Content: "sc", Price: 30})
+
+	notifies := room.flush()
+	assert.Len(t, notifies, 2)
+	for _, notify := range notifies {
+		switch notify.Target {
+		case mmsg.NewGroupTargetId(test.G1):
+			assert.Len(t, notify.Danmaku, 1)
+		case mmsg.NewGroupTargetId(test.G2):
+			assert.Len(t, notify.Danmaku, 2)
+		default:
+			assert.Fail(t, "unexpected group")
+		}
+		assert.NotNil(t, notify.ToMessage())
+		assert.Equal(t, Live, notify.Type())
+	}
+	assert.Empty(t, room.flush())
+
+	for i := 0; i < danmakuRelayBatchLimit+5; i++ {
+		room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Content: "kw"})
+	}
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeGuard, Content: "舰长×1"})
+	notifies = room.flush()
+	assert.Len(t, notifies, 2)
+	for _, notify := range notifies {
+		if notify.Target == mmsg.NewGroupTargetId(test.G2) {
+			assert.Len(t, notify.Danmaku, danmakuRelayBatchLimit)
+			assert.Equal(t, 6, notify.Dropped)
+			assert.Equal(t, DanmakuTypeGuard, notify.Danmaku[0].Type)
+		}
+	}
+}
+
+func TestGroupConcernConfig_DanmakuRelay(t *testing.T) {
+	c := NewGroupConcernConfig(new(concern.GroupConcernConfig), nil)
+	c.GetGroupConcernNotify().DanmakuRelay = &concern.GroupConcernDanmakuRelayConfig{Enable: true}
+	assert.Nil(t, c.Validate())
+
+	notify := &ConcernDanmakuNotify{Target: mmsg.NewGroupTargetId(test.G1), UserInfo: &UserInfo{Mid: test.UID1}}
+	assert.True(t, c.FilterHook(notify).Pass)
+	assert.False(t, c.AtBeforeHook(notify).Pass)
+}
+
+func TestDanmakuAlertRoom(t *testing.T) {
+	var alerts []*ConcernDanmakuAlertNotify
+	room := newDanmakuRelayRoom(&UserInfo{Mid: test.UID1, Name: test.NAME1, RoomId: test.ROOMID1}, nil,
+		func(notify *ConcernDanmakuAlertNotify) {
+			alerts = append(alerts, notify)
+		})
+	room.alerts[mmsg.NewGroupTargetId(test.G1)] = &concern.GroupConcernDanmakuAlertConfig{Keywords: []string{"开奖"}}
+	room.alerts[mmsg.NewGroupTargetId(test.G2)] = &concern.GroupConcernDanmakuAlertConfig{Users: []string{"name1", "777"}}
+	assert.False(t, room.empty())
+
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Name: "other", Content: "no"})
+	assert.Empty(t, alerts)
+
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Name: "other", Content: "准备开奖了"})
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), alerts[0].Target)
+	assert.Equal(t, "开奖", alerts[0].Reason)
+	assert.NotNil(t, alerts[0].ToMessage())
+	assert.Equal(t, Live, alerts[0].Type())
+
+	// 冷却时间内同一个关键字不会重复提醒
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Name: "other", Content: "开奖"})
+	assert.Len(t, alerts, 1)
+
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeText, Uid: 777, Name: "other", Content: "hello"})
+	room.onDanmaku(&DanmakuMessage{Type: DanmakuTypeSuperChat, Uid: 1, Name: "name1", Content: "sc", Price: 30})
+	assert.Len(t, alerts, 3)
+	assert.Equal(t, "777", alerts[1].Reason)
+	assert.Equal(t, "name1", alerts[2].Reason)
+
+	// 弹幕提醒不会进入转发的缓存
+	assert.Empty(t, room.flush())
+
+	delete(room.alerts, mmsg.NewGroupTargetId(test.G1))
+	delete(room.alerts, mmsg.NewGroupTargetId(test.G2))
+	assert.True(t, room.empty())
+}
+
+func TestGroupConcernConfig_DanmakuAlert(t *testing.T) {
+	c := NewGroupConcernConfig(new(concern.GroupConcernConfig), nil)
+	c.GetGroupConcernNotify().DanmakuAlert = &concern.GroupConcernDanmakuAlertConfig{Keywords: []string{"开奖"}}
+	assert.Nil(t, c.Validate())
+
+	notify := &ConcernDanmakuAlertNotify{
+		Target:   mmsg.NewGroupTargetId(test.G1),
+		UserInfo: &UserInfo{Mid: test.UID1},
+		Danmaku:  &DanmakuMessage{Content: "开奖"},
+		Reason:   "开奖",
+	}
+	assert.True(t, c.FilterHook(notify).Pass)
+	assert.False(t, c.AtBeforeHook(notify).Pass)
+}
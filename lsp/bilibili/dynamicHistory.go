@@ -37,6 +37,7 @@ func DynamicSvrDynamicHistory(offsetDynamicId string) (*DynamicSvrDynamicHistory
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.HeaderOption("origin", fmt.Sprintf("https://t.bilibili.com")),
 		requests.HeaderOption("referer", fmt.Sprintf("https://t.bilibili.com")),
 		AddUAOption(),
@@ -39,6 +39,7 @@ func DynamicSvrDynamicNew() (*DynamicSvrDynamicNewResponse, error) {
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.HeaderOption("origin", fmt.Sprintf("https://t.bilibili.com")),
 		requests.HeaderOption("referer", fmt.Sprintf("https://t.bilibili.com")),
 		AddUAOption(),
@@ -59,6 +59,7 @@ func FeedList(opt ...FeedOpt) (*FeedListResponse, error) {
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		AddUAOption(),
 		requests.TimeoutOption(time.Second*10),
 	)
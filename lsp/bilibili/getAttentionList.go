@@ -24,6 +24,7 @@ func GetAttentionList() (*GetAttentionListResponse, error) {
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		AddUAOption(),
 		requests.TimeoutOption(time.Second*10),
 		delete412ProxyOption,
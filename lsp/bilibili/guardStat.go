@@ -0,0 +1,142 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+const (
+	PathGuardTopList    = "/xlive/app-room/v2/guardTab/topList"
+	PathFansMembersRank = "/xlive/general-interface/v1/rank/getFansMembersRank"
+)
+
+type GuardTopListRequest struct {
+	RoomId   int64 `json:"roomid"`
+	Ruid     int64 `json:"ruid"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+}
+
+type GuardTopListResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Info struct {
+			// Num 舰长、提督、总督的总数
+			Num int64 `json:"num"`
+		} `json:"info"`
+	} `json:"data"`
+}
+
+func (r *GuardTopListResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+type FansMembersRankRequest struct {
+	Ruid     int64 `json:"ruid"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+}
+
+type FansMembersRankResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		// Num 粉丝团人数
+		Num int64 `json:"num"`
+	} `json:"data"`
+}
+
+func (r *FansMembersRankResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+// GuardTopList 查询直播间的大航海列表，只需要总数，所以每页只取一条
+func GuardTopList(roomId int64, mid int64) (*GuardTopListResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := BPath(PathGuardTopList)
+	params, err := utils.ToParams(&GuardTopListRequest{
+		RoomId:   roomId,
+		Ruid:     mid,
+		Page:     1,
+		PageSize: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 15),
+		AddUAOption(),
+		delete412ProxyOption,
+	}
+	resp := new(GuardTopListResponse)
+	err = requests.Get(url, params, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FansMembersRank 查询主播的粉丝团排行，只需要总人数，所以每页只取一条
+func FansMembersRank(mid int64) (*FansMembersRankResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := BPath(PathFansMembersRank)
+	params, err := utils.ToParams(&FansMembersRankRequest{
+		Ruid:     mid,
+		Page:     1,
+		PageSize: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 15),
+		AddUAOption(),
+		delete412ProxyOption,
+	}
+	resp := new(FansMembersRankResponse)
+	err = requests.Get(url, params, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetGuardStat 查询主播当前的舰长数和粉丝团人数
+func GetGuardStat(mid int64, roomId int64) (*GuardStat, error) {
+	guardResp, err := GuardTopList(roomId, mid)
+	if err != nil {
+		return nil, err
+	}
+	if guardResp.GetCode() != 0 {
+		return nil, codeError("GuardTopList", guardResp.GetCode(), guardResp.Message)
+	}
+	fansResp, err := FansMembersRank(mid)
+	if err != nil {
+		return nil, err
+	}
+	if fansResp.GetCode() != 0 {
+		return nil, codeError("FansMembersRank", fansResp.GetCode(), fansResp.Message)
+	}
+	return NewGuardStat(mid, guardResp.Data.Info.Num, fansResp.Data.Num), nil
+}
@@ -1,12 +1,21 @@
 package bilibili
 
 import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"time"
 )
 
 func init() {
 	concern.RegisterConcern(NewConcern(concern.GetNotifyChan()))
+	localdb.RegisterJsonType(localdb.BilibiliUserInfoKey, func() interface{} { return new(UserInfo) })
+	localdb.RegisterJsonType(localdb.BilibiliUserStatKey, func() interface{} { return new(UserStat) })
+	localdb.RegisterJsonType(localdb.BilibiliGuardStatKey, func() interface{} { return new(GuardStat) })
+	localdb.RegisterJsonType(localdb.BilibiliCurrentLiveKey, func() interface{} { return new(LiveInfo) })
+	localdb.RegisterJsonType(localdb.BilibiliCurrentNewsKey, func() interface{} { return new(NewsInfo) })
+	localdb.RegisterJsonType(localdb.BilibiliReserveKey, func() interface{} { return new(ReserveInfo) })
+	localdb.RegisterJsonType(localdb.BilibiliStatSampleKey, func() interface{} { return new(concern.StatSample) })
+	localdb.RegisterJsonType(localdb.BilibiliLiveSessionKey, func() interface{} { return new(concern.LiveSession) })
 	refreshCookieJar()
 	refreshNavWbi()
 	go func() {
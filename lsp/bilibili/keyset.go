@@ -1,6 +1,10 @@
 package bilibili
 
-import "github.com/Sora233/DDBOT/lsp/buntdb"
+import (
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
 
 type keySet struct {
 }
@@ -21,8 +25,8 @@ func (k *keySet) FreshKey(keys ...interface{}) string {
 	return buntdb.BilibliFreshKey(keys...)
 }
 
-func (k *keySet) ParseGroupConcernStateKey(key string) (int64, interface{}, error) {
-	return buntdb.ParseConcernStateKeyWithInt64(key)
+func (k *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	return concern.ParseConcernStateKeyWithInt64(key)
 }
 
 type extraKey struct {
@@ -58,6 +62,22 @@ func (k *extraKey) LastFreshKey(keys ...interface{}) string {
 	return buntdb.BilibiliLastFreshKey(keys...)
 }
 
+func (k *extraKey) GuardStatKey(keys ...interface{}) string {
+	return buntdb.BilibiliGuardStatKey(keys...)
+}
+
+func (k *extraKey) ReserveKey(keys ...interface{}) string {
+	return buntdb.BilibiliReserveKey(keys...)
+}
+
+func (k *extraKey) StatSampleKey(keys ...interface{}) string {
+	return buntdb.BilibiliStatSampleKey(keys...)
+}
+
+func (k *extraKey) LiveSessionKey(keys ...interface{}) string {
+	return buntdb.BilibiliLiveSessionKey(keys...)
+}
+
 func (k *extraKey) CompactMarkKey(keys ...interface{}) string {
 	return buntdb.BilibiliCompactMarkKey(keys...)
 }
@@ -70,6 +90,10 @@ func (k *extraKey) ActiveTimestampKey(keys ...interface{}) string {
 	return buntdb.BilibiliActiveTimestampKey(keys...)
 }
 
+func (k *extraKey) NewsHistoryKey(keys ...interface{}) string {
+	return buntdb.BilibiliNewsHistoryKey(keys...)
+}
+
 func NewKeySet() *keySet {
 	return &keySet{}
 }
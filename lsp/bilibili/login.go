@@ -82,6 +82,7 @@ func Login(username string, password string) (*LoginResponse, error) {
 
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		AddUAOption(),
 		requests.TimeoutOption(time.Second*10),
 		AddReferOption(),
@@ -138,6 +139,7 @@ func GetKey() (*GetKeyResponse, error) {
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		AddUAOption(),
 		AddReferOption(),
 		requests.TimeoutOption(time.Second*10),
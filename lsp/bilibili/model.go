@@ -1,8 +1,12 @@
 package bilibili
 
 import (
+	"fmt"
 	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/image_cache"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/template"
 	localutils "github.com/Sora233/DDBOT/utils"
@@ -11,6 +15,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"strings"
 	"sync"
+	"time"
 )
 
 type NewsInfo struct {
@@ -38,10 +43,31 @@ func (n *NewsInfo) Logger() *logrus.Entry {
 	})
 }
 
+// VideoInfo 只包含视频投稿的 NewsInfo ，推送给只订阅了视频的群
+type VideoInfo struct {
+	NewsInfo
+}
+
+func (v *VideoInfo) Type() concern_type.Type {
+	return Video
+}
+
+func (v *VideoInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":     Site,
+		"Mid":      v.Mid,
+		"Name":     v.Name,
+		"CardSize": len(v.Cards),
+		"Type":     v.Type().String(),
+	})
+}
+
 type ConcernNewsNotify struct {
-	GroupCode int64 `json:"group_code"`
+	Target mmsg.TargetId `json:"target"`
 	*UserInfo
 	Card *CacheCard
+	// ctype 为空时表示 News ，通过视频订阅推送时为 Video
+	ctype concern_type.Type
 
 	// 用于联合投稿和转发的时候防止多人同时推送
 	shouldCompact bool
@@ -58,7 +84,7 @@ func (notify *ConcernNewsNotify) Living() bool {
 }
 
 type ConcernLiveNotify struct {
-	GroupCode int64 `json:"group_code"`
+	Target mmsg.TargetId `json:"target"`
 	*LiveInfo
 }
 
@@ -70,6 +96,205 @@ type UserStat struct {
 	Follower int64 `json:"follower"`
 }
 
+// GuardStat 舰长数和粉丝团人数的快照
+type GuardStat struct {
+	Mid int64 `json:"mid"`
+	// GuardNum 舰长、提督、总督的总数
+	GuardNum int64 `json:"guard_num"`
+	// FansClubNum 粉丝团人数
+	FansClubNum int64 `json:"fans_club_num"`
+	Timestamp   int64 `json:"timestamp"`
+}
+
+// GuardInfo 舰长数或者粉丝团人数达成里程碑时产生的事件
+type GuardInfo struct {
+	UserInfo
+	GuardNum    int64 `json:"guard_num"`
+	FansClubNum int64 `json:"fans_club_num"`
+	// GuardMilestone 本次达成的舰长数里程碑，没有达成时为0
+	GuardMilestone int64 `json:"guard_milestone"`
+	// FansClubMilestone 本次达成的粉丝团人数里程碑，没有达成时为0
+	FansClubMilestone int64 `json:"fans_club_milestone"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (g *GuardInfo) Site() string {
+	return Site
+}
+
+func (g *GuardInfo) Type() concern_type.Type {
+	return Guard
+}
+
+func (g *GuardInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":              Site,
+		"Mid":               g.Mid,
+		"Name":              g.Name,
+		"GuardNum":          g.GuardNum,
+		"FansClubNum":       g.FansClubNum,
+		"GuardMilestone":    g.GuardMilestone,
+		"FansClubMilestone": g.FansClubMilestone,
+		"Type":              g.Type().String(),
+	})
+}
+
+// TemplateData 返回舰长推送模板使用的数据
+func (g *GuardInfo) TemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"uid":                 g.Mid,
+		"name":                g.Name,
+		"url":                 fmt.Sprintf("https://live.bilibili.com/%v", g.RoomId),
+		"guard_num":           g.GuardNum,
+		"fans_club_num":       g.FansClubNum,
+		"guard_milestone":     g.GuardMilestone,
+		"fans_club_milestone": g.FansClubMilestone,
+	}
+}
+
+func (g *GuardInfo) GetMSG() *mmsg.MSG {
+	if g == nil {
+		return nil
+	}
+	g.once.Do(func() {
+		var err error
+		g.msgCache, err = template.LoadAndExec("notify.group.bilibili.guard.tmpl", g.TemplateData())
+		if err != nil {
+			logger.Errorf("bilibili: GuardInfo LoadAndExec error %v", err)
+		}
+	})
+	return g.msgCache
+}
+
+type ConcernGuardNotify struct {
+	Target mmsg.TargetId `json:"target"`
+	*GuardInfo
+}
+
+func (notify *ConcernGuardNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.GuardInfo.GetMSG()
+}
+
+func (notify *ConcernGuardNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.GuardInfo.Logger().
+		WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func (notify *ConcernGuardNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func NewConcernGuardNotify(target mmsg.TargetId, guardInfo *GuardInfo) *ConcernGuardNotify {
+	if guardInfo == nil {
+		return nil
+	}
+	return &ConcernGuardNotify{
+		Target:    target,
+		GuardInfo: guardInfo,
+	}
+}
+
+// ReserveInfo 直播预约，作为事件时 Remind 为false表示发布了新的直播预约，为true表示预约的直播即将开始
+type ReserveInfo struct {
+	UserInfo
+	// Sid 预约的id
+	Sid   string `json:"sid"`
+	Title string `json:"title"`
+	// StartTime 预约的开播时间
+	StartTime int64  `json:"start_time"`
+	DynamicId string `json:"dynamic_id"`
+	// Reminded 为true时已经提醒过或者已经开播，不会再提醒
+	Reminded bool `json:"reminded"`
+	Remind   bool `json:"-"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (r *ReserveInfo) Site() string {
+	return Site
+}
+
+// Type 直播预约推送给订阅了直播的群
+func (r *ReserveInfo) Type() concern_type.Type {
+	return Live
+}
+
+func (r *ReserveInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":      Site,
+		"Mid":       r.Mid,
+		"Name":      r.Name,
+		"Sid":       r.Sid,
+		"StartTime": r.StartTime,
+		"Remind":    r.Remind,
+		"Type":      r.Type().String(),
+	})
+}
+
+// TemplateData 返回直播预约推送模板使用的数据
+func (r *ReserveInfo) TemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"uid":         r.Mid,
+		"name":        r.Name,
+		"title":       r.Title,
+		"start_time":  time.Unix(r.StartTime, 0).Format("2006-01-02 15:04"),
+		"remind":      r.Remind,
+		"url":         fmt.Sprintf("https://live.bilibili.com/%v", r.RoomId),
+		"dynamic_url": DynamicUrl(r.DynamicId),
+	}
+}
+
+func (r *ReserveInfo) GetMSG() *mmsg.MSG {
+	if r == nil {
+		return nil
+	}
+	r.once.Do(func() {
+		var err error
+		r.msgCache, err = template.LoadAndExec("notify.group.bilibili.reserve.tmpl", r.TemplateData())
+		if err != nil {
+			logger.Errorf("bilibili: ReserveInfo LoadAndExec error %v", err)
+		}
+	})
+	return r.msgCache
+}
+
+type ConcernReserveNotify struct {
+	Target mmsg.TargetId `json:"target"`
+	*ReserveInfo
+}
+
+func (notify *ConcernReserveNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.ReserveInfo.GetMSG()
+}
+
+func (notify *ConcernReserveNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.ReserveInfo.Logger().
+		WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func (notify *ConcernReserveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func NewConcernReserveNotify(target mmsg.TargetId, reserveInfo *ReserveInfo) *ConcernReserveNotify {
+	if reserveInfo == nil {
+		return nil
+	}
+	return &ConcernReserveNotify{
+		Target:      target,
+		ReserveInfo: reserveInfo,
+	}
+}
+
 type UserInfo struct {
 	Mid     int64  `json:"mid"`
 	Name    string `json:"name"`
@@ -95,17 +320,24 @@ type LiveInfo struct {
 	Status    LiveStatus `json:"status"`
 	LiveTitle string     `json:"live_title"`
 	Cover     string     `json:"cover"`
+	// UserCover 主播设置的直播间封面， Cover 在部分接口中是直播画面的关键帧，会一直变化，所以用这个字段判断是否更换了封面
+	UserCover string `json:"user_cover,omitempty"`
+	AreaId    int64  `json:"area_id,omitempty"`
+	// AreaName 分区名字，部分接口只返回 AreaId ，这时为空
+	AreaName string `json:"area_name,omitempty"`
+	// Online 直播人气，只在刷新时更新，不会因为人气变化推送
+	Online int64 `json:"online,omitempty"`
 
 	once              sync.Once
 	msgCache          *mmsg.MSG
 	liveStatusChanged bool
 	liveTitleChanged  bool
+	liveCoverChanged  bool
+	liveAreaChanged   bool
 }
 
-func (l *LiveInfo) GetMSG() *mmsg.MSG {
-	if l == nil {
-		return nil
-	}
+// TemplateData 返回直播推送模板使用的数据
+func (l *LiveInfo) TemplateData() map[string]interface{} {
 	// 现在直播url会带一个`?broadcast_type=0`，好像删掉也行
 	cleanRoomUrl := func(url string) string {
 		if pos := strings.Index(url, "?"); pos > 0 {
@@ -113,17 +345,27 @@ func (l *LiveInfo) GetMSG() *mmsg.MSG {
 		}
 		return url
 	}
+	return map[string]interface{}{
+		"uid":    l.Mid,
+		"title":  l.LiveTitle,
+		"name":   l.Name,
+		"url":    cleanRoomUrl(l.RoomUrl),
+		"cover":  l.Cover,
+		"living": l.Living(),
+		"area":   l.AreaName,
+
+		"cover_changed": l.liveCoverChanged,
+		"area_changed":  l.liveAreaChanged,
+	}
+}
+
+func (l *LiveInfo) GetMSG() *mmsg.MSG {
+	if l == nil {
+		return nil
+	}
 	l.once.Do(func() {
-		var data = map[string]interface{}{
-			"uid":    l.Mid,
-			"title":  l.LiveTitle,
-			"name":   l.Name,
-			"url":    cleanRoomUrl(l.RoomUrl),
-			"cover":  l.Cover,
-			"living": l.Living(),
-		}
 		var err error
-		l.msgCache, err = template.LoadAndExec("notify.group.bilibili.live.tmpl", data)
+		l.msgCache, err = template.LoadAndExec("notify.group.bilibili.live.tmpl", l.TemplateData())
 		if err != nil {
 			logger.Errorf("bilibili: LiveInfo LoadAndExec error %v", err)
 		}
@@ -136,6 +378,35 @@ func (l *LiveInfo) TitleChanged() bool {
 	return l.liveTitleChanged
 }
 
+func (l *LiveInfo) CoverChanged() bool {
+	return l.liveCoverChanged
+}
+
+func (l *LiveInfo) AreaChanged() bool {
+	return l.liveAreaChanged
+}
+
+// checkLiveChange 与上一次保存的状态对比，标记直播中是否更换了封面或者分区，
+// 不同的接口返回的字段不一样，没有返回的字段沿用上一次的值，并且不参与比较
+func (l *LiveInfo) checkLiveChange(oldInfo *LiveInfo) {
+	if oldInfo == nil || !oldInfo.Living() || !l.Living() {
+		return
+	}
+	if len(l.UserCover) == 0 {
+		l.UserCover = oldInfo.UserCover
+	} else if len(oldInfo.UserCover) > 0 && oldInfo.UserCover != l.UserCover {
+		l.liveCoverChanged = true
+	}
+	if l.AreaId == 0 {
+		l.AreaId = oldInfo.AreaId
+		l.AreaName = oldInfo.AreaName
+	} else if oldInfo.AreaId != 0 && oldInfo.AreaId != l.AreaId {
+		l.liveAreaChanged = true
+	} else if len(l.AreaName) == 0 {
+		l.AreaName = oldInfo.AreaName
+	}
+}
+
 func (l *LiveInfo) LiveStatusChanged() bool {
 	return l.liveStatusChanged
 }
@@ -179,6 +450,15 @@ func NewUserStat(mid, following, follower int64) *UserStat {
 	}
 }
 
+func NewGuardStat(mid, guardNum, fansClubNum int64) *GuardStat {
+	return &GuardStat{
+		Mid:         mid,
+		GuardNum:    guardNum,
+		FansClubNum: fansClubNum,
+		Timestamp:   time.Now().Unix(),
+	}
+}
+
 func NewUserInfo(mid, roomId int64, name, url string) *UserInfo {
 	return &UserInfo{
 		Mid:     mid,
@@ -226,29 +506,59 @@ func NewNewsInfoWithDetail(userInfo *UserInfo, cards []*Card) *NewsInfo {
 	}
 }
 
-func NewConcernNewsNotify(groupCode int64, newsInfo *NewsInfo, c *Concern) []*ConcernNewsNotify {
+func NewConcernNewsNotify(target mmsg.TargetId, newsInfo *NewsInfo, c *Concern) []*ConcernNewsNotify {
 	if newsInfo == nil {
 		return nil
 	}
 	var result []*ConcernNewsNotify
 	for _, card := range newsInfo.Cards {
 		result = append(result, &ConcernNewsNotify{
-			GroupCode: groupCode,
-			UserInfo:  &newsInfo.UserInfo,
-			Card:      NewCacheCard(card),
-			concern:   c,
+			Target:   target,
+			UserInfo: &newsInfo.UserInfo,
+			Card:     NewCacheCard(card),
+			concern:  c,
 		})
 	}
 	return result
 }
 
-func NewConcernLiveNotify(groupCode int64, liveInfo *LiveInfo) *ConcernLiveNotify {
+// NewVideoInfo 返回newsInfo中的视频投稿，没有视频投稿时返回nil
+func NewVideoInfo(newsInfo *NewsInfo) *VideoInfo {
+	if newsInfo == nil {
+		return nil
+	}
+	var cards []*Card
+	for _, card := range newsInfo.Cards {
+		if card.GetDesc().GetType() == DynamicDescType_WithVideo {
+			cards = append(cards, card)
+		}
+	}
+	if len(cards) == 0 {
+		return nil
+	}
+	var videoInfo = &VideoInfo{NewsInfo: *newsInfo}
+	videoInfo.Cards = cards
+	return videoInfo
+}
+
+func NewConcernVideoNotify(target mmsg.TargetId, videoInfo *VideoInfo, c *Concern) []*ConcernNewsNotify {
+	if videoInfo == nil {
+		return nil
+	}
+	var result = NewConcernNewsNotify(target, &videoInfo.NewsInfo, c)
+	for _, notify := range result {
+		notify.ctype = Video
+	}
+	return result
+}
+
+func NewConcernLiveNotify(target mmsg.TargetId, liveInfo *LiveInfo) *ConcernLiveNotify {
 	if liveInfo == nil {
 		return nil
 	}
 	return &ConcernLiveNotify{
-		GroupCode: groupCode,
-		LiveInfo:  liveInfo,
+		Target:   target,
+		LiveInfo: liveInfo,
 	}
 }
 
@@ -263,7 +573,7 @@ func (notify *ConcernNewsNotify) ToMessage() (m *mmsg.MSG) {
 	if notify.shouldCompact {
 		// 通过回复之前消息的方式简化推送
 		m = mmsg.NewMSG()
-		msg, _ := notify.concern.GetNotifyMsg(notify.GroupCode, notify.compactKey)
+		msg, _ := notify.concern.GetNotifyMsg(notify.Target, notify.compactKey)
 		if msg != nil {
 			m.Append(message.NewReply(msg))
 		}
@@ -293,7 +603,89 @@ func (notify *ConcernNewsNotify) ToMessage() (m *mmsg.MSG) {
 	return
 }
 
+// TemplateData 返回动态推送模板使用的数据
+func (notify *ConcernNewsNotify) TemplateData() map[string]interface{} {
+	var data = map[string]interface{}{
+		"uid":        notify.Mid,
+		"name":       notify.Name,
+		"url":        DynamicUrl(notify.Card.GetDesc().GetDynamicIdStr()),
+		"date":       localutils.TimestampFormat(notify.Card.GetDesc().GetTimestamp()),
+		"dynamic_id": notify.Card.GetDesc().GetDynamicIdStr(),
+	}
+	if cardVideo, err := notify.Card.GetCardWithVideo(); err == nil {
+		extra, _ := notify.Card.GetVideoExtra()
+		data["duration"] = formatVideoDuration(cardVideo.GetDuration())
+		data["tname"] = cardVideo.GetTname()
+		data["resolution"] = extra.Resolution()
+	}
+	return data
+}
+
+// TranslateText 返回动态的正文，转发动态只翻译转发时的评论
+func (notify *ConcernNewsNotify) TranslateText() string {
+	return cardContent(notify.Card.Card)
+}
+
+// DedupKeys 实现 concern.NotifyDedupExt ，视频使用bvid，转发动态使用被转发的动态id，
+// 这样联合投稿的视频、转发同一条动态、转发订阅的账号发布的动态都会被视为相同的内容
+func (notify *ConcernNewsNotify) DedupKeys() []string {
+	desc := notify.Card.GetDesc()
+	var keys []string
+	if len(desc.GetBvid()) > 0 {
+		keys = append(keys, "bv:"+desc.GetBvid())
+	}
+	if desc.GetType() == DynamicDescType_WithOrigin && len(desc.GetOrigDyIdStr()) > 0 && desc.GetOrigDyIdStr() != "0" {
+		keys = append(keys, "dy:"+desc.GetOrigDyIdStr())
+	} else if len(desc.GetDynamicIdStr()) > 0 {
+		keys = append(keys, "dy:"+desc.GetDynamicIdStr())
+	}
+	return keys
+}
+
+// cardContent 返回动态的正文，转发动态只返回转发时的评论
+func cardContent(card *Card) string {
+	switch card.GetDesc().GetType() {
+	case DynamicDescType_TextOnly:
+		cardTextOnly, _ := card.GetCardTextOnly()
+		return cardTextOnly.GetItem().GetContent()
+	case DynamicDescType_WithImage:
+		cardWithImage, _ := card.GetCardWithImage()
+		return cardWithImage.GetItem().GetDescription()
+	case DynamicDescType_WithOrigin:
+		cardWithOrig, _ := card.GetCardWithOrig()
+		return cardWithOrig.GetItem().GetContent()
+	case DynamicDescType_WithVideo:
+		cardWithVideo, _ := card.GetCardWithVideo()
+		return cardWithVideo.GetDynamic()
+	}
+	return ""
+}
+
+// NewNews 把动态转换成 concern.News 保存到历史动态中
+func NewNews(card *Card) *concern.News {
+	var content string
+	switch card.GetDesc().GetType() {
+	case DynamicDescType_WithVideo:
+		cardWithVideo, _ := card.GetCardWithVideo()
+		content = cardWithVideo.GetTitle()
+	case DynamicDescType_WithPost:
+		cardWithPost, _ := card.GetCardWithPost()
+		content = cardWithPost.GetTitle()
+	default:
+		content = cardContent(card)
+	}
+	return &concern.News{
+		Id:        card.GetDesc().GetDynamicIdStr(),
+		Timestamp: card.GetDesc().GetTimestamp(),
+		Content:   content,
+		Url:       DynamicUrl(card.GetDesc().GetDynamicIdStr()),
+	}
+}
+
 func (notify *ConcernNewsNotify) Type() concern_type.Type {
+	if !notify.ctype.Empty() {
+		return notify.ctype
+	}
 	return News
 }
 
@@ -301,8 +693,8 @@ func (notify *ConcernNewsNotify) Site() string {
 	return Site
 }
 
-func (notify *ConcernNewsNotify) GetGroupCode() int64 {
-	return notify.GroupCode
+func (notify *ConcernNewsNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
 }
 func (notify *ConcernNewsNotify) GetUid() interface{} {
 	return notify.Mid
@@ -312,7 +704,7 @@ func (notify *ConcernNewsNotify) Logger() *logrus.Entry {
 	if notify == nil {
 		return logger
 	}
-	return logger.WithFields(localutils.GroupLogFields(notify.GroupCode)).
+	return logger.WithFields(mmsg.TargetLogFields(notify.Target)).
 		WithFields(logrus.Fields{
 			"Site":      Site,
 			"Mid":       notify.Mid,
@@ -332,11 +724,11 @@ func (notify *ConcernLiveNotify) Logger() *logrus.Entry {
 		return logger
 	}
 	return notify.LiveInfo.Logger().
-		WithFields(localutils.GroupLogFields(notify.GroupCode))
+		WithFields(mmsg.TargetLogFields(notify.Target))
 }
 
-func (notify *ConcernLiveNotify) GetGroupCode() int64 {
-	return notify.GroupCode
+func (notify *ConcernLiveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
 }
 
 // combineImageCache 是给combineImage用的cache，其他地方禁止使用
@@ -407,7 +799,7 @@ func urlsMergeImage(urls []string) (result []byte, err error) {
 	cacheR := combineImageCache.WithCacheDo(strings.Join(urls, "+"), func() blockCache.ActionResult {
 		var imgBytes = make([][]byte, len(urls))
 		for index, url := range urls {
-			imgBytes[index], err = localutils.ImageGet(url)
+			imgBytes[index], err = image_cache.Get(url)
 			if err != nil {
 				return blockCache.NewResultWrapper(nil, err)
 			}
@@ -676,10 +1068,17 @@ func (c *CacheCard) prepare() {
 		// web接口好像还区分不了动态视频，先不处理了
 		actionText := card.GetDisplay().GetUsrActionTxt()
 		m.Textf("%v%v：\n%v\n%v\n", name, actionText, date, cardVideo.GetTitle())
+		extra, _ := card.GetVideoExtra()
+		if detail := videoDetail(cardVideo, extra); len(detail) != 0 {
+			m.Textf("%v\n", detail)
+		}
 		if len(description) != 0 {
 			m.Textf("%v\n", description)
 		}
 		m.ImageByUrlWithNorm(cardVideo.GetPic(), "[封面]")
+		if cfg.GetBilibiliVideoFirstFrame() && extra != nil && len(extra.FirstFrame) > 0 {
+			m.ImageByUrlWithNorm(extra.FirstFrame, "[第一帧]")
+		}
 	case DynamicDescType_WithPost:
 		cardPost, err := card.GetCardWithPost()
 		if err != nil {
@@ -2,6 +2,8 @@ package bilibili
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -10,14 +12,14 @@ func TestModelNotify(t *testing.T) {
 	var live *LiveInfo
 	assert.False(t, live.Living())
 	liveNotify := newLiveInfo(test.UID1, true, false, false)
-	liveNotify.GroupCode = test.G1
+	liveNotify.Target = mmsg.NewGroupTargetId(test.G1)
 	m := liveNotify.ToMessage()
 	assert.NotNil(t, m)
 
 	assert.Equal(t, Site, liveNotify.Site())
 	assert.NotNil(t, liveNotify.Logger())
 	assert.NotNil(t, Live, liveNotify.Type())
-	assert.Equal(t, test.G1, liveNotify.GetGroupCode())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), liveNotify.GetTarget())
 	assert.Equal(t, test.UID1, liveNotify.GetUid())
 
 	liveNotify.Status = LiveStatus_NoLiving
@@ -25,13 +27,13 @@ func TestModelNotify(t *testing.T) {
 	assert.NotNil(t, m)
 
 	newsNotify := newNewsInfo(test.UID1, DynamicDescType_TextOnly)[0]
-	newsNotify.GroupCode = test.G2
+	newsNotify.Target = mmsg.NewGroupTargetId(test.G2)
 	assert.NotNil(t, newsNotify)
 	assert.NotNil(t, newsNotify.Logger())
 	assert.Equal(t, Site, newsNotify.Site())
 	assert.Equal(t, News, newsNotify.Type())
 	assert.Equal(t, test.UID1, newsNotify.GetUid())
-	assert.Equal(t, test.G2, newsNotify.GetGroupCode())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G2), newsNotify.GetTarget())
 	m = newsNotify.ToMessage()
 	assert.NotNil(t, m)
 	newsNotify.shouldCompact = true
@@ -43,7 +45,7 @@ func TestModelNotify(t *testing.T) {
 		DynamicDescType_WithPost, DynamicDescType_WithMusic, DynamicDescType_WithSketch, DynamicDescType_WithLive,
 		DynamicDescType_WithLiveV2, DynamicDescType_WithMiss)
 	for _, notify := range notifies {
-		notify.GroupCode = test.G2
+		notify.Target = mmsg.NewGroupTargetId(test.G2)
 		m = notify.ToMessage()
 		assert.NotNil(t, m)
 		notify.Card.Card.Card = "{}"
@@ -56,20 +58,155 @@ func TestModelNotify(t *testing.T) {
 }
 
 func TestNewConcernLiveNotify(t *testing.T) {
-	notify := NewConcernLiveNotify(test.G1, nil)
+	notify := NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), nil)
 	assert.Nil(t, notify)
 	origUserInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
 	origLiveInfo := NewLiveInfo(origUserInfo, "", "", LiveStatus_Living)
-	notify = NewConcernLiveNotify(test.G1, origLiveInfo)
+	notify = NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), origLiveInfo)
 	assert.NotNil(t, notify)
 }
 
 func TestNewConcernNewsNotify(t *testing.T) {
-	notify := NewConcernNewsNotify(test.G1, nil, nil)
+	notify := NewConcernNewsNotify(mmsg.NewGroupTargetId(test.G1), nil, nil)
 	assert.Nil(t, notify)
 	origUserInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
 	origNewsInfo := NewNewsInfo(origUserInfo, test.DynamicID1, test.TIMESTAMP1)
 	origNewsInfo.Cards = []*Card{{}}
-	notify = NewConcernNewsNotify(test.G1, origNewsInfo, nil)
+	notify = NewConcernNewsNotify(mmsg.NewGroupTargetId(test.G1), origNewsInfo, nil)
 	assert.NotNil(t, notify)
 }
+
+func TestConcernNewsNotify_DedupKeys(t *testing.T) {
+	notify := &ConcernNewsNotify{Card: NewCacheCard(&Card{Desc: &Card_Desc{
+		Type:         DynamicDescType_WithVideo,
+		Bvid:         "BV1xx411c7mD",
+		DynamicIdStr: "100",
+	}})}
+	assert.EqualValues(t, []string{"bv:BV1xx411c7mD", "dy:100"}, notify.DedupKeys())
+
+	notify = &ConcernNewsNotify{Card: NewCacheCard(&Card{Desc: &Card_Desc{
+		Type:         DynamicDescType_WithOrigin,
+		DynamicIdStr: "200",
+		OrigDyIdStr:  "100",
+	}})}
+	assert.EqualValues(t, []string{"dy:100"}, notify.DedupKeys())
+
+	notify = &ConcernNewsNotify{Card: NewCacheCard(&Card{Desc: &Card_Desc{
+		Type:         DynamicDescType_TextOnly,
+		DynamicIdStr: "300",
+	}})}
+	assert.EqualValues(t, []string{"dy:300"}, notify.DedupKeys())
+}
+
+func TestLiveInfo_checkLiveChange(t *testing.T) {
+	var newInfo = func(cover string, areaId int64, areaName string) *LiveInfo {
+		info := NewLiveInfo(NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, ""), "title", cover, LiveStatus_Living)
+		info.UserCover = cover
+		info.AreaId = areaId
+		info.AreaName = areaName
+		return info
+	}
+	old := newInfo("c1", 1, "a1")
+
+	info := newInfo("c1", 1, "a1")
+	info.checkLiveChange(old)
+	assert.False(t, info.CoverChanged())
+	assert.False(t, info.AreaChanged())
+
+	info = newInfo("c2", 2, "a2")
+	info.checkLiveChange(old)
+	assert.True(t, info.CoverChanged())
+	assert.True(t, info.AreaChanged())
+	assert.True(t, info.TemplateData()["area_changed"].(bool))
+	assert.Equal(t, "a2", info.TemplateData()["area"])
+	assert.Contains(t, msgstringer.MsgToString(info.GetMSG().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements), "切换到了【a2】分区")
+
+	// 接口没有返回的字段沿用上一次的值
+	info = newInfo("", 0, "")
+	info.checkLiveChange(old)
+	assert.False(t, info.CoverChanged())
+	assert.False(t, info.AreaChanged())
+	assert.Equal(t, "c1", info.UserCover)
+	assert.EqualValues(t, 1, info.AreaId)
+	assert.Equal(t, "a1", info.AreaName)
+
+	info = newInfo("c1", 1, "")
+	info.checkLiveChange(old)
+	assert.Equal(t, "a1", info.AreaName)
+
+	// 不是直播中不比较
+	info = newInfo("c2", 2, "a2")
+	info.Status = LiveStatus_NoLiving
+	info.checkLiveChange(old)
+	assert.False(t, info.CoverChanged())
+	assert.False(t, info.AreaChanged())
+	info = newInfo("c2", 2, "a2")
+	info.checkLiveChange(nil)
+	assert.False(t, info.CoverChanged())
+}
+
+func TestCrossedMilestone(t *testing.T) {
+	var milestones = []int64{10, 50, 100}
+	assert.EqualValues(t, 0, crossedMilestone(1, 9, milestones))
+	assert.EqualValues(t, 10, crossedMilestone(9, 10, milestones))
+	assert.EqualValues(t, 0, crossedMilestone(10, 20, milestones))
+	assert.EqualValues(t, 100, crossedMilestone(9, 120, milestones))
+	// 减少时不推送
+	assert.EqualValues(t, 0, crossedMilestone(60, 40, milestones))
+	assert.EqualValues(t, 0, crossedMilestone(1, 1000, nil))
+}
+
+func TestNewGuardInfo(t *testing.T) {
+	userInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
+
+	assert.Nil(t, newGuardInfo(userInfo, NewGuardStat(test.UID1, 1, 10), NewGuardStat(test.UID1, 2, 20)))
+
+	info := newGuardInfo(userInfo, NewGuardStat(test.UID1, 9, 10), NewGuardStat(test.UID1, 12, 20))
+	if assert.NotNil(t, info) {
+		assert.EqualValues(t, 10, info.GuardMilestone)
+		assert.EqualValues(t, 0, info.FansClubMilestone)
+		assert.Equal(t, Guard, info.Type())
+		assert.Equal(t, Site, info.Site())
+		assert.NotNil(t, info.Logger())
+
+		notify := NewConcernGuardNotify(mmsg.NewGroupTargetId(test.G1), info)
+		assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+		assert.EqualValues(t, test.UID1, notify.GetUid())
+		assert.NotNil(t, notify.Logger())
+		s := msgstringer.MsgToString(notify.ToMessage().Elements())
+		assert.Contains(t, s, "舰长数达到了10")
+		assert.Contains(t, s, "12位舰长")
+		assert.NotContains(t, s, "粉丝团")
+	}
+	assert.Nil(t, NewConcernGuardNotify(mmsg.NewGroupTargetId(test.G1), nil))
+
+	info = newGuardInfo(userInfo, NewGuardStat(test.UID1, 9, 999), NewGuardStat(test.UID1, 10, 1000))
+	if assert.NotNil(t, info) {
+		s := msgstringer.MsgToString(info.GetMSG().Elements())
+		assert.Contains(t, s, "舰长数达到了10")
+		assert.Contains(t, s, "粉丝团人数达到了1000")
+	}
+}
+
+func TestNewConcernVideoNotify(t *testing.T) {
+	assert.Nil(t, NewVideoInfo(nil))
+	assert.Nil(t, NewConcernVideoNotify(mmsg.NewGroupTargetId(test.G1), nil, nil))
+
+	origUserInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
+	origNewsInfo := NewNewsInfo(origUserInfo, test.DynamicID1, test.TIMESTAMP1)
+	origNewsInfo.Cards = []*Card{getCard(DynamicDescType_TextOnly)}
+	assert.Nil(t, NewVideoInfo(origNewsInfo))
+
+	origNewsInfo.Cards = append(origNewsInfo.Cards, getCard(DynamicDescType_WithVideo))
+	videoInfo := NewVideoInfo(origNewsInfo)
+	if assert.NotNil(t, videoInfo) {
+		assert.Equal(t, Video, videoInfo.Type())
+		assert.Len(t, videoInfo.Cards, 1)
+		assert.Len(t, origNewsInfo.Cards, 2)
+	}
+	notifies := NewConcernVideoNotify(mmsg.NewGroupTargetId(test.G1), videoInfo, nil)
+	if assert.Len(t, notifies, 1) {
+		assert.Equal(t, Video, notifies[0].Type())
+		assert.NotNil(t, notifies[0].ToMessage())
+	}
+}
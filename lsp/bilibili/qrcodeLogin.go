@@ -0,0 +1,407 @@
+package bilibili
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	PathPassportQRCodeGenerate = "/x/passport-login/web/qrcode/generate"
+	PathPassportQRCodePoll     = "/x/passport-login/web/qrcode/poll"
+	PathPassportCookieInfo     = "/x/passport-login/web/cookie/info"
+	PathPassportCookieRefresh  = "/x/passport-login/web/cookie/refresh"
+	PathPassportConfirmRefresh = "/x/passport-login/web/confirm/refresh"
+
+	CorrespondUrl = "https://www.bilibili.com/correspond/1"
+)
+
+// 扫码登陆的状态
+const (
+	QRCodeStatusSuccess    = 0
+	QRCodeStatusExpired    = 86038
+	QRCodeStatusScanned    = 86090
+	QRCodeStatusNotScanned = 86101
+)
+
+// correspondPubKey 用于生成刷新cookie时需要的 correspondPath
+const correspondPubKey = `-----BEGIN PUBLIC KEY-----
+MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDLgd2OAkcGVtoE3ThUREbio0Eg
+Uc/prcajMKXvkCKFCWhJYJcLkcM2DKKcSeFpD/j6Boy538YXnR6VhcuUJOhH2x71
+nzPjfdTcqMz7djHum0qSZA0AyCBDABUqCrfNgCiJ00Ra7GmRj+YCK1NJEuewlb40
+JNrRuoEUXpabUzGB8QIDAQAB
+-----END PUBLIC KEY-----`
+
+var (
+	ErrQRCodeExpired = errors.New("二维码已失效")
+
+	refreshCsrfRegex = regexp.MustCompile(`<div id="1-name">(\w+)</div>`)
+)
+
+type QRCodeGenerateResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Url       string `json:"url"`
+		QrcodeKey string `json:"qrcode_key"`
+	} `json:"data"`
+}
+
+func (r *QRCodeGenerateResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+type QRCodePollResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Url          string `json:"url"`
+		RefreshToken string `json:"refresh_token"`
+		Timestamp    int64  `json:"timestamp"`
+		Code         int32  `json:"code"`
+		Message      string `json:"message"`
+	} `json:"data"`
+}
+
+func (r *QRCodePollResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+type CookieInfoResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Refresh   bool  `json:"refresh"`
+		Timestamp int64 `json:"timestamp"`
+	} `json:"data"`
+}
+
+type CookieRefreshResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Status       int32  `json:"status"`
+		Message      string `json:"message"`
+		RefreshToken string `json:"refresh_token"`
+	} `json:"data"`
+}
+
+type ConfirmRefreshResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// QRCodeLoginInfo 扫码登陆获得的cookie，RefreshToken用于在cookie过期前刷新cookie
+type QRCodeLoginInfo struct {
+	CookieInfo   *LoginResponse_Data_CookieInfo `json:"cookie_info"`
+	RefreshToken string                         `json:"refresh_token"`
+}
+
+func passportOptions() []requests.Option {
+	return []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		AddUAOption(),
+		AddReferOption(),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}
+}
+
+// QRCodeGenerate 申请一个登陆二维码，二维码的内容为返回的Url，QrcodeKey用于查询扫码状态
+func QRCodeGenerate() (*QRCodeGenerateResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	resp := new(QRCodeGenerateResponse)
+	err := requests.Get(BPath(PathPassportQRCodeGenerate), nil, resp, passportOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// QRCodePoll 查询扫码状态，登陆成功时会同时返回cookie
+func QRCodePoll(qrcodeKey string) (*QRCodePollResponse, []*http.Cookie, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var cookies []*http.Cookie
+	resp := new(QRCodePollResponse)
+	opts := append(passportOptions(), requests.GetResponseCookieOption(&cookies))
+	err := requests.Get(BPath(PathPassportQRCodePoll), map[string]string{"qrcode_key": qrcodeKey}, resp, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, cookies, nil
+}
+
+// QRCodeLoginWait 每隔interval查询一次扫码状态，直到登陆成功、二维码失效或者ctx结束
+func QRCodeLoginWait(ctx context.Context, qrcodeKey string, interval time.Duration) (*QRCodeLoginInfo, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		resp, cookies, err := QRCodePoll(qrcodeKey)
+		if err != nil {
+			logger.Errorf("QRCodePoll error %v", err)
+			continue
+		}
+		if resp.GetCode() != 0 {
+			return nil, fmt.Errorf("QRCodePoll code %v - %v", resp.GetCode(), resp.Message)
+		}
+		switch resp.Data.Code {
+		case QRCodeStatusSuccess:
+			info := &QRCodeLoginInfo{
+				CookieInfo:   newCookieInfo(cookies),
+				RefreshToken: resp.Data.RefreshToken,
+			}
+			if _, biliJct, _ := parseCookieInfo(info.CookieInfo); len(biliJct) == 0 {
+				return nil, errors.New("扫码登陆成功，但是没有获取到cookie")
+			}
+			return info, nil
+		case QRCodeStatusExpired:
+			return nil, ErrQRCodeExpired
+		case QRCodeStatusScanned, QRCodeStatusNotScanned:
+			continue
+		default:
+			return nil, fmt.Errorf("unknown qrcode status %v - %v", resp.Data.Code, resp.Data.Message)
+		}
+	}
+}
+
+// QRCodeLogin 实现 concern.QRCodeLoginExt ，登陆成功后保存并使用新的cookie
+func (c *Concern) QRCodeLogin() (string, func(ctx context.Context) error, error) {
+	resp, err := QRCodeGenerate()
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.GetCode() != 0 {
+		return "", nil, fmt.Errorf("QRCodeGenerate code %v - %v", resp.GetCode(), resp.Message)
+	}
+	return resp.Data.Url, func(ctx context.Context) error {
+		info, err := QRCodeLoginWait(ctx, resp.Data.QrcodeKey, time.Second*3)
+		if err != nil {
+			return err
+		}
+		return ApplyQRCodeLoginInfo(info)
+	}, nil
+}
+
+// ApplyQRCodeLoginInfo 保存扫码登陆的结果并立即使用该cookie
+func ApplyQRCodeLoginInfo(info *QRCodeLoginInfo) error {
+	SESSDATA, biliJct, _ := parseCookieInfo(info.GetCookieInfo())
+	if len(SESSDATA) == 0 || len(biliJct) == 0 {
+		return errors.New("cookie缺少SESSDATA或bili_jct")
+	}
+	if err := SetQRCodeLoginInfo(info); err != nil {
+		return err
+	}
+	SetVerify(SESSDATA, biliJct)
+	FreshSelfInfo()
+	return nil
+}
+
+func (info *QRCodeLoginInfo) GetCookieInfo() *LoginResponse_Data_CookieInfo {
+	if info == nil {
+		return nil
+	}
+	return info.CookieInfo
+}
+
+func newCookieInfo(cookies []*http.Cookie) *LoginResponse_Data_CookieInfo {
+	var ci = new(LoginResponse_Data_CookieInfo)
+	for _, cookie := range cookies {
+		var httpOnly int32
+		if cookie.HttpOnly {
+			httpOnly = 1
+		}
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		} else if cookie.MaxAge > 0 {
+			expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second).Unix()
+		}
+		ci.Cookies = append(ci.Cookies, &LoginResponse_Data_CookieInfo_Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			HttpOnly: httpOnly,
+			Expires:  expires,
+		})
+	}
+	return ci
+}
+
+// parseCookieInfo 返回cookie中的SESSDATA与bili_jct，以及最早的过期时间，没有过期时间时返回-1
+func parseCookieInfo(cookieInfo *LoginResponse_Data_CookieInfo) (SESSDATA string, biliJct string, expire int64) {
+	expire = -1
+	for _, cookie := range cookieInfo.GetCookies() {
+		if cookie.GetExpires() > 0 && (expire == -1 || expire > cookie.GetExpires()) {
+			expire = cookie.GetExpires()
+		}
+		switch cookie.GetName() {
+		case "SESSDATA":
+			SESSDATA = cookie.GetValue()
+		case "bili_jct":
+			biliJct = cookie.GetValue()
+		}
+	}
+	return
+}
+
+func correspondPath(ts int64) (string, error) {
+	block, _ := pem.Decode([]byte(correspondPubKey))
+	if block == nil {
+		return "", errors.New("pem Decode empty")
+	}
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	pubKey, ok := parsedKey.(*rsa.PublicKey)
+	if !ok {
+		return "", errors.New("parsedKey type error")
+	}
+	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pubKey, []byte(fmt.Sprintf("refresh_%d", ts)), nil)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(encrypted), nil
+}
+
+// RefreshQRCodeCookie 使用扫码登陆时获得的refresh_token刷新cookie，成功后保存并使用新的cookie。
+// force为false时，只有b站提示需要刷新时才会刷新。
+func RefreshQRCodeCookie(info *QRCodeLoginInfo, force bool) error {
+	SESSDATA, biliJct, _ := parseCookieInfo(info.GetCookieInfo())
+	if len(SESSDATA) == 0 || len(biliJct) == 0 || len(info.RefreshToken) == 0 {
+		return errors.New("扫码登陆信息不完整")
+	}
+	cookieOpts := []requests.Option{
+		requests.CookieOption("SESSDATA", SESSDATA),
+		requests.CookieOption("bili_jct", biliJct),
+	}
+
+	cookieInfoResp := new(CookieInfoResponse)
+	err := requests.Get(BPath(PathPassportCookieInfo), map[string]string{"csrf": biliJct}, cookieInfoResp,
+		append(passportOptions(), cookieOpts...)...)
+	if err != nil {
+		return fmt.Errorf("cookie info error %w", err)
+	}
+	if cookieInfoResp.Code != 0 {
+		return fmt.Errorf("cookie info code %v - %v", cookieInfoResp.Code, cookieInfoResp.Message)
+	}
+	if !cookieInfoResp.Data.Refresh && !force {
+		logger.Debug("b站提示cookie无需刷新")
+		return nil
+	}
+
+	path, err := correspondPath(cookieInfoResp.Data.Timestamp)
+	if err != nil {
+		return fmt.Errorf("correspondPath error %w", err)
+	}
+	var html string
+	err = requests.Get(fmt.Sprintf("%v/%v", CorrespondUrl, path), nil, &html,
+		append(passportOptions(), cookieOpts...)...)
+	if err != nil {
+		return fmt.Errorf("correspond error %w", err)
+	}
+	match := refreshCsrfRegex.FindStringSubmatch(html)
+	if len(match) != 2 {
+		return errors.New("没有找到refresh_csrf")
+	}
+
+	var cookies []*http.Cookie
+	refreshResp := new(CookieRefreshResponse)
+	err = requests.PostForm(BPath(PathPassportCookieRefresh), map[string]interface{}{
+		"csrf":          biliJct,
+		"refresh_csrf":  match[1],
+		"source":        "main_web",
+		"refresh_token": info.RefreshToken,
+	}, refreshResp, append(passportOptions(), append(cookieOpts, requests.GetResponseCookieOption(&cookies))...)...)
+	if err != nil {
+		return fmt.Errorf("cookie refresh error %w", err)
+	}
+	if refreshResp.Code != 0 {
+		return fmt.Errorf("cookie refresh code %v - %v", refreshResp.Code, refreshResp.Message)
+	}
+	newInfo := &QRCodeLoginInfo{
+		CookieInfo:   newCookieInfo(cookies),
+		RefreshToken: refreshResp.Data.RefreshToken,
+	}
+	if err = ApplyQRCodeLoginInfo(newInfo); err != nil {
+		return err
+	}
+
+	// 确认刷新后旧的refresh_token才会失效，失败不影响新的cookie使用
+	newSESSDATA, newBiliJct, _ := parseCookieInfo(newInfo.CookieInfo)
+	confirmResp := new(ConfirmRefreshResponse)
+	err = requests.PostForm(BPath(PathPassportConfirmRefresh), map[string]interface{}{
+		"csrf":          newBiliJct,
+		"refresh_token": info.RefreshToken,
+	}, confirmResp, append(passportOptions(),
+		requests.CookieOption("SESSDATA", newSESSDATA),
+		requests.CookieOption("bili_jct", newBiliJct),
+	)...)
+	if err != nil {
+		logger.Errorf("confirm refresh error %v", err)
+	} else if confirmResp.Code != 0 {
+		logger.Errorf("confirm refresh code %v - %v", confirmResp.Code, confirmResp.Message)
+	}
+	return nil
+}
+
+// checkCookieRefresh 检查当前使用的cookie，在过期前自动刷新
+func checkCookieRefresh() {
+	var refreshBefore = cfg.GetBilibiliCookieRefreshBefore()
+	if info, err := GetQRCodeLoginInfo(); err == nil {
+		_, _, expire := parseCookieInfo(info.GetCookieInfo())
+		force := expire > 0 && time.Until(time.Unix(expire, 0)) < refreshBefore
+		if err = RefreshQRCodeCookie(info, force); err != nil {
+			logger.Errorf("刷新b站扫码登陆cookie失败 - %v，如果cookie已经过期，请重新扫码登陆", err)
+		}
+		return
+	}
+	if !IsAccountGiven() {
+		return
+	}
+	ci, err := GetCookieInfo(username)
+	if err != nil {
+		return
+	}
+	_, _, expire := parseCookieInfo(ci)
+	if expire > 0 && time.Until(time.Unix(expire, 0)) < refreshBefore {
+		logger.Info("b站cookie即将过期，将使用帐号重新登陆")
+		if err = ClearCookieInfo(username); err != nil {
+			logger.Errorf("ClearCookieInfo error %v", err)
+		}
+		// 清空后下次请求时会使用帐号重新登陆
+		atomicVerifyInfo.Store(new(VerifyInfo))
+	}
+}
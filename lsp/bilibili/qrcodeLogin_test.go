@@ -0,0 +1,69 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseCookieInfo(t *testing.T) {
+	var expires = time.Now().Add(time.Hour).Truncate(time.Second)
+	ci := newCookieInfo([]*http.Cookie{
+		{Name: "SESSDATA", Value: "sess", HttpOnly: true, Expires: expires},
+		{Name: "bili_jct", Value: "jct", Expires: expires.Add(time.Hour)},
+		{Name: "DedeUserID", Value: "1"},
+	})
+	assert.Len(t, ci.GetCookies(), 3)
+	assert.EqualValues(t, 1, ci.GetCookies()[0].GetHttpOnly())
+
+	SESSDATA, biliJct, expire := parseCookieInfo(ci)
+	assert.EqualValues(t, "sess", SESSDATA)
+	assert.EqualValues(t, "jct", biliJct)
+	assert.EqualValues(t, expires.Unix(), expire)
+
+	SESSDATA, biliJct, expire = parseCookieInfo(nil)
+	assert.Empty(t, SESSDATA)
+	assert.Empty(t, biliJct)
+	assert.EqualValues(t, -1, expire)
+}
+
+func TestCorrespondPath(t *testing.T) {
+	path, err := correspondPath(time.Now().UnixMilli())
+	assert.Nil(t, err)
+	// 1024位的RSA密钥，加密结果为128字节
+	assert.Len(t, path, 256)
+}
+
+func TestQRCodeLoginInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	_, err := GetQRCodeLoginInfo()
+	assert.EqualValues(t, localdb.ErrNotFound, err)
+
+	assert.NotNil(t, SetQRCodeLoginInfo(nil))
+	assert.NotNil(t, ApplyQRCodeLoginInfo(&QRCodeLoginInfo{}))
+
+	info := &QRCodeLoginInfo{
+		CookieInfo: newCookieInfo([]*http.Cookie{
+			{Name: "SESSDATA", Value: "sess"},
+			{Name: "bili_jct", Value: "jct"},
+		}),
+		RefreshToken: "token",
+	}
+	assert.Nil(t, SetQRCodeLoginInfo(info))
+
+	info2, err := GetQRCodeLoginInfo()
+	assert.Nil(t, err)
+	assert.EqualValues(t, "token", info2.RefreshToken)
+	SESSDATA, biliJct, _ := parseCookieInfo(info2.GetCookieInfo())
+	assert.EqualValues(t, "sess", SESSDATA)
+	assert.EqualValues(t, "jct", biliJct)
+
+	assert.Nil(t, ClearQRCodeLoginInfo())
+	_, err = GetQRCodeLoginInfo()
+	assert.EqualValues(t, localdb.ErrNotFound, err)
+}
@@ -50,6 +50,7 @@ func RelationModify(fid int64, act int) (*RelationModifyResponse, error) {
 	var opts []requests.Option
 	opts = append(opts,
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.TimeoutOption(time.Second*10),
 		AddUAOption(),
 		delete412ProxyOption,
@@ -0,0 +1,85 @@
+package bilibili
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+const (
+	PathRoomPlayUrl = "/room/v1/Room/playUrl"
+)
+
+type RoomPlayUrlRequest struct {
+	Cid      int64  `json:"cid"`
+	Qn       int    `json:"qn"`
+	Platform string `json:"platform"`
+}
+
+type RoomPlayUrlResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Durl []struct {
+			Url string `json:"url"`
+		} `json:"durl"`
+	} `json:"data"`
+}
+
+func (r *RoomPlayUrlResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+// RoomPlayUrl 获取直播间的flv直播流地址，qn为10000表示原画
+func RoomPlayUrl(roomId int64) (*RoomPlayUrlResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := BPath(PathRoomPlayUrl)
+	params, err := utils.ToParams(&RoomPlayUrlRequest{
+		Cid:      roomId,
+		Qn:       10000,
+		Platform: "web",
+	})
+	if err != nil {
+		return nil, err
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 15),
+		AddUAOption(),
+		delete412ProxyOption,
+	}
+	opts = append(opts, GetVerifyOption()...)
+	resp := new(RoomPlayUrlResponse)
+	err = requests.Get(url, params, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetStreamUrl 返回直播间第一个可用的直播流地址
+func GetStreamUrl(roomId int64) (string, error) {
+	resp, err := RoomPlayUrl(roomId)
+	if err != nil {
+		return "", err
+	}
+	if resp.GetCode() != 0 {
+		return "", codeError("RoomPlayUrl", resp.GetCode(), resp.Message)
+	}
+	for _, durl := range resp.Data.Durl {
+		if len(durl.Url) > 0 {
+			return durl.Url, nil
+		}
+	}
+	return "", errors.New("直播流地址为空")
+}
@@ -0,0 +1,102 @@
+package bilibili
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"time"
+)
+
+const (
+	PathRoomGetStatusInfoByUids = "/room/v1/Room/get_status_info_by_uids"
+)
+
+type RoomStatusInfo struct {
+	Uid           int64  `json:"uid"`
+	RoomId        int64  `json:"room_id"`
+	Uname         string `json:"uname"`
+	Title         string `json:"title"`
+	LiveStatus    int32  `json:"live_status"`
+	CoverFromUser string `json:"cover_from_user"`
+	Keyframe      string `json:"keyframe"`
+	AreaV2Id      int64  `json:"area_v2_id"`
+	AreaV2Name    string `json:"area_v2_name"`
+	Online        int64  `json:"online"`
+}
+
+// RoomStatusInfoMap uid到直播间信息，查询的uid都没有直播间时b站会返回空数组
+type RoomStatusInfoMap map[string]*RoomStatusInfo
+
+func (m *RoomStatusInfoMap) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '[' {
+		*m = make(RoomStatusInfoMap)
+		return nil
+	}
+	var r map[string]*RoomStatusInfo
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+	*m = r
+	return nil
+}
+
+type RoomStatusInfoResponse struct {
+	Code    int32             `json:"code"`
+	Message string            `json:"message"`
+	Data    RoomStatusInfoMap `json:"data"`
+}
+
+func (r *RoomStatusInfoResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+// LiveInfo 转换成 LiveInfo ，轮播视为未直播
+func (r *RoomStatusInfo) LiveInfo() *LiveInfo {
+	var status = LiveStatus_NoLiving
+	if r.LiveStatus == int32(LiveStatus_Living) {
+		status = LiveStatus_Living
+	}
+	var cover = r.CoverFromUser
+	if cover == "" {
+		cover = r.Keyframe
+	}
+	info := NewLiveInfo(
+		NewUserInfo(r.Uid, r.RoomId, r.Uname, fmt.Sprintf("https://live.bilibili.com/%v", r.RoomId)),
+		r.Title,
+		cover,
+		status,
+	)
+	info.UserCover = r.CoverFromUser
+	info.AreaId = r.AreaV2Id
+	info.AreaName = r.AreaV2Name
+	info.Online = r.Online
+	return info
+}
+
+// RoomGetStatusInfoByUids 一次请求查询多个uid的直播间状态，不需要登陆
+func RoomGetStatusInfoByUids(uids []int64) (*RoomStatusInfoResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := BPath(PathRoomGetStatusInfoByUids)
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 15),
+		AddUAOption(),
+		delete412ProxyOption,
+	}
+	resp := new(RoomStatusInfoResponse)
+	err := requests.PostJson(url, gout.H{"uids": uids}, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
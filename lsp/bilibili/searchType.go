@@ -0,0 +1,82 @@
+package bilibili
+
+import (
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"regexp"
+	"time"
+)
+
+const (
+	PathXWebInterfaceSearchType = "/x/web-interface/wbi/search/type"
+)
+
+// searchHighlightRegexp 搜索结果中关键字会被 <em class="keyword"> 包裹
+var searchHighlightRegexp = regexp.MustCompile(`</?em[^>]*>`)
+
+type SearchUserRequest struct {
+	SearchType string `json:"search_type"`
+	Keyword    string `json:"keyword"`
+	Page       int    `json:"page"`
+}
+
+type SearchUserResponse struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Result []struct {
+			Mid   int64  `json:"mid"`
+			Uname string `json:"uname"`
+			Fans  int64  `json:"fans"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (r *SearchUserResponse) GetCode() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.Code
+}
+
+// SearchUser 使用b站的搜索接口按关键字搜索用户
+func SearchUser(keyword string) (*SearchUserResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := BPath(PathXWebInterfaceSearchType)
+	params, err := utils.ToDatas(&SearchUserRequest{
+		SearchType: "bili_user",
+		Keyword:    keyword,
+		Page:       1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	signWbi(params)
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 15),
+		AddUAOption(),
+		requests.HeaderOption("accept", "application/json"),
+		requests.HeaderOption("referer", "https://search.bilibili.com/"),
+		requests.WithCookieJar(cj.Load()),
+		delete412ProxyOption,
+	}
+	opts = append(opts, GetVerifyOption()...)
+	resp := new(SearchUserResponse)
+	err = requests.Get(url, params, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// cleanSearchHighlight 去掉搜索结果中关键字的高亮标签
+func cleanSearchHighlight(s string) string {
+	return searchHighlightRegexp.ReplaceAllString(s, "")
+}
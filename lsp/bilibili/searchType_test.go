@@ -0,0 +1,12 @@
+package bilibili
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCleanSearchHighlight(t *testing.T) {
+	assert.Equal(t, "abc", cleanSearchHighlight(`<em class="keyword">abc</em>`))
+	assert.Equal(t, "xabcx", cleanSearchHighlight(`x<em class="keyword">abc</em>x`))
+	assert.Equal(t, "abc", cleanSearchHighlight("abc"))
+}
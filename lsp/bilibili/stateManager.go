@@ -4,9 +4,13 @@ import (
 	"errors"
 	"github.com/Mrs4s/MiraiGo/message"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/recorder"
 	localutils "github.com/Sora233/DDBOT/utils"
-	"github.com/tidwall/buntdb"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -16,8 +20,8 @@ type StateManager struct {
 	concern *Concern
 }
 
-func (c *StateManager) GetGroupConcernConfig(groupCode int64, id interface{}) (concernConfig concern.IConfig) {
-	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(groupCode, id), c.concern)
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id), c.concern)
 }
 
 func (c *StateManager) AddUserInfo(userInfo *UserInfo) error {
@@ -29,7 +33,7 @@ func (c *StateManager) AddUserInfo(userInfo *UserInfo) error {
 
 func (c *StateManager) GetUserInfo(mid int64) (*UserInfo, error) {
 	var userInfo = &UserInfo{}
-	err := c.GetJson(c.UserInfoKey(mid), userInfo)
+	err := c.GetJson(c.UserInfoKey(mid), userInfo, localdb.GetCacheOpt())
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +56,216 @@ func (c *StateManager) GetUserStat(mid int64) (*UserStat, error) {
 	return userStat, nil
 }
 
+// AddGuardStat 保存舰长数和粉丝团人数的快照，用于下次查询时判断是否达成了里程碑
+func (c *StateManager) AddGuardStat(guardStat *GuardStat) error {
+	if guardStat == nil {
+		return errors.New("nil GuardStat")
+	}
+	return c.SetJson(c.GuardStatKey(guardStat.Mid), guardStat)
+}
+
+func (c *StateManager) GetGuardStat(mid int64) (*GuardStat, error) {
+	var guardStat = &GuardStat{}
+	err := c.GetJson(c.GuardStatKey(mid), guardStat)
+	if err != nil {
+		return nil, err
+	}
+	return guardStat, nil
+}
+
+func (c *StateManager) DeleteGuardStat(mid int64) error {
+	_, err := c.Delete(c.GuardStatKey(mid), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// AddReserve 保存直播预约，保存到预约的开播时间之后 reserveKeep ，同一个预约只会保存一次，
+// 已经保存过时返回 localdb.ErrRollback
+func (c *StateManager) AddReserve(reserve *ReserveInfo) error {
+	if reserve == nil {
+		return errors.New("nil ReserveInfo")
+	}
+	expire := time.Until(time.Unix(reserve.StartTime, 0)) + reserveKeep
+	if expire <= 0 {
+		return localdb.ErrRollback
+	}
+	return c.SetJson(c.ReserveKey(reserve.Mid, reserve.Sid), reserve,
+		localdb.SetExpireOpt(expire), localdb.SetNoOverWriteOpt())
+}
+
+// ListReserve 返回所有保存的直播预约，包括已经提醒过的
+func (c *StateManager) ListReserve() (result []*ReserveInfo, err error) {
+	err = c.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(c.ReserveKey("*"), func(key, value string) bool {
+			var reserve = new(ReserveInfo)
+			if err := json.Unmarshal([]byte(value), reserve); err != nil {
+				logger.WithField("key", key).Errorf("Unmarshal ReserveInfo error %v", err)
+				return true
+			}
+			result = append(result, reserve)
+			return true
+		})
+	})
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// MarkReserveReminded 把直播预约标记为已经提醒过，sid为*时标记用户所有的直播预约，返回之前没有提醒过的预约
+func (c *StateManager) MarkReserveReminded(mid int64, sid string) (marked []*ReserveInfo, err error) {
+	err = c.RWCoverTx(func(tx localdb.Tx) error {
+		var values = make(map[string]*ReserveInfo)
+		err := tx.AscendKeys(c.ReserveKey(mid, sid), func(key, value string) bool {
+			var reserve = new(ReserveInfo)
+			if err := json.Unmarshal([]byte(value), reserve); err != nil {
+				return true
+			}
+			if !reserve.Reminded {
+				values[key] = reserve
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		for key, reserve := range values {
+			reserve.Reminded = true
+			if err := c.SetJson(key, reserve, localdb.SetKeepLastExpireOpt()); err != nil {
+				return err
+			}
+			marked = append(marked, reserve)
+		}
+		return nil
+	})
+	if err != nil {
+		marked = nil
+	}
+	return
+}
+
+// DeleteReserve 删除用户所有的直播预约
+func (c *StateManager) DeleteReserve(mid int64) error {
+	return c.RWCoverTx(func(tx localdb.Tx) error {
+		var keys []string
+		err := tx.AscendKeys(c.ReserveKey(mid, "*"), func(key, value string) bool {
+			keys = append(keys, key)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _, err := tx.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddStatSample 保存一次粉丝数和直播人气的记录，保存 cfg.GetBilibiliStatsRetention
+func (c *StateManager) AddStatSample(mid int64, sample *concern.StatSample) error {
+	if sample == nil {
+		return errors.New("nil StatSample")
+	}
+	return c.SetJson(c.StatSampleKey(mid, sample.Time), sample, localdb.SetExpireOpt(cfg.GetBilibiliStatsRetention()))
+}
+
+// StartLiveSession 开始记录一次直播，同一个开播时间只会记录一次，已经记录过时返回 localdb.ErrRollback
+func (c *StateManager) StartLiveSession(mid int64, session *concern.LiveSession) error {
+	if session == nil {
+		return errors.New("nil LiveSession")
+	}
+	return c.SetJson(c.LiveSessionKey(mid, session.Start), session,
+		localdb.SetExpireOpt(cfg.GetBilibiliStatsRetention()), localdb.SetNoOverWriteOpt())
+}
+
+// updateLiveSession 对用户所有还没有结束的直播记录执行f，f返回true时保存修改
+func (c *StateManager) updateLiveSession(mid int64, f func(session *concern.LiveSession) bool) error {
+	return c.RWCoverTx(func(tx localdb.Tx) error {
+		var sessions = make(map[string]*concern.LiveSession)
+		err := tx.AscendKeys(c.LiveSessionKey(mid, "*"), func(key, value string) bool {
+			var session = new(concern.LiveSession)
+			if err := json.Unmarshal([]byte(value), session); err != nil {
+				return true
+			}
+			if session.End == 0 {
+				sessions[key] = session
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		for key, session := range sessions {
+			if !f(session) {
+				continue
+			}
+			if err := c.SetJson(key, session, localdb.SetKeepLastExpireOpt()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EndLiveSession 结束用户在end之前开始并且还没有结束的直播记录
+func (c *StateManager) EndLiveSession(mid int64, end time.Time) error {
+	return c.updateLiveSession(mid, func(session *concern.LiveSession) bool {
+		if session.Start >= end.Unix() {
+			return false
+		}
+		session.End = end.Unix()
+		return true
+	})
+}
+
+// UpdateLiveSessionOnline 更新用户还没有结束的直播记录中的最高人气
+func (c *StateManager) UpdateLiveSessionOnline(mid int64, online int64) error {
+	return c.updateLiveSession(mid, func(session *concern.LiveSession) bool {
+		if online <= session.MaxOnline {
+			return false
+		}
+		session.MaxOnline = online
+		return true
+	})
+}
+
+// GetStats 实现 concern.StatsExt
+func (c *StateManager) GetStats(id interface{}, since time.Time) (samples []*concern.StatSample, sessions []*concern.LiveSession, err error) {
+	mid := id.(int64)
+	err = c.RCoverTx(func(tx localdb.Tx) error {
+		err := tx.AscendKeys(c.StatSampleKey(mid, "*"), func(key, value string) bool {
+			var sample = new(concern.StatSample)
+			if err := json.Unmarshal([]byte(value), sample); err == nil && sample.Time >= since.Unix() {
+				samples = append(samples, sample)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return tx.AscendKeys(c.LiveSessionKey(mid, "*"), func(key, value string) bool {
+			var session = new(concern.LiveSession)
+			if err := json.Unmarshal([]byte(value), session); err == nil &&
+				(session.End == 0 || session.End >= since.Unix()) {
+				sessions = append(sessions, session)
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Time < samples[j].Time
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Start < sessions[j].Start
+	})
+	return
+}
+
 func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
 	if liveInfo == nil {
 		return errors.New("nil LiveInfo")
@@ -67,7 +281,7 @@ func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
 
 func (c *StateManager) GetLiveInfo(mid int64) (*LiveInfo, error) {
 	var liveInfo = &LiveInfo{}
-	err := c.GetJson(c.CurrentLiveKey(mid), liveInfo)
+	err := c.GetJson(c.CurrentLiveKey(mid), liveInfo, localdb.GetCacheOpt())
 	if err != nil {
 		return nil, err
 	}
@@ -92,15 +306,21 @@ func (c *StateManager) DeleteNewsInfo(mid int64) error {
 	return err
 }
 
+// DeleteLiveInfo 删除直播信息，同时会停止正在进行的录制，并断开弹幕转发和弹幕提醒的连接
 func (c *StateManager) DeleteLiveInfo(mid int64) error {
+	recorder.Stop(Site, strconv.FormatInt(mid, 10))
+	if c.concern != nil && c.concern.danmakuRelay != nil {
+		c.concern.danmakuRelay.StopMid(mid)
+	}
 	_, err := c.Delete(c.CurrentLiveKey(mid))
 	return err
 }
 
 func (c *StateManager) DeleteNewsAndLiveInfo(mid int64) error {
-	return c.RWCoverTx(func(tx *buntdb.Tx) error {
+	recorder.Stop(Site, strconv.FormatInt(mid, 10))
+	return c.RWCoverTx(func(tx localdb.Tx) error {
 		_, err := tx.Delete(c.CurrentLiveKey(mid))
-		if err != nil && err != buntdb.ErrNotFound {
+		if err != nil && err != localdb.ErrNotFound {
 			return err
 		}
 		_, err = tx.Delete(c.CurrentNewsKey(mid))
@@ -109,7 +329,7 @@ func (c *StateManager) DeleteNewsAndLiveInfo(mid int64) error {
 }
 
 func (c *StateManager) ClearByMid(mid int64) error {
-	return c.RWCoverTx(func(tx *buntdb.Tx) error {
+	return c.RWCoverTx(func(tx localdb.Tx) error {
 		var errs []error
 		_, err := tx.Delete(c.CurrentLiveKey(mid))
 		errs = append(errs, err)
@@ -121,8 +341,10 @@ func (c *StateManager) ClearByMid(mid int64) error {
 		errs = append(errs, err)
 		_, err = tx.Delete(c.NotLiveKey(mid))
 		errs = append(errs, err)
+		_, err = tx.Delete(c.NewsHistoryKey(mid))
+		errs = append(errs, err)
 		for _, e := range errs {
-			if e != nil && e != buntdb.ErrNotFound {
+			if e != nil && e != localdb.ErrNotFound {
 				return e
 			}
 		}
@@ -130,6 +352,60 @@ func (c *StateManager) ClearByMid(mid int64) error {
 	})
 }
 
+// AddNewsHistory 把 NewsInfo 中的动态保存到历史动态中，每个用户最多保存 cfg.GetBilibiliNewsHistorySize 条，
+// 超过 cfg.GetBilibiliNewsHistoryTTL 没有新动态时历史动态会被删除
+func (c *StateManager) AddNewsHistory(newsInfo *NewsInfo) error {
+	if newsInfo == nil {
+		return errors.New("nil NewsInfo")
+	}
+	size := cfg.GetBilibiliNewsHistorySize()
+	if size <= 0 || len(newsInfo.Cards) == 0 {
+		return nil
+	}
+	return c.RWCover(func() error {
+		var history []*concern.News
+		err := c.GetJson(c.NewsHistoryKey(newsInfo.Mid), &history)
+		if err != nil && !localdb.IsNotFound(err) {
+			return err
+		}
+		var exists = make(map[string]bool)
+		for _, news := range history {
+			exists[news.Id] = true
+		}
+		for _, card := range newsInfo.Cards {
+			news := NewNews(card)
+			if exists[news.Id] {
+				continue
+			}
+			exists[news.Id] = true
+			history = append(history, news)
+		}
+		sort.SliceStable(history, func(i, j int) bool {
+			return history[i].Timestamp > history[j].Timestamp
+		})
+		if len(history) > size {
+			history = history[:size]
+		}
+		return c.SetJson(c.NewsHistoryKey(newsInfo.Mid), history, localdb.SetExpireOpt(cfg.GetBilibiliNewsHistoryTTL()))
+	})
+}
+
+// GetRecentNews 实现 concern.NewsHistoryExt
+func (c *StateManager) GetRecentNews(id interface{}, n int) ([]*concern.News, error) {
+	var history []*concern.News
+	err := c.GetJson(c.NewsHistoryKey(id.(int64)), &history)
+	if localdb.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n >= 0 && len(history) > n {
+		history = history[:n]
+	}
+	return history, nil
+}
+
 func (c *StateManager) GetNewsInfo(mid int64) (*NewsInfo, error) {
 	var newsInfo = &NewsInfo{}
 	err := c.GetJson(c.CurrentNewsKey(mid), newsInfo)
@@ -139,9 +415,28 @@ func (c *StateManager) GetNewsInfo(mid int64) (*NewsInfo, error) {
 	return newsInfo, nil
 }
 
+// GetState 实现 concern.StateExt
+func (c *StateManager) GetState(id interface{}) *concern.State {
+	mid := id.(int64)
+	var state *concern.State
+	if liveInfo, err := c.GetLiveInfo(mid); err == nil {
+		state = &concern.State{
+			Living: liveInfo.Living(),
+			Title:  liveInfo.LiveTitle,
+		}
+	}
+	if newsInfo, err := c.GetNewsInfo(mid); err == nil {
+		if state == nil {
+			state = new(concern.State)
+		}
+		state.LastNewsTime = newsInfo.Timestamp
+	}
+	return state
+}
+
 func (c *StateManager) CheckDynamicId(dynamic int64) (result bool) {
 	_, err := c.Get(c.DynamicIdKey(dynamic))
-	if err == buntdb.ErrNotFound {
+	if err == localdb.ErrNotFound {
 		return true
 	}
 	return false
@@ -150,7 +445,7 @@ func (c *StateManager) CheckDynamicId(dynamic int64) (result bool) {
 func (c *StateManager) MarkDynamicId(dynamic int64) (bool, error) {
 	//	一个错误的写法，用闭包返回值简单地替代了RWTxCover返回值
 	//	在磁盘空间用尽的情况下，闭包可以成功执行，但RWTxCover执行持久化时会报错，这个错误就被意外地忽略了
-	//	c.RWCoverTx(func(tx *buntdb.Tx) error {
+	//	c.RWCoverTx(func(tx localdb.Tx) error {
 	//		key := c.DynamicIdKey(dynamic)
 	//		_, replaced, err = tx.Set(key, "", localdb.ExpireOption(time.Hour*120))
 	//		return err
@@ -187,8 +482,16 @@ func (c *StateManager) GetUidFirstTimestamp(uid int64) (timestamp int64, err err
 	return c.GetInt64(c.UidFirstTimestamp(uid))
 }
 
-func (c *StateManager) SetGroupCompactMarkIfNotExist(groupCode int64, compactKey string) error {
-	return c.Set(c.CompactMarkKey(groupCode, compactKey), "",
+// GroupKeyPrefix 额外包含合并推送标记和推送消息的key前缀
+func (c *StateManager) GroupKeyPrefix(target mmsg.TargetId) []string {
+	return append(c.StateManager.GroupKeyPrefix(target),
+		c.CompactMarkKey(target),
+		c.NotifyMsgKey(target),
+	)
+}
+
+func (c *StateManager) SetGroupCompactMarkIfNotExist(target mmsg.TargetId, compactKey string) error {
+	return c.Set(c.CompactMarkKey(target, compactKey), "",
 		localdb.SetExpireOpt(CompactExpireTime), localdb.SetNoOverWriteOpt())
 }
 func (c *StateManager) SetLastFreshTime(ts int64) error {
@@ -217,7 +520,7 @@ func (c *StateManager) GetLatestActive(mid int64) (int64, error) {
 	return c.GetInt64(c.ActiveTimestampKey(mid), localdb.IgnoreNotFoundOpt())
 }
 
-func (c *StateManager) SetNotifyMsg(notifyKey string, msg *message.GroupMessage) error {
+func (c *StateManager) SetNotifyMsg(target mmsg.TargetId, notifyKey string, msg *message.GroupMessage) error {
 	tmp := &message.GroupMessage{
 		Id:        msg.Id,
 		GroupCode: msg.GroupCode,
@@ -231,12 +534,12 @@ func (c *StateManager) SetNotifyMsg(notifyKey string, msg *message.GroupMessage)
 	if err != nil {
 		return err
 	}
-	return c.Set(c.NotifyMsgKey(tmp.GroupCode, notifyKey), value,
+	return c.Set(c.NotifyMsgKey(target, notifyKey), value,
 		localdb.SetExpireOpt(CompactExpireTime), localdb.SetNoOverWriteOpt())
 }
 
-func (c *StateManager) GetNotifyMsg(groupCode int64, notifyKey string) (*message.GroupMessage, error) {
-	value, err := c.Get(c.NotifyMsgKey(groupCode, notifyKey))
+func (c *StateManager) GetNotifyMsg(target mmsg.TargetId, notifyKey string) (*message.GroupMessage, error) {
+	value, err := c.Get(c.NotifyMsgKey(target, notifyKey))
 	if err != nil {
 		return nil, err
 	}
@@ -269,6 +572,24 @@ func ClearCookieInfo(username string) error {
 	return err
 }
 
+// SetQRCodeLoginInfo 保存扫码登陆的cookie，与帐号登陆不同，这里不设置过期时间，过期前会使用refresh_token刷新
+func SetQRCodeLoginInfo(info *QRCodeLoginInfo) error {
+	if info == nil {
+		return errors.New("<nil> info")
+	}
+	return localdb.SetJson(localdb.BilibiliQRCodeLoginInfoKey(), info)
+}
+
+func GetQRCodeLoginInfo() (info *QRCodeLoginInfo, err error) {
+	err = localdb.GetJson(localdb.BilibiliQRCodeLoginInfoKey(), &info)
+	return
+}
+
+func ClearQRCodeLoginInfo() error {
+	_, err := localdb.Delete(localdb.BilibiliQRCodeLoginInfoKey(), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
 func (c *StateManager) Start() error {
 	for _, pattern := range []localdb.KeyPatternFunc{
 		c.GroupConcernStateKey, c.CurrentLiveKey, c.FreshKey,
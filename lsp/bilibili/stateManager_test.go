@@ -5,8 +5,9 @@ import (
 	"github.com/Mrs4s/MiraiGo/message"
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"testing"
 	"time"
 )
@@ -14,7 +15,7 @@ import (
 func initStateManager(t *testing.T) *StateManager {
 	sm := NewStateManager(NewConcern(nil))
 	assert.NotNil(t, sm)
-	sm.FreshIndex(test.G1, test.G2)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
 	return sm
 }
 
@@ -24,7 +25,7 @@ func TestNewStateManager(t *testing.T) {
 
 	sm := initStateManager(t)
 	assert.NotNil(t, sm)
-	assert.NotNil(t, sm.GetGroupConcernConfig(test.G1, test.UID1))
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1))
 }
 
 func TestStateManager_GetUserInfo(t *testing.T) {
@@ -67,14 +68,14 @@ func TestStateManager_GetLiveInfo(t *testing.T) {
 	assert.EqualValues(t, origLiveInfo, liveInfo)
 
 	liveInfo, err = c.GetLiveInfo(test.UID2)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 	assert.Nil(t, liveInfo)
 
 	err = c.DeleteLiveInfo(test.UID1)
 	assert.Nil(t, err)
 
 	liveInfo, err = c.GetLiveInfo(test.UID1)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 	assert.Nil(t, liveInfo)
 
 	assert.NotNil(t, c.AddLiveInfo(nil))
@@ -103,19 +104,74 @@ func TestStateManager_GetNewsInfo(t *testing.T) {
 	assert.EqualValues(t, newsInfo, origNewsInfo)
 
 	newsInfo, err = c.GetNewsInfo(test.UID2)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 	assert.Nil(t, newsInfo)
 
 	err = c.DeleteNewsInfo(test.UID1)
 	assert.Nil(t, err)
 
 	newsInfo, err = c.GetNewsInfo(test.UID1)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 	assert.Nil(t, newsInfo)
 
 	assert.NotNil(t, c.AddNewsInfo(nil))
 }
 
+func TestStateManager_NewsHistory(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	defer config.GlobalConfig.Set("bilibili.newsHistory", nil)
+
+	c := initStateManager(t)
+
+	var newCard = func(id int64, content string) *Card {
+		return &Card{
+			Card: fmt.Sprintf(`{"item":{"content":"%v"}}`, content),
+			Desc: &Card_Desc{
+				Type:         DynamicDescType_TextOnly,
+				DynamicId:    id,
+				DynamicIdStr: fmt.Sprint(id),
+				Timestamp:    id,
+			},
+		}
+	}
+	userInfo := NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, "")
+
+	history, err := c.GetRecentNews(test.UID1, 3)
+	assert.Nil(t, err)
+	assert.Empty(t, history)
+
+	assert.NotNil(t, c.AddNewsHistory(nil))
+	config.GlobalConfig.Set("bilibili.newsHistory.size", 3)
+	assert.Nil(t, c.AddNewsHistory(NewNewsInfoWithDetail(userInfo, []*Card{newCard(2, "b"), newCard(1, "a")})))
+	assert.Nil(t, c.AddNewsHistory(NewNewsInfoWithDetail(userInfo, []*Card{newCard(4, "d"), newCard(3, "c"), newCard(2, "b")})))
+
+	history, err = c.GetRecentNews(test.UID1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, "4", history[0].Id)
+	assert.Equal(t, "d", history[0].Content)
+	assert.Equal(t, DynamicUrl("4"), history[0].Url)
+	assert.Equal(t, "2", history[2].Id)
+
+	history, err = c.GetRecentNews(test.UID1, 1)
+	assert.Nil(t, err)
+	assert.Len(t, history, 1)
+	assert.EqualValues(t, 4, history[0].Timestamp)
+
+	// 设置为0时不保存
+	config.GlobalConfig.Set("bilibili.newsHistory.size", 0)
+	assert.Nil(t, c.AddNewsHistory(NewNewsInfoWithDetail(userInfo, []*Card{newCard(5, "e")})))
+	history, err = c.GetRecentNews(test.UID1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "4", history[0].Id)
+
+	assert.Nil(t, c.ClearByMid(test.UID1))
+	history, err = c.GetRecentNews(test.UID1, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, history)
+}
+
 func TestStateManager_DeleteNewsAndLiveInfo(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
@@ -191,7 +247,7 @@ func TestStateManager_SetUidFirstTimestampIfNotExist(t *testing.T) {
 	c := initStateManager(t)
 
 	_, err := c.GetUidFirstTimestamp(test.UID2)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 
 	assert.Nil(t, c.SetUidFirstTimestampIfNotExist(test.UID1, test.TIMESTAMP1))
 
@@ -207,7 +263,7 @@ func TestStateManager_SetUidFirstTimestampIfNotExist(t *testing.T) {
 	assert.Nil(t, c.UnsetUidFirstTimestamp(test.UID1))
 
 	ts1, err = c.GetUidFirstTimestamp(test.UID1)
-	assert.Equal(t, buntdb.ErrNotFound, err)
+	assert.Equal(t, localdb.ErrNotFound, err)
 }
 
 func TestStateManager_ClearByMid(t *testing.T) {
@@ -254,7 +310,7 @@ func TestGetCookieInfo(t *testing.T) {
 	_ = initStateManager(t)
 
 	cookieInfo, err := GetCookieInfo(test.NAME1)
-	assert.EqualValues(t, buntdb.ErrNotFound, err)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
 	assert.Nil(t, cookieInfo)
 
 	err = SetCookieInfo(test.NAME1, &LoginResponse_Data_CookieInfo{
@@ -292,7 +348,7 @@ func TestGetCookieInfo(t *testing.T) {
 	assert.Nil(t, err)
 
 	_, err = GetCookieInfo(test.NAME2)
-	assert.EqualValues(t, buntdb.ErrNotFound, err)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
 }
 
 func TestStateManager_GetUserStat(t *testing.T) {
@@ -340,9 +396,9 @@ func TestStateManager_GetNotifyMsgReplyInfo(t *testing.T) {
 		},
 	}
 
-	err := c.SetNotifyMsg(test.BVID1, msg)
+	err := c.SetNotifyMsg(mmsg.NewGroupTargetId(test.G1), test.BVID1, msg)
 	assert.Nil(t, err)
-	actual, err := c.GetNotifyMsg(test.G1, test.BVID1)
+	actual, err := c.GetNotifyMsg(mmsg.NewGroupTargetId(test.G1), test.BVID1)
 	assert.Nil(t, err)
 	assert.EqualValues(t, actual, msg)
 }
@@ -353,8 +409,8 @@ func TestStateManager_SetGroupCompactMarkIfNotExist(t *testing.T) {
 
 	c := initStateManager(t)
 
-	assert.Nil(t, c.SetGroupCompactMarkIfNotExist(test.G1, test.BVID1))
-	assert.NotNil(t, c.SetGroupCompactMarkIfNotExist(test.G1, test.BVID1))
+	assert.Nil(t, c.SetGroupCompactMarkIfNotExist(mmsg.NewGroupTargetId(test.G1), test.BVID1))
+	assert.NotNil(t, c.SetGroupCompactMarkIfNotExist(mmsg.NewGroupTargetId(test.G1), test.BVID1))
 }
 
 func TestStateManager_GetLastFreshTime(t *testing.T) {
@@ -403,3 +459,73 @@ func TestStateManager_GetLatestActiveTimestamp(t *testing.T) {
 	assert.Nil(t, err)
 	assert.EqualValues(t, test.TIMESTAMP1+20, ts)
 }
+
+func TestStateManager_GuardStat(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	c := initStateManager(t)
+
+	_, err := c.GetGuardStat(test.UID1)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
+
+	assert.NotNil(t, c.AddGuardStat(nil))
+	assert.Nil(t, c.AddGuardStat(NewGuardStat(test.UID1, 10, 200)))
+
+	guardStat, err := c.GetGuardStat(test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, test.UID1, guardStat.Mid)
+	assert.EqualValues(t, 10, guardStat.GuardNum)
+	assert.EqualValues(t, 200, guardStat.FansClubNum)
+	assert.NotZero(t, guardStat.Timestamp)
+
+	assert.Nil(t, c.DeleteGuardStat(test.UID1))
+	assert.Nil(t, c.DeleteGuardStat(test.UID1))
+	_, err = c.GetGuardStat(test.UID1)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
+}
+
+func TestStateManager_Reserve(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	c := initStateManager(t)
+
+	var newReserve = func(sid string, start time.Time) *ReserveInfo {
+		return &ReserveInfo{
+			UserInfo:  *NewUserInfo(test.UID1, test.ROOMID1, test.NAME1, ""),
+			Sid:       sid,
+			StartTime: start.Unix(),
+		}
+	}
+
+	assert.NotNil(t, c.AddReserve(nil))
+	assert.Nil(t, c.AddReserve(newReserve("1", time.Now().Add(time.Hour))))
+	assert.Nil(t, c.AddReserve(newReserve("2", time.Now().Add(time.Hour*2))))
+	assert.True(t, localdb.IsRollback(c.AddReserve(newReserve("1", time.Now().Add(time.Hour)))))
+	// 已经过期的预约不保存
+	assert.True(t, localdb.IsRollback(c.AddReserve(newReserve("3", time.Now().Add(-time.Hour)))))
+
+	reserves, err := c.ListReserve()
+	assert.Nil(t, err)
+	assert.Len(t, reserves, 2)
+
+	marked, err := c.MarkReserveReminded(test.UID1, "1")
+	assert.Nil(t, err)
+	assert.Len(t, marked, 1)
+	marked, err = c.MarkReserveReminded(test.UID1, "1")
+	assert.Nil(t, err)
+	assert.Len(t, marked, 0)
+	// 标记后仍然保存，避免重复保存
+	assert.True(t, localdb.IsRollback(c.AddReserve(newReserve("1", time.Now().Add(time.Hour)))))
+
+	marked, err = c.MarkReserveReminded(test.UID1, "*")
+	assert.Nil(t, err)
+	assert.Len(t, marked, 1)
+	assert.EqualValues(t, "2", marked[0].Sid)
+
+	assert.Nil(t, c.DeleteReserve(test.UID1))
+	reserves, err = c.ListReserve()
+	assert.Nil(t, err)
+	assert.Empty(t, reserves)
+}
@@ -0,0 +1,137 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"sort"
+	"strings"
+)
+
+// AddBlocklistId 禁止订阅网站的一个id，已经添加过时返回 localdb.ErrKeyExist
+func (s *StateManager) AddBlocklistId(site string, id interface{}) error {
+	err := s.Set(s.BlocklistIdKey(site, fmt.Sprint(id)), "", localdb.SetNoOverWriteOpt())
+	if localdb.IsRollback(err) {
+		err = localdb.ErrKeyExist
+	}
+	return err
+}
+
+// DeleteBlocklistId 解除禁止订阅，没有添加过时返回 localdb.ErrNotFound
+func (s *StateManager) DeleteBlocklistId(site string, id interface{}) error {
+	_, err := s.Delete(s.BlocklistIdKey(site, fmt.Sprint(id)))
+	return err
+}
+
+// CheckBlocklistId 返回网站的这个id是否已被禁止订阅
+func (s *StateManager) CheckBlocklistId(site string, id interface{}) bool {
+	return s.Exist(s.BlocklistIdKey(site, fmt.Sprint(id)))
+}
+
+// ListBlocklistId 返回每个网站被禁止订阅的id
+func (s *StateManager) ListBlocklistId() (result map[string][]string, err error) {
+	result = make(map[string][]string)
+	prefix := s.BlocklistIdKey() + ":"
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(s.BlocklistIdKey("*"), func(key, value string) bool {
+			// id中可能包含冒号，只切分出网站
+			splits := strings.SplitN(strings.TrimPrefix(key, prefix), ":", 2)
+			if len(splits) != 2 {
+				return true
+			}
+			result[splits[0]] = append(result[splits[0]], splits[1])
+			return true
+		})
+	})
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// AddBlocklistKeyword 添加屏蔽关键词，包含关键词的推送不会发送，已经添加过时返回 localdb.ErrKeyExist
+func (s *StateManager) AddBlocklistKeyword(keyword string) error {
+	err := s.Set(s.BlocklistKeywordKey(keyword), "", localdb.SetNoOverWriteOpt())
+	if localdb.IsRollback(err) {
+		err = localdb.ErrKeyExist
+	}
+	return err
+}
+
+// DeleteBlocklistKeyword 删除屏蔽关键词，没有添加过时返回 localdb.ErrNotFound
+func (s *StateManager) DeleteBlocklistKeyword(keyword string) error {
+	_, err := s.Delete(s.BlocklistKeywordKey(keyword))
+	return err
+}
+
+// ListBlocklistKeyword 返回所有屏蔽关键词
+func (s *StateManager) ListBlocklistKeyword() (result []string, err error) {
+	prefix := s.BlocklistKeywordKey() + ":"
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(s.BlocklistKeywordKey("*"), func(key, value string) bool {
+			result = append(result, strings.TrimPrefix(key, prefix))
+			return true
+		})
+	})
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// MatchBlocklistKeyword 返回text中包含的第一个屏蔽关键词，不包含时返回空字符串
+func (s *StateManager) MatchBlocklistKeyword(text string) string {
+	keywords, err := s.ListBlocklistKeyword()
+	if err != nil {
+		logger.Errorf("ListBlocklistKeyword error %v", err)
+		return ""
+	}
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// blocklistNotify 推送的账号被禁止订阅，或者推送内容包含屏蔽关键词时返回true，这条推送不应该发送
+func (l *Lsp) blocklistNotify(inotify concern.Notify, m *mmsg.MSG) bool {
+	if l.LspStateManager.CheckBlocklistId(inotify.Site(), inotify.GetUid()) {
+		inotify.Logger().Info("该账号已被管理员禁止订阅，跳过本次推送")
+		return true
+	}
+	if keyword := l.LspStateManager.MatchBlocklistKeyword(msgstringer.MsgToString(m.Elements())); keyword != "" {
+		inotify.Logger().WithField("Keyword", keyword).Info("推送内容包含屏蔽关键词，跳过本次推送")
+		return true
+	}
+	return false
+}
+
+// formatBlocklist 格式化为 /blocklist list 的回复内容
+func formatBlocklist(ids map[string][]string, keywords []string) string {
+	if len(ids) == 0 && len(keywords) == 0 {
+		return "当前没有禁止订阅的id和屏蔽关键词"
+	}
+	var sb strings.Builder
+	sb.WriteString("禁止订阅的id：")
+	if len(ids) == 0 {
+		sb.WriteString("无")
+	}
+	var sites []string
+	for site := range ids {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	for _, site := range sites {
+		sb.WriteString(fmt.Sprintf("\n%v：%v", site, strings.Join(ids[site], " ")))
+	}
+	sb.WriteString("\n屏蔽关键词：")
+	if len(keywords) == 0 {
+		sb.WriteString("无")
+	} else {
+		sb.WriteString(strings.Join(keywords, " "))
+	}
+	return sb.String()
+}
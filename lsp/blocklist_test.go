@@ -0,0 +1,95 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateManager_Blocklist(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.False(t, sm.CheckBlocklistId(test.Site1, test.UID1))
+	assert.Nil(t, sm.AddBlocklistId(test.Site1, test.UID1))
+	assert.Equal(t, localdb.ErrKeyExist, sm.AddBlocklistId(test.Site1, test.UID1))
+	assert.Nil(t, sm.AddBlocklistId(test.Site2, "a:b"))
+	assert.True(t, sm.CheckBlocklistId(test.Site1, test.UID1))
+	assert.False(t, sm.CheckBlocklistId(test.Site2, test.UID1))
+
+	ids, err := sm.ListBlocklistId()
+	assert.Nil(t, err)
+	assert.Len(t, ids, 2)
+	assert.EqualValues(t, []string{"a:b"}, ids[test.Site2])
+
+	assert.Nil(t, sm.DeleteBlocklistId(test.Site1, test.UID1))
+	assert.True(t, localdb.IsNotFound(sm.DeleteBlocklistId(test.Site1, test.UID1)))
+	assert.False(t, sm.CheckBlocklistId(test.Site1, test.UID1))
+
+	assert.Empty(t, sm.MatchBlocklistKeyword("广告内容"))
+	assert.Nil(t, sm.AddBlocklistKeyword("广告"))
+	assert.Equal(t, localdb.ErrKeyExist, sm.AddBlocklistKeyword("广告"))
+	assert.Equal(t, "广告", sm.MatchBlocklistKeyword("广告内容"))
+	assert.Empty(t, sm.MatchBlocklistKeyword("正常内容"))
+
+	keywords, err := sm.ListBlocklistKeyword()
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"广告"}, keywords)
+	assert.Nil(t, sm.DeleteBlocklistKeyword("广告"))
+	assert.Empty(t, sm.MatchBlocklistKeyword("广告内容"))
+
+	assert.Equal(t, "当前没有禁止订阅的id和屏蔽关键词", formatBlocklist(nil, nil))
+	assert.Equal(t, "禁止订阅的id：\nsite1：1 2\n屏蔽关键词：无",
+		formatBlocklist(map[string][]string{"site1": {"1", "2"}}, nil))
+}
+
+func TestLsp_BlocklistNotify(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	l := &Lsp{LspStateManager: newStateManager(t)}
+	tc1 := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	notify := tc1.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)
+
+	assert.False(t, l.blocklistNotify(notify, mmsg.NewText("广告内容")))
+	assert.Nil(t, l.LspStateManager.AddBlocklistKeyword("广告"))
+	assert.True(t, l.blocklistNotify(notify, mmsg.NewText("广告内容")))
+	assert.False(t, l.blocklistNotify(notify, mmsg.NewText("正常内容")))
+
+	assert.Nil(t, l.LspStateManager.AddBlocklistId(test.Site1, test.NAME1))
+	assert.True(t, l.blocklistNotify(notify, mmsg.NewText("正常内容")))
+}
+
+func TestIWatch_Blocklist(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	tc1 := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer concern.ClearConcern()
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+	assert.Nil(t, Instance.LspStateManager.AddBlocklistId(test.Site1, test.NAME1))
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	assert.Contains(t, reply(), "禁止订阅")
+
+	assert.Nil(t, Instance.LspStateManager.DeleteBlocklistId(test.Site1, test.NAME1))
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	assert.Contains(t, reply(), success)
+}
@@ -0,0 +1,37 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+// SubscribeBreaker 在事件总线中订阅网站暂停刷新和恢复刷新的事件，私聊通知所有管理员
+func (l *Lsp) SubscribeBreaker() {
+	concern.Subscribe("breaker", l.onBreakerEvent, concern.TopicBreaker)
+}
+
+func (l *Lsp) onBreakerEvent(e *concern.BusEvent) {
+	if e.Breaker == nil {
+		return
+	}
+	m := mmsg.NewText(formatBreakerEvent(e.Breaker))
+	for _, admin := range l.PermissionStateManager.ListAdmin() {
+		if localutils.GetBot().FindFriend(admin) == nil {
+			continue
+		}
+		logger.WithField("Target", admin).WithField("Site", e.Breaker.Site).
+			WithField("State", e.Breaker.State.String()).Info("breaker notify")
+		l.SendMsg(m, mmsg.NewPrivateTarget(admin))
+	}
+}
+
+func formatBreakerEvent(e *concern.BreakerEvent) string {
+	if e.State == concern.BreakerOpen {
+		return fmt.Sprintf("DDBOT管理员您好，%v触发了风控或者连续刷新失败，已暂停该网站的所有刷新%v，之后会降低刷新频率并逐步恢复，期间的推送可能会延迟。\n错误：%v",
+			e.Site, e.Pause.Round(time.Second), e.Reason)
+	}
+	return fmt.Sprintf("DDBOT管理员您好，%v的刷新已经完全恢复正常", e.Site)
+}
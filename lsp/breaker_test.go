@@ -0,0 +1,21 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFormatBreakerEvent(t *testing.T) {
+	open := formatBreakerEvent(&concern.BreakerEvent{
+		Site:   "bilibili",
+		State:  concern.BreakerOpen,
+		Pause:  time.Minute + time.Millisecond*300,
+		Reason: "code -412",
+	})
+	assert.Contains(t, open, "bilibili")
+	assert.Contains(t, open, "1m0s")
+	assert.Contains(t, open, "code -412")
+	assert.Contains(t, formatBreakerEvent(&concern.BreakerEvent{Site: "bilibili", State: concern.BreakerClosed}), "完全恢复")
+}
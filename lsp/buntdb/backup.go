@@ -0,0 +1,145 @@
+package buntdb
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofrs/flock"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	BackupPrefix = "ddbot-backup-"
+	BackupSuffix = ".db"
+)
+
+// Backup 将当前数据库的快照保存到dir目录下，文件名带有时间戳，返回备份文件的路径
+// 快照通过 Storage.Save 生成，格式与 buntdb 的数据文件相同
+func Backup(dir string) (string, error) {
+	if db == nil {
+		return "", ErrNotInitialized
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	var path = filepath.Join(dir, BackupPrefix+time.Now().Format("20060102-150405")+BackupSuffix)
+	// 先写入临时文件，避免留下不完整的备份
+	f, err := os.CreateTemp(dir, BackupPrefix+"*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if err = db.Save(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ListBackup 返回dir目录下的所有备份文件，按时间从旧到新排列
+func ListBackup(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var result []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, BackupPrefix) || !strings.HasSuffix(name, BackupSuffix) {
+			continue
+		}
+		result = append(result, filepath.Join(dir, name))
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// CleanBackup 只保留dir目录下最新的keep个备份，返回被删除的文件，keep小于等于0时不删除
+func CleanBackup(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	backups, err := ListBackup(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) <= keep {
+		return nil, nil
+	}
+	var removed []string
+	for _, path := range backups[:len(backups)-keep] {
+		if err = os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// CheckBackup 检查备份文件能否被正常读取
+func CheckBackup(src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return newSnapshotStorage().load(f, time.Now())
+}
+
+// Restore 使用备份文件src覆盖dbpath的数据库文件，原来的数据库文件会被重命名保留。
+// 必须在初始化数据库之前调用，支持 buntdb 和 snapshot 存储，它们的数据文件格式相同
+func Restore(src string, dbpath string) error {
+	if db != nil {
+		return errors.New("数据库已经初始化，请在启动前恢复")
+	}
+	if dbpath == "" {
+		dbpath = LSPDB
+	}
+	if dbpath == MEMORYDB {
+		return errors.New("内存数据库不支持恢复")
+	}
+	if err := CheckBackup(src); err != nil {
+		return fmt.Errorf("备份文件无法读取：%v", err)
+	}
+	fileLock := flock.New(dbpath + ".lock")
+	ok, err := fileLock.TryLock()
+	if err != nil || !ok {
+		return ErrLockNotHold
+	}
+	defer fileLock.Unlock()
+
+	if _, err = os.Stat(dbpath); err == nil {
+		if err = os.Rename(dbpath, dbpath+".before-restore-"+time.Now().Format("20060102-150405")); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dbpath)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
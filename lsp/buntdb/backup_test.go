@@ -0,0 +1,77 @@
+package buntdb
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Backup(dir)
+	assert.Equal(t, ErrNotInitialized, err)
+
+	assert.Nil(t, InitBuntDB(MEMORYDB))
+	assert.Nil(t, SetInt64("a", 1))
+	path, err := Backup(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, CheckBackup(path))
+	assert.Nil(t, Close())
+
+	backups, err := ListBackup(dir)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{path}, backups)
+
+	// 恢复到一个新的数据库文件
+	dbpath := filepath.Join(dir, "test.db")
+	assert.Nil(t, os.WriteFile(dbpath, []byte("old"), 0644))
+	assert.Nil(t, Restore(path, dbpath))
+	assert.Nil(t, InitBuntDB(dbpath))
+	v, err := GetInt64("a")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, v)
+	assert.NotNil(t, Restore(path, dbpath))
+	assert.Nil(t, Close())
+
+	matches, err := filepath.Glob(dbpath + ".before-restore-*")
+	assert.Nil(t, err)
+	assert.Len(t, matches, 1)
+
+	invalid := filepath.Join(dir, "invalid.db")
+	assert.Nil(t, os.WriteFile(invalid, []byte("invalid"), 0644))
+	assert.NotNil(t, CheckBackup(invalid))
+	assert.NotNil(t, Restore(invalid, filepath.Join(dir, "other.db")))
+	assert.NotNil(t, Restore(path, MEMORYDB))
+}
+
+func TestCleanBackup(t *testing.T) {
+	dir := t.TempDir()
+	backups, err := ListBackup(filepath.Join(dir, "not-exist"))
+	assert.Nil(t, err)
+	assert.Empty(t, backups)
+
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("%v2021010%v-000000%v", BackupPrefix, i, BackupSuffix)
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "other.db"), nil, 0644))
+
+	removed, err := CleanBackup(dir, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, removed)
+
+	removed, err = CleanBackup(dir, 2)
+	assert.Nil(t, err)
+	assert.Len(t, removed, 3)
+	backups, err = ListBackup(dir)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{
+		filepath.Join(dir, BackupPrefix+"20210104-000000"+BackupSuffix),
+		filepath.Join(dir, BackupPrefix+"20210105-000000"+BackupSuffix),
+	}, backups)
+	_, err = os.Stat(filepath.Join(dir, "other.db"))
+	assert.Nil(t, err)
+}
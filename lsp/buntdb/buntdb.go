@@ -1,81 +1,72 @@
 package buntdb
 
 import (
-	"fmt"
-	"github.com/gofrs/flock"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/modern-go/gls"
-	"github.com/tidwall/buntdb"
 )
 
-var db *buntdb.DB
+var db Storage
+var dbPath string
 
 const MEMORYDB = ":memory:"
 const LSPDB = ".lsp.db"
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
-var fileLock *flock.Flock
 
-// InitBuntDB 初始化buntdb，正常情况下框架会负责初始化
+// InitBuntDB 使用默认的存储引擎初始化数据库，正常情况下框架会负责初始化
 func InitBuntDB(dbpath string) error {
-	if dbpath == "" {
-		dbpath = LSPDB
+	if dbpath == MEMORYDB {
+		return InitStorage(StorageMemory, dbpath)
 	}
-	if dbpath != MEMORYDB {
-		var dblock = dbpath + ".lock"
-		fileLock = flock.New(dblock)
-		ok, err := fileLock.TryLock()
-		if err != nil {
-			fmt.Printf("buntdb tryLock err: %v", err)
-		}
-		if !ok {
-			return ErrLockNotHold
-		}
-	}
-	buntDB, err := buntdb.Open(dbpath)
+	return InitStorage(StorageBuntDB, dbpath)
+}
+
+// InitStorage 使用指定的存储引擎初始化数据库，name为空时使用默认的 buntdb
+func InitStorage(name string, dbpath string) error {
+	factory, err := getStorageFactory(name)
 	if err != nil {
 		return err
 	}
-	if dbpath != MEMORYDB {
-		buntDB.SetConfig(buntdb.Config{
-			SyncPolicy:           buntdb.EverySecond,
-			AutoShrinkPercentage: 10,
-			AutoShrinkMinSize:    1 * 1024 * 1024,
-		})
+	s := factory()
+	if err = s.Open(dbpath); err != nil {
+		return err
 	}
-	db = buntDB
+	db = s
+	dbPath = dbpath
 	return nil
 }
 
-// GetClient 获取 buntdb.DB 对象，如果没有初始化会返回 ErrNotInitialized
-func GetClient() (*buntdb.DB, error) {
+// GetClient 获取当前使用的 Storage ，如果没有初始化会返回 ErrNotInitialized
+func GetClient() (Storage, error) {
 	if db == nil {
 		return nil, ErrNotInitialized
 	}
 	return db, nil
 }
 
-// MustGetClient 获取 buntdb.DB 对象，如果没有初始化会panic，在编写订阅组件时可以放心调用
-func MustGetClient() *buntdb.DB {
+// MustGetClient 获取当前使用的 Storage ，如果没有初始化会panic，在编写订阅组件时可以放心调用
+func MustGetClient() Storage {
 	if db == nil {
 		panic(ErrNotInitialized)
 	}
 	return db
 }
 
-// Close 关闭buntdb，正常情况下框架会负责关闭
+// Shrink 重写数据库文件，删除已经过期或者被覆盖的数据，内存数据库不做任何操作
+func Shrink() error {
+	if db == nil {
+		return ErrNotInitialized
+	}
+	return db.Shrink()
+}
+
+// Close 关闭数据库并释放文件锁，正常情况下框架会负责关闭
 func Close() error {
 	if db != nil {
-		if itx := gls.Get(txKey); itx != nil {
-			itx.(*buntdb.Tx).Rollback()
-		}
-		if err := db.Close(); err != nil {
-			return err
-		}
+		s := db
 		db = nil
-	}
-	if fileLock != nil {
-		return fileLock.Unlock()
+		dbPath = ""
+		cachePurge()
+		return s.Close()
 	}
 	return nil
 }
@@ -2,7 +2,6 @@ package buntdb
 
 import (
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"os"
 	"sync"
 	"testing"
@@ -67,11 +66,11 @@ func TestNamedKey(t *testing.T) {
 }
 
 func TestRTxCover(t *testing.T) {
-	err := RWCoverTx(func(tx *buntdb.Tx) error {
+	err := RWCoverTx(func(tx Tx) error {
 		return nil
 	})
 	assert.Equal(t, ErrNotInitialized, err)
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		return nil
 	})
 	assert.Equal(t, ErrNotInitialized, err)
@@ -89,17 +88,17 @@ func TestRTxCover(t *testing.T) {
 	err = InitBuntDB(MEMORYDB)
 	assert.Nil(t, err)
 	defer Close()
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		_, _, err := tx.Set("a", "b", nil)
 		return err
 	})
-	assert.Equal(t, buntdb.ErrTxNotWritable, err)
-	err = RWCoverTx(func(tx *buntdb.Tx) error {
+	assert.Equal(t, ErrTxNotWritable, err)
+	err = RWCoverTx(func(tx Tx) error {
 		_, _, err := tx.Set("a", "b", nil)
 		return err
 	})
 	assert.Nil(t, err)
-	_ = RCoverTx(func(tx *buntdb.Tx) error {
+	_ = RCoverTx(func(tx Tx) error {
 		val, err := tx.Get("a")
 		assert.Equal(t, "b", val)
 		assert.Nil(t, err)
@@ -113,18 +112,18 @@ func TestRWTxCover(t *testing.T) {
 	assert.Nil(t, err)
 	defer Close()
 
-	err = RWCoverTx(func(tx *buntdb.Tx) error {
+	err = RWCoverTx(func(tx Tx) error {
 		_, _, err := tx.Set("a", "b", ExpireOption(time.Hour*48))
 		return err
 	})
 	assert.Nil(t, err)
-	err = RWCoverTx(func(tx *buntdb.Tx) error {
+	err = RWCoverTx(func(tx Tx) error {
 		tx.Set("a", "c", ExpireOption(time.Second*1))
 		return ErrRollback
 	})
 	assert.EqualValues(t, ErrRollback, err)
 	var ttl time.Duration
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		var err error
 		ttl, err = tx.TTL("a")
 		return err
@@ -140,26 +139,26 @@ func TestNestedCover(t *testing.T) {
 	defer Close()
 
 	setAfn := func() error {
-		return RWCoverTx(func(tx *buntdb.Tx) error {
+		return RWCoverTx(func(tx Tx) error {
 			_, _, err := tx.Set("a", "b", nil)
 			return err
 		})
 	}
 	setBfn := func() error {
-		return RWCoverTx(func(tx *buntdb.Tx) error {
+		return RWCoverTx(func(tx Tx) error {
 			_, _, err := tx.Set("b", "c", nil)
 			return err
 		})
 	}
 	setCfn := func() error {
-		return RWCoverTx(func(tx *buntdb.Tx) error {
+		return RWCoverTx(func(tx Tx) error {
 			_, _, err := tx.Set("c", "d", nil)
 			return err
 		})
 	}
 	readBfn := func() (string, error) {
 		var result string
-		err := RCoverTx(func(tx *buntdb.Tx) error {
+		err := RCoverTx(func(tx Tx) error {
 			val, err := tx.Get("b", false)
 			result = val
 			return err
@@ -170,8 +169,8 @@ func TestNestedCover(t *testing.T) {
 	var val string
 	err = RWCover(func() error {
 		return RWCover(func() error {
-			return RWCoverTx(func(tx *buntdb.Tx) error {
-				return RWCoverTx(func(tx *buntdb.Tx) error {
+			return RWCoverTx(func(tx Tx) error {
+				return RWCoverTx(func(tx Tx) error {
 					_, _, err := tx.Set("d", "e", nil)
 					if err != nil {
 						return err
@@ -197,7 +196,7 @@ func TestNestedCover(t *testing.T) {
 	assert.Equal(t, "c", val)
 	err = RCover(func() error {
 		return RCover(func() error {
-			return RCoverTx(func(tx *buntdb.Tx) error {
+			return RCoverTx(func(tx Tx) error {
 				val, err := tx.Get("a")
 				assert.Nil(t, err)
 				assert.Equal(t, "b", val)
@@ -212,16 +211,16 @@ func TestNestedCover(t *testing.T) {
 		})
 	})
 
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		val, err := readBfn()
 		assert.Nil(t, err)
 		assert.Equal(t, "c", val)
 		err = setCfn()
-		assert.EqualValues(t, buntdb.ErrTxNotWritable, err)
+		assert.EqualValues(t, ErrTxNotWritable, err)
 		return nil
 	})
 	assert.Nil(t, err)
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		_, err := tx.Get("c")
 		assert.True(t, IsNotFound(err))
 		return nil
@@ -235,41 +234,41 @@ func TestRWTxCover2(t *testing.T) {
 	assert.Nil(t, err)
 	defer Close()
 
-	testFn := func(tx *buntdb.Tx, key, exp string) {
+	testFn := func(tx Tx, key, exp string) {
 		val, err := tx.Get(key)
 		assert.Nil(t, err)
 		assert.Equal(t, exp, val)
 	}
 
-	set1Fn := func(tx *buntdb.Tx) error {
+	set1Fn := func(tx Tx) error {
 		_, _, err := tx.Set("a", "a", nil)
 		if err != nil {
 			return err
 		}
-		err = RWCoverTx(func(tx *buntdb.Tx) error {
+		err = RWCoverTx(func(tx Tx) error {
 			_, _, err = tx.Set("b", "b", nil)
 			return err
 		})
 		if err != nil {
 			return err
 		}
-		err = RCoverTx(func(tx *buntdb.Tx) error {
+		err = RCoverTx(func(tx Tx) error {
 			testFn(tx, "a", "a")
 			testFn(tx, "b", "b")
 			return nil
 		})
 		return err
 	}
-	set2Fn := func(tx *buntdb.Tx) error {
+	set2Fn := func(tx Tx) error {
 		_, _, err := tx.Set("d", "d", nil)
 		if err != nil {
 			return err
 		}
-		err = RWCoverTx(func(tx *buntdb.Tx) error {
+		err = RWCoverTx(func(tx Tx) error {
 			_, _, err = tx.Set("c", "c", nil)
 			return err
 		})
-		err = RCoverTx(func(tx *buntdb.Tx) error {
+		err = RCoverTx(func(tx Tx) error {
 			testFn(tx, "c", "c")
 			testFn(tx, "d", "d")
 			return nil
@@ -361,7 +360,7 @@ func TestSeqNext(t *testing.T) {
 	assert.Nil(t, err)
 	assert.EqualValues(t, 1, s)
 
-	err = RWCoverTx(func(tx *buntdb.Tx) error {
+	err = RWCoverTx(func(tx Tx) error {
 		_, _, err := tx.Set(seq1, "wrong", nil)
 		return err
 	})
@@ -441,7 +440,7 @@ func TestRemoveByPrefixAndIndex(t *testing.T) {
 	assert.Nil(t, err)
 	defer Close()
 
-	err = RWCoverTx(func(tx *buntdb.Tx) error {
+	err = RWCoverTx(func(tx Tx) error {
 		_, _, err := tx.Set(BilibiliGroupConcernStateKey("1"), "", nil)
 		assert.Nil(t, err)
 		_, _, err = tx.Set(BilibiliGroupConcernStateKey("2"), "", nil)
@@ -453,7 +452,7 @@ func TestRemoveByPrefixAndIndex(t *testing.T) {
 		_, _, err = tx.Set(HuyaGroupConcernStateKey("5"), "", nil)
 		assert.Nil(t, err)
 		createIndex := func(patternFunc KeyPatternFunc) {
-			assert.Nil(t, tx.CreateIndex(patternFunc(), patternFunc("*"), buntdb.IndexString))
+			assert.Nil(t, tx.CreateIndex(patternFunc(), patternFunc("*"), IndexString))
 		}
 		for _, pattern := range []KeyPatternFunc{BilibiliGroupConcernStateKey, DouyuGroupConcernStateKey, HuyaGroupConcernStateKey} {
 			createIndex(pattern)
@@ -464,7 +463,7 @@ func TestRemoveByPrefixAndIndex(t *testing.T) {
 	deletedKeys, err := RemoveByPrefixAndIndex([]string{BilibiliGroupConcernStateKey(), DouyuGroupConcernStateKey()}, []string{BilibiliGroupConcernStateKey(), DouyuGroupConcernStateKey()})
 	assert.Nil(t, err)
 	assert.Len(t, deletedKeys, 4)
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		assertNotExist := func(key string) {
 			_, err := tx.Get(key)
 			assert.True(t, IsNotFound(err))
@@ -503,7 +502,7 @@ func TestCreatePatternIndex(t *testing.T) {
 	defer Close()
 
 	assert.Nil(t, CreatePatternIndex(BilibiliGroupConcernStateKey, nil))
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	err = RCoverTx(func(tx Tx) error {
 		indexes, err := tx.Indexes()
 		assert.Nil(t, err)
 		assert.Len(t, indexes, 1)
@@ -514,8 +513,8 @@ func TestCreatePatternIndex(t *testing.T) {
 
 	var suffix = []interface{}{"a", "1"}
 
-	assert.Nil(t, CreatePatternIndex(BilibiliGroupConcernStateKey, suffix, buntdb.IndexBinary))
-	err = RCoverTx(func(tx *buntdb.Tx) error {
+	assert.Nil(t, CreatePatternIndex(BilibiliGroupConcernStateKey, suffix, IndexBinary))
+	err = RCoverTx(func(tx Tx) error {
 		indexes, err := tx.Indexes()
 		assert.Nil(t, err)
 		assert.Len(t, indexes, 2)
@@ -632,3 +631,10 @@ func TestGet(t *testing.T) {
 
 	assert.False(t, Exist("wrong", GetTTLOpt(&ttl)))
 }
+
+func TestShrink(t *testing.T) {
+	assert.EqualValues(t, ErrNotInitialized, Shrink())
+	assert.Nil(t, InitBuntDB(MEMORYDB))
+	assert.Nil(t, Shrink())
+	assert.Nil(t, Close())
+}
@@ -0,0 +1,164 @@
+package buntdb
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// jsonCache 是 GetJson 的内存LRU缓存，保存key上的原始值以及json解析后的对象。
+// 读取时仍然会在事务中获取原始值，只有原始值完全相同时才使用缓存的对象，
+// 这样即使数据在事务中被直接修改（没有经过 Set / Delete ），也不会读到旧数据，
+// 节省的是热点key每次读取时的 json.Unmarshal 开销。
+type jsonCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+
+	hit  uint64
+	miss uint64
+}
+
+type jsonCacheEntry struct {
+	key   string
+	raw   string
+	value reflect.Value
+}
+
+// CacheStats 缓存的统计信息
+type CacheStats struct {
+	Capacity int
+	Len      int
+	Hit      uint64
+	Miss     uint64
+}
+
+// HitRate 返回缓存命中率，没有读取过时返回0
+func (s CacheStats) HitRate() float64 {
+	if s.Hit+s.Miss == 0 {
+		return 0
+	}
+	return float64(s.Hit) / float64(s.Hit+s.Miss)
+}
+
+var cache atomic.Pointer[jsonCache]
+
+// EnableCache 开启 GetJson 的内存缓存，size为最多缓存的key数量，size小于等于0时关闭缓存。
+// 只有使用了 GetCacheOpt 的 GetJson 会使用缓存。
+func EnableCache(size int) {
+	if size <= 0 {
+		DisableCache()
+		return
+	}
+	cache.Store(&jsonCache{
+		capacity: size,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	})
+	logger.Debugf("json缓存已开启，容量为%v", size)
+}
+
+// DisableCache 关闭并清空 GetJson 的内存缓存
+func DisableCache() {
+	cache.Store(nil)
+}
+
+// GetCacheStats 返回缓存的统计信息，没有开启缓存时返回 false
+func GetCacheStats() (CacheStats, bool) {
+	c := cache.Load()
+	if c == nil {
+		return CacheStats{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Capacity: c.capacity,
+		Len:      c.ll.Len(),
+		Hit:      c.hit,
+		Miss:     c.miss,
+	}, true
+}
+
+// cacheLoad 当key上缓存的原始值与raw相同时，把缓存的对象复制到obj上并返回true
+func cacheLoad(key string, raw string, obj interface{}) bool {
+	c := cache.Load()
+	if c == nil {
+		return false
+	}
+	dst := reflect.ValueOf(obj)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, found := c.items[key]; found {
+		entry := e.Value.(*jsonCacheEntry)
+		if entry.raw == raw && entry.value.Type() == dst.Type().Elem() {
+			c.ll.MoveToFront(e)
+			dst.Elem().Set(entry.value)
+			c.hit++
+			return true
+		}
+	}
+	c.miss++
+	return false
+}
+
+// cacheStore 保存obj当前的值，之后修改obj不会影响缓存
+func cacheStore(key string, raw string, obj interface{}) {
+	c := cache.Load()
+	if c == nil {
+		return
+	}
+	src := reflect.ValueOf(obj)
+	if src.Kind() != reflect.Ptr || src.IsNil() {
+		return
+	}
+	value := reflect.New(src.Type().Elem()).Elem()
+	value.Set(src.Elem())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, found := c.items[key]; found {
+		entry := e.Value.(*jsonCacheEntry)
+		entry.raw = raw
+		entry.value = value
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&jsonCacheEntry{key: key, raw: raw, value: value})
+	for c.ll.Len() > c.capacity {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*jsonCacheEntry).key)
+	}
+}
+
+// cacheInvalidate 在key被写入或删除时调用，删除key上的缓存
+func cacheInvalidate(keys ...string) {
+	c := cache.Load()
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if e, found := c.items[key]; found {
+			c.ll.Remove(e)
+			delete(c.items, key)
+		}
+	}
+}
+
+// cachePurge 清空缓存的内容，在数据库关闭时调用
+func cachePurge() {
+	c := cache.Load()
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
@@ -0,0 +1,70 @@
+package buntdb
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	assert.Nil(t, InitBuntDB(MEMORYDB))
+	defer Close()
+
+	_, ok := GetCacheStats()
+	assert.False(t, ok)
+
+	EnableCache(2)
+	defer DisableCache()
+
+	assert.Nil(t, SetJson(key1, &test1{"a", "b"}))
+	var r1 = new(test1)
+	assert.Nil(t, GetJson(key1, r1, GetCacheOpt()))
+	assert.Equal(t, "a", r1.A1)
+
+	// 修改读取到的对象不影响缓存
+	r1.A1 = "c"
+	var r2 = new(test1)
+	assert.Nil(t, GetJson(key1, r2, GetCacheOpt()))
+	assert.Equal(t, "a", r2.A1)
+
+	stats, ok := GetCacheStats()
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, stats.Hit)
+	assert.EqualValues(t, 1, stats.Miss)
+	assert.EqualValues(t, 1, stats.Len)
+	assert.Equal(t, 0.5, stats.HitRate())
+
+	// 通过Set写入时缓存失效
+	assert.Nil(t, SetJson(key1, &test1{"d", "e"}))
+	assert.Nil(t, GetJson(key1, r2, GetCacheOpt()))
+	assert.Equal(t, "d", r2.A1)
+
+	// 直接在事务中写入时也不会读到旧数据
+	assert.Nil(t, RWCoverTx(func(tx Tx) error {
+		_, _, err := tx.Set(key1, `{"a_1":"f"}`, nil)
+		return err
+	}))
+	assert.Nil(t, GetJson(key1, r2, GetCacheOpt()))
+	assert.Equal(t, "f", r2.A1)
+
+	// 不同类型的对象不会使用缓存
+	var r3 = new(test2)
+	assert.Nil(t, GetJson(key1, r3, GetCacheOpt()))
+	assert.Equal(t, "", r3.A2)
+
+	_, err := Delete(key1)
+	assert.Nil(t, err)
+	assert.True(t, IsNotFound(GetJson(key1, r2, GetCacheOpt())))
+
+	// 超过容量时淘汰最久没有使用的key
+	for _, key := range []string{"k1", "k2", "k3"} {
+		assert.Nil(t, SetJson(key, &test1{A1: key}))
+		assert.Nil(t, GetJson(key, new(test1), GetCacheOpt()))
+	}
+	stats, _ = GetCacheStats()
+	assert.Equal(t, 2, stats.Len)
+	assert.Equal(t, 2, stats.Capacity)
+
+	EnableCache(0)
+	_, ok = GetCacheStats()
+	assert.False(t, ok)
+}
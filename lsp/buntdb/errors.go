@@ -2,14 +2,24 @@ package buntdb
 
 import (
 	"errors"
-	"github.com/tidwall/buntdb"
 )
 
 var (
-	ErrKeyExist       = errors.New("key exist")
-	ErrNotInitialized = errors.New("not initialized")
-	ErrRollback       = errors.New("rollback")
-	ErrLockNotHold    = errors.New("lock not hold")
+	ErrKeyExist        = errors.New("key exist")
+	ErrNotInitialized  = errors.New("not initialized")
+	ErrRollback        = errors.New("rollback")
+	ErrLockNotHold     = errors.New("lock not hold")
+	ErrStorageNotFound = errors.New("storage not found")
+
+	// 以下错误由 Tx 返回，不同的 Storage 返回相同的错误
+
+	ErrNotFound       = errors.New("not found")
+	ErrIndexExists    = errors.New("index exists")
+	ErrTxNotWritable  = errors.New("tx not writable")
+	ErrTxIterating    = errors.New("tx is iterating")
+	ErrTxClosed       = errors.New("tx closed")
+	ErrDatabaseClosed = errors.New("database closed")
+	ErrInvalidData    = errors.New("invalid data")
 )
 
 func IsRollback(e error) bool {
@@ -17,5 +27,5 @@ func IsRollback(e error) bool {
 }
 
 func IsNotFound(e error) bool {
-	return e == buntdb.ErrNotFound
+	return errors.Is(e, ErrNotFound)
 }
@@ -0,0 +1,139 @@
+package buntdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 用于 ddbot db 命令检查和修复数据库，这些函数会直接遍历整个数据库，不要在BOT运行时频繁调用
+
+var (
+	jsonTypeMu sync.RWMutex
+	jsonTypes  = make(map[string]func() interface{})
+)
+
+// RegisterJsonType 注册patternFunc对应的key保存的json类型，VerifyJson 会使用newObj创建的对象检查json是否可以正常解析
+func RegisterJsonType(patternFunc KeyPatternFunc, newObj func() interface{}) {
+	jsonTypeMu.Lock()
+	defer jsonTypeMu.Unlock()
+	jsonTypes[patternFunc()] = newObj
+}
+
+// getJsonType 返回key对应的json类型，有多个匹配时使用最长的名字，没有注册时返回nil
+func getJsonType(key string) (string, func() interface{}) {
+	jsonTypeMu.RLock()
+	defer jsonTypeMu.RUnlock()
+	var name string
+	var newObj func() interface{}
+	for n, f := range jsonTypes {
+		if (key == n || strings.HasPrefix(key, n+":")) && len(n) > len(name) {
+			name, newObj = n, f
+		}
+	}
+	return name, newObj
+}
+
+// KeyNames 返回数据库中所有key的名字（第一个冒号前的部分）以及数量
+func KeyNames() (map[string]int, error) {
+	var result = make(map[string]int)
+	err := RCoverTx(func(tx Tx) error {
+		return tx.AscendKeys("*", func(key, value string) bool {
+			result[strings.SplitN(key, ":", 2)[0]]++
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListKeys 按顺序返回匹配pattern的key，limit大于0时最多返回limit个
+func ListKeys(pattern string, limit int) ([]string, error) {
+	var result []string
+	err := RCoverTx(func(tx Tx) error {
+		return tx.AscendKeys(pattern, func(key, value string) bool {
+			result = append(result, key)
+			return limit <= 0 || len(result) < limit
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RebuildIndex 删除并重新创建pattern对应的索引，索引名字与pattern相同，返回每个索引中key的数量
+// 索引只保存在内存中，BOT启动时会自动创建需要的索引
+func RebuildIndex(patterns ...string) ([]*IndexStat, error) {
+	var result []*IndexStat
+	err := RWCoverTx(func(tx Tx) error {
+		for _, pattern := range patterns {
+			if err := tx.DropIndex(pattern); err != nil && err != ErrNotFound {
+				return err
+			}
+			if err := tx.CreateIndex(pattern, pattern, IndexString); err != nil {
+				return err
+			}
+			var stat = &IndexStat{Name: pattern}
+			if err := tx.Ascend(pattern, func(key, value string) bool {
+				stat.Count++
+				return true
+			}); err != nil {
+				return err
+			}
+			result = append(result, stat)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// VerifyResult 一个无法解析的key
+type VerifyResult struct {
+	Key  string
+	Type string
+	Err  error
+}
+
+func (v *VerifyResult) String() string {
+	return fmt.Sprintf("%v（%v）：%v", v.Key, v.Type, v.Err)
+}
+
+// VerifyJson 检查匹配pattern的key中，注册过类型（ RegisterJsonType ）的值能否正常解析，
+// 返回检查过的key数量和无法解析的key
+func VerifyJson(pattern string) (checked int, invalid []*VerifyResult, err error) {
+	err = RCoverTx(func(tx Tx) error {
+		return tx.AscendKeys(pattern, func(key, value string) bool {
+			name, newObj := getJsonType(key)
+			if newObj == nil {
+				return true
+			}
+			checked++
+			obj := newObj()
+			var verifyErr error
+			if len(value) == 0 {
+				verifyErr = errors.New("empty value")
+			} else {
+				verifyErr = json.Unmarshal([]byte(value), obj)
+			}
+			if verifyErr != nil {
+				invalid = append(invalid, &VerifyResult{
+					Key:  key,
+					Type: fmt.Sprintf("%v %T", name, obj),
+					Err:  verifyErr,
+				})
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package buntdb
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testJsonType struct {
+	A int `json:"a"`
+}
+
+func TestInspect(t *testing.T) {
+	assert.Nil(t, InitBuntDB(MEMORYDB))
+	defer Close()
+
+	for key, value := range map[string]string{
+		"a:1":   `{"a":1}`,
+		"a:2":   `{"a":"x"}`,
+		"a:3":   ``,
+		"ab:1":  `xxx`,
+		"b:1":   `{`,
+		"b:1:2": `{}`,
+	} {
+		assert.Nil(t, Set(key, value))
+	}
+
+	names, err := KeyNames()
+	assert.Nil(t, err)
+	assert.EqualValues(t, map[string]int{"a": 3, "ab": 1, "b": 2}, names)
+
+	keys, err := ListKeys("a:*", 0)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"a:1", "a:2", "a:3"}, keys)
+	keys, err = ListKeys("*", 2)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"a:1", "a:2"}, keys)
+
+	stats, err := RebuildIndex("a:*", "b:*")
+	assert.Nil(t, err)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, 3, stats[0].Count)
+	assert.Equal(t, 2, stats[1].Count)
+	stats, err = RebuildIndex("a:*")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, stats[0].Count)
+
+	RegisterJsonType(func(keys ...interface{}) string {
+		return NamedKey("a", keys)
+	}, func() interface{} {
+		return new(testJsonType)
+	})
+	checked, invalid, err := VerifyJson("*")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, checked)
+	if assert.Len(t, invalid, 2) {
+		assert.Equal(t, "a:2", invalid[0].Key)
+		assert.Equal(t, "a:3", invalid[1].Key)
+		assert.Contains(t, invalid[0].String(), "testJsonType")
+	}
+}
@@ -10,9 +10,18 @@ import (
 
 type KeyPatternFunc func(...interface{}) string
 
+// Keyer 可以自定义在key中的格式，例如 mmsg.TargetId
+type Keyer interface {
+	Key() string
+}
+
 func Key(keys ...interface{}) string {
 	var _keys []string
 	for _, ikey := range keys {
+		if k, ok := ikey.(Keyer); ok {
+			_keys = append(_keys, k.Key())
+			continue
+		}
 		rk := reflect.ValueOf(ikey)
 		if !rk.IsValid() {
 			panic(fmt.Sprintf("invalid value %T %v", ikey, ikey))
@@ -68,6 +77,9 @@ func BilibiliUidFirstTimestampKey(keys ...interface{}) string {
 func BilibiliUserCookieInfoKey(keys ...interface{}) string {
 	return NamedKey("UserCookieInfo", keys)
 }
+func BilibiliQRCodeLoginInfoKey(keys ...interface{}) string {
+	return NamedKey("QRCodeLoginInfo", keys)
+}
 func BilibiliNotLiveCountKey(keys ...interface{}) string {
 	return NamedKey("NotLiveCount", keys)
 }
@@ -89,9 +101,24 @@ func BilibiliNotifyMsgKey(keys ...interface{}) string {
 func BilibiliActiveTimestampKey(keys ...interface{}) string {
 	return NamedKey("ActiveTimestamp", keys)
 }
+func BilibiliNewsHistoryKey(keys ...interface{}) string {
+	return NamedKey("NewsHistory", keys)
+}
 func BilibiliLastFreshKey(keys ...interface{}) string {
 	return NamedKey("BilibiliLastFresh", keys)
 }
+func BilibiliGuardStatKey(keys ...interface{}) string {
+	return NamedKey("BilibiliGuardStat", keys)
+}
+func BilibiliReserveKey(keys ...interface{}) string {
+	return NamedKey("BilibiliReserve", keys)
+}
+func BilibiliStatSampleKey(keys ...interface{}) string {
+	return NamedKey("StatSample", keys)
+}
+func BilibiliLiveSessionKey(keys ...interface{}) string {
+	return NamedKey("LiveSession", keys)
+}
 func DouyuGroupConcernStateKey(keys ...interface{}) string {
 	return NamedKey("DouyuConcernState", keys)
 }
@@ -107,6 +134,12 @@ func DouyuCurrentLiveKey(keys ...interface{}) string {
 func DouyuGroupAtAllMarkKey(keys ...interface{}) string {
 	return NamedKey("DouyuGroupAtAll", keys)
 }
+func DouyuLastReplayKey(keys ...interface{}) string {
+	return NamedKey("DouyuLastReplay", keys)
+}
+func DouyuLastNewsKey(keys ...interface{}) string {
+	return NamedKey("DouyuLastNews", keys)
+}
 func YoutubeGroupConcernStateKey(keys ...interface{}) string {
 	return NamedKey("YoutubeConcernState", keys)
 }
@@ -143,6 +176,138 @@ func HuyaCurrentLiveKey(keys ...interface{}) string {
 func HuyaGroupAtAllMarkKey(keys ...interface{}) string {
 	return NamedKey("HuyaGroupAtAll", keys)
 }
+func HuyaScheduleKey(keys ...interface{}) string {
+	return NamedKey("HuyaSchedule", keys)
+}
+func HuyaScheduleRemindKey(keys ...interface{}) string {
+	return NamedKey("HuyaScheduleRemind", keys)
+}
+func HuyaLastReplayKey(keys ...interface{}) string {
+	return NamedKey("HuyaLastReplay", keys)
+}
+func TwitchGroupConcernStateKey(keys ...interface{}) string {
+	return NamedKey("TwitchConcernState", keys)
+}
+func TwitchGroupConcernConfigKey(keys ...interface{}) string {
+	return NamedKey("TwitchConcernConfig", keys)
+}
+func TwitchFreshKey(keys ...interface{}) string {
+	return NamedKey("TwitchFresh", keys)
+}
+func TwitchCurrentLiveKey(keys ...interface{}) string {
+	return NamedKey("TwitchCurrentLive", keys)
+}
+func TwitchGroupAtAllMarkKey(keys ...interface{}) string {
+	return NamedKey("TwitchGroupAtAll", keys)
+}
+func SteamGroupConcernStateKey(keys ...interface{}) string {
+	return NamedKey("SteamConcernState", keys)
+}
+func SteamGroupConcernConfigKey(keys ...interface{}) string {
+	return NamedKey("SteamConcernConfig", keys)
+}
+func SteamFreshKey(keys ...interface{}) string {
+	return NamedKey("SteamFresh", keys)
+}
+func SteamGroupAtAllMarkKey(keys ...interface{}) string {
+	return NamedKey("SteamGroupAtAll", keys)
+}
+func SteamServerInfoKey(keys ...interface{}) string {
+	return NamedKey("SteamServerInfo", keys)
+}
+func SteamServerOfflineKey(keys ...interface{}) string {
+	return NamedKey("SteamServerOffline", keys)
+}
+func SteamUserStatusKey(keys ...interface{}) string {
+	return NamedKey("SteamUserStatus", keys)
+}
+func DouyinGroupConcernStateKey(keys ...interface{}) string {
+	return NamedKey("DouyinConcernState", keys)
+}
+func DouyinGroupConcernConfigKey(keys ...interface{}) string {
+	return NamedKey("DouyinConcernConfig", keys)
+}
+func DouyinFreshKey(keys ...interface{}) string {
+	return NamedKey("DouyinFresh", keys)
+}
+func DouyinGroupAtAllMarkKey(keys ...interface{}) string {
+	return NamedKey("DouyinGroupAtAll", keys)
+}
+func DouyinUserInfoKey(keys ...interface{}) string {
+	return NamedKey("DouyinUserInfo", keys)
+}
+func DouyinCurrentLiveKey(keys ...interface{}) string {
+	return NamedKey("DouyinCurrentLive", keys)
+}
+func DouyinVideoKey(keys ...interface{}) string {
+	return NamedKey("DouyinVideo", keys)
+}
+func DouyinLastVideoTimeKey(keys ...interface{}) string {
+	return NamedKey("DouyinLastVideoTime", keys)
+}
+func NeteaseGroupConcernStateKey(keys ...interface{}) string {
+	return NamedKey("NeteaseConcernState", keys)
+}
+func NeteaseGroupConcernConfigKey(keys ...interface{}) string {
+	return NamedKey("NeteaseConcernConfig", keys)
+}
+func NeteaseFreshKey(keys ...interface{}) string {
+	return NamedKey("NeteaseFresh", keys)
+}
+func NeteaseGroupAtAllMarkKey(keys ...interface{}) string {
+	return NamedKey("NeteaseGroupAtAll", keys)
+}
+func NeteaseUserInfoKey(keys ...interface{}) string {
+	return NamedKey("NeteaseUserInfo", keys)
+}
+func NeteaseReleaseKey(keys ...interface{}) string {
+	return NamedKey("NeteaseRelease", keys)
+}
+func NeteaseLastReleaseTimeKey(keys ...interface{}) string {
+	return NamedKey("NeteaseLastReleaseTime", keys)
+}
+func TwitterGroupConcernStateKey(keys ...interface{}) string {
+	return NamedKey("TwitterConcernState", keys)
+}
+func TwitterGroupConcernConfigKey(keys ...interface{}) string {
+	return NamedKey("TwitterConcernConfig", keys)
+}
+func TwitterFreshKey(keys ...interface{}) string {
+	return NamedKey("TwitterFresh", keys)
+}
+func TwitterGroupAtAllMarkKey(keys ...interface{}) string {
+	return NamedKey("TwitterGroupAtAll", keys)
+}
+func TwitterUserInfoKey(keys ...interface{}) string {
+	return NamedKey("TwitterUserInfo", keys)
+}
+func TwitterTweetKey(keys ...interface{}) string {
+	return NamedKey("TwitterTweet", keys)
+}
+func TwitterLastTweetIdKey(keys ...interface{}) string {
+	return NamedKey("TwitterLastTweetId", keys)
+}
+func PixivGroupConcernStateKey(keys ...interface{}) string {
+	return NamedKey("PixivConcernState", keys)
+}
+func PixivGroupConcernConfigKey(keys ...interface{}) string {
+	return NamedKey("PixivConcernConfig", keys)
+}
+func PixivFreshKey(keys ...interface{}) string {
+	return NamedKey("PixivFresh", keys)
+}
+func PixivGroupAtAllMarkKey(keys ...interface{}) string {
+	return NamedKey("PixivGroupAtAll", keys)
+}
+func PixivUserInfoKey(keys ...interface{}) string {
+	return NamedKey("PixivUserInfo", keys)
+}
+func PixivLastIllustIdKey(keys ...interface{}) string {
+	return NamedKey("PixivLastIllustId", keys)
+}
+func PixivCookieInfoKey(keys ...interface{}) string {
+	return NamedKey("PixivCookieInfo", keys)
+}
 func AcfunUserInfoKey(keys ...interface{}) string {
 	return NamedKey("AcfunUserInfo", keys)
 }
@@ -183,6 +348,9 @@ func GlobalEnabledKey(keys ...interface{}) string {
 func GroupMessageImageKey(keys ...interface{}) string {
 	return NamedKey("GroupMessageImage", keys)
 }
+func GroupMessageArchiveKey(keys ...interface{}) string {
+	return NamedKey("GroupMessageArchive", keys)
+}
 func GroupSilenceKey(keys ...interface{}) string {
 	return NamedKey("GroupSilence", keys)
 }
@@ -196,6 +364,23 @@ func GroupInvitorKey(keys ...interface{}) string {
 	return NamedKey("GroupInventor", keys)
 }
 
+func ScoreKey(keys ...interface{}) string {
+	return NamedKey("Score", keys)
+}
+func ScoreDateKey(keys ...interface{}) string {
+	return NamedKey("ScoreDate", keys)
+}
+func ScoreStreakKey(keys ...interface{}) string {
+	return NamedKey("ScoreStreak", keys)
+}
+func ScoreLedgerKey(keys ...interface{}) string {
+	return NamedKey("ScoreLedger", keys)
+}
+
+func TemplateStoreKey(keys ...interface{}) string {
+	return NamedKey("TemplateStore", keys)
+}
+
 func LoliconPoolStoreKey(keys ...interface{}) string {
 	return NamedKey("LoliconPoolStore", keys)
 }
@@ -204,15 +389,88 @@ func ImageCacheKey(keys ...interface{}) string {
 	return NamedKey("ImageCache", keys)
 }
 
+func TranslateCacheKey(keys ...interface{}) string {
+	return NamedKey("TranslateCache", keys)
+}
+
 func ModeKey() string {
 	return NamedKey("Mode", nil)
 }
 func NewFriendRequestKey(keys ...interface{}) string {
 	return NamedKey("NewFriendRequest", keys)
 }
+func PushQueueKey(keys ...interface{}) string {
+	return NamedKey("PushQueue", keys)
+}
 func GroupInvitedKey(keys ...interface{}) string {
 	return NamedKey("GroupInvited", keys)
 }
+func GroupQuietHoursKey(keys ...interface{}) string {
+	return NamedKey("GroupQuietHours", keys)
+}
+func QuietQueueKey(keys ...interface{}) string {
+	return NamedKey("QuietQueue", keys)
+}
+func LastPushKey(keys ...interface{}) string {
+	return NamedKey("LastPush", keys)
+}
+func ConcernFreshErrorKey(keys ...interface{}) string {
+	return NamedKey("ConcernFreshError", keys)
+}
+func StaleConcernKey(keys ...interface{}) string {
+	return NamedKey("StaleConcern", keys)
+}
+func CommandCooldownKey(keys ...interface{}) string {
+	return NamedKey("CommandCooldown", keys)
+}
+func ConcernTagKey(keys ...interface{}) string {
+	return NamedKey("ConcernTag", keys)
+}
+func PushDedupKey(keys ...interface{}) string {
+	return NamedKey("PushDedup", keys)
+}
+func AuditLogKey(keys ...interface{}) string {
+	return NamedKey("AuditLog", keys)
+}
+func HttpSiteConfigKey(keys ...interface{}) string {
+	return NamedKey("HttpSiteConfig", keys)
+}
+func GroupReminderKey(keys ...interface{}) string {
+	return NamedKey("GroupReminder", keys)
+}
+func GroupReminderSeqKey() string {
+	return NamedKey("GroupReminderSeq", nil)
+}
+func GroupLocaleKey(keys ...interface{}) string {
+	return NamedKey("GroupLocale", keys)
+}
+func MentionSubscriberKey(keys ...interface{}) string {
+	return NamedKey("MentionSubscriber", keys)
+}
+func GroupDigestKey(keys ...interface{}) string {
+	return NamedKey("GroupDigest", keys)
+}
+func DigestQueueKey(keys ...interface{}) string {
+	return NamedKey("DigestQueue", keys)
+}
+func BlocklistIdKey(keys ...interface{}) string {
+	return NamedKey("BlocklistId", keys)
+}
+func BlocklistKeywordKey(keys ...interface{}) string {
+	return NamedKey("BlocklistKeyword", keys)
+}
+func FailedPushKey(keys ...interface{}) string {
+	return NamedKey("FailedPush", keys)
+}
+func FailedPushSeqKey() string {
+	return NamedKey("FailedPushSeq", nil)
+}
+func TrialWatchKey(keys ...interface{}) string {
+	return NamedKey("TrialWatch", keys)
+}
+func GroupModuleKey(keys ...interface{}) string {
+	return NamedKey("GroupModule", keys)
+}
 
 func VersionKey(keys ...interface{}) string {
 	return NamedKey("Version", keys)
@@ -2,6 +2,7 @@ package buntdb
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"testing"
 )
 
@@ -88,17 +89,21 @@ func TestKeys(t *testing.T) {
 	BilibiliDynamicIdKey()
 	BilibiliUidFirstTimestampKey()
 	BilibiliUserCookieInfoKey()
+	BilibiliQRCodeLoginInfoKey()
 	BilibiliNotLiveCountKey()
 	BilibiliUserInfoKey()
 	BilibiliUserStatKey()
 	BilibiliGroupAtAllMarkKey()
 	BilibiliNotifyMsgKey()
+	BilibiliNewsHistoryKey()
 	BilibiliCompactMarkKey()
 	DouyuGroupConcernStateKey()
 	DouyuGroupConcernConfigKey()
 	DouyuFreshKey()
 	DouyuCurrentLiveKey()
 	DouyuGroupAtAllMarkKey()
+	DouyuLastReplayKey()
+	DouyuLastNewsKey()
 	YoutubeGroupConcernStateKey()
 	YoutubeGroupConcernConfigKey()
 	YoutubeFreshKey()
@@ -111,6 +116,38 @@ func TestKeys(t *testing.T) {
 	HuyaFreshKey()
 	HuyaCurrentLiveKey()
 	HuyaGroupAtAllMarkKey()
+	HuyaScheduleKey()
+	HuyaScheduleRemindKey()
+	HuyaLastReplayKey()
+	DouyinGroupConcernStateKey()
+	DouyinGroupConcernConfigKey()
+	DouyinFreshKey()
+	DouyinGroupAtAllMarkKey()
+	DouyinUserInfoKey()
+	DouyinCurrentLiveKey()
+	DouyinVideoKey()
+	DouyinLastVideoTimeKey()
+	NeteaseGroupConcernStateKey()
+	NeteaseGroupConcernConfigKey()
+	NeteaseFreshKey()
+	NeteaseGroupAtAllMarkKey()
+	NeteaseUserInfoKey()
+	NeteaseReleaseKey()
+	NeteaseLastReleaseTimeKey()
+	TwitterGroupConcernStateKey()
+	TwitterGroupConcernConfigKey()
+	TwitterFreshKey()
+	TwitterGroupAtAllMarkKey()
+	TwitterUserInfoKey()
+	TwitterTweetKey()
+	TwitterLastTweetIdKey()
+	PixivGroupConcernStateKey()
+	PixivGroupConcernConfigKey()
+	PixivFreshKey()
+	PixivGroupAtAllMarkKey()
+	PixivUserInfoKey()
+	PixivLastIllustIdKey()
+	PixivCookieInfoKey()
 	PermissionKey()
 	BlockListKey()
 	GroupPermissionKey()
@@ -120,14 +157,36 @@ func TestKeys(t *testing.T) {
 	GroupSilenceKey()
 	GlobalSilenceKey()
 	GroupMuteKey()
+	CommandCooldownKey()
+	ConcernTagKey()
+	PushDedupKey()
+	AuditLogKey()
+	HttpSiteConfigKey()
+	GroupReminderKey()
+	GroupReminderSeqKey()
+	GroupLocaleKey()
+	MentionSubscriberKey()
+	GroupDigestKey()
+	DigestQueueKey()
+	BlocklistIdKey()
+	BlocklistKeywordKey()
+	FailedPushKey()
+	FailedPushSeqKey()
+	TrialWatchKey()
+	GroupModuleKey()
 	GroupInvitorKey()
 	LoliconPoolStoreKey()
 	ImageCacheKey()
+	TranslateCacheKey()
 	ModeKey()
 	NewFriendRequestKey()
 	GroupInvitedKey()
 	VersionKey()
 	BilibiliLastFreshKey()
+	BilibiliGuardStatKey()
+	BilibiliReserveKey()
+	BilibiliStatSampleKey()
+	BilibiliLiveSessionKey()
 	AcfunLiveInfoKey()
 	AcfunNotLiveKey()
 	AcfunUidFirstTimestampKey()
@@ -142,3 +201,15 @@ func TestKeys(t *testing.T) {
 		Key(nil)
 	})
 }
+
+type testKeyer int64
+
+func (k testKeyer) Key() string {
+	return "k" + strconv.FormatInt(int64(k), 10)
+}
+
+func TestKeyer(t *testing.T) {
+	assert.Equal(t, "ConcernState:k1:777", BilibiliGroupConcernStateKey(testKeyer(1), Uid))
+	var k Keyer = testKeyer(2)
+	assert.Equal(t, "ConcernState:k2", BilibiliGroupConcernStateKey(k))
+}
@@ -0,0 +1,133 @@
+package buntdb
+
+import (
+	"github.com/tidwall/gjson"
+	"time"
+)
+
+// Tx 是一个数据库事务，所有的读写都通过 Tx 完成，StateManager 与订阅组件只依赖这个接口，
+// 具体的存储引擎由 Storage 提供，方法的语义与 buntdb 相同：
+// key不存在或者已经过期时返回 ErrNotFound ，在只读事务中写入返回 ErrTxNotWritable ，
+// 遍历期间不允许写入，返回 ErrTxIterating
+type Tx interface {
+	// Get 获取key上的值，ignoreExpired为true时已经过期但还没有被清理的key也可以获取到
+	Get(key string, ignoreExpired ...bool) (val string, err error)
+	// Set 设置key上的值，返回之前的值以及是否覆盖了一个没有过期的值，opts为nil时永不过期
+	Set(key, value string, opts *SetOptions) (previousValue string, replaced bool, err error)
+	// Delete 删除key，返回删除前的值
+	Delete(key string) (val string, err error)
+	// TTL 返回key剩余的过期时间，没有设置过期时间时返回-1
+	TTL(key string) (time.Duration, error)
+	// Len 返回数据库中key的数量
+	Len() (int, error)
+	// AscendKeys 按key的顺序遍历匹配pattern的key，pattern中 * 匹配任意数量的字符， ? 匹配一个字符
+	AscendKeys(pattern string, iterator func(key, value string) bool) error
+	// DescendKeys 与 AscendKeys 相同，但是按key的逆序遍历
+	DescendKeys(pattern string, iterator func(key, value string) bool) error
+	// Ascend 按索引的顺序遍历索引中的key，index为空时按key的顺序遍历所有key，索引不存在时返回 ErrNotFound
+	Ascend(index string, iterator func(key, value string) bool) error
+	// AscendEqual 按索引的顺序遍历索引中值与pivot相等的key
+	AscendEqual(index, pivot string, iterator func(key, value string) bool) error
+	// CreateIndex 创建索引，索引包含所有匹配pattern的key，按less依次比较值的大小，值相等时按key排序，
+	// 没有less时索引为空，索引已经存在时返回 ErrIndexExists
+	CreateIndex(name, pattern string, less ...func(a, b string) bool) error
+	// DropIndex 删除索引，索引不存在时返回 ErrNotFound
+	DropIndex(name string) error
+	// Indexes 按名字的顺序返回所有索引
+	Indexes() ([]string, error)
+}
+
+// SetOptions Tx.Set 的过期时间设置，Expires为true时key会在TTL后过期
+type SetOptions struct {
+	Expires bool
+	TTL     time.Duration
+}
+
+// IndexString 不区分大小写比较字符串，用于 Tx.CreateIndex
+func IndexString(a, b string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ca, cb := lowerByte(a[i]), lowerByte(b[i])
+		if ca < cb {
+			return true
+		} else if ca > cb {
+			return false
+		}
+	}
+	return len(a) < len(b)
+}
+
+// IndexBinary 按字节比较字符串，用于 Tx.CreateIndex
+func IndexBinary(a, b string) bool {
+	return a < b
+}
+
+// IndexJSON 比较json中path对应的字段，字段为字符串时不区分大小写，用于 Tx.CreateIndex
+func IndexJSON(path string) func(a, b string) bool {
+	return func(a, b string) bool {
+		return gjson.Get(a, path).Less(gjson.Get(b, path), false)
+	}
+}
+
+func lowerByte(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 32
+	}
+	return c
+}
+
+// compoundLess 把多个less合并成一个，依次比较，前一个相等时才比较下一个
+func compoundLess(lessers []func(a, b string) bool) func(a, b string) bool {
+	switch len(lessers) {
+	case 0:
+		return nil
+	case 1:
+		return lessers[0]
+	}
+	return func(a, b string) bool {
+		for i := 0; i < len(lessers)-1; i++ {
+			if lessers[i](a, b) {
+				return true
+			}
+			if lessers[i](b, a) {
+				return false
+			}
+		}
+		return lessers[len(lessers)-1](a, b)
+	}
+}
+
+// matchPattern 判断key是否匹配pattern， * 匹配任意数量的字符， ? 匹配一个字符
+func matchPattern(key, pattern string) bool {
+	var (
+		k, p         int
+		starP, starK = -1, 0
+	)
+	for k < len(key) {
+		if p < len(pattern) && (pattern[p] == '?' || pattern[p] == key[k]) {
+			k++
+			p++
+		} else if p < len(pattern) && pattern[p] == '*' {
+			starP, starK = p, k
+			p++
+		} else if starP >= 0 {
+			starK++
+			k, p = starK, starP+1
+		} else {
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// patternPrefix 返回pattern中第一个通配符之前的部分，匹配pattern的key一定以它为前缀
+func patternPrefix(pattern string) string {
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '*' || pattern[i] == '?' {
+			return pattern[:i]
+		}
+	}
+	return pattern
+}
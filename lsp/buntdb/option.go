@@ -1,7 +1,6 @@
 package buntdb
 
 import (
-	"github.com/tidwall/buntdb"
 	"strconv"
 	"time"
 )
@@ -15,6 +14,7 @@ type option struct {
 	previous       interface{}
 	ignoreNotFound bool
 	ttl            *time.Duration
+	cache          bool
 }
 
 func (o *option) getIgnoreExpire() bool {
@@ -38,11 +38,11 @@ func (o *option) getExpire() time.Duration {
 	return o.expire
 }
 
-func (o *option) getInnerExpire() *buntdb.SetOptions {
+func (o *option) getInnerExpire() *SetOptions {
 	if o == nil || o.expire <= 0 {
 		return nil
 	}
-	return &buntdb.SetOptions{
+	return &SetOptions{
 		Expires: true,
 		TTL:     o.expire,
 	}
@@ -62,6 +62,13 @@ func (o *option) getTTL() *time.Duration {
 	return o.ttl
 }
 
+func (o *option) getCache() bool {
+	if o == nil {
+		return false
+	}
+	return o.cache
+}
+
 func (o *option) setPrevious(previous string) {
 	if o == nil || o.previous == nil || len(previous) == 0 {
 		return
@@ -172,7 +179,7 @@ func GetIgnoreExpireOpt() OptionFunc {
 	}
 }
 
-// IgnoreNotFoundOpt 获取值时不返回 buntdb.ErrNotFound ，而是返回nil
+// IgnoreNotFoundOpt 获取值时不返回 ErrNotFound ，而是返回nil
 func IgnoreNotFoundOpt() OptionFunc {
 	return func(o *option) {
 		o.ignoreNotFound = true
@@ -186,6 +193,14 @@ func GetTTLOpt(ttl *time.Duration) OptionFunc {
 	}
 }
 
+// GetCacheOpt GetJson配置，开启缓存（ EnableCache ）时，使用内存缓存中已经解析好的对象，省去 json.Unmarshal 的开销
+// 缓存的对象会被浅拷贝到obj上，所以obj的类型不能包含会被修改的指针、slice或者map，obj在解析前设置的字段也会被覆盖
+func GetCacheOpt() OptionFunc {
+	return func(o *option) {
+		o.cache = true
+	}
+}
+
 func getOption(opts ...OptionFunc) *option {
 	var s = new(option)
 	for _, opt := range opts {
@@ -4,7 +4,7 @@ import (
 	"errors"
 	"github.com/Sora233/MiraiGo-Template/utils"
 	"github.com/modern-go/gls"
-	"github.com/tidwall/buntdb"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -24,15 +24,15 @@ var logger = utils.GetModuleLogger("localdb")
 // 可以忽略error，但不要简单地用f返回值替代RWTxCover返回值，ref: bilibili/MarkDynamicId
 // 需要注意可写事务是唯一的，同一时间只会存在一个可写事务，所有耗时操作禁止放在可写事务中执行
 // 在同一Goroutine中，可写事务可以嵌套
-func (*ShortCut) RWCoverTx(f func(tx *buntdb.Tx) error) error {
+func (*ShortCut) RWCoverTx(f func(tx Tx) error) error {
 	if itx := gls.Get(txKey); itx != nil {
-		return f(itx.(*buntdb.Tx))
+		return f(itx.(Tx))
 	}
 	db, err := GetClient()
 	if err != nil {
 		return err
 	}
-	return db.Update(func(tx *buntdb.Tx) error {
+	return db.Update(func(tx Tx) error {
 		var err error
 		gls.WithEmptyGls(func() {
 			gls.Set(txKey, tx)
@@ -42,7 +42,7 @@ func (*ShortCut) RWCoverTx(f func(tx *buntdb.Tx) error) error {
 	})
 }
 
-// RWCover 在一个可读可写事务中执行f，不同的是它不获取 buntdb.Tx ，而由 f 自己控制。
+// RWCover 在一个可读可写事务中执行f，不同的是它不获取 Tx ，而由 f 自己控制。
 // 需要注意可写事务是唯一的，同一时间只会存在一个可写事务，所有耗时操作禁止放在可写事务中执行
 // 在同一Goroutine中，可写事务可以嵌套
 func (*ShortCut) RWCover(f func() error) error {
@@ -53,7 +53,7 @@ func (*ShortCut) RWCover(f func() error) error {
 	if err != nil {
 		return err
 	}
-	return db.Update(func(tx *buntdb.Tx) error {
+	return db.Update(func(tx Tx) error {
 		var err error
 		gls.WithEmptyGls(func() {
 			gls.Set(txKey, tx)
@@ -65,15 +65,15 @@ func (*ShortCut) RWCover(f func() error) error {
 
 // RCoverTx 在一个只读事务中执行f。
 // 所有写操作会失败或者回滚。
-func (*ShortCut) RCoverTx(f func(tx *buntdb.Tx) error) error {
+func (*ShortCut) RCoverTx(f func(tx Tx) error) error {
 	if itx := gls.Get(txKey); itx != nil {
-		return f(itx.(*buntdb.Tx))
+		return f(itx.(Tx))
 	}
 	db, err := GetClient()
 	if err != nil {
 		return err
 	}
-	return db.View(func(tx *buntdb.Tx) error {
+	return db.View(func(tx Tx) error {
 		var err error
 		gls.WithEmptyGls(func() {
 			gls.Set(txKey, tx)
@@ -83,7 +83,7 @@ func (*ShortCut) RCoverTx(f func(tx *buntdb.Tx) error) error {
 	})
 }
 
-// RCover 在一个只读事务中执行f，不同的是它不获取 buntdb.Tx ，而由 f 自己控制。
+// RCover 在一个只读事务中执行f，不同的是它不获取 Tx ，而由 f 自己控制。
 // 所有写操作会失败，或者回滚。
 func (*ShortCut) RCover(f func() error) error {
 	if itx := gls.Get(txKey); itx != nil {
@@ -93,7 +93,7 @@ func (*ShortCut) RCover(f func() error) error {
 	if err != nil {
 		return err
 	}
-	return db.View(func(tx *buntdb.Tx) error {
+	return db.View(func(tx Tx) error {
 		var err error
 		gls.WithEmptyGls(func() {
 			gls.Set(txKey, tx)
@@ -130,14 +130,14 @@ func (s *ShortCut) IncInt64(key string, value int64) (int64, error) {
 }
 
 // GetJson 获取key对应的value，并通过 json.Unmarshal 到obj上
-// 支持 GetIgnoreExpireOpt IgnoreNotFoundOpt GetTTLOpt
+// 支持 GetIgnoreExpireOpt IgnoreNotFoundOpt GetTTLOpt GetCacheOpt
 func (s *ShortCut) GetJson(key string, obj interface{}, opt ...OptionFunc) error {
 	if obj == nil {
 		return errors.New("<nil obj>")
 	}
 	opts := getOption(opt...)
 	var value string
-	err := s.RCoverTx(func(tx *buntdb.Tx) error {
+	err := s.RCoverTx(func(tx Tx) error {
 		var err error
 		value, err = s.getWithOpts(tx, key, opts)
 		return err
@@ -148,7 +148,17 @@ func (s *ShortCut) GetJson(key string, obj interface{}, opt ...OptionFunc) error
 	if len(value) == 0 {
 		return nil
 	}
-	return json.Unmarshal([]byte(value), obj)
+	if !opts.getCache() {
+		return json.Unmarshal([]byte(value), obj)
+	}
+	if cacheLoad(key, value, obj) {
+		return nil
+	}
+	if err = json.Unmarshal([]byte(value), obj); err != nil {
+		return err
+	}
+	cacheStore(key, value, obj)
+	return nil
 }
 
 // SetJson 将obj通过 json.Marshal 转成json字符串，并设置到key上。
@@ -163,7 +173,7 @@ func (s *ShortCut) SetJson(key string, obj interface{}, opt ...OptionFunc) error
 		return err
 	}
 	opts := getOption(opt...)
-	return s.RWCoverTx(func(tx *buntdb.Tx) error {
+	return s.RWCoverTx(func(tx Tx) error {
 		return s.setWithOpts(tx, key, string(b), opts)
 	})
 }
@@ -193,7 +203,7 @@ func (s *ShortCut) SetInt64(key string, value int64, opt ...OptionFunc) error {
 func (s *ShortCut) Delete(key string, opt ...OptionFunc) (string, error) {
 	opts := getOption(opt...)
 	var previous string
-	err := s.RWCoverTx(func(tx *buntdb.Tx) error {
+	err := s.RWCoverTx(func(tx Tx) error {
 		var err error
 		previous, err = s.deleteWithOpts(tx, key, opts)
 		return err
@@ -206,7 +216,7 @@ func (s *ShortCut) Delete(key string, opt ...OptionFunc) (string, error) {
 func (s *ShortCut) Get(key string, opt ...OptionFunc) (string, error) {
 	var result string
 	opts := getOption(opt...)
-	err := s.RCoverTx(func(tx *buntdb.Tx) error {
+	err := s.RCoverTx(func(tx Tx) error {
 		var err error
 		result, err = s.getWithOpts(tx, key, opts)
 		return err
@@ -219,7 +229,7 @@ func (s *ShortCut) Get(key string, opt ...OptionFunc) (string, error) {
 // SetGetPreviousValueStringOpt SetGetPreviousValueInt64Opt SetGetPreviousValueJsonObjectOpt
 func (s *ShortCut) Set(key, value string, opt ...OptionFunc) error {
 	opts := getOption(opt...)
-	return s.RWCoverTx(func(tx *buntdb.Tx) error {
+	return s.RWCoverTx(func(tx Tx) error {
 		return s.setWithOpts(tx, key, value, opts)
 	})
 }
@@ -229,7 +239,7 @@ func (s *ShortCut) Set(key, value string, opt ...OptionFunc) error {
 func (s *ShortCut) Exist(key string, opt ...OptionFunc) bool {
 	var result bool
 	opts := getOption(opt...)
-	err := s.RWCoverTx(func(tx *buntdb.Tx) error {
+	err := s.RWCoverTx(func(tx Tx) error {
 		result = s.existWithOpts(tx, key, opts)
 		return nil
 	})
@@ -242,13 +252,13 @@ func (s *ShortCut) Exist(key string, opt ...OptionFunc) bool {
 	return result
 }
 
-// setWithOpts 统一在有option的情况下的set行为，考虑到性能需要手动传 buntdb.Tx
-func (s *ShortCut) setWithOpts(tx *buntdb.Tx, key string, value string, opt *option) error {
+// setWithOpts 统一在有option的情况下的set行为，考虑到性能需要手动传 Tx
+func (s *ShortCut) setWithOpts(tx Tx, key string, value string, opt *option) error {
 	var (
 		prev     string
 		replaced bool
 		err      error
-		setOpt   *buntdb.SetOptions
+		setOpt   *SetOptions
 	)
 	if innerOpt := opt.getInnerExpire(); innerOpt != nil {
 		setOpt = innerOpt
@@ -259,6 +269,7 @@ func (s *ShortCut) setWithOpts(tx *buntdb.Tx, key string, value string, opt *opt
 		}
 	}
 	prev, replaced, err = tx.Set(key, value, setOpt)
+	cacheInvalidate(key)
 	if err != nil {
 		return err
 	}
@@ -270,8 +281,8 @@ func (s *ShortCut) setWithOpts(tx *buntdb.Tx, key string, value string, opt *opt
 	return nil
 }
 
-// getWithOpts 统一在有option的情况下的get行为，考虑到性能需要手动传 buntdb.Tx
-func (s *ShortCut) getWithOpts(tx *buntdb.Tx, key string, opt *option) (string, error) {
+// getWithOpts 统一在有option的情况下的get行为，考虑到性能需要手动传 Tx
+func (s *ShortCut) getWithOpts(tx Tx, key string, opt *option) (string, error) {
 	result, err := tx.Get(key, opt.getIgnoreExpire())
 	if opt.getTTL() != nil {
 		ttl, _ := tx.TTL(key)
@@ -283,17 +294,18 @@ func (s *ShortCut) getWithOpts(tx *buntdb.Tx, key string, opt *option) (string,
 	return result, err
 }
 
-// deleteWithOpts 统一在有option的情况下的delete行为，考虑到性能需要手动传 buntdb.Tx
-func (s *ShortCut) deleteWithOpts(tx *buntdb.Tx, key string, opt *option) (string, error) {
+// deleteWithOpts 统一在有option的情况下的delete行为，考虑到性能需要手动传 Tx
+func (s *ShortCut) deleteWithOpts(tx Tx, key string, opt *option) (string, error) {
 	result, err := tx.Delete(key)
+	cacheInvalidate(key)
 	if opt.getIgnoreNotFound() && IsNotFound(err) {
 		err = nil
 	}
 	return result, err
 }
 
-// existWithOpts 统一在有option的情况下的exist行为，考虑到性能需要手动传 buntdb.Tx
-func (s *ShortCut) existWithOpts(tx *buntdb.Tx, key string, opt *option) bool {
+// existWithOpts 统一在有option的情况下的exist行为，考虑到性能需要手动传 Tx
+func (s *ShortCut) existWithOpts(tx Tx, key string, opt *option) bool {
 	_, err := tx.Get(key, opt.getIgnoreExpire())
 	if opt.getTTL() != nil {
 		ttl, _ := tx.TTL(key)
@@ -313,13 +325,13 @@ func (s *ShortCut) int64Wrapper(result string, err error) (int64, error) {
 }
 
 func (s *ShortCut) CreatePatternIndex(patternFunc KeyPatternFunc, suffix []interface{}, less ...func(a, b string) bool) error {
-	return s.RWCoverTx(func(tx *buntdb.Tx) error {
+	return s.RWCoverTx(func(tx Tx) error {
 		var err error
 		if len(less) == 0 {
-			err = tx.CreateIndex(patternFunc(suffix...), patternFunc(append(suffix[:], "*")...), buntdb.IndexString)
+			less = append(less, IndexString)
 		}
 		err = tx.CreateIndex(patternFunc(suffix...), patternFunc(append(suffix[:], "*")...), less...)
-		if err == buntdb.ErrIndexExists {
+		if err == ErrIndexExists {
 			err = nil
 		}
 		return err
@@ -331,11 +343,11 @@ func (s *ShortCut) CreatePatternIndex(patternFunc KeyPatternFunc, suffix []inter
 // 可以忽略error，但不要简单地用f返回值替代RWTxCover返回值，ref: bilibili/MarkDynamicId
 // 需要注意可写事务是唯一的，同一时间只会存在一个可写事务，所有耗时操作禁止放在可写事务中执行
 // 在同一Goroutine中，可写事务可以嵌套
-func RWCoverTx(f func(tx *buntdb.Tx) error) error {
+func RWCoverTx(f func(tx Tx) error) error {
 	return shortCut.RWCoverTx(f)
 }
 
-// RWCover 在一个可读可写事务中执行f，不同的是它不获取 buntdb.Tx ，而由 f 自己控制。
+// RWCover 在一个可读可写事务中执行f，不同的是它不获取 Tx ，而由 f 自己控制。
 // 需要注意可写事务是唯一的，同一时间只会存在一个可写事务，所有耗时操作禁止放在可写事务中执行
 // 在同一Goroutine中，可写事务可以嵌套
 func RWCover(f func() error) error {
@@ -344,11 +356,11 @@ func RWCover(f func() error) error {
 
 // RCoverTx 在一个只读事务中执行f。
 // 所有写操作会失败或者回滚。
-func RCoverTx(f func(tx *buntdb.Tx) error) error {
+func RCoverTx(f func(tx Tx) error) error {
 	return shortCut.RCoverTx(f)
 }
 
-// RCover 在一个只读事务中执行f，不同的是它不获取 buntdb.Tx ，而由 f 自己控制。
+// RCover 在一个只读事务中执行f，不同的是它不获取 Tx ，而由 f 自己控制。
 // 所有写操作会失败，或者回滚。
 func RCover(f func() error) error {
 	return shortCut.RCover(f)
@@ -369,7 +381,7 @@ func IncInt64(key string, value int64) (int64, error) {
 }
 
 // GetJson 获取key对应的value，并通过 json.Unmarshal 到obj上
-// 支持 GetIgnoreExpireOpt IgnoreNotFoundOpt GetTTLOpt
+// 支持 GetIgnoreExpireOpt IgnoreNotFoundOpt GetTTLOpt GetCacheOpt
 func GetJson(key string, obj interface{}, opt ...OptionFunc) error {
 	return shortCut.GetJson(key, obj, opt...)
 }
@@ -426,13 +438,13 @@ func Exist(key string, opt ...OptionFunc) bool {
 	return shortCut.Exist(key, opt...)
 }
 
-// ExpireOption 是一个创建 buntdb.SetOptions 的函数糖，当直接操作底层buntdb的时候可以使用。
+// ExpireOption 是一个创建 SetOptions 的函数糖，当直接操作 Tx 的时候可以使用。
 // 使用本package的时候请使用 SetExpireOpt
-func ExpireOption(duration time.Duration) *buntdb.SetOptions {
+func ExpireOption(duration time.Duration) *SetOptions {
 	if duration <= 0 {
 		return nil
 	}
-	return &buntdb.SetOptions{
+	return &SetOptions{
 		Expires: true,
 		TTL:     duration,
 	}
@@ -441,7 +453,7 @@ func ExpireOption(duration time.Duration) *buntdb.SetOptions {
 // RemoveByPrefixAndIndex 遍历每个index，如果一个key满足任意prefix，则删掉
 func RemoveByPrefixAndIndex(prefixKey []string, indexKey []string) ([]string, error) {
 	var deletedKey []string
-	err := RWCoverTx(func(tx *buntdb.Tx) error {
+	err := RWCoverTx(func(tx Tx) error {
 		var removeKey = make(map[string]interface{})
 		var iterErr error
 		for _, index := range indexKey {
@@ -460,6 +472,7 @@ func RemoveByPrefixAndIndex(prefixKey []string, indexKey []string) ([]string, er
 		}
 		for key := range removeKey {
 			_, err := tx.Delete(key)
+			cacheInvalidate(key)
 			if err == nil {
 				deletedKey = append(deletedKey, key)
 			}
@@ -469,6 +482,94 @@ func RemoveByPrefixAndIndex(prefixKey []string, indexKey []string) ([]string, er
 	return deletedKey, err
 }
 
+// RemoveByKeyPrefix 删除prefix本身以及所有以prefix为前缀的key，prefix需要是完整的key，
+// 例如 GroupMute:123 会匹配 GroupMute:123:456，但不会匹配 GroupMute:1234
+// 所有删除在同一个事务中完成，dryRun为true时不删除，只返回将会被删除的key
+func RemoveByKeyPrefix(prefixKey []string, dryRun bool) ([]string, error) {
+	var result []string
+	err := RWCoverTx(func(tx Tx) error {
+		var removeKey = make(map[string]interface{})
+		for _, prefix := range prefixKey {
+			if _, err := tx.Get(prefix); err == nil {
+				removeKey[prefix] = struct{}{}
+			}
+			err := tx.AscendKeys(prefix+":*", func(key, value string) bool {
+				removeKey[key] = struct{}{}
+				return true
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for key := range removeKey {
+			if !dryRun {
+				_, err := tx.Delete(key)
+				cacheInvalidate(key)
+				if err != nil {
+					continue
+				}
+			}
+			result = append(result, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// CopyByKeyPrefix 把fromPrefix[i]本身以及所有以fromPrefix[i]为前缀的key复制为以toPrefix[i]为前缀的key，
+// 前缀的匹配规则与 RemoveByKeyPrefix 相同，复制时保留原来的过期时间，已经存在的key不会被覆盖
+// 所有复制在同一个事务中完成，返回复制后的key和因为已经存在而跳过的key
+func CopyByKeyPrefix(fromPrefix []string, toPrefix []string) (copied []string, skipped []string, err error) {
+	if len(fromPrefix) != len(toPrefix) {
+		return nil, nil, errors.New("prefix length mismatch")
+	}
+	err = RWCoverTx(func(tx Tx) error {
+		var copyKey = make(map[string]string)
+		for idx, prefix := range fromPrefix {
+			if _, err := tx.Get(prefix); err == nil {
+				copyKey[prefix] = toPrefix[idx]
+			}
+			err := tx.AscendKeys(prefix+":*", func(key, value string) bool {
+				copyKey[key] = toPrefix[idx] + strings.TrimPrefix(key, prefix)
+				return true
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for from, to := range copyKey {
+			if _, err := tx.Get(to); err == nil {
+				skipped = append(skipped, to)
+				continue
+			}
+			value, err := tx.Get(from)
+			if err != nil {
+				continue
+			}
+			var opt *SetOptions
+			if ttl, _ := tx.TTL(from); ttl > 0 {
+				opt = ExpireOption(ttl)
+			}
+			if _, _, err = tx.Set(to, value, opt); err != nil {
+				return err
+			}
+			cacheInvalidate(to)
+			copied = append(copied, to)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(copied)
+	sort.Strings(skipped)
+	return
+}
+
 func CreatePatternIndex(patternFunc KeyPatternFunc, suffix []interface{}, less ...func(a, b string) bool) error {
 	return shortCut.CreatePatternIndex(patternFunc, suffix, less...)
 }
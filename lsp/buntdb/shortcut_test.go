@@ -2,9 +2,9 @@ package buntdb
 
 import (
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type test1 struct {
@@ -56,7 +56,7 @@ func TestShortCut_JsonGet(t *testing.T) {
 
 	var notfound = &test1{}
 
-	assert.EqualValues(t, buntdb.ErrNotFound, GetJson("not_found", notfound))
+	assert.EqualValues(t, ErrNotFound, GetJson("not_found", notfound))
 	assert.Nil(t, GetJson("not_found", notfound, IgnoreNotFoundOpt()))
 
 	assert.NotNil(t, GetJson("nil", nil))
@@ -72,3 +72,71 @@ func TestShortCut_JsonGet(t *testing.T) {
 	assert.Nil(t, SetJson(key1, s2, SetGetPreviousValueJsonObjectOpt(&lastS)))
 	assert.EqualValues(t, s1, lastS)
 }
+
+func TestRemoveByKeyPrefix(t *testing.T) {
+	var err error
+	err = InitBuntDB(MEMORYDB)
+	assert.Nil(t, err)
+	defer Close()
+
+	for _, key := range []string{
+		GroupMuteKey(123),
+		GroupMuteKey(123, 1),
+		GroupMuteKey(123, 2),
+		GroupMuteKey(1234, 1),
+		ScoreKey(123, 1),
+		ScoreKey(456, 1),
+	} {
+		assert.Nil(t, Set(key, ""))
+	}
+
+	keys, err := RemoveByKeyPrefix([]string{GroupMuteKey(123), ScoreKey(123)}, true)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 4)
+	assert.True(t, Exist(GroupMuteKey(123, 1)))
+
+	keys, err = RemoveByKeyPrefix([]string{GroupMuteKey(123), ScoreKey(123)}, false)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{GroupMuteKey(123), GroupMuteKey(123, 1), GroupMuteKey(123, 2), ScoreKey(123, 1)}, keys)
+	assert.False(t, Exist(GroupMuteKey(123, 1)))
+	assert.True(t, Exist(GroupMuteKey(1234, 1)))
+	assert.True(t, Exist(ScoreKey(456, 1)))
+}
+
+func TestCopyByKeyPrefix(t *testing.T) {
+	var err error
+	err = InitBuntDB(MEMORYDB)
+	assert.Nil(t, err)
+	defer Close()
+
+	assert.Nil(t, Set(GroupMuteKey(123, 1), "a"))
+	assert.Nil(t, Set(GroupMuteKey(123, 2), "b", SetExpireOpt(time.Hour)))
+	assert.Nil(t, Set(GroupMuteKey(1234, 1), "c"))
+	assert.Nil(t, Set(ScoreKey(123, 1), "d"))
+	assert.Nil(t, Set(ScoreKey(456, 1), "e"))
+
+	_, _, err = CopyByKeyPrefix([]string{GroupMuteKey(123)}, nil)
+	assert.NotNil(t, err)
+
+	copied, skipped, err := CopyByKeyPrefix(
+		[]string{GroupMuteKey(123), ScoreKey(123)},
+		[]string{GroupMuteKey(456), ScoreKey(456)},
+	)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{GroupMuteKey(456, 1), GroupMuteKey(456, 2)}, copied)
+	assert.EqualValues(t, []string{ScoreKey(456, 1)}, skipped)
+
+	val, err := Get(GroupMuteKey(456, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, "a", val)
+	var ttl time.Duration
+	val, err = Get(GroupMuteKey(456, 2), GetTTLOpt(&ttl))
+	assert.Nil(t, err)
+	assert.Equal(t, "b", val)
+	assert.True(t, ttl > 0)
+	val, err = Get(ScoreKey(456, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, "e", val)
+	assert.False(t, Exist(GroupMuteKey(4564, 1)))
+	assert.True(t, Exist(GroupMuteKey(123, 1)))
+}
@@ -0,0 +1,63 @@
+package buntdb
+
+import (
+	"os"
+)
+
+// IndexStat 一个索引中key的数量
+type IndexStat struct {
+	Name  string
+	Count int
+}
+
+// DBFileSize 返回数据库文件的大小，内存数据库或者文件不存在时返回0
+func DBFileSize() (int64, error) {
+	if db == nil {
+		return 0, ErrNotInitialized
+	}
+	if dbPath == MEMORYDB || len(dbPath) == 0 {
+		return 0, nil
+	}
+	fi, err := os.Stat(dbPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// KeyStats 返回数据库中key的总数，以及每个索引中key的数量
+func KeyStats() (total int, indexes []*IndexStat, err error) {
+	if db == nil {
+		return 0, nil, ErrNotInitialized
+	}
+	err = db.View(func(tx Tx) error {
+		var err error
+		total, err = tx.Len()
+		if err != nil {
+			return err
+		}
+		names, err := tx.Indexes()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			var stat = &IndexStat{Name: name}
+			err = tx.Ascend(name, func(key, value string) bool {
+				stat.Count++
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			indexes = append(indexes, stat)
+		}
+		return nil
+	})
+	if err != nil {
+		total, indexes = 0, nil
+	}
+	return
+}
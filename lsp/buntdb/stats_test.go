@@ -0,0 +1,48 @@
+package buntdb
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyStats(t *testing.T) {
+	_, _, err := KeyStats()
+	assert.EqualValues(t, ErrNotInitialized, err)
+	_, err = DBFileSize()
+	assert.EqualValues(t, ErrNotInitialized, err)
+
+	assert.Nil(t, InitBuntDB(MEMORYDB))
+	defer Close()
+
+	assert.Nil(t, MustGetClient().Update(func(tx Tx) error {
+		assert.Nil(t, tx.CreateIndex("a", "a:*", IndexString))
+		for _, key := range []string{"a:1", "a:2", "b:1"} {
+			_, _, err := tx.Set(key, "v", nil)
+			assert.Nil(t, err)
+		}
+		return nil
+	}))
+
+	total, indexes, err := KeyStats()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.EqualValues(t, []*IndexStat{{Name: "a", Count: 2}}, indexes)
+
+	size, err := DBFileSize()
+	assert.Nil(t, err)
+	assert.Zero(t, size)
+}
+
+func TestDBFileSize(t *testing.T) {
+	assert.Nil(t, InitBuntDB(filepath.Join(t.TempDir(), LSPDB)))
+	defer Close()
+
+	assert.Nil(t, MustGetClient().Update(func(tx Tx) error {
+		_, _, err := tx.Set("key", "value", nil)
+		return err
+	}))
+	size, err := DBFileSize()
+	assert.Nil(t, err)
+	assert.Positive(t, size)
+}
@@ -0,0 +1,89 @@
+package buntdb
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	StorageBuntDB   = "buntdb"
+	StorageMemory   = "memory"
+	StorageSnapshot = "snapshot"
+)
+
+// Storage 是数据库的存储引擎，所有读写都通过 View 和 Update 中的 Tx 完成，
+// 上层的 ShortCut 、StateManager 以及订阅组件不依赖具体的引擎，可以在配置中通过 db.storage 切换
+type Storage interface {
+	// Open 打开path对应的数据库，path为 MEMORYDB 时数据只保存在内存中
+	Open(path string) error
+	// View 在一个只读事务中执行fn
+	View(fn func(tx Tx) error) error
+	// Update 在一个可读可写事务中执行fn，fn返回错误或者panic时事务中的修改全部回滚
+	Update(fn func(tx Tx) error) error
+	// Save 把数据库的快照写入w，格式与 buntdb 的数据文件相同，用于备份
+	Save(w io.Writer) error
+	// Shrink 重写数据库文件，删除已经过期或者被覆盖的数据
+	Shrink() error
+	// Close 关闭数据库并释放占用的资源
+	Close() error
+}
+
+// StorageFactory 每次初始化数据库时创建一个新的 Storage
+type StorageFactory func() Storage
+
+var (
+	storageMutex    sync.RWMutex
+	storageRegistry = make(map[string]StorageFactory)
+)
+
+// RegisterStorage 注册一种存储引擎，可以在 init 中调用，之后即可在配置中通过 name 选择
+func RegisterStorage(name string, factory StorageFactory) {
+	storageMutex.Lock()
+	defer storageMutex.Unlock()
+	if factory == nil {
+		panic("RegisterStorage: <nil> factory")
+	}
+	name = strings.ToLower(name)
+	if _, found := storageRegistry[name]; found {
+		panic(fmt.Sprintf("RegisterStorage: storage %v already registered", name))
+	}
+	storageRegistry[name] = factory
+}
+
+// ListStorage 返回所有已注册的存储引擎
+func ListStorage() []string {
+	storageMutex.RLock()
+	defer storageMutex.RUnlock()
+	return listStorage()
+}
+
+func listStorage() []string {
+	var result []string
+	for name := range storageRegistry {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func getStorageFactory(name string) (StorageFactory, error) {
+	storageMutex.RLock()
+	defer storageMutex.RUnlock()
+	if name == "" {
+		name = StorageBuntDB
+	}
+	factory, found := storageRegistry[strings.ToLower(name)]
+	if !found {
+		return nil, fmt.Errorf("%w: %v，支持的存储：%v", ErrStorageNotFound, name, strings.Join(listStorage(), " / "))
+	}
+	return factory, nil
+}
+
+func init() {
+	RegisterStorage(StorageBuntDB, func() Storage { return new(buntDBStorage) })
+	RegisterStorage(StorageMemory, func() Storage { return &buntDBStorage{memory: true} })
+	RegisterStorage(StorageSnapshot, func() Storage { return newSnapshotStorage() })
+}
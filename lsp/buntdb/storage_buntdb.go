@@ -0,0 +1,177 @@
+package buntdb
+
+import (
+	"fmt"
+	"github.com/gofrs/flock"
+	"github.com/tidwall/buntdb"
+	"io"
+	"time"
+)
+
+// buntDBStorage 默认的存储引擎，使用 buntdb 把数据保存在本地的单个文件中，使用文件锁防止重复打开，
+// memory为true时数据只保存在内存中，重启后丢失，适用于测试
+type buntDBStorage struct {
+	memory   bool
+	db       *buntdb.DB
+	fileLock *flock.Flock
+}
+
+func (s *buntDBStorage) Open(dbpath string) error {
+	if dbpath == "" {
+		dbpath = LSPDB
+	}
+	if s.memory || dbpath == MEMORYDB {
+		buntDB, err := buntdb.Open(MEMORYDB)
+		if err != nil {
+			return err
+		}
+		s.db = buntDB
+		return nil
+	}
+	var dblock = dbpath + ".lock"
+	s.fileLock = flock.New(dblock)
+	ok, err := s.fileLock.TryLock()
+	if err != nil {
+		fmt.Printf("buntdb tryLock err: %v", err)
+	}
+	if !ok {
+		return ErrLockNotHold
+	}
+	buntDB, err := buntdb.Open(dbpath)
+	if err != nil {
+		s.fileLock.Unlock()
+		return err
+	}
+	buntDB.SetConfig(buntdb.Config{
+		SyncPolicy:           buntdb.EverySecond,
+		AutoShrinkPercentage: 10,
+		AutoShrinkMinSize:    1 * 1024 * 1024,
+	})
+	s.db = buntDB
+	return nil
+}
+
+func (s *buntDBStorage) View(fn func(tx Tx) error) error {
+	return s.db.View(func(tx *buntdb.Tx) error {
+		return fn(&buntTx{tx})
+	})
+}
+
+// Update buntdb 在fn panic时会提交事务，这里先转换成错误让 buntdb 回滚，再重新panic
+func (s *buntDBStorage) Update(fn func(tx Tx) error) error {
+	var panicked interface{}
+	err := s.db.Update(func(tx *buntdb.Tx) (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				panicked = e
+				err = ErrRollback
+			}
+		}()
+		return fn(&buntTx{tx})
+	})
+	if panicked != nil {
+		panic(panicked)
+	}
+	return err
+}
+
+func (s *buntDBStorage) Save(w io.Writer) error {
+	return s.db.Save(w)
+}
+
+func (s *buntDBStorage) Shrink() error {
+	return fromBuntErr(s.db.Shrink())
+}
+
+func (s *buntDBStorage) Close() error {
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	if s.fileLock != nil {
+		return s.fileLock.Unlock()
+	}
+	return nil
+}
+
+// buntTx 把 buntdb.Tx 包装成 Tx ，并把 buntdb 的错误转换为本package的错误
+type buntTx struct {
+	tx *buntdb.Tx
+}
+
+func (t *buntTx) Get(key string, ignoreExpired ...bool) (string, error) {
+	val, err := t.tx.Get(key, ignoreExpired...)
+	return val, fromBuntErr(err)
+}
+
+func (t *buntTx) Set(key, value string, opts *SetOptions) (string, bool, error) {
+	var setOpts *buntdb.SetOptions
+	if opts != nil {
+		setOpts = &buntdb.SetOptions{Expires: opts.Expires, TTL: opts.TTL}
+	}
+	previous, replaced, err := t.tx.Set(key, value, setOpts)
+	return previous, replaced, fromBuntErr(err)
+}
+
+func (t *buntTx) Delete(key string) (string, error) {
+	val, err := t.tx.Delete(key)
+	return val, fromBuntErr(err)
+}
+
+func (t *buntTx) TTL(key string) (time.Duration, error) {
+	ttl, err := t.tx.TTL(key)
+	return ttl, fromBuntErr(err)
+}
+
+func (t *buntTx) Len() (int, error) {
+	n, err := t.tx.Len()
+	return n, fromBuntErr(err)
+}
+
+func (t *buntTx) AscendKeys(pattern string, iterator func(key, value string) bool) error {
+	return fromBuntErr(t.tx.AscendKeys(pattern, iterator))
+}
+
+func (t *buntTx) DescendKeys(pattern string, iterator func(key, value string) bool) error {
+	return fromBuntErr(t.tx.DescendKeys(pattern, iterator))
+}
+
+func (t *buntTx) Ascend(index string, iterator func(key, value string) bool) error {
+	return fromBuntErr(t.tx.Ascend(index, iterator))
+}
+
+func (t *buntTx) AscendEqual(index, pivot string, iterator func(key, value string) bool) error {
+	return fromBuntErr(t.tx.AscendEqual(index, pivot, iterator))
+}
+
+func (t *buntTx) CreateIndex(name, pattern string, less ...func(a, b string) bool) error {
+	return fromBuntErr(t.tx.CreateIndex(name, pattern, less...))
+}
+
+func (t *buntTx) DropIndex(name string) error {
+	return fromBuntErr(t.tx.DropIndex(name))
+}
+
+func (t *buntTx) Indexes() ([]string, error) {
+	names, err := t.tx.Indexes()
+	return names, fromBuntErr(err)
+}
+
+func fromBuntErr(err error) error {
+	switch err {
+	case buntdb.ErrNotFound:
+		return ErrNotFound
+	case buntdb.ErrIndexExists:
+		return ErrIndexExists
+	case buntdb.ErrTxNotWritable:
+		return ErrTxNotWritable
+	case buntdb.ErrTxIterating:
+		return ErrTxIterating
+	case buntdb.ErrTxClosed:
+		return ErrTxClosed
+	case buntdb.ErrDatabaseClosed:
+		return ErrDatabaseClosed
+	case buntdb.ErrInvalid:
+		return ErrInvalidData
+	}
+	return err
+}
@@ -0,0 +1,704 @@
+package buntdb
+
+import (
+	"bufio"
+	"errors"
+	"github.com/gofrs/flock"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// snapshotInterval 检查数据是否有修改并写入快照的间隔，同时也是清理过期key的间隔
+const snapshotInterval = time.Second
+
+// snapshotStorage 不依赖 buntdb 的存储引擎，数据保存在内存的有序表中，
+// 有修改时每隔 snapshotInterval 把完整的快照写入文件（先写入临时文件再替换），
+// 异常退出时最多丢失 snapshotInterval 内的修改。
+// 快照的格式与 buntdb 的数据文件相同，可以直接读取 buntdb 的数据文件，写入的快照也可以被 buntdb 读取，
+// 因此可以随时在两种引擎之间切换。
+// 每次写入都是完整的快照，适用于数据量不大但是写入频繁、不希望数据文件不断增长的场景
+type snapshotStorage struct {
+	mu       sync.RWMutex
+	path     string
+	fileLock *flock.Flock
+	closed   bool
+	items    map[string]*snapshotItem
+	keys     []string
+	indexes  map[string]*snapshotIndex
+	// dirty 不为0时说明有还没有写入快照的修改
+	dirty int32
+	// saveMu 保证同一时间只有一个goroutine写入快照文件
+	saveMu sync.Mutex
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type snapshotItem struct {
+	value string
+	// expireAt 为零值时永不过期
+	expireAt time.Time
+}
+
+func (i *snapshotItem) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && now.After(i.expireAt)
+}
+
+type snapshotIndex struct {
+	pattern string
+	less    func(a, b string) bool
+}
+
+func newSnapshotStorage() *snapshotStorage {
+	return &snapshotStorage{
+		items:   make(map[string]*snapshotItem),
+		indexes: make(map[string]*snapshotIndex),
+		stop:    make(chan struct{}),
+	}
+}
+
+func (s *snapshotStorage) Open(dbpath string) error {
+	if dbpath == "" {
+		dbpath = LSPDB
+	}
+	if dbpath != MEMORYDB {
+		s.fileLock = flock.New(dbpath + ".lock")
+		ok, err := s.fileLock.TryLock()
+		if err != nil {
+			logger.Errorf("snapshot tryLock err: %v", err)
+		}
+		if !ok {
+			return ErrLockNotHold
+		}
+		if err = s.loadFile(dbpath); err != nil {
+			s.fileLock.Unlock()
+			return err
+		}
+		s.path = dbpath
+	}
+	s.wg.Add(1)
+	go s.background()
+	return nil
+}
+
+// loadFile 读取数据文件，文件不存在时为空数据库
+func (s *snapshotStorage) loadFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	err = s.load(f, fi.ModTime())
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// buntdb 的数据文件在异常退出时最后一条记录可能不完整，忽略这条记录
+		logger.Warnf("数据文件%v的最后一条记录不完整，已忽略", path)
+		err = nil
+	}
+	if err == nil {
+		// 读取的可能是 buntdb 的数据文件，尽快重写为快照
+		atomic.StoreInt32(&s.dirty, 1)
+	}
+	return err
+}
+
+// load 读取 buntdb 格式的数据，modTime为数据写入的时间，用于计算剩余的过期时间
+func (s *snapshotStorage) load(r io.Reader, modTime time.Time) error {
+	return readCommands(r, func(parts []string) error {
+		switch strings.ToLower(parts[0]) {
+		case "set":
+			if len(parts) != 3 && len(parts) != 5 {
+				return ErrInvalidData
+			}
+			var item = &snapshotItem{value: parts[2]}
+			if len(parts) == 5 {
+				if strings.ToLower(parts[3]) != "ex" {
+					return ErrInvalidData
+				}
+				ex, err := strconv.ParseUint(parts[4], 10, 64)
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+				dur := time.Duration(ex)*time.Second - now.Sub(modTime)
+				if dur <= 0 {
+					s.remove(parts[1])
+					return nil
+				}
+				item.expireAt = now.Add(dur)
+			}
+			s.insert(parts[1], item)
+		case "del":
+			if len(parts) != 2 {
+				return ErrInvalidData
+			}
+			s.remove(parts[1])
+		case "flushdb":
+			s.items = make(map[string]*snapshotItem)
+			s.keys = nil
+		default:
+			return ErrInvalidData
+		}
+		return nil
+	})
+}
+
+func (s *snapshotStorage) background() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.deleteExpired()
+			if err := s.persist(); err != nil {
+				logger.Errorf("snapshot persist error %v", err)
+			}
+		}
+	}
+}
+
+// deleteExpired 删除所有已经过期的key
+func (s *snapshotStorage) deleteExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var now = time.Now()
+	for key, item := range s.items {
+		if item.expired(now) {
+			s.remove(key)
+			atomic.StoreInt32(&s.dirty, 1)
+		}
+	}
+}
+
+// persist 如果有还没有写入的修改，把快照写入文件，内存数据库不做任何操作
+func (s *snapshotStorage) persist() error {
+	if len(s.path) == 0 {
+		return nil
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	if atomic.SwapInt32(&s.dirty, 0) == 0 {
+		s.mu.RUnlock()
+		return nil
+	}
+	buf := s.appendSnapshot(nil, time.Now())
+	s.mu.RUnlock()
+	if err := writeFileAtomic(s.path, buf); err != nil {
+		atomic.StoreInt32(&s.dirty, 1)
+		return err
+	}
+	return nil
+}
+
+func (s *snapshotStorage) appendSnapshot(buf []byte, now time.Time) []byte {
+	for _, key := range s.keys {
+		item := s.items[key]
+		if item.expireAt.IsZero() {
+			buf = appendCommand(buf, "set", key, item.value)
+		} else if !item.expired(now) {
+			ex := item.expireAt.Sub(now) / time.Second
+			buf = appendCommand(buf, "set", key, item.value, "ex", strconv.FormatUint(uint64(ex), 10))
+		}
+	}
+	return buf
+}
+
+func (s *snapshotStorage) View(fn func(tx Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrDatabaseClosed
+	}
+	tx := &snapshotTx{s: s}
+	defer tx.close()
+	return fn(tx)
+}
+
+func (s *snapshotStorage) Update(fn func(tx Tx) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrDatabaseClosed
+	}
+	tx := &snapshotTx{
+		s:               s,
+		writable:        true,
+		rollbackItems:   make(map[string]*snapshotItem),
+		rollbackIndexes: make(map[string]*snapshotIndex),
+	}
+	defer tx.close()
+	defer func() {
+		if e := recover(); e != nil {
+			tx.rollback()
+			panic(e)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	if len(tx.rollbackItems) > 0 {
+		atomic.StoreInt32(&s.dirty, 1)
+	}
+	return nil
+}
+
+func (s *snapshotStorage) Save(w io.Writer) error {
+	s.mu.RLock()
+	buf := s.appendSnapshot(nil, time.Now())
+	s.mu.RUnlock()
+	_, err := w.Write(buf)
+	return err
+}
+
+// Shrink 快照中没有过期或者被覆盖的数据，立即写入一次快照
+func (s *snapshotStorage) Shrink() error {
+	atomic.StoreInt32(&s.dirty, 1)
+	return s.persist()
+}
+
+func (s *snapshotStorage) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrDatabaseClosed
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.stop)
+	s.wg.Wait()
+	err := s.persist()
+	if s.fileLock != nil {
+		if unlockErr := s.fileLock.Unlock(); err == nil {
+			err = unlockErr
+		}
+	}
+	return err
+}
+
+// insert 插入或者覆盖key，返回之前的值
+func (s *snapshotStorage) insert(key string, item *snapshotItem) *snapshotItem {
+	prev, found := s.items[key]
+	if !found {
+		i := sort.SearchStrings(s.keys, key)
+		s.keys = append(s.keys, "")
+		copy(s.keys[i+1:], s.keys[i:])
+		s.keys[i] = key
+	}
+	s.items[key] = item
+	return prev
+}
+
+// remove 删除key，返回之前的值
+func (s *snapshotStorage) remove(key string) *snapshotItem {
+	prev, found := s.items[key]
+	if !found {
+		return nil
+	}
+	delete(s.items, key)
+	i := sort.SearchStrings(s.keys, key)
+	s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	return prev
+}
+
+// prefixRange 返回以prefix为前缀的key在keys中的范围
+func (s *snapshotStorage) prefixRange(prefix string) (int, int) {
+	start := sort.SearchStrings(s.keys, prefix)
+	end := start
+	for end < len(s.keys) && strings.HasPrefix(s.keys[end], prefix) {
+		end++
+	}
+	return start, end
+}
+
+// snapshotTx snapshotStorage 的事务，可写事务记录每个key和索引修改前的状态，回滚时恢复
+type snapshotTx struct {
+	s         *snapshotStorage
+	writable  bool
+	closed    bool
+	iterating int
+	// rollbackItems 中值为nil表示key在事务开始前不存在
+	rollbackItems   map[string]*snapshotItem
+	rollbackIndexes map[string]*snapshotIndex
+}
+
+func (t *snapshotTx) close() {
+	t.closed = true
+}
+
+func (t *snapshotTx) rollback() {
+	for key, item := range t.rollbackItems {
+		if item == nil {
+			t.s.remove(key)
+		} else {
+			t.s.insert(key, item)
+		}
+	}
+	for name, idx := range t.rollbackIndexes {
+		if idx == nil {
+			delete(t.s.indexes, name)
+		} else {
+			t.s.indexes[name] = idx
+		}
+	}
+}
+
+func (t *snapshotTx) checkWrite() error {
+	if t.closed {
+		return ErrTxClosed
+	} else if !t.writable {
+		return ErrTxNotWritable
+	} else if t.iterating > 0 {
+		return ErrTxIterating
+	}
+	return nil
+}
+
+func (t *snapshotTx) Get(key string, ignoreExpired ...bool) (string, error) {
+	if t.closed {
+		return "", ErrTxClosed
+	}
+	item := t.s.items[key]
+	if item == nil || (item.expired(time.Now()) && (len(ignoreExpired) == 0 || !ignoreExpired[0])) {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (t *snapshotTx) Set(key, value string, opts *SetOptions) (previousValue string, replaced bool, err error) {
+	if err = t.checkWrite(); err != nil {
+		return "", false, err
+	}
+	var now = time.Now()
+	var item = &snapshotItem{value: value}
+	if opts != nil && opts.Expires {
+		item.expireAt = now.Add(opts.TTL)
+	}
+	prev := t.s.insert(key, item)
+	if _, found := t.rollbackItems[key]; !found {
+		t.rollbackItems[key] = prev
+	}
+	if prev != nil && !prev.expired(now) {
+		previousValue, replaced = prev.value, true
+	}
+	return
+}
+
+func (t *snapshotTx) Delete(key string) (string, error) {
+	if err := t.checkWrite(); err != nil {
+		return "", err
+	}
+	prev := t.s.remove(key)
+	if prev == nil {
+		return "", ErrNotFound
+	}
+	if _, found := t.rollbackItems[key]; !found {
+		t.rollbackItems[key] = prev
+	}
+	if prev.expired(time.Now()) {
+		return "", ErrNotFound
+	}
+	return prev.value, nil
+}
+
+func (t *snapshotTx) TTL(key string) (time.Duration, error) {
+	if t.closed {
+		return 0, ErrTxClosed
+	}
+	item := t.s.items[key]
+	if item == nil {
+		return 0, ErrNotFound
+	} else if item.expireAt.IsZero() {
+		return -1, nil
+	}
+	dur := time.Until(item.expireAt)
+	if dur < 0 {
+		return 0, ErrNotFound
+	}
+	return dur, nil
+}
+
+func (t *snapshotTx) Len() (int, error) {
+	if t.closed {
+		return 0, ErrTxClosed
+	}
+	return len(t.s.items), nil
+}
+
+// iterate 依次对keys中没有过期的key调用iterator，遍历期间不允许写入
+func (t *snapshotTx) iterate(keys []string, iterator func(key, value string) bool) {
+	t.iterating++
+	defer func() {
+		t.iterating--
+	}()
+	var now = time.Now()
+	for _, key := range keys {
+		item := t.s.items[key]
+		if item == nil || item.expired(now) {
+			continue
+		}
+		if !iterator(key, item.value) {
+			return
+		}
+	}
+}
+
+// matchKeys 按顺序返回匹配pattern的key
+func (t *snapshotTx) matchKeys(pattern string) []string {
+	start, end := t.s.prefixRange(patternPrefix(pattern))
+	var result []string
+	for _, key := range t.s.keys[start:end] {
+		if matchPattern(key, pattern) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+func (t *snapshotTx) AscendKeys(pattern string, iterator func(key, value string) bool) error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	if pattern == "" {
+		return nil
+	}
+	t.iterate(t.matchKeys(pattern), iterator)
+	return nil
+}
+
+func (t *snapshotTx) DescendKeys(pattern string, iterator func(key, value string) bool) error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	if pattern == "" {
+		return nil
+	}
+	keys := t.matchKeys(pattern)
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+	t.iterate(keys, iterator)
+	return nil
+}
+
+// indexKeys 按索引的顺序返回索引中的key，值相等时按key排序
+func (t *snapshotTx) indexKeys(index string) ([]string, func(a, b string) bool, error) {
+	if index == "" {
+		return append([]string(nil), t.s.keys...), nil, nil
+	}
+	idx, found := t.s.indexes[index]
+	if !found {
+		return nil, nil, ErrNotFound
+	}
+	if idx.less == nil {
+		return nil, nil, nil
+	}
+	keys := t.matchKeys(idx.pattern)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return idx.less(t.s.items[keys[i]].value, t.s.items[keys[j]].value)
+	})
+	return keys, idx.less, nil
+}
+
+func (t *snapshotTx) Ascend(index string, iterator func(key, value string) bool) error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	keys, _, err := t.indexKeys(index)
+	if err != nil {
+		return err
+	}
+	t.iterate(keys, iterator)
+	return nil
+}
+
+func (t *snapshotTx) AscendEqual(index, pivot string, iterator func(key, value string) bool) error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	if index == "" {
+		t.iterate([]string{pivot}, iterator)
+		return nil
+	}
+	keys, less, err := t.indexKeys(index)
+	if err != nil {
+		return err
+	}
+	var equal []string
+	for _, key := range keys {
+		value := t.s.items[key].value
+		if less(value, pivot) {
+			continue
+		}
+		if less(pivot, value) {
+			break
+		}
+		equal = append(equal, key)
+	}
+	t.iterate(equal, iterator)
+	return nil
+}
+
+func (t *snapshotTx) CreateIndex(name, pattern string, less ...func(a, b string) bool) error {
+	if err := t.checkWrite(); err != nil {
+		return err
+	}
+	if name == "" {
+		return ErrIndexExists
+	}
+	if _, found := t.s.indexes[name]; found {
+		return ErrIndexExists
+	}
+	t.s.indexes[name] = &snapshotIndex{pattern: pattern, less: compoundLess(less)}
+	if _, found := t.rollbackIndexes[name]; !found {
+		t.rollbackIndexes[name] = nil
+	}
+	return nil
+}
+
+func (t *snapshotTx) DropIndex(name string) error {
+	if err := t.checkWrite(); err != nil {
+		return err
+	}
+	idx, found := t.s.indexes[name]
+	if !found {
+		return ErrNotFound
+	}
+	delete(t.s.indexes, name)
+	if _, found := t.rollbackIndexes[name]; !found {
+		t.rollbackIndexes[name] = idx
+	}
+	return nil
+}
+
+func (t *snapshotTx) Indexes() ([]string, error) {
+	if t.closed {
+		return nil, ErrTxClosed
+	}
+	var names = make([]string, 0, len(t.s.indexes))
+	for name := range t.s.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// appendCommand 把一条命令按照 buntdb 数据文件的格式（RESP）追加到buf
+func appendCommand(buf []byte, parts ...string) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(parts)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, part := range parts {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(part)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, part...)
+		buf = append(buf, '\r', '\n')
+	}
+	return buf
+}
+
+// readCommands 依次读取 buntdb 数据文件中的命令，最后一条命令不完整时返回 io.ErrUnexpectedEOF
+func readCommands(r io.Reader, fn func(parts []string) error) error {
+	br := bufio.NewReader(r)
+	readLength := func(prefix byte) (int, error) {
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(line) < 4 || line[0] != prefix || line[len(line)-2] != '\r' {
+			return 0, ErrInvalidData
+		}
+		n, err := strconv.Atoi(line[1 : len(line)-2])
+		if err != nil || n < 0 {
+			return 0, ErrInvalidData
+		}
+		return n, nil
+	}
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == 0 {
+			// buntdb 会忽略nul字符
+			continue
+		}
+		if err = br.UnreadByte(); err != nil {
+			return err
+		}
+		n, err := readLength('*')
+		if err != nil {
+			return err
+		}
+		var parts = make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			size, err := readLength('$')
+			if err != nil {
+				return err
+			}
+			var data = make([]byte, size+2)
+			if _, err = io.ReadFull(br, data); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if data[size] != '\r' || data[size+1] != '\n' {
+				return ErrInvalidData
+			}
+			parts = append(parts, string(data[:size]))
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if err = fn(parts); err != nil {
+			return err
+		}
+	}
+}
+
+// writeFileAtomic 先把data写入临时文件，再替换path，保证path中始终是完整的数据
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
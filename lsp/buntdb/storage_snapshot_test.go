@@ -0,0 +1,291 @@
+package buntdb
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testTxStorage 对不同的存储引擎执行相同的检查，保证 Tx 的语义一致
+func testTxStorage(t *testing.T, s Storage) {
+	assert.Nil(t, s.Update(func(tx Tx) error {
+		for _, key := range []string{"a:1", "a:2", "a:10", "b:1"} {
+			if _, _, err := tx.Set(key, key, nil); err != nil {
+				return err
+			}
+		}
+		prev, replaced, err := tx.Set("a:1", `{"n":3}`, nil)
+		assert.Nil(t, err)
+		assert.True(t, replaced)
+		assert.Equal(t, "a:1", prev)
+		_, _, err = tx.Set("a:2", `{"n":1}`, ExpireOption(time.Hour))
+		return err
+	}))
+
+	assert.Nil(t, s.View(func(tx Tx) error {
+		_, _, err := tx.Set("c", "c", nil)
+		assert.Equal(t, ErrTxNotWritable, err)
+
+		v, err := tx.Get("a:1")
+		assert.Nil(t, err)
+		assert.Equal(t, `{"n":3}`, v)
+		_, err = tx.Get("not-exist")
+		assert.Equal(t, ErrNotFound, err)
+
+		ttl, err := tx.TTL("a:2")
+		assert.Nil(t, err)
+		assert.True(t, ttl > time.Minute*59)
+		ttl, err = tx.TTL("a:1")
+		assert.Nil(t, err)
+		assert.EqualValues(t, -1, ttl)
+		_, err = tx.TTL("not-exist")
+		assert.Equal(t, ErrNotFound, err)
+
+		n, err := tx.Len()
+		assert.Nil(t, err)
+		assert.Equal(t, 4, n)
+
+		var keys []string
+		assert.Nil(t, tx.AscendKeys("a:?", func(key, value string) bool {
+			keys = append(keys, key)
+			return true
+		}))
+		assert.Equal(t, []string{"a:1", "a:2"}, keys)
+		keys = nil
+		assert.Nil(t, tx.DescendKeys("a:*", func(key, value string) bool {
+			keys = append(keys, key)
+			return len(keys) < 2
+		}))
+		assert.Equal(t, []string{"a:2", "a:10"}, keys)
+		keys = nil
+		assert.Nil(t, tx.Ascend("", func(key, value string) bool {
+			keys = append(keys, key)
+			return true
+		}))
+		assert.Equal(t, []string{"a:1", "a:10", "a:2", "b:1"}, keys)
+		return nil
+	}))
+
+	// 出错时回滚所有修改，包括索引
+	err := s.Update(func(tx Tx) error {
+		tx.Set("a:1", "changed", nil)
+		tx.Delete("b:1")
+		tx.Set("new", "new", nil)
+		tx.CreateIndex("rollback", "*", IndexString)
+		return ErrRollback
+	})
+	assert.Equal(t, ErrRollback, err)
+	assert.Nil(t, s.View(func(tx Tx) error {
+		v, err := tx.Get("a:1")
+		assert.Nil(t, err)
+		assert.Equal(t, `{"n":3}`, v)
+		_, err = tx.Get("b:1")
+		assert.Nil(t, err)
+		_, err = tx.Get("new")
+		assert.Equal(t, ErrNotFound, err)
+		names, err := tx.Indexes()
+		assert.Nil(t, err)
+		assert.Empty(t, names)
+		return nil
+	}))
+
+	assert.Nil(t, s.Update(func(tx Tx) error {
+		assert.Nil(t, tx.CreateIndex("n", "a:*", IndexJSON("n")))
+		assert.Equal(t, ErrIndexExists, tx.CreateIndex("n", "a:*", IndexJSON("n")))
+		assert.Nil(t, tx.CreateIndex("none", "a:*"))
+		assert.Equal(t, ErrNotFound, tx.DropIndex("not-exist"))
+
+		var keys []string
+		assert.Nil(t, tx.Ascend("n", func(key, value string) bool {
+			keys = append(keys, key)
+			// 遍历期间不允许写入
+			_, _, err := tx.Set("c", "c", nil)
+			assert.Equal(t, ErrTxIterating, err)
+			return true
+		}))
+		// a:10 的值不是json，n为空，排在最前面
+		assert.Equal(t, []string{"a:10", "a:2", "a:1"}, keys)
+		keys = nil
+		assert.Nil(t, tx.AscendEqual("n", `{"n":1}`, func(key, value string) bool {
+			keys = append(keys, key)
+			return true
+		}))
+		assert.Equal(t, []string{"a:2"}, keys)
+		assert.Nil(t, tx.Ascend("none", func(key, value string) bool {
+			t.Errorf("index without less should be empty")
+			return true
+		}))
+		assert.Equal(t, ErrNotFound, tx.Ascend("not-exist", func(key, value string) bool { return true }))
+
+		val, err := tx.Delete("a:10")
+		assert.Nil(t, err)
+		assert.Equal(t, "a:10", val)
+		_, err = tx.Delete("a:10")
+		assert.Equal(t, ErrNotFound, err)
+		names, err := tx.Indexes()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"n", "none"}, names)
+		return nil
+	}))
+
+	// 过期的key
+	assert.Nil(t, s.Update(func(tx Tx) error {
+		_, _, err := tx.Set("expire", "expire", ExpireOption(time.Millisecond))
+		return err
+	}))
+	time.Sleep(time.Millisecond * 5)
+	assert.Nil(t, s.View(func(tx Tx) error {
+		_, err := tx.Get("expire")
+		assert.Equal(t, ErrNotFound, err)
+		v, err := tx.Get("expire", true)
+		if err == nil {
+			assert.Equal(t, "expire", v)
+		}
+		_, err = tx.TTL("expire")
+		assert.Equal(t, ErrNotFound, err)
+		return nil
+	}))
+
+	// 出错时panic也会回滚
+	assert.Panics(t, func() {
+		s.Update(func(tx Tx) error {
+			tx.Set("panic", "panic", nil)
+			panic("test")
+		})
+	})
+	assert.Nil(t, s.View(func(tx Tx) error {
+		_, err := tx.Get("panic")
+		assert.Equal(t, ErrNotFound, err)
+		return nil
+	}))
+}
+
+func TestStorageTx(t *testing.T) {
+	for _, name := range []string{StorageMemory, StorageSnapshot} {
+		t.Run(name, func(t *testing.T) {
+			factory, err := getStorageFactory(name)
+			assert.Nil(t, err)
+			s := factory()
+			assert.Nil(t, s.Open(MEMORYDB))
+			testTxStorage(t, s)
+			assert.Nil(t, s.Close())
+		})
+	}
+}
+
+func TestSnapshotStorage(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "test.db")
+
+	assert.Nil(t, InitStorage(StorageSnapshot, dbpath))
+	assert.Nil(t, Set("a", "b"))
+	assert.Nil(t, Set("expire", "b", SetExpireOpt(time.Hour)))
+	assert.Nil(t, SetJson("json", map[string]int{"a": 1}))
+	// 同一个文件不能被打开两次
+	assert.Equal(t, ErrLockNotHold, newSnapshotStorage().Open(dbpath))
+	// 有修改时定期写入快照，不需要等到关闭
+	assert.Eventually(t, func() bool {
+		return CheckBackup(dbpath) == nil && func() bool {
+			s := newSnapshotStorage()
+			if s.loadFile(dbpath) != nil {
+				return false
+			}
+			return len(s.items) == 3
+		}()
+	}, time.Second*5, time.Millisecond*100)
+	_, err := Delete("a")
+	assert.Nil(t, err)
+	assert.Nil(t, Close())
+
+	assert.Nil(t, InitStorage(StorageSnapshot, dbpath))
+	_, err = Get("a")
+	assert.True(t, IsNotFound(err))
+	var ttl time.Duration
+	v, err := Get("expire", GetTTLOpt(&ttl))
+	assert.Nil(t, err)
+	assert.Equal(t, "b", v)
+	assert.True(t, ttl > time.Minute*59)
+	var m map[string]int
+	assert.Nil(t, GetJson("json", &m))
+	assert.Equal(t, 1, m["a"])
+	assert.Nil(t, Close())
+}
+
+func TestSnapshotStorage_BuntDBCompatible(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "test.db")
+
+	// buntdb 的数据文件可以被 snapshot 读取，包括被删除和覆盖的key
+	assert.Nil(t, InitStorage(StorageBuntDB, dbpath))
+	assert.Nil(t, Set("a", "1"))
+	assert.Nil(t, Set("a", "2"))
+	assert.Nil(t, Set("b", "1"))
+	_, err := Delete("b")
+	assert.Nil(t, err)
+	assert.Nil(t, Set("c", "1", SetExpireOpt(time.Hour)))
+	assert.Nil(t, Close())
+
+	assert.Nil(t, InitStorage(StorageSnapshot, dbpath))
+	v, err := Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+	assert.False(t, Exist("b"))
+	assert.True(t, Exist("c"))
+	assert.Nil(t, Set("d", "1"))
+	assert.Nil(t, Close())
+
+	// snapshot 写入的快照可以被 buntdb 读取
+	assert.Nil(t, InitStorage(StorageBuntDB, dbpath))
+	v, err = Get("d")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	var ttl time.Duration
+	_, err = Get("c", GetTTLOpt(&ttl))
+	assert.Nil(t, err)
+	assert.True(t, ttl > time.Minute*58)
+	assert.Nil(t, Close())
+}
+
+func TestMatchPattern(t *testing.T) {
+	var testCase = []struct {
+		key     string
+		pattern string
+		match   bool
+	}{
+		{"a:1", "a:*", true},
+		{"a:", "a:*", true},
+		{"a", "a:*", false},
+		{"a:1:2", "a:*:2", true},
+		{"a:1:3", "a:*:2", false},
+		{"a:1", "a:?", true},
+		{"a:10", "a:?", false},
+		{"abc", "*", true},
+		{"", "*", true},
+		{"abc", "a*c*", true},
+		{"abc", "abc", true},
+		{"abcd", "abc", false},
+	}
+	for _, c := range testCase {
+		assert.Equal(t, c.match, matchPattern(c.key, c.pattern), "%v %v", c.key, c.pattern)
+	}
+	assert.Equal(t, "a:", patternPrefix("a:*:b"))
+	assert.Equal(t, "abc", patternPrefix("abc"))
+}
+
+func TestReadCommands(t *testing.T) {
+	buf := appendCommand(nil, "set", "a", "1")
+	buf = appendCommand(buf, "del", "a")
+	var cmds [][]string
+	assert.Nil(t, readCommands(bytes.NewReader(buf), func(parts []string) error {
+		cmds = append(cmds, parts)
+		return nil
+	}))
+	assert.Equal(t, [][]string{{"set", "a", "1"}, {"del", "a"}}, cmds)
+
+	err := readCommands(bytes.NewReader(buf[:len(buf)-3]), func(parts []string) error { return nil })
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	err = readCommands(bytes.NewReader([]byte("invalid\r\n")), func(parts []string) error { return nil })
+	assert.Equal(t, ErrInvalidData, err)
+}
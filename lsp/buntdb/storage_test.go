@@ -0,0 +1,82 @@
+package buntdb
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+type testStorage struct {
+	*snapshotStorage
+	opened bool
+	closed bool
+}
+
+func (s *testStorage) Open(string) error {
+	s.opened = true
+	return s.snapshotStorage.Open(MEMORYDB)
+}
+
+func (s *testStorage) Close() error {
+	s.closed = true
+	return s.snapshotStorage.Close()
+}
+
+func TestRegisterStorage(t *testing.T) {
+	var s = &testStorage{snapshotStorage: newSnapshotStorage()}
+	RegisterStorage("test-storage", func() Storage { return s })
+	defer func() {
+		storageMutex.Lock()
+		delete(storageRegistry, "test-storage")
+		storageMutex.Unlock()
+	}()
+	assert.Contains(t, ListStorage(), StorageBuntDB)
+	assert.Contains(t, ListStorage(), StorageMemory)
+	assert.Contains(t, ListStorage(), StorageSnapshot)
+	assert.Contains(t, ListStorage(), "test-storage")
+
+	assert.Panics(t, func() {
+		RegisterStorage("Test-Storage", func() Storage { return s })
+	})
+	assert.Panics(t, func() {
+		RegisterStorage("nil-storage", nil)
+	})
+
+	assert.Nil(t, InitStorage("test-storage", ""))
+	assert.True(t, s.opened)
+	assert.Nil(t, SetInt64("a", 1))
+	v, err := GetInt64("a")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, v)
+	assert.Nil(t, Close())
+	assert.True(t, s.closed)
+
+	_, err = GetClient()
+	assert.Equal(t, ErrNotInitialized, err)
+}
+
+func TestInitStorage(t *testing.T) {
+	err := InitStorage("not-exist", "")
+	assert.True(t, errors.Is(err, ErrStorageNotFound))
+	_, err = GetClient()
+	assert.Equal(t, ErrNotInitialized, err)
+
+	assert.Nil(t, InitStorage(StorageMemory, ""))
+	assert.Nil(t, Close())
+
+	dbpath := filepath.Join(t.TempDir(), "test.db")
+	assert.Nil(t, InitStorage("", dbpath))
+	assert.Nil(t, Set("a", "b"))
+
+	// 同一个文件不能被打开两次
+	err = new(buntDBStorage).Open(dbpath)
+	assert.Equal(t, ErrLockNotHold, err)
+	assert.Nil(t, Close())
+
+	assert.Nil(t, InitStorage(StorageBuntDB, dbpath))
+	v, err := Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", v)
+	assert.Nil(t, Close())
+}
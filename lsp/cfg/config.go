@@ -2,7 +2,10 @@ package cfg
 
 import (
 	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/requests"
 	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/Sora233/sliceutil"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cast"
 	"go.uber.org/atomic"
@@ -47,18 +50,23 @@ var customCommandPrefixAtomic atomic.Value
 
 // ReloadCustomCommandPrefix TODO wtf
 func ReloadCustomCommandPrefix() {
-	var result map[string]string
-	defer func() {
-		customCommandPrefixAtomic.Store(result)
-	}()
 	data, err := os.ReadFile("application.yaml")
 	if err != nil {
+		customCommandPrefixAtomic.Store(map[string]string(nil))
 		return
 	}
+	loadCustomCommandPrefix(data)
+}
+
+// loadCustomCommandPrefix 直接从配置文件内容中读取，viper会把key转换为小写
+func loadCustomCommandPrefix(data []byte) {
+	var result map[string]string
+	defer func() {
+		customCommandPrefixAtomic.Store(result)
+	}()
 	var all = make(map[string]interface{})
 
-	err = yaml.Unmarshal(data, &all)
-	if err != nil {
+	if err := yaml.Unmarshal(data, &all); err != nil {
 		return
 	}
 	var a = all["customCommandPrefix"]
@@ -145,3 +153,618 @@ func GetNotifyParallel() int {
 func GetBilibiliOnlyOnlineNotify() bool {
 	return config.GlobalConfig.GetBool("bilibili.onlyOnlineNotify")
 }
+
+// GetBilibiliBatchSize 批量查询b站直播状态时每次请求包含的uid数量，默认为50
+func GetBilibiliBatchSize() int {
+	var size = config.GlobalConfig.GetInt("bilibili.batchSize")
+	if size <= 0 {
+		size = 50
+	}
+	return size
+}
+
+// GetBilibiliGuardInterval 查询b站舰长数和粉丝团人数的间隔，默认为30分钟，最少为1分钟
+func GetBilibiliGuardInterval() time.Duration {
+	var interval = config.GlobalConfig.GetDuration("bilibili.guard.interval")
+	if interval <= 0 {
+		interval = time.Minute * 30
+	}
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	return interval
+}
+
+// GetBilibiliGuardMilestones 舰长数达到这些数量时推送，默认为10、50、100、500、1000、5000、10000
+func GetBilibiliGuardMilestones() []int64 {
+	return getMilestones("bilibili.guard.guardMilestones", []int64{10, 50, 100, 500, 1000, 5000, 10000})
+}
+
+// GetBilibiliFansClubMilestones 粉丝团人数达到这些数量时推送，默认为1000、5000、10000、50000、100000
+func GetBilibiliFansClubMilestones() []int64 {
+	return getMilestones("bilibili.guard.fansClubMilestones", []int64{1000, 5000, 10000, 50000, 100000})
+}
+
+func getMilestones(key string, def []int64) []int64 {
+	if !config.GlobalConfig.IsSet(key) {
+		return def
+	}
+	var result []int64
+	for _, m := range config.GlobalConfig.GetIntSlice(key) {
+		if m > 0 {
+			result = append(result, int64(m))
+		}
+	}
+	return result
+}
+
+// GetBilibiliReserveRemindBefore b站直播预约在开播前多久提醒，默认为5分钟，设置为0时不提醒
+func GetBilibiliReserveRemindBefore() time.Duration {
+	if !config.GlobalConfig.IsSet("bilibili.reserve.remindBefore") {
+		return time.Minute * 5
+	}
+	return config.GlobalConfig.GetDuration("bilibili.reserve.remindBefore")
+}
+
+// GetBilibiliStatsInterval 记录b站粉丝数和直播人气的间隔，默认为30分钟，设置为0时不记录，最少为1分钟
+func GetBilibiliStatsInterval() time.Duration {
+	if !config.GlobalConfig.IsSet("bilibili.stats.interval") {
+		return time.Minute * 30
+	}
+	var interval = config.GlobalConfig.GetDuration("bilibili.stats.interval")
+	if interval > 0 && interval < time.Minute {
+		interval = time.Minute
+	}
+	return interval
+}
+
+// GetBilibiliStatsRetention b站粉丝数、直播人气和直播记录的保留时间，默认为30天
+func GetBilibiliStatsRetention() time.Duration {
+	var retention = config.GlobalConfig.GetDuration("bilibili.stats.retention")
+	if retention <= 0 {
+		retention = time.Hour * 24 * 30
+	}
+	return retention
+}
+
+// GetArchiveRetention 群消息存档的保留时间，默认为7天
+func GetArchiveRetention() time.Duration {
+	var retention = config.GlobalConfig.GetDuration("archive.retention")
+	if retention <= 0 {
+		retention = time.Hour * 24 * 7
+	}
+	return retention
+}
+
+// GetCheckinExtraImageCost 色图命令每张额外图片消耗的签到积分，<=0 表示不允许兑换
+func GetCheckinExtraImageCost() int64 {
+	return config.GlobalConfig.GetInt64("checkin.extraImageCost")
+}
+
+// GetBilibiliDanmakuRelayInterval 直播弹幕转发的合并间隔，默认为30秒
+func GetBilibiliDanmakuRelayInterval() time.Duration {
+	var interval = config.GlobalConfig.GetDuration("bilibili.danmakuRelayInterval")
+	if interval < time.Second*5 {
+		interval = time.Second * 30
+	}
+	return interval
+}
+
+// GetBilibiliCookieRefreshBefore b站cookie在过期前多久自动刷新，默认为3天
+func GetBilibiliCookieRefreshBefore() time.Duration {
+	var d = config.GlobalConfig.GetDuration("bilibili.cookieRefreshBefore")
+	if d <= 0 {
+		d = time.Hour * 24 * 3
+	}
+	return d
+}
+
+// GetBilibiliVideoFirstFrame 推送视频投稿时是否附加视频第一帧的截图，默认关闭
+func GetBilibiliVideoFirstFrame() bool {
+	return config.GlobalConfig.GetBool("bilibili.video.firstFrame")
+}
+
+// GetBilibiliNewsHistorySize 每个b站用户最多保存多少条历史动态，默认为10，设置为0时不保存
+func GetBilibiliNewsHistorySize() int {
+	if !config.GlobalConfig.IsSet("bilibili.newsHistory.size") {
+		return 10
+	}
+	return config.GlobalConfig.GetInt("bilibili.newsHistory.size")
+}
+
+// GetBilibiliNewsHistoryTTL 历史动态的保存时间，超过这个时间没有新动态时会被删除，默认为30天
+func GetBilibiliNewsHistoryTTL() time.Duration {
+	var d = config.GlobalConfig.GetDuration("bilibili.newsHistory.ttl")
+	if d <= 0 {
+		d = time.Hour * 24 * 30
+	}
+	return d
+}
+
+// CheckModuleEnabled 检查订阅模块是否启用，
+// module.enable 不为空时只启用其中的模块，module.disable 中的模块总是禁用
+func CheckModuleEnabled(site string) bool {
+	if enable := config.GlobalConfig.GetStringSlice("module.enable"); len(enable) > 0 {
+		if !sliceutil.Contains(enable, site) {
+			return false
+		}
+	}
+	return !sliceutil.Contains(config.GlobalConfig.GetStringSlice("module.disable"), site)
+}
+
+// GetPushQueueGroupInterval 推送队列中同一个群两次发送之间的最小间隔，默认为1秒
+func GetPushQueueGroupInterval() time.Duration {
+	var interval = config.GlobalConfig.GetDuration("pushQueue.groupInterval")
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return interval
+}
+
+// GetPushQueueRetryInterval 推送失败后第一次重试的等待时间，之后每次重试翻倍，默认为5秒
+func GetPushQueueRetryInterval() time.Duration {
+	var interval = config.GlobalConfig.GetDuration("pushQueue.retryInterval")
+	if interval <= 0 {
+		interval = time.Second * 5
+	}
+	return interval
+}
+
+// GetPushQueueMaxRetry 推送失败后的最大重试次数，默认为3次，设置为0表示不重试
+func GetPushQueueMaxRetry() int {
+	if !config.GlobalConfig.IsSet("pushQueue.maxRetry") {
+		return 3
+	}
+	var retry = config.GlobalConfig.GetInt("pushQueue.maxRetry")
+	if retry < 0 {
+		retry = 0
+	}
+	return retry
+}
+
+// GetDedupWindow 同一个群内相同内容的推送去重的时间范围，默认为0，表示不去重
+func GetDedupWindow() time.Duration {
+	var window = config.GlobalConfig.GetDuration("dedup.window")
+	if window < 0 {
+		window = 0
+	}
+	return window
+}
+
+// GetAuditLogRetention 审计日志的保存时间，超过这个时间的记录会被自动删除，默认为30天
+func GetAuditLogRetention() time.Duration {
+	var retention = config.GlobalConfig.GetDuration("auditlog.retention")
+	if retention <= 0 {
+		retention = time.Hour * 24 * 30
+	}
+	return retention
+}
+
+// GetHttpSiteConfig 配置文件中按网站设置的http请求配置，key为网站名
+func GetHttpSiteConfig() map[string]*requests.SiteConfig {
+	var result map[string]*requests.SiteConfig
+	if err := config.GlobalConfig.UnmarshalKey("http.sites", &result); err != nil {
+		logger.Errorf("GetHttpSiteConfig UnmarshalKey <http.sites> error %v", err)
+		return nil
+	}
+	return result
+}
+
+// GetShutdownDrainTimeout 退出时等待推送队列发送完毕的最长时间，默认为10秒，设置为0表示不等待
+func GetShutdownDrainTimeout() time.Duration {
+	if !config.GlobalConfig.IsSet("shutdown.drainTimeout") {
+		return time.Second * 10
+	}
+	var timeout = config.GlobalConfig.GetDuration("shutdown.drainTimeout")
+	if timeout < 0 {
+		timeout = 0
+	}
+	return timeout
+}
+
+// GetAdminApiAddr HTTP管理接口的监听地址，为空时不启用
+func GetAdminApiAddr() string {
+	return config.GlobalConfig.GetString("adminApi.addr")
+}
+
+// GetAdminApiToken HTTP管理接口的访问token
+func GetAdminApiToken() string {
+	return config.GlobalConfig.GetString("adminApi.token")
+}
+
+// GetMetricsAddr /metrics 接口的监听地址，为空时不启用
+func GetMetricsAddr() string {
+	return config.GlobalConfig.GetString("metrics.addr")
+}
+
+// GetBackupDir 数据库备份的保存目录，默认为backup
+func GetBackupDir() string {
+	var dir = config.GlobalConfig.GetString("backup.dir")
+	if dir == "" {
+		dir = "backup"
+	}
+	return dir
+}
+
+// GetBackupCron 自动备份的cron表达式，为空时不自动备份
+func GetBackupCron() string {
+	return config.GlobalConfig.GetString("backup.cron")
+}
+
+// GetBackupKeep 最多保留的备份数量，默认为7个，设置为0表示不删除旧的备份
+func GetBackupKeep() int {
+	if !config.GlobalConfig.IsSet("backup.keep") {
+		return 7
+	}
+	var keep = config.GlobalConfig.GetInt("backup.keep")
+	if keep < 0 {
+		keep = 0
+	}
+	return keep
+}
+
+// GetTelegramToken Telegram bot的token，配置后可以把订阅推送到Telegram
+func GetTelegramToken() string {
+	return config.GlobalConfig.GetString("telegram.token")
+}
+
+// GetOneBotEnable 是否通过OneBot v11协议连接go-cqhttp等实现收发消息，开启后不再使用MiraiGo登录
+func GetOneBotEnable() bool {
+	return config.GlobalConfig.GetBool("onebot.enable")
+}
+
+// GetOneBotAddr OneBot正向websocket的地址，默认为 ws://127.0.0.1:6700
+func GetOneBotAddr() string {
+	addr := config.GlobalConfig.GetString("onebot.addr")
+	if len(addr) == 0 {
+		return "ws://127.0.0.1:6700"
+	}
+	return addr
+}
+
+// GetOneBotAccessToken OneBot的access-token，未配置时不鉴权
+func GetOneBotAccessToken() string {
+	return config.GlobalConfig.GetString("onebot.accessToken")
+}
+
+// GetRecordEnable 是否允许录制直播，默认关闭，开启后还需要在群内对订阅单独开启录制
+func GetRecordEnable() bool {
+	return config.GlobalConfig.GetBool("record.enable")
+}
+
+// GetRecordDir 直播录制的保存目录，默认为record
+func GetRecordDir() string {
+	var dir = config.GlobalConfig.GetString("record.dir")
+	if dir == "" {
+		dir = "record"
+	}
+	return dir
+}
+
+// GetRecordQuota 录制文件占用的空间上限，单位为MB，默认为20480，设置为0表示不限制
+// 超过上限时会从最早的录制文件开始删除
+func GetRecordQuota() int64 {
+	if !config.GlobalConfig.IsSet("record.quota") {
+		return 20480
+	}
+	var quota = config.GlobalConfig.GetInt64("record.quota")
+	if quota < 0 {
+		quota = 0
+	}
+	return quota
+}
+
+// GetFreshJitter 刷新间隔的随机抖动比例，0.2表示在±20%的范围内随机，默认为0.2
+func GetFreshJitter() float64 {
+	if !config.GlobalConfig.IsSet("concern.jitter") {
+		return 0.2
+	}
+	var jitter = config.GlobalConfig.GetFloat64("concern.jitter")
+	if jitter < 0 {
+		jitter = 0
+	}
+	return jitter
+}
+
+// GetFreshInterval 同一个订阅两次刷新之间的最小间隔，依次查找
+// concern.freshInterval.<site>.<ctype> 、 concern.freshInterval.<site>.default 、
+// concern.freshInterval.default ，都没有配置时为1分钟
+func GetFreshInterval(site string, ctype string) time.Duration {
+	for _, key := range []string{
+		"concern.freshInterval." + site + "." + ctype,
+		"concern.freshInterval." + site + ".default",
+		"concern.freshInterval.default",
+	} {
+		if d := config.GlobalConfig.GetDuration(key); d > 0 {
+			return d
+		}
+	}
+	return time.Minute
+}
+
+// GetStaleThreshold 订阅连续刷新失败多少次后视为失效并通知订阅的群，默认为100次，设置为0表示不检查
+func GetStaleThreshold() int64 {
+	if !config.GlobalConfig.IsSet("staleCleanup.threshold") {
+		return 100
+	}
+	var threshold = config.GlobalConfig.GetInt64("staleCleanup.threshold")
+	if threshold < 0 {
+		threshold = 0
+	}
+	return threshold
+}
+
+// GetStaleAutoRemove 通知后下一次检查时仍然失效的订阅是否自动取消，默认关闭
+func GetStaleAutoRemove() bool {
+	return config.GlobalConfig.GetBool("staleCleanup.autoRemove")
+}
+
+// GetFreshBackoffMax 触发风控后暂停刷新的最长时间，暂停时间从1分钟开始每次翻倍，默认最长为30分钟
+func GetFreshBackoffMax() time.Duration {
+	var d = config.GlobalConfig.GetDuration("concern.backoffMax")
+	if d <= 0 {
+		d = time.Minute * 30
+	}
+	return d
+}
+
+// GetBreakerThreshold 同一个网站连续刷新失败多少次后暂停刷新，风控或者请求频率限制会直接暂停，默认为20次，设置为0或者负数表示只在风控时暂停
+func GetBreakerThreshold() int64 {
+	if !config.GlobalConfig.IsSet("concern.breakerThreshold") {
+		return 20
+	}
+	return config.GlobalConfig.GetInt64("concern.breakerThreshold")
+}
+
+// GetTrialWatchEnable 是否允许群成员使用 /trial 添加自动到期的试用订阅，默认关闭
+func GetTrialWatchEnable() bool {
+	return config.GlobalConfig.GetBool("trialWatch.enable")
+}
+
+// GetTrialWatchDuration 试用订阅的最长时间，默认为24小时，最少为1分钟
+func GetTrialWatchDuration() time.Duration {
+	var d = config.GlobalConfig.GetDuration("trialWatch.duration")
+	if d <= 0 {
+		return time.Hour * 24
+	}
+	if d < time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+// GetTrialWatchMaxPerGroup 每个群同时存在的试用订阅数量上限，默认为3
+func GetTrialWatchMaxPerGroup() int {
+	var n = config.GlobalConfig.GetInt("trialWatch.maxPerGroup")
+	if n <= 0 {
+		n = 3
+	}
+	return n
+}
+
+// GetImageCacheEnable 是否把推送用到的图片缓存到磁盘，默认关闭
+func GetImageCacheEnable() bool {
+	return config.GlobalConfig.GetBool("imageCache.enable")
+}
+
+// GetImageCacheDir 图片缓存的保存目录，默认为image_cache
+func GetImageCacheDir() string {
+	var dir = config.GlobalConfig.GetString("imageCache.dir")
+	if dir == "" {
+		dir = "image_cache"
+	}
+	return dir
+}
+
+// GetImageCacheTTL 图片缓存的有效期，默认为24小时
+func GetImageCacheTTL() time.Duration {
+	var d = config.GlobalConfig.GetDuration("imageCache.ttl")
+	if d <= 0 {
+		d = time.Hour * 24
+	}
+	return d
+}
+
+// GetImageCacheRetry 下载图片失败时最多尝试的次数，默认为3次
+func GetImageCacheRetry() int {
+	var retry = config.GlobalConfig.GetInt("imageCache.retry")
+	if retry <= 0 {
+		retry = 3
+	}
+	return retry
+}
+
+// GetImageCacheProxy 下载图片使用的代理，为空时不使用代理，
+// 可以填写 any 、 mainland 、 oversea 从代理池中选择，也可以直接填写代理地址
+func GetImageCacheProxy() string {
+	return config.GlobalConfig.GetString("imageCache.proxy")
+}
+
+// BotAccount 额外的bot账号，只用于分担推送，不会响应命令
+type BotAccount struct {
+	Account  int64  `yaml:"account"`
+	Password string `yaml:"password"`
+}
+
+// GetBotAccounts 额外的bot账号，推送时会从所在的群中选择一个账号发送
+func GetBotAccounts() []*BotAccount {
+	var result []*BotAccount
+	if err := config.GlobalConfig.UnmarshalKey("bot.accounts", &result); err != nil {
+		logger.Errorf("GetBotAccounts UnmarshalKey <bot.accounts> error %v", err)
+		return nil
+	}
+	return result
+}
+
+// GetCommandCooldown 命令的冷却时间，分别读取 cooldown.<命令>.user 和 cooldown.<命令>.group ，
+// user为同一个用户两次使用的最小间隔，group为同一个群两次使用的最小间隔，默认为0表示不限制
+func GetCommandCooldown(command string) (user time.Duration, group time.Duration) {
+	user = config.GlobalConfig.GetDuration("cooldown." + command + ".user")
+	group = config.GlobalConfig.GetDuration("cooldown." + command + ".group")
+	return
+}
+
+// GetTranslateProvider 翻译推送使用的翻译服务，可以是 baidu 、 deepl 、 google ，为空时不翻译
+func GetTranslateProvider() string {
+	return strings.ToLower(config.GlobalConfig.GetString("translate.provider"))
+}
+
+// GetTranslateTarget 翻译的目标语言，默认为zh
+func GetTranslateTarget() string {
+	var target = config.GlobalConfig.GetString("translate.target")
+	if target == "" {
+		target = "zh"
+	}
+	return target
+}
+
+// GetTranslateBaidu 百度翻译开放平台的appId和密钥
+func GetTranslateBaidu() (appId string, secret string) {
+	return config.GlobalConfig.GetString("translate.baidu.appId"), config.GlobalConfig.GetString("translate.baidu.secret")
+}
+
+// GetTranslateDeeplAuthKey DeepL的authKey，免费版的key以 :fx 结尾
+func GetTranslateDeeplAuthKey() string {
+	return config.GlobalConfig.GetString("translate.deepl.authKey")
+}
+
+// GetTranslateGoogleApiKey Google Cloud Translation的apiKey
+func GetTranslateGoogleApiKey() string {
+	return config.GlobalConfig.GetString("translate.google.apiKey")
+}
+
+// GetTranslateCacheTTL 翻译结果的缓存时间，默认为7天
+func GetTranslateCacheTTL() time.Duration {
+	var d = config.GlobalConfig.GetDuration("translate.cacheTTL")
+	if d <= 0 {
+		d = time.Hour * 24 * 7
+	}
+	return d
+}
+
+// GetWebhookUrls 全局webhook地址，所有订阅事件都会发送到这些地址
+func GetWebhookUrls() []string {
+	return config.GlobalConfig.GetStringSlice("webhook.urls")
+}
+
+// GetWebhookGroupUrls 群webhook地址，读取 webhook.groups.<群号> ，只会收到这个群通过了群配置过滤的事件
+func GetWebhookGroupUrls(groupCode int64) []string {
+	return config.GlobalConfig.GetStringSlice(fmt.Sprintf("webhook.groups.%v", groupCode))
+}
+
+// GetWebhookGroupCount 配置了webhook地址的群数量
+func GetWebhookGroupCount() int {
+	return len(config.GlobalConfig.GetStringMap("webhook.groups"))
+}
+
+// GetWebhookSecret webhook签名使用的密钥，为空时不签名
+func GetWebhookSecret() string {
+	return config.GlobalConfig.GetString("webhook.secret")
+}
+
+// GetWebhookRetry webhook发送失败时最多尝试的次数，默认为3次
+func GetWebhookRetry() int {
+	var retry = config.GlobalConfig.GetInt("webhook.retry")
+	if retry <= 0 {
+		retry = 3
+	}
+	return retry
+}
+
+// GetLogLevel 日志级别，为空或者无法识别时使用Debug级别
+func GetLogLevel() string {
+	return config.GlobalConfig.GetString("logLevel")
+}
+
+// GetDebugGroups 开启debug模式的群，这些群内的命令会输出更详细的日志
+func GetDebugGroups() []string {
+	return config.GlobalConfig.GetStringSlice("debug.group")
+}
+
+// GetDebugUins 开启debug模式的QQ号，这些QQ号的命令会输出更详细的日志
+func GetDebugUins() []string {
+	return config.GlobalConfig.GetStringSlice("debug.uin")
+}
+
+// GetProxyType 代理池类型，可以填写 pyProxyPool 、 localProxyPool 、 off
+func GetProxyType() string {
+	return config.GlobalConfig.GetString("proxy.type")
+}
+
+// GetPyProxyPoolHost pyProxyPool 代理池的地址
+func GetPyProxyPoolHost() string {
+	return config.GlobalConfig.GetString("pyProxyPool.host")
+}
+
+// GetLocalProxyPool localProxyPool 代理池中的海外代理和国内代理
+func GetLocalProxyPool() (oversea []string, mainland []string) {
+	return config.GlobalConfig.GetStringSlice("localProxyPool.oversea"),
+		config.GlobalConfig.GetStringSlice("localProxyPool.mainland")
+}
+
+// GetBilibiliInterval b站每轮刷新的间隔，默认为20秒
+func GetBilibiliInterval() time.Duration {
+	var interval = config.GlobalConfig.GetDuration("bilibili.interval")
+	if interval <= 0 {
+		interval = time.Second * 20
+	}
+	return interval
+}
+
+// GetAcfunInterval acfun每轮刷新的间隔，默认为20秒
+func GetAcfunInterval() time.Duration {
+	var interval = config.GlobalConfig.GetDuration("acfun.interval")
+	if interval <= 0 {
+		interval = time.Second * 20
+	}
+	return interval
+}
+
+// GetTwitterToken 推特官方API的Bearer Token
+func GetTwitterToken() string {
+	return config.GlobalConfig.GetString("twitter.token")
+}
+
+// GetTwitterNitter Nitter实例的地址，默认为 https://nitter.net
+func GetTwitterNitter() string {
+	nitter := strings.TrimSuffix(config.GlobalConfig.GetString("twitter.nitter"), "/")
+	if len(nitter) == 0 {
+		return "https://nitter.net"
+	}
+	return nitter
+}
+
+// GetTwitterBackend 获取推特数据的方式，可以填写 api 、 nitter ，为空时自动选择
+func GetTwitterBackend() string {
+	return strings.ToLower(config.GlobalConfig.GetString("twitter.backend"))
+}
+
+// GetPixivCookie pixiv登陆后cookie中的PHPSESSID，不配置时无法获取R-18作品
+func GetPixivCookie() string {
+	return config.GlobalConfig.GetString("pixiv.cookie")
+}
+
+// GetSteamApiKey steam Web API的key
+func GetSteamApiKey() string {
+	return config.GlobalConfig.GetString("steam.apiKey")
+}
+
+// GetDouyinSignServer 抖音签名服务的地址，为空时不签名直接请求
+func GetDouyinSignServer() string {
+	return config.GlobalConfig.GetString("douyin.signServer")
+}
+
+// GetTwitchClientId twitch应用的clientId
+func GetTwitchClientId() string {
+	return config.GlobalConfig.GetString("twitch.clientId")
+}
+
+// GetTwitchClientSecret twitch应用的clientSecret
+func GetTwitchClientSecret() string {
+	return config.GlobalConfig.GetString("twitch.clientSecret")
+}
+
+// GetI18nDefaultLocale 默认使用的语言
+func GetI18nDefaultLocale() string {
+	return config.GlobalConfig.GetString("i18n.defaultLocale")
+}
@@ -0,0 +1,217 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/fsnotify/fsnotify"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadDebounce 编辑器保存文件时可能连续产生多个事件，合并这段时间内的事件只重新加载一次
+const reloadDebounce = time.Millisecond * 500
+
+var (
+	reloadMu        sync.Mutex
+	reloadListeners []func()
+)
+
+// durationKeys 需要填写为时间间隔的配置，例如 30s 、 5m 、 1h
+var durationKeys = []string{
+	"concern.emitInterval",
+	"concern.backoffMax",
+	"bilibili.interval",
+	"bilibili.guard.interval",
+	"bilibili.danmakuRelayInterval",
+	"bilibili.cookieRefreshBefore",
+	"bilibili.newsHistory.ttl",
+	"bilibili.reserve.remindBefore",
+	"bilibili.stats.interval",
+	"bilibili.stats.retention",
+	"acfun.interval",
+	"archive.retention",
+	"auditlog.retention",
+	"pushQueue.groupInterval",
+	"pushQueue.retryInterval",
+	"dedup.window",
+	"shutdown.drainTimeout",
+	"imageCache.ttl",
+	"translate.cacheTTL",
+}
+
+// intKeys 需要填写为非负整数的配置
+var intKeys = []string{
+	"notify.parallel",
+	"dispatch.largeNotifyLimit",
+	"bilibili.batchSize",
+	"bilibili.minFollowerCap",
+	"bilibili.newsHistory.size",
+	"pushQueue.maxRetry",
+	"imageCache.retry",
+	"backup.keep",
+	"webhook.retry",
+	"staleCleanup.threshold",
+	"record.quota",
+}
+
+// proxyTypes proxy.type 可以填写的值，为空时等同于 off
+var proxyTypes = []string{"", "off", "pyProxyPool", "localProxyPool"}
+
+// Validate 检查配置中的取值是否合法，返回的错误包含所有不合法的配置项
+func Validate(v *viper.Viper) error {
+	var errs []string
+	if level := v.GetString("logLevel"); level != "" {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			errs = append(errs, fmt.Sprintf("logLevel：无法识别的日志级别 %v", level))
+		}
+	}
+	for _, key := range durationKeys {
+		if !v.IsSet(key) {
+			continue
+		}
+		if d, err := cast.ToDurationE(v.Get(key)); err != nil {
+			errs = append(errs, fmt.Sprintf("%v：无法解析为时间间隔 %v", key, v.Get(key)))
+		} else if d < 0 {
+			errs = append(errs, fmt.Sprintf("%v：不能为负数", key))
+		}
+	}
+	for _, key := range intKeys {
+		if !v.IsSet(key) {
+			continue
+		}
+		if i, err := cast.ToInt64E(v.Get(key)); err != nil {
+			errs = append(errs, fmt.Sprintf("%v：无法解析为整数 %v", key, v.Get(key)))
+		} else if i < 0 {
+			errs = append(errs, fmt.Sprintf("%v：不能为负数", key))
+		}
+	}
+	if v.IsSet("concern.jitter") {
+		if _, err := cast.ToFloat64E(v.Get("concern.jitter")); err != nil {
+			errs = append(errs, fmt.Sprintf("concern.jitter：无法解析为小数 %v", v.Get("concern.jitter")))
+		}
+	}
+	var proxyType = v.GetString("proxy.type")
+	var found bool
+	for _, t := range proxyTypes {
+		if t == proxyType {
+			found = true
+		}
+	}
+	if !found {
+		errs = append(errs, fmt.Sprintf("proxy.type：未知的代理类型 %v", proxyType))
+	}
+	var cronjobs []*CronJob
+	if err := v.UnmarshalKey("cronjob", &cronjobs); err != nil {
+		errs = append(errs, fmt.Sprintf("cronjob：格式错误 %v", err))
+	}
+	for _, job := range cronjobs {
+		if job == nil {
+			continue
+		}
+		if _, err := cron.ParseStandard(job.Cron); err != nil {
+			errs = append(errs, fmt.Sprintf("cronjob：无法解析定时表达式 %v", job.Cron))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// OnReload 注册配置重新加载成功后的回调，回调按注册顺序执行
+func OnReload(f func()) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadListeners = append(reloadListeners, f)
+}
+
+// Reload 重新读取配置文件，检查通过后替换当前的配置并执行 OnReload 注册的回调，
+// 检查失败时继续使用原来的配置
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	file := config.GlobalConfig.ConfigFileUsed()
+	if file == "" {
+		return errors.New("配置文件尚未加载")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败 - %v", err)
+	}
+	var v = viper.New()
+	v.SetConfigType("yaml")
+	if err = v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("解析配置文件失败 - %v", err)
+	}
+	if err = Validate(v); err != nil {
+		return err
+	}
+	if err = config.GlobalConfig.ReadConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("解析配置文件失败 - %v", err)
+	}
+	loadCustomCommandPrefix(data)
+	for _, f := range reloadListeners {
+		f()
+	}
+	return nil
+}
+
+// WatchConfig 监测配置文件的变动并自动 Reload ，需要在配置文件加载后调用
+func WatchConfig() {
+	file := config.GlobalConfig.ConfigFileUsed()
+	if file == "" {
+		logger.Errorf("配置文件尚未加载，无法监测配置文件变动")
+		return
+	}
+	file = filepath.Clean(file)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Errorf("监测配置文件失败：%v", err)
+		return
+	}
+	// 监测所在的文件夹，部分编辑器保存时会删除并重新创建文件
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		logger.Errorf("监测配置文件失败：%v", err)
+		watcher.Close()
+		return
+	}
+	go func() {
+		defer watcher.Close()
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != file || !(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDebounce, func() {
+					if err := Reload(); err != nil {
+						logger.Errorf("监测到配置文件变动，但新的配置检查失败，将继续使用原来的配置：\n%v", err)
+					} else {
+						logger.Infof("监测到配置文件变动，已重新加载配置")
+					}
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Errorf("监测配置文件发生错误：%v", err)
+			}
+		}
+	}()
+}
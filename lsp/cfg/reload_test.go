@@ -0,0 +1,80 @@
+package cfg
+
+import (
+	"bytes"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newViper(t *testing.T, data string) *viper.Viper {
+	var v = viper.New()
+	v.SetConfigType("yaml")
+	assert.Nil(t, v.ReadConfig(bytes.NewBufferString(data)))
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate(newViper(t, "")))
+	assert.Nil(t, Validate(newViper(t, `
+logLevel: info
+bilibili:
+  interval: 30s
+notify:
+  parallel: 2
+proxy:
+  type: localProxyPool
+cronjob:
+  - cron: "*/5 * * * *"
+    templateName: a
+`)))
+
+	err := Validate(newViper(t, `
+logLevel: verbose
+bilibili:
+  interval: abc
+notify:
+  parallel: -1
+proxy:
+  type: unknown
+cronjob:
+  - cron: "* *"
+`))
+	assert.NotNil(t, err)
+	for _, key := range []string{"logLevel", "bilibili.interval", "notify.parallel", "proxy.type", "cronjob"} {
+		assert.Contains(t, err.Error(), key)
+	}
+}
+
+func TestReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "application.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte("bilibili:\n  interval: 30s\n"), 0644))
+	config.GlobalConfig.SetConfigFile(file)
+	assert.Nil(t, config.GlobalConfig.ReadInConfig())
+	assert.Equal(t, time.Second*30, GetBilibiliInterval())
+
+	var called int
+	OnReload(func() {
+		called++
+	})
+
+	assert.Nil(t, os.WriteFile(file, []byte("bilibili:\n  interval: 1m\ncustomCommandPrefix:\n  watch: \"!\"\n"), 0644))
+	assert.Nil(t, Reload())
+	assert.Equal(t, 1, called)
+	assert.Equal(t, time.Minute, GetBilibiliInterval())
+	assert.Equal(t, "!", GetCommandPrefix("watch"))
+
+	// 检查失败时继续使用原来的配置
+	assert.Nil(t, os.WriteFile(file, []byte("bilibili:\n  interval: abc\n"), 0644))
+	assert.NotNil(t, Reload())
+	assert.Equal(t, 1, called)
+	assert.Equal(t, time.Minute, GetBilibiliInterval())
+
+	assert.Nil(t, os.WriteFile(file, []byte("bilibili: [\n"), 0644))
+	assert.NotNil(t, Reload())
+	assert.Equal(t, time.Minute, GetBilibiliInterval())
+}
@@ -11,6 +11,7 @@ var CommandMaps = map[string]string{
 	"RollCommand":          RollCommand,
 	"CheckinCommand":       CheckinCommand,
 	"ScoreCommand":         ScoreCommand,
+	"ScoreRankCommand":     ScoreRankCommand,
 	"GrantCommand":         GrantCommand,
 	"LspCommand":           LspCommand,
 	"WatchCommand":         WatchCommand,
@@ -34,27 +35,52 @@ var CommandMaps = map[string]string{
 	"FriendRequestCommand": FriendRequestCommand,
 	"AdminCommand":         AdminCommand,
 	"SilenceCommand":       SilenceCommand,
+	"QuietCommand":         QuietCommand,
 	"NoUpdateCommand":      NoUpdateCommand,
 	"AbnormalConcernCheck": AbnormalConcernCheck,
 	"CleanConcern":         CleanConcern,
+	"PurgeGroupCommand":    PurgeGroupCommand,
+	"SearchCommand":        SearchCommand,
+	"LoginCommand":         LoginCommand,
+	"BackupCommand":        BackupCommand,
+	"RecordCommand":        RecordCommand,
+	"WebhookCommand":       WebhookCommand,
+	"RecentCommand":        RecentCommand,
+	"TagCommand":           TagCommand,
+	"UnwatchTagCommand":    UnwatchTagCommand,
+	"ShutdownCommand":      ShutdownCommand,
+	"AuditLogCommand":      AuditLogCommand,
+	"HttpCommand":          HttpCommand,
 }
 
 const (
-	RollCommand    = "roll"
-	CheckinCommand = "签到"
-	ScoreCommand   = "查询积分"
-	GrantCommand   = "grant"
-	LspCommand     = "lsp"
-	WatchCommand   = "watch"
-	UnwatchCommand = "unwatch"
-	ListCommand    = "list"
-	SetuCommand    = "色图"
-	HuangtuCommand = "黄图"
-	EnableCommand  = "enable"
-	DisableCommand = "disable"
-	ReverseCommand = "倒放"
-	HelpCommand    = "help"
-	ConfigCommand  = "config"
+	RollCommand       = "roll"
+	CheckinCommand    = "签到"
+	ScoreCommand      = "查询积分"
+	ScoreRankCommand  = "积分排行"
+	GrantCommand      = "grant"
+	LspCommand        = "lsp"
+	WatchCommand      = "watch"
+	UnwatchCommand    = "unwatch"
+	ListCommand       = "list"
+	SetuCommand       = "色图"
+	HuangtuCommand    = "黄图"
+	EnableCommand     = "enable"
+	DisableCommand    = "disable"
+	ReverseCommand    = "倒放"
+	HelpCommand       = "help"
+	ConfigCommand     = "config"
+	SearchCommand     = "search"
+	RecentCommand     = "recent"
+	TagCommand        = "tag"
+	UnwatchTagCommand = "unwatchtag"
+	ReminderCommand   = "remind"
+	LocaleCommand     = "locale"
+	FindCommand       = "find"
+	SubMeCommand      = "subme"
+	DigestCommand     = "digest"
+	StatsCommand      = "stats"
+	TrialCommand      = "trial"
 )
 
 // private command
@@ -70,9 +96,26 @@ const (
 	FriendRequestCommand = "好友申请"
 	AdminCommand         = "admin"
 	SilenceCommand       = "silence"
+	QuietCommand         = "quiet"
 	NoUpdateCommand      = "退订更新"
 	AbnormalConcernCheck = "检测异常订阅"
 	CleanConcern         = "清除订阅"
+	PurgeGroupCommand    = "purge-group"
+	LoginCommand         = "login"
+	BackupCommand        = "backup"
+	RecordCommand        = "record"
+	ExportCommand        = "export"
+	ImportCommand        = "import"
+	WebhookCommand       = "webhook"
+	ShutdownCommand      = "shutdown"
+	AuditLogCommand      = "auditlog"
+	HttpCommand          = "http"
+	StatusCommand        = "status"
+	ReloadCommand        = "reload"
+	BlocklistCommand     = "blocklist"
+	MigrateCommand       = "migrate"
+	FailedCommand        = "failed"
+	ResendCommand        = "resend"
 )
 
 var allGroupCommand = [...]string{
@@ -81,8 +124,12 @@ var allGroupCommand = [...]string{
 	ListCommand, SetuCommand, HuangtuCommand,
 	EnableCommand, DisableCommand,
 	ReverseCommand, ConfigCommand,
-	HelpCommand, ScoreCommand, AdminCommand,
+	HelpCommand, ScoreCommand, ScoreRankCommand, AdminCommand,
 	SilenceCommand, NoUpdateCommand, CleanConcern,
+	SearchCommand, QuietCommand, RecentCommand,
+	TagCommand, UnwatchTagCommand, ReminderCommand,
+	LocaleCommand, FindCommand, SubMeCommand,
+	DigestCommand, StatsCommand, TrialCommand,
 }
 
 var allPrivateOperate = [...]string{
@@ -93,7 +140,15 @@ var allPrivateOperate = [...]string{
 	WhosyourdaddyCommand, QuitCommand, ModeCommand,
 	GroupRequestCommand, FriendRequestCommand, AdminCommand,
 	SilenceCommand, NoUpdateCommand, AbnormalConcernCheck,
-	CleanConcern,
+	CleanConcern, PurgeGroupCommand, LoginCommand,
+	BackupCommand, RecordCommand, QuietCommand,
+	ExportCommand, ImportCommand, WebhookCommand,
+	RecentCommand, TagCommand, UnwatchTagCommand,
+	ShutdownCommand, AuditLogCommand, HttpCommand,
+	ReminderCommand, LocaleCommand, FindCommand,
+	StatusCommand, DigestCommand, ReloadCommand,
+	BlocklistCommand, StatsCommand, MigrateCommand,
+	FailedCommand, ResendCommand,
 }
 
 var nonOprateable = [...]string{
@@ -102,7 +157,13 @@ var nonOprateable = [...]string{
 	WhosyourdaddyCommand, QuitCommand, ModeCommand,
 	GroupRequestCommand, FriendRequestCommand, AdminCommand,
 	SilenceCommand, NoUpdateCommand, AbnormalConcernCheck,
-	CleanConcern,
+	CleanConcern, PurgeGroupCommand, LoginCommand,
+	BackupCommand, RecordCommand, QuietCommand,
+	ExportCommand, ImportCommand, WebhookCommand,
+	ShutdownCommand, AuditLogCommand, HttpCommand,
+	ReminderCommand, LocaleCommand, StatusCommand,
+	DigestCommand, ReloadCommand, BlocklistCommand,
+	MigrateCommand, FailedCommand, ResendCommand,
 }
 
 func CheckValidCommand(command string) bool {
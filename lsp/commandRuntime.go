@@ -61,6 +61,15 @@ func (r *Runtime) parseCommandSyntax(ast interface{}, name string, options ...ko
 	return ctx, ""
 }
 
+// templateText 返回命令中的模板内容，优先使用第一行之后的原始文本以保留换行和引号，
+// 否则使用分割后的参数拼接
+func (r *Runtime) templateText(args []string) string {
+	if text := r.GetRawTextAfterFirstLine(); text != "" {
+		return text
+	}
+	return strings.Join(args, " ")
+}
+
 func (r *Runtime) ParseRawSiteAndType(rawSite string, rawType string) (string, concern_type.Type, error) {
 	site, ctype, err := concern.ParseRawSiteAndType(rawSite, rawType)
 	if err == concern.ErrSiteNotSupported {
@@ -0,0 +1,70 @@
+package concern
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState 网站熔断器的状态
+type BreakerState int32
+
+const (
+	// BreakerClosed 正常刷新
+	BreakerClosed BreakerState = iota
+	// BreakerOpen 触发了风控或者连续刷新失败，暂停这个网站的所有刷新
+	BreakerOpen
+	// BreakerRampUp 暂停结束后降低刷新频率，每次刷新成功后逐步恢复，恢复过程中再次触发风控会重新暂停
+	BreakerRampUp
+)
+
+// breakerRampLevel 暂停结束后刷新频率降低为原来的 1/2^breakerRampLevel ，每次刷新成功后翻倍
+const breakerRampLevel = 3
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "暂停刷新"
+	case BreakerRampUp:
+		return "恢复中"
+	default:
+		return "正常"
+	}
+}
+
+// BreakerEvent 是 TopicBreaker 事件的内容，网站暂停刷新以及完全恢复时各发布一次
+type BreakerEvent struct {
+	Site  string
+	State BreakerState
+	// Pause 第一次暂停刷新的时间，只在 BreakerOpen 时有效
+	Pause time.Duration
+	// Reason 触发暂停的错误，只在 BreakerOpen 时有效
+	Reason string
+}
+
+// breaker 记录一个网站的熔断状态，暂停的截止时间保存在buntdb中，重启后仍然有效，见 StateManager.InFreshBackoff
+type breaker struct {
+	mu    sync.Mutex
+	state BreakerState
+	// level 连续触发暂停的次数，用于计算暂停的时间
+	level int32
+	// failures 连续刷新失败的次数，风控以外的错误达到 cfg.GetBreakerThreshold 时也会暂停刷新
+	failures int64
+	// ramp 恢复阶段刷新频率降低的级别，为0时恢复正常
+	ramp int32
+	// skip 恢复阶段跳过刷新的计数
+	skip int64
+	// notified 是否已经发布过暂停事件，只有发布过暂停事件时才会发布恢复事件
+	notified bool
+}
+
+// sync 根据是否还在暂停时间内更新状态，暂停结束后进入恢复阶段，调用时需要持有锁
+func (b *breaker) sync(open bool) {
+	switch {
+	case open:
+		b.state = BreakerOpen
+	case b.state == BreakerOpen:
+		b.state = BreakerRampUp
+		b.ramp = breakerRampLevel
+		b.skip = 0
+	}
+}
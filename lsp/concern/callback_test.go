@@ -27,8 +27,8 @@ func (t *testNotify) Logger() *logrus.Entry {
 	return logrus.WithField("Site", t.Site())
 }
 
-func (t *testNotify) GetGroupCode() int64 {
-	return test.G1
+func (t *testNotify) GetTarget() mmsg.TargetId {
+	return mmsg.NewGroupTargetId(test.G1)
 }
 
 func (t *testNotify) ToMessage() *mmsg.MSG {
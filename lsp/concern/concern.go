@@ -17,12 +17,12 @@ type Event interface {
 }
 
 // Notify 是对推送的一个抽象，它在 Event 的基础上还包含了推送的接受方信息，例如：qq群号码
-// Event 产生后，通过 Event + 需要推送的QQ群信息，由 Dispatch 和 NotifyGenerator 产生一组 Notify
+// Event 产生后，通过 Event + 需要推送的目标信息，由 Dispatch 和 NotifyGenerator 产生一组 Notify
 // 因为可能多个群订阅同一个 Event，所以一个 Event 可以产生多个 Notify
-// DDBOT目前只支持向QQ群推送
+// 推送的目标可以是QQ群、QQ好友或者Telegram，需要根据 mmsg.TargetId 的类型区分
 type Notify interface {
 	Event
-	GetGroupCode() int64
+	GetTarget() mmsg.TargetId
 	ToMessage() *mmsg.MSG
 }
 
@@ -45,16 +45,16 @@ type Concern interface {
 	ParseId(string) (interface{}, error)
 
 	// Add 添加一个订阅
-	Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (IdentityInfo, error)
+	Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (IdentityInfo, error)
 	// Remove 删除一个订阅
-	Remove(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (IdentityInfo, error)
+	Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (IdentityInfo, error)
 	// Get 获取一个订阅信息
 	Get(id interface{}) (IdentityInfo, error)
 
 	// GetStateManager 获取 IStateManager
 	GetStateManager() IStateManager
 	// FreshIndex 刷新 group 的 index，通常不需要用户主动调用，StateManager.FreshIndex 有默认实现。
-	FreshIndex(groupCode ...int64)
+	FreshIndex(targets ...mmsg.TargetId)
 }
 
 // IdentityInfo 表示订阅对象的信息，包括名字，ID
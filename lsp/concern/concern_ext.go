@@ -1,5 +1,10 @@
 package concern
 
+import (
+	"context"
+	"time"
+)
+
 // NotifyLiveExt 是一个针对直播推送过滤的扩展接口， Notify 可以选择性实现这个接口，如果实现了，则会自动使用默认的推送过滤逻辑
 // 默认情况下，如果 IsLive 为 true，则根据以下规则推送：
 // Living 为 true 且 LiveStatusChanged 为true（说明是开播了）进行推送
@@ -18,3 +23,115 @@ type NotifyLiveExt interface {
 	// 如果没有变化也可以发送给DDBOT，DDBOT会自动进行过滤
 	LiveStatusChanged() bool
 }
+
+// NotifyLiveChangeExt 是 NotifyLiveExt 的补充接口，直播推送可以选择性实现这个接口，
+// 实现后 Living 为 true 且 LiveStatusChanged 为false 时，如果封面或者分区发生了变化，根据 LiveChangeNotify 配置进行推送
+type NotifyLiveChangeExt interface {
+	// CoverChanged 表示直播中是否更换了封面
+	CoverChanged() bool
+	// AreaChanged 表示直播中是否更换了分区
+	AreaChanged() bool
+}
+
+// QRCodeLoginExt 是一个扫码登陆的扩展接口， Concern 可以选择性实现这个接口，
+// 实现后管理员可以通过私聊命令获取登陆二维码，扫码后 Concern 使用登陆的账号访问网站
+type QRCodeLoginExt interface {
+	// QRCodeLogin 申请一个登陆二维码，content 为二维码的内容，
+	// wait 会阻塞直到登陆成功、二维码失效或者ctx结束，登陆成功时返回nil
+	QRCodeLogin() (content string, wait func(ctx context.Context) error, err error)
+}
+
+// State 订阅当前保存的状态，用于在网页面板中展示
+type State struct {
+	Living bool   `json:"living"`
+	Title  string `json:"title,omitempty"`
+	// LastNewsTime 最近一条动态的时间戳，没有动态信息时为0
+	LastNewsTime int64 `json:"last_news_time,omitempty"`
+}
+
+// StateExt 是一个查询订阅状态的扩展接口， Concern 可以选择性实现这个接口，
+// 实现后可以在网页面板中查看订阅当前的直播状态
+type StateExt interface {
+	// GetState 返回id当前保存的状态，没有保存过状态时返回nil
+	GetState(id interface{}) *State
+}
+
+// NotifyTranslateExt 是一个推送翻译的扩展接口， Notify 可以选择性实现这个接口，
+// 实现后如果群内开启了 GroupConcernNotifyConfig.Translate ，会把 TranslateText 的翻译结果附加在推送的末尾
+type NotifyTranslateExt interface {
+	// TranslateText 返回需要翻译的正文，返回空字符串时不翻译
+	TranslateText() string
+}
+
+// NotifyDedupExt 是一个推送去重的扩展接口， Notify 可以选择性实现这个接口，
+// 实现后如果配置了 dedup.window ，同一个群在这段时间内只会收到一次相同内容的推送，
+// 例如多个订阅的账号转发了同一条动态，或者联合投稿了同一个视频
+type NotifyDedupExt interface {
+	// DedupKeys 返回推送内容的指纹，同一个网站内任意一个指纹相同即视为相同的内容，返回空时不去重
+	DedupKeys() []string
+}
+
+// News 保存的一条历史动态
+type News struct {
+	Id        string `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+	// Content 动态的正文，视频动态为视频标题
+	Content string `json:"content,omitempty"`
+	Url     string `json:"url,omitempty"`
+}
+
+// NewsHistoryExt 是一个查询历史动态的扩展接口， Concern 可以选择性实现这个接口，
+// 实现后可以在群内使用 /recent 命令查看最近的动态，用于补看错过的推送
+type NewsHistoryExt interface {
+	// GetRecentNews 返回id最近的n条动态，最新的在前面，没有保存过动态时返回空
+	GetRecentNews(id interface{}, n int) ([]*News, error)
+}
+
+// StatSample 账号在某个时间点的统计数据
+type StatSample struct {
+	Time int64 `json:"time"`
+	// Follower 粉丝数，获取失败时为-1
+	Follower int64 `json:"follower"`
+	// Online 直播人气，没有直播时为0
+	Online int64 `json:"online"`
+}
+
+// LiveSession 一次直播的记录
+type LiveSession struct {
+	Start int64 `json:"start"`
+	// End 下播时间，还在直播时为0
+	End   int64  `json:"end"`
+	Title string `json:"title,omitempty"`
+	// MaxOnline 这次直播记录到的最高人气
+	MaxOnline int64 `json:"max_online"`
+}
+
+// StatsExt 是一个查询统计数据的扩展接口， Concern 可以选择性实现这个接口，
+// 实现后可以使用 /stats 命令查看账号最近的粉丝数、直播人气变化以及直播时长
+type StatsExt interface {
+	// GetStats 返回id在since之后的统计数据和直播记录，都按时间从早到晚排列
+	GetStats(id interface{}, since time.Time) ([]*StatSample, []*LiveSession, error)
+}
+
+// SearchResult 通过 SearchExt 搜索到的一个账号
+type SearchResult struct {
+	// Id 可以直接用于 /watch 的id
+	Id   interface{} `json:"id"`
+	Name string      `json:"name"`
+	// Followers 粉丝数，网站没有返回时为-1
+	Followers int64 `json:"followers"`
+}
+
+// SearchExt 是一个搜索账号的扩展接口， Concern 可以选择性实现这个接口，
+// 实现后可以在 /find 命令中通过网站的搜索接口按关键字查找账号，不需要先找到账号的数字id
+type SearchExt interface {
+	// Search 返回关键字搜索到的最多n个账号，没有结果时返回空
+	Search(keyword string, n int) ([]*SearchResult, error)
+}
+
+// DiagnoseExt 是一个自我诊断的扩展接口， Concern 可以选择性实现这个接口，
+// 实现后 /status 命令会显示返回的诊断信息，例如登录状态、cookie是否有效
+type DiagnoseExt interface {
+	// Diagnose 返回诊断信息，每个元素显示为一行
+	Diagnose() []string
+}
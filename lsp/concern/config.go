@@ -12,6 +12,7 @@ type IConfig interface {
 	GetGroupConcernAt() *GroupConcernAtConfig
 	GetGroupConcernNotify() *GroupConcernNotifyConfig
 	GetGroupConcernFilter() *GroupConcernFilterConfig
+	GetGroupConcernTemplate() *GroupConcernTemplateConfig
 	ICallback
 	Hook
 }
@@ -23,15 +24,46 @@ type GroupConcernConfig struct {
 	GroupConcernAt     GroupConcernAtConfig     `json:"group_concern_at"`
 	GroupConcernNotify GroupConcernNotifyConfig `json:"group_concern_notify"`
 	GroupConcernFilter GroupConcernFilterConfig `json:"group_concern_filter"`
+
+	GroupConcernTemplate GroupConcernTemplateConfig `json:"group_concern_template"`
 }
 
 // Validate 可以在此自定义config校验，每次对config修改后会在同一个事务中调用，如果返回non-nil，则改动会回滚，此次操作失败
 // 默认支持 GroupConcernNotifyConfig GroupConcernAtConfig
-// GroupConcernFilterConfig 默认只支持 text
+// GroupConcernFilterConfig 默认只支持 text，并且会检查其中的正则表达式
+// GroupConcernDanmakuRelayConfig 默认不支持
+// GroupConcernDanmakuAlertConfig 默认不支持
+// GroupConcernNotifyConfig.Record 默认不支持
+// GroupConcernNotifyConfig.ScheduleRemind 默认不支持
+// GroupConcernTemplateConfig 会检查模板能否正常解析
 func (g *GroupConcernConfig) Validate() error {
+	if err := g.GetGroupConcernTemplate().Validate(); err != nil {
+		return err
+	}
 	if !g.GetGroupConcernFilter().Empty() && g.GetGroupConcernFilter().Type != FilterTypeText {
 		return ErrConfigNotSupported
 	}
+	if !g.GetGroupConcernFilter().Empty() {
+		textFilter, err := g.GetGroupConcernFilter().GetFilterByText()
+		if err != nil {
+			return err
+		}
+		if err = textFilter.Validate(); err != nil {
+			return err
+		}
+	}
+	if g.GetGroupConcernNotify().CheckDanmakuRelay() {
+		return ErrConfigNotSupported
+	}
+	if g.GetGroupConcernNotify().CheckDanmakuAlert() {
+		return ErrConfigNotSupported
+	}
+	if g.GetGroupConcernNotify().CheckRecord() {
+		return ErrConfigNotSupported
+	}
+	if g.GetGroupConcernNotify().CheckScheduleRemind() {
+		return ErrConfigNotSupported
+	}
 	return nil
 }
 
@@ -51,16 +83,10 @@ func (g *GroupConcernConfig) FilterHook(notify Notify) *HookResult {
 		} else {
 			var hook = new(HookResult)
 			msgString := msgstringer.MsgToString(notify.ToMessage().Elements())
-			for _, text := range textFilter.Text {
-				if strings.Contains(msgString, text) {
-					hook.Pass = true
-					break
-				}
-			}
+			hook.Pass, hook.Reason = textFilter.Match(msgString)
 			if !hook.Pass {
-				logger.WithField("TextFilter", textFilter.Text).
+				logger.WithField("TextFilter", textFilter).
 					Debug("news notify filtered by textFilter")
-				hook.Reason = "TextFilter All pattern match failed"
 			} else {
 				logger.Debugf("news notify FilterHook pass")
 			}
@@ -96,14 +122,23 @@ func (g *GroupConcernConfig) ShouldSendHook(notify Notify) *HookResult {
 				// 上播了
 				return HookResultPass
 			}
-			if liveExt.TitleChanged() {
-				// 直播间标题改了，检查改标题推送配置
+			var notifyConfig = g.GetGroupConcernNotify()
+			if liveExt.TitleChanged() && notifyConfig.CheckTitleChangeNotify(notify.Type()) {
+				// 直播间标题改了，并且配置了改标题推送
+				return HookResultPass
+			}
+			if changeExt, ok := notify.(NotifyLiveChangeExt); ok && (changeExt.CoverChanged() || changeExt.AreaChanged()) {
+				// 直播间封面或者分区改了，检查直播间变化推送配置
 				result.PassOrReason(
-					g.GetGroupConcernNotify().CheckTitleChangeNotify(notify.Type()),
-					"CheckTitleChangeNotify is false",
+					notifyConfig.CheckLiveChangeNotify(notify.Type()),
+					"CheckLiveChangeNotify is false",
 				)
 				return result
 			}
+			if liveExt.TitleChanged() {
+				result.PassOrReason(false, "CheckTitleChangeNotify is false")
+				return result
+			}
 		} else if liveExt.LiveStatusChanged() {
 			// 下播了，检查下播推送配置
 			result.PassOrReason(
@@ -132,6 +167,11 @@ func (g *GroupConcernConfig) GetGroupConcernFilter() *GroupConcernFilterConfig {
 	return &g.GroupConcernFilter
 }
 
+// GetGroupConcernTemplate 返回 GroupConcernTemplateConfig，总是返回 non-nil
+func (g *GroupConcernConfig) GetGroupConcernTemplate() *GroupConcernTemplateConfig {
+	return &g.GroupConcernTemplate
+}
+
 // ToString 将 GroupConcernConfig 通过json序列化成string
 func (g *GroupConcernConfig) ToString() string {
 	b, e := json.Marshal(g)
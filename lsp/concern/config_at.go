@@ -7,10 +7,87 @@ type AtSomeone struct {
 	AtList []int64           `json:"at_list"`
 }
 
+// MentionMode 推送时@成员的方式
+type MentionMode string
+
+const (
+	// MentionNone 不@任何人
+	MentionNone MentionMode = "none"
+	// MentionMember @配置的成员以及使用/subme订阅的成员
+	MentionMember MentionMode = "member"
+	// MentionAll bot是管理员时@全体成员，@全体成员失败时@配置的成员以及使用/subme订阅的成员
+	MentionAll MentionMode = "all"
+)
+
+// ParseMentionMode 解析用户输入的@方式
+func ParseMentionMode(s string) (MentionMode, bool) {
+	switch mode := MentionMode(s); mode {
+	case MentionNone, MentionMember, MentionAll:
+		return mode, true
+	default:
+		return "", false
+	}
+}
+
+type Mention struct {
+	Ctype concern_type.Type `json:"ctype"`
+	Mode  MentionMode       `json:"mode"`
+}
+
 // GroupConcernAtConfig @配置
 type GroupConcernAtConfig struct {
 	AtAll     concern_type.Type `json:"at_all"`
 	AtSomeone []*AtSomeone      `json:"at_someone"`
+	// Mention 按推送类型单独配置的@方式，没有配置时根据 AtAll 判断
+	Mention []*Mention `json:"mention,omitempty"`
+}
+
+// GetMentionMode 返回ctype的推送使用的@方式
+func (g *GroupConcernAtConfig) GetMentionMode(ctype concern_type.Type) MentionMode {
+	if g == nil {
+		return MentionMember
+	}
+	for _, m := range g.Mention {
+		if m.Ctype.ContainAll(ctype) {
+			return m.Mode
+		}
+	}
+	if g.CheckAtAll(ctype) {
+		return MentionAll
+	}
+	return MentionMember
+}
+
+// SetMentionMode 设置ctype的推送使用的@方式，同时更新 AtAll
+func (g *GroupConcernAtConfig) SetMentionMode(ctype concern_type.Type, mode MentionMode) {
+	if g == nil {
+		return
+	}
+	g.ClearMentionMode(ctype)
+	g.Mention = append(g.Mention, &Mention{
+		Ctype: ctype,
+		Mode:  mode,
+	})
+	if mode == MentionAll {
+		g.AtAll = g.AtAll.Add(ctype)
+	} else {
+		g.AtAll = g.AtAll.Remove(ctype)
+	}
+}
+
+// ClearMentionMode 清除ctype单独配置的@方式，之后根据 AtAll 判断
+func (g *GroupConcernAtConfig) ClearMentionMode(ctype concern_type.Type) {
+	if g == nil {
+		return
+	}
+	var newList []*Mention
+	for _, m := range g.Mention {
+		if m.Ctype.ContainAll(ctype) {
+			continue
+		}
+		newList = append(newList, m)
+	}
+	g.Mention = newList
 }
 
 func (g *GroupConcernAtConfig) CheckAtAll(ctype concern_type.Type) bool {
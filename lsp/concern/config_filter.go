@@ -2,6 +2,10 @@ package concern
 
 import (
 	"errors"
+	"fmt"
+	lru "github.com/hashicorp/golang-lru"
+	"regexp"
+	"strings"
 )
 
 const (
@@ -10,6 +14,28 @@ const (
 	FilterTypeText    = "text"
 )
 
+// regexCacheSize 缓存已编译的正则表达式的数量，过滤器配置在每次推送时都会重新解析，缓存可以避免重复编译
+const regexCacheSize = 256
+
+var regexCache, _ = lru.New(regexCacheSize)
+
+// compileRegex 编译正则表达式，编译结果以及编译错误都会被缓存
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if v, ok := regexCache.Get(expr); ok {
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		regexCache.Add(expr, err)
+		return nil, err
+	}
+	regexCache.Add(expr, re)
+	return re, nil
+}
+
 type GroupConcernFilterConfigByType struct {
 	Type []string `json:"type"`
 }
@@ -19,8 +45,79 @@ func (g *GroupConcernFilterConfigByType) ToString() string {
 	return string(b)
 }
 
+// GroupConcernFilterConfigByText 关键字过滤配置，所有规则同时生效：
+// Text 与 Regex 非空时，内容必须包含任意一个关键字并且匹配任意一个正则表达式；
+// 内容包含 NotText 中任意一个关键字，或者匹配 NotRegex 中任意一个正则表达式时不推送
 type GroupConcernFilterConfigByText struct {
-	Text []string `json:"text"`
+	Text     []string `json:"text"`
+	NotText  []string `json:"not_text,omitempty"`
+	Regex    []string `json:"regex,omitempty"`
+	NotRegex []string `json:"not_regex,omitempty"`
+}
+
+// Empty 没有设置任何规则时返回true
+func (g *GroupConcernFilterConfigByText) Empty() bool {
+	return len(g.Text) == 0 && len(g.NotText) == 0 && len(g.Regex) == 0 && len(g.NotRegex) == 0
+}
+
+// Validate 检查所有正则表达式能否正常编译
+func (g *GroupConcernFilterConfigByText) Validate() error {
+	for _, expr := range append(append([]string{}, g.Regex...), g.NotRegex...) {
+		if _, err := compileRegex(expr); err != nil {
+			return fmt.Errorf("正则表达式<%v>错误：%v", expr, err)
+		}
+	}
+	return nil
+}
+
+// Match 检查内容是否满足过滤规则，不满足时返回原因，正则表达式无法编译时视为不满足并在原因中返回错误
+func (g *GroupConcernFilterConfigByText) Match(content string) (bool, string) {
+	if len(g.Text) > 0 && !containsAny(content, g.Text) {
+		return false, "TextFilter All pattern match failed"
+	}
+	if kw, found := findAny(content, g.NotText); found {
+		return false, fmt.Sprintf("TextFilter NotText <%v> matched", kw)
+	}
+	if len(g.Regex) > 0 {
+		var matched bool
+		for _, expr := range g.Regex {
+			re, err := compileRegex(expr)
+			if err != nil {
+				return false, fmt.Sprintf("TextFilter Regex <%v> invalid: %v", expr, err)
+			}
+			if re.MatchString(content) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "TextFilter All regex match failed"
+		}
+	}
+	for _, expr := range g.NotRegex {
+		re, err := compileRegex(expr)
+		if err != nil {
+			return false, fmt.Sprintf("TextFilter NotRegex <%v> invalid: %v", expr, err)
+		}
+		if re.MatchString(content) {
+			return false, fmt.Sprintf("TextFilter NotRegex <%v> matched", expr)
+		}
+	}
+	return true, ""
+}
+
+func containsAny(content string, keywords []string) bool {
+	_, found := findAny(content, keywords)
+	return found
+}
+
+func findAny(content string, keywords []string) (string, bool) {
+	for _, kw := range keywords {
+		if strings.Contains(content, kw) {
+			return kw, true
+		}
+	}
+	return "", false
 }
 
 func (g *GroupConcernFilterConfigByText) ToString() string {
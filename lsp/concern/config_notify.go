@@ -6,6 +6,22 @@ import "github.com/Sora233/DDBOT/lsp/concern_type"
 type GroupConcernNotifyConfig struct {
 	TitleChangeNotify concern_type.Type `json:"title_change_notify"`
 	OfflineNotify     concern_type.Type `json:"offline_notify"`
+	// LiveChangeNotify 直播中更换封面或者分区时是否推送，需要推送实现 NotifyLiveChangeExt
+	LiveChangeNotify concern_type.Type `json:"live_change_notify,omitempty"`
+
+	DanmakuRelay *GroupConcernDanmakuRelayConfig `json:"danmaku_relay,omitempty"`
+	DanmakuAlert *GroupConcernDanmakuAlertConfig `json:"danmaku_alert,omitempty"`
+
+	// Record 开播时录制直播，需要同时在配置文件中开启 record.enable
+	// 目前仅b站和斗鱼支持，默认的 GroupConcernConfig.Validate 会拒绝开启
+	Record bool `json:"record,omitempty"`
+
+	// ScheduleRemind 在预告的开播时间之前多少分钟提醒，0表示不提醒
+	// 目前仅虎牙支持，默认的 GroupConcernConfig.Validate 会拒绝开启
+	ScheduleRemind int `json:"schedule_remind,omitempty"`
+
+	// Translate 推送实现了 NotifyTranslateExt 时，把外语正文的翻译附加在推送的末尾，需要在配置文件中设置 translate.provider
+	Translate bool `json:"translate,omitempty"`
 }
 
 func (g *GroupConcernNotifyConfig) CheckTitleChangeNotify(ctype concern_type.Type) bool {
@@ -15,3 +31,46 @@ func (g *GroupConcernNotifyConfig) CheckTitleChangeNotify(ctype concern_type.Typ
 func (g *GroupConcernNotifyConfig) CheckOfflineNotify(ctype concern_type.Type) bool {
 	return g.OfflineNotify.ContainAll(ctype)
 }
+
+func (g *GroupConcernNotifyConfig) CheckLiveChangeNotify(ctype concern_type.Type) bool {
+	return g.LiveChangeNotify.ContainAll(ctype)
+}
+
+func (g *GroupConcernNotifyConfig) CheckDanmakuRelay() bool {
+	return g.DanmakuRelay != nil && g.DanmakuRelay.Enable
+}
+
+func (g *GroupConcernNotifyConfig) CheckDanmakuAlert() bool {
+	return g.DanmakuAlert != nil && !g.DanmakuAlert.Empty()
+}
+
+func (g *GroupConcernNotifyConfig) CheckRecord() bool {
+	return g.Record
+}
+
+func (g *GroupConcernNotifyConfig) CheckScheduleRemind() bool {
+	return g.ScheduleRemind > 0
+}
+
+func (g *GroupConcernNotifyConfig) CheckTranslate() bool {
+	return g.Translate
+}
+
+// GroupConcernDanmakuRelayConfig 直播弹幕转发配置，开启后直播期间会把醒目留言、上舰消息以及包含关键字的弹幕合并转发到群内
+// 目前仅b站支持，默认的 GroupConcernConfig.Validate 会拒绝开启
+type GroupConcernDanmakuRelayConfig struct {
+	Enable   bool     `json:"enable"`
+	Keywords []string `json:"keywords"`
+}
+
+// GroupConcernDanmakuAlertConfig 直播弹幕提醒配置，开启后直播期间弹幕包含关键字或者指定的用户发言时会立即提醒
+// 目前仅b站支持，默认的 GroupConcernConfig.Validate 会拒绝开启
+type GroupConcernDanmakuAlertConfig struct {
+	Keywords []string `json:"keywords,omitempty"`
+	// Users 需要提醒的用户，可以是uid或者用户名
+	Users []string `json:"users,omitempty"`
+}
+
+func (g *GroupConcernDanmakuAlertConfig) Empty() bool {
+	return len(g.Keywords) == 0 && len(g.Users) == 0
+}
@@ -0,0 +1,122 @@
+package concern
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/template"
+)
+
+const (
+	TemplateKindLive        = "live"
+	TemplateKindTitleChange = "title"
+	TemplateKindNews        = "news"
+)
+
+var ErrTemplateKindNotSupported = errors.New("不支持的模板类型")
+
+// NotifyTemplateData 是一个针对群自定义推送模板的扩展接口， Notify 可以选择性实现这个接口，
+// 返回的内容会合并到模板数据中，在模板中可以通过 .key 引用。
+// 如果没有实现，模板中仅能使用 .msg .group_code .site .type .uid 这些通用数据
+type NotifyTemplateData interface {
+	TemplateData() map[string]interface{}
+}
+
+// GroupConcernTemplateConfig 群自定义推送模板配置，为空表示使用默认的推送内容
+type GroupConcernTemplateConfig struct {
+	Live        string `json:"live,omitempty"`
+	TitleChange string `json:"title_change,omitempty"`
+	News        string `json:"news,omitempty"`
+}
+
+func (g *GroupConcernTemplateConfig) field(kind string) (*string, error) {
+	switch kind {
+	case TemplateKindLive:
+		return &g.Live, nil
+	case TemplateKindTitleChange:
+		return &g.TitleChange, nil
+	case TemplateKindNews:
+		return &g.News, nil
+	default:
+		return nil, ErrTemplateKindNotSupported
+	}
+}
+
+// Get 返回 kind 对应的模板内容
+func (g *GroupConcernTemplateConfig) Get(kind string) (string, error) {
+	f, err := g.field(kind)
+	if err != nil {
+		return "", err
+	}
+	return *f, nil
+}
+
+// Set 设置 kind 对应的模板内容，设置前会检查模板能否正常解析
+func (g *GroupConcernTemplateConfig) Set(kind string, text string) error {
+	f, err := g.field(kind)
+	if err != nil {
+		return err
+	}
+	if err := ValidateNotifyTemplate(kind, text); err != nil {
+		return err
+	}
+	*f = text
+	return nil
+}
+
+// Clear 清除 kind 对应的模板内容，恢复为默认推送
+func (g *GroupConcernTemplateConfig) Clear(kind string) error {
+	f, err := g.field(kind)
+	if err != nil {
+		return err
+	}
+	*f = ""
+	return nil
+}
+
+// Empty 没有设置任何模板时返回true
+func (g *GroupConcernTemplateConfig) Empty() bool {
+	return g == nil || (g.Live == "" && g.TitleChange == "" && g.News == "")
+}
+
+// Validate 检查所有已设置的模板能否正常解析
+func (g *GroupConcernTemplateConfig) Validate() error {
+	for _, kind := range []string{TemplateKindLive, TemplateKindTitleChange, TemplateKindNews} {
+		text, _ := g.Get(kind)
+		if err := ValidateNotifyTemplate(kind, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTemplate 根据 Notify 选择对应的模板内容，返回空字符串表示没有设置
+// 实现了 NotifyLiveExt 的直播推送中，仅修改标题（未改变直播状态）的推送使用 title 模板，其他使用 live 模板；
+// 其余推送均使用 news 模板
+func (g *GroupConcernTemplateConfig) GetTemplate(notify Notify) (kind string, text string) {
+	if g == nil {
+		return "", ""
+	}
+	if liveExt, ok := notify.(NotifyLiveExt); ok && liveExt.IsLive() {
+		if liveExt.Living() && !liveExt.LiveStatusChanged() && liveExt.TitleChanged() {
+			return TemplateKindTitleChange, g.TitleChange
+		}
+		return TemplateKindLive, g.Live
+	}
+	return TemplateKindNews, g.News
+}
+
+// ValidateNotifyTemplate 检查模板能否正常解析，空模板总是合法的
+func ValidateNotifyTemplate(kind string, text string) error {
+	if text == "" {
+		return nil
+	}
+	if _, err := template.New(NotifyTemplateName(kind)).Parse(text); err != nil {
+		return fmt.Errorf("模板解析失败：%v", err)
+	}
+	return nil
+}
+
+// NotifyTemplateName 返回群自定义推送模板的名字，仅用于日志与报错
+func NotifyTemplateName(kind string) string {
+	return fmt.Sprintf("group.concern.template.%v", kind)
+}
@@ -0,0 +1,96 @@
+package concern
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testLiveNotify struct {
+	Notify
+	living            bool
+	titleChanged      bool
+	liveStatusChanged bool
+}
+
+func (t *testLiveNotify) IsLive() bool {
+	return true
+}
+
+func (t *testLiveNotify) Living() bool {
+	return t.living
+}
+
+func (t *testLiveNotify) TitleChanged() bool {
+	return t.titleChanged
+}
+
+func (t *testLiveNotify) LiveStatusChanged() bool {
+	return t.liveStatusChanged
+}
+
+func TestGroupConcernTemplateConfig(t *testing.T) {
+	var g = new(GroupConcernTemplateConfig)
+	assert.True(t, g.Empty())
+	assert.Nil(t, g.Validate())
+
+	assert.Nil(t, g.Set(TemplateKindLive, "{{ .name }}开播了"))
+	assert.NotNil(t, g.Set(TemplateKindTitleChange, "{{ .name "))
+	assert.NotNil(t, g.Set(TemplateKindTitleChange, "{{ .name | not_exist_func }}"))
+	assert.EqualValues(t, ErrTemplateKindNotSupported, g.Set("unknown", "text"))
+	assert.False(t, g.Empty())
+
+	text, err := g.Get(TemplateKindLive)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "{{ .name }}开播了", text)
+	text, err = g.Get(TemplateKindTitleChange)
+	assert.Nil(t, err)
+	assert.Empty(t, text)
+	_, err = g.Get("unknown")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, g.Validate())
+	g.News = "{{ .msg "
+	assert.NotNil(t, g.Validate())
+
+	assert.Nil(t, g.Clear(TemplateKindNews))
+	assert.Nil(t, g.Clear(TemplateKindLive))
+	assert.True(t, g.Empty())
+}
+
+func TestGroupConcernTemplateConfig_GetTemplate(t *testing.T) {
+	var g = &GroupConcernTemplateConfig{
+		Live:        "live",
+		TitleChange: "title",
+		News:        "news",
+	}
+	var kind, text string
+
+	kind, text = g.GetTemplate(&testLiveNotify{living: true, liveStatusChanged: true})
+	assert.EqualValues(t, TemplateKindLive, kind)
+	assert.EqualValues(t, "live", text)
+
+	kind, text = g.GetTemplate(&testLiveNotify{living: false, liveStatusChanged: true})
+	assert.EqualValues(t, TemplateKindLive, kind)
+	assert.EqualValues(t, "live", text)
+
+	kind, text = g.GetTemplate(&testLiveNotify{living: true, titleChanged: true})
+	assert.EqualValues(t, TemplateKindTitleChange, kind)
+	assert.EqualValues(t, "title", text)
+
+	kind, text = g.GetTemplate(&testNotify{})
+	assert.EqualValues(t, TemplateKindNews, kind)
+	assert.EqualValues(t, "news", text)
+
+	var nilConfig *GroupConcernTemplateConfig
+	_, text = nilConfig.GetTemplate(&testNotify{})
+	assert.Empty(t, text)
+}
+
+func TestGroupConcernConfig_ValidateTemplate(t *testing.T) {
+	var g = new(GroupConcernConfig)
+	assert.Nil(t, g.Validate())
+	g.GetGroupConcernTemplate().Live = "{{ .msg "
+	assert.NotNil(t, g.Validate())
+	g.GetGroupConcernTemplate().Live = "{{ .msg }}"
+	assert.Nil(t, g.Validate())
+}
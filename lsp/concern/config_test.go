@@ -20,6 +20,44 @@ func TestGroupConcernAtConfig_CheckAtAll(t *testing.T) {
 	assert.False(t, g.CheckAtAll(test.BibiliLive))
 }
 
+func TestGroupConcernAtConfig_MentionMode(t *testing.T) {
+	var g *GroupConcernAtConfig
+	assert.Equal(t, MentionMember, g.GetMentionMode(test.BibiliLive))
+	g.SetMentionMode(test.BibiliLive, MentionNone)
+
+	g = &GroupConcernAtConfig{
+		AtAll: test.BibiliLive,
+	}
+	assert.Equal(t, MentionAll, g.GetMentionMode(test.BibiliLive))
+	assert.Equal(t, MentionMember, g.GetMentionMode(test.BilibiliNews))
+
+	g.SetMentionMode(test.BibiliLive, MentionNone)
+	assert.Equal(t, MentionNone, g.GetMentionMode(test.BibiliLive))
+	assert.False(t, g.CheckAtAll(test.BibiliLive))
+
+	g.SetMentionMode(test.BilibiliNews, MentionAll)
+	assert.Equal(t, MentionAll, g.GetMentionMode(test.BilibiliNews))
+	assert.True(t, g.CheckAtAll(test.BilibiliNews))
+	assert.Len(t, g.Mention, 2)
+
+	g.SetMentionMode(test.BilibiliNews, MentionMember)
+	assert.Equal(t, MentionMember, g.GetMentionMode(test.BilibiliNews))
+	assert.False(t, g.CheckAtAll(test.BilibiliNews))
+	assert.Len(t, g.Mention, 2)
+
+	g.ClearMentionMode(test.BibiliLive)
+	assert.Equal(t, MentionMember, g.GetMentionMode(test.BibiliLive))
+	assert.Len(t, g.Mention, 1)
+
+	for _, s := range []string{"none", "member", "all"} {
+		mode, ok := ParseMentionMode(s)
+		assert.True(t, ok)
+		assert.EqualValues(t, s, mode)
+	}
+	_, ok := ParseMentionMode("xxx")
+	assert.False(t, ok)
+}
+
 func TestNewGroupConcernConfigFromString(t *testing.T) {
 	var testCase = []string{
 		`{"group_concern_at":{"at_all":"bilibiliLive","at_someone":[{"ctype":"bilibiliLive", "at_list":[1,2,3,4,5]}]}}`,
@@ -74,7 +112,8 @@ func TestGroupConcernConfig_ToString(t *testing.T) {
 			},
 			"group_concern_filter": {
 				"type": "", "config":""
-			}
+			},
+			"group_concern_template": {}
 		}`,
 	}
 	assert.Equal(t, len(testCase), len(expected))
@@ -256,6 +295,29 @@ func TestGroupConcernConfig_Validate(t *testing.T) {
 	g.GetGroupConcernFilter().Type = FilterTypeType
 	g.GetGroupConcernFilter().Config = "wrong"
 	assert.NotNil(t, g.Validate())
+
+	var g2 GroupConcernConfig
+	g2.GetGroupConcernNotify().DanmakuRelay = &GroupConcernDanmakuRelayConfig{Enable: true}
+	assert.Equal(t, ErrConfigNotSupported, g2.Validate())
+
+	var g3 GroupConcernConfig
+	g3.GetGroupConcernNotify().Record = true
+	assert.Equal(t, ErrConfigNotSupported, g3.Validate())
+
+	var g4 GroupConcernConfig
+	g4.GetGroupConcernNotify().ScheduleRemind = 10
+	assert.Equal(t, ErrConfigNotSupported, g4.Validate())
+
+	var g5 GroupConcernConfig
+	g5.GetGroupConcernNotify().DanmakuAlert = &GroupConcernDanmakuAlertConfig{}
+	assert.Nil(t, g5.Validate())
+	g5.GetGroupConcernNotify().DanmakuAlert.Keywords = []string{"开奖"}
+	assert.Equal(t, ErrConfigNotSupported, g5.Validate())
+
+	var g6 GroupConcernConfig
+	g6.GetGroupConcernNotify().Translate = true
+	assert.True(t, g6.GetGroupConcernNotify().CheckTranslate())
+	assert.Nil(t, g6.Validate())
 }
 
 type testInfo struct {
@@ -263,8 +325,10 @@ type testInfo struct {
 	living        bool
 	titleChanged  bool
 	statusChanged bool
+	coverChanged  bool
+	areaChanged   bool
 	uid           int64
-	groupCode     int64
+	target        mmsg.TargetId
 	t             concern_type.Type
 }
 
@@ -287,8 +351,8 @@ func (t *testInfo) Logger() *logrus.Entry {
 	return logrus.WithField("Site", t.Site())
 }
 
-func (t *testInfo) GetGroupCode() int64 {
-	return t.groupCode
+func (t *testInfo) GetTarget() mmsg.TargetId {
+	return t.target
 }
 
 func (t *testInfo) ToMessage() *mmsg.MSG {
@@ -299,6 +363,14 @@ func (t *testInfo) TitleChanged() bool {
 	return t.titleChanged
 }
 
+func (t *testInfo) CoverChanged() bool {
+	return t.coverChanged
+}
+
+func (t *testInfo) AreaChanged() bool {
+	return t.areaChanged
+}
+
 func (t *testInfo) IsLive() bool {
 	return t.isLive
 }
@@ -404,6 +476,31 @@ func TestGroupConcernConfig_ShouldSendHook(t *testing.T) {
 	}
 }
 
+func TestGroupConcernConfig_ShouldSendHookLiveChange(t *testing.T) {
+	var coverChanged = &testInfo{isLive: true, living: true, coverChanged: true}
+	var areaChanged = &testInfo{isLive: true, living: true, areaChanged: true}
+	var titleAndCoverChanged = &testInfo{isLive: true, living: true, titleChanged: true, coverChanged: true}
+	// 下播状态的变化不推
+	var notLiving = &testInfo{isLive: true, living: false, coverChanged: true}
+
+	var g = new(GroupConcernConfig)
+	assert.False(t, g.ShouldSendHook(coverChanged).Pass)
+	assert.False(t, g.ShouldSendHook(areaChanged).Pass)
+	assert.False(t, g.ShouldSendHook(titleAndCoverChanged).Pass)
+	assert.False(t, g.ShouldSendHook(notLiving).Pass)
+
+	g.GetGroupConcernNotify().LiveChangeNotify = test.BibiliLive
+	assert.True(t, g.ShouldSendHook(coverChanged).Pass)
+	assert.True(t, g.ShouldSendHook(areaChanged).Pass)
+	assert.True(t, g.ShouldSendHook(titleAndCoverChanged).Pass)
+	assert.False(t, g.ShouldSendHook(notLiving).Pass)
+
+	g.GetGroupConcernNotify().LiveChangeNotify = ""
+	g.GetGroupConcernNotify().TitleChangeNotify = test.BibiliLive
+	assert.False(t, g.ShouldSendHook(coverChanged).Pass)
+	assert.True(t, g.ShouldSendHook(titleAndCoverChanged).Pass)
+}
+
 func TestGroupConcernConfig_AtBeforeHook(t *testing.T) {
 	var liveInfos = []Notify{
 		// 下播状态 什么也没变 不推
@@ -445,3 +542,59 @@ func TestGroupConcernConfig_FilterHook(t *testing.T) {
 	result := g.FilterHook(newLiveInfo(test.UID1, true, true, true))
 	assert.True(t, result.Pass)
 }
+
+func TestGroupConcernFilterConfigByText_Match(t *testing.T) {
+	var g = &GroupConcernFilterConfigByText{}
+	assert.True(t, g.Empty())
+	ok, _ := g.Match("anything")
+	assert.True(t, ok)
+
+	g.Text = []string{"抽奖", "直播"}
+	ok, _ = g.Match("今晚直播")
+	assert.True(t, ok)
+	ok, reason := g.Match("普通动态")
+	assert.False(t, ok)
+	assert.NotEmpty(t, reason)
+
+	g.NotText = []string{"广告"}
+	ok, _ = g.Match("直播带广告")
+	assert.False(t, ok)
+
+	g.Text = nil
+	g.Regex = []string{`^\d+号`}
+	ok, _ = g.Match("3号直播")
+	assert.True(t, ok)
+	ok, _ = g.Match("直播3号")
+	assert.False(t, ok)
+
+	g.NotRegex = []string{`[Bb]V\w+`}
+	ok, _ = g.Match("3号投稿BV1xx")
+	assert.False(t, ok)
+	assert.False(t, g.Empty())
+
+	assert.Nil(t, g.Validate())
+	g.NotRegex = append(g.NotRegex, `(`)
+	assert.NotNil(t, g.Validate())
+	ok, reason = g.Match("3号直播")
+	assert.False(t, ok)
+	assert.Contains(t, reason, "invalid")
+	// 编译错误同样会被缓存
+	ok, reason = g.Match("3号直播")
+	assert.False(t, ok)
+	assert.Contains(t, reason, "invalid")
+
+	re1, err := compileRegex(`^\d+号`)
+	assert.Nil(t, err)
+	re2, err := compileRegex(`^\d+号`)
+	assert.Nil(t, err)
+	assert.True(t, re1 == re2)
+}
+
+func TestGroupConcernConfig_ValidateTextFilter(t *testing.T) {
+	var g = &GroupConcernConfig{}
+	g.GetGroupConcernFilter().Type = FilterTypeText
+	g.GetGroupConcernFilter().Config = (&GroupConcernFilterConfigByText{Regex: []string{`\d+`}}).ToString()
+	assert.Nil(t, g.Validate())
+	g.GetGroupConcernFilter().Config = (&GroupConcernFilterConfigByText{NotRegex: []string{`[`}}).ToString()
+	assert.NotNil(t, g.Validate())
+}
@@ -1,6 +1,10 @@
 package concern
 
-import "errors"
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/requests"
+	"net/http"
+)
 
 var (
 	ErrAlreadyExists  = errors.New("already exists")
@@ -9,4 +13,20 @@ var (
 	ErrTypeNotSupported   = errors.New("不支持的类型参数")
 	ErrSiteNotSupported   = errors.New("不支持的网站参数")
 	ErrConfigNotSupported = errors.New("不支持的配置")
+
+	// ErrRateLimited 刷新时触发了风控或者请求频率限制，订阅模块可以用 fmt.Errorf("%w") 包装后返回，
+	// StateManager 会暂停刷新一段时间
+	ErrRateLimited = errors.New("触发了风控或者请求频率限制")
 )
+
+// IsRateLimited 判断err是否是风控或者请求频率限制，http code 412 与 429 也会被当作频率限制
+func IsRateLimited(err error) bool {
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var codeErr *requests.HttpCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.Code == http.StatusPreconditionFailed || codeErr.Code == http.StatusTooManyRequests
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package concern
+
+import (
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Topic 事件总线中事件的种类
+type Topic string
+
+const (
+	// TopicLiveStart 开播
+	TopicLiveStart Topic = "live_start"
+	// TopicLiveStop 下播
+	TopicLiveStop Topic = "live_stop"
+	// TopicLiveTitleChange 直播中更改了标题
+	TopicLiveTitleChange Topic = "live_title_change"
+	// TopicLiveChange 直播中更换了封面或者分区，需要 Event 实现 NotifyLiveChangeExt
+	TopicLiveChange Topic = "live_change"
+	// TopicNewDynamic 动态、视频等不是直播状态变化的事件
+	TopicNewDynamic Topic = "new_dynamic"
+	// TopicNotify 经过群配置过滤后需要推送到群内的 Notify ，每个 BusEvent 只包含一个 Notify
+	TopicNotify Topic = "notify"
+	// TopicBreaker 网站因为风控或者连续刷新失败暂停刷新，以及之后完全恢复，事件内容在 BusEvent.Breaker 中
+	TopicBreaker Topic = "breaker"
+)
+
+// subscriberBacklogWarn 订阅者还没有处理的事件达到这个数量时记录警告，Publish 不会因为订阅者处理较慢而阻塞
+const subscriberBacklogWarn = 64
+
+// BusEvent 事件总线中传递的事件
+type BusEvent struct {
+	Topic Topic
+	// Event 模块产生的原始事件， TopicNotify 中为nil
+	Event Event
+	// Targets 订阅了这个事件的所有推送目标，不受群配置过滤的影响，例如关闭了下播推送的群也会出现在 TopicLiveStop 中
+	Targets []mmsg.TargetId
+	// Notifies 经过群配置过滤后需要推送的 Notify ，可能为空
+	Notifies []Notify
+	// Breaker 只在 TopicBreaker 中有效
+	Breaker *BreakerEvent
+	Time    time.Time
+}
+
+// Site 返回事件所属的网站
+func (e *BusEvent) Site() string {
+	if e.Breaker != nil {
+		return e.Breaker.Site
+	}
+	if e.Event != nil {
+		return e.Event.Site()
+	}
+	if len(e.Notifies) > 0 {
+		return e.Notifies[0].Site()
+	}
+	return ""
+}
+
+// TopicOf 根据 NotifyLiveExt 判断 Event 的种类，直播事件的状态没有变化时返回空字符串，
+// 没有实现 NotifyLiveExt 或者 IsLive 为false的事件都认为是 TopicNewDynamic
+func TopicOf(event Event) Topic {
+	liveExt, ok := event.(NotifyLiveExt)
+	if !ok || !liveExt.IsLive() {
+		return TopicNewDynamic
+	}
+	switch {
+	case liveExt.LiveStatusChanged() && liveExt.Living():
+		return TopicLiveStart
+	case liveExt.LiveStatusChanged():
+		return TopicLiveStop
+	case liveExt.Living() && liveExt.TitleChanged():
+		return TopicLiveTitleChange
+	case liveExt.Living() && liveChanged(event):
+		return TopicLiveChange
+	default:
+		return ""
+	}
+}
+
+func liveChanged(event Event) bool {
+	changeExt, ok := event.(NotifyLiveChangeExt)
+	return ok && (changeExt.CoverChanged() || changeExt.AreaChanged())
+}
+
+// BusHandler 处理事件总线中的事件，panic会被恢复并记录到日志中
+type BusHandler func(e *BusEvent)
+
+// subscription 每个订阅者使用一个不限长度的队列缓存还没有处理的事件
+type subscription struct {
+	name    string
+	topics  map[Topic]bool
+	handler BusHandler
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []*BusEvent
+	closed bool
+}
+
+func newSubscription(name string, handler BusHandler, topics ...Topic) *subscription {
+	var s = &subscription{
+		name:    name,
+		topics:  make(map[Topic]bool),
+		handler: handler,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+	return s
+}
+
+func (s *subscription) match(topic Topic) bool {
+	return len(s.topics) == 0 || s.topics[topic]
+}
+
+// push 把事件加入队列，不会阻塞，订阅者已经关闭时丢弃
+func (s *subscription) push(e *BusEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.queue = append(s.queue, e)
+	if len(s.queue)%subscriberBacklogWarn == 0 {
+		logger.WithField("subscriber", s.name).WithField("backlog", len(s.queue)).
+			Warn("event bus订阅者处理事件较慢，未处理的事件正在堆积")
+	}
+	s.cond.Signal()
+}
+
+// close 关闭订阅者，已经在队列中的事件仍然会被处理
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+// pop 取出下一个事件，队列为空时等待，关闭并且队列为空时返回nil
+func (s *subscription) pop() *BusEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.queue) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if len(s.queue) == 0 {
+		return nil
+	}
+	e := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	return e
+}
+
+func (s *subscription) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for e := s.pop(); e != nil; e = s.pop() {
+		s.handle(e)
+	}
+}
+
+func (s *subscription) handle(e *BusEvent) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).
+				WithField("subscriber", s.name).WithField("topic", e.Topic).
+				Errorf("event bus handler panic recovered %v", err)
+		}
+	}()
+	s.handler(e)
+}
+
+// EventBus 事件总线，订阅模块产生的事件会发布到事件总线中，
+// QQ推送、录制、监控指标等功能作为订阅者接收自己关心的事件，新增的功能不需要修改各个订阅模块
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   []*subscription
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe 订阅指定种类的事件，topics为空时订阅所有事件，返回取消订阅的函数。
+// 每个订阅者使用单独的goroutine按顺序处理事件，处理较慢的订阅者不会影响其他订阅者，
+// 注意不能在 BusHandler 中调用 Subscribe 或者取消订阅
+func (b *EventBus) Subscribe(name string, handler BusHandler, topics ...Topic) (unsubscribe func()) {
+	var s = newSubscription(name, handler, topics...)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		logger.WithField("subscriber", name).Warn("event bus已关闭，订阅失败")
+		return func() {}
+	}
+	b.subs = append(b.subs, s)
+	b.wg.Add(1)
+	go s.run(&b.wg)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for idx, sub := range b.subs {
+				if sub == s {
+					b.subs = append(b.subs[:idx], b.subs[idx+1:]...)
+					s.close()
+					break
+				}
+			}
+		})
+	}
+}
+
+// Publish 把事件加入所有订阅了这个种类的订阅者的队列中，不会等待订阅者处理，
+// 事件总线关闭后发布的事件会被丢弃
+func (b *EventBus) Publish(e *BusEvent) {
+	if e == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	var subs []*subscription
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		logger.WithField("topic", e.Topic).Debug("event bus已关闭，丢弃事件")
+		return
+	}
+	for _, s := range b.subs {
+		if s.match(e.Topic) {
+			subs = append(subs, s)
+		}
+	}
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s.push(e)
+	}
+}
+
+// Close 关闭事件总线，等待所有订阅者处理完已经发布的事件
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, s := range b.subs {
+		s.close()
+	}
+	b.subs = nil
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+var globalBus = NewEventBus()
+
+// GetEventBus 返回全局的事件总线
+func GetEventBus() *EventBus {
+	return globalBus
+}
+
+// Subscribe 订阅全局事件总线中指定种类的事件，topics为空时订阅所有事件，返回取消订阅的函数。
+func Subscribe(name string, handler BusHandler, topics ...Topic) (unsubscribe func()) {
+	return globalBus.Subscribe(name, handler, topics...)
+}
+
+// Publish 向全局事件总线发布事件，通常由 StateManager.DefaultDispatch 和框架负责调用。
+func Publish(e *BusEvent) {
+	globalBus.Publish(e)
+}
+
+// publishEvent 把 Event 按照 TopicOf 的种类发布到全局事件总线
+func publishEvent(event Event, targets []mmsg.TargetId, notifies []Notify) {
+	topic := TopicOf(event)
+	if topic == "" || len(targets) == 0 {
+		return
+	}
+	Publish(&BusEvent{
+		Topic:    topic,
+		Event:    event,
+		Targets:  targets,
+		Notifies: notifies,
+	})
+}
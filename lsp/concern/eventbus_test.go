@@ -0,0 +1,121 @@
+package concern
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopicOf(t *testing.T) {
+	assert.EqualValues(t, TopicNewDynamic, TopicOf(&testInfo{}))
+	assert.EqualValues(t, TopicLiveStart, TopicOf(&testInfo{isLive: true, living: true, statusChanged: true}))
+	assert.EqualValues(t, TopicLiveStop, TopicOf(&testInfo{isLive: true, living: false, statusChanged: true}))
+	assert.EqualValues(t, TopicLiveTitleChange, TopicOf(&testInfo{isLive: true, living: true, titleChanged: true}))
+	assert.Empty(t, TopicOf(&testInfo{isLive: true, living: true}))
+	assert.Empty(t, TopicOf(&testInfo{isLive: true, living: false, titleChanged: true}))
+	assert.EqualValues(t, TopicLiveChange, TopicOf(&testInfo{isLive: true, living: true, coverChanged: true}))
+	assert.EqualValues(t, TopicLiveChange, TopicOf(&testInfo{isLive: true, living: true, areaChanged: true}))
+	assert.EqualValues(t, TopicLiveTitleChange, TopicOf(&testInfo{isLive: true, living: true, titleChanged: true, areaChanged: true}))
+	assert.Empty(t, TopicOf(&testInfo{isLive: true, living: false, areaChanged: true}))
+}
+
+func TestEventBus(t *testing.T) {
+	var bus = NewEventBus()
+
+	var mu sync.Mutex
+	var live, all []Topic
+	var record = func(result *[]Topic) BusHandler {
+		return func(e *BusEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			*result = append(*result, e.Topic)
+		}
+	}
+	unsubscribe := bus.Subscribe("live", record(&live), TopicLiveStart, TopicLiveStop)
+	bus.Subscribe("all", record(&all))
+	bus.Subscribe("panic", func(e *BusEvent) {
+		panic("test")
+	})
+
+	var event = &testInfo{isLive: true, living: true, statusChanged: true}
+	bus.Publish(&BusEvent{Topic: TopicLiveStart, Event: event, Targets: []mmsg.TargetId{mmsg.NewGroupTargetId(test.G1)}})
+	bus.Publish(&BusEvent{Topic: TopicNewDynamic})
+	bus.Publish(nil)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(live) == 1 && len(all) == 2
+	}, time.Second, time.Millisecond*10)
+
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(&BusEvent{Topic: TopicLiveStop})
+
+	bus.Close()
+	assert.EqualValues(t, []Topic{TopicLiveStart}, live)
+	assert.EqualValues(t, []Topic{TopicLiveStart, TopicNewDynamic, TopicLiveStop}, all)
+
+	// 关闭后不再接收事件
+	bus.Publish(&BusEvent{Topic: TopicLiveStop})
+	bus.Subscribe("closed", record(&live))
+	bus.Close()
+	assert.Len(t, all, 3)
+}
+
+func TestEventBus_SlowSubscriber(t *testing.T) {
+	var bus = NewEventBus()
+
+	var release = make(chan struct{})
+	var blocked = make(chan struct{}, 1)
+	bus.Subscribe("slow", func(e *BusEvent) {
+		select {
+		case blocked <- struct{}{}:
+		default:
+		}
+		<-release
+	})
+	var mu sync.Mutex
+	var count int
+	bus.Subscribe("fast", func(e *BusEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+	})
+
+	const total = subscriberBacklogWarn * 3
+	var published = make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < total; i++ {
+			bus.Publish(&BusEvent{Topic: TopicNewDynamic})
+		}
+	}()
+	<-blocked
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		assert.Fail(t, "publish blocked by slow subscriber")
+	}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return count == total
+	}, time.Second, time.Millisecond*10)
+
+	// 处理较慢的订阅者不影响订阅和取消订阅
+	unsubscribe := bus.Subscribe("other", func(e *BusEvent) {})
+	unsubscribe()
+
+	close(release)
+	bus.Close()
+}
+
+func TestBusEvent_Site(t *testing.T) {
+	assert.Empty(t, new(BusEvent).Site())
+	assert.Equal(t, testSite, (&BusEvent{Event: &testInfo{}}).Site())
+	assert.Equal(t, testSite, (&BusEvent{Notifies: []Notify{&testInfo{}}}).Site())
+}
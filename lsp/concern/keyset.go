@@ -1,7 +1,11 @@
 package concern
 
 import (
+	"errors"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"strconv"
+	"strings"
 )
 
 // KeySet 是不同 StateManager 之间用来彼此隔离的一个接口。
@@ -12,7 +16,7 @@ type KeySet interface {
 	GroupConcernConfigKey(keys ...interface{}) string
 	FreshKey(keys ...interface{}) string
 	GroupAtAllMarkKey(keys ...interface{}) string
-	ParseGroupConcernStateKey(key string) (groupCode int64, id interface{}, err error)
+	ParseGroupConcernStateKey(key string) (target mmsg.TargetId, id interface{}, err error)
 }
 
 // PrefixKeySet 是 KeySet 的一个默认实现，它使用一个唯一的前缀彼此区分。
@@ -22,7 +26,7 @@ type PrefixKeySet struct {
 	groupConcernConfigKey string
 	freshKey              string
 	groupAtAllMarkKey     string
-	parser                func(key string) (groupCode int64, id interface{}, err error)
+	parser                func(key string) (target mmsg.TargetId, id interface{}, err error)
 }
 
 func (p *PrefixKeySet) GroupConcernStateKey(keys ...interface{}) string {
@@ -41,11 +45,11 @@ func (p *PrefixKeySet) GroupAtAllMarkKey(keys ...interface{}) string {
 	return localdb.NamedKey(p.groupAtAllMarkKey, keys)
 }
 
-func (p *PrefixKeySet) ParseGroupConcernStateKey(key string) (groupCode int64, id interface{}, err error) {
+func (p *PrefixKeySet) ParseGroupConcernStateKey(key string) (target mmsg.TargetId, id interface{}, err error) {
 	return p.parser(key)
 }
 
-func newPrefixKeySet(prefix string, parser func(key string) (groupCode int64, id interface{}, err error)) *PrefixKeySet {
+func newPrefixKeySet(prefix string, parser func(key string) (target mmsg.TargetId, id interface{}, err error)) *PrefixKeySet {
 	p := &PrefixKeySet{
 		prefix: prefix,
 		parser: parser,
@@ -61,8 +65,8 @@ func newPrefixKeySet(prefix string, parser func(key string) (groupCode int64, id
 // id的格式需要与 Concern.ParseId 返回的格式一致
 // prefix 可以简单地使用 Concern.Site
 func NewPrefixKeySetWithStringID(prefix string) *PrefixKeySet {
-	return newPrefixKeySet(prefix, func(key string) (groupCode int64, id interface{}, err error) {
-		return localdb.ParseConcernStateKeyWithString(key)
+	return newPrefixKeySet(prefix, func(key string) (target mmsg.TargetId, id interface{}, err error) {
+		return ParseConcernStateKeyWithString(key)
 	})
 }
 
@@ -70,7 +74,37 @@ func NewPrefixKeySetWithStringID(prefix string) *PrefixKeySet {
 // id的格式需要与 Concern.ParseId 返回的格式一致
 // prefix 可以简单地使用 Concern.Site
 func NewPrefixKeySetWithInt64ID(prefix string) *PrefixKeySet {
-	return newPrefixKeySet(prefix, func(key string) (groupCode int64, id interface{}, err error) {
-		return localdb.ParseConcernStateKeyWithInt64(key)
+	return newPrefixKeySet(prefix, func(key string) (target mmsg.TargetId, id interface{}, err error) {
+		return ParseConcernStateKeyWithInt64(key)
 	})
 }
+
+// ParseConcernStateKeyWithInt64 解析 KeySet.GroupConcernStateKey 格式的key，key中的推送目标为 mmsg.TargetId.Key 的格式，id为int64格式
+func ParseConcernStateKeyWithInt64(key string) (target mmsg.TargetId, id interface{}, err error) {
+	keys := strings.Split(key, ":")
+	if len(keys) != 3 {
+		return target, nil, errors.New("invalid key")
+	}
+	target, err = mmsg.ParseTargetId(keys[1])
+	if err != nil {
+		return target, nil, err
+	}
+	id, err = strconv.ParseInt(keys[2], 10, 64)
+	if err != nil {
+		return mmsg.TargetId{}, nil, err
+	}
+	return target, id, nil
+}
+
+// ParseConcernStateKeyWithString 解析 KeySet.GroupConcernStateKey 格式的key，key中的推送目标为 mmsg.TargetId.Key 的格式，id为string格式
+func ParseConcernStateKeyWithString(key string) (target mmsg.TargetId, id interface{}, err error) {
+	keys := strings.Split(key, ":")
+	if len(keys) != 3 {
+		return target, nil, errors.New("invalid key")
+	}
+	target, err = mmsg.ParseTargetId(keys[1])
+	if err != nil {
+		return target, nil, err
+	}
+	return target, keys[2], nil
+}
@@ -2,6 +2,7 @@ package concern
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -14,8 +15,24 @@ func TestNewPrefixKeySetWithInt64ID(t *testing.T) {
 	pks.GroupConcernConfigKey()
 	g, id, err := pks.ParseGroupConcernStateKey(pks.GroupConcernStateKey(test.G1, test.UID1))
 	assert.Nil(t, err)
-	assert.EqualValues(t, test.G1, g)
+	assert.EqualValues(t, mmsg.NewGroupTargetId(test.G1), g)
 	assert.EqualValues(t, test.UID1, id)
+
+	for _, target := range []mmsg.TargetId{
+		mmsg.NewGroupTargetId(test.G1),
+		mmsg.NewPrivateTargetId(test.UID2),
+		mmsg.NewTelegramTargetId(-test.G2),
+	} {
+		g, id, err = pks.ParseGroupConcernStateKey(pks.GroupConcernStateKey(target, test.UID1))
+		assert.Nil(t, err)
+		assert.Equal(t, target, g)
+		assert.EqualValues(t, test.UID1, id)
+	}
+
+	_, _, err = pks.ParseGroupConcernStateKey(pks.GroupConcernStateKey(mmsg.NewPrivateTargetId(test.UID2), test.NAME1))
+	assert.NotNil(t, err)
+	_, _, err = pks.ParseGroupConcernStateKey(pks.GroupConcernStateKey("wrong", test.UID1))
+	assert.NotNil(t, err)
 }
 
 func TestNewPrefixKeySetWithStringID(t *testing.T) {
@@ -26,6 +43,14 @@ func TestNewPrefixKeySetWithStringID(t *testing.T) {
 	pks.GroupConcernConfigKey()
 	g, id, err := pks.ParseGroupConcernStateKey(pks.GroupConcernStateKey(test.G1, test.NAME1))
 	assert.Nil(t, err)
-	assert.EqualValues(t, test.G1, g)
+	assert.EqualValues(t, mmsg.NewGroupTargetId(test.G1), g)
+	assert.EqualValues(t, test.NAME1, id)
+
+	g, id, err = pks.ParseGroupConcernStateKey(pks.GroupConcernStateKey(mmsg.NewPrivateTargetId(test.UID1), test.NAME1))
+	assert.Nil(t, err)
+	assert.Equal(t, mmsg.NewPrivateTargetId(test.UID1), g)
 	assert.EqualValues(t, test.NAME1, id)
+
+	_, _, err = pks.ParseGroupConcernStateKey("invalid")
+	assert.NotNil(t, err)
 }
@@ -2,6 +2,7 @@ package concern
 
 import (
 	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/utils"
 	"strings"
@@ -18,13 +19,21 @@ type option struct {
 type OptFunc func(opt *option) *option
 
 type center struct {
+	mu          sync.RWMutex
 	concernList []Concern
+	// disabledList 是在配置中禁用的 Concern，它们没有启动，可以通过 StartConcern 重新启动
+	disabledList []Concern
+	// registered 是所有注册过的 Concern，按注册的顺序
+	registered []Concern
+	// running 是已经启动的 Concern 的site
+	running map[string]bool
 	// for cache
 	concernMap     map[string]Concern
 	concernTypeMap map[string]concern_type.Type
 	concernSites   []string
 }
 
+// freshCache 调用时需要持有 gc.mu
 func (gc *center) freshCache() {
 	var concernMap = make(map[string]Concern)
 	var concernSites []string
@@ -40,21 +49,35 @@ func (gc *center) freshCache() {
 }
 
 func (gc *center) GetConcernMap() map[string]Concern {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
 	return gc.concernMap
 }
 
 func (gc *center) GetConcernSites() []string {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
 	return gc.concernSites
 }
 
 func (gc *center) GetConcernTypeMap() map[string]concern_type.Type {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
 	return gc.concernTypeMap
 }
 
 func (gc *center) GetConcernList() []Concern {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
 	return gc.concernList
 }
 
+func (gc *center) GetDisabledList() []Concern {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
+	return gc.disabledList
+}
+
 func (gc *center) GetConcernBySite(site string) (Concern, error) {
 	if c, found := gc.GetConcernMap()[site]; found {
 		return c, nil
@@ -78,7 +101,9 @@ func (gc *center) Register(c Concern, opts ...OptFunc) {
 	}
 	site := c.Site()
 
-	for _, concern := range gc.GetConcernList() {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	for _, concern := range gc.registered {
 		if concern.Site() == site {
 			panic(fmt.Sprintf("Concern %v: is already registered", site))
 		}
@@ -92,14 +117,29 @@ func (gc *center) Register(c Concern, opts ...OptFunc) {
 	if concern_type.Empty.Add(c.Types()...).Empty() {
 		panic(fmt.Sprintf("Concern %v: register with empty types", site))
 	}
+	gc.registered = append(gc.registered, c)
 	gc.concernList = append(gc.concernList, c)
 	gc.freshCache()
 }
 
 func (gc *center) StartAll() {
+	gc.mu.Lock()
+	var enabledConcern []Concern
+	for _, c := range gc.concernList {
+		if cfg.CheckModuleEnabled(c.Site()) {
+			enabledConcern = append(enabledConcern, c)
+		} else {
+			logger.Infof("Concern %v模块已在配置中禁用", c.Site())
+			gc.disabledList = append(gc.disabledList, c)
+		}
+	}
+	gc.concernList = enabledConcern
+	gc.freshCache()
+	gc.mu.Unlock()
+
 	var wg sync.WaitGroup
-	var errConcern = make([]int, len(gc.GetConcernList()))
-	for idx, c := range gc.concernList {
+	var errConcern = make([]int, len(enabledConcern))
+	for idx, c := range enabledConcern {
 		wg.Add(1)
 		go func(idx int, c Concern) {
 			defer wg.Done()
@@ -113,10 +153,14 @@ func (gc *center) StartAll() {
 		}(idx, c)
 	}
 	wg.Wait()
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
 	var newConcern []Concern
 	for idx, v := range errConcern {
 		if v == 0 {
-			newConcern = append(newConcern, gc.concernList[idx])
+			newConcern = append(newConcern, enabledConcern[idx])
+			gc.setRunning(enabledConcern[idx].Site(), true)
 		}
 	}
 	gc.concernList = newConcern
@@ -124,8 +168,116 @@ func (gc *center) StartAll() {
 	return
 }
 
+// setRunning 调用时需要持有 gc.mu
+func (gc *center) setRunning(site string, running bool) {
+	if gc.running == nil {
+		gc.running = make(map[string]bool)
+	}
+	if running {
+		gc.running[site] = true
+	} else {
+		delete(gc.running, site)
+	}
+}
+
+// find 查找site对应的 Concern，返回是否在 disabledList 中，调用时需要持有 gc.mu
+func (gc *center) find(site string) (Concern, bool) {
+	for _, c := range gc.concernList {
+		if c.Site() == site {
+			return c, false
+		}
+	}
+	for _, c := range gc.disabledList {
+		if c.Site() == site {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// move 把 Concern 移动到 concernList 或者 disabledList 中，两个列表都保持注册时的顺序，调用时需要持有 gc.mu
+func (gc *center) move(c Concern, disabled bool) {
+	var state = make(map[string]bool)
+	for _, e := range gc.concernList {
+		state[e.Site()] = false
+	}
+	for _, e := range gc.disabledList {
+		state[e.Site()] = true
+	}
+	state[c.Site()] = disabled
+	var enabledList, disabledList []Concern
+	for _, e := range gc.registered {
+		if d, found := state[e.Site()]; !found {
+			continue
+		} else if d {
+			disabledList = append(disabledList, e)
+		} else {
+			enabledList = append(enabledList, e)
+		}
+	}
+	gc.concernList, gc.disabledList = enabledList, disabledList
+	gc.freshCache()
+}
+
+func (gc *center) StartConcern(site string) error {
+	gc.mu.Lock()
+	c, _ := gc.find(site)
+	if c == nil {
+		gc.mu.Unlock()
+		return ErrSiteNotSupported
+	}
+	if gc.running[site] {
+		gc.mu.Unlock()
+		return nil
+	}
+	gc.mu.Unlock()
+
+	logger.Debugf("启动Concern %v模块", site)
+	err := c.Start()
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if err != nil {
+		logger.Errorf("启动Concern %v 失败 - %v", site, err)
+		gc.move(c, true)
+		return err
+	}
+	gc.setRunning(site, true)
+	gc.move(c, false)
+	return nil
+}
+
+func (gc *center) StopConcern(site string) error {
+	gc.mu.Lock()
+	c, _ := gc.find(site)
+	if c == nil {
+		gc.mu.Unlock()
+		return ErrSiteNotSupported
+	}
+	running := gc.running[site]
+	gc.setRunning(site, false)
+	// 先移出 concernList，停止期间不再接受新的订阅
+	gc.move(c, true)
+	gc.mu.Unlock()
+
+	if running {
+		logger.Debugf("停止Concern %v模块", site)
+		c.Stop()
+	}
+	return nil
+}
+
 func (gc *center) StopAll() {
-	for _, c := range gc.GetConcernList() {
+	gc.mu.Lock()
+	var running []Concern
+	for _, c := range gc.concernList {
+		if gc.running[c.Site()] {
+			running = append(running, c)
+		}
+	}
+	gc.running = nil
+	gc.mu.Unlock()
+	for _, c := range running {
 		c.Stop()
 	}
 	close(notifyChan)
@@ -145,13 +297,26 @@ func ClearConcern() {
 	globalCenter = newConcernCenter()
 }
 
-// StartAll 启动所有 Concern，正常情况下框架会负责启动。
+// StartAll 启动所有 Concern，在配置中禁用的 Concern 不会启动，启动失败的 Concern 会被移除。
 func StartAll() error {
 	globalCenter.StartAll()
 	return nil
 }
 
-// StopAll 停止所有Concern模块，正常情况下框架会负责停止。
+// StartConcern 启动site对应的 Concern，已经启动时不做任何事，
+// 启动成功后会出现在 ListConcern 中，启动失败时会被移动到 ListDisabledConcern 中。
+// 停止过的 Concern 也可以重新启动，用于在运行时启用模块。
+func StartConcern(site string) error {
+	return globalCenter.StartConcern(site)
+}
+
+// StopConcern 停止site对应的 Concern 并移动到 ListDisabledConcern 中，之后可以通过 StartConcern 重新启动，
+// 还没有启动的 Concern 只会被移动，用于在运行时禁用模块。
+func StopConcern(site string) error {
+	return globalCenter.StopConcern(site)
+}
+
+// StopAll 停止所有已经启动的Concern模块，正常情况下框架会负责停止。
 // 会关闭notifyChan，所以停止后禁止再向notifyChan中写入数据。
 func StopAll() {
 	globalCenter.StopAll()
@@ -162,6 +327,11 @@ func ListConcern() []Concern {
 	return globalCenter.GetConcernList()
 }
 
+// ListDisabledConcern 返回所有在配置中禁用的 Concern，它们不会出现在 ListConcern 中。
+func ListDisabledConcern() []Concern {
+	return globalCenter.GetDisabledList()
+}
+
 // GetConcernBySite 根据site返回 Concern。
 // 如果site没有注册过，则会返回 ErrSiteNotSupported。
 func GetConcernBySite(site string) (Concern, error) {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -12,24 +13,28 @@ type testConcern struct {
 	site     string
 	types    []concern_type.Type
 	startErr error
+	started  int
+	stopped  int
 }
 
 func (t *testConcern) Start() error {
+	t.started++
 	return t.startErr
 }
 
 func (t *testConcern) Stop() {
+	t.stopped++
 }
 
 func (t *testConcern) ParseId(s string) (interface{}, error) {
 	return s, nil
 }
 
-func (t *testConcern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (IdentityInfo, error) {
+func (t *testConcern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (IdentityInfo, error) {
 	return nil, nil
 }
 
-func (t *testConcern) Remove(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (IdentityInfo, error) {
+func (t *testConcern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (IdentityInfo, error) {
 	return nil, nil
 }
 
@@ -41,7 +46,7 @@ func (t *testConcern) GetStateManager() IStateManager {
 	return nil
 }
 
-func (t *testConcern) FreshIndex(groupCode ...int64) {
+func (t *testConcern) FreshIndex(targets ...mmsg.TargetId) {
 }
 
 func (t *testConcern) Site() string {
@@ -164,9 +169,20 @@ func TestConcernManager(t *testing.T) {
 	assert.Nil(t, cm)
 	assert.EqualValues(t, ErrSiteNotSupported, err)
 
+	RegisterConcern(&testConcern{
+		site: "disabledSite",
+		types: []concern_type.Type{
+			"10",
+		},
+	})
+	config.GlobalConfig.Set("module.disable", []string{"disabledSite"})
+	defer config.GlobalConfig.Set("module.disable", nil)
+
 	StartAll()
 
 	assert.NotContains(t, ListSite(), "errSite")
+	assert.NotContains(t, ListSite(), "disabledSite")
+	assert.Len(t, ListDisabledConcern(), 1)
 
 	_, err = GetConcernBySite("errSite")
 	assert.EqualValues(t, ErrSiteNotSupported, err)
@@ -207,3 +223,44 @@ func TestConcernManager(t *testing.T) {
 
 	ClearConcern()
 }
+
+func TestStartStopConcern(t *testing.T) {
+	defer ClearConcern()
+
+	c1 := &testConcern{site: "test1", types: []concern_type.Type{"1"}}
+	c2 := &testConcern{site: "test2", types: []concern_type.Type{"2"}}
+	c3 := &testConcern{site: "test3", types: []concern_type.Type{"3"}, startErr: errors.New("error")}
+	RegisterConcern(c1)
+	RegisterConcern(c2)
+	RegisterConcern(c3)
+
+	assert.Equal(t, ErrSiteNotSupported, StartConcern("test4"))
+	assert.Equal(t, ErrSiteNotSupported, StopConcern("test4"))
+
+	assert.Nil(t, StartConcern("test1"))
+	assert.Nil(t, StartConcern("test1"))
+	assert.Equal(t, 1, c1.started)
+
+	// 没有启动过的 Concern 只会被移动，不会调用 Stop
+	assert.Nil(t, StopConcern("test2"))
+	assert.Equal(t, 0, c2.stopped)
+	assert.Equal(t, []string{"test1", "test3"}, ListSite())
+	assert.Len(t, ListDisabledConcern(), 1)
+
+	assert.NotNil(t, StartConcern("test3"))
+	assert.Equal(t, []string{"test1"}, ListSite())
+	assert.Len(t, ListDisabledConcern(), 2)
+
+	assert.Nil(t, StopConcern("test1"))
+	assert.Equal(t, 1, c1.stopped)
+	assert.Empty(t, ListSite())
+	_, err := GetConcernBySite("test1")
+	assert.Equal(t, ErrSiteNotSupported, err)
+
+	// 停止后可以重新启动，顺序与注册时相同
+	assert.Nil(t, StartConcern("test2"))
+	assert.Nil(t, StartConcern("test1"))
+	assert.Equal(t, 2, c1.started)
+	assert.Equal(t, []string{"test1", "test2"}, ListSite())
+	assert.Len(t, ListDisabledConcern(), 1)
+}
@@ -7,10 +7,11 @@ import (
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/metrics"
 	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/MiraiGo-Template/utils"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
 	"go.uber.org/atomic"
 	"runtime"
 	"runtime/debug"
@@ -23,11 +24,11 @@ var ErrEmitQueueNotInit = errors.New("emit queue not init")
 var ErrMaxGroupConcernExceed = errors.New("本群已达到订阅上限")
 
 // NotifyGeneratorFunc 是 IStateManager.NotifyGenerator 函数的具体逻辑
-// 它针对一组 groupCode 把 Event 转变成一组 Notify
+// 它针对一个推送目标把 Event 转变成一组 Notify
 //
 // 使用 StateManager 时，在 StateManager.Start 之前，
 // 必须使用 StateManager.UseNotifyGeneratorFunc 来指定一个 NotifyGeneratorFunc, 否则会发生 panic
-type NotifyGeneratorFunc func(groupCode int64, event Event) []Notify
+type NotifyGeneratorFunc func(target mmsg.TargetId, event Event) []Notify
 
 // DispatchFunc 是 IStateManager.Dispatch 函数的具体逻辑
 // 它从event channel中获取 Event，把 Event 转变成（可能多个） Notify 并发送到notify channel
@@ -43,26 +44,33 @@ type DispatchFunc func(event <-chan Event, notify chan<- Notify)
 type FreshFunc func(ctx context.Context, eventChan chan<- Event)
 
 type IStateManager interface {
-	GetGroupConcernConfig(groupCode int64, id interface{}) (concernConfig IConfig)
-	OperateGroupConcernConfig(groupCode int64, id interface{}, cfg IConfig, f func(concernConfig IConfig) bool) error
+	GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig IConfig)
+	OperateGroupConcernConfig(target mmsg.TargetId, id interface{}, cfg IConfig, f func(concernConfig IConfig) bool) error
 
-	GetGroupConcern(groupCode int64, id interface{}) (result concern_type.Type, err error)
+	GetGroupConcern(target mmsg.TargetId, id interface{}) (result concern_type.Type, err error)
 	GetConcern(id interface{}) (result concern_type.Type, err error)
 
-	CheckAndSetAtAllMark(groupCode int64, id interface{}) (result bool)
-	CheckGroupConcern(groupCode int64, id interface{}, ctype concern_type.Type) error
+	CheckAndSetAtAllMark(target mmsg.TargetId, id interface{}) (result bool)
+	CheckGroupConcern(target mmsg.TargetId, id interface{}, ctype concern_type.Type) error
 	CheckConcern(id interface{}, ctype concern_type.Type) error
 
-	AddGroupConcern(groupCode int64, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error)
-	RemoveGroupConcern(groupCode int64, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error)
-	RemoveAllByGroupCode(groupCode int64) (keys []string, err error)
+	AddGroupConcern(target mmsg.TargetId, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error)
+	RemoveGroupConcern(target mmsg.TargetId, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error)
+	RemoveAllByGroupCode(target mmsg.TargetId) (keys []string, err error)
+	// GroupKeyPrefix 返回所有与推送目标有关的key前缀，用于清除一个群的全部数据
+	GroupKeyPrefix(target mmsg.TargetId) []string
 
-	ListConcernState(filter func(groupCode int64, id interface{}, p concern_type.Type) bool) (idGroups []int64,
+	ListConcernState(filter func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool) (targets []mmsg.TargetId,
 		ids []interface{}, idTypes []concern_type.Type, err error)
 	GroupTypeById(ids []interface{}, types []concern_type.Type) ([]interface{}, []concern_type.Type, error)
 
+	// GetFreshErrorCount 返回id连续刷新失败的次数，用于检测已经注销或者被封禁的订阅
+	GetFreshErrorCount(id interface{}) int64
+	// ClearFreshErrorCount 清除id连续刷新失败的次数
+	ClearFreshErrorCount(id interface{}) error
+
 	// NotifyGenerator 从 Event 产生多个 Notify
-	NotifyGenerator(groupCode int64, event Event) []Notify
+	NotifyGenerator(target mmsg.TargetId, event Event) []Notify
 	// Fresh 是一个长生命周期的函数，它产生 Event
 	Fresh(wg *sync.WaitGroup, eventChan chan<- Event)
 	// Dispatch 是一个长生命周期的函数，它从event channel中获取 Event， 并产生 Notify 发送到notify channel
@@ -97,19 +105,20 @@ type StateManager struct {
 	logger              *logrus.Entry
 	maxGroupConcern     int
 	largeNotifyCount    atomic.Int32
+	breaker             breaker
 }
 
-func (c *StateManager) getGroupConcernConfig(groupCode int64, id interface{}) (concernConfig *GroupConcernConfig) {
-	val, err := c.Get(c.GroupConcernConfigKey(groupCode, id), localdb.IgnoreNotFoundOpt())
+func (c *StateManager) getGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig *GroupConcernConfig) {
+	val, err := c.Get(c.GroupConcernConfigKey(target, id), localdb.IgnoreNotFoundOpt())
 	if err != nil {
-		c.Logger().WithFields(localutils.GroupLogFields(groupCode)).
+		c.Logger().WithField("Target", target).
 			WithField("id", id).
 			Errorf("GetGroupConcernConfig error %v", err)
 	}
 	if len(val) > 0 {
 		concernConfig, err = NewGroupConcernConfigFromString(val)
 		if err != nil {
-			c.Logger().WithFields(localutils.GroupLogFields(groupCode)).
+			c.Logger().WithField("Target", target).
 				WithFields(logrus.Fields{"id": id, "val": val}).Errorf("NewGroupConcernConfigFromString error %v", err)
 		}
 	}
@@ -120,12 +129,12 @@ func (c *StateManager) getGroupConcernConfig(groupCode int64, id interface{}) (c
 }
 
 // GetGroupConcernConfig 总是返回non-nil
-func (c *StateManager) GetGroupConcernConfig(groupCode int64, id interface{}) IConfig {
-	return c.getGroupConcernConfig(groupCode, id)
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) IConfig {
+	return c.getGroupConcernConfig(target, id)
 }
 
 // OperateGroupConcernConfig 在一个rw事务中获取GroupConcernConfig并交给函数，如果返回true，就保存GroupConcernConfig，否则就回滚。
-func (c *StateManager) OperateGroupConcernConfig(groupCode int64, id interface{}, cfg IConfig, f func(concernConfig IConfig) bool) error {
+func (c *StateManager) OperateGroupConcernConfig(target mmsg.TargetId, id interface{}, cfg IConfig, f func(concernConfig IConfig) bool) error {
 	err := c.RWCover(func() error {
 		if !f(cfg) {
 			return localdb.ErrRollback
@@ -133,26 +142,27 @@ func (c *StateManager) OperateGroupConcernConfig(groupCode int64, id interface{}
 		if err := cfg.Validate(); err != nil {
 			return err
 		}
-		ccfg := c.getGroupConcernConfig(groupCode, id)
+		ccfg := c.getGroupConcernConfig(target, id)
 		ccfg.GroupConcernNotify = *cfg.GetGroupConcernNotify()
 		ccfg.GroupConcernAt = *cfg.GetGroupConcernAt()
 		ccfg.GroupConcernFilter = *cfg.GetGroupConcernFilter()
-		return c.SetJson(c.GroupConcernConfigKey(groupCode, id), ccfg)
+		ccfg.GroupConcernTemplate = *cfg.GetGroupConcernTemplate()
+		return c.SetJson(c.GroupConcernConfigKey(target, id), ccfg)
 	})
 	return err
 }
 
 // CheckAndSetAtAllMark 检查@全体标记是否过期，未设置过或已过期返回true，并重置标记，否则返回false。
 // 因为@全体有次数限制，并且较为恼人，故设置标记，两次@全体之间必须有间隔。
-func (c *StateManager) CheckAndSetAtAllMark(groupCode int64, id interface{}) (result bool) {
-	err := c.Set(c.GroupAtAllMarkKey(groupCode, id), "",
+func (c *StateManager) CheckAndSetAtAllMark(target mmsg.TargetId, id interface{}) (result bool) {
+	err := c.Set(c.GroupAtAllMarkKey(target, id), "",
 		localdb.SetExpireOpt(time.Hour*2), localdb.SetNoOverWriteOpt())
 	return err == nil
 }
 
 // CheckGroupConcern 检查group是否已经添加过id的ctype订阅，如果添加过，返回 ErrAlreadyExists
-func (c *StateManager) CheckGroupConcern(groupCode int64, id interface{}, ctype concern_type.Type) error {
-	state, _ := c.GetGroupConcern(groupCode, id)
+func (c *StateManager) CheckGroupConcern(target mmsg.TargetId, id interface{}, ctype concern_type.Type) error {
+	state, _ := c.GetGroupConcern(target, id)
 	if state.ContainAll(ctype) {
 		return ErrAlreadyExists
 	}
@@ -173,16 +183,16 @@ func (c *StateManager) CheckConcern(id interface{}, ctype concern_type.Type) err
 
 // AddGroupConcern 在group内添加id的ctype订阅，多次添加同样的订阅会返回 ErrAlreadyExists，如果超过订阅上限，则会返回 ErrMaxGroupConcernExceed。
 // 订阅上限可以使用 SetMaxGroupConcern 设置。
-func (c *StateManager) AddGroupConcern(groupCode int64, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error) {
+func (c *StateManager) AddGroupConcern(target mmsg.TargetId, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error) {
 	err = c.RWCover(func() error {
 		var err error
-		if c.CheckGroupConcern(groupCode, id, ctype) == ErrAlreadyExists {
+		if c.CheckGroupConcern(target, id, ctype) == ErrAlreadyExists {
 			return ErrAlreadyExists
 		}
 
 		if c.maxGroupConcern > 0 {
-			_, ids, ctypes, err := c.ListConcernState(func(_groupCode int64, id interface{}, p concern_type.Type) bool {
-				return _groupCode == groupCode
+			_, ids, ctypes, err := c.ListConcernState(func(_target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+				return _target == target
 			})
 			if err != nil {
 				return err
@@ -193,7 +203,7 @@ func (c *StateManager) AddGroupConcern(groupCode int64, id interface{}, ctype co
 			}
 		}
 
-		groupStateKey := c.GroupConcernStateKey(groupCode, id)
+		groupStateKey := c.GroupConcernStateKey(target, id)
 		newCtype, err = c.upsertConcernType(groupStateKey, ctype)
 		if err != nil {
 			return err
@@ -214,14 +224,14 @@ func (c *StateManager) AddGroupConcern(groupCode int64, id interface{}, ctype co
 	return
 }
 
-// RemoveGroupConcern 在group内删除id的ctype订阅，并返回删除后当前id的在群内的ctype，删除不存在的订阅会返回 buntdb.ErrNotFound
-func (c *StateManager) RemoveGroupConcern(groupCode int64, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error) {
-	err = c.RWCoverTx(func(tx *buntdb.Tx) error {
+// RemoveGroupConcern 在group内删除id的ctype订阅，并返回删除后当前id的在群内的ctype，删除不存在的订阅会返回 localdb.ErrNotFound
+func (c *StateManager) RemoveGroupConcern(target mmsg.TargetId, id interface{}, ctype concern_type.Type) (newCtype concern_type.Type, err error) {
+	err = c.RWCoverTx(func(tx localdb.Tx) error {
 		var err error
-		if c.CheckGroupConcern(groupCode, id, ctype) != ErrAlreadyExists {
-			return buntdb.ErrNotFound
+		if c.CheckGroupConcern(target, id, ctype) != ErrAlreadyExists {
+			return localdb.ErrNotFound
 		}
-		groupStateKey := c.GroupConcernStateKey(groupCode, id)
+		groupStateKey := c.GroupConcernStateKey(target, id)
 		newCtype, err = c.removeConcernType(groupStateKey, ctype)
 		return err
 	})
@@ -243,21 +253,30 @@ func (c *StateManager) RemoveGroupConcern(groupCode int64, id interface{}, ctype
 	return
 }
 
-// RemoveAllByGroupCode 删除一个group内所有订阅
-func (c *StateManager) RemoveAllByGroupCode(groupCode int64) (keys []string, err error) {
+// RemoveAllByGroupCode 删除一个推送目标的所有订阅
+func (c *StateManager) RemoveAllByGroupCode(target mmsg.TargetId) (keys []string, err error) {
 	var indexKey = []string{
 		c.GroupConcernStateKey(),
 		c.GroupConcernConfigKey(),
 	}
 	var prefixKey = []string{
-		c.GroupConcernStateKey(groupCode),
-		c.GroupConcernConfigKey(groupCode),
+		c.GroupConcernStateKey(target),
+		c.GroupConcernConfigKey(target),
 	}
 	return localdb.RemoveByPrefixAndIndex(prefixKey, indexKey)
 }
 
+// GroupKeyPrefix 返回推送目标的订阅，配置，@全体成员标记的key前缀
+func (c *StateManager) GroupKeyPrefix(target mmsg.TargetId) []string {
+	return []string{
+		c.GroupConcernStateKey(target),
+		c.GroupConcernConfigKey(target),
+		c.GroupAtAllMarkKey(target),
+	}
+}
+
 func (c *StateManager) RemoveAllById(_id interface{}) (err error) {
-	return c.RWCoverTx(func(tx *buntdb.Tx) error {
+	return c.RWCoverTx(func(tx localdb.Tx) error {
 		var removeKey []string
 		var iterErr error
 		iterErr = tx.Ascend(c.GroupConcernStateKey(), func(key, value string) bool {
@@ -274,6 +293,7 @@ func (c *StateManager) RemoveAllById(_id interface{}) (err error) {
 		for _, key := range removeKey {
 			tx.Delete(key)
 		}
+		tx.Delete(localdb.ConcernFreshErrorKey(c.name, _id))
 		if c.useEmit {
 			c.emitQueue.Delete(_id)
 		}
@@ -282,8 +302,8 @@ func (c *StateManager) RemoveAllById(_id interface{}) (err error) {
 }
 
 // GetGroupConcern 返回一个id在群内的所有 concern_type.Type
-func (c *StateManager) GetGroupConcern(groupCode int64, id interface{}) (result concern_type.Type, err error) {
-	val, err := c.Get(c.GroupConcernStateKey(groupCode, id))
+func (c *StateManager) GetGroupConcern(target mmsg.TargetId, id interface{}) (result concern_type.Type, err error) {
+	val, err := c.Get(c.GroupConcernStateKey(target, id))
 	if err != nil {
 		return
 	}
@@ -294,7 +314,7 @@ func (c *StateManager) GetGroupConcern(groupCode int64, id interface{}) (result
 // GetConcern 查询一个id在所有group内的 concern_type.Type
 func (c *StateManager) GetConcern(id interface{}) (result concern_type.Type, err error) {
 	var ctypes []concern_type.Type
-	_, _, ctypes, err = c.ListConcernState(func(groupCode int64, _id interface{}, p concern_type.Type) bool {
+	_, _, ctypes, err = c.ListConcernState(func(target mmsg.TargetId, _id interface{}, p concern_type.Type) bool {
 		return id == _id
 	})
 	result = concern_type.Empty.Add(ctypes...)
@@ -302,13 +322,13 @@ func (c *StateManager) GetConcern(id interface{}) (result concern_type.Type, err
 }
 
 // ListConcernState 遍历所有订阅，并根据 filter 返回需要的订阅
-func (c *StateManager) ListConcernState(filter func(groupCode int64, id interface{}, p concern_type.Type) bool) (groupCodes []int64, ids []interface{}, idTypes []concern_type.Type, err error) {
-	err = c.RCoverTx(func(tx *buntdb.Tx) error {
+func (c *StateManager) ListConcernState(filter func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool) (targets []mmsg.TargetId, ids []interface{}, idTypes []concern_type.Type, err error) {
+	err = c.RCoverTx(func(tx localdb.Tx) error {
 		var iterErr error
 		err := tx.Ascend(c.GroupConcernStateKey(), func(key, value string) bool {
-			var groupCode int64
+			var target mmsg.TargetId
 			var id interface{}
-			groupCode, id, iterErr = c.ParseGroupConcernStateKey(key)
+			target, id, iterErr = c.ParseGroupConcernStateKey(key)
 			if iterErr != nil {
 				return false
 			}
@@ -316,8 +336,8 @@ func (c *StateManager) ListConcernState(filter func(groupCode int64, id interfac
 			if ctype.Empty() {
 				return true
 			}
-			if filter(groupCode, id, ctype) == true {
-				groupCodes = append(groupCodes, groupCode)
+			if filter(target, id, ctype) == true {
+				targets = append(targets, target)
 				ids = append(ids, id)
 				idTypes = append(idTypes, ctype)
 			}
@@ -378,6 +398,210 @@ func (c *StateManager) checkFresh(id interface{}, setTTL bool) bool {
 	return err == nil
 }
 
+// checkFreshType 检查id的每种订阅类型是否到了刷新时间，返回需要刷新的类型，并为这些类型设置刷新标记
+// 刷新标记的有效期由 cfg.GetFreshInterval 决定，并带有随机抖动
+func (c *StateManager) checkFreshType(id interface{}, ctype concern_type.Type) concern_type.Type {
+	var result concern_type.Type
+	err := c.RWCover(func() error {
+		var jitter = cfg.GetFreshJitter()
+		for _, t := range ctype.Split() {
+			freshKey := c.FreshKey(id, t)
+			if c.Exist(freshKey) {
+				continue
+			}
+			interval := localutils.Jitter(cfg.GetFreshInterval(c.name, t.String()), jitter)
+			if err := c.Set(freshKey, "", localdb.SetExpireOpt(interval)); err != nil {
+				return err
+			}
+			result = result.Add(t)
+		}
+		return nil
+	})
+	if err != nil {
+		c.Logger().WithField("Id", id).Errorf("checkFreshType error %v", err)
+		return concern_type.Empty
+	}
+	return result
+}
+
+// freshBackoffId 退避标记使用的FreshKey，正常的id不会是这个值
+const freshBackoffId = "!backoff"
+
+// freshBackoffBase 第一次触发风控时暂停刷新的时间，之后每次翻倍
+const freshBackoffBase = time.Minute
+
+// InFreshBackoff 返回是否因为触发风控或者连续刷新失败而暂停刷新
+func (c *StateManager) InFreshBackoff() bool {
+	return c.Exist(c.FreshKey(freshBackoffId))
+}
+
+// BreakerState 返回网站当前的熔断状态
+func (c *StateManager) BreakerState() BreakerState {
+	open := c.InFreshBackoff()
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	c.breaker.sync(open)
+	return c.breaker.state
+}
+
+// AllowFresh 返回现在是否可以刷新，暂停刷新时返回false，
+// 恢复阶段按照降低后的频率返回true，适用于逐个id刷新的 EmitQueueFresher
+func (c *StateManager) AllowFresh() bool {
+	open := c.InFreshBackoff()
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	c.breaker.sync(open)
+	switch c.breaker.state {
+	case BreakerOpen:
+		return false
+	case BreakerRampUp:
+		c.breaker.skip++
+		return (c.breaker.skip-1)%(1<<c.breaker.ramp) == 0
+	default:
+		return true
+	}
+}
+
+// FreshInterval 返回恢复阶段降低频率后的刷新间隔，适用于定时刷新的 FreshFunc
+func (c *StateManager) FreshInterval(interval time.Duration) time.Duration {
+	open := c.InFreshBackoff()
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	c.breaker.sync(open)
+	if c.breaker.state == BreakerRampUp {
+		return interval << c.breaker.ramp
+	}
+	return interval
+}
+
+// FreshBackoff 根据刷新的结果更新熔断状态，返回需要暂停刷新的时间。
+// err为风控或者请求频率限制时（见 IsRateLimited ），或者连续 cfg.GetBreakerThreshold 次刷新失败时，暂停这个网站的所有刷新，
+// 暂停的时间从1分钟开始每次翻倍，最长为 cfg.GetFreshBackoffMax ，第一次暂停时会发布 TopicBreaker 事件通知管理员；
+// 暂停结束后进入恢复阶段，刷新频率先降低，每次刷新成功后逐步恢复，完全恢复后再次发布 TopicBreaker 事件。
+// err为nil时重置失败次数，其他错误只记录失败次数，这两种情况都返回0
+func (c *StateManager) FreshBackoff(err error) time.Duration {
+	open := c.InFreshBackoff()
+	c.breaker.mu.Lock()
+	c.breaker.sync(open)
+	if err == nil {
+		c.breaker.failures = 0
+		var recovered bool
+		if c.breaker.state == BreakerRampUp {
+			c.breaker.ramp--
+			if c.breaker.ramp <= 0 {
+				c.breaker.state = BreakerClosed
+				recovered = c.breaker.notified
+				c.breaker.notified = false
+			}
+		}
+		if c.breaker.state != BreakerRampUp {
+			c.breaker.level = 0
+		}
+		c.breaker.mu.Unlock()
+		if recovered {
+			c.Logger().Info("刷新已经完全恢复")
+			Publish(&BusEvent{
+				Topic:   TopicBreaker,
+				Breaker: &BreakerEvent{Site: c.name, State: BreakerClosed},
+			})
+		}
+		return 0
+	}
+	if !IsRateLimited(err) {
+		c.breaker.failures++
+		threshold := cfg.GetBreakerThreshold()
+		if threshold <= 0 || c.breaker.failures < threshold {
+			c.breaker.mu.Unlock()
+			return 0
+		}
+	}
+	c.breaker.failures = 0
+	c.breaker.level++
+	level := c.breaker.level
+	var d = freshBackoffBase
+	var maxD = cfg.GetFreshBackoffMax()
+	for i := int32(1); i < level && d < maxD; i++ {
+		d *= 2
+	}
+	if d > maxD {
+		d = maxD
+	}
+	d = localutils.Jitter(d, cfg.GetFreshJitter())
+	var notify = !c.breaker.notified
+	c.breaker.notified = true
+	c.breaker.state = BreakerOpen
+	c.breaker.mu.Unlock()
+	if setErr := c.Set(c.FreshKey(freshBackoffId), "", localdb.SetExpireOpt(d)); setErr != nil {
+		c.Logger().Errorf("FreshBackoff set backoff mark error %v", setErr)
+	}
+	c.Logger().WithField("Level", level).Warnf("触发了风控或者连续刷新失败，将暂停刷新%v - %v", d.Round(time.Second), err)
+	if notify {
+		Publish(&BusEvent{
+			Topic: TopicBreaker,
+			Breaker: &BreakerEvent{
+				Site:   c.name,
+				State:  BreakerOpen,
+				Pause:  d,
+				Reason: err.Error(),
+			},
+		})
+	}
+	return d
+}
+
+// IncFreshErrorCount 记录一次id刷新失败，返回连续失败的次数，风控或者请求频率限制（见 IsRateLimited ）不计入
+func (c *StateManager) IncFreshErrorCount(id interface{}, err error) int64 {
+	if err == nil || IsRateLimited(err) {
+		return c.GetFreshErrorCount(id)
+	}
+	result, seqErr := c.SeqNext(localdb.ConcernFreshErrorKey(c.name, id))
+	if seqErr != nil {
+		c.Logger().WithField("Id", id).Errorf("IncFreshErrorCount error %v", seqErr)
+		return 0
+	}
+	return result
+}
+
+// GetFreshErrorCount 返回id连续刷新失败的次数
+func (c *StateManager) GetFreshErrorCount(id interface{}) int64 {
+	result, err := c.GetInt64(localdb.ConcernFreshErrorKey(c.name, id), localdb.IgnoreNotFoundOpt())
+	if err != nil {
+		return 0
+	}
+	return result
+}
+
+// ClearFreshErrorCount 清除id连续刷新失败的次数，刷新成功时调用
+func (c *StateManager) ClearFreshErrorCount(id interface{}) error {
+	_, err := c.Delete(localdb.ConcernFreshErrorKey(c.name, id), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// FreshNow 清除id的刷新标记，并让id成为下一个刷新的目标，仅在使用EmitQueue时可用
+// id没有被订阅时返回 localdb.ErrNotFound
+func (c *StateManager) FreshNow(id interface{}) error {
+	if !c.useEmit {
+		return ErrEmitQueueNotInit
+	}
+	ctype, err := c.GetConcern(id)
+	if err != nil {
+		return err
+	}
+	var keys = []string{c.FreshKey(id)}
+	for _, t := range ctype.Split() {
+		keys = append(keys, c.FreshKey(id, t))
+	}
+	for _, key := range keys {
+		if _, err := c.Delete(key, localdb.IgnoreNotFoundOpt()); err != nil {
+			return err
+		}
+	}
+	if !c.emitQueue.Next(id) {
+		return localdb.ErrNotFound
+	}
+	return nil
+}
+
 // SetMaxGroupConcern 设置单个群订阅的数量上限，当设置为0或者负数表示无限制。
 func (c *StateManager) SetMaxGroupConcern(maxGroupConcern int) {
 	if maxGroupConcern < 0 {
@@ -386,26 +610,26 @@ func (c *StateManager) SetMaxGroupConcern(maxGroupConcern int) {
 	c.maxGroupConcern = maxGroupConcern
 }
 
-// FreshIndex 刷新 group 的 index，通常不需要用户主动调用
-// 在单元测试中有时候需要主动刷新 index，否则遍历时会返回 buntdb.ErrNotFound
-func (c *StateManager) FreshIndex(groups ...int64) {
+// FreshIndex 刷新推送目标的 index，没有指定时刷新所有群以及所有已有订阅的推送目标，通常不需要用户主动调用
+// 在单元测试中有时候需要主动刷新 index，否则遍历时会返回 localdb.ErrNotFound
+func (c *StateManager) FreshIndex(targets ...mmsg.TargetId) {
 	for _, pattern := range []localdb.KeyPatternFunc{
 		c.GroupConcernStateKey, c.GroupConcernConfigKey,
 	} {
 		c.CreatePatternIndex(pattern, nil)
 	}
-	var groupSet = make(map[int64]interface{})
-	if len(groups) == 0 {
+	var groupSet = make(map[mmsg.TargetId]interface{})
+	if len(targets) == 0 {
 		for _, groupInfo := range localutils.GetBot().GetGroupList() {
-			groupSet[groupInfo.Code] = struct{}{}
+			groupSet[mmsg.NewGroupTargetId(groupInfo.Code)] = struct{}{}
 		}
 	} else {
-		for _, g := range groups {
+		for _, g := range targets {
 			groupSet[g] = struct{}{}
 		}
 	}
-	c.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
-		groupSet[groupCode] = struct{}{}
+	c.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+		groupSet[target] = struct{}{}
 		return true
 	})
 	for g := range groupSet {
@@ -437,7 +661,7 @@ func (c *StateManager) removeConcernType(key string, ctype concern_type.Type) (n
 		}
 		oldCtype := concern_type.FromString(val)
 		if !oldCtype.ContainAll(ctype) {
-			return buntdb.ErrNotFound
+			return localdb.ErrNotFound
 		}
 		newCtype = oldCtype.Remove(ctype)
 		if newCtype.Empty() {
@@ -466,8 +690,14 @@ func (c *StateManager) Stop() {
 }
 
 // Start 启动 StateManager，别忘记在 Concern.Start 中启动
-// 启动前需要指定 FreshFunc 与 NotifyGeneratorFunc，否则会panic
+// 启动前需要指定 FreshFunc 与 NotifyGeneratorFunc，否则会panic，
+// Stop 之后可以再次 Start ，使用 EmitQueue 时需要在 Start 前重新调用 UseEmitQueue
 func (c *StateManager) Start() error {
+	if c.ctx.Err() != nil {
+		// Stop 时会关闭eventChan并取消ctx，重新启动时需要重新创建
+		c.ctx, c.cancelCtx = context.WithCancel(context.Background())
+		c.eventChan = make(chan Event, 4)
+	}
 	if c.freshFunc == nil {
 		panic(fmt.Sprintf("StateManager %v: freshFunc not set", c.name))
 	}
@@ -488,7 +718,7 @@ func (c *StateManager) Start() error {
 	}
 	if c.useEmit {
 		c.emitQueue.Start()
-		_, ids, ctypes, err := c.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+		_, ids, ctypes, err := c.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 			return true
 		})
 		if err != nil {
@@ -519,24 +749,34 @@ func (c *StateManager) EmitQueueFresher(doFresh func(p concern_type.Type, id int
 					return
 				}
 				id := emitItem.Id
-				if ok := c.checkFresh(id, true); !ok {
+				if !c.AllowFresh() {
+					c.Logger().WithField("Id", id).Trace("fresh skipped by breaker")
+					continue
+				}
+				ctype := c.checkFreshType(id, emitItem.Type)
+				if ctype.Empty() {
 					c.Logger().WithFields(logrus.Fields{
-						"Id":     id,
-						"Type":   emitItem.Type.String(),
-						"Result": ok,
+						"Id":   id,
+						"Type": emitItem.Type.String(),
 					}).Trace("fresh check failed")
 					continue
 				}
 				c.Logger().WithField("id", id).Trace("fresh")
-				if events, err := doFresh(emitItem.Type, id); err == nil {
+				start := time.Now()
+				events, err := doFresh(ctype, id)
+				metrics.ObserveFresh(c.name, start, err)
+				c.FreshBackoff(err)
+				if err == nil {
+					c.ClearFreshErrorCount(id)
 					for _, event := range events {
 						c.eventChan <- event
 					}
 				} else {
 					c.Logger().WithFields(logrus.Fields{
-						"Id":   id,
-						"Type": emitItem.Type.String(),
-						"Name": c.name,
+						"Id":         id,
+						"Type":       emitItem.Type.String(),
+						"Name":       c.name,
+						"ErrorCount": c.IncFreshErrorCount(id, err),
 					}).Errorf("doFresh error %v", err)
 				}
 			case <-ctx.Done():
@@ -572,8 +812,8 @@ func (c *StateManager) Dispatch(wg *sync.WaitGroup, eventChan <-chan Event, noti
 	c.dispatchFunc(eventChan, notifyChan)
 }
 
-func (c *StateManager) NotifyGenerator(groupCode int64, event Event) []Notify {
-	return c.notifyGeneratorFunc(groupCode, event)
+func (c *StateManager) NotifyGenerator(target mmsg.TargetId, event Event) []Notify {
+	return c.notifyGeneratorFunc(target, event)
 }
 
 func (c *StateManager) filterNotify(inotify Notify) bool {
@@ -586,7 +826,7 @@ func (c *StateManager) filterNotify(inotify Notify) bool {
 		nLogger.Errorf("filterNotify: GetConcernBySiteAndType error %v", err)
 		return true
 	}
-	concernConfig := concern.GetStateManager().GetGroupConcernConfig(inotify.GetGroupCode(), inotify.GetUid())
+	concernConfig := concern.GetStateManager().GetGroupConcernConfig(inotify.GetTarget(), inotify.GetUid())
 
 	sendHookResult := concernConfig.ShouldSendHook(inotify)
 	if !sendHookResult.Pass {
@@ -603,12 +843,12 @@ func (c *StateManager) filterNotify(inotify Notify) bool {
 }
 
 // DefaultDispatch 是 DispatchFunc 的默认实现。
-// 它查询所有订阅过此 Event.GetUid 与 Event.Type 的群，并为每个群生成 Notify 发送给框架
+// 它查询所有订阅过此 Event.GetUid 与 Event.Type 的推送目标，并为每个推送目标生成 Notify 发送给框架
 func (c *StateManager) DefaultDispatch() DispatchFunc {
 	return func(eventChan <-chan Event, notifyChan chan<- Notify) {
 		for event := range eventChan {
 			log := event.Logger()
-			groups, _, _, err := c.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+			targets, _, _, err := c.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 				return event.GetUid() == id && p.ContainAll(event.Type())
 			})
 			if err != nil {
@@ -616,15 +856,16 @@ func (c *StateManager) DefaultDispatch() DispatchFunc {
 				continue
 			}
 			var notifies []Notify
-			var filteredGroups = make(map[int64]interface{})
-			for _, groupCode := range groups {
-				for _, n := range c.NotifyGenerator(groupCode, event) {
+			var filteredGroups = make(map[mmsg.TargetId]interface{})
+			for _, target := range targets {
+				for _, n := range c.NotifyGenerator(target, event) {
 					if c.filterNotify(n) {
 						notifies = append(notifies, n)
-						filteredGroups[n.GetGroupCode()] = true
+						filteredGroups[n.GetTarget()] = true
 					}
 				}
 			}
+			publishEvent(event, targets, notifies)
 			if len(notifies) == 0 {
 				continue
 			}
@@ -679,6 +920,7 @@ func (c *StateManager) UseEmitQueue() {
 	}
 	c.emitChan = make(chan *localutils.EmitE)
 	c.emitQueue = localutils.NewEmitQueue(c.emitChan, interval)
+	c.emitQueue.Jitter = cfg.GetFreshJitter()
 }
 
 // EmitQueueEnabled 返回是否使用了EmitQueue
@@ -704,6 +946,9 @@ func NewStateManagerWithCustomKey(name string, keySet KeySet, notifyChan chan<-
 		cancelCtx:  cancel,
 		logger:     logger.WithFields(logrus.Fields{"Name": name}),
 	}
+	localdb.RegisterJsonType(keySet.GroupConcernConfigKey, func() interface{} {
+		return new(GroupConcernConfig)
+	})
 	return sm
 }
 
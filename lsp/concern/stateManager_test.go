@@ -3,14 +3,17 @@ package concern
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"go.uber.org/atomic"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -39,17 +42,17 @@ func (t *testKeySet) GroupAtAllMarkKey(keys ...interface{}) string {
 	return localdb.NamedKey("test4", keys)
 }
 
-func (t *testKeySet) ParseGroupConcernStateKey(key string) (groupCode int64, id interface{}, err error) {
-	return localdb.ParseConcernStateKeyWithInt64(key)
+func (t *testKeySet) ParseGroupConcernStateKey(key string) (target mmsg.TargetId, id interface{}, err error) {
+	return ParseConcernStateKeyWithInt64(key)
 }
 
 type testEvent struct {
-	id        int64
-	groupCode int64
+	id     int64
+	target mmsg.TargetId
 }
 
-func (t *testEvent) GetGroupCode() int64 {
-	return t.groupCode
+func (t *testEvent) GetTarget() mmsg.TargetId {
+	return t.target
 }
 
 func (t *testEvent) ToMessage() *mmsg.MSG {
@@ -75,7 +78,7 @@ func (t *testEvent) Logger() *logrus.Entry {
 func newStateManager(t *testing.T) *StateManager {
 	sm := NewStateManagerWithCustomKey("test", &testKeySet{}, nil)
 	assert.NotNil(t, sm)
-	sm.FreshIndex(test.G1, test.G2)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
 	return sm
 }
 
@@ -115,12 +118,12 @@ func TestNewStateManager(t *testing.T) {
 	assert.Panics(t, func() {
 		sm.Start()
 	})
-	sm.UseNotifyGeneratorFunc(func(groupCode int64, event Event) []Notify {
+	sm.UseNotifyGeneratorFunc(func(target mmsg.TargetId, event Event) []Notify {
 		return nil
 	})
 	sm.UseEmitQueue()
 
-	_, err := sm.AddGroupConcern(test.G1, test.UID1, "test")
+	_, err := sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, "test")
 	assert.Nil(t, err)
 	sm.Start()
 	defer sm.Stop()
@@ -138,8 +141,8 @@ func TestNewStateManager(t *testing.T) {
 	case <-time.After(time.Second * 2):
 	}
 
-	err = localdb.RWCoverTx(func(tx *buntdb.Tx) error {
-		_, err := tx.Delete(sm.FreshKey(test.UID1))
+	err = localdb.RWCoverTx(func(tx localdb.Tx) error {
+		_, err := tx.Delete(sm.FreshKey(test.UID1, "test"))
 		return err
 	})
 	assert.Nil(t, err)
@@ -169,7 +172,7 @@ func TestNewStateManager2(t *testing.T) {
 			panic("error")
 		}
 	})
-	sm.UseNotifyGeneratorFunc(func(groupCode int64, event Event) []Notify {
+	sm.UseNotifyGeneratorFunc(func(target mmsg.TargetId, event Event) []Notify {
 		return nil
 	})
 	assert.Nil(t, sm.Start())
@@ -186,8 +189,8 @@ func TestStateManagerNotify(t *testing.T) {
 	testEventChan := make(chan Event, 16)
 	testNotifyChan := make(chan Notify, 16)
 	sm.notifyChan = testNotifyChan
-	sm.UseNotifyGeneratorFunc(func(groupCode int64, event Event) []Notify {
-		event.(*testEvent).groupCode = groupCode
+	sm.UseNotifyGeneratorFunc(func(target mmsg.TargetId, event Event) []Notify {
+		event.(*testEvent).target = target
 		return []Notify{
 			event.(*testEvent),
 		}
@@ -206,9 +209,9 @@ func TestStateManagerNotify(t *testing.T) {
 	})
 	sm.Start()
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, testType)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, testType)
 	assert.Nil(t, err)
-	_, err = sm.AddGroupConcern(test.G2, test.UID1, testType)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, testType)
 	assert.Nil(t, err)
 	testEventChan <- &testEvent{
 		id: test.UID2,
@@ -220,16 +223,31 @@ func TestStateManagerNotify(t *testing.T) {
 	case <-time.After(time.Second):
 	}
 
+	var busEvents = make(chan *BusEvent, 4)
+	unsubscribe := Subscribe("test", func(e *BusEvent) {
+		busEvents <- e
+	}, TopicNewDynamic)
+	defer unsubscribe()
+
 	testEventChan <- &testEvent{
 		id: test.UID1,
 	}
 
+	select {
+	case e := <-busEvents:
+		assert.ElementsMatch(t, []mmsg.TargetId{mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2)}, e.Targets)
+		assert.Len(t, e.Notifies, 2)
+		assert.EqualValues(t, test.UID1, e.Event.GetUid())
+	case <-time.After(time.Second):
+		assert.Fail(t, "no bus event received")
+	}
+
 	for i := 0; i < 2; i++ {
 		select {
 		case notify := <-testNotifyChan:
 			assert.NotNil(t, notify)
 			assert.EqualValues(t, test.UID1, notify.GetUid())
-			assert.True(t, notify.GetGroupCode() == test.G1 || notify.GetGroupCode() == test.G2)
+			assert.True(t, notify.GetTarget() == mmsg.NewGroupTargetId(test.G1) || notify.GetTarget() == mmsg.NewGroupTargetId(test.G2))
 		case <-time.After(time.Second):
 			assert.Fail(t, "no item received")
 		}
@@ -237,10 +255,49 @@ func TestStateManagerNotify(t *testing.T) {
 
 }
 
+func TestStateManager_Restart(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	testNotifyChan := make(chan Notify, 16)
+	sm.notifyChan = testNotifyChan
+	sm.UseNotifyGeneratorFunc(func(target mmsg.TargetId, event Event) []Notify {
+		event.(*testEvent).target = target
+		return []Notify{event.(*testEvent)}
+	})
+	var fresh = make(chan struct{}, 1)
+	sm.UseFreshFunc(func(ctx context.Context, eventChan chan<- Event) {
+		for {
+			select {
+			case <-fresh:
+				eventChan <- &testEvent{id: test.UID1}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	_, err := sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, testType)
+	assert.Nil(t, err)
+
+	// Stop 之后可以再次 Start ，重新启动后仍然可以正常刷新与推送
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, sm.Start())
+		fresh <- struct{}{}
+		select {
+		case notify := <-testNotifyChan:
+			assert.EqualValues(t, test.UID1, notify.GetUid())
+		case <-time.After(time.Second):
+			assert.Fail(t, "no item received")
+		}
+		sm.Stop()
+	}
+}
+
 func TestStateManager_GroupConcernConfig(t *testing.T) {
 	sm := newStateManager(t)
 
-	c := sm.GetGroupConcernConfig(test.G1, test.UID1)
+	c := sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
 	assert.NotNil(t, c)
 
 	test.InitBuntdb(t)
@@ -248,14 +305,14 @@ func TestStateManager_GroupConcernConfig(t *testing.T) {
 
 	sm = newStateManager(t)
 
-	c = sm.GetGroupConcernConfig(test.G1, test.UID1)
+	c = sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
 	assert.NotNil(t, c)
 
 	assert.Nil(t, c.GetGroupConcernAt().AtSomeone)
 	assert.EqualValues(t, c, new(GroupConcernConfig))
 
-	cfg := sm.GetGroupConcernConfig(test.G1, test.UID1)
-	err := sm.OperateGroupConcernConfig(test.G1, test.UID1, cfg, func(concernConfig IConfig) bool {
+	cfg := sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
+	err := sm.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1, cfg, func(concernConfig IConfig) bool {
 		concernConfig.GetGroupConcernNotify().TitleChangeNotify = test.BibiliLive
 		concernConfig.GetGroupConcernAt().AtSomeone = []*AtSomeone{
 			{
@@ -268,7 +325,7 @@ func TestStateManager_GroupConcernConfig(t *testing.T) {
 	})
 	assert.Nil(t, err)
 
-	c = sm.GetGroupConcernConfig(test.G1, test.UID1)
+	c = sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
 	assert.NotNil(t, c)
 	assert.NotNil(t, c.GetGroupConcernFilter())
 	assert.EqualValues(t, c.GetGroupConcernNotify().TitleChangeNotify, test.BibiliLive)
@@ -280,19 +337,19 @@ func TestStateManager_GroupConcernConfig(t *testing.T) {
 		},
 	})
 
-	cfg = sm.GetGroupConcernConfig(test.G1, test.UID1)
-	err = sm.OperateGroupConcernConfig(test.G1, test.UID1, cfg, func(concernConfig IConfig) bool {
+	cfg = sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
+	err = sm.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1, cfg, func(concernConfig IConfig) bool {
 		concernConfig.GetGroupConcernNotify().TitleChangeNotify = concern_type.Empty
 		return false
 	})
 	assert.EqualValues(t, localdb.ErrRollback, err)
 
-	c = sm.GetGroupConcernConfig(test.G1, test.UID1)
+	c = sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
 	assert.NotNil(t, c)
 	assert.EqualValues(t, c.GetGroupConcernNotify().TitleChangeNotify, test.BibiliLive)
 
-	cfg = sm.GetGroupConcernConfig(test.G1, test.UID1)
-	err = sm.OperateGroupConcernConfig(test.G1, test.UID1, cfg, func(concernConfig IConfig) bool {
+	cfg = sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1)
+	err = sm.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1, cfg, func(concernConfig IConfig) bool {
 		concernConfig.GetGroupConcernFilter().Type = FilterTypeType
 		concernConfig.GetGroupConcernFilter().Config = (&GroupConcernFilterConfigByType{Type: []string{"q", "w", "e"}}).ToString()
 		return true
@@ -306,8 +363,8 @@ func TestStateManager_CheckAndSetAtAllMark(t *testing.T) {
 
 	sm := newStateManager(t)
 
-	assert.True(t, sm.CheckAndSetAtAllMark(test.G1, test.UID1))
-	assert.False(t, sm.CheckAndSetAtAllMark(test.G1, test.UID1))
+	assert.True(t, sm.CheckAndSetAtAllMark(mmsg.NewGroupTargetId(test.G1), test.UID1))
+	assert.False(t, sm.CheckAndSetAtAllMark(mmsg.NewGroupTargetId(test.G1), test.UID1))
 }
 
 func TestStateManager_FreshCheck(t *testing.T) {
@@ -326,6 +383,142 @@ func TestStateManager_FreshCheck(t *testing.T) {
 	assert.False(t, result)
 }
 
+func TestStateManager_FreshCheckType(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+
+	ctype := test.BibiliLive.Add(test.BilibiliNews)
+	assert.EqualValues(t, ctype, sm.checkFreshType(test.UID1, ctype))
+	assert.True(t, sm.checkFreshType(test.UID1, ctype).Empty())
+
+	_, err := sm.Delete(sm.FreshKey(test.UID1, test.BilibiliNews))
+	assert.Nil(t, err)
+	assert.EqualValues(t, test.BilibiliNews, sm.checkFreshType(test.UID1, ctype))
+	assert.EqualValues(t, test.BibiliLive, sm.checkFreshType(test.UID2, test.BibiliLive))
+}
+
+func TestStateManager_FreshBackoff(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.False(t, sm.InFreshBackoff())
+
+	assert.Zero(t, sm.FreshBackoff(nil))
+	assert.Zero(t, sm.FreshBackoff(errors.New("error")))
+	assert.False(t, sm.InFreshBackoff())
+
+	d1 := sm.FreshBackoff(fmt.Errorf("code -412: %w", ErrRateLimited))
+	assert.True(t, d1 > 0)
+	assert.True(t, sm.InFreshBackoff())
+	d2 := sm.FreshBackoff(&requests.HttpCodeError{Code: http.StatusTooManyRequests})
+	assert.True(t, d2 > d1)
+	for i := 0; i < 10; i++ {
+		assert.True(t, sm.FreshBackoff(ErrRateLimited) <= time.Minute*36)
+	}
+
+	assert.Zero(t, sm.FreshBackoff(nil))
+	assert.True(t, sm.FreshBackoff(ErrRateLimited) <= time.Minute*2)
+}
+
+func TestStateManager_Breaker(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	config.GlobalConfig.Set("concern.breakerThreshold", 3)
+	defer config.GlobalConfig.Set("concern.breakerThreshold", nil)
+
+	var events = make(chan *BusEvent, 4)
+	unsubscribe := Subscribe("breaker_test", func(e *BusEvent) {
+		events <- e
+	}, TopicBreaker)
+	defer unsubscribe()
+
+	sm := newStateManager(t)
+	assert.Equal(t, BreakerClosed, sm.BreakerState())
+	assert.True(t, sm.AllowFresh())
+	assert.Equal(t, time.Minute, sm.FreshInterval(time.Minute))
+
+	// 连续失败达到阈值时暂停刷新
+	assert.Zero(t, sm.FreshBackoff(errors.New("error")))
+	assert.Zero(t, sm.FreshBackoff(errors.New("error")))
+	assert.True(t, sm.FreshBackoff(errors.New("error")) > 0)
+	assert.Equal(t, BreakerOpen, sm.BreakerState())
+	assert.False(t, sm.AllowFresh())
+
+	select {
+	case e := <-events:
+		assert.Equal(t, testSite, e.Site())
+		assert.Equal(t, BreakerOpen, e.Breaker.State)
+		assert.Equal(t, "error", e.Breaker.Reason)
+	case <-time.After(time.Second):
+		assert.Fail(t, "breaker open event not published")
+	}
+
+	// 暂停结束后进入恢复阶段，刷新频率降低
+	_, err := sm.Delete(sm.FreshKey(freshBackoffId))
+	assert.Nil(t, err)
+	assert.Equal(t, BreakerRampUp, sm.BreakerState())
+	assert.Equal(t, time.Minute<<breakerRampLevel, sm.FreshInterval(time.Minute))
+	var allowed int
+	for i := 0; i < 1<<breakerRampLevel; i++ {
+		if sm.AllowFresh() {
+			allowed++
+		}
+	}
+	assert.Equal(t, 1, allowed)
+
+	// 恢复阶段再次触发风控时重新暂停，不会重复通知
+	d1 := sm.FreshBackoff(ErrRateLimited)
+	assert.True(t, d1 > 0)
+	assert.Equal(t, BreakerOpen, sm.BreakerState())
+	_, err = sm.Delete(sm.FreshKey(freshBackoffId))
+	assert.Nil(t, err)
+
+	for i := 0; i < breakerRampLevel; i++ {
+		assert.Equal(t, BreakerRampUp, sm.BreakerState())
+		assert.Zero(t, sm.FreshBackoff(nil))
+	}
+	assert.Equal(t, BreakerClosed, sm.BreakerState())
+	assert.True(t, sm.AllowFresh())
+
+	select {
+	case e := <-events:
+		assert.Equal(t, BreakerClosed, e.Breaker.State)
+	case <-time.After(time.Second):
+		assert.Fail(t, "breaker closed event not published")
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	assert.False(t, IsRateLimited(nil))
+	assert.False(t, IsRateLimited(errors.New("error")))
+	assert.False(t, IsRateLimited(&requests.HttpCodeError{Code: http.StatusNotFound}))
+	assert.True(t, IsRateLimited(&requests.HttpCodeError{Code: http.StatusPreconditionFailed}))
+	assert.True(t, IsRateLimited(fmt.Errorf("wrap %w", ErrRateLimited)))
+}
+
+func TestStateManager_FreshNow(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.Equal(t, ErrEmitQueueNotInit, sm.FreshNow(test.UID1))
+
+	sm.UseEmitQueue()
+	assert.Equal(t, localdb.ErrNotFound, sm.FreshNow(test.UID1))
+
+	_, err := sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, testType)
+	assert.Nil(t, err)
+	assert.True(t, sm.checkFresh(test.UID1, true))
+	assert.False(t, sm.checkFresh(test.UID1, false))
+
+	assert.Nil(t, sm.FreshNow(test.UID1))
+	assert.True(t, sm.checkFresh(test.UID1, false))
+}
+
 func TestStateManager_GroupConcern(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
@@ -334,31 +527,31 @@ func TestStateManager_GroupConcern(t *testing.T) {
 	sm := newStateManager(t)
 	sm.UseEmitQueue()
 
-	assert.Nil(t, sm.CheckGroupConcern(test.G1, test.UID1, test.BibiliLive))
+	assert.Nil(t, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive))
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID2, test.HuyaLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.HuyaLive)
 	assert.Nil(t, err)
-	_, err = sm.RemoveGroupConcern(test.G1, test.UID2, test.HuyaLive)
+	_, err = sm.RemoveGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.HuyaLive)
 	assert.Nil(t, err)
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.BibiliLive.Add(test.YoutubeLive))
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive.Add(test.YoutubeLive))
 	assert.Nil(t, err)
 
-	_, err = sm.RemoveGroupConcern(test.G1, test.UID1, test.BibiliLive)
+	_, err = sm.RemoveGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive)
 	assert.Nil(t, err)
-	_, err = sm.RemoveGroupConcern(test.G1, test.UID1, test.BibiliLive)
-	assert.EqualValues(t, buntdb.ErrNotFound, err)
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.BibiliLive.Add(test.YoutubeLive))
+	_, err = sm.RemoveGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive.Add(test.YoutubeLive))
 	assert.Nil(t, err)
 
-	_, err = sm.AddGroupConcern(test.G2, test.UID1, test.HuyaLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.HuyaLive)
 	assert.Nil(t, err)
-	_, err = sm.AddGroupConcern(test.G2, test.UID1, test.HuyaLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.HuyaLive)
 	assert.EqualValues(t, ErrAlreadyExists, err)
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID2, test.DouyuLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.DouyuLive)
 	assert.Nil(t, err)
-	_, err = sm.AddGroupConcern(test.G2, test.UID2, test.DouyuLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID2, test.DouyuLive)
 	assert.Nil(t, err)
 
 	ctype, err := sm.GetConcern(test.UID1)
@@ -369,26 +562,26 @@ func TestStateManager_GroupConcern(t *testing.T) {
 	// G2 UID1: hlive       , UID2  dlive
 
 	// 检查UID在G1中有 blive和ylive
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G1, test.UID1, test.BibiliLive))
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G1, test.UID1, test.YoutubeLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.YoutubeLive))
 
 	// 检查UID在G1没有 hlive和dlive
-	assert.EqualValues(t, nil, sm.CheckGroupConcern(test.G1, test.UID1, test.DouyuLive))
-	assert.EqualValues(t, nil, sm.CheckGroupConcern(test.G1, test.UID1, test.HuyaLive))
+	assert.EqualValues(t, nil, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.DouyuLive))
+	assert.EqualValues(t, nil, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.HuyaLive))
 
 	// 检查UID在G2中有hlive
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G2, test.UID1, test.HuyaLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.HuyaLive))
 
 	// 检查UID2 在G1和G2中有dlive
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G1, test.UID2, test.DouyuLive))
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G2, test.UID2, test.DouyuLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.DouyuLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID2, test.DouyuLive))
 
 	// 检查UID2 在G1中没有blive和ylive
-	assert.EqualValues(t, nil, sm.CheckGroupConcern(test.G1, test.UID2, test.BibiliLive))
-	assert.EqualValues(t, nil, sm.CheckGroupConcern(test.G1, test.UID2, test.YoutubeLive))
+	assert.EqualValues(t, nil, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.BibiliLive))
+	assert.EqualValues(t, nil, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.YoutubeLive))
 
 	// 添加已有的状态会报错
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.BibiliLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive)
 	assert.EqualValues(t, ErrAlreadyExists, err)
 
 	// 检查UID在所有G中有blive和ylive和hlive
@@ -397,7 +590,7 @@ func TestStateManager_GroupConcern(t *testing.T) {
 	assert.Nil(t, sm.CheckConcern(test.UID1, test.DouyuLive))
 
 	// 删除UID在G1中的ylive
-	_, err = sm.RemoveGroupConcern(test.G1, test.UID1, test.YoutubeLive)
+	_, err = sm.RemoveGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.YoutubeLive)
 	assert.Nil(t, err)
 
 	ctype, err = sm.GetConcern(test.UID1)
@@ -409,29 +602,29 @@ func TestStateManager_GroupConcern(t *testing.T) {
 	// 检查UID在所有G中有blive和hlive
 	assert.EqualValues(t, ErrAlreadyExists, sm.CheckConcern(test.UID1, test.BibiliLive.Add(test.HuyaLive)))
 	// 检查UID在G1中有blive
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G1, test.UID1, test.BibiliLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive))
 	// 检查UID在G2中有hlive
-	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(test.G2, test.UID1, test.HuyaLive))
+	assert.EqualValues(t, ErrAlreadyExists, sm.CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.HuyaLive))
 
 	// 列出所有有hlive的记录，应该只有UID G2
-	groups, ids, ctypes, err := sm.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+	groups, ids, ctypes, err := sm.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 		return p.ContainAny(test.HuyaLive)
 	})
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(groups))
 	assert.Equal(t, 1, len(ids))
 	assert.Equal(t, 1, len(ctypes))
-	assert.Equal(t, test.G2, groups[0])
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G2), groups[0])
 	assert.Equal(t, test.UID1, ids[0])
 	assert.Equal(t, test.HuyaLive, ctypes[0])
 
-	ctype, err = sm.GetGroupConcern(test.G2, test.UID2)
+	ctype, err = sm.GetGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID2)
 	assert.Nil(t, err)
 	assert.EqualValues(t, test.DouyuLive, ctype)
 
 	// G1中有 UID1:blive UID2:dlive
-	_, ids, ctypes, err = sm.ListConcernState(func(g int64, id interface{}, p concern_type.Type) bool {
-		return g == test.G1
+	_, ids, ctypes, err = sm.ListConcernState(func(g mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+		return g == mmsg.NewGroupTargetId(test.G1)
 	})
 	assert.Nil(t, err)
 	assert.EqualValues(t, 2, len(ids))
@@ -462,13 +655,13 @@ func TestStateManager_GroupConcern(t *testing.T) {
 	assert.Nil(t, err)
 	assert.EqualValues(t, concern_type.Empty, ctype)
 
-	_, err = sm.RemoveAllByGroupCode(test.G2)
+	_, err = sm.RemoveAllByGroupCode(mmsg.NewGroupTargetId(test.G2))
 	assert.Nil(t, err)
-	ctype, err = sm.GetGroupConcern(test.G1, test.UID2)
+	ctype, err = sm.GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2)
 	assert.Nil(t, err)
 	assert.EqualValues(t, test.DouyuLive, ctype)
-	ctype, err = sm.GetGroupConcern(test.G2, test.UID2)
-	assert.EqualValues(t, buntdb.ErrNotFound, err)
+	ctype, err = sm.GetGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID2)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
 }
 
 func TestStateManager_GroupConcern2(t *testing.T) {
@@ -478,26 +671,26 @@ func TestStateManager_GroupConcern2(t *testing.T) {
 	var err error
 	sm := newStateManager(t)
 	sm.UseEmitQueue()
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.BilibiliNews)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BilibiliNews)
 	assert.Nil(t, err)
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.BibiliLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.BibiliLive)
 	assert.Nil(t, err)
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.DouyuLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.DouyuLive)
 	assert.Nil(t, err)
 
-	_, err = sm.AddGroupConcern(test.G1, test.UID1, test.HuyaLive)
+	_, err = sm.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.HuyaLive)
 	assert.Nil(t, err)
 
-	ctype, err := sm.GetGroupConcern(test.G1, test.UID1)
+	ctype, err := sm.GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1)
 	assert.Nil(t, err)
 	assert.EqualValues(t, test.BilibiliNews.Add(test.BibiliLive, test.DouyuLive, test.HuyaLive), ctype)
 }
 
 func listIds(sm *StateManager) ([]interface{}, error) {
 	var m = make(map[interface{}]interface{})
-	_, _, _, err := sm.ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+	_, _, _, err := sm.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 		m[id] = struct{}{}
 		return true
 	})
@@ -510,3 +703,22 @@ func listIds(sm *StateManager) ([]interface{}, error) {
 	}
 	return ids, nil
 }
+
+func TestStateManager_FreshErrorCount(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.Zero(t, sm.GetFreshErrorCount(test.NAME1))
+
+	assert.EqualValues(t, 1, sm.IncFreshErrorCount(test.NAME1, errors.New("error")))
+	assert.EqualValues(t, 2, sm.IncFreshErrorCount(test.NAME1, errors.New("error")))
+	// 风控不计入
+	assert.EqualValues(t, 2, sm.IncFreshErrorCount(test.NAME1, ErrRateLimited))
+	assert.EqualValues(t, 2, sm.GetFreshErrorCount(test.NAME1))
+	assert.Zero(t, sm.GetFreshErrorCount(test.NAME2))
+
+	assert.Nil(t, sm.ClearFreshErrorCount(test.NAME1))
+	assert.Zero(t, sm.GetFreshErrorCount(test.NAME1))
+	assert.Nil(t, sm.ClearFreshErrorCount(test.NAME1))
+}
@@ -0,0 +1,119 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"strconv"
+	"time"
+)
+
+type cooldownScope struct {
+	key      string
+	duration time.Duration
+}
+
+// CheckCooldown 检查命令的冷却时间，冷却时间通过 cfg.GetCommandCooldown 配置。
+// 用户冷却对所有群和私聊生效，群冷却对群内所有用户生效，groupCode为0时只检查用户冷却。
+// 没有在冷却中时会记录本次使用并返回0，冷却中时返回剩余的时间，不会记录本次使用
+func (s *StateManager) CheckCooldown(command string, groupCode int64, uin int64) (time.Duration, error) {
+	userCooldown, groupCooldown := cfg.GetCommandCooldown(command)
+	var scopes []cooldownScope
+	if userCooldown > 0 && uin != 0 {
+		scopes = append(scopes, cooldownScope{s.CommandCooldownKey(command, "user", uin), userCooldown})
+	}
+	if groupCooldown > 0 && groupCode != 0 {
+		scopes = append(scopes, cooldownScope{s.CommandCooldownKey(command, "group", groupCode), groupCooldown})
+	}
+	if len(scopes) == 0 {
+		return 0, nil
+	}
+	var remaining time.Duration
+	var now = time.Now()
+	err := s.RWCoverTx(func(tx localdb.Tx) error {
+		for _, scope := range scopes {
+			val, err := tx.Get(scope.key)
+			if err == localdb.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// 值为冷却结束的时间
+			expire, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				continue
+			}
+			if r := time.UnixMilli(expire).Sub(now); r > remaining {
+				remaining = r
+			}
+		}
+		if remaining > 0 {
+			return nil
+		}
+		for _, scope := range scopes {
+			_, _, err := tx.Set(scope.key, strconv.FormatInt(now.Add(scope.duration).UnixMilli(), 10),
+				localdb.ExpireOption(scope.duration))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return remaining, nil
+}
+
+// formatCooldown 把剩余时间格式化为 X分Y秒 ，不足一秒按一秒计算
+func formatCooldown(d time.Duration) string {
+	var seconds = int64((d + time.Second - 1) / time.Second)
+	if seconds >= 60 {
+		if seconds%60 == 0 {
+			return fmt.Sprintf("%v分钟", seconds/60)
+		}
+		return fmt.Sprintf("%v分%v秒", seconds/60, seconds%60)
+	}
+	return fmt.Sprintf("%v秒", seconds)
+}
+
+// requireCooldown 检查命令是否在冷却中，冷却中时回复剩余时间并返回false，BOT管理员不受冷却限制
+func (lgc *LspGroupCommand) requireCooldown(command string) bool {
+	if lgc.l.PermissionStateManager.CheckAdmin(lgc.uin()) {
+		return true
+	}
+	log := lgc.DefaultLoggerWithCommand(command)
+	remaining, err := lgc.l.LspStateManager.CheckCooldown(command, lgc.groupCode(), lgc.uin())
+	if err != nil {
+		log.Errorf("CheckCooldown error %v", err)
+		return true
+	}
+	if remaining > 0 {
+		log.WithField("remaining", remaining).Debug("command cooldown")
+		if !lgc.l.PermissionStateManager.CheckGroupSilence(lgc.groupCode()) {
+			lgc.textReply(fmt.Sprintf("命令冷却中，请%v后再试", formatCooldown(remaining)))
+		}
+		return false
+	}
+	return true
+}
+
+// requireCooldown 检查命令是否在冷却中，私聊只检查用户冷却
+func (c *LspPrivateCommand) requireCooldown(command string) bool {
+	if c.l.PermissionStateManager.CheckAdmin(c.uin()) {
+		return true
+	}
+	log := c.DefaultLoggerWithCommand(command)
+	remaining, err := c.l.LspStateManager.CheckCooldown(command, 0, c.uin())
+	if err != nil {
+		log.Errorf("CheckCooldown error %v", err)
+		return true
+	}
+	if remaining > 0 {
+		log.WithField("remaining", remaining).Debug("command cooldown")
+		c.textSend(fmt.Sprintf("命令冷却中，请%v后再试", formatCooldown(remaining)))
+		return false
+	}
+	return true
+}
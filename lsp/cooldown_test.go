@@ -0,0 +1,61 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStateManager_CheckCooldown(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := NewStateManager()
+
+	// 没有配置时不限制
+	for i := 0; i < 3; i++ {
+		remaining, err := sm.CheckCooldown(WatchCommand, test.G1, test.UID1)
+		assert.Nil(t, err)
+		assert.Zero(t, remaining)
+	}
+
+	config.GlobalConfig.Set("cooldown.watch.user", "1m")
+	config.GlobalConfig.Set("cooldown.watch.group", "10s")
+	defer func() {
+		config.GlobalConfig.Set("cooldown.watch.user", nil)
+		config.GlobalConfig.Set("cooldown.watch.group", nil)
+	}()
+
+	remaining, err := sm.CheckCooldown(WatchCommand, test.G1, test.UID1)
+	assert.Nil(t, err)
+	assert.Zero(t, remaining)
+
+	// 用户冷却对其他群也生效
+	remaining, err = sm.CheckCooldown(WatchCommand, test.G2, test.UID1)
+	assert.Nil(t, err)
+	assert.True(t, remaining > 50*time.Second && remaining <= time.Minute)
+
+	// 群冷却对群内其他用户生效
+	remaining, err = sm.CheckCooldown(WatchCommand, test.G1, test.UID2)
+	assert.Nil(t, err)
+	assert.True(t, remaining > 0 && remaining <= 10*time.Second)
+
+	// 冷却中的使用不会被记录
+	remaining, err = sm.CheckCooldown(WatchCommand, test.G2, test.UID2)
+	assert.Nil(t, err)
+	assert.Zero(t, remaining)
+
+	// 其他命令不受影响
+	remaining, err = sm.CheckCooldown(ListCommand, test.G1, test.UID1)
+	assert.Nil(t, err)
+	assert.Zero(t, remaining)
+}
+
+func TestFormatCooldown(t *testing.T) {
+	assert.Equal(t, "1秒", formatCooldown(time.Millisecond))
+	assert.Equal(t, "30秒", formatCooldown(time.Second*30))
+	assert.Equal(t, "2分钟", formatCooldown(time.Minute*2))
+	assert.Equal(t, "1分30秒", formatCooldown(time.Second*90))
+}
@@ -59,6 +59,8 @@ func (l *Lsp) CronjobReload() {
 				Errorf("添加定时任务失败：%v", err)
 		}
 	}
+	l.backupReload()
+	l.reminderReload()
 }
 
 func (l *Lsp) CronStart() {
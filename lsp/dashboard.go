@@ -0,0 +1,171 @@
+package lsp
+
+import (
+	_ "embed"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dashboardErrorSize 网页面板中保留的最近错误日志条数
+const dashboardErrorSize = 50
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+type dashboardError struct {
+	Time    int64  `json:"time"`
+	Module  string `json:"module"`
+	Message string `json:"message"`
+}
+
+// errorCollector 是一个logrus的hook，保存最近的错误日志用于在网页面板中展示
+type errorCollector struct {
+	mu     sync.Mutex
+	size   int
+	errors []*dashboardError
+}
+
+func newErrorCollector(size int) *errorCollector {
+	return &errorCollector{size: size}
+}
+
+func (c *errorCollector) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+func (c *errorCollector) Fire(entry *logrus.Entry) error {
+	var module string
+	if m, ok := entry.Data["module"]; ok {
+		module, _ = m.(string)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, &dashboardError{
+		Time:    entry.Time.Unix(),
+		Module:  module,
+		Message: entry.Message,
+	})
+	if len(c.errors) > c.size {
+		c.errors = c.errors[len(c.errors)-c.size:]
+	}
+	return nil
+}
+
+// List 返回最近的错误日志，按时间从新到旧排序
+func (c *errorCollector) List() []*dashboardError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var result = make([]*dashboardError, 0, len(c.errors))
+	for i := len(c.errors) - 1; i >= 0; i-- {
+		result = append(result, c.errors[i])
+	}
+	return result
+}
+
+var (
+	recentErrors     = newErrorCollector(dashboardErrorSize)
+	recentErrorsOnce sync.Once
+)
+
+// registerErrorCollector 开始收集错误日志，只会注册一次
+func registerErrorCollector() {
+	recentErrorsOnce.Do(func() {
+		logrus.AddHook(recentErrors)
+	})
+}
+
+type dashboardConcern struct {
+	Site     string         `json:"site"`
+	Id       interface{}    `json:"id"`
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	LastPush int64          `json:"last_push"`
+	State    *concern.State `json:"state"`
+}
+
+type dashboardGroup struct {
+	GroupCode int64 `json:"group_code,omitempty"`
+	// Target 推送目标，格式与 mmsg.TargetId.Key 相同
+	Target    string              `json:"target"`
+	GroupName string              `json:"group_name"`
+	Concerns  []*dashboardConcern `json:"concerns"`
+
+	target mmsg.TargetId
+}
+
+type dashboardResponse struct {
+	Time   int64             `json:"time"`
+	Groups []*dashboardGroup `json:"groups"`
+	Errors []*dashboardError `json:"errors"`
+}
+
+func (a *AdminApi) handleDashboardPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(dashboardPage)
+}
+
+// handleDashboard 返回网页面板需要的所有数据，按推送目标分组
+func (a *AdminApi) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	var groups = make(map[mmsg.TargetId]*dashboardGroup)
+	for _, cm := range concern.ListConcern() {
+		stateExt, _ := cm.(concern.StateExt)
+		targets, ids, ctypes, err := cm.GetStateManager().ListConcernState(
+			func(mmsg.TargetId, interface{}, concern_type.Type) bool { return true })
+		if err != nil {
+			a.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		// 同一个id在多个群中订阅时只查询一次状态
+		var states = make(map[interface{}]*concern.State)
+		for index, id := range ids {
+			target := targets[index]
+			g, found := groups[target]
+			if !found {
+				g = &dashboardGroup{GroupCode: target.GroupCode(), Target: target.Key(), target: target}
+				if !target.TargetType().IsGroup() {
+					g.GroupName = target.Name()
+				} else if info := localutils.GetBot().FindGroup(target.TargetCode()); info != nil {
+					g.GroupName = info.Name
+				}
+				groups[target] = g
+			}
+			state, found := states[id]
+			if !found && stateExt != nil {
+				state = stateExt.GetState(id)
+				states[id] = state
+			}
+			c := a.newConcern(cm, target, id, ctypes[index])
+			g.Concerns = append(g.Concerns, &dashboardConcern{
+				Site:     c.Site,
+				Id:       c.Id,
+				Name:     c.Name,
+				Type:     c.Type,
+				LastPush: a.l.LspStateManager.GetLastPush(target, cm.Site(), id),
+				State:    state,
+			})
+		}
+	}
+	var resp = &dashboardResponse{
+		Time:   time.Now().Unix(),
+		Groups: make([]*dashboardGroup, 0, len(groups)),
+		Errors: recentErrors.List(),
+	}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, g)
+	}
+	sort.Slice(resp.Groups, func(i, j int) bool {
+		if resp.Groups[i].target.Type != resp.Groups[j].target.Type {
+			return resp.Groups[i].target.Type < resp.Groups[j].target.Type
+		}
+		return resp.Groups[i].target.Code < resp.Groups[j].target.Code
+	})
+	a.writeJson(w, http.StatusOK, resp)
+}
@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestErrorCollector(t *testing.T) {
+	c := newErrorCollector(2)
+	log := logrus.New()
+	log.AddHook(c)
+	log.Out = new(nopWriter)
+
+	log.WithField("module", "m1").Error("e1")
+	log.Info("info")
+	log.WithField("module", "m2").Error("e2")
+	log.WithError(errors.New("err")).Error("e3")
+
+	list := c.List()
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "e3", list[0].Message)
+		assert.Equal(t, "", list[0].Module)
+		assert.Equal(t, "e2", list[1].Message)
+		assert.Equal(t, "m2", list[1].Module)
+	}
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestAdminApi_Dashboard(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	localutils.GetBot().TESTAddGroup(test.G1)
+
+	h := NewAdminApi(Instance, testAdminApiToken).Handler()
+	g1 := strconv.FormatInt(test.G1, 10)
+
+	// 页面本身不需要token
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "api/dashboard")
+
+	code, _, _ := adminApiRequest(t, h, http.MethodGet, "/api/dashboard", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, obj, _ := adminApiRequest(t, h, http.MethodGet, "/api/dashboard", nil, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, obj["groups"], 0)
+
+	var watch = url.Values{"group": {g1}, "site": {test.Site1}, "id": {test.NAME1}, "type": {test.T1.String()}}
+	code, _, _ = adminApiRequest(t, h, http.MethodPost, "/api/concern", watch, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+
+	now := time.Now()
+	assert.Nil(t, Instance.LspStateManager.SetLastPush(mmsg.NewGroupTargetId(test.G1), test.Site1, test.NAME1, now))
+
+	code, obj, _ = adminApiRequest(t, h, http.MethodGet, "/api/dashboard", nil, testAdminApiToken)
+	assert.Equal(t, http.StatusOK, code)
+	groups := obj["groups"].([]interface{})
+	if assert.Len(t, groups, 1) {
+		group := groups[0].(map[string]interface{})
+		assert.EqualValues(t, test.G1, group["group_code"])
+		concerns := group["concerns"].([]interface{})
+		if assert.Len(t, concerns, 1) {
+			item := concerns[0].(map[string]interface{})
+			assert.EqualValues(t, test.Site1, item["site"])
+			assert.EqualValues(t, test.NAME1, item["id"])
+			assert.EqualValues(t, now.Unix(), item["last_push"])
+			// 测试用的订阅没有实现 concern.StateExt
+			assert.Nil(t, item["state"])
+		}
+	}
+	assert.Contains(t, obj, "errors")
+}
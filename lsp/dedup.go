@@ -0,0 +1,29 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+)
+
+// isDuplicateNotify 检查推送目标在 dedup.window 内是否已经推送过相同内容，没有推送过时会标记本次推送的内容，
+// 只对实现了 concern.NotifyDedupExt 的推送生效
+func (l *Lsp) isDuplicateNotify(inotify concern.Notify) bool {
+	var window = cfg.GetDedupWindow()
+	if window <= 0 {
+		return false
+	}
+	dedupExt, ok := inotify.(concern.NotifyDedupExt)
+	if !ok {
+		return false
+	}
+	keys := dedupExt.DedupKeys()
+	if len(keys) == 0 {
+		return false
+	}
+	duplicate, err := l.LspStateManager.MarkPushDedup(inotify.GetTarget(), inotify.Site(), keys, window)
+	if err != nil {
+		inotify.Logger().WithField("DedupKeys", keys).Errorf("MarkPushDedup error %v", err)
+		return false
+	}
+	return duplicate
+}
@@ -0,0 +1,115 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testDedupEvent struct {
+	*tc.TestEvent
+	keys []string
+}
+
+func (t *testDedupEvent) DedupKeys() []string {
+	return t.keys
+}
+
+func TestLsp_isDuplicateNotify(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	l := &Lsp{LspStateManager: newStateManager(t)}
+	testConcern := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	newEvent := func(groupCode int64, id string, keys ...string) *testDedupEvent {
+		return &testDedupEvent{testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(groupCode), id), keys}
+	}
+
+	// 默认不去重
+	assert.False(t, l.isDuplicateNotify(newEvent(test.G1, test.NAME1, "a")))
+	assert.False(t, l.isDuplicateNotify(newEvent(test.G1, test.NAME2, "a")))
+
+	config.GlobalConfig.Set("dedup.window", "1h")
+	defer config.GlobalConfig.Set("dedup", nil)
+
+	assert.False(t, l.isDuplicateNotify(newEvent(test.G1, test.NAME1, "b")))
+	assert.True(t, l.isDuplicateNotify(newEvent(test.G1, test.NAME2, "b")))
+	assert.False(t, l.isDuplicateNotify(newEvent(test.G2, test.NAME2, "b")))
+
+	// 没有实现扩展接口或者没有指纹时不去重
+	assert.False(t, l.isDuplicateNotify(testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)))
+	assert.False(t, l.isDuplicateNotify(testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)))
+	assert.False(t, l.isDuplicateNotify(newEvent(test.G1, test.NAME1)))
+	assert.False(t, l.isDuplicateNotify(newEvent(test.G1, test.NAME1)))
+}
+
+func TestLsp_pushNotifyDedup(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	Instance.pushQueue = newTestPushQueue(t, sender)
+	Instance.pushQueue.Start()
+	defer Instance.pushQueue.Stop()
+
+	testConcern := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(testConcern)
+	defer concern.ClearConcern()
+
+	config.GlobalConfig.Set("dedup.window", "1h")
+	defer config.GlobalConfig.Set("dedup", nil)
+
+	newEvent := func() *testDedupEvent {
+		return &testDedupEvent{testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1), []string{"a"}}
+	}
+
+	// 被屏蔽的推送不占用去重的时间窗口
+	assert.Nil(t, Instance.LspStateManager.AddBlocklistId(test.Site1, test.NAME1))
+	Instance.pushNotify(newEvent())
+	assert.Nil(t, Instance.LspStateManager.DeleteBlocklistId(test.Site1, test.NAME1))
+
+	Instance.pushNotify(newEvent())
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 1
+	}, time.Second, time.Millisecond*10)
+
+	Instance.pushNotify(newEvent())
+	assert.True(t, Instance.pushQueue.Drain(time.Second))
+	assert.Len(t, sender.Result(), 1)
+}
+
+func TestLsp_pushNotifyDigestDedup(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	Instance.pushQueue = newTestPushQueue(t, sender)
+	Instance.pushQueue.Start()
+	defer Instance.pushQueue.Stop()
+
+	testConcern := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(testConcern)
+	defer concern.ClearConcern()
+
+	config.GlobalConfig.Set("dedup.window", "1h")
+	defer config.GlobalConfig.Set("dedup", nil)
+	assert.Nil(t, Instance.LspStateManager.SetDigest(test.G1, &DigestConfig{Interval: 30}))
+
+	newEvent := func() *testDedupEvent {
+		return &testDedupEvent{testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1), []string{"a"}}
+	}
+
+	// 重复的推送不会被暂存到汇总推送中
+	Instance.pushNotify(newEvent())
+	Instance.pushNotify(newEvent())
+	records, err := Instance.LspStateManager.PopDigestItem(test.G1)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Empty(t, sender.Result())
+}
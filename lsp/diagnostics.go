@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/metrics"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SiteDiagnostics 一个网站的诊断信息
+type SiteDiagnostics struct {
+	Site string
+	// LastFresh 最后一次成功刷新订阅的时间，没有成功刷新过时为零值
+	LastFresh time.Time
+	// ConcernCount 订阅的账号数量，获取失败时为-1
+	ConcernCount int
+	// Extra 由 concern.DiagnoseExt 提供的额外信息
+	Extra []string
+}
+
+// Diagnostics 汇总各个模块的运行状态，用于 /status 命令自我诊断
+type Diagnostics struct {
+	Sites []*SiteDiagnostics
+	// DBFileSize 数据库文件大小，内存数据库为0，获取失败时为-1
+	DBFileSize int64
+	// DBKeys 数据库中key的总数，获取失败时为-1
+	DBKeys    int
+	DBIndexes []*localdb.IndexStat
+	// DBCache 数据库json缓存的统计信息，没有开启缓存时为nil
+	DBCache *localdb.CacheStats
+	// PushQueueLength 推送队列中等待发送的推送数量
+	PushQueueLength int
+	MemAlloc        uint64
+	MemSys          uint64
+	NumGC           uint32
+	NumGoroutine    int
+}
+
+// diagnoseSite 从 concern 及其 StateManager 收集一个网站的诊断信息
+func diagnoseSite(cm concern.Concern) *SiteDiagnostics {
+	var result = &SiteDiagnostics{
+		Site:         cm.Site(),
+		ConcernCount: -1,
+	}
+	if ts := metrics.ConcernLastFresh.Get(cm.Site()); ts > 0 {
+		result.LastFresh = time.Unix(int64(ts), 0)
+	}
+	sm := cm.GetStateManager()
+	_, ids, ctypes, err := sm.ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+		return true
+	})
+	if err == nil {
+		ids, _, err = sm.GroupTypeById(ids, ctypes)
+	}
+	if err != nil {
+		logger.WithField("site", cm.Site()).Errorf("diagnose ListConcernState error %v", err)
+	} else {
+		result.ConcernCount = len(ids)
+	}
+	if ext, ok := cm.(concern.DiagnoseExt); ok {
+		result.Extra = append(result.Extra, ext.Diagnose()...)
+	}
+	if ext, ok := sm.(concern.DiagnoseExt); ok {
+		result.Extra = append(result.Extra, ext.Diagnose()...)
+	}
+	return result
+}
+
+// Diagnose 收集当前的运行状态，获取失败的项目会记录日志并使用-1表示
+func (l *Lsp) Diagnose() *Diagnostics {
+	var d = new(Diagnostics)
+	for _, cm := range concern.ListConcern() {
+		d.Sites = append(d.Sites, diagnoseSite(cm))
+	}
+
+	var err error
+	d.DBFileSize, err = localdb.DBFileSize()
+	if err != nil {
+		logger.Errorf("diagnose DBFileSize error %v", err)
+		d.DBFileSize = -1
+	}
+	d.DBKeys, d.DBIndexes, err = localdb.KeyStats()
+	if err != nil {
+		logger.Errorf("diagnose KeyStats error %v", err)
+		d.DBKeys = -1
+	}
+	if stats, ok := localdb.GetCacheStats(); ok {
+		d.DBCache = &stats
+	}
+
+	if l.pushQueue != nil {
+		d.PushQueueLength = l.pushQueue.Len()
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	d.MemAlloc = ms.Alloc
+	d.MemSys = ms.Sys
+	d.NumGC = ms.NumGC
+	d.NumGoroutine = runtime.NumGoroutine()
+	return d
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%vB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// String 格式化为 /status 的回复内容
+func (d *Diagnostics) String() string {
+	var sb strings.Builder
+	sb.WriteString("订阅刷新：")
+	if len(d.Sites) == 0 {
+		sb.WriteString("\n没有启用任何订阅")
+	}
+	for _, site := range d.Sites {
+		sb.WriteString("\n" + site.Site + "：")
+		if site.LastFresh.IsZero() {
+			sb.WriteString("尚未成功刷新")
+		} else {
+			sb.WriteString(fmt.Sprintf("最后成功刷新 %v（%v前）",
+				site.LastFresh.Format("2006-01-02 15:04:05"),
+				time.Since(site.LastFresh).Truncate(time.Second)))
+		}
+		if site.ConcernCount >= 0 {
+			sb.WriteString(fmt.Sprintf("，订阅数：%v", site.ConcernCount))
+		}
+		for _, extra := range site.Extra {
+			sb.WriteString("\n  " + extra)
+		}
+	}
+
+	sb.WriteString("\n数据库：")
+	switch {
+	case d.DBFileSize < 0:
+		sb.WriteString("文件大小获取失败")
+	case d.DBFileSize == 0:
+		sb.WriteString("内存数据库")
+	default:
+		sb.WriteString("文件大小 " + formatBytes(d.DBFileSize))
+	}
+	if d.DBKeys < 0 {
+		sb.WriteString("，key数量获取失败")
+	} else {
+		sb.WriteString(fmt.Sprintf("，key总数：%v", d.DBKeys))
+		for _, index := range d.DBIndexes {
+			sb.WriteString(fmt.Sprintf("\n  %v：%v", index.Name, index.Count))
+		}
+	}
+	if d.DBCache != nil {
+		sb.WriteString(fmt.Sprintf("\n  缓存：%v/%v，命中率 %.1f%%（命中%v次，未命中%v次）",
+			d.DBCache.Len, d.DBCache.Capacity, d.DBCache.HitRate()*100, d.DBCache.Hit, d.DBCache.Miss))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n推送队列：%v", d.PushQueueLength))
+	sb.WriteString(fmt.Sprintf("\n内存：已分配 %v，系统占用 %v，GC次数 %v，goroutine数量 %v",
+		formatBytes(int64(d.MemAlloc)), formatBytes(int64(d.MemSys)), d.NumGC, d.NumGoroutine))
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/metrics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testDiagnoseConcern struct {
+	*tc.TestConcern
+}
+
+func (t *testDiagnoseConcern) Diagnose() []string {
+	return []string{"test diagnose"}
+}
+
+func TestLsp_Diagnose(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 1)
+	tc1 := newTestConcern(t, testEventChan, nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+	tc2 := &testDiagnoseConcern{TestConcern: newTestConcern(t, testEventChan, nil, test.Site2, []concern_type.Type{test.T2})}
+	concern.RegisterConcern(tc2)
+	defer tc2.Stop()
+
+	_, err := tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1)
+	assert.Nil(t, err)
+	_, err = tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.NAME1, test.T1)
+	assert.Nil(t, err)
+	_, err = tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME2, test.T1)
+	assert.Nil(t, err)
+	metrics.ConcernLastFresh.Set(float64(time.Now().Unix()), test.Site1)
+
+	d := Instance.Diagnose()
+	assert.Len(t, d.Sites, 2)
+	for _, site := range d.Sites {
+		switch site.Site {
+		case test.Site1:
+			assert.False(t, site.LastFresh.IsZero())
+			assert.Equal(t, 2, site.ConcernCount)
+			assert.Empty(t, site.Extra)
+		case test.Site2:
+			assert.Equal(t, 0, site.ConcernCount)
+			assert.EqualValues(t, []string{"test diagnose"}, site.Extra)
+		default:
+			assert.Fail(t, "unexpected site", site.Site)
+		}
+	}
+	assert.Zero(t, d.DBFileSize)
+	assert.Positive(t, d.DBKeys)
+	assert.NotEmpty(t, d.DBIndexes)
+	assert.Positive(t, d.NumGoroutine)
+
+	s := d.String()
+	assert.Contains(t, s, "订阅数：2")
+	assert.Contains(t, s, "test diagnose")
+	assert.Contains(t, s, "内存数据库")
+	assert.Contains(t, s, "推送队列：0")
+	assert.NotContains(t, s, "缓存")
+
+	localdb.EnableCache(10)
+	defer localdb.DisableCache()
+	d = Instance.Diagnose()
+	if assert.NotNil(t, d.DBCache) {
+		assert.Equal(t, 10, d.DBCache.Capacity)
+	}
+	assert.Contains(t, d.String(), "缓存：0/10，命中率 0.0%")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512B", formatBytes(512))
+	assert.Equal(t, "1.5KB", formatBytes(1536))
+	assert.Equal(t, "2.0MB", formatBytes(2*1024*1024))
+}
@@ -0,0 +1,320 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/message"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"runtime/debug"
+	"time"
+)
+
+// digestCheckInterval 检查汇总推送是否到达发送时间的间隔
+const digestCheckInterval = time.Minute
+
+const (
+	// minDigestInterval 汇总推送最短的间隔，单位为分钟
+	minDigestInterval = 5
+	// maxDigestInterval 汇总推送最长的间隔，单位为分钟
+	maxDigestInterval = 24 * 60
+	// digestItemExpire 暂存的推送最长保存时间，超过后不再发送
+	digestItemExpire = time.Minute*maxDigestInterval + pushQueueItemExpire
+)
+
+// DigestConfig 群汇总推送配置，开启后直播以外的推送会暂存，每隔一段时间汇总成一条推送发送
+type DigestConfig struct {
+	// Interval 汇总的间隔，单位为分钟，0表示关闭
+	Interval int `json:"interval"`
+}
+
+// digestRecord 暂存的一条推送，Time 为暂存的时间
+type digestRecord struct {
+	Time   int64           `json:"time"`
+	Record *pushItemRecord `json:"record"`
+}
+
+// GetDigest 返回群的汇总推送配置，没有配置时返回一个关闭的配置
+func (s *StateManager) GetDigest(groupCode int64) *DigestConfig {
+	var config = new(DigestConfig)
+	if err := s.GetJson(s.GroupDigestKey(groupCode), config); err != nil && !localdb.IsNotFound(err) {
+		logger.WithFields(localutils.GroupLogFields(groupCode)).Errorf("GetDigest error %v", err)
+	}
+	return config
+}
+
+// SetDigest 保存群的汇总推送配置，关闭时删除配置，已经暂存的推送会在下一次检查时发送
+func (s *StateManager) SetDigest(groupCode int64, config *DigestConfig) error {
+	if config == nil || config.Interval <= 0 {
+		_, err := s.Delete(s.GroupDigestKey(groupCode), localdb.IgnoreNotFoundOpt())
+		return err
+	}
+	return s.SetJson(s.GroupDigestKey(groupCode), config)
+}
+
+// SaveDigestItem 暂存一条推送，到达汇总时间后会和其他暂存的推送一起发送
+func (s *StateManager) SaveDigestItem(record *pushItemRecord, t time.Time) error {
+	return s.SetJson(s.DigestQueueKey(record.Target, record.Id), &digestRecord{
+		Time:   t.Unix(),
+		Record: record,
+	}, localdb.SetExpireOpt(digestItemExpire))
+}
+
+// PopDigestItem 取出并删除一个群内所有暂存的推送，按添加顺序返回
+func (s *StateManager) PopDigestItem(groupCode int64) (results []*pushItemRecord, err error) {
+	err = s.RWCoverTx(func(tx localdb.Tx) error {
+		var keys []string
+		var iterErr error
+		err := tx.AscendKeys(s.DigestQueueKey(groupCode, "*"), func(key, value string) bool {
+			var item = new(digestRecord)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			keys = append(keys, key)
+			if item.Record != nil {
+				results = append(results, item.Record)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if iterErr != nil {
+			return iterErr
+		}
+		for _, key := range keys {
+			if _, err := tx.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		results = nil
+	}
+	return
+}
+
+// ListDigestGroup 返回有暂存推送的群，以及每个群最早暂存推送的时间
+func (s *StateManager) ListDigestGroup() (groups map[int64]time.Time, err error) {
+	groups = make(map[int64]time.Time)
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(s.DigestQueueKey("*"), func(key, value string) bool {
+			groupCode, _, err := localdb.ParseConcernStateKeyWithString(key)
+			if err != nil {
+				return true
+			}
+			var item = new(digestRecord)
+			if err = json.Unmarshal([]byte(value), item); err != nil {
+				return true
+			}
+			t := time.Unix(item.Time, 0)
+			if first, found := groups[groupCode]; !found || t.Before(first) {
+				groups[groupCode] = t
+			}
+			return true
+		})
+	})
+	if err != nil {
+		groups = nil
+	}
+	return
+}
+
+const (
+	// digestChunkSize 汇总推送每条消息最多包含的推送数量
+	digestChunkSize = 10
+	// digestChunkTextLen 汇总推送每条消息最多包含的文字长度，单条推送超过时单独发送
+	digestChunkTextLen = 2000
+)
+
+// newDigestMSG 把暂存的推送合并成汇总消息，推送较多时按 digestChunkSize 和 digestChunkTextLen 拆分成多条消息，
+// 每条消息单独发送，其中一条发送失败不影响其他消息，暂存的推送中不包含@
+func newDigestMSG(records []*pushItemRecord) []*mmsg.MSG {
+	return newChunkedDigest("汇总推送，共有%v条推送", records)
+}
+
+// newChunkedDigest 把暂存的推送按顺序拆分成多条消息，每条消息以header开头，header中的%v为推送总数，拆分后在header后标注序号
+func newChunkedDigest(header string, records []*pushItemRecord) []*mmsg.MSG {
+	var chunks [][]*pushItemRecord
+	var textLen int
+	for _, record := range records {
+		l := pushRecordTextLen(record)
+		last := len(chunks) - 1
+		if last < 0 || len(chunks[last]) >= digestChunkSize || textLen+l > digestChunkTextLen {
+			chunks = append(chunks, nil)
+			last++
+			textLen = 0
+		}
+		chunks[last] = append(chunks[last], record)
+		textLen += l
+	}
+	var result []*mmsg.MSG
+	for index, chunk := range chunks {
+		var m = mmsg.NewMSG()
+		m.Textf(header, len(records))
+		if len(chunks) > 1 {
+			m.Textf("（%v/%v）", index+1, len(chunks))
+		}
+		m.Text("：")
+		appendPushRecords(m, chunk)
+		result = append(result, m)
+	}
+	return result
+}
+
+// appendPushRecords 把暂存的推送依次追加到m，每条推送另起一行，推送中的分割会被忽略
+func appendPushRecords(m *mmsg.MSG, records []*pushItemRecord) {
+	for _, record := range records {
+		m.Text("\n")
+		for _, e := range record.toPushItem().MSG.Elements() {
+			if _, ok := e.(*mmsg.CutElement); ok {
+				continue
+			}
+			m.Append(e)
+		}
+	}
+}
+
+// pushRecordTextLen 返回暂存的推送中文字的长度
+func pushRecordTextLen(record *pushItemRecord) int {
+	var l int
+	for _, e := range record.toPushItem().MSG.Elements() {
+		if text, ok := e.(*message.TextElement); ok {
+			l += len([]rune(text.Content))
+		}
+	}
+	return l
+}
+
+// digestNotify 如果群开启了汇总推送，并且这条推送不是直播推送，则暂存这条推送，返回true表示推送已被处理，
+// 汇总推送只能在QQ群内开启
+func (l *Lsp) digestNotify(inotify concern.Notify, m *mmsg.MSG) bool {
+	if liveExt, ok := inotify.(concern.NotifyLiveExt); ok && liveExt.IsLive() {
+		return false
+	}
+	target := inotify.GetTarget()
+	if !target.TargetType().IsGroup() || l.LspStateManager.GetDigest(target.TargetCode()).Interval <= 0 {
+		return false
+	}
+	var now = time.Now()
+	record := newPushItemRecord(&PushItem{
+		Id:       fmt.Sprintf("%v", now.UnixNano()),
+		Target:   target,
+		Priority: PushPriorityNormal,
+		MSG:      m,
+	})
+	if err := l.LspStateManager.SaveDigestItem(record, now); err != nil {
+		inotify.Logger().Errorf("SaveDigestItem error %v", err)
+		return false
+	}
+	inotify.Logger().Info("已开启汇总推送，推送已暂存")
+	return true
+}
+
+// PushDigest 定期检查到达汇总时间的群，把暂存的推送汇总后发送
+func (l *Lsp) PushDigest() {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).Errorf("push digest recoverd %v", err)
+			go l.PushDigest()
+		}
+	}()
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.sendDigest(time.Now())
+		}
+	}
+}
+
+// sendDigest 发送最早暂存的推送已经超过汇总间隔的群，关闭了汇总推送的群会立即发送剩余的推送，
+// 汇总后的推送同样遵守免打扰时段
+func (l *Lsp) sendDigest(now time.Time) {
+	groups, err := l.LspStateManager.ListDigestGroup()
+	if err != nil {
+		logger.Errorf("ListDigestGroup error %v", err)
+		return
+	}
+	for groupCode, first := range groups {
+		interval := l.LspStateManager.GetDigest(groupCode).Interval
+		if interval > 0 && now.Sub(first) < time.Duration(interval)*time.Minute {
+			continue
+		}
+		log := logger.WithFields(localutils.GroupLogFields(groupCode))
+		records, err := l.LspStateManager.PopDigestItem(groupCode)
+		if err != nil {
+			log.Errorf("PopDigestItem error %v", err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+		log.WithField("Size", len(records)).Info("发送汇总推送")
+		target := mmsg.NewGroupTargetId(groupCode)
+		for _, m := range newDigestMSG(records) {
+			if l.quietNotify(target, m) {
+				continue
+			}
+			l.pushQueue.Push(&PushItem{
+				Target:   target,
+				Priority: PushPriorityNormal,
+				MSG:      m,
+			})
+		}
+	}
+}
+
+// IDigestCmd 设置群的汇总推送，action为set时interval为汇总的间隔，单位为分钟
+func IDigestCmd(c *MessageContext, groupCode int64, action string, interval int) {
+	if groupCode == 0 {
+		c.TextReply("失败 - 请指定要操作的QQ群号码")
+		return
+	}
+	if !c.Lsp.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.Sender.Uin),
+		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
+	) {
+		c.NoPermissionReply()
+		return
+	}
+	config := c.Lsp.LspStateManager.GetDigest(groupCode)
+	switch action {
+	case "show":
+		if config.Interval <= 0 {
+			c.TextReply("当前没有开启汇总推送")
+		} else {
+			c.TextReply(fmt.Sprintf("当前已开启汇总推送，直播以外的推送每%v分钟汇总发送一次", config.Interval))
+		}
+		return
+	case "set":
+		if interval < minDigestInterval || interval > maxDigestInterval {
+			c.TextReply(fmt.Sprintf("失败 - 汇总间隔需要在%v到%v分钟之间", minDigestInterval, maxDigestInterval))
+			return
+		}
+		config.Interval = interval
+	case "off":
+		if config.Interval <= 0 {
+			c.TextReply("失败 - 当前没有开启汇总推送")
+			return
+		}
+		config = nil
+	default:
+		c.Log.Errorf("unknown action")
+		c.TextReply("失败 - 未知操作")
+		return
+	}
+	if err := c.Lsp.LspStateManager.SetDigest(groupCode, config); err != nil {
+		c.Log.Errorf("SetDigest error %v", err)
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	c.audit(groupCode)
+	c.TextReply("成功")
+}
@@ -0,0 +1,183 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLiveEvent struct {
+	*tc.TestEvent
+}
+
+func (t *testLiveEvent) IsLive() bool {
+	return true
+}
+
+func (t *testLiveEvent) Living() bool {
+	return true
+}
+
+func (t *testLiveEvent) TitleChanged() bool {
+	return false
+}
+
+func (t *testLiveEvent) LiveStatusChanged() bool {
+	return true
+}
+
+func TestStateManager_Digest(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.Zero(t, sm.GetDigest(test.G1).Interval)
+	assert.Nil(t, sm.SetDigest(test.G1, &DigestConfig{Interval: 30}))
+	assert.Equal(t, 30, sm.GetDigest(test.G1).Interval)
+	assert.Nil(t, sm.SetDigest(test.G1, nil))
+	assert.Zero(t, sm.GetDigest(test.G1).Interval)
+	assert.Nil(t, sm.SetDigest(test.G1, nil))
+
+	var now = time.Now()
+	assert.Nil(t, sm.SaveDigestItem(&pushItemRecord{Id: "1", Target: mmsg.NewGroupTargetId(test.G1)}, now.Add(-time.Hour)))
+	assert.Nil(t, sm.SaveDigestItem(&pushItemRecord{Id: "2", Target: mmsg.NewGroupTargetId(test.G1)}, now))
+	assert.Nil(t, sm.SaveDigestItem(&pushItemRecord{Id: "3", Target: mmsg.NewGroupTargetId(test.G2)}, now))
+	groups, err := sm.ListDigestGroup()
+	assert.Nil(t, err)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), groups[test.G1].Unix())
+	assert.Equal(t, now.Unix(), groups[test.G2].Unix())
+
+	records, err := sm.PopDigestItem(test.G1)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].Id)
+	records, err = sm.PopDigestItem(test.G1)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+	groups, err = sm.ListDigestGroup()
+	assert.Nil(t, err)
+	assert.Len(t, groups, 1)
+	assert.Contains(t, groups, test.G2)
+
+	assert.Contains(t, sm.GroupKeyPrefix(test.G1), sm.DigestQueueKey(test.G1))
+}
+
+func TestLsp_DigestNotify(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	l := &Lsp{LspStateManager: newStateManager(t)}
+	l.pushQueue = newTestPushQueue(t, sender)
+	l.pushQueue.Start()
+	defer l.pushQueue.Stop()
+
+	tc1 := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	news := tc1.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	live := &testLiveEvent{tc1.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)}
+
+	// 没有开启汇总推送
+	assert.False(t, l.digestNotify(news, mmsg.NewText("a")))
+
+	assert.Nil(t, l.LspStateManager.SetDigest(test.G1, &DigestConfig{Interval: 30}))
+	assert.False(t, l.digestNotify(live, mmsg.NewText("live")))
+	assert.True(t, l.digestNotify(news, mmsg.NewText("a")))
+	assert.True(t, l.digestNotify(news, mmsg.NewText("b").Cut().Text("c")))
+
+	// 还没有到汇总时间
+	var now = time.Now()
+	l.sendDigest(now)
+	groups, err := l.LspStateManager.ListDigestGroup()
+	assert.Nil(t, err)
+	assert.Len(t, groups, 1)
+
+	l.sendDigest(now.Add(time.Minute * 30))
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, "汇总推送，共有2条推送：\na\nbc", sender.Result()[0])
+	groups, err = l.LspStateManager.ListDigestGroup()
+	assert.Nil(t, err)
+	assert.Empty(t, groups)
+
+	// 关闭汇总推送后剩余的推送立即发送
+	assert.True(t, l.digestNotify(news, mmsg.NewText("d")))
+	assert.Nil(t, l.LspStateManager.SetDigest(test.G1, nil))
+	l.sendDigest(now)
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 2
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, "汇总推送，共有1条推送：\nd", sender.Result()[1])
+}
+
+func TestNewDigestMSG(t *testing.T) {
+	var records []*pushItemRecord
+	for i := 0; i < digestChunkSize+1; i++ {
+		records = append(records, newPushItemRecord(&PushItem{MSG: mmsg.NewText("a")}))
+	}
+	msgs := newDigestMSG(records)
+	assert.Len(t, msgs, 2)
+	target := mmsg.NewGroupTarget(test.G1)
+	assert.True(t, strings.HasPrefix(msgstringer.MsgToString(msgs[0].ToCombineMessage(target).Elements), "汇总推送，共有11条推送（1/2）："))
+	assert.Equal(t, "汇总推送，共有11条推送（2/2）：\na", msgstringer.MsgToString(msgs[1].ToCombineMessage(target).Elements))
+
+	// 文字过长的推送单独发送
+	records = []*pushItemRecord{
+		newPushItemRecord(&PushItem{MSG: mmsg.NewText("a")}),
+		newPushItemRecord(&PushItem{MSG: mmsg.NewText(strings.Repeat("b", digestChunkTextLen))}),
+		newPushItemRecord(&PushItem{MSG: mmsg.NewText("c")}),
+	}
+	msgs = newDigestMSG(records)
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, "汇总推送，共有3条推送（3/3）：\nc", msgstringer.MsgToString(msgs[2].ToCombineMessage(target).Elements))
+
+	assert.Len(t, newDigestMSG(records[:1]), 1)
+}
+
+func TestIDigestCmd(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	IDigestCmd(ctx, test.G1, "show", 0)
+	assert.Equal(t, noPermission, reply())
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	IDigestCmd(ctx, 0, "show", 0)
+	assert.Contains(t, reply(), failed)
+
+	IDigestCmd(ctx, test.G1, "show", 0)
+	assert.Contains(t, reply(), "没有开启")
+
+	IDigestCmd(ctx, test.G1, "off", 0)
+	assert.Contains(t, reply(), failed)
+
+	IDigestCmd(ctx, test.G1, "set", 1)
+	assert.Contains(t, reply(), failed)
+
+	IDigestCmd(ctx, test.G1, "set", 60)
+	assert.Equal(t, success, reply())
+	assert.Equal(t, 60, Instance.LspStateManager.GetDigest(test.G1).Interval)
+
+	IDigestCmd(ctx, test.G1, "show", 0)
+	assert.Contains(t, reply(), "每60分钟")
+
+	IDigestCmd(ctx, test.G1, "off", 0)
+	assert.Equal(t, success, reply())
+	assert.Zero(t, Instance.LspStateManager.GetDigest(test.G1).Interval)
+}
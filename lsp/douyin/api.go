@@ -0,0 +1,160 @@
+package douyin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+type UrlList struct {
+	UrlList []string `json:"url_list"`
+}
+
+func (u *UrlList) First() string {
+	if u == nil || len(u.UrlList) == 0 {
+		return ""
+	}
+	return u.UrlList[0]
+}
+
+type UserProfileResponse struct {
+	StatusCode int32  `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+	User       *struct {
+		Uid          string   `json:"uid"`
+		SecUid       string   `json:"sec_uid"`
+		Nickname     string   `json:"nickname"`
+		AvatarLarger *UrlList `json:"avatar_larger"`
+		// RoomId 不为0时表示正在直播
+		RoomId int64 `json:"room_id"`
+	} `json:"user"`
+}
+
+type AwemePostResponse struct {
+	StatusCode int32  `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+	AwemeList  []*struct {
+		AwemeId    string `json:"aweme_id"`
+		Desc       string `json:"desc"`
+		CreateTime int64  `json:"create_time"`
+		IsTop      int32  `json:"is_top"`
+		Author     struct {
+			SecUid   string `json:"sec_uid"`
+			Nickname string `json:"nickname"`
+		} `json:"author"`
+		Video struct {
+			Cover *UrlList `json:"cover"`
+		} `json:"video"`
+	} `json:"aweme_list"`
+}
+
+type RoomReflowResponse struct {
+	StatusCode int32 `json:"status_code"`
+	Data       struct {
+		Room *struct {
+			IdStr string `json:"id_str"`
+			// Status 2为正在直播，4为直播结束
+			Status int32    `json:"status"`
+			Title  string   `json:"title"`
+			Cover  *UrlList `json:"cover"`
+			Owner  struct {
+				Nickname string `json:"nickname"`
+				WebRid   string `json:"web_rid"`
+			} `json:"owner"`
+		} `json:"room"`
+	} `json:"data"`
+}
+
+const roomStatusLiving = 2
+
+// GetUserProfile 查询用户信息
+func GetUserProfile(secUid string) (*UserInfo, error) {
+	var resp = new(UserProfileResponse)
+	err := webGet(DouyinPath(PathUserProfile), url.Values{"sec_user_id": {secUid}}, resp, UserUrl(secUid))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 0 {
+		return nil, fmt.Errorf("status code %v - %v", resp.StatusCode, resp.StatusMsg)
+	}
+	if resp.User == nil || len(resp.User.SecUid) == 0 {
+		return nil, ErrUserNotExist
+	}
+	return &UserInfo{
+		SecUid:   resp.User.SecUid,
+		Uid:      resp.User.Uid,
+		Nickname: resp.User.Nickname,
+		Avatar:   resp.User.AvatarLarger.First(),
+		RoomId:   resp.User.RoomId,
+	}, nil
+}
+
+// GetAwemePost 查询用户最新发布的作品，按发布时间从新到旧排列，置顶的作品也会包含在内
+func GetAwemePost(secUid string) ([]*VideoInfo, error) {
+	var resp = new(AwemePostResponse)
+	err := webGet(DouyinPath(PathAwemePost), url.Values{
+		"sec_user_id": {secUid},
+		"count":       {"18"},
+		"max_cursor":  {"0"},
+	}, resp, UserUrl(secUid))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 0 {
+		return nil, fmt.Errorf("status code %v - %v", resp.StatusCode, resp.StatusMsg)
+	}
+	var result []*VideoInfo
+	for _, aweme := range resp.AwemeList {
+		result = append(result, &VideoInfo{
+			SecUid:     secUid,
+			Nickname:   aweme.Author.Nickname,
+			AwemeId:    aweme.AwemeId,
+			Desc:       aweme.Desc,
+			Cover:      aweme.Video.Cover.First(),
+			CreateTime: aweme.CreateTime,
+		})
+	}
+	return result, nil
+}
+
+// GetRoomReflow 查询直播间信息，这个接口不需要签名
+func GetRoomReflow(secUid string, roomId int64) (*RoomReflowResponse, error) {
+	var resp = new(RoomReflowResponse)
+	err := webGet(WebcastHost+PathRoomReflow, url.Values{
+		"type_id":     {"0"},
+		"live_id":     {"1"},
+		"app_id":      {"1128"},
+		"room_id":     {strconv.FormatInt(roomId, 10)},
+		"sec_user_id": {secUid},
+	}, resp, LiveHost)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 0 {
+		return nil, fmt.Errorf("status code %v", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// LoadLiveInfo 查询用户信息与直播状态
+func LoadLiveInfo(secUid string) (*LiveInfo, error) {
+	user, err := GetUserProfile(secUid)
+	if err != nil {
+		return nil, err
+	}
+	info := &LiveInfo{UserInfo: *user}
+	if user.RoomId == 0 {
+		return info, nil
+	}
+	reflow, err := GetRoomReflow(secUid, user.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	if room := reflow.Data.Room; room != nil {
+		info.IsLiving = room.Status == roomStatusLiving
+		info.Title = room.Title
+		info.Cover = room.Cover.First()
+		info.WebRid = room.Owner.WebRid
+	}
+	return info, nil
+}
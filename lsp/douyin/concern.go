@@ -0,0 +1,263 @@
+package douyin
+
+import (
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var logger = utils.GetModuleLogger("douyin-concern")
+
+var secUidRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{16,}$`)
+
+const (
+	Live concern_type.Type = "live"
+	News concern_type.Type = "news"
+)
+
+type Concern struct {
+	*StateManager
+}
+
+func (c *Concern) Site() string {
+	return Site
+}
+
+func (c *Concern) Types() []concern_type.Type {
+	return []concern_type.Type{Live, News}
+}
+
+// ParseId 使用用户的sec_uid作为id，也支持直接输入用户主页链接
+func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://", "http://", "www.", "douyin.com/user/"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	if idx := strings.IndexAny(s, "?#"); idx >= 0 {
+		s = s[:idx]
+	}
+	s = strings.TrimSuffix(s, "/")
+	if !secUidRegexp.MatchString(s) {
+		return nil, errors.New("无效的用户id，请使用用户主页链接中 /user/ 后面的部分")
+	}
+	return s, nil
+}
+
+func (c *Concern) GetStateManager() concern.IStateManager {
+	return c.StateManager
+}
+
+func (c *Concern) Stop() {
+	logger.Trace("正在停止douyin concern")
+	logger.Trace("正在停止douyin StateManager")
+	c.StateManager.Stop()
+	logger.Trace("douyin StateManager已停止")
+	logger.Trace("douyin concern已停止")
+}
+
+func (c *Concern) Start() error {
+	if len(cfg.GetDouyinSignServer()) == 0 {
+		logger.Warn("没有配置 douyin.signServer ，抖音接口可能无法正常访问")
+	}
+	c.UseEmitQueue()
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(c.fresh())
+	return c.StateManager.Start()
+}
+
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	var err error
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
+
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := c.FindOrLoadUser(id.(string))
+	if err != nil {
+		log.Errorf("FindOrLoadUser error %v", err)
+		return nil, fmt.Errorf("查询用户信息失败 %v - %v", id, err)
+	}
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
+
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	id := _id.(string)
+	identity, _ := c.Get(id)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
+		allCtype, err := c.GetConcern(id)
+		if err != nil {
+			return err
+		}
+		if allCtype.Empty() {
+			return c.DeleteUserInfo(id)
+		}
+		if !allCtype.ContainAny(Live) {
+			err = c.DeleteLiveInfo(id)
+		}
+		return err
+	})
+	return identity, err
+}
+
+func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
+	userInfo, err := c.GetUserInfo(id.(string))
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(userInfo.SecUid, userInfo.GetName()), nil
+}
+
+func (c *Concern) FindOrLoadUser(secUid string) (*UserInfo, error) {
+	info, _ := c.GetUserInfo(secUid)
+	if info != nil {
+		return info, nil
+	}
+	info, err := GetUserProfile(secUid)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddUserInfo(info)
+	return info, nil
+}
+
+func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
+		switch info := event.(type) {
+		case *LiveInfo:
+			if info.Living() {
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("living notify")
+			} else {
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("noliving notify")
+			}
+			return []concern.Notify{NewConcernLiveNotify(target, info)}
+		case *VideoInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("video notify")
+			return []concern.Notify{NewConcernVideoNotify(target, info)}
+		default:
+			logger.Errorf("unknown EventType %+v", event)
+			return nil
+		}
+	}
+}
+
+func (c *Concern) freshLive(secUid string) (*LiveInfo, error) {
+	oldInfo, _ := c.GetLiveInfo(secUid)
+	liveInfo, err := LoadLiveInfo(secUid)
+	if err != nil {
+		return nil, err
+	}
+	if oldInfo == nil {
+		liveInfo.liveStatusChanged = true
+	} else {
+		if oldInfo.Living() != liveInfo.Living() {
+			liveInfo.liveStatusChanged = true
+		}
+		if oldInfo.Living() && liveInfo.Living() && oldInfo.Title != liveInfo.Title {
+			liveInfo.liveTitleChanged = true
+		}
+		if !liveInfo.Living() {
+			// 下播后保留最后一次直播的信息
+			liveInfo.Title = oldInfo.Title
+			liveInfo.Cover = oldInfo.Cover
+			liveInfo.WebRid = oldInfo.WebRid
+		}
+	}
+	_ = c.AddLiveInfo(liveInfo)
+	return liveInfo, nil
+}
+
+// filterNewVideos 返回发布时间晚于lastTime的视频，按发布时间从旧到新排列
+func filterNewVideos(videos []*VideoInfo, lastTime int64) []*VideoInfo {
+	var result []*VideoInfo
+	for _, video := range videos {
+		if video.CreateTime > lastTime {
+			result = append(result, video)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreateTime < result[j].CreateTime
+	})
+	return result
+}
+
+// freshVideo 第一次刷新时只记录当前最新视频的时间，不推送
+func (c *Concern) freshVideo(secUid string) ([]*VideoInfo, error) {
+	videos, err := GetAwemePost(secUid)
+	if err != nil {
+		return nil, err
+	}
+	lastTime, err := c.GetLastVideoTime(secUid)
+	firstFresh := err == localdb.ErrNotFound
+	if err != nil && !firstFresh {
+		return nil, err
+	}
+	var result []*VideoInfo
+	for _, video := range filterNewVideos(videos, lastTime) {
+		lastTime = video.CreateTime
+		replaced, err := c.MarkVideo(video.AwemeId)
+		if err != nil || replaced || firstFresh {
+			continue
+		}
+		result = append(result, video)
+	}
+	if err = c.SetLastVideoTime(secUid, lastTime); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Concern) fresh() concern.FreshFunc {
+	return c.EmitQueueFresher(func(ctype concern_type.Type, id interface{}) ([]concern.Event, error) {
+		var result []concern.Event
+		secUid := id.(string)
+		if ctype.ContainAny(Live) {
+			liveInfo, err := c.freshLive(secUid)
+			if err == ErrUserNotExist {
+				userInfo, _ := c.GetUserInfo(secUid)
+				logger.WithFields(logrus.Fields{
+					"SecUid":   secUid,
+					"Nickname": userInfo.GetName(),
+				}).Warn("用户不存在或被封禁，订阅将失效")
+				c.RemoveAllById(id)
+				return nil, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("load liveinfo failed %v", err)
+			}
+			result = append(result, liveInfo)
+		}
+		if ctype.ContainAny(News) {
+			videos, err := c.freshVideo(secUid)
+			if err != nil {
+				return result, fmt.Errorf("load video failed %v", err)
+			}
+			for _, video := range videos {
+				result = append(result, video)
+			}
+		}
+		return result, nil
+	})
+}
+
+func NewConcern(notify chan<- concern.Notify) *Concern {
+	c := &Concern{
+		StateManager: NewStateManager(notify),
+	}
+	return c
+}
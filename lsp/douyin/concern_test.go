@@ -0,0 +1,138 @@
+package douyin
+
+import (
+	"context"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const testSecUid = "MS4wLjABAAAA_test-sec-uid"
+
+func TestConcern_ParseId(t *testing.T) {
+	c := NewConcern(nil)
+	for _, s := range []string{
+		testSecUid,
+		"https://www.douyin.com/user/" + testSecUid,
+		"https://www.douyin.com/user/" + testSecUid + "?vid=123",
+		"douyin.com/user/" + testSecUid + "/",
+	} {
+		id, err := c.ParseId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, testSecUid, id, s)
+	}
+	for _, s := range []string{"", "abc", "https://www.douyin.com/user/", "MS4wLjABAAAA/test"} {
+		_, err := c.ParseId(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestFilterNewVideos(t *testing.T) {
+	videos := []*VideoInfo{
+		{AwemeId: "top", CreateTime: 50},
+		{AwemeId: "3", CreateTime: 300},
+		{AwemeId: "2", CreateTime: 200},
+		{AwemeId: "1", CreateTime: 100},
+	}
+	result := filterNewVideos(videos, 100)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "2", result[0].AwemeId)
+	assert.Equal(t, "3", result[1].AwemeId)
+
+	assert.Empty(t, filterNewVideos(videos, 300))
+	assert.Len(t, filterNewVideos(videos, 0), 4)
+}
+
+func TestConcern(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify)
+
+	c := NewConcern(testNotifyChan)
+	assert.NotNil(t, c.GetStateManager())
+	assert.Equal(t, Site, c.Site())
+
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(func(ctx context.Context, eventChan chan<- concern.Event) {
+		for {
+			select {
+			case e := <-testEventChan:
+				if e != nil {
+					eventChan <- e
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	assert.Nil(t, c.StateManager.Start())
+	defer c.Stop()
+	defer close(testEventChan)
+
+	liveInfo := &LiveInfo{
+		UserInfo: UserInfo{
+			SecUid:   testSecUid,
+			Nickname: test.NAME1,
+		},
+		Title:    test.NAME2,
+		IsLiving: true,
+	}
+	assert.Nil(t, c.AddLiveInfo(liveInfo))
+	_, err := c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), testSecUid, Live)
+	assert.Nil(t, err)
+	_, err = c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), testSecUid, News)
+	assert.Nil(t, err)
+
+	identity, err := c.Get(testSecUid)
+	assert.Nil(t, err)
+	assert.Equal(t, testSecUid, identity.GetUid())
+	assert.Equal(t, test.NAME1, identity.GetName())
+
+	found, err := c.FindOrLoadUser(testSecUid)
+	assert.Nil(t, err)
+	assert.EqualValues(t, &liveInfo.UserInfo, found)
+
+	liveInfo.liveStatusChanged = true
+	testEventChan <- liveInfo
+
+	select {
+	case notify := <-testNotifyChan:
+		assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+		assert.Equal(t, testSecUid, notify.GetUid())
+		assert.Equal(t, Live, notify.Type())
+	case <-time.After(time.Second):
+		assert.Fail(t, "no notify received")
+	}
+
+	testEventChan <- &VideoInfo{
+		SecUid:   testSecUid,
+		Nickname: test.NAME1,
+		AwemeId:  "7000000000000000000",
+	}
+
+	select {
+	case notify := <-testNotifyChan:
+		assert.Equal(t, mmsg.NewGroupTargetId(test.G2), notify.GetTarget())
+		assert.Equal(t, testSecUid, notify.GetUid())
+		assert.Equal(t, News, notify.Type())
+	case <-time.After(time.Second):
+		assert.Fail(t, "no notify received")
+	}
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), testSecUid, Live)
+	assert.Nil(t, err)
+	_, err = c.GetLiveInfo(testSecUid)
+	assert.NotNil(t, err)
+	_, err = c.GetUserInfo(testSecUid)
+	assert.Nil(t, err)
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G2), testSecUid, News)
+	assert.Nil(t, err)
+	_, err = c.GetUserInfo(testSecUid)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,177 @@
+package douyin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	Site        = "douyin"
+	Host        = "https://www.douyin.com"
+	LiveHost    = "https://live.douyin.com"
+	WebcastHost = "https://webcast.amemv.com"
+	TtwidUrl    = "https://ttwid.bytedance.com/ttwid/union/register/"
+
+	// UserAgent 签名与请求需要使用同一个UA，所以这里不使用随机UA
+	UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Safari/537.36"
+)
+
+const (
+	PathUserProfile = "/aweme/v1/web/user/profile/other/"
+	PathAwemePost   = "/aweme/v1/web/aweme/post/"
+	PathRoomReflow  = "/webcast/room/reflow/info/"
+)
+
+func DouyinPath(path string) string {
+	return Host + path
+}
+
+func UserUrl(secUid string) string {
+	return fmt.Sprintf("%v/user/%v", Host, secUid)
+}
+
+func VideoUrl(awemeId string) string {
+	return fmt.Sprintf("%v/video/%v", Host, awemeId)
+}
+
+func LiveUrl(webRid string) string {
+	return fmt.Sprintf("%v/%v", LiveHost, webRid)
+}
+
+// ttwid 是访问抖音网页接口需要的cookie，可以直接向 TtwidUrl 申请，有效期很长
+var ttwid struct {
+	sync.Mutex
+	value  string
+	expire time.Time
+}
+
+func getTtwid() (string, error) {
+	ttwid.Lock()
+	defer ttwid.Unlock()
+	if len(ttwid.value) > 0 && time.Now().Before(ttwid.expire) {
+		return ttwid.value, nil
+	}
+	var cookies []*http.Cookie
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.AddUAOption(UserAgent),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+		requests.GetResponseCookieOption(&cookies),
+	}
+	err := requests.PostJson(TtwidUrl, gout.H{
+		"region":        "cn",
+		"aid":           1768,
+		"needFid":       false,
+		"service":       "www.ixigua.com",
+		"migrate_info":  gout.H{"ticket": "", "source": "node"},
+		"cbUrlProtocol": "https",
+		"union":         true,
+	}, new(bytes.Buffer), opts...)
+	if err != nil {
+		return "", err
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == "ttwid" {
+			ttwid.value = cookie.Value
+			ttwid.expire = time.Now().Add(time.Hour * 24)
+			return ttwid.value, nil
+		}
+	}
+	return "", errors.New("ttwid not found")
+}
+
+const msTokenChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// genMsToken 生成随机的msToken，网页接口只检查格式
+func genMsToken() string {
+	var b = make([]byte, 107)
+	for i := range b {
+		b[i] = msTokenChars[rand.Intn(len(msTokenChars))]
+	}
+	return string(b)
+}
+
+type SignResponse struct {
+	Url string `json:"url"`
+}
+
+// Sign 网页接口需要在url上附加签名参数，签名算法经常变化，所以交给外部的签名服务完成。
+// 签名服务需要支持 GET {signServer}?url=xxx&ua=xxx ，返回 {"url": "签名后的url"}。
+// 没有配置签名服务时返回原url，部分接口不签名也可以访问。
+func Sign(rawUrl string) (string, error) {
+	signServer := cfg.GetDouyinSignServer()
+	if len(signServer) == 0 {
+		return rawUrl, nil
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}
+	var resp = new(SignResponse)
+	err := requests.Get(signServer, gout.H{"url": rawUrl, "ua": UserAgent}, resp, opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Url) == 0 {
+		return "", errors.New("sign server returned empty url")
+	}
+	return resp.Url, nil
+}
+
+func webParams(params url.Values) url.Values {
+	for k, v := range map[string]string{
+		"device_platform": "webapp",
+		"aid":             "6383",
+		"channel":         "channel_pc_web",
+		"pc_client_type":  "1",
+		"version_code":    "170400",
+		"cookie_enabled":  "true",
+		"platform":        "PC",
+		"msToken":         genMsToken(),
+	} {
+		params.Set(k, v)
+	}
+	return params
+}
+
+// webGet 请求抖音网页接口，会自动附加通用参数、cookie与签名
+func webGet(rawUrl string, params url.Values, out interface{}, refer string) error {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	token, err := getTtwid()
+	if err != nil {
+		return fmt.Errorf("get ttwid error %v", err)
+	}
+	signedUrl, err := Sign(rawUrl + "?" + webParams(params).Encode())
+	if err != nil {
+		return fmt.Errorf("sign error %v", err)
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.AddUAOption(UserAgent),
+		requests.HeaderOption("Referer", refer),
+		requests.CookieOption("ttwid", token),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}
+	return requests.Get(signedUrl, nil, out, opts...)
+}
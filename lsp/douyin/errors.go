@@ -0,0 +1,7 @@
+package douyin
+
+import "errors"
+
+var (
+	ErrUserNotExist = errors.New("用户不存在")
+)
@@ -0,0 +1,56 @@
+package douyin
+
+import (
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
+
+type keySet struct {
+}
+
+func (l *keySet) GroupAtAllMarkKey(keys ...interface{}) string {
+	return buntdb.DouyinGroupAtAllMarkKey(keys...)
+}
+
+func (l *keySet) GroupConcernConfigKey(keys ...interface{}) string {
+	return buntdb.DouyinGroupConcernConfigKey(keys...)
+}
+
+func (l *keySet) GroupConcernStateKey(keys ...interface{}) string {
+	return buntdb.DouyinGroupConcernStateKey(keys...)
+}
+
+func (l *keySet) FreshKey(keys ...interface{}) string {
+	return buntdb.DouyinFreshKey(keys...)
+}
+
+func (l *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	return concern.ParseConcernStateKeyWithString(key)
+}
+
+type extraKey struct{}
+
+func (k extraKey) UserInfoKey(keys ...interface{}) string {
+	return buntdb.DouyinUserInfoKey(keys...)
+}
+
+func (k extraKey) CurrentLiveKey(keys ...interface{}) string {
+	return buntdb.DouyinCurrentLiveKey(keys...)
+}
+
+func (k extraKey) VideoKey(keys ...interface{}) string {
+	return buntdb.DouyinVideoKey(keys...)
+}
+
+func (k extraKey) LastVideoTimeKey(keys ...interface{}) string {
+	return buntdb.DouyinLastVideoTimeKey(keys...)
+}
+
+func NewExtraKey() *extraKey {
+	return &extraKey{}
+}
+
+func NewKeySet() *keySet {
+	return &keySet{}
+}
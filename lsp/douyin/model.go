@@ -0,0 +1,231 @@
+package douyin
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+type UserInfo struct {
+	SecUid   string `json:"sec_uid"`
+	Uid      string `json:"uid"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	RoomId   int64  `json:"room_id"`
+}
+
+func (u *UserInfo) GetUid() interface{} {
+	return u.SecUid
+}
+
+func (u *UserInfo) GetName() string {
+	if u == nil {
+		return ""
+	}
+	return u.Nickname
+}
+
+type LiveInfo struct {
+	UserInfo
+	WebRid   string `json:"web_rid"`
+	Title    string `json:"title"`
+	Cover    string `json:"cover"`
+	IsLiving bool   `json:"living"`
+
+	once              sync.Once
+	msgCache          *mmsg.MSG
+	liveStatusChanged bool
+	liveTitleChanged  bool
+}
+
+func (m *LiveInfo) TitleChanged() bool {
+	return m.liveTitleChanged
+}
+
+func (m *LiveInfo) IsLive() bool {
+	return true
+}
+
+func (m *LiveInfo) Living() bool {
+	return m.IsLiving
+}
+
+func (m *LiveInfo) LiveStatusChanged() bool {
+	return m.liveStatusChanged
+}
+
+func (m *LiveInfo) Type() concern_type.Type {
+	return Live
+}
+
+func (m *LiveInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":     Site,
+		"Nickname": m.Nickname,
+		"SecUid":   m.SecUid,
+		"Title":    m.Title,
+		"Living":   m.IsLiving,
+	})
+}
+
+func (m *LiveInfo) Site() string {
+	return Site
+}
+
+// LiveUrl 没有直播间号时使用用户主页
+func (m *LiveInfo) LiveUrl() string {
+	if len(m.WebRid) == 0 {
+		return UserUrl(m.SecUid)
+	}
+	return LiveUrl(m.WebRid)
+}
+
+func (m *LiveInfo) GetMSG() *mmsg.MSG {
+	m.once.Do(func() {
+		var cover = m.Cover
+		if len(cover) == 0 {
+			cover = m.Avatar
+		}
+		var data = map[string]interface{}{
+			"title":  m.Title,
+			"name":   m.Nickname,
+			"url":    m.LiveUrl(),
+			"cover":  cover,
+			"living": m.Living(),
+		}
+		var err error
+		m.msgCache, err = template.LoadAndExec("notify.group.douyin.live.tmpl", data)
+		if err != nil {
+			logger.Errorf("douyin: LiveInfo LoadAndExec error %v", err)
+		}
+		return
+	})
+	return m.msgCache
+}
+
+type VideoInfo struct {
+	SecUid     string `json:"sec_uid"`
+	Nickname   string `json:"nickname"`
+	AwemeId    string `json:"aweme_id"`
+	Desc       string `json:"desc"`
+	Cover      string `json:"cover"`
+	CreateTime int64  `json:"create_time"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (v *VideoInfo) GetUid() interface{} {
+	return v.SecUid
+}
+
+func (v *VideoInfo) GetName() string {
+	if v == nil {
+		return ""
+	}
+	return v.Nickname
+}
+
+func (v *VideoInfo) Type() concern_type.Type {
+	return News
+}
+
+func (v *VideoInfo) Site() string {
+	return Site
+}
+
+func (v *VideoInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":     Site,
+		"Nickname": v.Nickname,
+		"SecUid":   v.SecUid,
+		"AwemeId":  v.AwemeId,
+	})
+}
+
+// DedupKeys 实现 concern.NotifyDedupExt ，多人共同创作的视频会出现在每个作者的主页
+func (v *VideoInfo) DedupKeys() []string {
+	return []string{"aweme:" + v.AwemeId}
+}
+
+func (v *VideoInfo) GetMSG() *mmsg.MSG {
+	v.once.Do(func() {
+		var data = map[string]interface{}{
+			"name":  v.Nickname,
+			"desc":  v.Desc,
+			"url":   VideoUrl(v.AwemeId),
+			"cover": v.Cover,
+			"time":  localutils.TimestampFormat(v.CreateTime),
+		}
+		var err error
+		v.msgCache, err = template.LoadAndExec("notify.group.douyin.news.tmpl", data)
+		if err != nil {
+			logger.Errorf("douyin: VideoInfo LoadAndExec error %v", err)
+		}
+		return
+	})
+	return v.msgCache
+}
+
+type ConcernLiveNotify struct {
+	*LiveInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernLiveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernLiveNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.LiveInfo.GetMSG()
+}
+
+func (notify *ConcernLiveNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.LiveInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+type ConcernVideoNotify struct {
+	*VideoInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernVideoNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernVideoNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.VideoInfo.GetMSG()
+}
+
+func (notify *ConcernVideoNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.VideoInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernLiveNotify(target mmsg.TargetId, l *LiveInfo) *ConcernLiveNotify {
+	if l == nil {
+		return nil
+	}
+	return &ConcernLiveNotify{
+		l,
+		target,
+	}
+}
+
+func NewConcernVideoNotify(target mmsg.TargetId, v *VideoInfo) *ConcernVideoNotify {
+	if v == nil {
+		return nil
+	}
+	return &ConcernVideoNotify{
+		v,
+		target,
+	}
+}
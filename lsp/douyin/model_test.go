@@ -0,0 +1,62 @@
+package douyin
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLiveInfo(t *testing.T) {
+	l := &LiveInfo{
+		UserInfo: UserInfo{
+			SecUid:   test.NAME1,
+			Nickname: test.NAME2,
+		},
+		Title: test.NAME2,
+	}
+	assert.Equal(t, Site, l.Site())
+	assert.Equal(t, test.NAME1, l.GetUid())
+	assert.Equal(t, test.NAME2, l.GetName())
+	assert.Equal(t, Live, l.Type())
+	assert.Equal(t, UserUrl(test.NAME1), l.LiveUrl())
+	notify := NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), l)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, test.NAME1, notify.GetUid())
+	assert.Equal(t, Live, notify.Type())
+
+	m := notify.ToMessage()
+	assert.NotNil(t, m)
+
+	notify.IsLiving = true
+	notify.WebRid = "123456"
+	assert.Equal(t, LiveUrl("123456"), notify.LiveUrl())
+	m = notify.ToMessage()
+	assert.NotNil(t, m)
+}
+
+func TestVideoInfo(t *testing.T) {
+	v := &VideoInfo{
+		SecUid:     test.NAME1,
+		Nickname:   test.NAME2,
+		AwemeId:    "7000000000000000000",
+		Desc:       test.NAME1,
+		CreateTime: 1600000000,
+	}
+	assert.Equal(t, Site, v.Site())
+	assert.Equal(t, test.NAME1, v.GetUid())
+	assert.Equal(t, test.NAME2, v.GetName())
+	assert.Equal(t, News, v.Type())
+	assert.EqualValues(t, []string{"aweme:7000000000000000000"}, v.DedupKeys())
+	notify := NewConcernVideoNotify(mmsg.NewGroupTargetId(test.G1), v)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, News, notify.Type())
+	assert.NotNil(t, notify.ToMessage())
+
+	assert.Nil(t, NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), nil))
+	assert.Nil(t, NewConcernVideoNotify(mmsg.NewGroupTargetId(test.G1), nil))
+}
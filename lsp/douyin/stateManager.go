@@ -0,0 +1,113 @@
+package douyin
+
+import (
+	"errors"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"time"
+)
+
+type StateManager struct {
+	*concern.StateManager
+	*extraKey
+}
+
+func (c *StateManager) AddUserInfo(userInfo *UserInfo) error {
+	if userInfo == nil {
+		return errors.New("nil UserInfo")
+	}
+	return c.SetJson(c.UserInfoKey(userInfo.SecUid), userInfo)
+}
+
+func (c *StateManager) GetUserInfo(secUid string) (*UserInfo, error) {
+	var userInfo = &UserInfo{}
+	err := c.GetJson(c.UserInfoKey(secUid), userInfo, localdb.GetCacheOpt())
+	if err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
+
+func (c *StateManager) GetLiveInfo(secUid string) (*LiveInfo, error) {
+	var liveInfo = &LiveInfo{}
+	err := c.GetJson(c.CurrentLiveKey(secUid), liveInfo, localdb.GetCacheOpt())
+	if err != nil {
+		return nil, err
+	}
+	return liveInfo, nil
+}
+
+// GetState 实现 concern.StateExt
+func (c *StateManager) GetState(id interface{}) *concern.State {
+	liveInfo, err := c.GetLiveInfo(id.(string))
+	if err != nil {
+		return nil
+	}
+	return &concern.State{
+		Living: liveInfo.Living(),
+		Title:  liveInfo.Title,
+	}
+}
+
+func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
+	if liveInfo == nil {
+		return errors.New("nil LiveInfo")
+	}
+	return c.RWCover(func() error {
+		err := c.SetJson(c.UserInfoKey(liveInfo.SecUid), liveInfo.UserInfo)
+		if err != nil {
+			return err
+		}
+		return c.SetJson(c.CurrentLiveKey(liveInfo.SecUid), liveInfo, localdb.SetExpireOpt(time.Hour*24*7))
+	})
+}
+
+func (c *StateManager) DeleteLiveInfo(secUid string) error {
+	_, err := c.Delete(c.CurrentLiveKey(secUid), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// DeleteUserInfo 删除用户信息以及视频的推送进度
+func (c *StateManager) DeleteUserInfo(secUid string) error {
+	return c.RWCover(func() error {
+		var err error
+		for _, key := range []string{
+			c.UserInfoKey(secUid),
+			c.CurrentLiveKey(secUid),
+			c.LastVideoTimeKey(secUid),
+		} {
+			_, err = c.Delete(key, localdb.IgnoreNotFoundOpt())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkVideo 标记视频已经推送过，返回的replaced为true时说明之前已经标记过
+func (c *StateManager) MarkVideo(awemeId string) (replaced bool, err error) {
+	err = c.Set(c.VideoKey(awemeId), "",
+		localdb.SetExpireOpt(time.Hour*24*30), localdb.SetGetIsOverwriteOpt(&replaced))
+	return
+}
+
+func (c *StateManager) SetLastVideoTime(secUid string, ts int64) error {
+	return c.SetInt64(c.LastVideoTimeKey(secUid), ts)
+}
+
+func (c *StateManager) GetLastVideoTime(secUid string) (int64, error) {
+	return c.GetInt64(c.LastVideoTimeKey(secUid))
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
+}
+
+func NewStateManager(notify chan<- concern.Notify) *StateManager {
+	sm := &StateManager{}
+	sm.extraKey = NewExtraKey()
+	sm.StateManager = concern.NewStateManagerWithCustomKey(Site, NewKeySet(), notify)
+	return sm
+}
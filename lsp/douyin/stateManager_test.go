@@ -0,0 +1,74 @@
+package douyin
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initStateManager(t *testing.T) *StateManager {
+	sm := NewStateManager(nil)
+	assert.NotNil(t, sm)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	return sm
+}
+
+func TestStateManager_GetLiveInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1))
+
+	_, err := sm.GetLiveInfo(test.NAME1)
+	assert.NotNil(t, err)
+
+	expected := &LiveInfo{
+		UserInfo: UserInfo{
+			SecUid:   test.NAME1,
+			Nickname: test.NAME2,
+		},
+		Title:    test.NAME2,
+		IsLiving: true,
+	}
+	assert.Nil(t, sm.AddLiveInfo(expected))
+	actual, err := sm.GetLiveInfo(test.NAME1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+
+	userInfo, err := sm.GetUserInfo(test.NAME1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, &expected.UserInfo, userInfo)
+
+	assert.Nil(t, sm.DeleteLiveInfo(test.NAME1))
+	assert.Nil(t, sm.DeleteLiveInfo(test.NAME1))
+	_, err = sm.GetLiveInfo(test.NAME1)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, sm.DeleteUserInfo(test.NAME1))
+	_, err = sm.GetUserInfo(test.NAME1)
+	assert.NotNil(t, err)
+}
+
+func TestStateManager_MarkVideo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	replaced, err := sm.MarkVideo(test.NAME1)
+	assert.Nil(t, err)
+	assert.False(t, replaced)
+	replaced, err = sm.MarkVideo(test.NAME1)
+	assert.Nil(t, err)
+	assert.True(t, replaced)
+
+	_, err = sm.GetLastVideoTime(test.NAME1)
+	assert.NotNil(t, err)
+	assert.Nil(t, sm.SetLastVideoTime(test.NAME1, 100))
+	ts, err := sm.GetLastVideoTime(test.NAME1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 100, ts)
+}
@@ -15,7 +15,27 @@ const (
 	PathBetard = "/betard"
 )
 
+// BetardRoomDetail betard接口中 BetardResponse 没有解析的直播间字段
+type BetardRoomDetail struct {
+	Room struct {
+		// RoomPic 主播设置的直播间封面
+		RoomPic string `json:"room_pic"`
+		// SecondLvlName 直播间所在的分区
+		SecondLvlName string `json:"second_lvl_name"`
+		// UpId 主播在斗鱼视频的id，用于查询录播
+		UpId string `json:"up_id"`
+		// OwnerUid 主播的用户id，用于查询鱼吧帖子
+		OwnerUid int64 `json:"owner_uid"`
+	} `json:"room"`
+}
+
 func Betard(id int64) (*BetardResponse, error) {
+	betardResp, _, err := BetardWithDetail(id)
+	return betardResp, err
+}
+
+// BetardWithDetail 与 Betard 相同，额外返回直播间的封面和分区
+func BetardWithDetail(id int64) (*BetardResponse, *BetardRoomDetail, error) {
 	st := time.Now()
 	defer func() {
 		ed := time.Now()
@@ -24,24 +44,29 @@ func Betard(id int64) (*BetardResponse, error) {
 	url := DouyuPath(PathBetard) + fmt.Sprintf("/%v", id)
 	var opts = []requests.Option{
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.TimeoutOption(time.Second * 10),
 		requests.RetryOption(3),
 	}
 	var body = new(bytes.Buffer)
 	err := requests.Get(url, nil, body, opts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	betardResp := new(BetardResponse)
 	err = json.Unmarshal(body.Bytes(), betardResp)
 	if err != nil {
 		if strings.Contains(body.String(), "没有开放") {
-			return nil, errors.New("房间不存在")
+			return nil, nil, errors.New("房间不存在")
 		}
 		if strings.Contains(body.String(), "已被关闭") {
-			return nil, ErrRoomBanned
+			return nil, nil, ErrRoomBanned
 		}
-		return nil, err
+		return nil, nil, err
+	}
+	detail := new(BetardRoomDetail)
+	if err = json.Unmarshal(body.Bytes(), detail); err != nil {
+		logger.WithField("id", id).Debugf("unmarshal BetardRoomDetail error %v", err)
 	}
-	return betardResp, nil
+	return betardResp, detail, nil
 }
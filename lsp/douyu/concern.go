@@ -2,23 +2,32 @@ package douyu
 
 import (
 	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
-	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/Sora233/DDBOT/lsp/recorder"
+	"github.com/Sora233/DDBOT/requests"
 	"github.com/Sora233/MiraiGo-Template/utils"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
+	"sort"
+	"strconv"
 )
 
 var logger = utils.GetModuleLogger("douyu-concern")
 
 const (
 	Live concern_type.Type = "live"
+	// Replay 主播发布的录播
+	Replay concern_type.Type = "replay"
+	// News 主播在鱼吧发布的帖子
+	News concern_type.Type = "news"
 )
 
 type Concern struct {
 	*StateManager
+	// unsubscribeRecord 取消录制在事件总线中的订阅
+	unsubscribeRecord func()
 }
 
 func (c *Concern) Site() string {
@@ -26,7 +35,7 @@ func (c *Concern) Site() string {
 }
 
 func (c *Concern) Types() []concern_type.Type {
-	return []concern_type.Type{Live}
+	return []concern_type.Type{Live, Replay, News}
 }
 
 func (c *Concern) ParseId(s string) (interface{}, error) {
@@ -39,6 +48,9 @@ func (c *Concern) GetStateManager() concern.IStateManager {
 
 func (c *Concern) Stop() {
 	logger.Trace("正在停止douyu concern")
+	if c.unsubscribeRecord != nil {
+		c.unsubscribeRecord()
+	}
 	logger.Trace("正在停止douyu StateManager")
 	c.StateManager.Stop()
 	logger.Trace("douyu StateManager已停止")
@@ -48,16 +60,18 @@ func (c *Concern) Stop() {
 func (c *Concern) Start() error {
 	c.UseEmitQueue()
 	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.unsubscribeRecord = concern.Subscribe(Site+".record", c.onRecordEvent,
+		concern.TopicLiveStart, concern.TopicLiveTitleChange, concern.TopicLiveStop)
 	c.StateManager.UseFreshFunc(c.fresh())
 	return c.StateManager.Start()
 }
 
-func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(int64)
 	var err error
-	log := logger.WithFields(localutils.GroupLogFields(groupCode)).WithField("id", id)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
 
-	err = c.StateManager.CheckGroupConcern(groupCode, id, ctype)
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -76,24 +90,26 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype
 		VideoLoop:  betardResp.GetRoom().GetVideoLoop(),
 		Avatar:     betardResp.GetRoom().GetAvatar(),
 	}
-	_, err = c.StateManager.AddGroupConcern(groupCode, id, ctype)
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
 	return liveInfo, nil
 }
 
-func (c *Concern) Remove(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(int64)
 	identity, _ := c.Get(id)
-	_, err := c.StateManager.RemoveGroupConcern(groupCode, id, ctype)
-	_ = c.RWCoverTx(func(tx *buntdb.Tx) error {
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
 		allCtype, err := c.GetConcern(id)
 		if err != nil {
 			return err
 		}
 		if allCtype.Empty() {
 			err = c.DeleteLiveInfo(id)
+			_ = c.DeleteLastReplayTime(id)
+			_ = c.DeleteLastNewsTime(id)
 		}
 		return err
 	})
@@ -108,16 +124,42 @@ func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
 	return concern.NewIdentity(liveInfo.GetRoomId(), liveInfo.GetNickname()), nil
 }
 
+// Search 实现 concern.SearchExt ，使用斗鱼的主播搜索
+func (c *Concern) Search(keyword string, n int) ([]*concern.SearchResult, error) {
+	resp, err := SearchUser(keyword)
+	if err != nil {
+		return nil, err
+	}
+	var result []*concern.SearchResult
+	for _, user := range resp.Data.RelateUser {
+		if len(result) >= n {
+			break
+		}
+		result = append(result, &concern.SearchResult{
+			Id:        user.AnchorInfo.Rid,
+			Name:      user.AnchorInfo.NickName,
+			Followers: user.AnchorInfo.FollowerCount,
+		})
+	}
+	return result, nil
+}
+
 func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, event concern.Event) []concern.Notify {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
 		switch info := event.(type) {
 		case *LiveInfo:
 			if info.Living() {
-				info.Logger().WithFields(localutils.GroupLogFields(groupCode)).Trace("living notify")
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("living notify")
 			} else {
-				info.Logger().WithFields(localutils.GroupLogFields(groupCode)).Trace("noliving notify")
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("noliving notify")
 			}
-			return []concern.Notify{NewConcernLiveNotify(groupCode, info)}
+			return []concern.Notify{NewConcernLiveNotify(target, info)}
+		case *ReplayInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("replay notify")
+			return []concern.Notify{NewConcernReplayNotify(target, info)}
+		case *NewsInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("news notify")
+			return []concern.Notify{NewConcernNewsNotify(target, info)}
 		default:
 			logger.Errorf("unknown EventType %+v", event)
 			return nil
@@ -125,6 +167,41 @@ func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
 	}
 }
 
+// onRecordEvent 是录制在事件总线中的订阅者，开播时如果有群开启了录制则开始录制，下播时停止
+func (c *Concern) onRecordEvent(e *concern.BusEvent) {
+	liveInfo, ok := e.Event.(*LiveInfo)
+	if !ok {
+		return
+	}
+	if !liveInfo.Living() {
+		recorder.Stop(Site, strconv.FormatInt(liveInfo.RoomId, 10))
+		return
+	}
+	var record bool
+	for _, target := range e.Targets {
+		if c.GetGroupConcernConfig(target, liveInfo.RoomId).GetGroupConcernNotify().CheckRecord() {
+			record = true
+			break
+		}
+	}
+	if !record {
+		return
+	}
+	roomId := liveInfo.RoomId
+	recorder.Start(&recorder.Task{
+		Site: Site,
+		Id:   strconv.FormatInt(roomId, 10),
+		Name: liveInfo.GetName(),
+		StreamUrl: func() (string, error) {
+			return GetStreamUrl(roomId)
+		},
+		Header: map[string]string{
+			"Referer":    Host,
+			"User-Agent": requests.RandomUA(requests.Computer),
+		},
+	})
+}
+
 func (c *Concern) fresh() concern.FreshFunc {
 	return c.EmitQueueFresher(func(ctype concern_type.Type, id interface{}) ([]concern.Event, error) {
 		var result []concern.Event
@@ -152,17 +229,113 @@ func (c *Concern) fresh() concern.FreshFunc {
 				if oldInfo.RoomName != liveInfo.RoomName {
 					liveInfo.liveTitleChanged = true
 				}
+				liveInfo.checkLiveChange(oldInfo)
 			}
 			result = append(result, liveInfo)
 		}
+		if ctype.ContainAny(Replay.Add(News)) {
+			liveInfo, err := c.findRoomWithOwner(roomid)
+			if err != nil {
+				return nil, fmt.Errorf("load liveinfo failed %v", err)
+			}
+			if ctype.ContainAll(Replay) {
+				replays, err := c.freshReplay(liveInfo)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, replays...)
+			}
+			if ctype.ContainAll(News) {
+				news, err := c.freshNews(liveInfo)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, news...)
+			}
+		}
 		return result, nil
 	})
 }
 
+// findRoomWithOwner 返回带有 UpId 和 OwnerUid 的直播间信息，之前保存的信息中没有时重新查询
+func (c *Concern) findRoomWithOwner(roomId int64) (*LiveInfo, error) {
+	liveInfo, _ := c.FindRoom(roomId, false)
+	if liveInfo != nil && len(liveInfo.UpId) > 0 && liveInfo.OwnerUid != 0 {
+		return liveInfo, nil
+	}
+	return c.FindRoom(roomId, true)
+}
+
+// freshReplay 返回上一次查询之后发布的录播，第一次查询时只记录，不会推送已经发布的录播
+func (c *Concern) freshReplay(liveInfo *LiveInfo) ([]concern.Event, error) {
+	if len(liveInfo.UpId) == 0 {
+		return nil, nil
+	}
+	lastTime, lastErr := c.GetLastReplayTime(liveInfo.RoomId)
+	replays, err := AuthorVideo(liveInfo.RoomId, liveInfo.UpId)
+	if err != nil {
+		return nil, fmt.Errorf("load replay failed %v", err)
+	}
+	sort.Slice(replays, func(i, j int) bool {
+		return replays[i].PublishTime < replays[j].PublishTime
+	})
+	var result []concern.Event
+	var newLastTime = lastTime
+	for _, r := range replays {
+		if r.PublishTime <= lastTime {
+			continue
+		}
+		if lastErr == nil {
+			r.Name = liveInfo.GetNickname()
+			result = append(result, r)
+		}
+		newLastTime = r.PublishTime
+	}
+	if lastErr != nil || newLastTime != lastTime {
+		if err := c.SetLastReplayTime(liveInfo.RoomId, newLastTime); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// freshNews 返回上一次查询之后发布的鱼吧帖子，第一次查询时只记录，不会推送已经发布的帖子
+func (c *Concern) freshNews(liveInfo *LiveInfo) ([]concern.Event, error) {
+	if liveInfo.OwnerUid == 0 {
+		return nil, nil
+	}
+	lastTime, lastErr := c.GetLastNewsTime(liveInfo.RoomId)
+	news, err := YubaFeed(liveInfo.RoomId, liveInfo.OwnerUid)
+	if err != nil {
+		return nil, fmt.Errorf("load yuba feed failed %v", err)
+	}
+	sort.Slice(news, func(i, j int) bool {
+		return news[i].PublishTime < news[j].PublishTime
+	})
+	var result []concern.Event
+	var newLastTime = lastTime
+	for _, n := range news {
+		if n.PublishTime <= lastTime {
+			continue
+		}
+		if lastErr == nil {
+			n.Name = liveInfo.GetNickname()
+			result = append(result, n)
+		}
+		newLastTime = n.PublishTime
+	}
+	if lastErr != nil || newLastTime != lastTime {
+		if err := c.SetLastNewsTime(liveInfo.RoomId, newLastTime); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
 func (c *Concern) FindRoom(id int64, load bool) (*LiveInfo, error) {
 	var liveInfo *LiveInfo
 	if load {
-		betardResp, err := Betard(id)
+		betardResp, detail, err := BetardWithDetail(id)
 		if err != nil {
 			return nil, err
 		}
@@ -174,6 +347,10 @@ func (c *Concern) FindRoom(id int64, load bool) (*LiveInfo, error) {
 			ShowStatus: betardResp.GetRoom().GetShowStatus(),
 			VideoLoop:  betardResp.GetRoom().GetVideoLoop(),
 			Avatar:     betardResp.GetRoom().GetAvatar(),
+			RoomPic:    detail.Room.RoomPic,
+			AreaName:   detail.Room.SecondLvlName,
+			UpId:       detail.Room.UpId,
+			OwnerUid:   detail.Room.OwnerUid,
 		}
 		_ = c.StateManager.AddLiveInfo(liveInfo)
 	}
@@ -8,6 +8,36 @@ type GroupConcernConfig struct {
 	concern.IConfig
 }
 
+// Validate 斗鱼支持直播录制，默认的Validate会拒绝，所以开启录制时只检查过滤器和推送模板
+func (g *GroupConcernConfig) Validate() error {
+	notifyConfig := g.GetGroupConcernNotify()
+	if !notifyConfig.CheckRecord() || notifyConfig.CheckDanmakuRelay() || notifyConfig.CheckDanmakuAlert() {
+		return g.IConfig.Validate()
+	}
+	if err := g.GetGroupConcernTemplate().Validate(); err != nil {
+		return err
+	}
+	if !g.GetGroupConcernFilter().Empty() {
+		if g.GetGroupConcernFilter().Type != concern.FilterTypeText {
+			return concern.ErrConfigNotSupported
+		}
+		textFilter, err := g.GetGroupConcernFilter().GetFilterByText()
+		if err != nil {
+			return err
+		}
+		return textFilter.Validate()
+	}
+	return nil
+}
+
+// FilterHook 推送过滤只对鱼吧帖子生效，直播和录播推送不过滤
+func (g *GroupConcernConfig) FilterHook(notify concern.Notify) *concern.HookResult {
+	if _, ok := notify.(*ConcernNewsNotify); !ok {
+		return concern.HookResultPass
+	}
+	return g.IConfig.FilterHook(notify)
+}
+
 func NewGroupConcernConfig(g concern.IConfig) *GroupConcernConfig {
 	return &GroupConcernConfig{g}
 }
@@ -3,6 +3,7 @@ package douyu
 import (
 	"github.com/Sora233/DDBOT/internal/test"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -29,6 +30,19 @@ func TestNewGroupConcernConfig(t *testing.T) {
 	assert.NotNil(t, g)
 }
 
+func TestGroupConcernConfig_Validate(t *testing.T) {
+	g := NewGroupConcernConfig(new(concern.GroupConcernConfig))
+	assert.Nil(t, g.Validate())
+	g.GetGroupConcernNotify().Record = true
+	assert.Nil(t, g.Validate())
+	g.GetGroupConcernFilter().Type = concern.FilterTypeType
+	g.GetGroupConcernFilter().Config = "{}"
+	assert.Equal(t, concern.ErrConfigNotSupported, g.Validate())
+	g.GetGroupConcernFilter().Type = ""
+	g.GetGroupConcernNotify().DanmakuRelay = &concern.GroupConcernDanmakuRelayConfig{Enable: true}
+	assert.Equal(t, concern.ErrConfigNotSupported, g.Validate())
+}
+
 func TestGroupConcernConfig_ShouldSendHook(t *testing.T) {
 	var notify = []concern.Notify{
 		// 下播状态 什么也没变 不推
@@ -133,3 +147,15 @@ func TestGroupConcernConfig_AtBeforeHook(t *testing.T) {
 		assert.EqualValues(t, expected[idx], hook.Pass)
 	}
 }
+
+func TestGroupConcernConfig_FilterHook(t *testing.T) {
+	g := NewGroupConcernConfig(new(concern.GroupConcernConfig))
+	g.GetGroupConcernFilter().Type = concern.FilterTypeText
+	g.GetGroupConcernFilter().Config = `{"text":["抽奖"]}`
+	assert.Nil(t, g.Validate())
+
+	assert.True(t, g.FilterHook(newLiveInfo(test.UID1, true, true, false)).Pass)
+	assert.True(t, g.FilterHook(NewConcernReplayNotify(mmsg.NewGroupTargetId(test.G1), &ReplayInfo{RoomId: test.UID1, HashId: "1"})).Pass)
+	assert.False(t, g.FilterHook(NewConcernNewsNotify(mmsg.NewGroupTargetId(test.G1), &NewsInfo{RoomId: test.UID1, FeedId: "1", Content: "content"})).Pass)
+	assert.True(t, g.FilterHook(NewConcernNewsNotify(mmsg.NewGroupTargetId(test.G1), &NewsInfo{RoomId: test.UID1, FeedId: "2", Content: "抽奖"})).Pass)
+}
@@ -12,6 +12,13 @@ const (
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+var (
+	// VodApi 斗鱼视频查询主播录播的接口地址
+	VodApi = "https://v.douyu.com/wgapi/vod/center/authorShowVideoList"
+	// YubaApi 鱼吧查询用户帖子的接口地址
+	YubaApi = "https://yuba.douyu.com/wbapi/web/user/feed"
+)
+
 func DouyuPath(path string) string {
 	return Host + path
 }
@@ -19,3 +26,13 @@ func DouyuPath(path string) string {
 func ParseUid(s string) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)
 }
+
+// VideoPath 录播的播放地址
+func VideoPath(hashId string) string {
+	return "https://v.douyu.com/show/" + hashId
+}
+
+// YubaPostPath 鱼吧帖子的地址
+func YubaPostPath(feedId string) string {
+	return "https://yuba.douyu.com/p/" + feedId
+}
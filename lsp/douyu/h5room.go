@@ -0,0 +1,60 @@
+package douyu
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+const (
+	PathH5Room = "/swf_api/h5room"
+)
+
+type H5RoomResponse struct {
+	Error int `json:"error"`
+	Data  struct {
+		RtmpUrl  string `json:"rtmp_url"`
+		RtmpLive string `json:"rtmp_live"`
+	} `json:"data"`
+}
+
+// H5Room 旧版网页播放器使用的接口，不需要签名就可以拿到flv直播流地址，但是不保证一直可用
+func H5Room(id int64) (*H5RoomResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	url := DouyuPath(PathH5Room) + fmt.Sprintf("/%v", id)
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 10),
+		requests.AddRandomUAOption(requests.Computer),
+		requests.RetryOption(3),
+	}
+	resp := new(H5RoomResponse)
+	err := requests.Get(url, nil, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetStreamUrl 返回直播间的flv直播流地址
+func GetStreamUrl(id int64) (string, error) {
+	resp, err := H5Room(id)
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != 0 {
+		return "", fmt.Errorf("H5Room failed %v", resp.Error)
+	}
+	if len(resp.Data.RtmpUrl) == 0 || len(resp.Data.RtmpLive) == 0 {
+		return "", errors.New("直播流地址为空")
+	}
+	return resp.Data.RtmpUrl + "/" + resp.Data.RtmpLive, nil
+}
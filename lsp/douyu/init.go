@@ -1,9 +1,11 @@
 package douyu
 
 import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
 )
 
 func init() {
 	concern.RegisterConcern(NewConcern(concern.GetNotifyChan()))
+	localdb.RegisterJsonType(localdb.DouyuCurrentLiveKey, func() interface{} { return new(LiveInfo) })
 }
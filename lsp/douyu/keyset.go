@@ -1,6 +1,10 @@
 package douyu
 
-import "github.com/Sora233/DDBOT/lsp/buntdb"
+import (
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
 
 type keySet struct {
 }
@@ -21,8 +25,8 @@ func (l *keySet) FreshKey(keys ...interface{}) string {
 	return buntdb.DouyuFreshKey(keys...)
 }
 
-func (l *keySet) ParseGroupConcernStateKey(key string) (int64, interface{}, error) {
-	return buntdb.ParseConcernStateKeyWithInt64(key)
+func (l *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	return concern.ParseConcernStateKeyWithInt64(key)
 }
 
 type extraKey struct {
@@ -32,6 +36,14 @@ func (l *extraKey) CurrentLiveKey(keys ...interface{}) string {
 	return buntdb.DouyuCurrentLiveKey(keys...)
 }
 
+func (l *extraKey) LastReplayKey(keys ...interface{}) string {
+	return buntdb.DouyuLastReplayKey(keys...)
+}
+
+func (l *extraKey) LastNewsKey(keys ...interface{}) string {
+	return buntdb.DouyuLastNewsKey(keys...)
+}
+
 func NewExtraKey() *extraKey {
 	return &extraKey{}
 }
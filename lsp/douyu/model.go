@@ -4,9 +4,10 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/template"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/sirupsen/logrus"
+	"strconv"
 	"sync"
+	"time"
 )
 
 type LiveInfo struct {
@@ -17,11 +18,21 @@ type LiveInfo struct {
 	ShowStatus ShowStatus      `json:"show_status"`
 	VideoLoop  VideoLoopStatus `json:"videoLoop"`
 	Avatar     *Avatar         `json:"avatar"`
+	// RoomPic 直播间封面
+	RoomPic string `json:"room_pic,omitempty"`
+	// AreaName 直播间所在的分区
+	AreaName string `json:"area_name,omitempty"`
+	// UpId 主播在斗鱼视频的id
+	UpId string `json:"up_id,omitempty"`
+	// OwnerUid 主播的用户id
+	OwnerUid int64 `json:"owner_uid,omitempty"`
 
 	once              sync.Once
 	msgCache          *mmsg.MSG
 	liveStatusChanged bool
 	liveTitleChanged  bool
+	liveCoverChanged  bool
+	liveAreaChanged   bool
 }
 
 func (m *LiveInfo) GetName() string {
@@ -32,6 +43,27 @@ func (m *LiveInfo) TitleChanged() bool {
 	return m.liveTitleChanged
 }
 
+func (m *LiveInfo) CoverChanged() bool {
+	return m.liveCoverChanged
+}
+
+func (m *LiveInfo) AreaChanged() bool {
+	return m.liveAreaChanged
+}
+
+// checkLiveChange 与上一次保存的状态对比，标记直播中是否更换了封面或者分区，旧状态没有保存封面或者分区时不比较
+func (m *LiveInfo) checkLiveChange(oldInfo *LiveInfo) {
+	if oldInfo == nil || !oldInfo.Living() || !m.Living() {
+		return
+	}
+	if len(oldInfo.RoomPic) > 0 && len(m.RoomPic) > 0 && oldInfo.RoomPic != m.RoomPic {
+		m.liveCoverChanged = true
+	}
+	if len(oldInfo.AreaName) > 0 && len(m.AreaName) > 0 && oldInfo.AreaName != m.AreaName {
+		m.liveAreaChanged = true
+	}
+}
+
 func (m *LiveInfo) LiveStatusChanged() bool {
 	return m.liveStatusChanged
 }
@@ -69,12 +101,20 @@ func (m *LiveInfo) Type() concern_type.Type {
 
 func (m *LiveInfo) GetMSG() *mmsg.MSG {
 	m.once.Do(func() {
+		var cover = m.GetAvatar().GetBig()
+		if m.liveCoverChanged {
+			cover = m.RoomPic
+		}
 		var data = map[string]interface{}{
 			"title":  m.RoomName,
 			"name":   m.Nickname,
 			"url":    m.RoomUrl,
-			"cover":  m.GetAvatar().GetBig(),
+			"cover":  cover,
 			"living": m.Living(),
+			"area":   m.AreaName,
+
+			"cover_changed": m.liveCoverChanged,
+			"area_changed":  m.liveAreaChanged,
 		}
 		var err error
 		m.msgCache, err = template.LoadAndExec("notify.group.douyu.live.tmpl", data)
@@ -154,11 +194,11 @@ func (m *LiveInfo) Logger() *logrus.Entry {
 
 type ConcernLiveNotify struct {
 	*LiveInfo
-	GroupCode int64 `json:"group_code"`
+	Target mmsg.TargetId `json:"target"`
 }
 
-func (notify *ConcernLiveNotify) GetGroupCode() int64 {
-	return notify.GroupCode
+func (notify *ConcernLiveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
 }
 
 func (notify *ConcernLiveNotify) ToMessage() (m *mmsg.MSG) {
@@ -169,15 +209,201 @@ func (notify *ConcernLiveNotify) Logger() *logrus.Entry {
 	if notify == nil {
 		return logger
 	}
-	return notify.LiveInfo.Logger().WithFields(localutils.GroupLogFields(notify.GroupCode))
+	return notify.LiveInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
 }
 
-func NewConcernLiveNotify(groupCode int64, l *LiveInfo) *ConcernLiveNotify {
+func NewConcernLiveNotify(target mmsg.TargetId, l *LiveInfo) *ConcernLiveNotify {
 	if l == nil {
 		return nil
 	}
 	return &ConcernLiveNotify{
-		LiveInfo:  l,
-		GroupCode: groupCode,
+		LiveInfo: l,
+		Target:   target,
+	}
+}
+
+// ReplayInfo 主播发布的录播
+type ReplayInfo struct {
+	RoomId      int64  `json:"room_id"`
+	Name        string `json:"name"`
+	HashId      string `json:"hash_id"`
+	Title       string `json:"title"`
+	Cover       string `json:"cover"`
+	Duration    int64  `json:"duration"`
+	PublishTime int64  `json:"publish_time"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (r *ReplayInfo) GetUid() interface{} {
+	return r.RoomId
+}
+
+func (r *ReplayInfo) GetName() string {
+	if r == nil {
+		return ""
+	}
+	if len(r.Name) == 0 {
+		return strconv.FormatInt(r.RoomId, 10)
+	}
+	return r.Name
+}
+
+func (r *ReplayInfo) Type() concern_type.Type {
+	return Replay
+}
+
+func (r *ReplayInfo) Site() string {
+	return Site
+}
+
+func (r *ReplayInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":   Site,
+		"RoomId": r.RoomId,
+		"Name":   r.Name,
+		"HashId": r.HashId,
+		"Title":  r.Title,
+	})
+}
+
+func (r *ReplayInfo) GetMSG() *mmsg.MSG {
+	r.once.Do(func() {
+		var data = map[string]interface{}{
+			"name":     r.GetName(),
+			"title":    r.Title,
+			"url":      VideoPath(r.HashId),
+			"cover":    r.Cover,
+			"duration": (time.Duration(r.Duration) * time.Second).String(),
+		}
+		var err error
+		r.msgCache, err = template.LoadAndExec("notify.group.douyu.replay.tmpl", data)
+		if err != nil {
+			logger.Errorf("douyu: ReplayInfo LoadAndExec error %v", err)
+		}
+	})
+	return r.msgCache
+}
+
+// NewsInfo 主播在鱼吧发布的帖子
+type NewsInfo struct {
+	RoomId      int64  `json:"room_id"`
+	Name        string `json:"name"`
+	FeedId      string `json:"feed_id"`
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	PublishTime int64  `json:"publish_time"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (n *NewsInfo) GetUid() interface{} {
+	return n.RoomId
+}
+
+func (n *NewsInfo) GetName() string {
+	if n == nil {
+		return ""
+	}
+	if len(n.Name) == 0 {
+		return strconv.FormatInt(n.RoomId, 10)
+	}
+	return n.Name
+}
+
+func (n *NewsInfo) Type() concern_type.Type {
+	return News
+}
+
+func (n *NewsInfo) Site() string {
+	return Site
+}
+
+func (n *NewsInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":   Site,
+		"RoomId": n.RoomId,
+		"Name":   n.Name,
+		"FeedId": n.FeedId,
+		"Title":  n.Title,
+	})
+}
+
+func (n *NewsInfo) GetMSG() *mmsg.MSG {
+	n.once.Do(func() {
+		var data = map[string]interface{}{
+			"name":    n.GetName(),
+			"title":   n.Title,
+			"content": n.Content,
+			"url":     YubaPostPath(n.FeedId),
+		}
+		var err error
+		n.msgCache, err = template.LoadAndExec("notify.group.douyu.news.tmpl", data)
+		if err != nil {
+			logger.Errorf("douyu: NewsInfo LoadAndExec error %v", err)
+		}
+	})
+	return n.msgCache
+}
+
+type ConcernReplayNotify struct {
+	*ReplayInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernReplayNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernReplayNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.ReplayInfo.GetMSG()
+}
+
+func (notify *ConcernReplayNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.ReplayInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernReplayNotify(target mmsg.TargetId, r *ReplayInfo) *ConcernReplayNotify {
+	if r == nil {
+		return nil
+	}
+	return &ConcernReplayNotify{
+		ReplayInfo: r,
+		Target:     target,
+	}
+}
+
+type ConcernNewsNotify struct {
+	*NewsInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernNewsNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernNewsNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.NewsInfo.GetMSG()
+}
+
+func (notify *ConcernNewsNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.NewsInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernNewsNotify(target mmsg.TargetId, n *NewsInfo) *ConcernNewsNotify {
+	if n == nil {
+		return nil
+	}
+	return &ConcernNewsNotify{
+		NewsInfo: n,
+		Target:   target,
 	}
 }
@@ -2,7 +2,10 @@ package douyu
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"testing"
 )
 
@@ -48,11 +51,11 @@ func TestLiveInfo(t *testing.T) {
 	assert.Equal(t, VideoLoopStatus_Off, l.GetVideoLoop())
 	assert.False(t, l.GetLiveStatusChanged())
 
-	notify := NewConcernLiveNotify(test.G1, l)
+	notify := NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), l)
 	assert.NotNil(t, notify)
 	assert.Equal(t, Live, notify.Type())
 	assert.NotNil(t, notify.Logger())
-	assert.Equal(t, test.G1, notify.GetGroupCode())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
 	assert.Equal(t, test.UID1, notify.GetUid())
 	assert.EqualValues(t, Site, notify.Site())
 
@@ -65,3 +68,81 @@ func TestLiveInfo(t *testing.T) {
 	assert.NotNil(t, m)
 
 }
+
+func TestLiveInfo_checkLiveChange(t *testing.T) {
+	var newInfo = func(roomPic, area string) *LiveInfo {
+		return &LiveInfo{
+			Nickname:   "nickname",
+			RoomId:     test.UID1,
+			RoomName:   "roomname",
+			ShowStatus: ShowStatus_Living,
+			VideoLoop:  VideoLoopStatus_Off,
+			RoomPic:    roomPic,
+			AreaName:   area,
+		}
+	}
+	old := newInfo("p1", "a1")
+
+	l := newInfo("p1", "a1")
+	l.checkLiveChange(old)
+	assert.False(t, l.CoverChanged())
+	assert.False(t, l.AreaChanged())
+
+	l = newInfo("p2", "a1")
+	l.checkLiveChange(old)
+	assert.True(t, l.CoverChanged())
+	assert.False(t, l.AreaChanged())
+	assert.Contains(t, msgstringer.MsgToString(l.GetMSG().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements), "更换了封面")
+
+	l = newInfo("p1", "a2")
+	l.checkLiveChange(old)
+	assert.False(t, l.CoverChanged())
+	assert.True(t, l.AreaChanged())
+	assert.Contains(t, msgstringer.MsgToString(l.GetMSG().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements), "切换到了【a2】分区")
+
+	// 旧状态没有保存时不比较
+	l = newInfo("p2", "a2")
+	l.checkLiveChange(newInfo("", ""))
+	assert.False(t, l.CoverChanged())
+	assert.False(t, l.AreaChanged())
+}
+
+func TestReplayAndNewsInfo(t *testing.T) {
+	r := &ReplayInfo{
+		RoomId:   test.UID1,
+		HashId:   "hash",
+		Title:    "replay",
+		Duration: 60,
+	}
+	assert.Equal(t, strconv.FormatInt(test.UID1, 10), r.GetName())
+	r.Name = test.NAME1
+	assert.Equal(t, test.NAME1, r.GetName())
+	assert.Equal(t, Replay, r.Type())
+	assert.Equal(t, Site, r.Site())
+	replayNotify := NewConcernReplayNotify(mmsg.NewGroupTargetId(test.G1), r)
+	assert.NotNil(t, replayNotify)
+	assert.NotNil(t, replayNotify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), replayNotify.GetTarget())
+	assert.Equal(t, test.UID1, replayNotify.GetUid())
+	msg := msgstringer.MsgToString(replayNotify.ToMessage().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements)
+	assert.Contains(t, msg, "发布了录播")
+	assert.Contains(t, msg, VideoPath("hash"))
+
+	n := &NewsInfo{
+		RoomId:  test.UID1,
+		Name:    test.NAME1,
+		FeedId:  "feed",
+		Content: "content",
+	}
+	assert.Equal(t, News, n.Type())
+	assert.Equal(t, Site, n.Site())
+	newsNotify := NewConcernNewsNotify(mmsg.NewGroupTargetId(test.G1), n)
+	assert.NotNil(t, newsNotify)
+	assert.NotNil(t, newsNotify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), newsNotify.GetTarget())
+	msg = msgstringer.MsgToString(newsNotify.ToMessage().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements)
+	assert.Contains(t, msg, "发布了鱼吧帖子")
+	assert.Contains(t, msg, "content")
+	assert.Contains(t, msg, YubaPostPath("feed"))
+	assert.NotContains(t, msg, "【")
+}
@@ -0,0 +1,58 @@
+package douyu
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"time"
+)
+
+const (
+	PathSearchUser = "/japi/search/api/searchUser"
+)
+
+type SearchUserResponse struct {
+	Error int    `json:"error"`
+	Msg   string `json:"msg"`
+	Data  struct {
+		RelateUser []struct {
+			AnchorInfo struct {
+				Rid           int64  `json:"rid"`
+				NickName      string `json:"nickName"`
+				FollowerCount int64  `json:"followerCount"`
+			} `json:"anchorInfo"`
+		} `json:"relateUser"`
+	} `json:"data"`
+}
+
+// SearchUser 使用斗鱼的搜索接口按关键字搜索主播
+func SearchUser(keyword string) (*SearchUserResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 10),
+		requests.AddRandomUAOption(requests.Computer),
+		requests.RetryOption(3),
+	}
+	resp := new(SearchUserResponse)
+	err := requests.Get(DouyuPath(PathSearchUser), gout.H{
+		"kw":         keyword,
+		"page":       1,
+		"pageSize":   20,
+		"filterType": 0,
+	}, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != 0 {
+		return nil, fmt.Errorf("SearchUser failed %v - %v", resp.Error, resp.Msg)
+	}
+	return resp, nil
+}
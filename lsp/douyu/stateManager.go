@@ -4,6 +4,9 @@ import (
 	"errors"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/recorder"
+	"strconv"
 	"time"
 )
 
@@ -14,13 +17,25 @@ type StateManager struct {
 
 func (c *StateManager) GetLiveInfo(id int64) (*LiveInfo, error) {
 	var liveInfo = &LiveInfo{}
-	err := c.GetJson(c.CurrentLiveKey(id), liveInfo)
+	err := c.GetJson(c.CurrentLiveKey(id), liveInfo, localdb.GetCacheOpt())
 	if err != nil {
 		return nil, err
 	}
 	return liveInfo, nil
 }
 
+// GetState 实现 concern.StateExt
+func (c *StateManager) GetState(id interface{}) *concern.State {
+	liveInfo, err := c.GetLiveInfo(id.(int64))
+	if err != nil {
+		return nil
+	}
+	return &concern.State{
+		Living: liveInfo.Living(),
+		Title:  liveInfo.RoomName,
+	}
+}
+
 func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
 	if liveInfo == nil {
 		return errors.New("nil LiveInfo")
@@ -28,13 +43,43 @@ func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
 	return c.SetJson(c.CurrentLiveKey(liveInfo.RoomId), liveInfo, localdb.SetExpireOpt(time.Hour*24*7))
 }
 
+// DeleteLiveInfo 删除直播信息，同时会停止正在进行的录制
 func (c *StateManager) DeleteLiveInfo(id int64) error {
+	recorder.Stop(Site, strconv.FormatInt(id, 10))
 	_, err := c.Delete(c.CurrentLiveKey(id))
 	return err
 }
 
-func (c *StateManager) GetGroupConcernConfig(groupCode int64, id interface{}) (concernConfig concern.IConfig) {
-	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(groupCode, id))
+// GetLastReplayTime 返回上一次推送的录播的发布时间，从来没有查询过时返回 localdb.ErrNotFound
+func (c *StateManager) GetLastReplayTime(id int64) (int64, error) {
+	return c.GetInt64(c.LastReplayKey(id))
+}
+
+func (c *StateManager) SetLastReplayTime(id int64, ts int64) error {
+	return c.SetInt64(c.LastReplayKey(id), ts)
+}
+
+func (c *StateManager) DeleteLastReplayTime(id int64) error {
+	_, err := c.Delete(c.LastReplayKey(id), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// GetLastNewsTime 返回上一次推送的鱼吧帖子的发布时间，从来没有查询过时返回 localdb.ErrNotFound
+func (c *StateManager) GetLastNewsTime(id int64) (int64, error) {
+	return c.GetInt64(c.LastNewsKey(id))
+}
+
+func (c *StateManager) SetLastNewsTime(id int64, ts int64) error {
+	return c.SetInt64(c.LastNewsKey(id), ts)
+}
+
+func (c *StateManager) DeleteLastNewsTime(id int64) error {
+	_, err := c.Delete(c.LastNewsKey(id), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
 }
 
 func NewStateManager(notify chan<- concern.Notify) *StateManager {
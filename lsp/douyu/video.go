@@ -0,0 +1,119 @@
+package douyu
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"strings"
+	"time"
+)
+
+type authorVideoResponse struct {
+	Error int    `json:"error"`
+	Msg   string `json:"msg"`
+	Data  struct {
+		List []struct {
+			HashId    string `json:"hash_id"`
+			Title     string `json:"title"`
+			VideoPic  string `json:"video_pic"`
+			Duration  int64  `json:"video_duration"`
+			StartTime int64  `json:"start_time"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+type yubaFeedResponse struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+	Data       struct {
+		List []struct {
+			FeedId    string `json:"feed_id"`
+			Title     string `json:"title"`
+			Content   string `json:"content"`
+			CreatedAt int64  `json:"created_at"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+func videoApiOptions() []requests.Option {
+	return []requests.Option{
+		requests.AddRandomUAOption(requests.Computer),
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.RetryOption(3),
+		requests.TimeoutOption(time.Second * 10),
+	}
+}
+
+// AuthorVideo 查询主播最近发布的录播，upId为 LiveInfo.UpId
+func AuthorVideo(roomId int64, upId string) ([]*ReplayInfo, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(authorVideoResponse)
+	err := requests.Get(VodApi, gout.H{
+		"up_id": upId,
+		"page":  1,
+		"limit": 10,
+	}, resp, videoApiOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != 0 {
+		return nil, fmt.Errorf("AuthorVideo failed %v - %v", resp.Error, resp.Msg)
+	}
+	var result []*ReplayInfo
+	for _, video := range resp.Data.List {
+		if len(video.HashId) == 0 {
+			return nil, errors.New("empty hash_id")
+		}
+		result = append(result, &ReplayInfo{
+			RoomId:      roomId,
+			HashId:      video.HashId,
+			Title:       strings.TrimSpace(video.Title),
+			Cover:       video.VideoPic,
+			Duration:    video.Duration,
+			PublishTime: video.StartTime,
+		})
+	}
+	return result, nil
+}
+
+// YubaFeed 查询主播最近在鱼吧发布的帖子，ownerUid为 LiveInfo.OwnerUid
+func YubaFeed(roomId int64, ownerUid int64) ([]*NewsInfo, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(yubaFeedResponse)
+	err := requests.Get(YubaApi, gout.H{
+		"uid":  ownerUid,
+		"page": 1,
+	}, resp, videoApiOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("YubaFeed failed %v - %v", resp.StatusCode, resp.Message)
+	}
+	var result []*NewsInfo
+	for _, feed := range resp.Data.List {
+		if len(feed.FeedId) == 0 {
+			return nil, errors.New("empty feed_id")
+		}
+		result = append(result, &NewsInfo{
+			RoomId:      roomId,
+			FeedId:      feed.FeedId,
+			Title:       strings.TrimSpace(feed.Title),
+			Content:     strings.TrimSpace(feed.Content),
+			PublishTime: feed.CreatedAt,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package douyu
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testUpId     = "up"
+	testOwnerUid = 100
+)
+
+func newVideoApiServer(t *testing.T, replay *string, news *string) func() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/vod":
+			assert.Equal(t, testUpId, r.URL.Query().Get("up_id"))
+			fmt.Fprint(w, *replay)
+		case "/yuba":
+			assert.Equal(t, fmt.Sprint(testOwnerUid), r.URL.Query().Get("uid"))
+			fmt.Fprint(w, *news)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	var oldVod, oldYuba = VodApi, YubaApi
+	VodApi = ts.URL + "/vod"
+	YubaApi = ts.URL + "/yuba"
+	return func() {
+		VodApi, YubaApi = oldVod, oldYuba
+		ts.Close()
+	}
+}
+
+func TestAuthorVideoAndYubaFeed(t *testing.T) {
+	var replay = `{"error":0,"data":{"list":[{"hash_id":"h1","title":" title ","video_pic":"pic","video_duration":3600,"start_time":1700000000}]}}`
+	var news = `{"status_code":200,"data":{"list":[{"feed_id":"f1","title":"title","content":" content ","created_at":1700000000}]}}`
+	defer newVideoApiServer(t, &replay, &news)()
+
+	replays, err := AuthorVideo(test.UID1, testUpId)
+	assert.Nil(t, err)
+	assert.Len(t, replays, 1)
+	assert.EqualValues(t, &ReplayInfo{
+		RoomId:      test.UID1,
+		HashId:      "h1",
+		Title:       "title",
+		Cover:       "pic",
+		Duration:    3600,
+		PublishTime: 1700000000,
+	}, replays[0])
+
+	feeds, err := YubaFeed(test.UID1, testOwnerUid)
+	assert.Nil(t, err)
+	assert.Len(t, feeds, 1)
+	assert.Equal(t, "f1", feeds[0].FeedId)
+	assert.Equal(t, "content", feeds[0].Content)
+
+	replay = `{"error":1,"msg":"error"}`
+	_, err = AuthorVideo(test.UID1, testUpId)
+	assert.NotNil(t, err)
+	news = `{"status_code":500,"message":"error"}`
+	_, err = YubaFeed(test.UID1, testOwnerUid)
+	assert.NotNil(t, err)
+}
+
+func TestConcern_FreshReplayAndNews(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var replay = `{"error":0,"data":{"list":[{"hash_id":"1","start_time":100}]}}`
+	var news = `{"status_code":200,"data":{"list":[]}}`
+	defer newVideoApiServer(t, &replay, &news)()
+
+	c := NewConcern(nil)
+	liveInfo := &LiveInfo{
+		Nickname: test.NAME1,
+		RoomId:   test.UID1,
+		UpId:     testUpId,
+		OwnerUid: testOwnerUid,
+	}
+
+	// 第一次查询只记录
+	events, err := c.freshReplay(liveInfo)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+	last, err := c.GetLastReplayTime(test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 100, last)
+
+	events, err = c.freshNews(liveInfo)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+	last, err = c.GetLastNewsTime(test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, last)
+
+	replay = `{"error":0,"data":{"list":[{"hash_id":"3","start_time":300},{"hash_id":"2","start_time":200},{"hash_id":"1","start_time":100}]}}`
+	events, err = c.freshReplay(liveInfo)
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "2", events[0].(*ReplayInfo).HashId)
+	assert.Equal(t, "3", events[1].(*ReplayInfo).HashId)
+	assert.Equal(t, test.NAME1, events[1].(*ReplayInfo).GetName())
+	assert.Len(t, c.notifyGenerator()(mmsg.NewGroupTargetId(test.G1), events[0]), 1)
+
+	events, err = c.freshReplay(liveInfo)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	news = `{"status_code":200,"data":{"list":[{"feed_id":"f1","created_at":50}]}}`
+	events, err = c.freshNews(liveInfo)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "f1", events[0].(*NewsInfo).FeedId)
+	assert.Len(t, c.notifyGenerator()(mmsg.NewGroupTargetId(test.G1), events[0]), 1)
+
+	// 没有查询到主播的id时跳过
+	events, err = c.freshNews(&LiveInfo{RoomId: test.UID2})
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	assert.Nil(t, c.DeleteLastReplayTime(test.UID1))
+	assert.Nil(t, c.DeleteLastNewsTime(test.UID1))
+	_, err = c.GetLastReplayTime(test.UID1)
+	assert.NotNil(t, err)
+}
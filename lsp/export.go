@@ -0,0 +1,260 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var exportLog = logrus.WithField("module", "export")
+
+// exportDir 导出文件的保存目录
+const exportDir = "export"
+
+// ConcernDocumentVersion 导出文件的格式版本，版本2开始使用 target 保存推送目标
+const ConcernDocumentVersion = 2
+
+// ConcernDocument 导出的订阅，可以导入到另一个bot的数据库中
+type ConcernDocument struct {
+	Version    int                `json:"version"`
+	ExportTime string             `json:"export_time"`
+	Concerns   []*ExportedConcern `json:"concerns"`
+}
+
+// ExportedConcern 一个推送目标的一个订阅以及它的配置，id统一保存为string，导入时使用 concern.Concern 的 ParseId 解析
+type ExportedConcern struct {
+	Site string `json:"site"`
+	// GroupCode 推送目标是QQ群时为群号码，版本1的导出文件只有这个字段
+	GroupCode int64 `json:"group_code,omitempty"`
+	// Target 推送目标，格式与 mmsg.TargetId.Key 相同
+	Target string                      `json:"target,omitempty"`
+	Id     string                      `json:"id"`
+	Type   string                      `json:"type"`
+	Config *concern.GroupConcernConfig `json:"config,omitempty"`
+}
+
+// ImportResult 导入的结果
+type ImportResult struct {
+	Added   int
+	Skipped int
+	Failed  []string
+}
+
+// ExportConcern 导出订阅，groupCode为0时导出所有群的订阅
+func ExportConcern(groupCode int64) (*ConcernDocument, error) {
+	var doc = &ConcernDocument{
+		Version:    ConcernDocumentVersion,
+		ExportTime: time.Now().Format(time.RFC3339),
+		Concerns:   make([]*ExportedConcern, 0),
+	}
+	for _, cm := range concern.ListConcern() {
+		sm := cm.GetStateManager()
+		targets, ids, ctypes, err := sm.ListConcernState(func(target mmsg.TargetId, _ interface{}, _ concern_type.Type) bool {
+			return groupCode == 0 || target == mmsg.NewGroupTargetId(groupCode)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v ListConcernState error %v", cm.Site(), err)
+		}
+		for index := range ids {
+			config := sm.GetGroupConcernConfig(targets[index], ids[index])
+			doc.Concerns = append(doc.Concerns, &ExportedConcern{
+				Site:      cm.Site(),
+				GroupCode: targets[index].GroupCode(),
+				Target:    targets[index].Key(),
+				Id:        fmt.Sprint(ids[index]),
+				Type:      ctypes[index].String(),
+				Config: &concern.GroupConcernConfig{
+					GroupConcernAt:       *config.GetGroupConcernAt(),
+					GroupConcernNotify:   *config.GetGroupConcernNotify(),
+					GroupConcernFilter:   *config.GetGroupConcernFilter(),
+					GroupConcernTemplate: *config.GetGroupConcernTemplate(),
+				},
+			})
+		}
+	}
+	return doc, nil
+}
+
+// ImportConcern 导入订阅，订阅会通过 concern.Concern 的 Add 添加，已经存在的订阅会跳过，
+// 导出文件中的配置会覆盖当前的配置
+func (l *Lsp) ImportConcern(doc *ConcernDocument) (*ImportResult, error) {
+	if doc == nil {
+		return nil, errors.New("nil ConcernDocument")
+	}
+	if doc.Version > ConcernDocumentVersion {
+		return nil, fmt.Errorf("不支持的导出文件版本 %v", doc.Version)
+	}
+	var result = new(ImportResult)
+	for _, c := range doc.Concerns {
+		target, err := c.target()
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%v %v %v %v - %v", c.Target, c.Site, c.Id, c.Type, err))
+			continue
+		}
+		log := exportLog.WithFields(mmsg.TargetLogFields(target)).
+			WithField("site", c.Site).WithField("id", c.Id).WithField("type", c.Type)
+		if err := l.importConcern(target, c, log); err != nil {
+			if err == concern.ErrAlreadyExists {
+				result.Skipped++
+				continue
+			}
+			log.Errorf("导入订阅失败 %v", err)
+			result.Failed = append(result.Failed, fmt.Sprintf("%v %v %v %v - %v", target, c.Site, c.Id, c.Type, err))
+			continue
+		}
+		result.Added++
+	}
+	return result, nil
+}
+
+// target 返回订阅的推送目标，版本1的导出文件中私聊与Telegram订阅编码在 GroupCode 中
+func (c *ExportedConcern) target() (mmsg.TargetId, error) {
+	if len(c.Target) > 0 {
+		return mmsg.ParseTargetId(c.Target)
+	}
+	return legacyTargetId(c.GroupCode), nil
+}
+
+func (l *Lsp) importConcern(target mmsg.TargetId, c *ExportedConcern, log *logrus.Entry) error {
+	cm, err := concern.GetConcernBySite(c.Site)
+	if err != nil {
+		return err
+	}
+	id, err := cm.ParseId(c.Id)
+	if err != nil {
+		return err
+	}
+	ctype := concern_type.FromString(c.Type)
+	if ctype.Empty() {
+		return concern.ErrTypeNotSupported
+	}
+	if l.LspStateManager.CheckBlocklistId(cm.Site(), id) {
+		return errors.New("该id已被管理员禁止订阅")
+	}
+	var added bool
+	for _, t := range ctype.Split() {
+		if _, err = concern.GetConcernBySiteAndType(c.Site, t); err != nil {
+			return err
+		}
+		_, err = cm.Add(newLogMessageContext(l, target, log), target, id, t)
+		if err == concern.ErrAlreadyExists {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		added = true
+	}
+	if c.Config != nil {
+		sm := cm.GetStateManager()
+		err = sm.OperateGroupConcernConfig(target, id, sm.GetGroupConcernConfig(target, id), func(config concern.IConfig) bool {
+			*config.GetGroupConcernAt() = c.Config.GroupConcernAt
+			*config.GetGroupConcernNotify() = c.Config.GroupConcernNotify
+			*config.GetGroupConcernFilter() = c.Config.GroupConcernFilter
+			*config.GetGroupConcernTemplate() = c.Config.GroupConcernTemplate
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("导入配置失败 %v", err)
+		}
+	}
+	if !added {
+		return concern.ErrAlreadyExists
+	}
+	return nil
+}
+
+// MarshalConcernDocument 按照文件后缀把 ConcernDocument 编码为JSON或者YAML，默认为JSON
+func MarshalConcernDocument(doc *ConcernDocument, path string) ([]byte, error) {
+	if isYamlPath(path) {
+		return yaml.Marshal(doc)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// UnmarshalConcernDocument 按照文件后缀把JSON或者YAML解码为 ConcernDocument ，默认为JSON
+func UnmarshalConcernDocument(data []byte, path string) (*ConcernDocument, error) {
+	var doc = new(ConcernDocument)
+	var err error
+	if isYamlPath(path) {
+		err = yaml.Unmarshal(data, doc)
+	} else {
+		err = json.Unmarshal(data, doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func isYamlPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// doExport 导出订阅并保存到 exportDir 目录，返回文件路径
+func doExport(groupCode int64, format string) (string, error) {
+	doc, err := ExportConcern(groupCode)
+	if err != nil {
+		return "", err
+	}
+	var path = filepath.Join(exportDir, fmt.Sprintf("ddbot-export-%v.%v", time.Now().Format("20060102-150405"), format))
+	b, err := MarshalConcernDocument(doc, path)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(exportDir, 0755); err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(path, b, 0644); err != nil {
+		return "", err
+	}
+	exportLog.WithField("path", path).WithField("count", len(doc.Concerns)).Info("导出订阅成功")
+	return path, nil
+}
+
+// doImport 从文件中导入订阅
+func (l *Lsp) doImport(path string) (*ImportResult, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := UnmarshalConcernDocument(b, path)
+	if err != nil {
+		return nil, fmt.Errorf("解析导出文件失败 %v", err)
+	}
+	result, err := l.ImportConcern(doc)
+	if err != nil {
+		return nil, err
+	}
+	exportLog.WithField("path", path).WithField("added", result.Added).
+		WithField("skipped", result.Skipped).WithField("failed", len(result.Failed)).Info("导入订阅完成")
+	return result, nil
+}
+
+// newLogMessageContext 订阅模块的Add与Remove需要一个 mmsg.IMsgCtx，这里的回复只会记录到日志中
+func newLogMessageContext(l *Lsp, target mmsg.TargetId, log *logrus.Entry) *MessageContext {
+	ctx := NewMessageContext()
+	ctx.Lsp = l
+	ctx.Log = log
+	ctx.Target = target
+	ctx.Sender = &message.Sender{}
+	ctx.SendFunc = func(m *mmsg.MSG) interface{} {
+		log.Debugf("reply: %v", m)
+		return nil
+	}
+	ctx.ReplyFunc = ctx.SendFunc
+	ctx.NoPermissionReplyFunc = func() interface{} { return nil }
+	ctx.DisabledReply = ctx.NoPermissionReplyFunc
+	ctx.GlobalDisabledReply = ctx.NoPermissionReplyFunc
+	return ctx
+}
@@ -0,0 +1,109 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportAndImportConcern(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1, test.T2})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	_, err := tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1.Add(test.T2))
+	assert.Nil(t, err)
+	_, err = tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.NAME2, test.T1)
+	assert.Nil(t, err)
+	err = tc1.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1, tc1.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1), func(config concern.IConfig) bool {
+		config.GetGroupConcernAt().SetAtSomeoneList(test.T1, []int64{test.UID1})
+		return true
+	})
+	assert.Nil(t, err)
+
+	doc, err := ExportConcern(0)
+	assert.Nil(t, err)
+	assert.Len(t, doc.Concerns, 2)
+
+	doc, err = ExportConcern(test.G1)
+	assert.Nil(t, err)
+	assert.Len(t, doc.Concerns, 1)
+	assert.Equal(t, test.Site1, doc.Concerns[0].Site)
+	assert.Equal(t, test.NAME1, doc.Concerns[0].Id)
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1).Key(), doc.Concerns[0].Target)
+
+	for _, path := range []string{"export.json", "export.yaml"} {
+		doc, err = ExportConcern(0)
+		assert.Nil(t, err)
+		b, err := MarshalConcernDocument(doc, path)
+		assert.Nil(t, err)
+		path = filepath.Join(t.TempDir(), path)
+		assert.Nil(t, os.WriteFile(path, b, 0644))
+
+		_, err = tc1.RemoveAllByGroupCode(mmsg.NewGroupTargetId(test.G1))
+		assert.Nil(t, err)
+		_, err = tc1.RemoveAllByGroupCode(mmsg.NewGroupTargetId(test.G2))
+		assert.Nil(t, err)
+
+		result, err := Instance.doImport(path)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, result.Added)
+		assert.Equal(t, 0, result.Skipped)
+		assert.Empty(t, result.Failed)
+
+		ctype, err := tc1.GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+		assert.Nil(t, err)
+		assert.True(t, ctype.ContainAll(test.T1.Add(test.T2)))
+		ctype, err = tc1.GetGroupConcern(mmsg.NewGroupTargetId(test.G2), test.NAME2)
+		assert.Nil(t, err)
+		assert.Equal(t, test.T1, ctype)
+		assert.EqualValues(t, []int64{test.UID1},
+			tc1.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1).GetGroupConcernAt().GetAtSomeoneList(test.T1))
+
+		// 再次导入时跳过已经存在的订阅
+		result, err = Instance.doImport(path)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, result.Added)
+		assert.Equal(t, 2, result.Skipped)
+	}
+
+	result, err := Instance.ImportConcern(&ConcernDocument{
+		Version: ConcernDocumentVersion,
+		Concerns: []*ExportedConcern{
+			{Site: "unknown", GroupCode: test.G1, Id: test.NAME1, Type: test.T1.String()},
+			{Site: test.Site1, GroupCode: test.G1, Id: test.NAME1, Type: ""},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, result.Failed, 2)
+
+	// 版本1的导出文件中私聊订阅编码在 group_code 中
+	result, err = Instance.ImportConcern(&ConcernDocument{
+		Version: 1,
+		Concerns: []*ExportedConcern{
+			{Site: test.Site1, GroupCode: -test.UID1, Id: test.NAME1, Type: test.T1.String()},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Added)
+	ctype, err := tc1.GetGroupConcern(mmsg.NewPrivateTargetId(test.UID1), test.NAME1)
+	assert.Nil(t, err)
+	assert.Equal(t, test.T1, ctype)
+
+	_, err = Instance.ImportConcern(&ConcernDocument{Version: ConcernDocumentVersion + 1})
+	assert.NotNil(t, err)
+
+	_, err = UnmarshalConcernDocument([]byte("wrong"), "export.json")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,271 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/message"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// failedPushExpire 发送失败的推送保存的时间，过期后不会再重试
+	failedPushExpire = time.Hour * 24 * 7
+	// failedPushRetryInterval 检查禁言是否已经解除的间隔
+	failedPushRetryInterval = time.Minute
+	// failedPushPreviewLength 查看发送失败的推送时，每条推送最多显示的字数
+	failedPushPreviewLength = 30
+)
+
+const (
+	// FailedReasonMuted BOT在群内被禁言，禁言解除后会自动重试
+	FailedReasonMuted = "BOT被禁言"
+	// FailedReasonSend 消息被拒绝或者被风控，需要使用 /resend 手动重试
+	FailedReasonSend = "发送失败，可能是消息被拒绝或者被风控"
+)
+
+var (
+	ErrFailedPushNotFound      = errors.New("发送失败的推送不存在")
+	ErrFailedPushGroupNotFound = errors.New("BOT已经不在推送的群内")
+)
+
+// FailedPush 发送失败的推送，保存推送内容以及失败原因，可以重新发送
+type FailedPush struct {
+	Id     int64           `json:"id"`
+	Target mmsg.TargetId   `json:"target"`
+	Site   string          `json:"site"`
+	Reason string          `json:"reason"`
+	Time   int64           `json:"time"`
+	Record *pushItemRecord `json:"record"`
+}
+
+// Preview 返回推送中的文字内容，最多 failedPushPreviewLength 个字
+func (f *FailedPush) Preview() string {
+	var sb strings.Builder
+	for _, e := range f.Record.Elements {
+		switch e.Type {
+		case pushElementText:
+			sb.WriteString(e.Content)
+		case pushElementImage:
+			sb.WriteString("[图片]")
+		}
+	}
+	preview := []rune(strings.Join(strings.Fields(sb.String()), " "))
+	if len(preview) > failedPushPreviewLength {
+		return string(preview[:failedPushPreviewLength]) + "..."
+	}
+	return string(preview)
+}
+
+// SaveFailedPush 保存一条发送失败的推送并分配id
+func (s *StateManager) SaveFailedPush(failed *FailedPush) error {
+	return s.RWCover(func() error {
+		id, err := s.SeqNext(s.FailedPushSeqKey())
+		if err != nil {
+			return err
+		}
+		failed.Id = id
+		if failed.Time == 0 {
+			failed.Time = time.Now().Unix()
+		}
+		return s.SetJson(s.FailedPushKey(failed.Target, failed.Id), failed, localdb.SetExpireOpt(failedPushExpire))
+	})
+}
+
+// failedPushKey 按id查找发送失败的推送的key，不存在时返回 ErrFailedPushNotFound
+func (s *StateManager) failedPushKey(id int64) (result string, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(s.FailedPushKey("*", id), func(key, value string) bool {
+			result = key
+			return false
+		})
+	})
+	if err == nil && result == "" {
+		err = ErrFailedPushNotFound
+	}
+	return
+}
+
+// GetFailedPush 返回一条发送失败的推送，不存在时返回 ErrFailedPushNotFound
+func (s *StateManager) GetFailedPush(id int64) (*FailedPush, error) {
+	key, err := s.failedPushKey(id)
+	if err != nil {
+		return nil, err
+	}
+	var failed = new(FailedPush)
+	err = s.GetJson(key, failed)
+	if localdb.IsNotFound(err) {
+		return nil, ErrFailedPushNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return failed, nil
+}
+
+// DeleteFailedPush 删除一条发送失败的推送，不存在时返回 ErrFailedPushNotFound
+func (s *StateManager) DeleteFailedPush(id int64) error {
+	return s.RWCover(func() error {
+		key, err := s.failedPushKey(id)
+		if err != nil {
+			return err
+		}
+		_, err = s.Delete(key)
+		if localdb.IsNotFound(err) {
+			return ErrFailedPushNotFound
+		}
+		return err
+	})
+}
+
+// ListFailedPush 按id从新到旧返回所有发送失败的推送
+func (s *StateManager) ListFailedPush() (results []*FailedPush, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(s.FailedPushKey("*"), func(key, value string) bool {
+			var failed = new(FailedPush)
+			if iterErr = json.Unmarshal([]byte(value), failed); iterErr != nil {
+				return false
+			}
+			results = append(results, failed)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Id > results[j].Id
+	})
+	return
+}
+
+// recordFailedPush 保存发送失败的推送，@全体成员不会保存，避免重新发送时再次@全体成员
+func (l *Lsp) recordFailedPush(target mmsg.TargetId, site string, reason string, m *mmsg.MSG) {
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("Reason", reason)
+	record := newPushItemRecord(&PushItem{
+		Target:   target,
+		Priority: PushPriorityNormal,
+		MSG:      m,
+	})
+	var elements []*pushElementRecord
+	for _, e := range record.Elements {
+		if e.Type == pushElementAt && e.Target == 0 {
+			continue
+		}
+		elements = append(elements, e)
+	}
+	record.Elements = elements
+	failed := &FailedPush{
+		Target: target,
+		Site:   site,
+		Reason: reason,
+		Record: record,
+	}
+	if err := l.LspStateManager.SaveFailedPush(failed); err != nil {
+		log.Errorf("SaveFailedPush error %v", err)
+		return
+	}
+	log.WithField("FailedId", failed.Id).Info("推送发送失败，已保存")
+}
+
+// failedPushTargetExist 推送的目标是群时，检查BOT是否还在群内
+func failedPushTargetExist(failed *FailedPush) bool {
+	return !failed.Target.TargetType().IsGroup() || localutils.GetBot().FindGroup(failed.Target.TargetCode()) != nil
+}
+
+// ResendFailedPush 把发送失败的推送重新加入推送队列，再次失败时会重新保存，
+// BOT已经不在推送的群内时返回 ErrFailedPushGroupNotFound ，退群后记录会被 PurgeGroup 清除
+func (l *Lsp) ResendFailedPush(id int64) error {
+	failed, err := l.LspStateManager.GetFailedPush(id)
+	if err != nil {
+		return err
+	}
+	if !failedPushTargetExist(failed) {
+		return ErrFailedPushGroupNotFound
+	}
+	if err = l.LspStateManager.DeleteFailedPush(id); err != nil {
+		return err
+	}
+	logger.WithFields(mmsg.TargetLogFields(failed.Target)).
+		WithField("FailedId", id).Info("重新发送失败的推送")
+	item := failed.Record.toPushItem()
+	l.pushQueue.Push(&PushItem{
+		Target:   failed.Target,
+		Site:     failed.Site,
+		Priority: failed.Record.Priority,
+		MSG:      item.MSG,
+		Callback: func(msgs []*message.GroupMessage) {
+			if len(msgs) == 0 || msgs[0].Id == -1 {
+				l.recordFailedPush(failed.Target, failed.Site, FailedReasonSend, item.MSG)
+			}
+		},
+	})
+	return nil
+}
+
+// restoredPushCallback 重启后恢复的推送没有原来的 Callback ，发送失败时同样记录为发送失败的推送
+func (l *Lsp) restoredPushCallback(item *PushItem, msgs []*message.GroupMessage) {
+	if len(msgs) == 0 || msgs[0].Id == -1 {
+		l.recordFailedPush(item.Target, item.Site, FailedReasonSend, item.MSG)
+	}
+}
+
+// FailedPushRetry 定期检查因为禁言而发送失败的推送，禁言解除后自动重新发送
+func (l *Lsp) FailedPushRetry() {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).Errorf("failed push retry recoverd %v", err)
+			go l.FailedPushRetry()
+		}
+	}()
+	ticker := time.NewTicker(failedPushRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.retryMutedFailedPush()
+		}
+	}
+}
+
+func (l *Lsp) retryMutedFailedPush() {
+	records, err := l.LspStateManager.ListFailedPush()
+	if err != nil {
+		logger.Errorf("ListFailedPush error %v", err)
+		return
+	}
+	// 按从旧到新的顺序重新发送
+	for i := len(records) - 1; i >= 0; i-- {
+		failed := records[i]
+		if failed.Reason != FailedReasonMuted || !failedPushTargetExist(failed) || l.isGroupMuted(failed.Target.GroupCode()) {
+			continue
+		}
+		if err := l.ResendFailedPush(failed.Id); err != nil && err != ErrFailedPushNotFound {
+			logger.WithFields(mmsg.TargetLogFields(failed.Target)).
+				WithField("FailedId", failed.Id).Errorf("ResendFailedPush error %v", err)
+		}
+	}
+}
+
+// formatFailedPush 返回 /failed 命令的回复内容
+func formatFailedPush(records []*FailedPush) string {
+	if len(records) == 0 {
+		return "当前没有发送失败的推送"
+	}
+	var sb strings.Builder
+	sb.WriteString("发送失败的推送：")
+	for _, failed := range records {
+		sb.WriteString(fmt.Sprintf("\n[%v] %v %v %v - %v\n%v",
+			failed.Id, localutils.TimestampFormat(failed.Time), failed.Target.Name(), failed.Site, failed.Reason, failed.Preview()))
+	}
+	return sb.String()
+}
@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateManager_FailedPush(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	records, err := sm.ListFailedPush()
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	for _, text := range []string{"a", "b"} {
+		assert.Nil(t, sm.SaveFailedPush(&FailedPush{
+			Target: mmsg.NewGroupTargetId(test.G1),
+			Site:   test.Site1,
+			Reason: FailedReasonSend,
+			Record: newPushItemRecord(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewText(text)}),
+		}))
+	}
+	records, err = sm.ListFailedPush()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.EqualValues(t, 2, records[0].Id)
+	assert.Equal(t, "b", records[0].Preview())
+	assert.NotZero(t, records[0].Time)
+
+	failed, err := sm.GetFailedPush(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", failed.Preview())
+	// id为1的推送不会匹配到id为11的推送
+	assert.Nil(t, sm.SetJson(sm.FailedPushKey(test.G2, 11), &FailedPush{
+		Id:     11,
+		Target: mmsg.NewGroupTargetId(test.G2),
+		Record: newPushItemRecord(&PushItem{Target: mmsg.NewGroupTargetId(test.G2), MSG: mmsg.NewText("c")}),
+	}))
+	assert.Nil(t, sm.DeleteFailedPush(1))
+	assert.Equal(t, ErrFailedPushNotFound, sm.DeleteFailedPush(1))
+	_, err = sm.GetFailedPush(1)
+	assert.Equal(t, ErrFailedPushNotFound, err)
+
+	// 退群后清除群内的记录
+	assert.Contains(t, sm.GroupKeyPrefix(test.G1), sm.FailedPushKey(test.G1))
+	_, err = localdb.RemoveByKeyPrefix(sm.GroupKeyPrefix(test.G1), false)
+	assert.Nil(t, err)
+	remain, err := sm.ListFailedPush()
+	assert.Nil(t, err)
+	if assert.Len(t, remain, 1) {
+		assert.EqualValues(t, mmsg.NewGroupTargetId(test.G2), remain[0].Target)
+	}
+
+	long := &FailedPush{Record: newPushItemRecord(&PushItem{
+		MSG: mmsg.NewText(strings.Repeat("啊", 40)).Image([]byte{1}, ""),
+	})}
+	assert.Equal(t, strings.Repeat("啊", failedPushPreviewLength)+"...", long.Preview())
+
+	assert.Equal(t, "当前没有发送失败的推送", formatFailedPush(nil))
+	assert.Contains(t, formatFailedPush(records[:1]), "[2]")
+	assert.Contains(t, formatFailedPush(records[:1]), FailedReasonSend)
+}
+
+func TestLsp_FailedPush(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	l := &Lsp{
+		LspStateManager: newStateManager(t),
+		accounts:        NewAccountPool(new(mainAccount)),
+	}
+	l.pushQueue = newTestPushQueue(t, sender)
+	l.pushQueue.Start()
+	defer l.pushQueue.Stop()
+
+	defer localutils.GetBot().TESTReset()
+	localutils.GetBot().TESTAddGroup(test.G1)
+
+	var uin = localutils.GetBot().GetUin()
+	assert.Nil(t, l.LspStateManager.Muted(test.G1, uin, 3600))
+
+	// @全体成员不会保存
+	l.recordFailedPush(mmsg.NewGroupTargetId(test.G1), test.Site1, FailedReasonMuted, newAtAllMsg(mmsg.NewText("a")))
+	l.recordFailedPush(mmsg.NewGroupTargetId(test.G2), test.Site1, FailedReasonSend, mmsg.NewText("b"))
+
+	// 还在禁言中，不重新发送
+	l.retryMutedFailedPush()
+	records, err := l.LspStateManager.ListFailedPush()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Nil(t, l.LspStateManager.Muted(test.G1, uin, 0))
+	l.retryMutedFailedPush()
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, "a", sender.Result()[0])
+
+	// 不是因为禁言而失败的推送需要手动重新发送
+	records, err = l.LspStateManager.ListFailedPush()
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.EqualValues(t, mmsg.NewGroupTargetId(test.G2), records[0].Target)
+
+	// BOT不在群内时不重新发送
+	assert.Equal(t, ErrFailedPushGroupNotFound, l.ResendFailedPush(records[0].Id))
+	localutils.GetBot().TESTAddGroup(test.G2)
+
+	sender.mu.Lock()
+	sender.fail[test.G2] = 10
+	sender.mu.Unlock()
+	assert.Nil(t, l.ResendFailedPush(records[0].Id))
+	assert.Equal(t, ErrFailedPushNotFound, l.ResendFailedPush(records[0].Id))
+	// 再次发送失败时重新保存
+	assert.Eventually(t, func() bool {
+		records, err = l.LspStateManager.ListFailedPush()
+		return err == nil && len(records) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, "b", records[0].Preview())
+}
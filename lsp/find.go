@@ -0,0 +1,147 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"strings"
+	"sync"
+)
+
+const (
+	// findLocalLimit /find 最多显示的已订阅账号数量
+	findLocalLimit = 10
+	// findSiteLimit /find 每个网站最多显示的搜索结果数量
+	findSiteLimit = 5
+)
+
+// findSiteResult 一个网站的搜索结果
+type findSiteResult struct {
+	site   string
+	result []*concern.SearchResult
+	err    error
+}
+
+// findLocal 在已经订阅的账号中查找名字包含keyword或者id等于keyword的账号
+func findLocal(concerns []concern.Concern, keyword string) (sites []string, result []*concern.SearchResult) {
+	lowerKeyword := strings.ToLower(keyword)
+	for _, cm := range concerns {
+		_, ids, ctypes, err := cm.GetStateManager().ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+			return true
+		})
+		if err != nil {
+			logger.WithField("site", cm.Site()).Errorf("ListConcernState error %v", err)
+			continue
+		}
+		ids, _, err = cm.GetStateManager().GroupTypeById(ids, ctypes)
+		if err != nil {
+			logger.WithField("site", cm.Site()).Errorf("GroupTypeById error %v", err)
+			continue
+		}
+		for _, id := range ids {
+			var name string
+			if info, err := cm.Get(id); err == nil && info != nil {
+				name = info.GetName()
+			}
+			if fmt.Sprint(id) != keyword && !strings.Contains(strings.ToLower(name), lowerKeyword) {
+				continue
+			}
+			sites = append(sites, cm.Site())
+			result = append(result, &concern.SearchResult{Id: id, Name: name, Followers: -1})
+			if len(result) >= findLocalLimit {
+				return
+			}
+		}
+	}
+	return
+}
+
+// findSites 同时使用所有实现了 concern.SearchExt 的网站搜索keyword
+func findSites(concerns []concern.Concern, keyword string) []*findSiteResult {
+	var results []*findSiteResult
+	var wg sync.WaitGroup
+	for _, cm := range concerns {
+		searchExt, ok := cm.(concern.SearchExt)
+		if !ok {
+			continue
+		}
+		r := &findSiteResult{site: cm.Site()}
+		results = append(results, r)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.result, r.err = searchExt.Search(keyword, findSiteLimit)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func formatSearchResult(m *mmsg.MSG, site string, r *concern.SearchResult) {
+	m.Textf("\n%v %v %v", site, r.Id, r.Name)
+	if r.Followers >= 0 {
+		m.Textf(" 粉丝数：%v", r.Followers)
+	}
+}
+
+// IFind 通过关键字查找账号，包括已经订阅的账号，以及网站搜索接口的结果，site为空时查找所有网站
+func IFind(c *MessageContext, site string, keyword string) {
+	log := c.Log
+
+	keyword = strings.TrimSpace(keyword)
+	if len(keyword) == 0 {
+		c.TextReply("失败 - 关键字不能为空")
+		return
+	}
+	var concerns = concern.ListConcern()
+	if len(site) > 0 {
+		var err error
+		site, err = concern.ParseRawSite(site)
+		if err != nil {
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+		cm, err := concern.GetConcernBySite(site)
+		if err != nil {
+			log.Errorf("GetConcernBySite error %v", err)
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+		concerns = []concern.Concern{cm}
+	}
+
+	m := mmsg.NewMSG()
+	var found bool
+	localSites, localResult := findLocal(concerns, keyword)
+	if len(localResult) > 0 {
+		found = true
+		m.Textf("已订阅的账号：")
+		for idx, r := range localResult {
+			formatSearchResult(m, localSites[idx], r)
+		}
+	}
+	for _, sr := range findSites(concerns, keyword) {
+		if sr.err != nil {
+			log.WithField("site", sr.site).Errorf("Search error %v", sr.err)
+			continue
+		}
+		if len(sr.result) == 0 {
+			continue
+		}
+		if found {
+			m.Text("\n")
+		}
+		found = true
+		m.Textf("%v搜索结果：", sr.site)
+		for _, r := range sr.result {
+			formatSearchResult(m, sr.site, r)
+		}
+	}
+	if !found {
+		c.TextReply(fmt.Sprintf("没有找到与%v有关的账号", keyword))
+		return
+	}
+	m.Textf("\n可以使用%v -s 网站 id 订阅", c.Lsp.CommandShowName(WatchCommand))
+	c.Send(m)
+}
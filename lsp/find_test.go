@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type testSearchConcern struct {
+	*tc.TestConcern
+	err error
+}
+
+func (t *testSearchConcern) Search(keyword string, n int) ([]*concern.SearchResult, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	return []*concern.SearchResult{
+		{Id: "1001", Name: keyword + "_1", Followers: 100},
+		{Id: "1002", Name: keyword + "_2", Followers: -1},
+	}, nil
+}
+
+func TestIFind(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	// IFind 只读取订阅，只需要创建索引，不需要启动刷新
+	tc1 := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	tc1.FreshIndex()
+	concern.RegisterConcern(tc1)
+	tc2 := &testSearchConcern{TestConcern: tc.NewTestConcern(nil, test.Site2, []concern_type.Type{test.T2})}
+	tc2.FreshIndex()
+	concern.RegisterConcern(tc2)
+	defer concern.ClearConcern()
+
+	_, err := tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), "hello_world", test.T1)
+	assert.Nil(t, err)
+	_, err = tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), "hello_world", test.T1)
+	assert.Nil(t, err)
+	_, err = tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), "other", test.T1)
+	assert.Nil(t, err)
+
+	IFind(ctx, "", " ")
+	assert.Contains(t, reply(), failed)
+
+	IFind(ctx, "xxx", "hello")
+	assert.Contains(t, reply(), failed)
+
+	IFind(ctx, "", "HELLO")
+	result := reply()
+	assert.Contains(t, result, "已订阅的账号")
+	assert.Equal(t, 1, strings.Count(result, test.Site1+" hello_world hello_world"))
+	assert.NotContains(t, result, "other")
+	assert.Contains(t, result, test.Site2+" 1001 HELLO_1 粉丝数：100")
+	assert.Contains(t, result, test.Site2+" 1002 HELLO_2")
+	assert.NotContains(t, result, "HELLO_2 粉丝数")
+
+	IFind(ctx, test.Site1, "other")
+	result = reply()
+	assert.Contains(t, result, test.Site1+" other other")
+	assert.NotContains(t, result, test.Site2)
+
+	tc2.err = errors.New("search error")
+	IFind(ctx, test.Site2, "nothing")
+	assert.Contains(t, reply(), "没有找到")
+}
@@ -15,12 +15,11 @@ import (
 	"github.com/Mrs4s/MiraiGo/message"
 	"github.com/Sora233/DDBOT/image_pool"
 	"github.com/Sora233/DDBOT/image_pool/lolicon_pool"
-	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/permission"
 	"github.com/Sora233/DDBOT/utils"
-	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/Sora233/sliceutil"
 	"github.com/alecthomas/kong"
 	"github.com/sirupsen/logrus"
@@ -44,10 +43,10 @@ func NewLspGroupCommand(l *Lsp, msg *message.GroupMessage) *LspGroupCommand {
 func (lgc *LspGroupCommand) DebugCheck() bool {
 	var ok bool
 	if lgc.debug {
-		if sliceutil.Contains(config.GlobalConfig.GetStringSlice("debug.group"), strconv.FormatInt(lgc.groupCode(), 10)) {
+		if sliceutil.Contains(cfg.GetDebugGroups(), strconv.FormatInt(lgc.groupCode(), 10)) {
 			ok = true
 		}
-		if sliceutil.Contains(config.GlobalConfig.GetStringSlice("debug.uin"), strconv.FormatInt(lgc.uin(), 10)) {
+		if sliceutil.Contains(cfg.GetDebugUins(), strconv.FormatInt(lgc.uin(), 10)) {
 			ok = true
 		}
 	} else {
@@ -87,6 +86,15 @@ func (lgc *LspGroupCommand) Execute() {
 		return
 	}
 
+	if !lgc.requireCooldown(lgc.CommandName()) {
+		return
+	}
+
+	if m := lgc.l.modules.GetByCommand(lgc.CommandName()); m != nil && !lgc.l.ModuleEnabled(lgc.groupCode(), m.Name) {
+		log.Debugf("module %v disabled", m.Name)
+		return
+	}
+
 	log.Debug("execute command")
 
 	switch lgc.CommandName() {
@@ -119,6 +127,22 @@ func (lgc *LspGroupCommand) Execute() {
 		if lgc.requireNotDisable(ListCommand) {
 			lgc.ListCommand()
 		}
+	case RecentCommand:
+		if lgc.requireNotDisable(RecentCommand) {
+			lgc.RecentCommand()
+		}
+	case StatsCommand:
+		if lgc.requireNotDisable(StatsCommand) {
+			lgc.StatsCommand()
+		}
+	case TagCommand:
+		if lgc.requireNotDisable(TagCommand) {
+			lgc.TagCommand()
+		}
+	case UnwatchTagCommand:
+		if lgc.requireNotDisable(UnwatchTagCommand) {
+			lgc.UnwatchTagCommand()
+		}
 	case ConfigCommand:
 		if lgc.requireNotDisable(ConfigCommand) {
 			lgc.ConfigCommand()
@@ -135,6 +159,10 @@ func (lgc *LspGroupCommand) Execute() {
 		if lgc.requireNotDisable(ScoreCommand) {
 			lgc.ScoreCommand()
 		}
+	case ScoreRankCommand:
+		if lgc.requireNotDisable(ScoreRankCommand) {
+			lgc.ScoreRankCommand()
+		}
 	case GrantCommand:
 		lgc.GrantCommand()
 	case EnableCommand:
@@ -143,6 +171,26 @@ func (lgc *LspGroupCommand) Execute() {
 		lgc.EnableCommand(true)
 	case SilenceCommand:
 		lgc.SilenceCommand()
+	case QuietCommand:
+		lgc.QuietCommand()
+	case DigestCommand:
+		lgc.DigestCommand()
+	case ReminderCommand:
+		lgc.ReminderCommand()
+	case LocaleCommand:
+		lgc.LocaleCommand()
+	case FindCommand:
+		if lgc.requireNotDisable(FindCommand) {
+			lgc.FindCommand()
+		}
+	case SubMeCommand:
+		if lgc.requireNotDisable(SubMeCommand) {
+			lgc.SubMeCommand()
+		}
+	case TrialCommand:
+		if lgc.requireNotDisable(TrialCommand) {
+			lgc.TrialCommand()
+		}
 	case ReverseCommand:
 		if lgc.requireNotDisable(ReverseCommand) {
 			lgc.ReverseCommand()
@@ -151,6 +199,10 @@ func (lgc *LspGroupCommand) Execute() {
 		if lgc.requireNotDisable(HelpCommand) {
 			lgc.HelpCommand()
 		}
+	case SearchCommand:
+		if lgc.requireEnable(SearchCommand) {
+			lgc.SearchCommand()
+		}
 	case CleanConcern:
 		if lgc.requireNotDisable(CleanConcern) {
 			if lgc.l.PermissionStateManager.RequireAny(
@@ -231,10 +283,30 @@ func (lgc *LspGroupCommand) SetuCommand(r18 bool) {
 		num = 1
 	}
 
+	if num <= 0 || num > 10 {
+		lgc.textReply("失败 - 数量范围为1-10")
+		return
+	}
+
 	if !lgc.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(lgc.uin())) {
 		if num != 1 {
-			lgc.textReply("失败 - 数量限制为1")
-			return
+			// 配置了额外图片的积分消耗时，可以使用签到积分兑换额外的数量
+			cost := cfg.GetCheckinExtraImageCost()
+			if cost <= 0 {
+				lgc.textReply("失败 - 数量限制为1")
+				return
+			}
+			cost *= int64(num - 1)
+			if _, err := lgc.l.LspStateManager.SpendScore(lgc.groupCode(), lgc.uin(), cost, lgc.CommandName()); err != nil {
+				if err == ErrScoreNotEnough {
+					lgc.textReplyF("失败 - 积分不足，额外的%v张图片需要%v积分", num-1, cost)
+				} else {
+					log.Errorf("SpendScore error %v", err)
+					lgc.textReply("失败 - 内部错误")
+				}
+				return
+			}
+			log = log.WithField("cost", cost)
 		}
 		if setuCmd.Tag != "" {
 			lgc.textReply("失败 - tag搜索已禁用")
@@ -242,11 +314,6 @@ func (lgc *LspGroupCommand) SetuCommand(r18 bool) {
 		}
 	}
 
-	if num <= 0 || num > 10 {
-		lgc.textReply("失败 - 数量范围为1-10")
-		return
-	}
-
 	var options []image_pool.OptionFunc
 	if r18 {
 		options = append(options, lolicon_pool.R18Option(lolicon_pool.R18On))
@@ -377,7 +444,7 @@ func (lgc *LspGroupCommand) SetuCommand(r18 bool) {
 
 func (lgc *LspGroupCommand) WatchCommand(remove bool) {
 	var (
-		groupCode = lgc.groupCode()
+		target    = lgc.target()
 		site      string
 		watchType = concern_type.Type("live")
 		err       error
@@ -414,11 +481,11 @@ func (lgc *LspGroupCommand) WatchCommand(remove bool) {
 
 	id := watchCmd.Id
 
-	IWatch(lgc.NewMessageContext(log), groupCode, id, site, watchType, remove)
+	IWatch(lgc.NewMessageContext(log), target, id, site, watchType, remove)
 }
 
 func (lgc *LspGroupCommand) ListCommand() {
-	groupCode := lgc.groupCode()
+	target := lgc.target()
 
 	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
 	log.Infof("run %v command", lgc.CommandName())
@@ -435,7 +502,131 @@ func (lgc *LspGroupCommand) ListCommand() {
 		return
 	}
 
-	IList(lgc.NewMessageContext(log), groupCode, listCmd.Site)
+	IList(lgc.NewMessageContext(log), target, listCmd.Site)
+}
+
+func (lgc *LspGroupCommand) RecentCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var recentCmd struct {
+		Site  string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Count int    `optional:"" short:"n" default:"3" help:"查看的动态数量"`
+		Id    string `arg:"" help:"已订阅的id"`
+	}
+	_, output := lgc.parseCommandSyntax(&recentCmd, lgc.CommandName())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	if recentCmd.Count <= 0 || recentCmd.Count > 10 {
+		lgc.textReply("失败 - 动态数量需要在1到10之间")
+		return
+	}
+
+	log = log.WithField("site", recentCmd.Site).WithField("id", recentCmd.Id)
+
+	IRecent(lgc.NewMessageContext(log), lgc.target(), recentCmd.Id, recentCmd.Site, recentCmd.Count)
+}
+
+func (lgc *LspGroupCommand) StatsCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var statsCmd struct {
+		Site string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Days int    `optional:"" short:"d" default:"7" help:"查看最近几天的统计"`
+		Id   string `arg:"" help:"已订阅的id"`
+	}
+	_, output := lgc.parseCommandSyntax(&statsCmd, lgc.CommandName(),
+		kong.Description("查看已订阅的账号最近的粉丝数、直播人气和直播时长"),
+	)
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	if statsCmd.Days <= 0 || statsCmd.Days > maxStatsDays {
+		lgc.textReply(fmt.Sprintf("失败 - 天数需要在1到%v之间", maxStatsDays))
+		return
+	}
+
+	log = log.WithField("site", statsCmd.Site).WithField("id", statsCmd.Id)
+
+	IStats(lgc.NewMessageContext(log), lgc.target(), statsCmd.Id, statsCmd.Site, statsCmd.Days)
+}
+
+func (lgc *LspGroupCommand) TagCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var tagCmd struct {
+		Add struct {
+			Site string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id   string   `arg:"" help:"已订阅的id"`
+			Tags []string `arg:"" help:"标签，可以一次填多个"`
+		} `cmd:"" help:"给订阅添加标签" name:"add"`
+		Remove struct {
+			Site string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id   string   `arg:"" help:"已订阅的id"`
+			Tags []string `arg:"" help:"标签，可以一次填多个"`
+		} `cmd:"" help:"删除订阅的标签" name:"remove"`
+		List struct {
+			Tag string `arg:"" optional:"" help:"标签，不填时列出所有标签"`
+		} `cmd:"" help:"查看标签以及标签下的订阅" name:"list"`
+	}
+	kongCtx, output := lgc.parseCommandSyntax(&tagCmd, lgc.CommandName(),
+		kong.Description("管理订阅的标签，可以使用/unwatchtag取消标签下的所有订阅，或者在/config中使用#标签代替id批量配置"),
+	)
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit || len(kongCtx.Path) <= 1 {
+		return
+	}
+
+	cmd := strings.Split(kongCtx.Command(), " ")[0]
+	log = log.WithField("sub_command", cmd)
+
+	switch cmd {
+	case "add":
+		log = log.WithField("site", tagCmd.Add.Site).WithField("id", tagCmd.Add.Id).WithField("tags", tagCmd.Add.Tags)
+		ITagAdd(lgc.NewMessageContext(log), lgc.target(), tagCmd.Add.Id, tagCmd.Add.Site, tagCmd.Add.Tags)
+	case "remove":
+		log = log.WithField("site", tagCmd.Remove.Site).WithField("id", tagCmd.Remove.Id).WithField("tags", tagCmd.Remove.Tags)
+		ITagRemove(lgc.NewMessageContext(log), lgc.target(), tagCmd.Remove.Id, tagCmd.Remove.Site, tagCmd.Remove.Tags)
+	case "list":
+		log = log.WithField("tag", tagCmd.List.Tag)
+		ITagList(lgc.NewMessageContext(log), lgc.target(), tagCmd.List.Tag)
+	}
+}
+
+func (lgc *LspGroupCommand) UnwatchTagCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var unwatchTagCmd struct {
+		Tag string `arg:"" help:"标签"`
+	}
+	_, output := lgc.parseCommandSyntax(&unwatchTagCmd, lgc.CommandName())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	log = log.WithField("tag", unwatchTagCmd.Tag)
+	IUnwatchTag(lgc.NewMessageContext(log), lgc.target(), unwatchTagCmd.Tag)
 }
 
 func (lgc *LspGroupCommand) RollCommand() {
@@ -518,46 +709,22 @@ func (lgc *LspGroupCommand) CheckinCommand() {
 		return
 	}
 
-	date := time.Now().Format("20060102")
-
-	var score int64
-	var success bool
-	err := localdb.RWCover(func() error {
-		var err error
-		scoreKey := localdb.Key("Score", lgc.groupCode(), lgc.uin())
-		dateMarker := localdb.Key("ScoreDate", lgc.groupCode(), lgc.uin(), date)
-
-		score, err = localdb.GetInt64(scoreKey, localdb.IgnoreNotFoundOpt())
-		if err != nil {
-			return err
-		}
-		if localdb.Exist(dateMarker) {
-			log = log.WithField("current_score", score)
-			success = false
-			return nil
-		}
-
-		score, err = localdb.SeqNext(scoreKey)
-		if err != nil {
-			return err
-		}
-
-		err = localdb.Set(dateMarker, "", localdb.SetExpireOpt(time.Hour*24*3))
-		if err != nil {
-			return err
-		}
-		log = log.WithField("new_score", score)
-		success = true
-		return nil
-	})
+	result, err := lgc.l.LspStateManager.Checkin(lgc.groupCode(), lgc.uin())
 	if err != nil {
 		lgc.textSend("失败 - 内部错误")
 		log.Errorf("checkin error %v", err)
 		return
 	}
+	log = log.WithFields(logrus.Fields{
+		"score":   result.Score,
+		"success": result.Success,
+		"streak":  result.Streak,
+	})
 	lgc.sendChain(lgc.templateMsg("command.group.checkin.tmpl", map[string]interface{}{
-		"score":   score,
-		"success": success,
+		"score":   result.Score,
+		"success": result.Success,
+		"gain":    result.Gain,
+		"streak":  result.Streak,
 	}))
 }
 
@@ -575,19 +742,112 @@ func (lgc *LspGroupCommand) ScoreCommand() {
 		return
 	}
 
-	var score int64
+	score, err := lgc.l.LspStateManager.GetScore(lgc.groupCode(), lgc.uin())
+	if err != nil {
+		log.Errorf("GetScore error %v", err)
+		lgc.textSend("失败 - 内部错误")
+		return
+	}
+	ledgers, err := lgc.l.LspStateManager.ListScoreLedger(lgc.groupCode(), lgc.uin(), 5)
+	if err != nil {
+		log.Errorf("ListScoreLedger error %v", err)
+	}
+	m := mmsg.NewMSG()
+	m.Textf("当前积分为%v", score)
+	if len(ledgers) > 0 {
+		m.Text("\n最近积分变动：")
+		for _, ledger := range ledgers {
+			m.Textf("\n%v %v %+d", time.Unix(ledger.Time, 0).Format("01-02 15:04"), ledger.Reason, ledger.Delta)
+		}
+	}
+	lgc.reply(m)
+}
 
-	err := localdb.RCover(func() error {
-		var err error
-		key := localdb.Key("Score", lgc.groupCode(), lgc.uin())
-		score, err = localdb.GetInt64(key, localdb.IgnoreNotFoundOpt())
-		return err
-	})
+func (lgc *LspGroupCommand) ScoreRankCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var rankCmd struct{}
+	_, output := lgc.parseCommandSyntax(&rankCmd, lgc.CommandName())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	ranks, err := lgc.l.LspStateManager.ListScoreRank(lgc.groupCode(), 10)
 	if err != nil {
+		log.Errorf("ListScoreRank error %v", err)
 		lgc.textSend("失败 - 内部错误")
-	} else {
-		lgc.textReplyF("当前积分为%v", score)
+		return
+	}
+	if len(ranks) == 0 {
+		lgc.textReply("暂无积分记录")
+		return
+	}
+	groupInfo := utils.GetBot().FindGroup(lgc.groupCode())
+	m := mmsg.NewMSG()
+	m.Text("积分排行：")
+	for index, rank := range ranks {
+		name := strconv.FormatInt(rank.Uin, 10)
+		if groupInfo != nil {
+			if member := groupInfo.FindMember(rank.Uin); member != nil {
+				name = member.DisplayName()
+			}
+		}
+		m.Textf("\n%v. %v - %v", index+1, name, rank.Score)
+	}
+	lgc.send(m)
+}
+
+func (lgc *LspGroupCommand) SearchCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var searchCmd struct {
+		Keyword string `arg:"" help:"要搜索的关键字"`
+		Page    int    `optional:"" short:"p" default:"1" help:"页码"`
+	}
+	_, output := lgc.parseCommandSyntax(&searchCmd, lgc.CommandName())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	log = log.WithField("keyword", searchCmd.Keyword).WithField("page", searchCmd.Page)
+
+	if searchCmd.Page <= 0 {
+		lgc.textReply("失败 - 页码需要大于0")
+		return
+	}
+
+	const pageSize = 5
+	result, total, err := lgc.l.LspStateManager.SearchGroupMessage(lgc.groupCode(), searchCmd.Keyword, (searchCmd.Page-1)*pageSize, pageSize)
+	if err != nil {
+		log.Errorf("SearchGroupMessage error %v", err)
+		lgc.textReply("失败 - 内部错误")
+		return
+	}
+	if total == 0 {
+		lgc.textReply("没有找到相关记录")
+		return
+	}
+	totalPage := (total + pageSize - 1) / pageSize
+	if len(result) == 0 {
+		lgc.textReplyF("失败 - 页码超出范围，共%v页", totalPage)
+		return
+	}
+	m := mmsg.NewMSG()
+	m.Textf("共找到%v条记录，第%v/%v页", total, searchCmd.Page, totalPage)
+	for _, item := range result {
+		m.Textf("\n[%v] %v(%v)：%v", time.Unix(item.Time, 0).Format("2006-01-02 15:04:05"), item.Name, item.Uin, item.Content)
 	}
+	lgc.reply(m)
 }
 
 func (lgc *LspGroupCommand) EnableCommand(disable bool) {
@@ -598,6 +858,7 @@ func (lgc *LspGroupCommand) EnableCommand(disable bool) {
 
 	var enableCmd struct {
 		Command string `arg:"" optional:"" help:"command name"`
+		Module  bool   `optional:"" short:"m" help:"操作模块，例如 -m bilibili"`
 	}
 	_, output := lgc.parseCommandSyntax(&enableCmd, lgc.CommandName())
 	if output != "" {
@@ -607,6 +868,12 @@ func (lgc *LspGroupCommand) EnableCommand(disable bool) {
 		return
 	}
 
+	if enableCmd.Module {
+		log = log.WithField("targetModule", enableCmd.Command)
+		IEnableModule(lgc.NewMessageContext(log), lgc.groupCode(), enableCmd.Command, disable)
+		return
+	}
+
 	log = log.WithField("targetCommand", enableCmd.Command)
 
 	IEnable(lgc.NewMessageContext(log), lgc.groupCode(), enableCmd.Command, disable)
@@ -619,7 +886,7 @@ func (lgc *LspGroupCommand) GrantCommand() {
 
 	var grantCmd struct {
 		Command string `required:"" short:"c" xor:"1" help:"命令名"`
-		Role    string `required:"" short:"r" xor:"1" enum:"Admin,GroupAdmin" help:"Admin / GroupAdmin"`
+		Role    string `required:"" short:"r" xor:"1" enum:"Admin,GroupAdmin,GroupOwner,GroupManager,GroupSubscriber" help:"Admin / GroupAdmin / GroupOwner / GroupManager / GroupSubscriber"`
 		Delete  bool   `short:"d" help:"删除模式，执行删除权限操作"`
 		Target  int64  `arg:"" help:"目标qq号"`
 	}
@@ -668,6 +935,170 @@ func (lgc *LspGroupCommand) SilenceCommand() {
 	ISilenceCmd(lgc.NewMessageContext(log), lgc.groupCode(), silenceCmd.Delete)
 }
 
+func (lgc *LspGroupCommand) QuietCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var quietCmd struct {
+		Action string   `arg:"" enum:"add,remove,clear,show,drop,digest" default:"show" help:"add / remove / clear / show / drop（丢弃时段内的推送） / digest（时段结束后汇总发送，默认）"`
+		Range  []string `arg:"" optional:"" help:"免打扰时段，格式为 HH:MM-HH:MM ，例如 23:00-07:00"`
+	}
+
+	_, output := lgc.parseCommandSyntax(&quietCmd, lgc.CommandName(), kong.Description("设置免打扰时段"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	IQuietCmd(lgc.NewMessageContext(log), lgc.groupCode(), quietCmd.Action, quietCmd.Range)
+}
+
+func (lgc *LspGroupCommand) DigestCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var digestCmd struct {
+		Action   string `arg:"" enum:"set,off,show" default:"show" help:"set / off / show"`
+		Interval int    `arg:"" optional:"" help:"汇总的间隔，单位为分钟"`
+	}
+
+	_, output := lgc.parseCommandSyntax(&digestCmd, lgc.CommandName(), kong.Description("设置汇总推送，开启后直播以外的推送会定期汇总成一条发送"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	IDigestCmd(lgc.NewMessageContext(log), lgc.groupCode(), digestCmd.Action, digestCmd.Interval)
+}
+
+func (lgc *LspGroupCommand) ReminderCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var reminderCmd struct {
+		Action string   `arg:"" enum:"add,list,delete" default:"list" help:"add / list / delete"`
+		Args   []string `arg:"" optional:"" help:"add时为cron表达式和提醒内容，例如 \"0 20 * * 5\" 今晚8点直播；delete时为提醒id"`
+	}
+
+	_, output := lgc.parseCommandSyntax(&reminderCmd, lgc.CommandName(), kong.Description("设置定时提醒，提醒内容支持模板语法"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	var text string
+	if len(reminderCmd.Args) > 0 {
+		text = lgc.templateText(reminderCmd.Args[1:])
+	}
+	IReminderCmd(lgc.NewMessageContext(log), lgc.groupCode(), reminderCmd.Action, reminderCmd.Args, text)
+}
+
+func (lgc *LspGroupCommand) LocaleCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var localeCmd struct {
+		Locale string `arg:"" optional:"" help:"要设置的语言，例如 zh-CN / en-US ，为空时查看当前的语言"`
+	}
+
+	_, output := lgc.parseCommandSyntax(&localeCmd, lgc.CommandName(), kong.Description("设置BOT在本群回复和推送使用的语言"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	ILocaleCmd(lgc.NewMessageContext(log), lgc.groupCode(), localeCmd.Locale)
+}
+
+func (lgc *LspGroupCommand) FindCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var findCmd struct {
+		Site    string `optional:"" short:"s" help:"网站参数，不指定时查找所有网站"`
+		Keyword string `arg:"" help:"账号名字的关键字"`
+	}
+
+	_, output := lgc.parseCommandSyntax(&findCmd, lgc.CommandName(), kong.Description("通过关键字查找账号的id"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	IFind(lgc.NewMessageContext(log.WithField("keyword", findCmd.Keyword)), findCmd.Site, findCmd.Keyword)
+}
+
+func (lgc *LspGroupCommand) SubMeCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var subMeCmd struct {
+		Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Remove bool   `optional:"" short:"r" help:"取消订阅"`
+		Id     string `arg:"" help:"本群已订阅的id"`
+	}
+
+	_, output := lgc.parseCommandSyntax(&subMeCmd, lgc.CommandName(), kong.Description("订阅后推送时会@你"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	log = log.WithField("site", subMeCmd.Site).WithField("id", subMeCmd.Id).WithField("remove", subMeCmd.Remove)
+	ISubMe(lgc.NewMessageContext(log), lgc.groupCode(), subMeCmd.Id, subMeCmd.Site, subMeCmd.Remove)
+}
+
+func (lgc *LspGroupCommand) TrialCommand() {
+	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
+	log.Infof("run %v command", lgc.CommandName())
+	defer func() { log.Infof("%v command end", lgc.CommandName()) }()
+
+	var trialCmd struct {
+		Site     string        `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Type     string        `optional:"" short:"t" default:"" help:"类型参数"`
+		Duration time.Duration `optional:"" short:"d" help:"试用时间，例如 2h ，默认为管理员配置的最长时间"`
+		Id       string        `arg:""`
+	}
+
+	_, output := lgc.parseCommandSyntax(&trialCmd, lgc.CommandName(),
+		kong.Description("添加试用订阅，不需要管理员权限，到期后自动取消"), kong.UsageOnError())
+	if output != "" {
+		lgc.textReply(output)
+	}
+	if lgc.exit {
+		return
+	}
+
+	site, watchType, err := lgc.ParseRawSiteAndType(trialCmd.Site, trialCmd.Type)
+	if err != nil {
+		log = log.WithField("args", lgc.GetArgs())
+		log.Errorf("ParseRawSiteAndType failed %v", err)
+		lgc.textReply(fmt.Sprintf("参数错误 - %v", err))
+		return
+	}
+	log = log.WithField("site", site).WithField("type", watchType).WithField("duration", trialCmd.Duration)
+
+	ITrialWatch(lgc.NewMessageContext(log), lgc.groupCode(), trialCmd.Id, site, watchType, trialCmd.Duration)
+}
+
 func (lgc *LspGroupCommand) ConfigCommand() {
 	log := lgc.DefaultLoggerWithCommand(lgc.CommandName())
 	log.Infof("run %v command", lgc.CommandName())
@@ -685,16 +1116,55 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 			Id     string `arg:"" help:"配置的主播id"`
 			Switch string `arg:"" default:"on" enum:"on,off" help:"on / off"`
 		} `cmd:"" help:"配置推送时@全体成员，默认关闭，需要管理员权限" name:"at_all"`
+		Mention struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Type   string `optional:"" short:"t" help:"推送类型，默认为网站的第一个类型，例如b站为live"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Action string `arg:"" enum:"none,member,all,show" help:"none（不@） / member（@配置的成员和/subme订阅的成员） / all（@全体成员） / show"`
+		} `cmd:"" help:"按推送类型配置推送时的@方式" name:"mention"`
 		TitleNotify struct {
 			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
 			Id     string `arg:"" help:"配置的主播id"`
 			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
 		} `cmd:"" help:"配置直播间标题发生变化时是否进行推送，默认不推送" name:"title_notify"`
+		LiveChangeNotify struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
+		} `cmd:"" help:"配置直播中更换封面或者分区时是否进行推送，默认不推送，目前支持b站和斗鱼" name:"live_change_notify"`
 		OfflineNotify struct {
 			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
 			Id     string `arg:"" help:"配置的主播id"`
 			Switch string `arg:"" default:"off" enum:"on,off," help:"on / off"`
 		} `cmd:"" help:"配置下播时是否进行推送，默认不推送" name:"offline_notify"`
+		Danmaku struct {
+			Site    string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id      string   `arg:"" help:"配置的主播id"`
+			Action  string   `arg:"" enum:"on,off,show" help:"on / off / show"`
+			Keyword []string `arg:"" optional:"" help:"需要转发的弹幕关键字，醒目留言及上舰消息总是转发"`
+		} `cmd:"" help:"配置直播期间转发弹幕到群内，默认关闭，目前仅支持b站" name:"danmaku"`
+		DanmakuAlert struct {
+			Site    string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			User    []string `optional:"" short:"u" help:"需要提醒的用户，可以是uid或者用户名"`
+			Id      string   `arg:"" help:"配置的主播id"`
+			Action  string   `arg:"" enum:"on,off,show" help:"on / off / show"`
+			Keyword []string `arg:"" optional:"" help:"需要提醒的弹幕关键字"`
+		} `cmd:"" help:"配置直播期间弹幕包含关键字或者指定用户发言时在群内提醒，默认关闭，目前仅支持b站" name:"danmaku_alert"`
+		Record struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
+		} `cmd:"" help:"配置开播时是否录制直播，默认关闭，目前支持b站和斗鱼" name:"record"`
+		ScheduleRemind struct {
+			Site    string `optional:"" short:"s" default:"huya" help:"网站参数"`
+			Id      string `arg:"" help:"配置的主播id"`
+			Minutes int    `arg:"" default:"0" help:"在预告的开播时间之前多少分钟提醒，0表示关闭"`
+		} `cmd:"" help:"配置开播预告的提醒，需要订阅schedule类型，默认关闭，目前仅支持虎牙" name:"schedule_remind"`
+		Translate struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
+		} `cmd:"" help:"配置是否在动态推送的末尾附加外语正文的翻译，默认关闭，目前支持b站和微博" name:"translate"`
 		Filter struct {
 			Site string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
 			Type struct {
@@ -709,6 +1179,18 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 				Id      string   `arg:"" help:"配置的主播id"`
 				Keyword []string `arg:"" optional:"" help:"指定的关键字"`
 			} `cmd:"" help:"当动态内容里出现关键字时进行推送" name:"text" group:"filter"`
+			NotText struct {
+				Id      string   `arg:"" help:"配置的主播id"`
+				Keyword []string `arg:"" optional:"" help:"指定的关键字"`
+			} `cmd:"" help:"当动态内容里出现关键字时不推送" name:"not_text" group:"filter"`
+			Regex struct {
+				Id    string   `arg:"" help:"配置的主播id"`
+				Regex []string `arg:"" optional:"" help:"指定的正则表达式"`
+			} `cmd:"" help:"当动态内容匹配正则表达式时进行推送" name:"regex" group:"filter"`
+			NotRegex struct {
+				Id    string   `arg:"" help:"配置的主播id"`
+				Regex []string `arg:"" optional:"" help:"指定的正则表达式"`
+			} `cmd:"" help:"当动态内容匹配正则表达式时不推送" name:"not_regex" group:"filter"`
 			Clear struct {
 				Id string `arg:"" help:"配置的主播id"`
 			} `cmd:"" help:"清除过滤器" name:"clear" group:"filter"`
@@ -716,10 +1198,17 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 				Id string `arg:"" help:"配置的主播id"`
 			} `cmd:"" help:"查看当前过滤器" name:"show" group:"filter"`
 		} `cmd:"" help:"配置动态过滤器" name:"filter"`
+		Template struct {
+			Site     string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id       string   `arg:"" help:"配置的主播id"`
+			Kind     string   `arg:"" enum:"live,title,news" help:"live（开播/下播） / title（改标题） / news（动态）"`
+			Action   string   `arg:"" enum:"set,clear,show" help:"set / clear / show"`
+			Template []string `arg:"" optional:"" passthrough:"" help:"模板内容，建议换行后输入"`
+		} `cmd:"" help:"配置自定义推送模板，默认使用内置的推送格式" name:"template"`
 	}
 
 	kongCtx, output := lgc.parseCommandSyntax(&configCmd, lgc.CommandName(),
-		kong.Description("管理BOT的配置，目前支持配置@成员、@全体成员、开启下播推送、开启标题推送、推送过滤"),
+		kong.Description("管理BOT的配置，目前支持配置@成员、@全体成员、@方式、开启下播推送、开启标题推送、弹幕转发、直播录制、开播预告提醒、推送过滤、推送模板"),
 	)
 	if output != "" {
 		lgc.textReply(output)
@@ -742,7 +1231,7 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 			return
 		}
 		log = log.WithField("site", site).WithField("id", configCmd.At.Id).WithField("action", configCmd.At.Action).WithField("QQ", configCmd.At.QQ)
-		IConfigAtCmd(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.At.Id, site, ctype, configCmd.At.Action, configCmd.At.QQ)
+		IConfigAtCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.At.Id, site, ctype, configCmd.At.Action, configCmd.At.QQ)
 	case "at_all":
 		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.AtAll.Site, "live")
 		if err != nil {
@@ -752,7 +1241,16 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 		}
 		var on = utils.Switch2Bool(configCmd.AtAll.Switch)
 		log = log.WithField("site", site).WithField("id", configCmd.AtAll.Id).WithField("on", on)
-		IConfigAtAllCmd(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.AtAll.Id, site, ctype, on)
+		IConfigAtAllCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.AtAll.Id, site, ctype, on)
+	case "mention":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.Mention.Site, configCmd.Mention.Type)
+		if err != nil {
+			log.WithField("site", configCmd.Mention.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.Mention.Id).WithField("action", configCmd.Mention.Action)
+		IConfigMentionCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.Mention.Id, site, ctype, configCmd.Mention.Action)
 	case "title_notify":
 		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.TitleNotify.Site, "live")
 		if err != nil {
@@ -762,7 +1260,17 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 		}
 		var on = utils.Switch2Bool(configCmd.TitleNotify.Switch)
 		log = log.WithField("site", site).WithField("id", configCmd.TitleNotify.Id).WithField("on", on)
-		IConfigTitleNotifyCmd(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.TitleNotify.Id, site, ctype, on)
+		IConfigTitleNotifyCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.TitleNotify.Id, site, ctype, on)
+	case "live_change_notify":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.LiveChangeNotify.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.LiveChangeNotify.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		var on = utils.Switch2Bool(configCmd.LiveChangeNotify.Switch)
+		log = log.WithField("site", site).WithField("id", configCmd.LiveChangeNotify.Id).WithField("on", on)
+		IConfigLiveChangeNotifyCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.LiveChangeNotify.Id, site, ctype, on)
 	case "offline_notify":
 		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.OfflineNotify.Site, "live")
 		if err != nil {
@@ -772,7 +1280,59 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 		}
 		var on = utils.Switch2Bool(configCmd.OfflineNotify.Switch)
 		log = log.WithField("site", site).WithField("id", configCmd.OfflineNotify.Id).WithField("on", on)
-		IConfigOfflineNotifyCmd(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.OfflineNotify.Id, site, ctype, on)
+		IConfigOfflineNotifyCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.OfflineNotify.Id, site, ctype, on)
+	case "danmaku":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.Danmaku.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.Danmaku.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.Danmaku.Id).WithField("action", configCmd.Danmaku.Action).WithField("keyword", configCmd.Danmaku.Keyword)
+		IConfigDanmakuRelayCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.Danmaku.Id, site, ctype, configCmd.Danmaku.Action, configCmd.Danmaku.Keyword)
+	case "danmaku_alert":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.DanmakuAlert.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.DanmakuAlert.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.DanmakuAlert.Id).WithField("action", configCmd.DanmakuAlert.Action).
+			WithField("keyword", configCmd.DanmakuAlert.Keyword).WithField("user", configCmd.DanmakuAlert.User)
+		IConfigDanmakuAlertCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.DanmakuAlert.Id, site, ctype, configCmd.DanmakuAlert.Action, configCmd.DanmakuAlert.Keyword, configCmd.DanmakuAlert.User)
+	case "record":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.Record.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.Record.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		var on = utils.Switch2Bool(configCmd.Record.Switch)
+		log = log.WithField("site", site).WithField("id", configCmd.Record.Id).WithField("on", on)
+		IConfigRecordCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.Record.Id, site, ctype, on)
+	case "translate":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.Translate.Site, "news")
+		if err != nil {
+			log.WithField("site", configCmd.Translate.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		var on = utils.Switch2Bool(configCmd.Translate.Switch)
+		log = log.WithField("site", site).WithField("id", configCmd.Translate.Id).WithField("on", on)
+		IConfigTranslateCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.Translate.Id, site, ctype, on)
+	case "schedule_remind":
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.ScheduleRemind.Site, "schedule")
+		if err != nil {
+			log.WithField("site", configCmd.ScheduleRemind.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		if configCmd.ScheduleRemind.Minutes < 0 {
+			lgc.textSend("失败 - 提醒时间不能小于0")
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.ScheduleRemind.Id).WithField("minutes", configCmd.ScheduleRemind.Minutes)
+		IConfigScheduleRemindCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.ScheduleRemind.Id, site, ctype, configCmd.ScheduleRemind.Minutes)
 	case "filter":
 		filterCmd := kongPath[1]
 		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.Filter.Site, "news")
@@ -783,19 +1343,38 @@ func (lgc *LspGroupCommand) ConfigCommand() {
 		}
 		switch filterCmd {
 		case "type":
-			IConfigFilterCmdType(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.Filter.Type.Id, site, ctype, configCmd.Filter.Type.Type)
+			IConfigFilterCmdType(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.Type.Id, site, ctype, configCmd.Filter.Type.Type)
 		case "not_type":
-			IConfigFilterCmdNotType(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.Filter.NotType.Id, site, ctype, configCmd.Filter.NotType.Type)
+			IConfigFilterCmdNotType(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.NotType.Id, site, ctype, configCmd.Filter.NotType.Type)
 		case "text":
-			IConfigFilterCmdText(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.Filter.Text.Id, site, ctype, configCmd.Filter.Text.Keyword)
+			IConfigFilterCmdText(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.Text.Id, site, ctype, configCmd.Filter.Text.Keyword)
+		case "not_text":
+			IConfigFilterCmdNotText(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.NotText.Id, site, ctype, configCmd.Filter.NotText.Keyword)
+		case "regex":
+			IConfigFilterCmdRegex(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.Regex.Id, site, ctype, configCmd.Filter.Regex.Regex)
+		case "not_regex":
+			IConfigFilterCmdNotRegex(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.NotRegex.Id, site, ctype, configCmd.Filter.NotRegex.Regex)
 		case "clear":
-			IConfigFilterCmdClear(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.Filter.Clear.Id, site, ctype)
+			IConfigFilterCmdClear(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.Clear.Id, site, ctype)
 		case "show":
-			IConfigFilterCmdShow(lgc.NewMessageContext(log), lgc.groupCode(), configCmd.Filter.Show.Id, site, ctype)
+			IConfigFilterCmdShow(lgc.NewMessageContext(log), lgc.target(), configCmd.Filter.Show.Id, site, ctype)
 		default:
 			log.WithField("filter_cmd", filterCmd).Errorf("unknown filter command")
 			lgc.textSend("未知的filter子命令")
 		}
+	case "template":
+		var rawType = "live"
+		if configCmd.Template.Kind == concern.TemplateKindNews {
+			rawType = "news"
+		}
+		site, ctype, err := lgc.ParseRawSiteAndType(configCmd.Template.Site, rawType)
+		if err != nil {
+			log.WithField("site", configCmd.Template.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			lgc.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.Template.Id).WithField("kind", configCmd.Template.Kind).WithField("action", configCmd.Template.Action)
+		IConfigTemplateCmd(lgc.NewMessageContext(log), lgc.target(), configCmd.Template.Id, site, ctype, configCmd.Template.Kind, configCmd.Template.Action, lgc.templateText(configCmd.Template.Template))
 	default:
 		lgc.textSend("暂未支持，你可以催作者GKD")
 	}
@@ -920,6 +1499,10 @@ func (lgc *LspGroupCommand) groupCode() int64 {
 	return lgc.msg.GroupCode
 }
 
+func (lgc *LspGroupCommand) target() mmsg.TargetId {
+	return mmsg.NewGroupTargetId(lgc.msg.GroupCode)
+}
+
 func (lgc *LspGroupCommand) groupName() string {
 	return lgc.msg.GroupName
 }
@@ -1004,11 +1587,11 @@ func (lgc *LspGroupCommand) sender() *message.Sender {
 }
 
 func (lgc *LspGroupCommand) noPermissionReply() *message.GroupMessage {
-	return lgc.textReply("权限不够")
+	return lgc.textReply(lgc.l.groupT(lgc.groupCode(), "reply.no_permission"))
 }
 
 func (lgc *LspGroupCommand) globalDisabledReply() *message.GroupMessage {
-	return lgc.textReply("无法操作该命令，该命令已被管理员禁用")
+	return lgc.textReply(lgc.l.groupT(lgc.groupCode(), "reply.global_disabled"))
 }
 
 func (lgc *LspGroupCommand) commonTemplateData() map[string]interface{} {
@@ -1067,5 +1650,6 @@ func (lgc *LspGroupCommand) NewMessageContext(log *logrus.Entry) *MessageContext
 		return nil
 	}
 	ctx.Sender = lgc.sender()
+	ctx.Command = strings.Join(lgc.GetCmdArgs(), " ")
 	return ctx
 }
@@ -0,0 +1,151 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/requests"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetHttpSiteConfig 保存通过命令修改的网站http请求配置，config为空时删除
+func (s *StateManager) SetHttpSiteConfig(site string, config *requests.SiteConfig) error {
+	if config.Empty() {
+		_, err := s.Delete(s.HttpSiteConfigKey(site), localdb.IgnoreNotFoundOpt())
+		return err
+	}
+	return s.SetJson(s.HttpSiteConfigKey(site), config)
+}
+
+// GetHttpSiteConfig 返回通过命令修改的网站http请求配置，没有时返回nil
+func (s *StateManager) GetHttpSiteConfig(site string) (*requests.SiteConfig, error) {
+	var config = new(requests.SiteConfig)
+	err := s.GetJson(s.HttpSiteConfigKey(site), config)
+	if err == localdb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ListHttpSiteConfig 返回所有通过命令修改的网站http请求配置
+func (s *StateManager) ListHttpSiteConfig() (map[string]*requests.SiteConfig, error) {
+	var result = make(map[string]*requests.SiteConfig)
+	err := s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(s.HttpSiteConfigKey("*"), func(key, value string) bool {
+			var config = new(requests.SiteConfig)
+			if iterErr = json.Unmarshal([]byte(value), config); iterErr != nil {
+				return false
+			}
+			result[strings.TrimPrefix(key, s.HttpSiteConfigKey()+":")] = config
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadHttpSiteConfig 加载网站的http请求配置，通过命令修改的配置覆盖配置文件中同一个网站的配置
+func (l *Lsp) loadHttpSiteConfig() {
+	var sites = cfg.GetHttpSiteConfig()
+	if sites == nil {
+		sites = make(map[string]*requests.SiteConfig)
+	}
+	dbSites, err := l.LspStateManager.ListHttpSiteConfig()
+	if err != nil {
+		logger.Errorf("ListHttpSiteConfig error %v", err)
+	}
+	for site, config := range dbSites {
+		sites[site] = config
+	}
+	for _, site := range requests.ListSiteConfig() {
+		if _, found := sites[site]; !found {
+			requests.SetSiteConfig(site, nil)
+		}
+	}
+	for site, config := range sites {
+		requests.SetSiteConfig(site, config)
+		logger.WithField("site", site).Debug("已加载网站http请求配置")
+	}
+}
+
+// copySiteConfig 复制一份配置用于修改，避免影响正在使用的配置
+func copySiteConfig(config *requests.SiteConfig) *requests.SiteConfig {
+	var result = new(requests.SiteConfig)
+	if config == nil {
+		return result
+	}
+	*result = *config
+	result.Proxies = append([]string(nil), config.Proxies...)
+	if config.Header != nil {
+		result.Header = make(map[string]string)
+		for k, v := range config.Header {
+			result.Header[k] = v
+		}
+	}
+	return result
+}
+
+func formatHttpSiteConfig(site string, config *requests.SiteConfig, status []*requests.ProxyStatus) string {
+	if config.Empty() {
+		return fmt.Sprintf("%v：未配置，使用默认的请求参数", site)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%v：", site))
+	if config.Timeout > 0 {
+		sb.WriteString(fmt.Sprintf("\n超时：%v", config.Timeout))
+	}
+	if config.Retry > 0 {
+		sb.WriteString(fmt.Sprintf("\n重试：%v次", config.Retry))
+	}
+	if len(config.UA) > 0 {
+		sb.WriteString(fmt.Sprintf("\nUA：%v", config.UA))
+	}
+	if len(config.Header) > 0 {
+		var keys []string
+		for k := range config.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("\nheader：%v: %v", k, config.Header[k]))
+		}
+	}
+	if len(status) > 0 {
+		sb.WriteString(fmt.Sprintf("\n代理%v个：", len(status)))
+		for _, p := range status {
+			sb.WriteString("\n")
+			sb.WriteString(p.Proxy)
+			if !p.DownUntil.IsZero() {
+				sb.WriteString(fmt.Sprintf(" 不可用，%v后重试", time.Until(p.DownUntil).Truncate(time.Second)))
+			} else if p.Fail > 0 {
+				sb.WriteString(fmt.Sprintf(" 连续失败%v次", p.Fail))
+			} else {
+				sb.WriteString(" 正常")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// updateHttpSiteConfig 在网站当前的配置上修改并保存，配置全部清空后恢复为配置文件中的配置
+func (l *Lsp) updateHttpSiteConfig(site string, f func(config *requests.SiteConfig)) (*requests.SiteConfig, error) {
+	config := copySiteConfig(requests.GetSiteConfig(site))
+	f(config)
+	if err := l.LspStateManager.SetHttpSiteConfig(site, config); err != nil {
+		return nil, err
+	}
+	l.loadHttpSiteConfig()
+	return requests.GetSiteConfig(site), nil
+}
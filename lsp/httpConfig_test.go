@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStateManager_HttpSiteConfig(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+
+	c, err := sm.GetHttpSiteConfig(test.Site1)
+	assert.Nil(t, err)
+	assert.Nil(t, c)
+
+	assert.Nil(t, sm.SetHttpSiteConfig(test.Site1, &requests.SiteConfig{
+		Proxies: []string{"127.0.0.1:1080"},
+		Timeout: time.Second * 5,
+	}))
+	assert.Nil(t, sm.SetHttpSiteConfig(test.Site2, &requests.SiteConfig{Retry: 2}))
+
+	c, err = sm.GetHttpSiteConfig(test.Site1)
+	assert.Nil(t, err)
+	if assert.NotNil(t, c) {
+		assert.EqualValues(t, []string{"127.0.0.1:1080"}, c.Proxies)
+		assert.Equal(t, time.Second*5, c.Timeout)
+	}
+
+	all, err := sm.ListHttpSiteConfig()
+	assert.Nil(t, err)
+	assert.Len(t, all, 2)
+	if assert.Contains(t, all, test.Site2) {
+		assert.Equal(t, 2, all[test.Site2].Retry)
+	}
+
+	// 空配置会删除
+	assert.Nil(t, sm.SetHttpSiteConfig(test.Site1, &requests.SiteConfig{}))
+	c, err = sm.GetHttpSiteConfig(test.Site1)
+	assert.Nil(t, err)
+	assert.Nil(t, c)
+	assert.Nil(t, sm.SetHttpSiteConfig(test.Site1, nil))
+}
+
+func TestLsp_loadHttpSiteConfig(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	config.GlobalConfig.Set("http", map[string]interface{}{
+		"sites": map[string]interface{}{
+			test.Site1: map[string]interface{}{
+				"timeout": "3s",
+				"ua":      "file-ua",
+			},
+		},
+	})
+	defer config.GlobalConfig.Set("http", nil)
+	defer func() {
+		requests.SetSiteConfig(test.Site1, nil)
+		requests.SetSiteConfig(test.Site2, nil)
+	}()
+
+	Instance.loadHttpSiteConfig()
+	if c := requests.GetSiteConfig(test.Site1); assert.NotNil(t, c) {
+		assert.Equal(t, time.Second*3, c.Timeout)
+		assert.Equal(t, "file-ua", c.UA)
+	}
+	assert.Nil(t, requests.GetSiteConfig(test.Site2))
+
+	// 命令修改的配置覆盖配置文件
+	c, err := Instance.updateHttpSiteConfig(test.Site1, func(config *requests.SiteConfig) {
+		config.Retry = 5
+		config.UA = ""
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, c) {
+		assert.Equal(t, time.Second*3, c.Timeout)
+		assert.Equal(t, 5, c.Retry)
+		assert.Empty(t, c.UA)
+	}
+	_, err = Instance.updateHttpSiteConfig(test.Site2, func(config *requests.SiteConfig) {
+		config.Proxies = []string{"127.0.0.1:1080"}
+	})
+	assert.Nil(t, err)
+
+	s := formatHttpSiteConfig(test.Site2, requests.GetSiteConfig(test.Site2), requests.GetSiteProxyStatus(test.Site2))
+	assert.Contains(t, s, "http://127.0.0.1:1080 正常")
+
+	// 清空后恢复为配置文件中的配置
+	assert.Nil(t, Instance.LspStateManager.SetHttpSiteConfig(test.Site1, nil))
+	_, err = Instance.updateHttpSiteConfig(test.Site2, func(config *requests.SiteConfig) {
+		config.Proxies = nil
+	})
+	assert.Nil(t, err)
+	if c := requests.GetSiteConfig(test.Site1); assert.NotNil(t, c) {
+		assert.Equal(t, "file-ua", c.UA)
+		assert.Zero(t, c.Retry)
+	}
+	assert.Nil(t, requests.GetSiteConfig(test.Site2))
+	assert.Contains(t, formatHttpSiteConfig(test.Site2, nil, nil), "未配置")
+}
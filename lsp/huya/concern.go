@@ -2,14 +2,16 @@ package huya
 
 import (
 	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/MiraiGo-Template/utils"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
+	"sort"
+	"strconv"
+	"time"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -17,8 +19,15 @@ var logger = utils.GetModuleLogger("huya-concern")
 
 const (
 	Live concern_type.Type = "live"
+	// Schedule 开播预告，可以通过 /config schedule_remind 配置开播前提醒
+	Schedule concern_type.Type = "schedule"
+	// Replay 直播回放
+	Replay concern_type.Type = "replay"
 )
 
+// maxScheduleRemind 开播前提醒最多可以提前多久
+const maxScheduleRemind = time.Hour * 24
+
 type Concern struct {
 	*StateManager
 }
@@ -28,7 +37,7 @@ func (c *Concern) Site() string {
 }
 
 func (c *Concern) Types() []concern_type.Type {
-	return []concern_type.Type{Live}
+	return []concern_type.Type{Live, Schedule, Replay}
 }
 
 func (c *Concern) ParseId(s string) (interface{}, error) {
@@ -54,11 +63,11 @@ func (c *Concern) Start() error {
 	return c.StateManager.Start()
 }
 
-func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	var err error
-	log := logger.WithFields(localutils.GroupLogFields(groupCode)).WithField("id", id)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
 
-	err = c.StateManager.CheckGroupConcern(groupCode, id, ctype)
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -68,24 +77,26 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype c
 		log.Errorf("RoomPage error %v", err)
 		return nil, fmt.Errorf("查询房间信息失败 %v - %v", id, err)
 	}
-	_, err = c.StateManager.AddGroupConcern(groupCode, id, ctype)
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
 	return liveInfo, nil
 }
 
-func (c *Concern) Remove(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(string)
 	identity, _ := c.Get(id)
-	_, err := c.StateManager.RemoveGroupConcern(groupCode, id, ctype)
-	_ = c.RWCoverTx(func(tx *buntdb.Tx) error {
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
 		allCtype, err := c.GetConcern(id)
 		if err != nil {
 			return err
 		}
 		if allCtype.Empty() {
 			err = c.DeleteLiveInfo(id)
+			_ = c.DeleteSchedules(id)
+			_ = c.DeleteLastReplayTime(id)
 		}
 		return err
 	})
@@ -100,6 +111,26 @@ func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
 	return concern.NewIdentity(liveInfo.RoomId, liveInfo.GetName()), nil
 }
 
+// Search 实现 concern.SearchExt ，使用虎牙的主播搜索
+func (c *Concern) Search(keyword string, n int) ([]*concern.SearchResult, error) {
+	resp, err := SearchAnchor(keyword)
+	if err != nil {
+		return nil, err
+	}
+	var result []*concern.SearchResult
+	for _, doc := range resp.Response.Anchor.Docs {
+		if len(result) >= n {
+			break
+		}
+		result = append(result, &concern.SearchResult{
+			Id:        strconv.FormatInt(doc.RoomId, 10),
+			Name:      doc.GameNick,
+			Followers: doc.Subscribe,
+		})
+	}
+	return result, nil
+}
+
 func (c *Concern) FindRoom(roomId string, load bool) (*LiveInfo, error) {
 	var liveInfo *LiveInfo
 	if load {
@@ -125,15 +156,24 @@ func (c *Concern) FindOrLoadRoom(roomId string) (*LiveInfo, error) {
 }
 
 func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, event concern.Event) []concern.Notify {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
 		switch info := event.(type) {
 		case *LiveInfo:
 			if info.Living() {
-				info.Logger().WithFields(localutils.GroupLogFields(groupCode)).Trace("living notify")
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("living notify")
 			} else {
-				info.Logger().WithFields(localutils.GroupLogFields(groupCode)).Trace("noliving notify")
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("noliving notify")
+			}
+			return []concern.Notify{NewConcernLiveNotify(target, info)}
+		case *ScheduleInfo:
+			if info.Remind() && !c.shouldRemind(target, info) {
+				return nil
 			}
-			return []concern.Notify{NewConcernLiveNotify(groupCode, info)}
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("schedule notify")
+			return []concern.Notify{NewConcernScheduleNotify(target, info)}
+		case *ReplayInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("replay notify")
+			return []concern.Notify{NewConcernReplayNotify(target, info)}
 		default:
 			logger.Errorf("unknown EventType %+v", event)
 			return nil
@@ -171,10 +211,104 @@ func (c *Concern) fresh() concern.FreshFunc {
 			}
 			result = append(result, liveInfo)
 		}
+		if ctype.ContainAll(Schedule) {
+			schedules, err := c.freshSchedule(roomid)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, schedules...)
+		}
+		if ctype.ContainAll(Replay) {
+			replays, err := c.freshReplay(roomid)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, replays...)
+		}
 		return result, nil
 	})
 }
 
+// shouldRemind 检查群配置的提醒时间，到达提醒时间并且没有提醒过时返回true
+func (c *Concern) shouldRemind(target mmsg.TargetId, info *ScheduleInfo) bool {
+	remind := c.GetGroupConcernConfig(target, info.RoomId).GetGroupConcernNotify().ScheduleRemind
+	if remind <= 0 {
+		return false
+	}
+	if time.Until(time.Unix(info.StartTime, 0)) > time.Duration(remind)*time.Minute {
+		return false
+	}
+	return c.MarkScheduleRemind(target, info.Id)
+}
+
+// freshSchedule 返回新发布的开播预告，以及即将开播的预告的提醒，是否提醒由每个群的配置决定
+// 第一次查询时只记录，不会推送已经发布的预告
+func (c *Concern) freshSchedule(roomId string) ([]concern.Event, error) {
+	oldSchedules, oldErr := c.GetSchedules(roomId)
+	schedules, err := LiveSchedule(roomId)
+	if err != nil {
+		return nil, fmt.Errorf("load schedule failed %v", err)
+	}
+	if err := c.SetSchedules(roomId, schedules); err != nil {
+		return nil, err
+	}
+	var known = make(map[string]bool)
+	for _, s := range oldSchedules {
+		known[s.Id] = true
+	}
+	var now = time.Now()
+	var result []concern.Event
+	for _, s := range schedules {
+		var start = time.Unix(s.StartTime, 0)
+		if !start.After(now) {
+			continue
+		}
+		if oldErr == nil && !known[s.Id] {
+			result = append(result, s)
+		}
+		if start.Sub(now) <= maxScheduleRemind {
+			result = append(result, &ScheduleInfo{
+				RoomId:    s.RoomId,
+				Name:      s.Name,
+				Id:        s.Id,
+				Title:     s.Title,
+				StartTime: s.StartTime,
+				remind:    true,
+			})
+		}
+	}
+	return result, nil
+}
+
+// freshReplay 返回上一次查询之后发布的直播回放，第一次查询时只记录，不会推送已经发布的回放
+func (c *Concern) freshReplay(roomId string) ([]concern.Event, error) {
+	lastTime, lastErr := c.GetLastReplayTime(roomId)
+	replays, err := LiveReplay(roomId)
+	if err != nil {
+		return nil, fmt.Errorf("load replay failed %v", err)
+	}
+	sort.Slice(replays, func(i, j int) bool {
+		return replays[i].PublishTime < replays[j].PublishTime
+	})
+	var result []concern.Event
+	var newLastTime = lastTime
+	for _, r := range replays {
+		if r.PublishTime <= lastTime {
+			continue
+		}
+		if lastErr == nil {
+			result = append(result, r)
+		}
+		newLastTime = r.PublishTime
+	}
+	if lastErr != nil || newLastTime != lastTime {
+		if err := c.SetLastReplayTime(roomId, newLastTime); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
 func NewConcern(notify chan<- concern.Notify) *Concern {
 	c := &Concern{
 		StateManager: NewStateManager(notify),
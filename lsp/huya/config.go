@@ -1,6 +1,7 @@
 package huya
 
 import (
+	"fmt"
 	"github.com/Sora233/DDBOT/lsp/concern"
 )
 
@@ -8,6 +9,22 @@ type GroupConcernConfig struct {
 	concern.IConfig
 }
 
+// Validate 虎牙支持开播预告提醒，默认的Validate会拒绝，所以检查提醒时间后再交给默认的Validate检查其他配置
+func (g *GroupConcernConfig) Validate() error {
+	notifyConfig := g.GetGroupConcernNotify()
+	if notifyConfig.CheckScheduleRemind() {
+		if notifyConfig.ScheduleRemind > int(maxScheduleRemind.Minutes()) {
+			return fmt.Errorf("提醒时间不能超过%v分钟", int(maxScheduleRemind.Minutes()))
+		}
+		remind := notifyConfig.ScheduleRemind
+		notifyConfig.ScheduleRemind = 0
+		defer func() {
+			notifyConfig.ScheduleRemind = remind
+		}()
+	}
+	return g.IConfig.Validate()
+}
+
 func NewGroupConcernConfig(g concern.IConfig) *GroupConcernConfig {
 	return &GroupConcernConfig{g}
 }
@@ -128,3 +128,16 @@ func TestGroupConcernConfig_AtBeforeHook(t *testing.T) {
 		assert.EqualValues(t, expcted[idx], hook.Pass)
 	}
 }
+
+func TestGroupConcernConfig_Validate(t *testing.T) {
+	var config = NewGroupConcernConfig(&concern.GroupConcernConfig{})
+	assert.Nil(t, config.Validate())
+	config.GetGroupConcernNotify().ScheduleRemind = 10
+	assert.Nil(t, config.Validate())
+	assert.Equal(t, 10, config.GetGroupConcernNotify().ScheduleRemind)
+	config.GetGroupConcernNotify().ScheduleRemind = 24*60 + 1
+	assert.NotNil(t, config.Validate())
+	config.GetGroupConcernNotify().ScheduleRemind = 10
+	config.GetGroupConcernNotify().Record = true
+	assert.Equal(t, concern.ErrConfigNotSupported, config.Validate())
+}
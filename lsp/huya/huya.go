@@ -5,6 +5,14 @@ const (
 	Host = "https://www.huya.com"
 )
 
+// CacheApi 虎牙开播预告和直播回放接口的地址
+var CacheApi = Host + "/cache.php"
+
 func HuyaPath(path string) string {
 	return Host + "/" + path
 }
+
+// ReplayPath 直播回放的播放地址
+func ReplayPath(vid string) string {
+	return "https://v.huya.com/play/" + vid + ".html"
+}
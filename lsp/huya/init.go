@@ -1,9 +1,11 @@
 package huya
 
 import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
 )
 
 func init() {
 	concern.RegisterConcern(NewConcern(concern.GetNotifyChan()))
+	localdb.RegisterJsonType(localdb.HuyaCurrentLiveKey, func() interface{} { return new(LiveInfo) })
 }
@@ -1,6 +1,10 @@
 package huya
 
-import "github.com/Sora233/DDBOT/lsp/buntdb"
+import (
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
 
 type keySet struct {
 }
@@ -21,8 +25,8 @@ func (l *keySet) FreshKey(keys ...interface{}) string {
 	return buntdb.HuyaFreshKey(keys...)
 }
 
-func (l *keySet) ParseGroupConcernStateKey(key string) (int64, interface{}, error) {
-	return buntdb.ParseConcernStateKeyWithString(key)
+func (l *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	return concern.ParseConcernStateKeyWithString(key)
 }
 
 type extraKey struct{}
@@ -31,6 +35,18 @@ func (k extraKey) CurrentLiveKey(keys ...interface{}) string {
 	return buntdb.HuyaCurrentLiveKey(keys...)
 }
 
+func (k extraKey) ScheduleKey(keys ...interface{}) string {
+	return buntdb.HuyaScheduleKey(keys...)
+}
+
+func (k extraKey) ScheduleRemindKey(keys ...interface{}) string {
+	return buntdb.HuyaScheduleRemindKey(keys...)
+}
+
+func (k extraKey) LastReplayKey(keys ...interface{}) string {
+	return buntdb.HuyaLastReplayKey(keys...)
+}
+
 func NewExtraKey() *extraKey {
 	return &extraKey{}
 }
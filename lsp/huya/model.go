@@ -4,9 +4,10 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/template"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/sirupsen/logrus"
+	"math"
 	"sync"
+	"time"
 )
 
 type LiveInfo struct {
@@ -100,11 +101,11 @@ func (m *LiveInfo) GetMSG() *mmsg.MSG {
 
 type ConcernLiveNotify struct {
 	*LiveInfo
-	GroupCode int64 `json:"group_code"`
+	Target mmsg.TargetId `json:"target"`
 }
 
-func (notify *ConcernLiveNotify) GetGroupCode() int64 {
-	return notify.GroupCode
+func (notify *ConcernLiveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
 }
 
 func (notify *ConcernLiveNotify) ToMessage() (m *mmsg.MSG) {
@@ -115,15 +116,211 @@ func (notify *ConcernLiveNotify) Logger() *logrus.Entry {
 	if notify == nil {
 		return logger
 	}
-	return notify.LiveInfo.Logger().WithFields(localutils.GroupLogFields(notify.GroupCode))
+	return notify.LiveInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
 }
 
-func NewConcernLiveNotify(groupCode int64, l *LiveInfo) *ConcernLiveNotify {
+func NewConcernLiveNotify(target mmsg.TargetId, l *LiveInfo) *ConcernLiveNotify {
 	if l == nil {
 		return nil
 	}
 	return &ConcernLiveNotify{
 		l,
-		groupCode,
+		target,
+	}
+}
+
+// ScheduleInfo 主播发布的开播预告
+type ScheduleInfo struct {
+	RoomId    string `json:"room_id"`
+	Name      string `json:"name"`
+	Id        string `json:"id"`
+	Title     string `json:"title"`
+	StartTime int64  `json:"start_time"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+	remind   bool
+}
+
+// Remind 返回是否是开播前的提醒，false表示新发布的预告
+func (s *ScheduleInfo) Remind() bool {
+	return s.remind
+}
+
+func (s *ScheduleInfo) GetUid() interface{} {
+	return s.RoomId
+}
+
+func (s *ScheduleInfo) GetName() string {
+	if s == nil {
+		return ""
+	}
+	if len(s.Name) == 0 {
+		return s.RoomId
+	}
+	return s.Name
+}
+
+func (s *ScheduleInfo) Type() concern_type.Type {
+	return Schedule
+}
+
+func (s *ScheduleInfo) Site() string {
+	return Site
+}
+
+func (s *ScheduleInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":       Site,
+		"RoomId":     s.RoomId,
+		"Name":       s.Name,
+		"ScheduleId": s.Id,
+		"Title":      s.Title,
+		"StartTime":  s.StartTime,
+		"Remind":     s.remind,
+	})
+}
+
+func (s *ScheduleInfo) GetMSG() *mmsg.MSG {
+	s.once.Do(func() {
+		var start = time.Unix(s.StartTime, 0)
+		var data = map[string]interface{}{
+			"name":       s.GetName(),
+			"title":      s.Title,
+			"url":        HuyaPath(s.RoomId),
+			"start_time": start.Format("2006-01-02 15:04"),
+			"remind":     s.remind,
+			"minutes":    int(math.Ceil(time.Until(start).Minutes())),
+		}
+		var err error
+		s.msgCache, err = template.LoadAndExec("notify.group.huya.schedule.tmpl", data)
+		if err != nil {
+			logger.Errorf("huya: ScheduleInfo LoadAndExec error %v", err)
+		}
+	})
+	return s.msgCache
+}
+
+// ReplayInfo 主播发布的直播回放
+type ReplayInfo struct {
+	RoomId      string `json:"room_id"`
+	Name        string `json:"name"`
+	Vid         string `json:"vid"`
+	Title       string `json:"title"`
+	Cover       string `json:"cover"`
+	Duration    int64  `json:"duration"`
+	PublishTime int64  `json:"publish_time"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (r *ReplayInfo) GetUid() interface{} {
+	return r.RoomId
+}
+
+func (r *ReplayInfo) GetName() string {
+	if r == nil {
+		return ""
+	}
+	if len(r.Name) == 0 {
+		return r.RoomId
+	}
+	return r.Name
+}
+
+func (r *ReplayInfo) Type() concern_type.Type {
+	return Replay
+}
+
+func (r *ReplayInfo) Site() string {
+	return Site
+}
+
+func (r *ReplayInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":   Site,
+		"RoomId": r.RoomId,
+		"Name":   r.Name,
+		"Vid":    r.Vid,
+		"Title":  r.Title,
+	})
+}
+
+func (r *ReplayInfo) GetMSG() *mmsg.MSG {
+	r.once.Do(func() {
+		var data = map[string]interface{}{
+			"name":     r.GetName(),
+			"title":    r.Title,
+			"url":      ReplayPath(r.Vid),
+			"cover":    r.Cover,
+			"duration": (time.Duration(r.Duration) * time.Second).String(),
+		}
+		var err error
+		r.msgCache, err = template.LoadAndExec("notify.group.huya.replay.tmpl", data)
+		if err != nil {
+			logger.Errorf("huya: ReplayInfo LoadAndExec error %v", err)
+		}
+	})
+	return r.msgCache
+}
+
+type ConcernScheduleNotify struct {
+	*ScheduleInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernScheduleNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernScheduleNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.ScheduleInfo.GetMSG()
+}
+
+func (notify *ConcernScheduleNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.ScheduleInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernScheduleNotify(target mmsg.TargetId, s *ScheduleInfo) *ConcernScheduleNotify {
+	if s == nil {
+		return nil
+	}
+	return &ConcernScheduleNotify{
+		s,
+		target,
+	}
+}
+
+type ConcernReplayNotify struct {
+	*ReplayInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernReplayNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernReplayNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.ReplayInfo.GetMSG()
+}
+
+func (notify *ConcernReplayNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.ReplayInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernReplayNotify(target mmsg.TargetId, r *ReplayInfo) *ConcernReplayNotify {
+	if r == nil {
+		return nil
+	}
+	return &ConcernReplayNotify{
+		r,
+		target,
 	}
 }
@@ -2,8 +2,10 @@ package huya
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestLiveInfo(t *testing.T) {
@@ -15,10 +17,10 @@ func TestLiveInfo(t *testing.T) {
 	assert.Equal(t, Site, l.Site())
 	assert.Equal(t, test.NAME2, l.GetName())
 	assert.Equal(t, Live, l.Type())
-	notify := NewConcernLiveNotify(test.G1, l)
+	notify := NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), l)
 	assert.NotNil(t, notify)
 	assert.NotNil(t, notify.Logger())
-	assert.Equal(t, test.G1, notify.GetGroupCode())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
 	assert.Equal(t, test.NAME1, notify.GetUid())
 	assert.Equal(t, Live, notify.Type())
 
@@ -29,3 +31,35 @@ func TestLiveInfo(t *testing.T) {
 	m = notify.ToMessage()
 	assert.NotNil(t, m)
 }
+
+func TestScheduleAndReplayInfo(t *testing.T) {
+	s := &ScheduleInfo{
+		RoomId:    test.NAME1,
+		Id:        "1",
+		Title:     test.NAME2,
+		StartTime: time.Now().Add(time.Hour).Unix(),
+	}
+	assert.Equal(t, Site, s.Site())
+	assert.Equal(t, test.NAME1, s.GetName())
+	assert.Equal(t, Schedule, s.Type())
+	notify := NewConcernScheduleNotify(mmsg.NewGroupTargetId(test.G1), s)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, test.NAME1, notify.GetUid())
+	assert.NotNil(t, notify.ToMessage())
+
+	r := &ReplayInfo{
+		RoomId:   test.NAME1,
+		Name:     test.NAME2,
+		Vid:      "1",
+		Duration: 60,
+	}
+	assert.Equal(t, test.NAME2, r.GetName())
+	assert.Equal(t, Replay, r.Type())
+	replayNotify := NewConcernReplayNotify(mmsg.NewGroupTargetId(test.G1), r)
+	assert.NotNil(t, replayNotify)
+	assert.NotNil(t, replayNotify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), replayNotify.GetTarget())
+	assert.NotNil(t, replayNotify.ToMessage())
+}
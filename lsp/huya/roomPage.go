@@ -22,6 +22,7 @@ func RoomPage(roomId string) (*LiveInfo, error) {
 	var opts = []requests.Option{
 		requests.AddUAOption(),
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.RetryOption(3),
 		requests.TimeoutOption(time.Second * 10),
 	}
@@ -0,0 +1,119 @@
+package huya
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"strings"
+	"time"
+)
+
+type liveNoticeResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    []struct {
+		Id        string `json:"id"`
+		Nick      string `json:"nick"`
+		Title     string `json:"title"`
+		StartTime int64  `json:"startTime"`
+	} `json:"data"`
+}
+
+type liveReplayResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    []struct {
+		Vid         string `json:"vid"`
+		Nick        string `json:"nick"`
+		Title       string `json:"title"`
+		Cover       string `json:"cover"`
+		Duration    int64  `json:"duration"`
+		PublishTime int64  `json:"publishTime"`
+	} `json:"data"`
+}
+
+func cacheApiOptions() []requests.Option {
+	return []requests.Option{
+		requests.AddUAOption(),
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.RetryOption(3),
+		requests.TimeoutOption(time.Second * 10),
+	}
+}
+
+// LiveSchedule 查询主播发布的开播预告
+func LiveSchedule(roomId string) ([]*ScheduleInfo, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(liveNoticeResponse)
+	err := requests.Get(CacheApi, gout.H{
+		"m":      "LiveNotice",
+		"do":     "getNoticeList",
+		"roomId": roomId,
+	}, resp, cacheApiOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != 200 {
+		return nil, fmt.Errorf("status %v - %v", resp.Status, resp.Message)
+	}
+	var result []*ScheduleInfo
+	for _, notice := range resp.Data {
+		result = append(result, &ScheduleInfo{
+			RoomId:    roomId,
+			Name:      notice.Nick,
+			Id:        notice.Id,
+			Title:     strings.TrimSpace(notice.Title),
+			StartTime: notice.StartTime,
+		})
+	}
+	return result, nil
+}
+
+// LiveReplay 查询主播最近发布的直播回放
+func LiveReplay(roomId string) ([]*ReplayInfo, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(liveReplayResponse)
+	err := requests.Get(CacheApi, gout.H{
+		"m":      "Video",
+		"do":     "getLiveReplayList",
+		"roomId": roomId,
+	}, resp, cacheApiOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != 200 {
+		return nil, fmt.Errorf("status %v - %v", resp.Status, resp.Message)
+	}
+	var result []*ReplayInfo
+	for _, replay := range resp.Data {
+		if len(replay.Vid) == 0 {
+			return nil, errors.New("empty vid")
+		}
+		var cover = replay.Cover
+		if strings.HasPrefix(cover, "//") {
+			cover = "https:" + cover
+		}
+		result = append(result, &ReplayInfo{
+			RoomId:      roomId,
+			Name:        replay.Nick,
+			Vid:         replay.Vid,
+			Title:       strings.TrimSpace(replay.Title),
+			Cover:       cover,
+			Duration:    replay.Duration,
+			PublishTime: replay.PublishTime,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package huya
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newCacheApiServer(t *testing.T, schedule *string, replay *string) func() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, testRoom, r.URL.Query().Get("roomId"))
+		switch r.URL.Query().Get("m") {
+		case "LiveNotice":
+			fmt.Fprint(w, *schedule)
+		case "Video":
+			fmt.Fprint(w, *replay)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	var old = CacheApi
+	CacheApi = ts.URL + "/cache.php"
+	return func() {
+		CacheApi = old
+		ts.Close()
+	}
+}
+
+func TestLiveScheduleAndReplay(t *testing.T) {
+	var schedule = `{"status":200,"data":[{"id":"1","nick":"name","title":" title ","startTime":1700000000}]}`
+	var replay = `{"status":200,"data":[{"vid":"100","nick":"name","title":"replay","cover":"//cover.jpg","duration":3600,"publishTime":1700000000}]}`
+	defer newCacheApiServer(t, &schedule, &replay)()
+
+	schedules, err := LiveSchedule(testRoom)
+	assert.Nil(t, err)
+	assert.Len(t, schedules, 1)
+	assert.EqualValues(t, &ScheduleInfo{
+		RoomId:    testRoom,
+		Name:      "name",
+		Id:        "1",
+		Title:     "title",
+		StartTime: 1700000000,
+	}, schedules[0])
+
+	replays, err := LiveReplay(testRoom)
+	assert.Nil(t, err)
+	assert.Len(t, replays, 1)
+	assert.Equal(t, "100", replays[0].Vid)
+	assert.Equal(t, "https://cover.jpg", replays[0].Cover)
+	assert.EqualValues(t, 3600, replays[0].Duration)
+
+	schedule = `{"status":500,"message":"error"}`
+	_, err = LiveSchedule(testRoom)
+	assert.NotNil(t, err)
+}
+
+func TestConcern_FreshSchedule(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var start = time.Now().Add(time.Hour).Unix()
+	var schedule = `{"status":200,"data":[]}`
+	var replay = `{"status":200,"data":[]}`
+	defer newCacheApiServer(t, &schedule, &replay)()
+
+	c := NewConcern(nil)
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+
+	// 第一次查询只记录
+	events, err := c.freshSchedule(testRoom)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	schedule = fmt.Sprintf(`{"status":200,"data":[{"id":"1","title":"t","startTime":%v},{"id":"2","startTime":%v}]}`,
+		start, time.Now().Add(-time.Hour).Unix())
+	events, err = c.freshSchedule(testRoom)
+	assert.Nil(t, err)
+	// 新发布的预告以及开播前提醒，已经开始的预告忽略
+	assert.Len(t, events, 2)
+	assert.False(t, events[0].(*ScheduleInfo).Remind())
+	assert.True(t, events[1].(*ScheduleInfo).Remind())
+
+	events, err = c.freshSchedule(testRoom)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	remindEvent := events[0].(*ScheduleInfo)
+	assert.True(t, remindEvent.Remind())
+
+	// 没有配置提醒
+	assert.False(t, c.shouldRemind(mmsg.NewGroupTargetId(test.G1), remindEvent))
+	assert.Empty(t, c.notifyGenerator()(mmsg.NewGroupTargetId(test.G1), remindEvent))
+
+	err = c.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), testRoom, c.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), testRoom), func(config concern.IConfig) bool {
+		config.GetGroupConcernNotify().ScheduleRemind = 30
+		return true
+	})
+	assert.Nil(t, err)
+	// 还没有到提醒时间
+	assert.False(t, c.shouldRemind(mmsg.NewGroupTargetId(test.G1), remindEvent))
+
+	err = c.OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), testRoom, c.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), testRoom), func(config concern.IConfig) bool {
+		config.GetGroupConcernNotify().ScheduleRemind = 90
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Len(t, c.notifyGenerator()(mmsg.NewGroupTargetId(test.G1), remindEvent), 1)
+	// 只提醒一次
+	assert.Empty(t, c.notifyGenerator()(mmsg.NewGroupTargetId(test.G1), remindEvent))
+}
+
+func TestConcern_FreshReplay(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var schedule = `{"status":200,"data":[]}`
+	var replay = `{"status":200,"data":[{"vid":"1","publishTime":100}]}`
+	defer newCacheApiServer(t, &schedule, &replay)()
+
+	c := NewConcern(nil)
+
+	// 第一次查询只记录
+	events, err := c.freshReplay(testRoom)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+	last, err := c.GetLastReplayTime(testRoom)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 100, last)
+
+	replay = `{"status":200,"data":[{"vid":"3","publishTime":300},{"vid":"2","publishTime":200},{"vid":"1","publishTime":100}]}`
+	events, err = c.freshReplay(testRoom)
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "2", events[0].(*ReplayInfo).Vid)
+	assert.Equal(t, "3", events[1].(*ReplayInfo).Vid)
+
+	events, err = c.freshReplay(testRoom)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,50 @@
+package huya
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"time"
+)
+
+// SearchApi 虎牙搜索接口的地址
+var SearchApi = "https://search.cdn.huya.com/"
+
+type SearchResponse struct {
+	Response struct {
+		Anchor struct {
+			Docs []struct {
+				RoomId   int64  `json:"room_id"`
+				GameNick string `json:"game_nick"`
+				// Subscribe 订阅数
+				Subscribe int64 `json:"game_activityCount"`
+			} `json:"docs"`
+		} `json:"1"`
+	} `json:"response"`
+}
+
+// SearchAnchor 使用虎牙的搜索接口按关键字搜索主播
+func SearchAnchor(keyword string) (*SearchResponse, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(SearchResponse)
+	err := requests.Get(SearchApi, gout.H{
+		"m":         "Search",
+		"do":        "getSearchContent",
+		"q":         keyword,
+		"uid":       0,
+		"v":         4,
+		"typ":       -5,
+		"livestate": 0,
+		"rows":      20,
+		"start":     0,
+	}, resp, cacheApiOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("SearchAnchor failed %v", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,32 @@
+package huya
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConcern_Search(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "name", r.URL.Query().Get("q"))
+		fmt.Fprint(w, `{"response":{"1":{"docs":[
+{"room_id":100,"game_nick":"name1","game_activityCount":10},
+{"room_id":200,"game_nick":"name2","game_activityCount":20}
+]}}}`)
+	}))
+	defer ts.Close()
+	var old = SearchApi
+	SearchApi = ts.URL
+	defer func() { SearchApi = old }()
+
+	c := NewConcern(nil)
+	result, err := c.Search("name", 1)
+	assert.Nil(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "100", result[0].Id)
+		assert.Equal(t, "name1", result[0].Name)
+		assert.EqualValues(t, 10, result[0].Followers)
+	}
+}
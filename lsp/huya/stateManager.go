@@ -4,6 +4,7 @@ import (
 	"errors"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"time"
 )
 
@@ -14,13 +15,25 @@ type StateManager struct {
 
 func (c *StateManager) GetLiveInfo(id string) (*LiveInfo, error) {
 	var liveInfo = &LiveInfo{}
-	err := c.GetJson(c.CurrentLiveKey(id), liveInfo)
+	err := c.GetJson(c.CurrentLiveKey(id), liveInfo, localdb.GetCacheOpt())
 	if err != nil {
 		return nil, err
 	}
 	return liveInfo, nil
 }
 
+// GetState 实现 concern.StateExt
+func (c *StateManager) GetState(id interface{}) *concern.State {
+	liveInfo, err := c.GetLiveInfo(id.(string))
+	if err != nil {
+		return nil
+	}
+	return &concern.State{
+		Living: liveInfo.Living(),
+		Title:  liveInfo.RoomName,
+	}
+}
+
 func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
 	if liveInfo == nil {
 		return errors.New("nil LiveInfo")
@@ -33,8 +46,51 @@ func (c *StateManager) DeleteLiveInfo(id string) error {
 	return err
 }
 
-func (c *StateManager) GetGroupConcernConfig(groupCode int64, id interface{}) (concernConfig concern.IConfig) {
-	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(groupCode, id))
+// GetSchedules 返回上一次查询到的开播预告，从来没有查询过时返回 localdb.ErrNotFound
+func (c *StateManager) GetSchedules(roomId string) ([]*ScheduleInfo, error) {
+	var schedules []*ScheduleInfo
+	err := c.GetJson(c.ScheduleKey(roomId), &schedules)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (c *StateManager) SetSchedules(roomId string, schedules []*ScheduleInfo) error {
+	if schedules == nil {
+		schedules = []*ScheduleInfo{}
+	}
+	return c.SetJson(c.ScheduleKey(roomId), schedules, localdb.SetExpireOpt(time.Hour*24*7))
+}
+
+func (c *StateManager) DeleteSchedules(roomId string) error {
+	_, err := c.Delete(c.ScheduleKey(roomId), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// MarkScheduleRemind 标记这个群已经提醒过这个预告，已经提醒过时返回false
+func (c *StateManager) MarkScheduleRemind(target mmsg.TargetId, scheduleId string) bool {
+	err := c.Set(c.ScheduleRemindKey(target, scheduleId), "",
+		localdb.SetExpireOpt(maxScheduleRemind*2), localdb.SetNoOverWriteOpt())
+	return err == nil
+}
+
+// GetLastReplayTime 返回上一次推送的直播回放的发布时间，从来没有查询过时返回 localdb.ErrNotFound
+func (c *StateManager) GetLastReplayTime(roomId string) (int64, error) {
+	return c.GetInt64(c.LastReplayKey(roomId))
+}
+
+func (c *StateManager) SetLastReplayTime(roomId string, ts int64) error {
+	return c.SetInt64(c.LastReplayKey(roomId), ts)
+}
+
+func (c *StateManager) DeleteLastReplayTime(roomId string) error {
+	_, err := c.Delete(c.LastReplayKey(roomId), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
 }
 
 func NewStateManager(notify chan<- concern.Notify) *StateManager {
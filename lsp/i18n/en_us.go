@@ -0,0 +1,16 @@
+package i18n
+
+var enUS = map[string]string{
+	"reply.no_permission":   "Permission denied",
+	"reply.global_disabled": "This command has been disabled by the administrator",
+	"reply.disabled":        "This command is disabled, please enable it and try again",
+	"reply.success":         "Success",
+	"reply.fail":            "Failed - %v",
+	"reply.no_group":        "Failed - please specify the QQ group",
+
+	"locale.current":     "Current language: %v\nSupported languages: %v",
+	"locale.unsupported": "Failed - unsupported language %v, supported languages: %v",
+	"locale.set":         "Success - language is set to %v",
+
+	"notify.translate": "\nTranslation: %v",
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"sort"
+	"strings"
+)
+
+const (
+	ZhCN = "zh-CN"
+	EnUS = "en-US"
+)
+
+// bundles 每种语言的文本，key为文本的id
+var bundles = map[string]map[string]string{
+	ZhCN: zhCN,
+	EnUS: enUS,
+}
+
+// Locales 返回所有支持的语言
+func Locales() []string {
+	var result []string
+	for locale := range bundles {
+		result = append(result, locale)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Normalize 把 zh / zh_cn / EN-us 等写法转换成支持的语言，不支持时返回false
+func Normalize(locale string) (string, bool) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	for _, l := range Locales() {
+		if strings.EqualFold(l, locale) || strings.EqualFold(strings.SplitN(l, "-", 2)[0], locale) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// DefaultLocale 返回 i18n.defaultLocale 配置的语言，没有配置或者配置错误时为 ZhCN
+func DefaultLocale() string {
+	if locale, ok := Normalize(cfg.GetI18nDefaultLocale()); ok {
+		return locale
+	}
+	return ZhCN
+}
+
+// T 返回key在locale中的文本，args不为空时使用 fmt.Sprintf 格式化，
+// locale中没有这个key时使用 ZhCN 的文本，都没有时返回key本身
+func T(locale string, key string, args ...interface{}) string {
+	text, found := bundles[locale][key]
+	if !found {
+		text, found = bundles[ZhCN][key]
+	}
+	if !found {
+		text = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBundles(t *testing.T) {
+	// 所有语言需要包含相同的key
+	for locale, bundle := range bundles {
+		assert.Len(t, bundle, len(zhCN), locale)
+		for key := range zhCN {
+			assert.Contains(t, bundle, key, locale)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for s, expected := range map[string]string{
+		"zh-CN": ZhCN,
+		"zh_cn": ZhCN,
+		"zh":    ZhCN,
+		" EN ":  EnUS,
+		"en-us": EnUS,
+		"en_US": EnUS,
+	} {
+		locale, ok := Normalize(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, locale, s)
+	}
+	for _, s := range []string{"", "ja", "en-GB-x"} {
+		_, ok := Normalize(s)
+		assert.False(t, ok, s)
+	}
+	assert.Equal(t, []string{EnUS, ZhCN}, Locales())
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "权限不够", T(ZhCN, "reply.no_permission"))
+	assert.Equal(t, "Permission denied", T(EnUS, "reply.no_permission"))
+	assert.Equal(t, "Failed - a", T(EnUS, "reply.fail", "a"))
+	assert.Equal(t, "权限不够", T("unknown", "reply.no_permission"))
+	assert.Equal(t, "missing.key", T(EnUS, "missing.key"))
+}
+
+func TestDefaultLocale(t *testing.T) {
+	assert.Equal(t, ZhCN, DefaultLocale())
+	config.GlobalConfig.Set("i18n.defaultLocale", "en")
+	defer config.GlobalConfig.Set("i18n.defaultLocale", nil)
+	assert.Equal(t, EnUS, DefaultLocale())
+}
@@ -0,0 +1,16 @@
+package i18n
+
+var zhCN = map[string]string{
+	"reply.no_permission":   "权限不够",
+	"reply.global_disabled": "无法操作该命令，该命令已被管理员禁用",
+	"reply.disabled":        "该命令已被设置为disable，请设置enable后重试",
+	"reply.success":         "成功",
+	"reply.fail":            "失败 - %v",
+	"reply.no_group":        "失败 - 请指定要操作的QQ群号码",
+
+	"locale.current":     "当前语言：%v\n支持的语言：%v",
+	"locale.unsupported": "失败 - 不支持的语言%v，支持的语言：%v",
+	"locale.set":         "成功 - 已设置语言为%v",
+
+	"notify.translate": "\n翻译：%v",
+}
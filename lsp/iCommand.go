@@ -4,20 +4,22 @@ import (
 	"errors"
 	"fmt"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/permission"
 	"github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/sliceutil"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
 	"sort"
 	"strings"
+	"time"
 )
 
-func IList(c *MessageContext, groupCode int64, site string) {
-	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(groupCode, ListCommand) {
+func IList(c *MessageContext, target mmsg.TargetId, site string) {
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), ListCommand) {
 		c.DisabledReply()
 		return
 	}
@@ -39,8 +41,8 @@ func IList(c *MessageContext, groupCode int64, site string) {
 		targetCM = concern.ListConcern()
 	}
 	for _, c := range targetCM {
-		_, ids, ctypes, err := c.GetStateManager().ListConcernState(func(_groupCode int64, _ interface{}, _ concern_type.Type) bool {
-			return groupCode == _groupCode
+		_, ids, ctypes, err := c.GetStateManager().ListConcernState(func(_target mmsg.TargetId, _ interface{}, _ concern_type.Type) bool {
+			return target == _target
 		})
 		if err == nil {
 			ids, ctypes, err = c.GetStateManager().GroupTypeById(ids, ctypes)
@@ -79,21 +81,75 @@ func IList(c *MessageContext, groupCode int64, site string) {
 	c.Send(listMsg)
 }
 
-func IWatch(c *MessageContext, groupCode int64, id string, site string, watchType concern_type.Type, remove bool) {
+// IRecent 回复本群订阅的id最近的动态，需要订阅模块实现 concern.NewsHistoryExt
+func IRecent(c *MessageContext, target mmsg.TargetId, id string, site string, n int) {
 	log := c.Log
 
-	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(groupCode, WatchCommand) {
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), RecentCommand) {
 		c.DisabledReply()
 		return
 	}
 
-	if !c.Lsp.PermissionStateManager.RequireAny(
-		permission.AdminRoleRequireOption(c.Sender.Uin),
-		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
-		permission.QQAdminRequireOption(groupCode, c.Sender.Uin),
-		permission.GroupCommandRequireOption(groupCode, c.Sender.Uin, WatchCommand),
-		permission.GroupCommandRequireOption(groupCode, c.Sender.Uin, UnwatchCommand),
-	) {
+	site, err := concern.ParseRawSite(site)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	cm, err := concern.GetConcernBySite(site)
+	if err != nil {
+		log.Errorf("GetConcernBySite error %v", err)
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	historyExt, ok := cm.(concern.NewsHistoryExt)
+	if !ok {
+		c.TextReply(fmt.Sprintf("失败 - %v暂不支持查看历史动态", site))
+		return
+	}
+	mid, err := cm.ParseId(id)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - 解析%v id格式错误", site))
+		return
+	}
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, mid); err != nil || ctype.Empty() {
+		c.TextReply("失败 - 该id尚未watch")
+		return
+	}
+	history, err := historyExt.GetRecentNews(mid, n)
+	if err != nil {
+		log.Errorf("GetRecentNews error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	var name = fmt.Sprint(mid)
+	if info, err := cm.Get(mid); err == nil && info != nil {
+		name = info.GetName()
+	}
+	if len(history) == 0 {
+		c.TextReply(fmt.Sprintf("%v暂无保存的动态", name))
+		return
+	}
+	m := mmsg.NewMSG()
+	m.Textf("%v最近的%v条动态：", name, len(history))
+	for _, news := range history {
+		content := []rune(strings.TrimSpace(news.Content))
+		if len(content) > 50 {
+			content = append(content[:50], []rune("...")...)
+		}
+		m.Textf("\n[%v] %v\n%v", time.Unix(news.Timestamp, 0).Format("2006-01-02 15:04"), string(content), news.Url)
+	}
+	c.Send(m)
+}
+
+func IWatch(c *MessageContext, target mmsg.TargetId, id string, site string, watchType concern_type.Type, remove bool) {
+	log := c.Log
+
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), WatchCommand) {
+		c.DisabledReply()
+		return
+	}
+
+	if !requireWatchPermission(c, target) {
 		c.NoPermissionReply()
 		return
 	}
@@ -105,6 +161,12 @@ func IWatch(c *MessageContext, groupCode int64, id string, site string, watchTyp
 		return
 	}
 
+	if !remove && c.Lsp.LspStateManager.CheckGroupModuleDisabled(target.GroupCode(), cm.Site()) {
+		log.Errorf("site %v disabled in group", cm.Site())
+		c.TextReply(fmt.Sprintf("失败 - %v订阅已在本群禁用", cm.Site()))
+		return
+	}
+
 	mid, err := cm.ParseId(id)
 	if err != nil {
 		log.Errorf("Parseid error %v", err)
@@ -115,8 +177,8 @@ func IWatch(c *MessageContext, groupCode int64, id string, site string, watchTyp
 	if remove {
 		// unwatch
 		userInfo, _ := cm.Get(mid)
-		if _, err := cm.Remove(c, groupCode, mid, watchType); err != nil {
-			if err == buntdb.ErrNotFound {
+		if _, err := cm.Remove(c, target, mid, watchType); err != nil {
+			if err == localdb.ErrNotFound {
 				c.TextReply(fmt.Sprintf("unwatch失败 - 未找到该用户"))
 			} else {
 				log.Errorf("site %v remove failed %v", site, err)
@@ -126,15 +188,27 @@ func IWatch(c *MessageContext, groupCode int64, id string, site string, watchTyp
 			if userInfo == nil {
 				userInfo = concern.NewIdentity(mid, "未知")
 			}
+			clearConcernTagIfEmpty(c, target, cm, mid)
+			clearTrialWatchIfRemoved(c, target, cm, mid)
 			log.WithField("name", userInfo.GetName()).Debugf("unwatch success")
+			c.audit(target.GroupCode())
 			c.TextReply(fmt.Sprintf("unwatch成功 - %v用户 %v", site, userInfo.GetName()))
 		}
 		return
 	}
 	// watch
-	userInfo, err := cm.Add(c, groupCode, mid, watchType)
+	if c.Lsp.LspStateManager.CheckBlocklistId(cm.Site(), mid) {
+		log.Errorf("id in blocklist")
+		c.TextReply(fmt.Sprintf("watch失败 - 该id已被管理员禁止订阅"))
+		return
+	}
+	userInfo, err := cm.Add(c, target, mid, watchType)
 	if err != nil {
-		if err == concern.ErrAlreadyExists {
+		if err == concern.ErrAlreadyExists && convertTrialWatch(c, target, cm.Site(), mid) {
+			log.Debugf("trial watch converted")
+			c.audit(target.GroupCode())
+			c.TextReply(fmt.Sprintf("watch成功 - 已将试用订阅转为正式订阅"))
+		} else if err == concern.ErrAlreadyExists {
 			log.Errorf("user already watched")
 			c.TextReply(fmt.Sprintf("watch失败 - 已经watch过了"))
 		} else {
@@ -147,10 +221,234 @@ func IWatch(c *MessageContext, groupCode int64, id string, site string, watchTyp
 		userInfo = concern.NewIdentity(mid, "未知")
 	}
 	log.WithField("name", userInfo.GetName()).Debugf("watch success")
+	c.audit(target.GroupCode())
 	c.TextReply(fmt.Sprintf("watch成功 - %v用户 %v", site, userInfo.GetName()))
 	return
 }
 
+// requireWatchPermission 检查是否有修改群内订阅的权限，/watch /unwatch /tag /unwatchtag 共用
+func requireWatchPermission(c *MessageContext, target mmsg.TargetId) bool {
+	return c.Lsp.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.Sender.Uin),
+		permission.GroupAdminRoleRequireOption(target.GroupCode(), c.Sender.Uin),
+		permission.QQAdminRequireOption(target.GroupCode(), c.Sender.Uin),
+		permission.GroupCommandRequireOption(target.GroupCode(), c.Sender.Uin, WatchCommand),
+		permission.GroupCommandRequireOption(target.GroupCode(), c.Sender.Uin, UnwatchCommand),
+		permission.GroupRoleRequireOption(target.GroupCode(), c.Sender.Uin, permission.GroupManager, permission.GroupSubscriber),
+		permission.PrivateConcernOwnerRequireOption(target, c.Sender.Uin),
+	)
+}
+
+// clearConcernTagIfEmpty 订阅的所有类型都被取消后，删除这个订阅的标签
+func clearConcernTagIfEmpty(c *MessageContext, target mmsg.TargetId, cm concern.Concern, id interface{}) {
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, id); err == nil && !ctype.Empty() {
+		return
+	}
+	if err := c.Lsp.LspStateManager.RemoveConcernTag(target, cm.Site(), id); err != nil {
+		c.GetLog().Errorf("RemoveConcernTag error %v", err)
+	}
+}
+
+// ITagAdd 给群内已经订阅的id添加标签
+func ITagAdd(c *MessageContext, target mmsg.TargetId, id string, site string, rawTags []string) {
+	cm, mid, tags, ok := iTagPrepare(c, target, id, site, rawTags)
+	if !ok {
+		return
+	}
+	if err := c.Lsp.LspStateManager.AddConcernTag(target, cm.Site(), mid, tags...); err != nil {
+		c.GetLog().Errorf("AddConcernTag error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	iTagReplyCurrent(c, target, cm, mid)
+}
+
+// ITagRemove 删除群内订阅的标签
+func ITagRemove(c *MessageContext, target mmsg.TargetId, id string, site string, rawTags []string) {
+	cm, mid, tags, ok := iTagPrepare(c, target, id, site, rawTags)
+	if !ok {
+		return
+	}
+	if err := c.Lsp.LspStateManager.RemoveConcernTag(target, cm.Site(), mid, tags...); err != nil {
+		c.GetLog().Errorf("RemoveConcernTag error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	iTagReplyCurrent(c, target, cm, mid)
+}
+
+func iTagPrepare(c *MessageContext, target mmsg.TargetId, id string, site string, rawTags []string) (cm concern.Concern, mid interface{}, tags []string, ok bool) {
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), TagCommand) {
+		c.DisabledReply()
+		return
+	}
+	if !requireWatchPermission(c, target) {
+		c.NoPermissionReply()
+		return
+	}
+	if len(rawTags) == 0 {
+		c.TextReply("失败 - 没有指定标签")
+		return
+	}
+	for _, rawTag := range rawTags {
+		tag, err := ParseConcernTag(rawTag)
+		if err != nil {
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+		tags = append(tags, tag)
+	}
+	site, err := concern.ParseRawSite(site)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	cm, err = concern.GetConcernBySite(site)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	mid, err = cm.ParseId(id)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - 解析%v id格式错误", site))
+		return
+	}
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, mid); err != nil || ctype.Empty() {
+		c.TextReply("失败 - 该id尚未watch")
+		return
+	}
+	return cm, mid, lo.Uniq(tags), true
+}
+
+func iTagReplyCurrent(c *MessageContext, target mmsg.TargetId, cm concern.Concern, mid interface{}) {
+	var name = fmt.Sprint(mid)
+	if info, err := cm.Get(mid); err == nil && info != nil {
+		name = info.GetName()
+	}
+	tags, err := c.Lsp.LspStateManager.GetConcernTag(target, cm.Site(), mid)
+	if err != nil {
+		c.GetLog().Errorf("GetConcernTag error %v", err)
+	}
+	if len(tags) == 0 {
+		c.TextReply(fmt.Sprintf("成功 - %v用户 %v 当前没有标签", cm.Site(), name))
+		return
+	}
+	c.TextReply(fmt.Sprintf("成功 - %v用户 %v 当前的标签：#%v", cm.Site(), name, strings.Join(tags, " #")))
+}
+
+// ITagList 列出群内的标签，tag不为空时列出这个标签下的订阅
+func ITagList(c *MessageContext, target mmsg.TargetId, tag string) {
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), TagCommand) {
+		c.DisabledReply()
+		return
+	}
+	if len(tag) > 0 {
+		var err error
+		if tag, err = ParseConcernTag(tag); err != nil {
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+	}
+	result, err := c.Lsp.LspStateManager.ListConcernTag(target, tag)
+	if err != nil {
+		c.GetLog().Errorf("ListConcernTag error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	if len(result) == 0 {
+		if len(tag) > 0 {
+			c.TextReply(fmt.Sprintf("标签#%v下暂无订阅", tag))
+		} else {
+			c.TextReply(fmt.Sprintf("暂无标签，可以使用%v命令添加", c.Lsp.CommandShowName(TagCommand)))
+		}
+		return
+	}
+	m := mmsg.NewMSG()
+	var lastTag string
+	for idx, item := range result {
+		if item.Tag != lastTag {
+			if idx > 0 {
+				m.Text("\n")
+			}
+			m.Textf("#%v：", item.Tag)
+			lastTag = item.Tag
+		}
+		var name = item.Id
+		if cm, err := concern.GetConcernBySite(item.Site); err == nil {
+			if mid, err := cm.ParseId(item.Id); err == nil {
+				if info, err := cm.Get(mid); err == nil && info != nil {
+					name = info.GetName()
+				}
+			}
+		}
+		m.Textf("\n%v %v %v", item.Site, name, item.Id)
+	}
+	c.Send(m)
+}
+
+// IUnwatchTag 取消群内带有这个标签的所有订阅
+func IUnwatchTag(c *MessageContext, target mmsg.TargetId, tag string) {
+	log := c.Log
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), UnwatchCommand) ||
+		c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), UnwatchTagCommand) {
+		c.DisabledReply()
+		return
+	}
+	if !requireWatchPermission(c, target) {
+		c.NoPermissionReply()
+		return
+	}
+	tag, err := ParseConcernTag(tag)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	result, err := c.Lsp.LspStateManager.ListConcernTag(target, tag)
+	if err != nil {
+		log.Errorf("ListConcernTag error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	if len(result) == 0 {
+		c.TextReply(fmt.Sprintf("失败 - 标签#%v下暂无订阅", tag))
+		return
+	}
+	var count int
+	var errs []string
+	for _, item := range result {
+		cm, err := concern.GetConcernBySite(item.Site)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v %v：%v", item.Site, item.Id, err))
+			continue
+		}
+		mid, err := cm.ParseId(item.Id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v %v：%v", item.Site, item.Id, err))
+			continue
+		}
+		ctype, err := cm.GetStateManager().GetGroupConcern(target, mid)
+		if err == nil && !ctype.Empty() {
+			if _, err = cm.Remove(c, target, mid, ctype); err != nil {
+				log.WithField("site", item.Site).WithField("id", item.Id).Errorf("remove failed %v", err)
+				errs = append(errs, fmt.Sprintf("%v %v：%v", item.Site, item.Id, err))
+				continue
+			}
+			count++
+		}
+		clearConcernTagIfEmpty(c, target, cm, mid)
+	}
+	if count > 0 {
+		c.audit(target.GroupCode())
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("unwatch成功 - 已取消标签#%v下的%v个订阅", tag, count))
+	for _, e := range errs {
+		sb.WriteString("\n失败 - ")
+		sb.WriteString(e)
+	}
+	c.TextReply(sb.String())
+}
+
 func IEnable(c *MessageContext, groupCode int64, command string, disable bool) {
 	var err error
 	log := c.Log
@@ -173,7 +471,11 @@ func IEnable(c *MessageContext, groupCode int64, command string, disable bool) {
 
 	if !CheckOperateableCommand(command) {
 		log.Errorf("non-operateable command")
-		c.TextReply(fmt.Sprintf("失败 - 【%v】无效命令", command))
+		if c.Lsp.modules.Get(command) != nil {
+			c.TextReply(fmt.Sprintf("失败 - 【%v】无效命令，如需操作%v模块请使用 -m 参数", command, command))
+		} else {
+			c.TextReply(fmt.Sprintf("失败 - 【%v】无效命令", command))
+		}
 		return
 	}
 	if disable {
@@ -198,6 +500,57 @@ func IEnable(c *MessageContext, groupCode int64, command string, disable bool) {
 		}
 		return
 	}
+	c.audit(groupCode)
+	c.TextReply("成功")
+}
+
+// IEnableModule 在群内启用或禁用模块，禁用后群内无法使用模块的命令，也不会收到模块的推送，
+// 对于订阅模块，禁用后还无法在群内新增该网站的订阅
+func IEnableModule(c *MessageContext, groupCode int64, name string, disable bool) {
+	log := c.Log.WithField("module", name)
+
+	if !c.Lsp.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.Sender.Uin),
+		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
+	) {
+		c.NoPermissionReply()
+		return
+	}
+
+	if len(name) == 0 {
+		c.TextReply("失败 - 没有指定要操作的模块名")
+		return
+	}
+	if c.Lsp.modules.Get(name) == nil {
+		c.TextReply(fmt.Sprintf("失败 - 【%v】无效模块，可以操作的模块有：%v", name, strings.Join(c.Lsp.modules.Names(), "/")))
+		return
+	}
+
+	var changed bool
+	var err error
+	if disable {
+		changed, err = c.Lsp.LspStateManager.DisableGroupModule(groupCode, name)
+	} else {
+		changed, err = c.Lsp.LspStateManager.EnableGroupModule(groupCode, name)
+	}
+	if err != nil {
+		log.Errorf("enable module failed %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	if !changed {
+		if disable {
+			c.TextReply("失败 - 该模块已经禁用过了，请不要重复禁用")
+		} else {
+			c.TextReply("失败 - 该模块没有在本群禁用")
+		}
+		return
+	}
+	c.audit(groupCode)
+	if !disable && !c.Lsp.ModuleEnabled(0, name) {
+		c.TextReply(fmt.Sprintf("成功，但%v模块已在配置中禁用，需要在配置中启用后才能使用", name))
+		return
+	}
 	c.TextReply("成功")
 }
 
@@ -205,11 +558,23 @@ func IGrantRole(c *MessageContext, groupCode int64, grantRole permission.RoleTyp
 	var err error
 	log := c.Log.WithField("role", grantRole.String()).WithFields(utils.GroupLogFields(groupCode))
 	switch grantRole {
-	case permission.GroupAdmin:
-		if !c.Lsp.PermissionStateManager.RequireAny(
+	case permission.GroupOwner, permission.GroupAdmin, permission.GroupManager, permission.GroupSubscriber:
+		var opts = []permission.RequireOption{
 			permission.AdminRoleRequireOption(c.Sender.Uin),
-			permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
-		) {
+		}
+		switch grantRole {
+		case permission.GroupOwner:
+			// GroupOwner 只能由bot管理员或者QQ群管理员授予
+			opts = append(opts, permission.QQAdminRequireOption(groupCode, c.Sender.Uin))
+		case permission.GroupAdmin:
+			opts = append(opts, permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin))
+		default:
+			opts = append(opts,
+				permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
+				permission.QQAdminRequireOption(groupCode, c.Sender.Uin),
+			)
+		}
+		if !c.Lsp.PermissionStateManager.RequireAny(opts...) {
 			c.NoPermissionReply()
 			return
 		}
@@ -250,6 +615,7 @@ func IGrantRole(c *MessageContext, groupCode int64, grantRole permission.RoleTyp
 		return
 	}
 	log.Debug("grant success")
+	c.audit(groupCode)
 	c.TextReply("成功")
 }
 
@@ -299,6 +665,7 @@ func IGrantCmd(c *MessageContext, groupCode int64, command string, grantTo int64
 		return
 	}
 	log.Debug("grant success")
+	c.audit(groupCode)
 	c.TextReply("成功")
 }
 
@@ -317,6 +684,7 @@ func ISilenceCmd(c *MessageContext, groupCode int64, delete bool) {
 			err = c.Lsp.PermissionStateManager.GlobalSilence()
 		}
 		if err == nil {
+			c.audit(groupCode)
 			c.TextReply("成功")
 		} else {
 			c.TextReply(fmt.Sprintf("失败 - %v", err))
@@ -343,21 +711,96 @@ func ISilenceCmd(c *MessageContext, groupCode int64, delete bool) {
 		err = c.Lsp.PermissionStateManager.GroupSilence(groupCode)
 	}
 	if err == nil {
+		c.audit(groupCode)
 		c.TextReply("成功")
 	} else {
 		c.TextReply(fmt.Sprintf("失败 - %v", err))
 	}
 }
 
-func IConfigAtCmd(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, action string, QQ []int64) {
-	err := configCmdGroupCommonCheck(c, groupCode)
+// IQuietCmd 管理群的免打扰时段
+func IQuietCmd(c *MessageContext, groupCode int64, action string, values []string) {
+	if groupCode == 0 {
+		c.TextReply("失败 - 请指定要操作的QQ群号码")
+		return
+	}
+	if !c.Lsp.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.Sender.Uin),
+		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
+	) {
+		c.NoPermissionReply()
+		return
+	}
+	quiet := c.Lsp.LspStateManager.GetQuietHours(groupCode)
+	switch action {
+	case "add":
+		if len(values) == 0 {
+			c.TextReply("失败 - 没有要添加的时段")
+			return
+		}
+		for _, v := range values {
+			if _, _, err := parseQuietRange(v); err != nil {
+				c.TextReply(fmt.Sprintf("失败 - %v：%v", v, err))
+				return
+			}
+			if !sliceutil.Contains(quiet.Ranges, v) {
+				quiet.Ranges = append(quiet.Ranges, v)
+			}
+		}
+	case "remove":
+		if len(values) == 0 {
+			c.TextReply("失败 - 没有要删除的时段")
+			return
+		}
+		quiet.Ranges = lo.Filter(quiet.Ranges, func(s string, _ int) bool {
+			return !sliceutil.Contains(values, s)
+		})
+	case "clear":
+		quiet = nil
+	case "drop":
+		quiet.Drop = true
+	case "digest":
+		quiet.Drop = false
+	case "show":
+		if len(quiet.Ranges) == 0 {
+			c.TextReply("当前没有设置免打扰时段")
+			return
+		}
+		sb := strings.Builder{}
+		sb.WriteString("当前免打扰时段：")
+		for _, r := range quiet.Ranges {
+			sb.WriteRune('\n')
+			sb.WriteString(r)
+		}
+		if quiet.Drop {
+			sb.WriteString("\n时段内的推送将被丢弃")
+		} else {
+			sb.WriteString("\n时段内的推送将在结束后汇总发送")
+		}
+		c.TextReply(sb.String())
+		return
+	default:
+		c.Log.Errorf("unknown action")
+		c.TextReply("失败 - 未知操作")
+		return
+	}
+	if err := c.Lsp.LspStateManager.SetQuietHours(groupCode, quiet); err != nil {
+		c.Log.Errorf("SetQuietHours error %v", err)
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	c.TextReply("成功")
+}
+
+func IConfigAtCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, action string, QQ []int64) {
+	err := configCmdGroupCommonCheck(c, target)
 	if err == nil {
 		if action != "show" && action != "clear" && len(QQ) == 0 {
 			c.TextReply("失败 - 没有要操作的指定QQ号")
 			return
 		}
 		if action == "add" {
-			g := utils.GetBot().FindGroup(groupCode)
+			g := utils.GetBot().FindGroup(target.GroupCode())
 			if g == nil {
 				c.TextReply("失败 - 无法找到这个群的信息，如果看到这个信息表示bot出现了一些问题")
 				// 可能没找到吗
@@ -375,7 +818,7 @@ func IConfigAtCmd(c *MessageContext, groupCode int64, id string, site string, ct
 				return
 			}
 		}
-		err = iConfigCmd(c, groupCode, id, site, ctype, operateAtConcernConfig(c, ctype, action, QQ))
+		err = iConfigCmd(c, target, id, site, ctype, operateAtConcernConfig(c, ctype, action, QQ))
 	}
 	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
 		return
@@ -389,8 +832,174 @@ func IConfigAtCmd(c *MessageContext, groupCode int64, id string, site string, ct
 	}
 }
 
-func IConfigAtAllCmd(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, on bool) {
-	err := iConfigCmd(c, groupCode, id, site, ctype, operateAtAllConcernConfig(c, ctype, on))
+func IConfigAtAllCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, on bool) {
+	err := iConfigCmd(c, target, id, site, ctype, operateAtAllConcernConfig(c, ctype, on))
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+	} else {
+		ReplyUserInfo(c, id, site, ctype)
+	}
+}
+
+func IConfigTitleNotifyCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, on bool) {
+	err := iConfigCmd(c, target, id, site, ctype, operateNotifyConcernConfig(c, ctype, on))
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+	} else {
+		ReplyUserInfo(c, id, site, ctype)
+	}
+}
+
+func IConfigLiveChangeNotifyCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, on bool) {
+	err := iConfigCmd(c, target, id, site, ctype, operateLiveChangeNotifyConcernConfig(c, ctype, on))
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+	} else {
+		ReplyUserInfo(c, id, site, ctype)
+	}
+}
+
+func IConfigOfflineNotifyCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, on bool) {
+	err := iConfigCmd(c, target, id, site, ctype, operateOfflineNotifyConcernConfig(c, ctype, on))
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+	} else {
+		ReplyUserInfo(c, id, site, ctype)
+	}
+}
+
+func IConfigRecordCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, on bool) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+		notifyConfig := config.GetGroupConcernNotify()
+		if notifyConfig.CheckRecord() == on {
+			if on {
+				c.TextReply("失败 - 已经配置过了")
+			} else {
+				c.TextReply("失败 - 该配置未设置")
+			}
+			return false
+		}
+		notifyConfig.Record = on
+		return true
+	})
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+		return
+	}
+	ReplyUserInfo(c, id, site, ctype)
+	if on && !cfg.GetRecordEnable() {
+		c.TextSend("注意：配置文件中没有开启 record.enable ，开启后才会录制")
+	} else if on && !c.Lsp.ModuleEnabled(0, RecordModule) {
+		c.TextSend("注意：record模块已在配置中禁用，启用后才会录制")
+	}
+}
+
+func IConfigTranslateCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, on bool) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+		notifyConfig := config.GetGroupConcernNotify()
+		if notifyConfig.CheckTranslate() == on {
+			if on {
+				c.TextReply("失败 - 已经配置过了")
+			} else {
+				c.TextReply("失败 - 该配置未设置")
+			}
+			return false
+		}
+		notifyConfig.Translate = on
+		return true
+	})
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+		return
+	}
+	ReplyUserInfo(c, id, site, ctype)
+	if on && cfg.GetTranslateProvider() == "" {
+		c.TextSend("注意：配置文件中没有设置 translate.provider ，设置后才会翻译")
+	}
+}
+
+func IConfigScheduleRemindCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, minutes int) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+		notifyConfig := config.GetGroupConcernNotify()
+		if notifyConfig.ScheduleRemind == minutes {
+			if minutes > 0 {
+				c.TextReply("失败 - 已经配置过了")
+			} else {
+				c.TextReply("失败 - 该配置未设置")
+			}
+			return false
+		}
+		notifyConfig.ScheduleRemind = minutes
+		return true
+	})
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+		return
+	}
+	ReplyUserInfo(c, id, site, ctype)
+}
+
+func IConfigDanmakuRelayCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, action string, keywords []string) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+		notifyConfig := config.GetGroupConcernNotify()
+		switch action {
+		case "on":
+			notifyConfig.DanmakuRelay = &concern.GroupConcernDanmakuRelayConfig{
+				Enable:   true,
+				Keywords: keywords,
+			}
+			return true
+		case "off":
+			if !notifyConfig.CheckDanmakuRelay() {
+				c.TextReply("失败 - 该配置未设置")
+				return false
+			}
+			notifyConfig.DanmakuRelay = nil
+			return true
+		case "show":
+			relayConfig := notifyConfig.DanmakuRelay
+			if !notifyConfig.CheckDanmakuRelay() {
+				c.TextReply("当前配置为空")
+				return false
+			}
+			sb := strings.Builder{}
+			sb.WriteString("当前配置：\n转发醒目留言及上舰消息")
+			if len(relayConfig.Keywords) > 0 {
+				sb.WriteString("\n转发包含以下关键字的弹幕：")
+				for _, kw := range relayConfig.Keywords {
+					sb.WriteRune('\n')
+					sb.WriteString(kw)
+				}
+			}
+			c.TextReply(sb.String())
+			return false
+		default:
+			c.Log.Errorf("unknown action")
+			c.TextReply("失败 - 未知操作")
+			return false
+		}
+	})
 	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
 		return
 	}
@@ -401,8 +1010,58 @@ func IConfigAtAllCmd(c *MessageContext, groupCode int64, id string, site string,
 	}
 }
 
-func IConfigTitleNotifyCmd(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, on bool) {
-	err := iConfigCmd(c, groupCode, id, site, ctype, operateNotifyConcernConfig(c, ctype, on))
+func IConfigDanmakuAlertCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, action string, keywords []string, users []string) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+		notifyConfig := config.GetGroupConcernNotify()
+		switch action {
+		case "on":
+			alertConfig := &concern.GroupConcernDanmakuAlertConfig{
+				Keywords: keywords,
+				Users:    users,
+			}
+			if alertConfig.Empty() {
+				c.TextReply("失败 - 请至少指定一个关键字或者用户")
+				return false
+			}
+			notifyConfig.DanmakuAlert = alertConfig
+			return true
+		case "off":
+			if !notifyConfig.CheckDanmakuAlert() {
+				c.TextReply("失败 - 该配置未设置")
+				return false
+			}
+			notifyConfig.DanmakuAlert = nil
+			return true
+		case "show":
+			alertConfig := notifyConfig.DanmakuAlert
+			if !notifyConfig.CheckDanmakuAlert() {
+				c.TextReply("当前配置为空")
+				return false
+			}
+			sb := strings.Builder{}
+			sb.WriteString("当前配置：")
+			if len(alertConfig.Keywords) > 0 {
+				sb.WriteString("\n弹幕包含以下关键字时提醒：")
+				for _, kw := range alertConfig.Keywords {
+					sb.WriteRune('\n')
+					sb.WriteString(kw)
+				}
+			}
+			if len(alertConfig.Users) > 0 {
+				sb.WriteString("\n以下用户发言时提醒：")
+				for _, user := range alertConfig.Users {
+					sb.WriteRune('\n')
+					sb.WriteString(user)
+				}
+			}
+			c.TextReply(sb.String())
+			return false
+		default:
+			c.Log.Errorf("unknown action")
+			c.TextReply("失败 - 未知操作")
+			return false
+		}
+	})
 	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
 		return
 	}
@@ -413,8 +1072,48 @@ func IConfigTitleNotifyCmd(c *MessageContext, groupCode int64, id string, site s
 	}
 }
 
-func IConfigOfflineNotifyCmd(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, on bool) {
-	err := iConfigCmd(c, groupCode, id, site, ctype, operateOfflineNotifyConcernConfig(c, ctype, on))
+func IConfigTemplateCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, kind string, action string, text string) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+		templateConfig := config.GetGroupConcernTemplate()
+		switch action {
+		case "set":
+			if strings.TrimSpace(text) == "" {
+				c.TextReply("失败 - 没有指定模板内容")
+				return false
+			}
+			if err := templateConfig.Set(kind, text); err != nil {
+				c.TextReply(fmt.Sprintf("失败 - %v", err))
+				return false
+			}
+			return true
+		case "clear":
+			if t, _ := templateConfig.Get(kind); t == "" {
+				c.TextReply("失败 - 该配置未设置")
+				return false
+			}
+			if err := templateConfig.Clear(kind); err != nil {
+				c.TextReply(fmt.Sprintf("失败 - %v", err))
+				return false
+			}
+			return true
+		case "show":
+			t, err := templateConfig.Get(kind)
+			if err != nil {
+				c.TextReply(fmt.Sprintf("失败 - %v", err))
+				return false
+			}
+			if t == "" {
+				c.TextReply("当前配置为空")
+				return false
+			}
+			c.TextReply(fmt.Sprintf("当前配置：\n%v", t))
+			return false
+		default:
+			c.Log.Errorf("unknown action")
+			c.TextReply("失败 - 未知操作")
+			return false
+		}
+	})
 	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
 		return
 	}
@@ -425,14 +1124,14 @@ func IConfigOfflineNotifyCmd(c *MessageContext, groupCode int64, id string, site
 	}
 }
 
-func IConfigFilterCmdType(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, types []string) {
-	err := configCmdGroupCommonCheck(c, groupCode)
+func IConfigFilterCmdType(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, types []string) {
+	err := configCmdGroupCommonCheck(c, target)
 	if err == nil {
 		if len(types) == 0 {
 			c.TextReply("失败 - 没有指定过滤类型")
 			return
 		}
-		err = iConfigCmd(c, groupCode, id, site, ctype, func(config concern.IConfig) bool {
+		err = iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
 			config.GetGroupConcernFilter().Type = concern.FilterTypeType
 			filterConfig := &concern.GroupConcernFilterConfigByType{Type: types}
 			config.GetGroupConcernFilter().Config = filterConfig.ToString()
@@ -449,15 +1148,15 @@ func IConfigFilterCmdType(c *MessageContext, groupCode int64, id string, site st
 	}
 }
 
-func IConfigFilterCmdNotType(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, types []string) {
-	err := configCmdGroupCommonCheck(c, groupCode)
+func IConfigFilterCmdNotType(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, types []string) {
+	err := configCmdGroupCommonCheck(c, target)
 	if err == nil {
 
 		if len(types) == 0 {
 			c.TextReply("失败 - 没有指定过滤类型")
 			return
 		}
-		err = iConfigCmd(c, groupCode, id, site, ctype, func(config concern.IConfig) bool {
+		err = iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
 			config.GetGroupConcernFilter().Type = concern.FilterTypeNotType
 			filterConfig := &concern.GroupConcernFilterConfigByType{Type: types}
 			config.GetGroupConcernFilter().Config = filterConfig.ToString()
@@ -474,16 +1173,52 @@ func IConfigFilterCmdNotType(c *MessageContext, groupCode int64, id string, site
 	}
 }
 
-func IConfigFilterCmdText(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, keywords []string) {
-	err := configCmdGroupCommonCheck(c, groupCode)
+func IConfigFilterCmdText(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, keywords []string) {
+	iConfigFilterCmdByText(c, target, id, site, ctype, keywords, "失败 - 没有指定过滤关键字",
+		func(filter *concern.GroupConcernFilterConfigByText) {
+			filter.Text = keywords
+		})
+}
+
+func IConfigFilterCmdNotText(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, keywords []string) {
+	iConfigFilterCmdByText(c, target, id, site, ctype, keywords, "失败 - 没有指定过滤关键字",
+		func(filter *concern.GroupConcernFilterConfigByText) {
+			filter.NotText = keywords
+		})
+}
+
+func IConfigFilterCmdRegex(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, exprs []string) {
+	iConfigFilterCmdByText(c, target, id, site, ctype, exprs, "失败 - 没有指定正则表达式",
+		func(filter *concern.GroupConcernFilterConfigByText) {
+			filter.Regex = exprs
+		})
+}
+
+func IConfigFilterCmdNotRegex(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, exprs []string) {
+	iConfigFilterCmdByText(c, target, id, site, ctype, exprs, "失败 - 没有指定正则表达式",
+		func(filter *concern.GroupConcernFilterConfigByText) {
+			filter.NotRegex = exprs
+		})
+}
+
+// iConfigFilterCmdByText 修改关键字过滤器中的一项规则，如果当前已经是关键字过滤器，则保留其他规则
+func iConfigFilterCmdByText(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type,
+	values []string, emptyReply string, set func(filter *concern.GroupConcernFilterConfigByText)) {
+	err := configCmdGroupCommonCheck(c, target)
 	if err == nil {
-		if len(keywords) == 0 {
-			c.TextReply("失败 - 没有指定过滤关键字")
+		if len(values) == 0 {
+			c.TextReply(emptyReply)
 			return
 		}
-		err = iConfigCmd(c, groupCode, id, site, ctype, func(config concern.IConfig) bool {
+		err = iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
+			var filterConfig = new(concern.GroupConcernFilterConfigByText)
+			if config.GetGroupConcernFilter().Type == concern.FilterTypeText {
+				if oldConfig, err := config.GetGroupConcernFilter().GetFilterByText(); err == nil {
+					filterConfig = oldConfig
+				}
+			}
+			set(filterConfig)
 			config.GetGroupConcernFilter().Type = concern.FilterTypeText
-			filterConfig := &concern.GroupConcernFilterConfigByText{Text: keywords}
 			config.GetGroupConcernFilter().Config = filterConfig.ToString()
 			return true
 		})
@@ -498,8 +1233,8 @@ func IConfigFilterCmdText(c *MessageContext, groupCode int64, id string, site st
 	}
 }
 
-func IConfigFilterCmdClear(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type) {
-	err := iConfigCmd(c, groupCode, id, site, ctype, func(config concern.IConfig) bool {
+func IConfigFilterCmdClear(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
 		*config.GetGroupConcernFilter() = concern.GroupConcernFilterConfig{}
 		return true
 	})
@@ -513,8 +1248,8 @@ func IConfigFilterCmdClear(c *MessageContext, groupCode int64, id string, site s
 	}
 }
 
-func IConfigFilterCmdShow(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type) {
-	err := iConfigCmd(c, groupCode, id, site, ctype, func(config concern.IConfig) bool {
+func IConfigFilterCmdShow(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type) {
+	err := iConfigCmd(c, target, id, site, ctype, func(config concern.IConfig) bool {
 		if config.GetGroupConcernFilter().Empty() {
 			c.TextReply("当前配置为空")
 			return false
@@ -530,9 +1265,24 @@ func IConfigFilterCmdShow(c *MessageContext, groupCode int64, id string, site st
 				c.TextReply("查询失败 - 内部错误")
 				return false
 			}
-			for _, kw := range filter.Text {
-				sb.WriteString(kw)
+			for _, rule := range []struct {
+				desc   string
+				values []string
+			}{
+				{"包含以下任意关键字时推送：", filter.Text},
+				{"包含以下任意关键字时不推送：", filter.NotText},
+				{"匹配以下任意正则表达式时推送：", filter.Regex},
+				{"匹配以下任意正则表达式时不推送：", filter.NotRegex},
+			} {
+				if len(rule.values) == 0 {
+					continue
+				}
+				sb.WriteString(rule.desc)
 				sb.WriteRune('\n')
+				for _, kw := range rule.values {
+					sb.WriteString(kw)
+					sb.WriteRune('\n')
+				}
 			}
 		case concern.FilterTypeType, concern.FilterTypeNotType:
 			filter, err := config.GetGroupConcernFilter().GetFilterByType()
@@ -564,8 +1314,8 @@ func IConfigFilterCmdShow(c *MessageContext, groupCode int64, id string, site st
 	}
 }
 
-func iConfigCmd(c *MessageContext, groupCode int64, id string, site string, ctype concern_type.Type, f func(config concern.IConfig) bool) (err error) {
-	if err = configCmdGroupCommonCheck(c, groupCode); err != nil {
+func iConfigCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, f func(config concern.IConfig) bool) (err error) {
+	if err = configCmdGroupCommonCheck(c, target); err != nil {
 		return err
 	}
 	if !sliceutil.Contains(concern.ListSite(), site) {
@@ -577,24 +1327,82 @@ func iConfigCmd(c *MessageContext, groupCode int64, id string, site string, ctyp
 		c.TextReply(fmt.Sprintf("失败 - %v", err))
 		return
 	}
+	if strings.HasPrefix(id, "#") {
+		if err = iConfigTagCmd(c, target, id, cm, ctype, f); err == nil {
+			c.audit(target.GroupCode())
+		}
+		return
+	}
 	mid, err := cm.ParseId(id)
 	if err != nil {
 		return fmt.Errorf("%v解析Id失败 - %v", cm.Site(), err)
 	}
-	err = cm.GetStateManager().CheckGroupConcern(groupCode, mid, ctype)
+	err = cm.GetStateManager().CheckGroupConcern(target, mid, ctype)
 	if err != concern.ErrAlreadyExists {
 		return errors.New("失败 - 该id尚未watch")
 	}
-	cfg := cm.GetStateManager().GetGroupConcernConfig(groupCode, mid)
-	err = cm.GetStateManager().OperateGroupConcernConfig(groupCode, mid, cfg, f)
+	cfg := cm.GetStateManager().GetGroupConcernConfig(target, mid)
+	err = cm.GetStateManager().OperateGroupConcernConfig(target, mid, cfg, f)
 	if err != nil && !localdb.IsRollback(err) {
 		c.GetLog().Errorf("OperateGroupConcernConfig failed %v", err)
 		err = fmt.Errorf("失败 - %v", err)
 	}
+	if err == nil {
+		c.audit(target.GroupCode())
+	}
 	return
 }
 
+// iConfigTagCmd 对标签下所有这个网站的订阅执行同样的配置，没有订阅ctype的id会被跳过
+func iConfigTagCmd(c *MessageContext, target mmsg.TargetId, rawTag string, cm concern.Concern, ctype concern_type.Type, f func(config concern.IConfig) bool) error {
+	tag, err := ParseConcernTag(rawTag)
+	if err != nil {
+		return fmt.Errorf("失败 - %v", err)
+	}
+	result, err := c.Lsp.LspStateManager.ListConcernTag(target, tag)
+	if err != nil {
+		c.GetLog().Errorf("ListConcernTag error %v", err)
+		return errors.New("失败 - 内部错误")
+	}
+	var count int
+	var rollbackErr error
+	for _, item := range result {
+		if item.Site != cm.Site() {
+			continue
+		}
+		mid, err := cm.ParseId(item.Id)
+		if err != nil {
+			continue
+		}
+		if cm.GetStateManager().CheckGroupConcern(target, mid, ctype) != concern.ErrAlreadyExists {
+			continue
+		}
+		cfg := cm.GetStateManager().GetGroupConcernConfig(target, mid)
+		err = cm.GetStateManager().OperateGroupConcernConfig(target, mid, cfg, f)
+		if localdb.IsRollback(err) {
+			rollbackErr = err
+			continue
+		}
+		if err != nil {
+			c.GetLog().Errorf("OperateGroupConcernConfig failed %v", err)
+			return fmt.Errorf("失败 - %v", err)
+		}
+		count++
+	}
+	if count == 0 {
+		if rollbackErr != nil {
+			return rollbackErr
+		}
+		return fmt.Errorf("失败 - 标签#%v下没有%v订阅", tag, cm.Site())
+	}
+	return nil
+}
+
 func ReplyUserInfo(c *MessageContext, id string, site string, ctype concern_type.Type) {
+	if strings.HasPrefix(id, "#") {
+		c.TextReply(fmt.Sprintf("成功 - 已修改标签%v下的%v订阅", id, site))
+		return
+	}
 	cm, err := concern.GetConcernBySiteAndType(site, ctype)
 	if err != nil {
 		c.GetLog().Errorf("GetConcernManager error %v", err)
@@ -617,17 +1425,19 @@ func ReplyUserInfo(c *MessageContext, id string, site string, ctype concern_type
 	c.TextReply(fmt.Sprintf("成功 - %v用户 %v", site, info.GetName()))
 }
 
-func configCmdGroupCommonCheck(c *MessageContext, groupCode int64) error {
-	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(groupCode, ConfigCommand) {
+func configCmdGroupCommonCheck(c *MessageContext, target mmsg.TargetId) error {
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), ConfigCommand) {
 		c.DisabledReply()
 		return permission.ErrDisabled
 	}
 
 	if !c.Lsp.PermissionStateManager.RequireAny(
 		permission.AdminRoleRequireOption(c.Sender.Uin),
-		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
-		permission.QQAdminRequireOption(groupCode, c.Sender.Uin),
-		permission.GroupCommandRequireOption(groupCode, c.Sender.Uin, ConfigCommand),
+		permission.GroupAdminRoleRequireOption(target.GroupCode(), c.Sender.Uin),
+		permission.QQAdminRequireOption(target.GroupCode(), c.Sender.Uin),
+		permission.GroupCommandRequireOption(target.GroupCode(), c.Sender.Uin, ConfigCommand),
+		permission.GroupRoleRequireOption(target.GroupCode(), c.Sender.Uin, permission.GroupManager),
+		permission.PrivateConcernOwnerRequireOption(target, c.Sender.Uin),
 	) {
 		c.NoPermissionReply()
 		return permission.ErrPermissionDenied
@@ -673,6 +1483,7 @@ func operateAtAllConcernConfig(c *MessageContext, ctype concern_type.Type, on bo
 			} else {
 				// 取消配置@all
 				concernConfig.GetGroupConcernAt().AtAll = concernConfig.GetGroupConcernAt().AtAll.Remove(ctype)
+				concernConfig.GetGroupConcernAt().ClearMentionMode(ctype)
 				return true
 			}
 		} else {
@@ -683,6 +1494,7 @@ func operateAtAllConcernConfig(c *MessageContext, ctype concern_type.Type, on bo
 			} else {
 				// 配置@all
 				concernConfig.GetGroupConcernAt().AtAll = concernConfig.GetGroupConcernAt().AtAll.Add(ctype)
+				concernConfig.GetGroupConcernAt().ClearMentionMode(ctype)
 				return true
 			}
 		}
@@ -715,6 +1527,31 @@ func operateNotifyConcernConfig(c *MessageContext, ctype concern_type.Type, on b
 	}
 }
 
+func operateLiveChangeNotifyConcernConfig(c *MessageContext, ctype concern_type.Type, on bool) func(concernConfig concern.IConfig) bool {
+	return func(concernConfig concern.IConfig) bool {
+		if concernConfig.GetGroupConcernNotify().CheckLiveChangeNotify(ctype) {
+			if on {
+				// 配置推送，但已经配置过了
+				c.TextReply("失败 - 已经配置过了")
+				return false
+			} else {
+				// 取消配置推送
+				concernConfig.GetGroupConcernNotify().LiveChangeNotify = concernConfig.GetGroupConcernNotify().LiveChangeNotify.Remove(ctype)
+				return true
+			}
+		} else {
+			if !on {
+				// 取消配置，但并没有配置
+				c.TextReply("失败 - 该配置未设置")
+				return false
+			} else {
+				concernConfig.GetGroupConcernNotify().LiveChangeNotify = concernConfig.GetGroupConcernNotify().LiveChangeNotify.Add(ctype)
+				return true
+			}
+		}
+	}
+}
+
 func operateOfflineNotifyConcernConfig(c *MessageContext, ctype concern_type.Type, on bool) func(concernConfig concern.IConfig) bool {
 	return func(concernConfig concern.IConfig) bool {
 		if concernConfig.GetGroupConcernNotify().CheckOfflineNotify(ctype) {
@@ -748,15 +1585,18 @@ func IAbnormalConcernCheck(c *MessageContext) {
 		return
 	}
 
-	var allGroups = make(map[int64]bool)
+	var allGroups = make(map[mmsg.TargetId]bool)
 	for _, groups := range utils.GetBot().GetGroupList() {
-		allGroups[groups.Code] = true
+		allGroups[mmsg.NewGroupTargetId(groups.Code)] = true
+	}
+	for _, friend := range utils.GetBot().GetFriendList() {
+		allGroups[mmsg.NewPrivateTargetId(friend.Uin)] = true
 	}
 
-	var allConcernGroups = make(map[int64]int)
+	var allConcernGroups = make(map[mmsg.TargetId]int)
 	for _, cm := range concern.ListConcern() {
-		_, _, _, err := cm.GetStateManager().ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
-			allConcernGroups[groupCode] += 1
+		_, _, _, err := cm.GetStateManager().ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+			allConcernGroups[target] += 1
 			return true
 		})
 		if err != nil {
@@ -764,11 +1604,19 @@ func IAbnormalConcernCheck(c *MessageContext) {
 			return
 		}
 	}
-	var unknownGroups [][2]int64
+	type unknownGroup struct {
+		target mmsg.TargetId
+		number int
+	}
+	var unknownGroups []unknownGroup
 
-	for groupCode, number := range allConcernGroups {
-		if _, found := allGroups[groupCode]; !found {
-			unknownGroups = append(unknownGroups, [2]int64{groupCode, int64(number)})
+	for target, number := range allConcernGroups {
+		// Telegram订阅无法通过QQ检查
+		if target.TargetType().IsTelegram() {
+			continue
+		}
+		if _, found := allGroups[target]; !found {
+			unknownGroups = append(unknownGroups, unknownGroup{target, number})
 		}
 	}
 
@@ -783,11 +1631,14 @@ func IAbnormalConcernCheck(c *MessageContext) {
 	} else {
 		// 让结果稳定
 		sort.Slice(unknownGroups, func(i, j int) bool {
-			return unknownGroups[i][0] < unknownGroups[j][0]
+			if unknownGroups[i].target.Type != unknownGroups[j].target.Type {
+				return unknownGroups[i].target.Type < unknownGroups[j].target.Type
+			}
+			return unknownGroups[i].target.Code < unknownGroups[j].target.Code
 		})
 		m.Textf("共查询到%v个异常群号:\n", len(unknownGroups))
-		for _, pair := range unknownGroups {
-			m.Textf("群 %v - %v个订阅\n", pair[0], pair[1])
+		for _, item := range unknownGroups {
+			m.Textf("%v - %v个订阅\n", item.target.Name(), item.number)
 		}
 		m.Textf("可以使用<%v --abnormal>命令清除异常群订阅", c.Lsp.CommandShowName(CleanConcern))
 	}
@@ -816,9 +1667,9 @@ func ICleanConcern(c *MessageContext, abnormal bool, groupCodes []int64, rawSite
 		}
 	}
 	type cleanItem struct {
-		groupCode int64
-		id        interface{}
-		tp        concern_type.Type
+		target mmsg.TargetId
+		id     interface{}
+		tp     concern_type.Type
 	}
 
 	type cleanResult struct {
@@ -827,8 +1678,8 @@ func ICleanConcern(c *MessageContext, abnormal bool, groupCodes []int64, rawSite
 	}
 
 	var (
-		cleanGroupCode = make(map[int64]bool)
-		allGroups      = make(map[int64]bool)
+		cleanGroupCode = make(map[mmsg.TargetId]bool)
+		allGroups      = make(map[mmsg.TargetId]bool)
 		site           string
 		err            error
 		tp             concern_type.Type
@@ -837,11 +1688,14 @@ func ICleanConcern(c *MessageContext, abnormal bool, groupCodes []int64, rawSite
 	)
 
 	for _, code := range groupCodes {
-		cleanGroupCode[code] = true
+		cleanGroupCode[mmsg.NewGroupTargetId(code)] = true
 	}
 
 	for _, groups := range utils.GetBot().GetGroupList() {
-		allGroups[groups.Code] = true
+		allGroups[mmsg.NewGroupTargetId(groups.Code)] = true
+	}
+	for _, friend := range utils.GetBot().GetFriendList() {
+		allGroups[mmsg.NewPrivateTargetId(friend.Uin)] = true
 	}
 
 	for _, cm := range concern.ListConcern() {
@@ -869,24 +1723,24 @@ func ICleanConcern(c *MessageContext, abnormal bool, groupCodes []int64, rawSite
 			site: site,
 			tp:   tp,
 		})
-		_, _, _, err = cm.GetStateManager().ListConcernState(func(groupCode int64, id interface{}, p concern_type.Type) bool {
+		_, _, _, err = cm.GetStateManager().ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 			var itp = p.Intersection(tp)
 			if itp.Empty() {
 				return true
 			}
 			if abnormal {
-				if _, found := allGroups[groupCode]; found {
+				if _, found := allGroups[target]; found || target.TargetType().IsTelegram() {
 					return true
 				}
 			} else {
-				if _, found := cleanGroupCode[groupCode]; !found {
+				if _, found := cleanGroupCode[target]; !found {
 					return true
 				}
 			}
 			itemMap[site] = append(itemMap[site], &cleanItem{
-				groupCode: groupCode,
-				id:        id,
-				tp:        itp,
+				target: target,
+				id:     id,
+				tp:     itp,
 			})
 			return true
 		})
@@ -904,8 +1758,8 @@ func ICleanConcern(c *MessageContext, abnormal bool, groupCodes []int64, rawSite
 			return
 		}
 		for _, item := range items {
-			_, err = cm.Remove(c, item.groupCode, item.id, item.tp)
-			if err == buntdb.ErrNotFound {
+			_, err = cm.Remove(c, item.target, item.id, item.tp)
+			if err == localdb.ErrNotFound {
 				continue
 			} else if err != nil {
 				c.TextReply(fmt.Sprintf("失败 - %v", err))
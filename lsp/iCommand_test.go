@@ -112,20 +112,20 @@ func TestIList(t *testing.T) {
 
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G2, ListCommand))
 
-	IList(ctx, test.G1, "xxx")
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), "xxx")
 	result := <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, ListCommand))
 
-	IList(ctx, test.G1, "xxx")
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), "xxx")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, ListCommand))
 	assert.Nil(t, Instance.PermissionStateManager.GlobalDisableGroupCommand(ListCommand))
 
-	IList(ctx, test.G1, "xxx")
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), "xxx")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 	assert.Nil(t, Instance.PermissionStateManager.GlobalEnableGroupCommand(ListCommand))
@@ -146,39 +146,153 @@ func TestIList(t *testing.T) {
 	assert.Contains(t, concern.ListSite(), test.Site1)
 	assert.Contains(t, concern.ListSite(), test.Site2)
 
-	IList(ctx, test.G1, "")
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "暂无订阅")
 
-	_, err := tc1.GetStateManager().AddGroupConcern(test.G1, test.NAME1, test.T1)
+	_, err := tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc2.GetStateManager().AddGroupConcern(test.G1, test.NAME2, test.T2)
+	_, err = tc2.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME2, test.T2)
 	assert.Nil(t, err)
 
-	IList(ctx, test.G1, "")
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements),
 		fmt.Sprintf("%v %v %v", test.NAME1, test.NAME1, test.T1))
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements),
 		fmt.Sprintf("%v %v %v", test.NAME2, test.NAME2, test.T2))
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G2, test.NAME1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.NAME1, test.T1)
 	assert.Nil(t, err)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G2, ListCommand))
-	IList(ctx, test.G2, "")
+	IList(ctx, mmsg.NewGroupTargetId(test.G2), "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements),
 		fmt.Sprintf("%v %v %v", test.NAME1, test.NAME1, test.T1))
 	assert.NotContains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME2)
 
-	IList(ctx, test.G1, tc1.Site())
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), tc1.Site())
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements),
 		fmt.Sprintf("%v %v %v", test.NAME1, test.NAME1, test.T1))
 	assert.NotContains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME2)
 }
 
+func TestIRecent(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+
+	IRecent(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, "xxx", 3)
+	result := <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, RecentCommand))
+	IRecent(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, "xxx", 3)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
+	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, RecentCommand))
+
+	testEventChan := make(chan concern.Event, 16)
+	tc1 := newTestConcern(t, testEventChan, nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	_, err := tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1)
+	assert.Nil(t, err)
+
+	IRecent(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, 3)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "暂不支持查看历史动态")
+}
+
+func TestITag(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	var msgString = func() string {
+		result := <-msgChan
+		return msgstringer.MsgToString(result.ToCombineMessage(target).Elements)
+	}
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	ITagAdd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, []string{"holo"})
+	assert.Contains(t, msgString(), noPermission)
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	ITagAdd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, []string{"holo"})
+	assert.Contains(t, msgString(), "尚未watch")
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	assert.Contains(t, msgString(), success)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME2, test.Site1, test.T1, false)
+	assert.Contains(t, msgString(), success)
+
+	ITagAdd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, []string{"a:b"})
+	assert.Contains(t, msgString(), failed)
+	ITagAdd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, nil)
+	assert.Contains(t, msgString(), failed)
+
+	ITagAdd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, []string{"Holo", "#game", "holo"})
+	assert.Contains(t, msgString(), "#game #holo")
+	ITagAdd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME2, test.Site1, []string{"holo"})
+	assert.Contains(t, msgString(), "#holo")
+
+	ITagRemove(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, []string{"game"})
+	msg := msgString()
+	assert.Contains(t, msg, "#holo")
+	assert.NotContains(t, msg, "game")
+
+	ITagList(ctx, mmsg.NewGroupTargetId(test.G1), "")
+	msg = msgString()
+	assert.Contains(t, msg, "#holo")
+	assert.Contains(t, msg, test.NAME1)
+	assert.Contains(t, msg, test.NAME2)
+
+	ITagList(ctx, mmsg.NewGroupTargetId(test.G1), "game")
+	assert.Contains(t, msgString(), "暂无订阅")
+
+	// 使用标签批量配置
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), "#holo", test.Site1, test.T1, true)
+	assert.Contains(t, msgString(), success)
+	assert.True(t, tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1).GetGroupConcernNotify().CheckOfflineNotify(test.T1))
+	assert.True(t, tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME2).GetGroupConcernNotify().CheckOfflineNotify(test.T1))
+
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), "#game", test.Site1, test.T1, true)
+	assert.Contains(t, msgString(), failed)
+
+	IUnwatchTag(ctx, mmsg.NewGroupTargetId(test.G1), "game")
+	assert.Contains(t, msgString(), failed)
+
+	IUnwatchTag(ctx, mmsg.NewGroupTargetId(test.G1), "holo")
+	assert.Contains(t, msgString(), "2个订阅")
+
+	_, ids, _, err := tc1.GetStateManager().ListConcernState(func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+		return target == mmsg.NewGroupTargetId(test.G1)
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, ids)
+
+	tags, err := Instance.LspStateManager.ListConcernTag(mmsg.NewGroupTargetId(test.G1), "")
+	assert.Nil(t, err)
+	assert.Empty(t, tags)
+}
+
 func TestIEnable(t *testing.T) {
 	initLsp(t)
 	defer closeLsp(t)
@@ -293,6 +407,56 @@ func TestIGrantRole(t *testing.T) {
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "失败 - 目标未有该权限")
 }
 
+func TestIGrantRole_GroupRoles(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	ctx2 := NewCtx(t, msgChan, test.Sender2, target)
+
+	// FindMember 使用二分查找，需要按qq号顺序添加
+	localutils.GetBot().TESTAddMember(test.G1, test.Sender1.Uin, client.Member)
+	localutils.GetBot().TESTAddMember(test.G1, test.UID2, client.Member)
+	localutils.GetBot().TESTAddMember(test.G1, test.UID3, client.Member)
+
+	IGrantRole(ctx, test.G1, permission.GroupOwner, test.UID2, false)
+	result := <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+
+	// GroupAdmin 不能授予 GroupOwner
+	assert.Nil(t, Instance.PermissionStateManager.GrantGroupRole(test.G1, test.Sender1.Uin, permission.GroupAdmin))
+	IGrantRole(ctx, test.G1, permission.GroupOwner, test.UID2, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+
+	// QQ群管理员可以授予 GroupOwner
+	localutils.GetBot().FindGroup(test.G1).FindMember(test.Sender1.Uin).Permission = client.Administrator
+	IGrantRole(ctx, test.G1, permission.GroupOwner, test.UID2, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	// GroupOwner 可以授予其他角色
+	IGrantRole(ctx2, test.G1, permission.GroupSubscriber, test.UID3, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+	assert.True(t, Instance.PermissionStateManager.CheckGroupRole(test.G1, test.UID3, permission.GroupSubscriber))
+
+	IGrantRole(ctx2, test.G1, permission.GroupManager, test.UID3, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IGrantRole(ctx2, test.G1, permission.GroupSubscriber, test.UID3, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+	assert.False(t, Instance.PermissionStateManager.CheckGroupRole(test.G1, test.UID3, permission.GroupSubscriber))
+
+	IGrantRole(ctx2, test.G1, permission.GroupOwner, test.UID3, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+}
+
 func TestIGrantCmd(t *testing.T) {
 	initLsp(t)
 	defer closeLsp(t)
@@ -418,7 +582,7 @@ func TestIWatch(t *testing.T) {
 	target := mmsg.NewGroupTarget(test.G1)
 	ctx := NewCtx(t, msgChan, test.Sender1, target)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
 
@@ -426,13 +590,13 @@ func TestIWatch(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, WatchCommand))
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, WatchCommand))
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
@@ -444,23 +608,57 @@ func TestIWatch(t *testing.T) {
 	concern.RegisterConcern(tc2)
 	defer tc2.Stop()
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	// 在群内禁用整个订阅模块
+	Instance.registerModules()
+	defer func() { Instance.modules = nil }()
+
+	// 订阅模块不是命令，需要使用 -m
+	IEnable(ctx, test.G1, test.Site1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "-m")
+
+	IEnableModule(ctx, test.G1, "invalid", true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "无效模块")
+
+	IEnableModule(ctx, test.G1, test.Site1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+	assert.False(t, Instance.ModuleEnabled(test.G1, test.Site1))
+	assert.True(t, Instance.ModuleEnabled(test.G2, test.Site1))
+	// 与命令开关互不影响
+	assert.False(t, Instance.PermissionStateManager.CheckGroupCommandDisabled(test.G1, test.Site1))
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IEnableModule(ctx, test.G1, test.Site1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IEnableModule(ctx, test.G1, test.Site1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+	assert.True(t, Instance.ModuleEnabled(test.G1, test.Site1))
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	testEventChan1 <- tc1.NewTestEvent(test.T1, 0, test.NAME1)
+	testEventChan1 <- tc1.NewTestEvent(test.T1, mmsg.TargetId{}, test.NAME1)
 
 	select {
 	case <-testNotifyChan:
@@ -468,22 +666,22 @@ func TestIWatch(t *testing.T) {
 	case <-time.After(time.Second):
 	}
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IWatch(ctx, test.G2, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G2), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	testEventChan1 <- tc1.NewTestEvent(test.T1, 0, test.NAME1)
+	testEventChan1 <- tc1.NewTestEvent(test.T1, mmsg.TargetId{}, test.NAME1)
 
 	for i := 0; i < 2; i++ {
 		select {
 		case notify := <-testNotifyChan:
 			assert.EqualValues(t, test.NAME1, notify.GetUid())
 			assert.EqualValues(t, test.Site1, notify.Site())
-			assert.Contains(t, []int64{test.G1, test.G2}, notify.GetGroupCode())
+			assert.Contains(t, []mmsg.TargetId{mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2)}, notify.GetTarget())
 		case <-time.After(time.Second):
 			assert.Fail(t, "no item received")
 		}
@@ -513,7 +711,7 @@ func TestIConfigAtCmd(t *testing.T) {
 	concern.RegisterConcern(tc2)
 	defer tc2.Stop()
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "show", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
 
@@ -521,65 +719,65 @@ func TestIConfigAtCmd(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "show", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, ConfigCommand))
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "show", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "当前配置为空")
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "add", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "add", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "add", []int64{test.UID1, test.UID2})
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "add", []int64{test.UID1, test.UID2})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
 	localutils.GetBot().TESTAddGroup(test.G1)
 	localutils.GetBot().TESTAddMember(test.G1, test.UID1, client.Member)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "add", []int64{test.UID1, test.UID2})
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "add", []int64{test.UID1, test.UID2})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
 	localutils.GetBot().TESTAddMember(test.G1, test.UID2, client.Member)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "add", []int64{test.UID1, test.UID2})
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "add", []int64{test.UID1, test.UID2})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "show", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), strconv.FormatInt(test.UID1, 10))
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), strconv.FormatInt(test.UID2, 10))
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "remove", []int64{test.UID1, test.UID3})
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "remove", []int64{test.UID1, test.UID3})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "show", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), strconv.FormatInt(test.UID2, 10))
 	assert.NotContains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), strconv.FormatInt(test.UID1, 10))
 	assert.NotContains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), strconv.FormatInt(test.UID3, 10))
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "clear", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "clear", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "show", nil)
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show", nil)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "当前配置为空")
 
-	IConfigAtCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, "unknown", []int64{test.UID1})
+	IConfigAtCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "unknown", []int64{test.UID1})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 }
@@ -606,7 +804,7 @@ func TestIConfigAtAllCmd(t *testing.T) {
 	tc2 := newTestConcern(t, testEventChan2, testNotifyChan, test.Site2, []concern_type.Type{test.T2})
 	concern.RegisterConcern(tc2)
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
 
@@ -614,33 +812,33 @@ func TestIConfigAtAllCmd(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigAtAllCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 }
@@ -667,7 +865,7 @@ func TestIConfigTitleNotifyCmd(t *testing.T) {
 	tc2 := newTestConcern(t, testEventChan2, testNotifyChan, test.Site2, []concern_type.Type{test.T2})
 	concern.RegisterConcern(tc2)
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
 
@@ -675,37 +873,83 @@ func TestIConfigTitleNotifyCmd(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigTitleNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IConfigTitleNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 }
 
+func TestIConfigLiveChangeNotifyCmd(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	var result *mmsg.MSG
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	IConfigLiveChangeNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigLiveChangeNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IConfigLiveChangeNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+	assert.True(t, tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1).GetGroupConcernNotify().CheckLiveChangeNotify(test.T1))
+
+	IConfigLiveChangeNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IConfigLiveChangeNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+	assert.False(t, tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1).GetGroupConcernNotify().CheckLiveChangeNotify(test.T1))
+}
+
 func TestIConfigOfflineNotifyCmd(t *testing.T) {
 	initLsp(t)
 	defer closeLsp(t)
@@ -728,7 +972,7 @@ func TestIConfigOfflineNotifyCmd(t *testing.T) {
 	tc2 := newTestConcern(t, testEventChan2, testNotifyChan, test.Site2, []concern_type.Type{test.T2})
 	concern.RegisterConcern(tc2)
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
 
@@ -736,33 +980,33 @@ func TestIConfigOfflineNotifyCmd(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, true)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, true)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigOfflineNotifyCmd(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IConfigOfflineNotifyCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 }
@@ -789,7 +1033,7 @@ func TestIConfigFilterCmd(t *testing.T) {
 	tc2 := newTestConcern(t, testEventChan2, testNotifyChan, test.Site2, []concern_type.Type{test.T2})
 	concern.RegisterConcern(tc2)
 
-	IConfigFilterCmdType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{test.Type1})
+	IConfigFilterCmdType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.Type1})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
 
@@ -797,55 +1041,146 @@ func TestIConfigFilterCmd(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigFilterCmdType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{test.Type1})
+	IConfigFilterCmdType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.Type1})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), disabled)
 
 	assert.Nil(t, Instance.PermissionStateManager.EnableGroupCommand(test.G1, ConfigCommand))
 
-	IConfigFilterCmdType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{test.Type1})
+	IConfigFilterCmdType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.Type1})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigFilterCmdType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{})
+	IConfigFilterCmdType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigFilterCmdType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{test.Type1})
+	IConfigFilterCmdType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.Type1})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigFilterCmdNotType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{})
+	IConfigFilterCmdNotType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigFilterCmdNotType(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{test.Type1})
+	IConfigFilterCmdNotType(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.Type1})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigFilterCmdText(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{})
+	IConfigFilterCmdText(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
 
-	IConfigFilterCmdText(ctx, test.G1, test.NAME1, test.Site1, test.T1, []string{test.NAME1, test.NAME2})
+	IConfigFilterCmdText(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.NAME1, test.NAME2})
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigFilterCmdShow(ctx, test.G1, test.NAME1, test.Site1, test.T1)
+	IConfigFilterCmdShow(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "关键字过滤模式")
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME1)
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME2)
 
-	IConfigFilterCmdClear(ctx, test.G1, test.NAME1, test.Site1, test.T1)
+	IConfigFilterCmdNotText(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{})
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IConfigFilterCmdNotText(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{test.NAME2})
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigFilterCmdRegex(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{`(`})
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IConfigFilterCmdRegex(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{`^\d+`})
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigFilterCmdNotRegex(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, []string{`\w+$`})
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigFilterCmdShow(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME1)
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "时不推送")
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), `^\d+`)
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), `\w+$`)
+
+	IConfigFilterCmdClear(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigFilterCmdShow(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "当前配置为空")
+}
+
+func TestIConfigTemplateCmd(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan1 := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	var result *mmsg.MSG
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+
+	tc1 := newTestConcern(t, testEventChan1, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "set", "{{ .msg }}")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "set", "{{ .msg }}")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IConfigFilterCmdShow(ctx, test.G1, test.NAME1, test.Site1, test.T1)
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "show", "")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "当前配置为空")
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "set", "")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "set", "{{ .msg ")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "模板解析失败")
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "set", "开播了\n{{ .msg }}")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "show", "")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "开播了\n{{ .msg }}")
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindNews, "clear", "")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), failed)
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "clear", "")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigTemplateCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, concern.TemplateKindLive, "show", "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "当前配置为空")
 }
@@ -879,68 +1214,68 @@ func TestICleanConcern(t *testing.T) {
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除0个")
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G2, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1}, test.Site1, test.T1.String())
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除1个")
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T2)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.NotNil(t, err)
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G2, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.NotNil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1}, test.Site1, test.T2.String())
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除1个")
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T2)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G2, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.NotNil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1}, test.Site1, test.T1.String())
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除0个")
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T2)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G2, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.NotNil(t, err)
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1, test.G2}, "", "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除2个")
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1, test.G2}, "", "")
@@ -949,15 +1284,15 @@ func TestICleanConcern(t *testing.T) {
 
 	localutils.GetBot().TESTAddGroup(test.G2)
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID2, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID3, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID3, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G2, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	ICleanConcern(ctx, true, []int64{test.G1, test.G2}, "", "")
@@ -968,7 +1303,7 @@ func TestICleanConcern(t *testing.T) {
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除3个")
 
-	err = tc1.GetStateManager().CheckGroupConcern(test.G2, test.UID1, test.T1)
+	err = tc1.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.NotNil(t, err)
 
 	ICleanConcern(ctx, false, nil, "", "")
@@ -979,15 +1314,15 @@ func TestICleanConcern(t *testing.T) {
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除1个")
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID2, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID3, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID3, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G2, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1, test.G2}, "", test.T2.String())
@@ -998,24 +1333,24 @@ func TestICleanConcern(t *testing.T) {
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除3个")
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID2, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID3, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID3, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G2, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc2.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc2.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1, test.G2}, test.Site1, "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除4个")
 
-	err = tc2.GetStateManager().CheckGroupConcern(test.G1, test.UID1, test.T1)
+	err = tc2.GetStateManager().CheckGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.NotNil(t, err)
 
 	ICleanConcern(ctx, false, []int64{test.G1, test.G2}, "wrongasdsad", "")
@@ -1026,31 +1361,31 @@ func TestICleanConcern(t *testing.T) {
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除1个")
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID2, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID3, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID3, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G2, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc2.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc2.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 	ICleanConcern(ctx, true, nil, "", "")
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "清除4个")
 
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T2)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID2, test.T1)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID2, test.T1)
 	assert.Nil(t, err)
-	_, err = tc1.GetStateManager().AddGroupConcern(test.G1, test.UID3, test.T2)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID3, test.T2)
 	assert.Nil(t, err)
-	_, err = tc2.GetStateManager().AddGroupConcern(test.G1, test.UID1, test.T1)
+	_, err = tc2.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.UID1, test.T1)
 	assert.Nil(t, err)
 
 	IAbnormalConcernCheck(ctx)
@@ -1073,3 +1408,55 @@ func TestICleanConcern(t *testing.T) {
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), "没有查询到")
 }
+
+func TestIWatch_Private(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	var result *mmsg.MSG
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewPrivateTarget(test.UID1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	selfTarget := mmsg.NewPrivateTargetId(test.UID1)
+	otherTarget := mmsg.NewPrivateTargetId(test.UID2)
+
+	// 只能操作自己的私聊订阅
+	IWatch(ctx, otherTarget, test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+
+	IWatch(ctx, selfTarget, test.NAME1, test.Site1, test.T1, false)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IList(ctx, selfTarget, "")
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME1)
+
+	IList(ctx, mmsg.NewGroupTargetId(test.G1), "")
+	result = <-msgChan
+	assert.NotContains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), test.NAME1)
+
+	IConfigTitleNotifyCmd(ctx, selfTarget, test.NAME1, test.Site1, test.T1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	IConfigTitleNotifyCmd(ctx, otherTarget, test.NAME1, test.Site1, test.T1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), noPermission)
+
+	IWatch(ctx, selfTarget, test.NAME1, test.Site1, test.T1, true)
+	result = <-msgChan
+	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
+
+	close(testEventChan)
+}
@@ -0,0 +1,36 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/image_cache"
+	"runtime/debug"
+	"time"
+)
+
+// imageCacheCleanInterval 清理过期图片缓存的间隔
+const imageCacheCleanInterval = time.Hour
+
+// ImageCacheClean 定期删除磁盘上已经过期的图片缓存
+func (l *Lsp) ImageCacheClean() {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).Errorf("image cache clean recoverd %v", err)
+			go l.ImageCacheClean()
+		}
+	}()
+	ticker := time.NewTicker(imageCacheCleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if !cfg.GetImageCacheEnable() {
+				continue
+			}
+			if err := image_cache.Clean(); err != nil {
+				logger.Errorf("image cache clean error %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package image_cache
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/nfnt/resize"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var logger = utils.GetModuleLogger("image_cache")
+
+const (
+	// MaxImageSide 图片的宽或者高超过这个值时会缩小，过大的图片上传QQ时经常失败
+	MaxImageSide = 4096
+	// MaxImageBytes 图片大小超过这个值时会重新编码为jpeg
+	MaxImageBytes = 5 << 20
+
+	tmpSuffix = ".tmp"
+)
+
+// RetryInterval 下载失败后等待多久再重试，第n次重试等待n倍的时间
+var RetryInterval = time.Second
+
+type entry struct {
+	Url        string `json:"url"`
+	File       string `json:"file"`
+	Format     string `json:"format"`
+	Size       int    `json:"size"`
+	CreateTime int64  `json:"create_time"`
+}
+
+func urlHash(url string) string {
+	h := sha1.Sum([]byte(url))
+	return hex.EncodeToString(h[:])
+}
+
+// Get 获取url对应的图片，没有开启 imageCache.enable 时与 utils.ImageGet 相同，
+// 开启后会优先使用磁盘上的缓存，下载失败时会重试，全部失败时使用已经过期但还没有清理的缓存
+func Get(url string, opts ...requests.Option) ([]byte, error) {
+	if !cfg.GetImageCacheEnable() {
+		return localutils.ImageGet(url, opts...)
+	}
+	if url == "" {
+		return nil, errors.New("empty url")
+	}
+	var (
+		hash = urlHash(url)
+		file = filepath.Join(cfg.GetImageCacheDir(), hash)
+		log  = logger.WithField("url", url)
+	)
+	var e = new(entry)
+	if err := localdb.GetJson(localdb.ImageCacheKey(hash), e); err == nil {
+		b, err := os.ReadFile(e.File)
+		if err == nil {
+			return b, nil
+		}
+		log.Debugf("read cache file error %v", err)
+	} else if !localdb.IsNotFound(err) {
+		log.Errorf("GetJson error %v", err)
+	}
+
+	b, err := fetch(url, opts...)
+	if err != nil {
+		if stale, serr := os.ReadFile(file); serr == nil {
+			log.Warnf("fetch error %v, use stale cache", err)
+			return stale, nil
+		}
+		return nil, err
+	}
+	b = Normalize(b)
+	if err := save(hash, file, url, b); err != nil {
+		log.Errorf("save image cache error %v", err)
+	}
+	return b, nil
+}
+
+func fetch(url string, opts ...requests.Option) ([]byte, error) {
+	var retry = cfg.GetImageCacheRetry()
+	var err error
+	for i := 0; i < retry; i++ {
+		if i > 0 {
+			time.Sleep(RetryInterval * time.Duration(i))
+		}
+		var options = []requests.Option{
+			requests.TimeoutOption(time.Second * 15),
+		}
+		options = append(options, proxyOption())
+		options = append(options, opts...)
+		var body = new(bytes.Buffer)
+		err = requests.Get(url, nil, body, options...)
+		if err == nil && body.Len() > 0 {
+			return body.Bytes(), nil
+		}
+		if err == nil {
+			err = errors.New("empty body")
+		}
+		logger.WithField("url", url).Debugf("fetch image failed %v/%v: %v", i+1, retry, err)
+	}
+	return nil, err
+}
+
+func proxyOption() requests.Option {
+	var proxy = cfg.GetImageCacheProxy()
+	switch strings.ToLower(proxy) {
+	case "":
+		return requests.ProxyOption(proxy_pool.PreferNone)
+	case "any":
+		return requests.ProxyOption(proxy_pool.PreferAny)
+	case "mainland":
+		return requests.ProxyOption(proxy_pool.PreferMainland)
+	case "oversea":
+		return requests.ProxyOption(proxy_pool.PreferOversea)
+	default:
+		return requests.RawProxyOption(proxy)
+	}
+}
+
+func save(hash string, file string, url string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	// 先写入临时文件再重命名，避免读到写了一半的文件
+	if err := os.WriteFile(file+tmpSuffix, b, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(file+tmpSuffix, file); err != nil {
+		return err
+	}
+	format, _ := localutils.ImageFormat(b)
+	return localdb.SetJson(localdb.ImageCacheKey(hash), &entry{
+		Url:        url,
+		File:       file,
+		Format:     format,
+		Size:       len(b),
+		CreateTime: time.Now().Unix(),
+	}, localdb.SetExpireOpt(cfg.GetImageCacheTTL()))
+}
+
+// Normalize 把图片处理为QQ可以接受的尺寸，宽或高超过 MaxImageSide 时等比例缩小，
+// 超过 MaxImageBytes 时重新编码为jpeg，无法识别的格式和gif保持原样
+func Normalize(b []byte) []byte {
+	config, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil || format == "gif" {
+		return b
+	}
+	if config.Width <= MaxImageSide && config.Height <= MaxImageSide && len(b) <= MaxImageBytes {
+		return b
+	}
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		logger.Errorf("image decode failed %v", err)
+		return b
+	}
+	if config.Width > MaxImageSide || config.Height > MaxImageSide {
+		img = resize.Thumbnail(MaxImageSide, MaxImageSide, img, resize.Lanczos3)
+		if result, err := encode(img, format); err == nil {
+			b = result
+		} else {
+			logger.Errorf("image encode failed %v", err)
+		}
+	}
+	if len(b) > MaxImageBytes {
+		// 透明的部分使用白色背景
+		var bg = image.NewRGBA(img.Bounds())
+		draw.Draw(bg, bg.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(bg, bg.Bounds(), img, img.Bounds().Min, draw.Over)
+		var buf = new(bytes.Buffer)
+		if err := jpeg.Encode(buf, bg, &jpeg.Options{Quality: 85}); err == nil {
+			b = buf.Bytes()
+		} else {
+			logger.Errorf("jpeg encode failed %v", err)
+		}
+	}
+	return b
+}
+
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf = new(bytes.Buffer)
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 95})
+	case "png":
+		err = png.Encode(buf, img)
+	default:
+		err = fmt.Errorf("unknown format %v", format)
+	}
+	return buf.Bytes(), err
+}
+
+// Clean 删除缓存目录中已经过期的图片
+func Clean() error {
+	var dir = cfg.GetImageCacheDir()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var count int
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		// 跳过刚刚写入的文件，它的缓存信息可能还没有保存
+		if info, err := f.Info(); err != nil || time.Since(info.ModTime()) < time.Minute {
+			continue
+		}
+		var hash = strings.TrimSuffix(f.Name(), tmpSuffix)
+		if hash == f.Name() && localdb.Exist(localdb.ImageCacheKey(hash)) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			logger.WithField("file", f.Name()).Errorf("remove error %v", err)
+			continue
+		}
+		count++
+	}
+	if count > 0 {
+		logger.Debugf("image cache cleaned %v files", count)
+	}
+	return nil
+}
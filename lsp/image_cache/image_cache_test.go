@@ -0,0 +1,134 @@
+package image_cache
+
+import (
+	"bytes"
+	"github.com/Sora233/DDBOT/internal/test"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newPng(t *testing.T, width, height int) []byte {
+	var buf = new(bytes.Buffer)
+	assert.Nil(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func initCache(t *testing.T) string {
+	test.InitBuntdb(t)
+	var dir = t.TempDir()
+	config.GlobalConfig.Set("imageCache.enable", true)
+	config.GlobalConfig.Set("imageCache.dir", dir)
+	RetryInterval = time.Millisecond
+	return dir
+}
+
+func closeCache(t *testing.T) {
+	config.GlobalConfig.Set("imageCache.enable", nil)
+	config.GlobalConfig.Set("imageCache.dir", nil)
+	RetryInterval = time.Second
+	test.CloseBuntdb(t)
+}
+
+func TestGet(t *testing.T) {
+	initCache(t)
+	defer closeCache(t)
+
+	var img = newPng(t, 10, 10)
+	var count int32
+	var fail int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if atomic.LoadInt32(&fail) > 0 {
+			atomic.AddInt32(&fail, -1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(img)
+	}))
+	defer ts.Close()
+
+	b, err := Get(ts.URL + "/a.png")
+	assert.Nil(t, err)
+	assert.EqualValues(t, img, b)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&count))
+
+	// 第二次使用缓存
+	b, err = Get(ts.URL + "/a.png")
+	assert.Nil(t, err)
+	assert.EqualValues(t, img, b)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&count))
+
+	// 失败后重试
+	atomic.StoreInt32(&fail, 2)
+	b, err = Get(ts.URL + "/b.png")
+	assert.Nil(t, err)
+	assert.EqualValues(t, img, b)
+	assert.EqualValues(t, 4, atomic.LoadInt32(&count))
+
+	// 全部失败
+	atomic.StoreInt32(&fail, 3)
+	_, err = Get(ts.URL + "/c.png")
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 7, atomic.LoadInt32(&count))
+
+	// 缓存过期后下载失败，使用还没有清理的文件
+	_, err = localdb.Delete(localdb.ImageCacheKey(urlHash(ts.URL + "/a.png")))
+	assert.Nil(t, err)
+	atomic.StoreInt32(&fail, 3)
+	b, err = Get(ts.URL + "/a.png")
+	assert.Nil(t, err)
+	assert.EqualValues(t, img, b)
+	assert.EqualValues(t, 10, atomic.LoadInt32(&count))
+
+	_, err = Get("")
+	assert.NotNil(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	var img = newPng(t, 10, 10)
+	assert.EqualValues(t, img, Normalize(img))
+	assert.EqualValues(t, []byte("not image"), Normalize([]byte("not image")))
+
+	img = newPng(t, MaxImageSide*2, 100)
+	b := Normalize(img)
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "png", format)
+	assert.EqualValues(t, MaxImageSide, cfg.Width)
+	assert.EqualValues(t, 50, cfg.Height)
+}
+
+func TestClean(t *testing.T) {
+	dir := initCache(t)
+	defer closeCache(t)
+
+	var old = time.Now().Add(-time.Hour)
+	var keep = urlHash("keep")
+	var expired = urlHash("expired")
+	for _, name := range []string{keep, expired, keep + tmpSuffix} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+		assert.Nil(t, os.Chtimes(filepath.Join(dir, name), old, old))
+	}
+	var recent = urlHash("recent")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, recent), []byte("x"), 0644))
+	assert.Nil(t, localdb.SetJson(localdb.ImageCacheKey(keep), &entry{Url: "keep"}))
+
+	assert.Nil(t, Clean())
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.ElementsMatch(t, []string{keep, recent}, names)
+}
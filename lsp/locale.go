@@ -0,0 +1,121 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/i18n"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/template"
+	"strings"
+)
+
+// SetGroupLocale 设置群内回复和推送使用的语言，locale需要是 i18n.Locales 中的一种
+func (s *StateManager) SetGroupLocale(groupCode int64, locale string) error {
+	return s.Set(s.GroupLocaleKey(groupCode), locale)
+}
+
+// GetGroupLocale 返回群内使用的语言，没有设置时返回 i18n.DefaultLocale
+func (s *StateManager) GetGroupLocale(groupCode int64) string {
+	locale, err := s.Get(s.GroupLocaleKey(groupCode))
+	if err != nil {
+		if !localdb.IsNotFound(err) {
+			logger.Errorf("GetGroupLocale error %v", err)
+		}
+		return i18n.DefaultLocale()
+	}
+	if locale, ok := i18n.Normalize(locale); ok {
+		return locale
+	}
+	return i18n.DefaultLocale()
+}
+
+// groupT 返回key在群内语言中的文本
+func (l *Lsp) groupT(groupCode int64, key string, args ...interface{}) string {
+	return i18n.T(l.LspStateManager.GetGroupLocale(groupCode), key, args...)
+}
+
+// Locale 返回回复使用的语言，群内为群设置的语言，私聊为默认语言
+func (c *MessageContext) Locale() string {
+	if c.IsFromGroup() && c.Lsp != nil {
+		return c.Lsp.LspStateManager.GetGroupLocale(c.Target.TargetCode())
+	}
+	return i18n.DefaultLocale()
+}
+
+// T 返回key在回复语言中的文本
+func (c *MessageContext) T(key string, args ...interface{}) string {
+	return i18n.T(c.Locale(), key, args...)
+}
+
+// localizeNotifyMessage 群内使用的语言不是中文，并且存在这个语言的推送模板 notify.group.<site>.<type>.<locale>.tmpl 时，
+// 使用 concern.NotifyTemplateData 提供的数据执行模板，返回nil时使用原本的推送内容
+func (l *Lsp) localizeNotifyMessage(inotify concern.Notify) *mmsg.MSG {
+	locale := l.LspStateManager.GetGroupLocale(inotify.GetTarget().GroupCode())
+	if locale == i18n.ZhCN {
+		return nil
+	}
+	ext, ok := inotify.(concern.NotifyTemplateData)
+	if !ok {
+		return nil
+	}
+	name := fmt.Sprintf("notify.group.%v.%v.%v.tmpl", inotify.Site(), inotify.Type(), locale)
+	if template.LoadTemplate(name) == nil {
+		return nil
+	}
+	var data = map[string]interface{}{
+		"group_code": inotify.GetTarget().GroupCode(),
+		"target":     inotify.GetTarget().Key(),
+		"site":       inotify.Site(),
+		"type":       inotify.Type().String(),
+		"uid":        inotify.GetUid(),
+	}
+	for k, v := range ext.TemplateData() {
+		if _, found := data[k]; !found {
+			data[k] = v
+		}
+	}
+	m, err := template.LoadAndExec(name, data)
+	if err != nil {
+		inotify.Logger().Errorf("localize notify template %v error %v", name, err)
+		return nil
+	}
+	if len(m.Elements()) == 0 {
+		return nil
+	}
+	return m
+}
+
+// ILocaleCmd 查看或设置群内使用的语言，locale为空时查看
+func ILocaleCmd(c *MessageContext, groupCode int64, locale string) {
+	if groupCode == 0 {
+		c.TextReply(c.T("reply.no_group"))
+		return
+	}
+	sm := c.Lsp.LspStateManager
+	supported := strings.Join(i18n.Locales(), " / ")
+	if len(locale) == 0 {
+		c.TextReply(i18n.T(sm.GetGroupLocale(groupCode), "locale.current", sm.GetGroupLocale(groupCode), supported))
+		return
+	}
+	if !c.Lsp.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.Sender.Uin),
+		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
+	) {
+		c.NoPermissionReply()
+		return
+	}
+	normalized, ok := i18n.Normalize(locale)
+	if !ok {
+		c.TextReply(c.T("locale.unsupported", locale, supported))
+		return
+	}
+	if err := sm.SetGroupLocale(groupCode, normalized); err != nil {
+		c.Log.Errorf("SetGroupLocale error %v", err)
+		c.TextReply(c.T("reply.fail", err))
+		return
+	}
+	c.audit(groupCode)
+	c.TextReply(i18n.T(normalized, "locale.set", normalized))
+}
@@ -0,0 +1,83 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/i18n"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateManager_GroupLocale(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.Equal(t, i18n.ZhCN, sm.GetGroupLocale(test.G1))
+	assert.Nil(t, sm.SetGroupLocale(test.G1, i18n.EnUS))
+	assert.Equal(t, i18n.EnUS, sm.GetGroupLocale(test.G1))
+	assert.Equal(t, i18n.ZhCN, sm.GetGroupLocale(test.G2))
+
+	assert.Nil(t, sm.SetGroupLocale(test.G2, "xx"))
+	assert.Equal(t, i18n.ZhCN, sm.GetGroupLocale(test.G2))
+}
+
+func TestILocaleCmd(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	ILocaleCmd(ctx, test.G1, "")
+	assert.Contains(t, reply(), i18n.ZhCN)
+
+	ILocaleCmd(ctx, test.G1, "en")
+	assert.Equal(t, noPermission, reply())
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	ILocaleCmd(ctx, test.G1, "xx")
+	assert.Contains(t, reply(), failed)
+
+	ILocaleCmd(ctx, test.G1, "en")
+	assert.Equal(t, i18n.T(i18n.EnUS, "locale.set", i18n.EnUS), reply())
+	assert.Equal(t, i18n.EnUS, Instance.LspStateManager.GetGroupLocale(test.G1))
+
+	// 设置后群内的回复使用新的语言
+	assert.Equal(t, i18n.EnUS, ctx.Locale())
+	assert.Equal(t, i18n.T(i18n.EnUS, "reply.no_permission"), ctx.T("reply.no_permission"))
+
+	ILocaleCmd(ctx, 0, "")
+	assert.Equal(t, i18n.T(i18n.EnUS, "reply.no_group"), reply())
+}
+
+func TestLsp_localizeNotifyMessage(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	var notify = &testTemplateNotify{
+		TestEvent: tc.NewTestConcern(nil, "twitter", []concern_type.Type{"news"}).NewTestEvent("news", mmsg.NewGroupTargetId(test.G1), test.NAME1),
+	}
+	assert.Nil(t, Instance.localizeNotifyMessage(notify))
+
+	assert.Nil(t, Instance.LspStateManager.SetGroupLocale(test.G1, i18n.EnUS))
+	m := Instance.localizeNotifyMessage(notify)
+	if assert.NotNil(t, m) {
+		assert.Contains(t, msgstringer.MsgToString(m.ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements), test.NAME2+" posted a new tweet")
+	}
+
+	// 没有对应语言模板的推送使用原本的内容
+	var notify2 = &testTemplateNotify{
+		TestEvent: tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1}).NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1),
+	}
+	assert.Nil(t, Instance.localizeNotifyMessage(notify2))
+}
@@ -0,0 +1,173 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"strconv"
+)
+
+// AddMentionSubscriber 成员订阅群内订阅的推送@，replaced为true时说明之前已经订阅过
+func (s *StateManager) AddMentionSubscriber(groupCode int64, site string, id interface{}, uin int64) (replaced bool, err error) {
+	err = s.SetInt64(s.MentionSubscriberKey(groupCode, site, id, uin), uin, localdb.SetGetIsOverwriteOpt(&replaced))
+	return
+}
+
+// RemoveMentionSubscriber 成员取消订阅推送@，没有订阅过时返回 localdb.ErrNotFound
+func (s *StateManager) RemoveMentionSubscriber(groupCode int64, site string, id interface{}, uin int64) error {
+	_, err := s.Delete(s.MentionSubscriberKey(groupCode, site, id, uin))
+	return err
+}
+
+// ListMentionSubscriber 返回订阅了群内订阅推送@的成员
+func (s *StateManager) ListMentionSubscriber(groupCode int64, site string, id interface{}) (uins []int64, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(s.MentionSubscriberKey(groupCode, site, id, "*"), func(key, value string) bool {
+			var uin int64
+			uin, iterErr = strconv.ParseInt(value, 10, 64)
+			if iterErr != nil {
+				return false
+			}
+			uins = append(uins, uin)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		uins = nil
+	}
+	return
+}
+
+// mentionIds 返回推送时需要@的成员，包括 /config at 配置的成员以及使用 /subme 订阅的成员，只有QQ群内的推送会@成员
+func (l *Lsp) mentionIds(inotify concern.Notify, cfg concern.IConfig) []int64 {
+	var result []int64
+	var seen = make(map[int64]bool)
+	subscribers, err := l.LspStateManager.ListMentionSubscriber(inotify.GetTarget().GroupCode(), inotify.Site(), inotify.GetUid())
+	if err != nil {
+		inotify.Logger().Errorf("ListMentionSubscriber error %v", err)
+	}
+	for _, uin := range append(cfg.GetGroupConcernAt().GetAtSomeoneList(inotify.Type()), subscribers...) {
+		if seen[uin] {
+			continue
+		}
+		seen[uin] = true
+		result = append(result, uin)
+	}
+	return result
+}
+
+// ISubMe 群成员订阅或者取消订阅群内订阅的推送@，推送时的@方式为 concern.MentionNone 时不会@
+func ISubMe(c *MessageContext, groupCode int64, id string, site string, remove bool) {
+	log := c.Log
+
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(groupCode, SubMeCommand) {
+		c.DisabledReply()
+		return
+	}
+
+	site, err := concern.ParseRawSite(site)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	cm, err := concern.GetConcernBySite(site)
+	if err != nil {
+		log.Errorf("GetConcernBySite error %v", err)
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	mid, err := cm.ParseId(id)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - 解析%v id格式错误", site))
+		return
+	}
+	target := mmsg.NewGroupTargetId(groupCode)
+	ctype, err := cm.GetStateManager().GetGroupConcern(target, mid)
+	if err != nil || ctype.Empty() {
+		c.TextReply("失败 - 该id尚未watch")
+		return
+	}
+	var name = fmt.Sprint(mid)
+	if info, err := cm.Get(mid); err == nil && info != nil {
+		name = info.GetName()
+	}
+	sm := c.Lsp.LspStateManager
+	if remove {
+		err = sm.RemoveMentionSubscriber(groupCode, site, mid, c.Sender.Uin)
+		if localdb.IsNotFound(err) {
+			c.TextReply(fmt.Sprintf("失败 - 没有订阅过%v的推送", name))
+			return
+		}
+		if err != nil {
+			log.Errorf("RemoveMentionSubscriber error %v", err)
+			c.TextReply("失败 - 内部错误")
+			return
+		}
+		c.TextReply(fmt.Sprintf("成功 - %v的推送不会再@你", name))
+		return
+	}
+	replaced, err := sm.AddMentionSubscriber(groupCode, site, mid, c.Sender.Uin)
+	if err != nil {
+		log.Errorf("AddMentionSubscriber error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	if replaced {
+		c.TextReply(fmt.Sprintf("失败 - 已经订阅过%v的推送", name))
+		return
+	}
+	var reply = fmt.Sprintf("成功 - %v推送时会@你", name)
+	cfg := cm.GetStateManager().GetGroupConcernConfig(target, mid)
+	var mentioned bool
+	for _, t := range ctype.Split() {
+		if cfg.GetGroupConcernAt().GetMentionMode(t) != concern.MentionNone {
+			mentioned = true
+		}
+	}
+	if !mentioned {
+		reply += "，但当前配置为推送时不@任何人，请联系管理员修改"
+	}
+	c.TextReply(reply)
+}
+
+// IConfigMentionCmd 配置推送时@成员的方式，action为show时查看当前的方式
+func IConfigMentionCmd(c *MessageContext, target mmsg.TargetId, id string, site string, ctype concern_type.Type, action string) {
+	err := iConfigCmd(c, target, id, site, ctype, operateMentionConcernConfig(c, ctype, action))
+	if localdb.IsRollback(err) || permission.IsPermissionError(err) {
+		return
+	}
+	if err != nil {
+		c.TextReply(err.Error())
+	} else {
+		ReplyUserInfo(c, id, site, ctype)
+	}
+}
+
+func operateMentionConcernConfig(c *MessageContext, ctype concern_type.Type, action string) func(concernConfig concern.IConfig) bool {
+	return func(concernConfig concern.IConfig) bool {
+		if action == "show" {
+			c.TextReply(fmt.Sprintf("当前配置：%v", concernConfig.GetGroupConcernAt().GetMentionMode(ctype)))
+			return false
+		}
+		mode, ok := concern.ParseMentionMode(action)
+		if !ok {
+			c.Log.Errorf("unknown action")
+			c.TextReply("失败 - 未知操作")
+			return false
+		}
+		if concernConfig.GetGroupConcernAt().GetMentionMode(ctype) == mode {
+			c.TextReply("失败 - 已经配置过了")
+			return false
+		}
+		concernConfig.GetGroupConcernAt().SetMentionMode(ctype, mode)
+		return true
+	}
+}
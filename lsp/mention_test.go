@@ -0,0 +1,148 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateManager_MentionSubscriber(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	uins, err := sm.ListMentionSubscriber(test.G1, test.Site1, test.NAME1)
+	assert.Nil(t, err)
+	assert.Empty(t, uins)
+
+	replaced, err := sm.AddMentionSubscriber(test.G1, test.Site1, test.NAME1, test.UID1)
+	assert.Nil(t, err)
+	assert.False(t, replaced)
+	replaced, err = sm.AddMentionSubscriber(test.G1, test.Site1, test.NAME1, test.UID1)
+	assert.Nil(t, err)
+	assert.True(t, replaced)
+	_, err = sm.AddMentionSubscriber(test.G1, test.Site1, test.NAME1, test.UID2)
+	assert.Nil(t, err)
+	_, err = sm.AddMentionSubscriber(test.G2, test.Site1, test.NAME1, test.UID2)
+	assert.Nil(t, err)
+
+	uins, err = sm.ListMentionSubscriber(test.G1, test.Site1, test.NAME1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int64{test.UID1, test.UID2}, uins)
+
+	assert.Nil(t, sm.RemoveMentionSubscriber(test.G1, test.Site1, test.NAME1, test.UID1))
+	assert.NotNil(t, sm.RemoveMentionSubscriber(test.G1, test.Site1, test.NAME1, test.UID1))
+	uins, err = sm.ListMentionSubscriber(test.G1, test.Site1, test.NAME1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []int64{test.UID2}, uins)
+
+	assert.Contains(t, sm.GroupKeyPrefix(test.G1), sm.MentionSubscriberKey(test.G1))
+}
+
+func TestISubMe(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, false)
+	assert.Contains(t, reply(), "尚未watch")
+
+	_, err := tc1.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1)
+	assert.Nil(t, err)
+
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, true)
+	assert.Contains(t, reply(), failed)
+
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, false)
+	assert.Equal(t, success+" - "+test.NAME1+"推送时会@你", reply())
+
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, false)
+	assert.Contains(t, reply(), failed)
+
+	var notify = tc1.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	cfg := tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	cfg.GetGroupConcernAt().SetAtSomeoneList(test.T1, []int64{test.UID2, test.Sender1.Uin})
+	assert.EqualValues(t, []int64{test.UID2, test.Sender1.Uin}, Instance.mentionIds(notify, cfg))
+
+	assert.Nil(t, tc1.GetStateManager().OperateGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1, cfg, func(concernConfig concern.IConfig) bool {
+		concernConfig.GetGroupConcernAt().SetMentionMode(test.T1, concern.MentionNone)
+		return true
+	}))
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, true)
+	assert.Contains(t, reply(), success)
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, false)
+	assert.Contains(t, reply(), "不@任何人")
+
+	assert.Nil(t, Instance.PermissionStateManager.DisableGroupCommand(test.G1, SubMeCommand))
+	ISubMe(ctx, test.G1, test.NAME1, test.Site1, false)
+	assert.Equal(t, disabled, reply())
+}
+
+func TestIConfigMentionCmd(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify, 1)
+	defer close(testNotifyChan)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	tc1 := newTestConcern(t, testEventChan, testNotifyChan, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer tc1.Stop()
+
+	IConfigMentionCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "none")
+	assert.Contains(t, reply(), noPermission)
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	assert.Contains(t, reply(), success)
+
+	IConfigMentionCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show")
+	assert.Contains(t, reply(), string(concern.MentionMember))
+
+	IConfigMentionCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "member")
+	assert.Contains(t, reply(), failed)
+
+	IConfigMentionCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "all")
+	assert.Contains(t, reply(), success)
+	cfg := tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	assert.True(t, cfg.GetGroupConcernAt().CheckAtAll(test.T1))
+
+	// 使用at_all关闭后恢复为member
+	IConfigAtAllCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
+	assert.Contains(t, reply(), success)
+	IConfigMentionCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "show")
+	assert.Contains(t, reply(), string(concern.MentionMember))
+
+	IConfigMentionCmd(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, "none")
+	assert.Contains(t, reply(), success)
+	cfg = tc1.GetStateManager().GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	assert.Equal(t, concern.MentionNone, cfg.GetGroupConcernAt().GetMentionMode(test.T1))
+}
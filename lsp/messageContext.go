@@ -16,6 +16,8 @@ type MessageContext struct {
 	Log                   *logrus.Entry
 	Target                mmsg.Target
 	Sender                *message.Sender
+	// Command 触发这次操作的完整命令，用于记录审计日志
+	Command string
 }
 
 func (c *MessageContext) TextSend(text string) interface{} {
@@ -0,0 +1,77 @@
+package lsp
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/metrics"
+	"net/http"
+	"time"
+)
+
+var metricsLogger = logger.WithField("sub_module", "metrics")
+
+var (
+	_ = metrics.NewGaugeFunc("ddbot_db_keys", "数据库中key的数量", func() float64 {
+		db, err := localdb.GetClient()
+		if err != nil {
+			return 0
+		}
+		var count int
+		db.View(func(tx localdb.Tx) error {
+			count, err = tx.Len()
+			return err
+		})
+		return float64(count)
+	})
+
+	_ = metrics.NewGaugeFunc("ddbot_db_cache_hits", "数据库json缓存的命中次数，没有开启缓存时为0", func() float64 {
+		stats, _ := localdb.GetCacheStats()
+		return float64(stats.Hit)
+	})
+
+	_ = metrics.NewGaugeFunc("ddbot_db_cache_misses", "数据库json缓存的未命中次数，没有开启缓存时为0", func() float64 {
+		stats, _ := localdb.GetCacheStats()
+		return float64(stats.Miss)
+	})
+
+	_ = metrics.NewGaugeFunc("ddbot_db_cache_size", "数据库json缓存中key的数量，没有开启缓存时为0", func() float64 {
+		stats, _ := localdb.GetCacheStats()
+		return float64(stats.Len)
+	})
+
+	_ = metrics.NewGaugeFunc("ddbot_push_queue_length", "推送队列中等待发送的推送数量", func() float64 {
+		if Instance.pushQueue == nil {
+			return 0
+		}
+		return float64(Instance.pushQueue.Len())
+	})
+)
+
+// SubscribeMetrics 在事件总线中订阅开播、下播、动态等事件，记录每个网站产生的事件数量
+func (l *Lsp) SubscribeMetrics() {
+	concern.Subscribe("metrics", func(e *concern.BusEvent) {
+		metrics.ObserveConcernEvent(e.Site(), string(e.Topic))
+	}, concern.TopicLiveStart, concern.TopicLiveStop, concern.TopicLiveTitleChange, concern.TopicLiveChange, concern.TopicNewDynamic)
+}
+
+// StartMetrics 根据配置启动 /metrics 接口，输出格式兼容Prometheus，未配置 metrics.addr 时不启动
+func (l *Lsp) StartMetrics() {
+	addr := cfg.GetMetricsAddr()
+	if len(addr) == 0 {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	l.metricsServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 10,
+	}
+	go func() {
+		metricsLogger.Infof("metrics接口已启动：%v", addr)
+		if err := l.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			metricsLogger.Errorf("metrics接口启动失败 %v", err)
+		}
+	}()
+}
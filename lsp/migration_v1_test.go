@@ -2,10 +2,16 @@ package lsp
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
 	"github.com/Sora233/DDBOT/lsp/version"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestMigrationV1(t *testing.T) {
@@ -47,3 +53,104 @@ func TestMigrationV1(t *testing.T) {
 	assert.Nil(t, err)
 
 }
+
+func TestMigrationV2(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	concern.RegisterConcern(tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1}))
+	defer concern.ClearConcern()
+
+	_, err := version.SetVersion(LspVersionName, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, localdb.Set(localdb.GroupEnabledKey(test.G1, test.Site1), permission.Disable))
+	assert.Nil(t, localdb.Set(localdb.GroupEnabledKey(test.G2, test.Site1), permission.Enable))
+	assert.Nil(t, localdb.Set(localdb.GroupEnabledKey(test.G1, WatchCommand), permission.Disable))
+
+	err = version.DoMigration(LspVersionName, lspMigrationMap)
+	assert.Nil(t, err)
+	assert.EqualValues(t, LspSupportVersion, version.GetCurrentVersion(LspVersionName))
+
+	assert.False(t, localdb.Exist(localdb.GroupEnabledKey(test.G1, test.Site1)))
+	assert.False(t, localdb.Exist(localdb.GroupEnabledKey(test.G2, test.Site1)))
+	assert.True(t, localdb.Exist(localdb.GroupModuleKey(test.G1, test.Site1)))
+	assert.False(t, localdb.Exist(localdb.GroupModuleKey(test.G2, test.Site1)))
+	// 命令开关不受影响
+	assert.True(t, localdb.Exist(localdb.GroupEnabledKey(test.G1, WatchCommand)))
+}
+
+func TestLegacyTargetId(t *testing.T) {
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), legacyTargetId(test.G1))
+	assert.Equal(t, mmsg.NewPrivateTargetId(test.UID1), legacyTargetId(-test.UID1))
+	assert.Equal(t, mmsg.NewTelegramTargetId(-100123), legacyTargetId(legacyTelegramConcernBase-100123))
+	assert.Equal(t, mmsg.NewTelegramTargetId(100123), legacyTargetId(legacyTelegramConcernBase+100123))
+}
+
+func TestMigrationV3(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	tc1 := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer concern.ClearConcern()
+
+	var (
+		privateCode  = mmsg.NewGroupTargetId(-test.UID1)
+		telegramCode = mmsg.NewGroupTargetId(legacyTelegramConcernBase - 100123)
+	)
+
+	_, err := version.SetVersion(LspVersionName, 2)
+	assert.Nil(t, err)
+	// 旧版本的私聊与Telegram订阅编码在群号码中
+	_, err = tc1.GetStateManager().AddGroupConcern(privateCode, test.NAME1, test.T1)
+	assert.Nil(t, err)
+	_, err = tc1.GetStateManager().AddGroupConcern(telegramCode, test.NAME2, test.T1)
+	assert.Nil(t, err)
+	_, err = tc1.GetStateManager().AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1)
+	assert.Nil(t, err)
+	assert.Nil(t, localdb.SetInt64(localdb.LastPushKey(privateCode, test.Site1, test.NAME1), 100, localdb.SetExpireOpt(time.Hour)))
+	assert.Nil(t, localdb.Set(localdb.PushQueueKey("1"), `{"id":"1","group_code":-1,"elements":[{"type":"at","target":2}]}`))
+	assert.Nil(t, localdb.Set(localdb.FailedPushKey(privateCode, 1),
+		`{"id":1,"group_code":-1,"record":{"id":"1","group_code":-1}}`))
+
+	err = version.DoMigration(LspVersionName, lspMigrationMap)
+	assert.Nil(t, err)
+	assert.EqualValues(t, LspSupportVersion, version.GetCurrentVersion(LspVersionName))
+
+	ctype, err := tc1.GetStateManager().GetGroupConcern(mmsg.NewPrivateTargetId(test.UID1), test.NAME1)
+	assert.Nil(t, err)
+	assert.Equal(t, test.T1, ctype)
+	ctype, err = tc1.GetStateManager().GetGroupConcern(mmsg.NewTelegramTargetId(-100123), test.NAME2)
+	assert.Nil(t, err)
+	assert.Equal(t, test.T1, ctype)
+	ctype, err = tc1.GetStateManager().GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	assert.Nil(t, err)
+	assert.Equal(t, test.T1, ctype)
+	ctype, _ = tc1.GetStateManager().GetGroupConcern(privateCode, test.NAME1)
+	assert.True(t, ctype.Empty())
+
+	key := localdb.LastPushKey(mmsg.NewPrivateTargetId(test.UID1), test.Site1, test.NAME1)
+	last, err := localdb.GetInt64(key)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 100, last)
+	assert.Nil(t, localdb.RCoverTx(func(tx localdb.Tx) error {
+		ttl, err := tx.TTL(key)
+		assert.True(t, ttl > 0)
+		return err
+	}))
+	assert.False(t, localdb.Exist(localdb.LastPushKey(privateCode, test.Site1, test.NAME1)))
+
+	var record pushItemRecord
+	assert.Nil(t, localdb.GetJson(localdb.PushQueueKey("1"), &record))
+	assert.Equal(t, mmsg.NewPrivateTargetId(1), record.Target)
+	if assert.Len(t, record.Elements, 1) {
+		assert.EqualValues(t, 2, record.Elements[0].Target)
+	}
+
+	var failed FailedPush
+	assert.Nil(t, localdb.GetJson(localdb.FailedPushKey(mmsg.NewPrivateTargetId(test.UID1), 1), &failed))
+	assert.Equal(t, mmsg.NewPrivateTargetId(1), failed.Target)
+	if assert.NotNil(t, failed.Record) {
+		assert.Equal(t, mmsg.NewPrivateTargetId(1), failed.Record.Target)
+	}
+}
@@ -0,0 +1,59 @@
+package lsp
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/version"
+	"strconv"
+	"strings"
+)
+
+// V2 群内禁用订阅模块的设置原来与命令开关共用 GroupEnabledKey ，
+// 现在移动到单独的 GroupModuleKey 中，避免与同名的命令冲突
+type V2 struct {
+}
+
+func (v *V2) Func() version.MigrationFunc {
+	return func() error {
+		var sites = make(map[string]bool)
+		for _, c := range append(concern.ListConcern(), concern.ListDisabledConcern()...) {
+			sites[c.Site()] = true
+		}
+		return localdb.RWCoverTx(func(tx localdb.Tx) error {
+			var data [][2]string
+			err := tx.AscendKeys(localdb.GroupEnabledKey()+":*", func(key, value string) bool {
+				data = append(data, [2]string{key, value})
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			for _, kv := range data {
+				// GroupEnabled:<groupCode>:<command>
+				keys := strings.SplitN(kv[0], ":", 3)
+				if len(keys) != 3 || !sites[keys[2]] {
+					continue
+				}
+				groupCode, err := strconv.ParseInt(keys[1], 10, 64)
+				if err != nil {
+					continue
+				}
+				if _, err = tx.Delete(kv[0]); err != nil && !localdb.IsNotFound(err) {
+					return err
+				}
+				if kv[1] != permission.Disable {
+					continue
+				}
+				if _, _, err = tx.Set(localdb.GroupModuleKey(groupCode, keys[2]), permission.Disable, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (v *V2) TargetVersion() int64 {
+	return 2
+}
@@ -0,0 +1,162 @@
+package lsp
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/version"
+	"strconv"
+	"strings"
+)
+
+// V3 私聊订阅与Telegram订阅原来把推送目标编码在 groupCode 中存储，私聊订阅使用QQ号的相反数，
+// Telegram订阅在chat id上加上 1<<60 ，现在key中的推送目标使用 mmsg.TargetId.Key 的格式，
+// 推送记录中的 group_code 字段改为 target 字段
+type V3 struct {
+}
+
+const (
+	legacyTelegramConcernBase  int64 = 1 << 60
+	legacyTelegramConcernRange int64 = 1 << 53
+)
+
+// legacyTargetId 返回旧版本编码在 groupCode 中的推送目标
+func legacyTargetId(groupCode int64) mmsg.TargetId {
+	if groupCode > legacyTelegramConcernBase-legacyTelegramConcernRange &&
+		groupCode < legacyTelegramConcernBase+legacyTelegramConcernRange {
+		return mmsg.NewTelegramTargetId(groupCode - legacyTelegramConcernBase)
+	}
+	if groupCode < 0 {
+		return mmsg.NewPrivateTargetId(-groupCode)
+	}
+	return mmsg.NewGroupTargetId(groupCode)
+}
+
+// v3TargetKeyNames 返回第二段为推送目标的key的名字
+func v3TargetKeyNames() []string {
+	var zero = mmsg.NewGroupTargetId(0)
+	var prefix []string
+	for _, c := range append(concern.ListConcern(), concern.ListDisabledConcern()...) {
+		prefix = append(prefix, c.GetStateManager().GroupKeyPrefix(zero)...)
+	}
+	prefix = append(prefix,
+		localdb.LastPushKey(zero),
+		localdb.ConcernTagKey(zero),
+		localdb.PushDedupKey(zero),
+		localdb.FailedPushKey(zero),
+	)
+	var names []string
+	for _, p := range prefix {
+		names = append(names, strings.TrimSuffix(p, ":"+zero.Key()))
+	}
+	return names
+}
+
+// v3RewriteRecord 把推送记录以及其中嵌套的 record 的 group_code 字段改为 target 字段
+func v3RewriteRecord(record map[string]interface{}) {
+	if code, ok := record["group_code"].(float64); ok {
+		delete(record, "group_code")
+		record["target"] = legacyTargetId(int64(code))
+	}
+	if nested, ok := record["record"].(map[string]interface{}); ok {
+		v3RewriteRecord(nested)
+	}
+}
+
+func (v *V3) Func() version.MigrationFunc {
+	return func() error {
+		names := v3TargetKeyNames()
+		return localdb.RWCoverTx(func(tx localdb.Tx) error {
+			type kv struct {
+				key, value string
+			}
+			var data []kv
+			for _, name := range names {
+				err := tx.AscendKeys(name+":*", func(key, value string) bool {
+					data = append(data, kv{key, value})
+					return true
+				})
+				if err != nil {
+					return err
+				}
+			}
+			var setOpt = func(key string) (*localdb.SetOptions, error) {
+				ttl, err := tx.TTL(key)
+				if err != nil {
+					return nil, err
+				}
+				if ttl <= 0 {
+					return nil, nil
+				}
+				return &localdb.SetOptions{Expires: true, TTL: ttl}, nil
+			}
+			for _, d := range data {
+				// <name>:<groupCode>:...
+				keys := strings.SplitN(d.key, ":", 3)
+				if len(keys) < 2 {
+					continue
+				}
+				groupCode, err := strconv.ParseInt(keys[1], 10, 64)
+				if err != nil {
+					continue
+				}
+				target := legacyTargetId(groupCode)
+				if target.TargetType().IsGroup() {
+					continue
+				}
+				keys[1] = target.Key()
+				opt, err := setOpt(d.key)
+				if err != nil {
+					return err
+				}
+				if _, err = tx.Delete(d.key); err != nil && !localdb.IsNotFound(err) {
+					return err
+				}
+				if _, _, err = tx.Set(strings.Join(keys, ":"), d.value, opt); err != nil {
+					return err
+				}
+			}
+
+			// 推送记录保存在json中的 group_code
+			var records []kv
+			for _, pattern := range []string{
+				localdb.PushQueueKey("*"),
+				localdb.QuietQueueKey("*"),
+				localdb.DigestQueueKey("*"),
+				localdb.FailedPushKey("*"),
+			} {
+				err := tx.AscendKeys(pattern, func(key, value string) bool {
+					records = append(records, kv{key, value})
+					return true
+				})
+				if err != nil {
+					return err
+				}
+			}
+			for _, r := range records {
+				var record = make(map[string]interface{})
+				if err := json.Unmarshal([]byte(r.value), &record); err != nil {
+					logger.WithField("key", r.key).Errorf("V3 unmarshal record error %v", err)
+					continue
+				}
+				v3RewriteRecord(record)
+				value, err := json.Marshal(record)
+				if err != nil {
+					return err
+				}
+				opt, err := setOpt(r.key)
+				if err != nil {
+					return err
+				}
+				if _, _, err = tx.Set(r.key, string(value), opt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (v *V3) TargetVersion() int64 {
+	return 3
+}
@@ -3,12 +3,19 @@ package lsp
 import "github.com/Sora233/DDBOT/lsp/version"
 
 const LspVersionName = "lsp"
-const LspSupportVersion int64 = 1
+const LspSupportVersion int64 = 3
 
-// 对于DDBOT来说，Version name固定为lsp，升级后的版本无法回退
+// lsp 为DDBOT自身的数据库版本，升级后的版本无法回退
+// 其他模块（例如bilibili douyu）可以通过 version.Register 注册自己的版本与迁移，启动时会一起迁移
 
 var lspMigrationMap = version.NewMigrationMapFromMap(
 	map[int64]version.Migration{
 		0: new(V1),
+		1: new(V2),
+		2: new(V3),
 	},
 )
+
+func init() {
+	version.Register(LspVersionName, LspSupportVersion, lspMigrationMap)
+}
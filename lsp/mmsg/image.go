@@ -2,6 +2,7 @@ package mmsg
 
 import (
 	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/image_cache"
 	"github.com/Sora233/DDBOT/requests"
 	"github.com/Sora233/DDBOT/utils"
 	"os"
@@ -16,10 +17,10 @@ func NewImage(buf []byte) *ImageBytesElement {
 	return &ImageBytesElement{Buf: buf, alternative: "[图片]"}
 }
 
-// NewImageByUrl 默认会对相同的url使用缓存
+// NewImageByUrl 默认会对相同的url使用缓存，开启 imageCache 后会缓存到磁盘
 func NewImageByUrl(url string, opts ...requests.Option) *ImageBytesElement {
 	var img = NewImage(nil)
-	b, err := utils.ImageGet(url, opts...)
+	b, err := image_cache.Get(url, opts...)
 	if err == nil {
 		img.Buf = b
 	} else {
@@ -1,10 +1,19 @@
 package mmsg
 
+import (
+	"fmt"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
 type TargetType int32
 
 const (
 	TargetGroup TargetType = iota
 	TargetPrivate
+	TargetTelegram
 )
 
 func (t TargetType) IsGroup() bool {
@@ -15,6 +24,10 @@ func (t TargetType) IsPrivate() bool {
 	return t == TargetPrivate
 }
 
+func (t TargetType) IsTelegram() bool {
+	return t == TargetTelegram
+}
+
 type Target interface {
 	TargetType() TargetType
 	TargetCode() int64
@@ -44,6 +57,19 @@ func (t *GroupTarget) TargetCode() int64 {
 	return t.GroupCode
 }
 
+// TelegramTarget Telegram的聊天，ChatId可以是用户、群组或者频道
+type TelegramTarget struct {
+	ChatId int64 `json:"chat_id"`
+}
+
+func (t *TelegramTarget) TargetType() TargetType {
+	return TargetTelegram
+}
+
+func (t *TelegramTarget) TargetCode() int64 {
+	return t.ChatId
+}
+
 func NewGroupTarget(groupCode int64) *GroupTarget {
 	return &GroupTarget{GroupCode: groupCode}
 }
@@ -51,3 +77,114 @@ func NewGroupTarget(groupCode int64) *GroupTarget {
 func NewPrivateTarget(uin int64) *PrivateTarget {
 	return &PrivateTarget{Uin: uin}
 }
+
+func NewTelegramTarget(chatId int64) *TelegramTarget {
+	return &TelegramTarget{ChatId: chatId}
+}
+
+// TargetId 是推送目标的值类型，由目标类型与号码组成，可以直接比较，也可以作为map的key。
+// 订阅相关的数据都以 TargetId 作为key的一部分存储，不同类型的目标不会互相混淆。
+type TargetId struct {
+	Type TargetType `json:"type"`
+	Code int64      `json:"code"`
+}
+
+func (t TargetId) TargetType() TargetType {
+	return t.Type
+}
+
+func (t TargetId) TargetCode() int64 {
+	return t.Code
+}
+
+// Key 返回 TargetId 在数据库key中的格式，QQ群直接使用群号码，与之前的数据保持兼容，
+// 其他类型的目标使用 类型_号码 的格式，例如 private_123456 、 telegram_-1001234567890
+func (t TargetId) Key() string {
+	switch t.Type {
+	case TargetPrivate:
+		return targetPrivateKeyPrefix + strconv.FormatInt(t.Code, 10)
+	case TargetTelegram:
+		return targetTelegramKeyPrefix + strconv.FormatInt(t.Code, 10)
+	default:
+		return strconv.FormatInt(t.Code, 10)
+	}
+}
+
+// GroupCode 目标是QQ群时返回群号码，否则返回0，用于只对QQ群生效的设置
+func (t TargetId) GroupCode() int64 {
+	if t.Type == TargetGroup {
+		return t.Code
+	}
+	return 0
+}
+
+func (t TargetId) String() string {
+	return t.Key()
+}
+
+// Name 返回适合展示给用户的名字，例如 群 123456
+func (t TargetId) Name() string {
+	switch t.Type {
+	case TargetPrivate:
+		return fmt.Sprintf("私聊 %v", t.Code)
+	case TargetTelegram:
+		return fmt.Sprintf("Telegram %v", t.Code)
+	default:
+		return fmt.Sprintf("群 %v", t.Code)
+	}
+}
+
+const (
+	targetPrivateKeyPrefix  = "private_"
+	targetTelegramKeyPrefix = "telegram_"
+)
+
+// ParseTargetId 解析 TargetId.Key 的结果
+func ParseTargetId(s string) (TargetId, error) {
+	var t = TargetId{Type: TargetGroup}
+	if strings.HasPrefix(s, targetPrivateKeyPrefix) {
+		t.Type = TargetPrivate
+		s = strings.TrimPrefix(s, targetPrivateKeyPrefix)
+	} else if strings.HasPrefix(s, targetTelegramKeyPrefix) {
+		t.Type = TargetTelegram
+		s = strings.TrimPrefix(s, targetTelegramKeyPrefix)
+	}
+	code, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return TargetId{}, err
+	}
+	t.Code = code
+	return t, nil
+}
+
+func NewGroupTargetId(groupCode int64) TargetId {
+	return TargetId{Type: TargetGroup, Code: groupCode}
+}
+
+func NewPrivateTargetId(uin int64) TargetId {
+	return TargetId{Type: TargetPrivate, Code: uin}
+}
+
+func NewTelegramTargetId(chatId int64) TargetId {
+	return TargetId{Type: TargetTelegram, Code: chatId}
+}
+
+// TargetLogFields 返回推送目标的日志字段，QQ群与 utils.GroupLogFields 相同
+func TargetLogFields(target TargetId) logrus.Fields {
+	switch target.Type {
+	case TargetPrivate:
+		return localutils.FriendLogFields(target.Code)
+	case TargetTelegram:
+		return logrus.Fields{"TelegramChatId": target.Code}
+	default:
+		return localutils.GroupLogFields(target.Code)
+	}
+}
+
+// ToTargetId 返回 target 对应的 TargetId
+func ToTargetId(target Target) TargetId {
+	if t, ok := target.(TargetId); ok {
+		return t
+	}
+	return TargetId{Type: target.TargetType(), Code: target.TargetCode()}
+}
@@ -17,3 +17,43 @@ func TestTarget(t *testing.T) {
 	assert.False(t, gt.TargetType().IsPrivate())
 	assert.EqualValues(t, test.ID2, gt.TargetCode())
 }
+
+func TestTargetId(t *testing.T) {
+	var testCase = []struct {
+		target TargetId
+		key    string
+	}{
+		{NewGroupTargetId(test.G1), "123456"},
+		{NewPrivateTargetId(test.UID1), "private_777"},
+		{NewTelegramTargetId(123456789), "telegram_123456789"},
+		{NewTelegramTargetId(-1001234567890), "telegram_-1001234567890"},
+	}
+	for _, c := range testCase {
+		assert.Equal(t, c.key, c.target.Key())
+		target, err := ParseTargetId(c.key)
+		assert.Nil(t, err)
+		assert.Equal(t, c.target, target)
+		assert.Equal(t, c.target, ToTargetId(c.target))
+	}
+
+	assert.True(t, NewGroupTargetId(test.G1).TargetType().IsGroup())
+	assert.True(t, NewPrivateTargetId(test.UID1).TargetType().IsPrivate())
+	assert.True(t, NewTelegramTargetId(test.UID1).TargetType().IsTelegram())
+	// 同样的号码，不同类型的目标不相等
+	assert.NotEqual(t, NewGroupTargetId(test.G1), NewPrivateTargetId(test.G1))
+	assert.NotEqual(t, NewGroupTargetId(test.G1).Key(), NewPrivateTargetId(test.G1).Key())
+
+	assert.Equal(t, NewPrivateTargetId(test.UID1), ToTargetId(NewPrivateTarget(test.UID1)))
+	assert.Equal(t, NewGroupTargetId(test.G1), ToTargetId(NewGroupTarget(test.G1)))
+	assert.Equal(t, NewTelegramTargetId(test.UID1), ToTargetId(NewTelegramTarget(test.UID1)))
+
+	assert.Equal(t, "群 123456", NewGroupTargetId(test.G1).Name())
+	assert.Equal(t, "私聊 777", NewPrivateTargetId(test.UID1).Name())
+	assert.Equal(t, test.G1, NewGroupTargetId(test.G1).GroupCode())
+	assert.Zero(t, NewPrivateTargetId(test.UID1).GroupCode())
+
+	for _, key := range []string{"", "private_", "telegram_a", "abc", "private_1_2"} {
+		_, err := ParseTargetId(key)
+		assert.NotNil(t, err, key)
+	}
+}
@@ -1,6 +1,7 @@
 package lsp
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Mrs4s/MiraiGo/client"
 	"github.com/Mrs4s/MiraiGo/message"
@@ -12,7 +13,9 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/onebot"
 	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/telegram"
 	"github.com/Sora233/DDBOT/lsp/template"
 	"github.com/Sora233/DDBOT/lsp/version"
 	"github.com/Sora233/DDBOT/proxy_pool"
@@ -22,13 +25,12 @@ import (
 	"github.com/Sora233/DDBOT/utils/msgstringer"
 	"github.com/Sora233/MiraiGo-Template/bot"
 	"github.com/Sora233/MiraiGo-Template/config"
-	"github.com/fsnotify/fsnotify"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
 	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
+	"net/http"
 	"os"
 	"reflect"
 	"runtime/debug"
@@ -50,9 +52,17 @@ type Lsp struct {
 	stop          chan interface{}
 	wg            sync.WaitGroup
 	status        *Status
-	notifyWg      sync.WaitGroup
 	msgLimit      *semaphore.Weighted
+	pushQueue     *PushQueue
 	cron          *cron.Cron
+	adminApi      *AdminApi
+	metricsServer *http.Server
+	accounts      *AccountPool
+	oneBot        *onebot.Client
+	webhook       *Webhook
+	modules       *ModuleRegistry
+	shutdown      chan struct{}
+	shutdownOnce  sync.Once
 
 	PermissionStateManager *permission.StateManager
 	LspStateManager        *StateManager
@@ -71,17 +81,17 @@ func (l *Lsp) MiraiGoModule() bot.ModuleInfo {
 }
 
 func (l *Lsp) Init() {
-	log := logger.WithField("log_level", config.GlobalConfig.GetString("logLevel"))
-	lev, err := logrus.ParseLevel(config.GlobalConfig.GetString("logLevel"))
-	if err != nil {
-		logrus.SetLevel(logrus.DebugLevel)
-		log.Warn("无法识别logLevel，将使用Debug级别")
-	} else {
-		logrus.SetLevel(lev)
-		log.Infof("设置logLevel为%v", lev.String())
-	}
+	l.applyLogLevel()
+	log := logger
 
 	l.msgLimit = semaphore.NewWeighted(int64(cfg.GetNotifyParallel()))
+	l.pushQueue = NewPushQueue(l.LspStateManager, l.msgLimit, l.sendNotifyMsg)
+	l.pushQueue.restoreCallback = l.restoredPushCallback
+
+	if token := cfg.GetTelegramToken(); len(token) > 0 {
+		RegisterNotifySender(mmsg.TargetTelegram, telegram.NewSender(token))
+		log.Info("已配置telegram.token，启用Telegram推送")
+	}
 
 	if Tags != "UNKNOWN" {
 		logger.Infof("DDBOT版本：Release版本【%v】", Tags)
@@ -95,28 +105,30 @@ func (l *Lsp) Init() {
 
 	db := localdb.MustGetClient()
 	var count int
-	err = db.View(func(tx *buntdb.Tx) error {
+	err := db.View(func(tx localdb.Tx) error {
 		return tx.Ascend("", func(key, value string) bool {
 			count++
 			return true
 		})
 	})
 	if err == nil && count == 0 {
-		if _, err := version.SetVersion(LspVersionName, LspSupportVersion); err != nil {
+		if err := version.InitVersion(); err != nil {
 			log.Fatalf("警告：初始化LspVersion失败！")
 		}
 	} else {
-		curVersion := version.GetCurrentVersion(LspVersionName)
-		if curVersion < 0 {
-			log.Errorf("警告：无法检查数据库兼容性，程序可能无法正常工作")
-		} else if curVersion > LspSupportVersion {
-			log.Fatalf("警告：检查数据库兼容性失败！最高支持版本：%v，当前版本：%v", LspSupportVersion, curVersion)
-		} else if curVersion < LspSupportVersion {
+		pending, err := version.PendingModules()
+		if errors.Is(err, version.ErrUnknownVersion) {
+			log.Errorf("警告：无法检查数据库兼容性，程序可能无法正常工作：%v", err)
+		} else if err != nil {
+			log.Fatalf("警告：检查数据库兼容性失败！%v", err)
+		} else if len(pending) > 0 {
 			// 应该更新下
+			for _, m := range pending {
+				log.Warnf("警告：数据库兼容性检查完毕，<%v>当前需要从<%v>更新至<%v>",
+					m.Name, version.GetCurrentVersion(m.Name), m.SupportVersion)
+			}
 			backupFileName := fmt.Sprintf("%v-%v", localdb.LSPDB, time.Now().Unix())
-			log.Warnf(
-				`警告：数据库兼容性检查完毕，当前需要从<%v>更新至<%v>，将备份当前数据库文件到"%v"`,
-				curVersion, LspSupportVersion, backupFileName)
+			log.Warnf(`将备份当前数据库文件到"%v"`, backupFileName)
 			f, err := os.Create(backupFileName)
 			if err != nil {
 				log.Fatalf(`无法创建备份文件<%v>：%v`, backupFileName, err)
@@ -128,12 +140,12 @@ func (l *Lsp) Init() {
 			log.Infof(`备份完成，已备份数据库到<%v>"`, backupFileName)
 			log.Info("五秒后将开始更新数据库，如需取消请按Ctrl+C")
 			time.Sleep(time.Second * 5)
-			err = version.DoMigration(LspVersionName, lspMigrationMap)
+			err = version.MigrateAll(false)
 			if err != nil {
 				log.Fatalf("更新数据库失败：%v", err)
 			}
 		} else {
-			log.Debugf("数据库兼容性检查完毕，当前已为最新模式：%v", curVersion)
+			log.Debugf("数据库兼容性检查完毕，当前已为最新模式")
 		}
 	}
 
@@ -169,11 +181,46 @@ func (l *Lsp) Init() {
 		log.Errorf("未知的图片池")
 	}
 
-	proxyType := config.GlobalConfig.GetString("proxy.type")
-	log = logger.WithField("proxy_type", proxyType)
+	l.initProxyPool()
+	l.loadHttpSiteConfig()
+	if cfg.GetTemplateEnabled() {
+		log.Infof("已启用模板")
+		template.InitTemplateLoader()
+	}
+	cfg.ReloadCustomCommandPrefix()
+	l.registerModules()
+	cfg.OnReload(func() {
+		l.applyLogLevel()
+		l.initProxyPool()
+		go l.loadHttpSiteConfig()
+		l.CronjobReload()
+		// 启动完成前由 PostStart 负责启动模块
+		if l.started.Load() {
+			l.modules.Apply()
+		}
+	})
+}
+
+// applyLogLevel 根据 logLevel 配置设置日志级别，无法识别时使用Debug级别
+func (l *Lsp) applyLogLevel() {
+	log := logger.WithField("log_level", cfg.GetLogLevel())
+	lev, err := logrus.ParseLevel(cfg.GetLogLevel())
+	if err != nil {
+		logrus.SetLevel(logrus.DebugLevel)
+		log.Warn("无法识别logLevel，将使用Debug级别")
+	} else {
+		logrus.SetLevel(lev)
+		log.Infof("设置logLevel为%v", lev.String())
+	}
+}
+
+// initProxyPool 根据 proxy.type 配置初始化代理池，重新加载配置时会替换原来的代理池
+func (l *Lsp) initProxyPool() {
+	proxyType := cfg.GetProxyType()
+	log := logger.WithField("proxy_type", proxyType)
 	switch proxyType {
 	case "pyProxyPool":
-		host := config.GlobalConfig.GetString("pyProxyPool.host")
+		host := cfg.GetPyProxyPoolHost()
 		log := log.WithField("host", host)
 		pyPool, err := py.NewPYProxyPool(host)
 		if err != nil {
@@ -183,8 +230,7 @@ func (l *Lsp) Init() {
 			l.status.ProxyPoolEnable = true
 		}
 	case "localProxyPool":
-		overseaProxies := config.GlobalConfig.GetStringSlice("localProxyPool.oversea")
-		mainlandProxies := config.GlobalConfig.GetStringSlice("localProxyPool.mainland")
+		overseaProxies, mainlandProxies := cfg.GetLocalProxyPool()
 		var proxies []*local_proxy_pool.Proxy
 		for _, proxy := range overseaProxies {
 			proxies = append(proxies, &local_proxy_pool.Proxy{
@@ -202,20 +248,13 @@ func (l *Lsp) Init() {
 		proxy_pool.Init(pool)
 		log.WithField("local_proxy_num", len(proxies)).Debug("debug")
 		l.status.ProxyPoolEnable = true
-	case "off":
+	case "", "off":
+		proxy_pool.Init(nil)
+		l.status.ProxyPoolEnable = false
 		log.Debug("proxy pool turn off")
 	default:
 		log.Errorf("unknown proxy type")
 	}
-	if cfg.GetTemplateEnabled() {
-		log.Infof("已启用模板")
-		template.InitTemplateLoader()
-	}
-	cfg.ReloadCustomCommandPrefix()
-	config.GlobalConfig.OnConfigChange(func(in fsnotify.Event) {
-		go cfg.ReloadCustomCommandPrefix()
-		l.CronjobReload()
-	})
 }
 
 func (l *Lsp) PostInit() {
@@ -432,8 +471,8 @@ func (l *Lsp) Serve(bot *bot.Bot) {
 			WithField("MemberCount", event.Group.MemberCount)
 		for _, c := range concern.ListConcern() {
 			_, ids, _, err := c.GetStateManager().ListConcernState(
-				func(groupCode int64, id interface{}, p concern_type.Type) bool {
-					return groupCode == event.Group.Code
+				func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
+					return target == mmsg.NewGroupTargetId(event.Group.Code)
 				})
 			if err != nil {
 				log = log.WithField(fmt.Sprintf("%v订阅", c.Site()), "查询失败")
@@ -492,22 +531,7 @@ func (l *Lsp) Serve(bot *bot.Bot) {
 	})
 
 	bot.GroupMessageEvent.Subscribe(func(qqClient *client.QQClient, msg *message.GroupMessage) {
-		if len(msg.Elements) <= 0 {
-			return
-		}
-		if err := l.LspStateManager.SaveMessageImageUrl(msg.GroupCode, msg.Id, msg.Elements); err != nil {
-			logger.Errorf("SaveMessageImageUrl failed %v", err)
-		}
-		if !l.started.Load() {
-			return
-		}
-		cmd := NewLspGroupCommand(l, msg)
-		if Debug {
-			cmd.Debug()
-		}
-		if !l.LspStateManager.IsMuted(msg.GroupCode, bot.Uin) {
-			go cmd.Execute()
-		}
+		l.onGroupMessage(msg)
 	})
 
 	bot.SelfGroupMessageEvent.Subscribe(func(qqClient *client.QQClient, msg *message.GroupMessage) {
@@ -526,17 +550,7 @@ func (l *Lsp) Serve(bot *bot.Bot) {
 	})
 
 	bot.PrivateMessageEvent.Subscribe(func(qqClient *client.QQClient, msg *message.PrivateMessage) {
-		if !l.started.Load() {
-			return
-		}
-		if len(msg.Elements) == 0 {
-			return
-		}
-		cmd := NewLspPrivateCommand(l, msg)
-		if Debug {
-			cmd.Debug()
-		}
-		go cmd.Execute()
+		l.onPrivateMessage(msg)
 	})
 	bot.DisconnectedEvent.Subscribe(func(qqClient *client.QQClient, event *client.ClientDisconnectedEvent) {
 		logger.Errorf("收到OnDisconnected事件 %v", event.Message)
@@ -550,6 +564,48 @@ func (l *Lsp) Serve(bot *bot.Bot) {
 
 }
 
+// onGroupMessage 处理收到的群消息，MiraiGo与OneBot共用
+func (l *Lsp) onGroupMessage(msg *message.GroupMessage) {
+	if len(msg.Elements) <= 0 {
+		return
+	}
+	if err := l.LspStateManager.SaveMessageImageUrl(msg.GroupCode, msg.Id, msg.Elements); err != nil {
+		logger.Errorf("SaveMessageImageUrl failed %v", err)
+	}
+	// 存档需要启用search模块，并且在群内启用search命令
+	if l.ModuleEnabled(msg.GroupCode, SearchModule) &&
+		l.PermissionStateManager.CheckGroupCommandEnabled(msg.GroupCode, SearchCommand) {
+		if err := l.LspStateManager.ArchiveGroupMessage(msg, cfg.GetArchiveRetention()); err != nil {
+			logger.Errorf("ArchiveGroupMessage failed %v", err)
+		}
+	}
+	if !l.started.Load() {
+		return
+	}
+	cmd := NewLspGroupCommand(l, msg)
+	if Debug {
+		cmd.Debug()
+	}
+	if !l.LspStateManager.IsMuted(msg.GroupCode, localutils.GetBot().GetUin()) {
+		go cmd.Execute()
+	}
+}
+
+// onPrivateMessage 处理收到的私聊消息，MiraiGo与OneBot共用
+func (l *Lsp) onPrivateMessage(msg *message.PrivateMessage) {
+	if !l.started.Load() {
+		return
+	}
+	if len(msg.Elements) == 0 {
+		return
+	}
+	cmd := NewLspPrivateCommand(l, msg)
+	if Debug {
+		cmd.Debug()
+	}
+	go cmd.Execute()
+}
+
 func (l *Lsp) PostStart(bot *bot.Bot) {
 	l.FreshIndex()
 	go func() {
@@ -557,10 +613,13 @@ func (l *Lsp) PostStart(bot *bot.Bot) {
 			l.FreshIndex()
 		}
 	}()
+	l.LoginAccounts()
 	l.CronjobReload()
 	l.CronStart()
-	concern.StartAll()
+	l.modules.Apply()
 	l.started.Store(true)
+	l.StartAdminApi()
+	l.StartMetrics()
 
 	var newVersionChan = make(chan string, 1)
 	go func() {
@@ -581,7 +640,17 @@ func (l *Lsp) PostStart(bot *bot.Bot) {
 }
 
 func (l *Lsp) Start(bot *bot.Bot) {
+	l.pushQueue.Start()
+	concern.Subscribe("qq", l.onNotifyEvent, concern.TopicNotify)
+	l.SubscribeMetrics()
+	l.SubscribeBreaker()
 	go l.ConcernNotify()
+	go l.QuietDigest()
+	go l.FailedPushRetry()
+	go l.PushDigest()
+	go l.StaleConcernCheck()
+	go l.TrialWatchCheck()
+	go l.ImageCacheClean()
 }
 
 func (l *Lsp) Stop(bot *bot.Bot, wg *sync.WaitGroup) {
@@ -590,14 +659,28 @@ func (l *Lsp) Stop(bot *bot.Bot, wg *sync.WaitGroup) {
 		close(l.stop)
 	}
 	l.CronStop()
+	if l.adminApi != nil {
+		l.adminApi.Stop()
+	}
+	if l.metricsServer != nil {
+		l.metricsServer.Close()
+	}
+	l.modules.StopAll()
 	concern.StopAll()
 
 	l.wg.Wait()
-	logger.Debug("等待所有推送发送完毕")
-	l.notifyWg.Wait()
-	logger.Debug("推送发送完毕")
-
+	concern.GetEventBus().Close()
+	l.drainPushQueue()
+	logger.Debug("等待正在发送的推送完毕")
+	l.pushQueue.Stop()
+	logger.Debug("推送发送完毕，未发送的推送将在下次启动后继续发送")
+
+	l.LogoutAccounts()
+	if l.oneBot != nil {
+		l.oneBot.Close()
+	}
 	proxy_pool.Stop()
+	l.shrinkDB()
 }
 
 func (l *Lsp) NewVersionNotify(newVersionChan <-chan string) {
@@ -656,10 +739,53 @@ func (l *Lsp) FreshIndex() {
 }
 
 func (l *Lsp) RemoveAllByGroup(groupCode int64) {
+	keys, err := l.PurgeGroup(groupCode, false)
+	if err != nil {
+		logger.WithFields(localutils.GroupLogFields(groupCode)).Errorf("PurgeGroup error %v", err)
+		return
+	}
+	logger.WithFields(localutils.GroupLogFields(groupCode)).Debugf("PurgeGroup removed %v keys", len(keys))
+}
+
+// PurgeGroup 在一个事务中删除所有与groupCode有关的数据，包括所有订阅模块的订阅，配置，标记，
+// 以及权限，命令开关，积分，消息记录等，dryRun为true时不删除，只返回将会被删除的key
+func (l *Lsp) PurgeGroup(groupCode int64, dryRun bool) ([]string, error) {
+	var prefix []string
+	target := mmsg.NewGroupTargetId(groupCode)
 	for _, c := range concern.ListConcern() {
-		c.GetStateManager().RemoveAllByGroupCode(groupCode)
+		prefix = append(prefix, c.GetStateManager().GroupKeyPrefix(target)...)
+	}
+	for _, c := range concern.ListDisabledConcern() {
+		prefix = append(prefix, c.GetStateManager().GroupKeyPrefix(target)...)
 	}
-	l.PermissionStateManager.RemoveAllByGroupCode(groupCode)
+	prefix = append(prefix, l.PermissionStateManager.GroupKeyPrefix(groupCode)...)
+	prefix = append(prefix, l.LspStateManager.GroupKeyPrefix(groupCode)...)
+	return localdb.RemoveByKeyPrefix(prefix, dryRun)
+}
+
+// MigrateGroup 在一个事务中把oldGroupCode的所有订阅模块的订阅，配置，标记复制到newGroupCode，
+// 用于群解散后重建的情况，newGroupCode中已经存在的数据不会被覆盖，返回复制的key和跳过的key
+func (l *Lsp) MigrateGroup(oldGroupCode int64, newGroupCode int64) (copied []string, skipped []string, err error) {
+	if oldGroupCode == newGroupCode {
+		return nil, nil, errors.New("新旧群号相同")
+	}
+	var concerns []concern.Concern
+	concerns = append(concerns, concern.ListConcern()...)
+	concerns = append(concerns, concern.ListDisabledConcern()...)
+	var fromPrefix, toPrefix []string
+	oldTarget, newTarget := mmsg.NewGroupTargetId(oldGroupCode), mmsg.NewGroupTargetId(newGroupCode)
+	for _, c := range concerns {
+		fromPrefix = append(fromPrefix, c.GetStateManager().GroupKeyPrefix(oldTarget)...)
+		toPrefix = append(toPrefix, c.GetStateManager().GroupKeyPrefix(newTarget)...)
+	}
+	copied, skipped, err = localdb.CopyByKeyPrefix(fromPrefix, toPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range concerns {
+		c.FreshIndex(newTarget)
+	}
+	return
 }
 
 func (l *Lsp) GetImageFromPool(options ...image_pool.OptionFunc) ([]image_pool.Image, error) {
@@ -721,7 +847,8 @@ func (l *Lsp) PM(res []interface{}) []*message.PrivateMessage {
 }
 
 func (l *Lsp) sendPrivateMessage(uin int64, msg *message.SendingMessage) (res *message.PrivateMessage) {
-	if bot.Instance == nil || !bot.Instance.Online.Load() {
+	account := l.accounts.PickPrivate(uin)
+	if account == nil {
 		return &message.PrivateMessage{Id: -1, Elements: msg.Elements}
 	}
 	if msg == nil {
@@ -735,7 +862,7 @@ func (l *Lsp) sendPrivateMessage(uin int64, msg *message.SendingMessage) (res *m
 		logger.WithFields(localutils.FriendLogFields(uin)).Debug("send with empty message")
 		return &message.PrivateMessage{Id: -1}
 	}
-	res = bot.Instance.SendPrivateMessage(uin, msg)
+	res = account.SendPrivateMessage(uin, msg)
 	if res == nil || res.Id == -1 {
 		logger.WithField("content", msgstringer.MsgToString(msg.Elements)).
 			WithFields(localutils.GroupLogFields(uin)).
@@ -766,13 +893,16 @@ func (l *Lsp) sendGroupMessage(groupCode int64, msg *message.SendingMessage, rec
 		}
 	}()
 
-	if bot.Instance == nil || !bot.Instance.Online.Load() {
-		return &message.GroupMessage{Id: -1, Elements: msg.Elements}
-	}
-	if l.LspStateManager.IsMuted(groupCode, bot.Instance.Uin) {
-		logger.WithField("content", msgstringer.MsgToString(msg.Elements)).
-			WithFields(localutils.GroupLogFields(groupCode)).
-			Debug("BOT被禁言无法发送群消息")
+	// 选择一个在群内并且没有被禁言的账号发送
+	account := l.accounts.PickGroup(groupCode, func(uin int64) bool {
+		return l.LspStateManager.IsMuted(groupCode, uin)
+	})
+	if account == nil {
+		if l.accounts.Main().IsOnline() {
+			logger.WithField("content", msgstringer.MsgToString(msg.Elements)).
+				WithFields(localutils.GroupLogFields(groupCode)).
+				Debug("BOT被禁言无法发送群消息")
+		}
 		return &message.GroupMessage{Id: -1, Elements: msg.Elements}
 	}
 	if msg == nil {
@@ -786,7 +916,7 @@ func (l *Lsp) sendGroupMessage(groupCode int64, msg *message.SendingMessage, rec
 		logger.WithFields(localutils.GroupLogFields(groupCode)).Debug("send with empty message")
 		return &message.GroupMessage{Id: -1}
 	}
-	res = bot.Instance.SendGroupMessage(groupCode, msg)
+	res = account.SendGroupMessage(groupCode, msg)
 	if res == nil || res.Id == -1 {
 		if msg.Count(func(e message.IMessageElement) bool {
 			return e.Type() == message.At && e.(*message.AtElement).Target == 0
@@ -809,11 +939,14 @@ func (l *Lsp) sendGroupMessage(groupCode int64, msg *message.SendingMessage, rec
 var Instance = &Lsp{
 	concernNotify:          concern.ReadNotifyChan(),
 	stop:                   make(chan interface{}),
+	shutdown:               make(chan struct{}),
 	status:                 NewStatus(),
 	msgLimit:               semaphore.NewWeighted(3),
 	PermissionStateManager: permission.NewStateManager(),
 	LspStateManager:        NewStateManager(),
 	cron:                   cron.New(cron.WithLogger(cron.VerbosePrintfLogger(cronLog))),
+	accounts:               NewAccountPool(new(mainAccount)),
+	webhook:                NewWebhook(),
 }
 
 func init() {
@@ -822,4 +955,31 @@ func init() {
 	template.RegisterExtFunc("currentMode", func() string {
 		return string(Instance.LspStateManager.GetCurrentMode())
 	})
+	template.RegisterExtFunc("sendGroup", func(groupCode int64, msg interface{}) string {
+		// 只能发送到bot所在的群
+		if localutils.GetBot().FindGroup(groupCode) == nil {
+			panic(fmt.Sprintf("sendGroup: group %v not found", groupCode))
+		}
+		Instance.SendMsg(toTemplateSendMsg(msg), mmsg.NewGroupTarget(groupCode))
+		return ""
+	})
+	template.RegisterExtFunc("sendPrivate", func(uin int64, msg interface{}) string {
+		// 只能发送给bot的好友
+		if localutils.GetBot().FindFriend(uin) == nil {
+			panic(fmt.Sprintf("sendPrivate: friend %v not found", uin))
+		}
+		Instance.SendMsg(toTemplateSendMsg(msg), mmsg.NewPrivateTarget(uin))
+		return ""
+	})
+}
+
+func toTemplateSendMsg(msg interface{}) *mmsg.MSG {
+	switch e := msg.(type) {
+	case *mmsg.MSG:
+		return e
+	case message.IMessageElement:
+		return mmsg.NewMSG().Append(e)
+	default:
+		return mmsg.NewText(fmt.Sprint(msg))
+	}
 }
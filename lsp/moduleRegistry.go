@@ -0,0 +1,313 @@
+package lsp
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/recorder"
+	"sync"
+)
+
+const (
+	CheckinModule = "checkin"
+	SearchModule  = "search"
+	RecordModule  = "record"
+	WebhookModule = "webhook"
+)
+
+// Module 是一个可以通过 module.enable 与 module.disable 在配置中启用或禁用的功能模块，
+// 每个订阅网站都是一个模块，另外还有签到、消息存档、直播录制与webhook模块。
+// 重新加载配置后会按照新的配置启动或停止模块，不需要重启bot。
+type Module struct {
+	Name string
+	// Commands 模块提供的命令，模块禁用时这些命令不会有任何反应
+	Commands []string
+	// Init 在模块第一次启动前调用，只会成功调用一次，可以为nil
+	Init func() error
+	// Start 启动模块，可以为nil
+	Start func() error
+	// Stop 停止模块，可能在没有 Start 过的时候调用，用于启动时就在配置中禁用的模块，可以为nil
+	Stop func()
+}
+
+type moduleState int
+
+const (
+	moduleUnknown moduleState = iota
+	moduleRunning
+	moduleStopped
+)
+
+// ModuleRegistry 管理所有的 Module ，模块按注册的顺序启动与停止
+type ModuleRegistry struct {
+	// applyMu 保证同时只有一个 Apply 或者 StopAll ，启动模块可能比较慢，期间不持有 mu
+	applyMu sync.Mutex
+	mu      sync.Mutex
+	modules []*Module
+	inited  map[string]bool
+	state   map[string]moduleState
+}
+
+func NewModuleRegistry() *ModuleRegistry {
+	return &ModuleRegistry{
+		inited: make(map[string]bool),
+		state:  make(map[string]moduleState),
+	}
+}
+
+// Register 注册一个模块，同一个名字只能注册一次，重复注册会panic
+func (r *ModuleRegistry) Register(m *Module) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.modules {
+		if e.Name == m.Name {
+			panic(fmt.Sprintf("Module %v: is already registered", m.Name))
+		}
+	}
+	r.modules = append(r.modules, m)
+}
+
+// Get 返回名字为name的模块，没有注册时返回nil
+func (r *ModuleRegistry) Get(name string) *Module {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.modules {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// GetByCommand 返回提供command命令的模块，command不属于任何模块时返回nil
+func (r *ModuleRegistry) GetByCommand(command string) *Module {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.modules {
+		for _, c := range m.Commands {
+			if c == command {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// Names 按注册的顺序返回所有模块的名字
+func (r *ModuleRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []string
+	for _, m := range r.modules {
+		result = append(result, m.Name)
+	}
+	return result
+}
+
+// Enabled 返回模块是否正在运行，Apply 之前或者模块没有注册时按照配置判断
+func (r *ModuleRegistry) Enabled(name string) bool {
+	if r == nil {
+		return cfg.CheckModuleEnabled(name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.state[name] {
+	case moduleRunning:
+		return true
+	case moduleStopped:
+		return false
+	}
+	return cfg.CheckModuleEnabled(name)
+}
+
+// Apply 按照 module.enable 与 module.disable 配置启动或停止模块，已经是目标状态的模块不会重复启动或停止，
+// 需要启动的模块会并行启动，启动失败的模块视为停止，下一次 Apply 时会重新尝试启动
+func (r *ModuleRegistry) Apply() {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	var toStart []*Module
+	for _, m := range r.list() {
+		state := r.getState(m.Name)
+		if cfg.CheckModuleEnabled(m.Name) {
+			if state != moduleRunning {
+				toStart = append(toStart, m)
+			}
+			continue
+		}
+		if state == moduleStopped {
+			continue
+		}
+		if state == moduleRunning {
+			logger.Infof("%v模块已在配置中禁用，停止模块", m.Name)
+		} else {
+			logger.Infof("%v模块已在配置中禁用", m.Name)
+		}
+		// 先标记为停止，停止期间不再接受命令与推送
+		r.setState(m.Name, moduleStopped)
+		if m.Stop != nil {
+			m.Stop()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, m := range toStart {
+		wg.Add(1)
+		go func(m *Module) {
+			defer wg.Done()
+			if err := r.start(m); err != nil {
+				logger.Errorf("启动%v模块失败 - %v", m.Name, err)
+				r.setState(m.Name, moduleStopped)
+				return
+			}
+			logger.Debugf("启动%v模块", m.Name)
+			r.setState(m.Name, moduleRunning)
+		}(m)
+	}
+	wg.Wait()
+}
+
+// start 调用模块的 Init 与 Start ， Init 成功后不会再次调用
+func (r *ModuleRegistry) start(m *Module) error {
+	if m.Init != nil {
+		r.mu.Lock()
+		inited := r.inited[m.Name]
+		r.mu.Unlock()
+		if !inited {
+			if err := m.Init(); err != nil {
+				return err
+			}
+			r.mu.Lock()
+			r.inited[m.Name] = true
+			r.mu.Unlock()
+		}
+	}
+	if m.Start != nil {
+		return m.Start()
+	}
+	return nil
+}
+
+func (r *ModuleRegistry) list() []*Module {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Module(nil), r.modules...)
+}
+
+func (r *ModuleRegistry) getState(name string) moduleState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state[name]
+}
+
+func (r *ModuleRegistry) setState(name string, state moduleState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state[name] = state
+}
+
+// StopAll 按注册的顺序停止所有正在运行的模块，用于bot退出
+func (r *ModuleRegistry) StopAll() {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	for _, m := range r.list() {
+		if r.getState(m.Name) != moduleRunning {
+			continue
+		}
+		r.setState(m.Name, moduleStopped)
+		if m.Stop != nil {
+			m.Stop()
+		}
+	}
+}
+
+// registerModules 注册所有的订阅模块以及签到、消息存档、直播录制与webhook模块
+func (l *Lsp) registerModules() {
+	l.modules = NewModuleRegistry()
+	for _, c := range concern.ListConcern() {
+		c := c
+		site := c.Site()
+		l.modules.Register(&Module{
+			Name: site,
+			Init: func() error {
+				c.FreshIndex()
+				return nil
+			},
+			Start: func() error {
+				return concern.StartConcern(site)
+			},
+			Stop: func() {
+				concern.StopConcern(site)
+			},
+		})
+	}
+	l.modules.Register(&Module{
+		Name:     CheckinModule,
+		Commands: []string{CheckinCommand},
+	})
+	l.modules.Register(&Module{
+		Name:     SearchModule,
+		Commands: []string{SearchCommand},
+	})
+	l.modules.Register(&Module{
+		Name:     RecordModule,
+		Commands: []string{RecordCommand},
+		Start: func() error {
+			recorder.Resume()
+			return nil
+		},
+		Stop: recorder.Pause,
+	})
+	l.modules.Register(&Module{
+		Name:     WebhookModule,
+		Commands: []string{WebhookCommand},
+		Start: func() error {
+			l.webhook.Start()
+			return nil
+		},
+		Stop: l.webhook.Stop,
+	})
+}
+
+// ModuleEnabled 检查模块是否在运行，并且没有在群内禁用，groupCode为0时只检查是否在运行
+func (l *Lsp) ModuleEnabled(groupCode int64, name string) bool {
+	if !l.modules.Enabled(name) {
+		return false
+	}
+	if groupCode == 0 || l.LspStateManager == nil {
+		return true
+	}
+	return !l.LspStateManager.CheckGroupModuleDisabled(groupCode, name)
+}
+
+// DisableGroupModule 在群内禁用模块，禁用后群内无法使用模块的命令，也不会收到模块的推送，返回是否修改了设置
+func (s *StateManager) DisableGroupModule(groupCode int64, name string) (bool, error) {
+	var isOverwrite bool
+	err := s.Set(s.GroupModuleKey(groupCode, name), permission.Disable, localdb.SetGetIsOverwriteOpt(&isOverwrite))
+	return err == nil && !isOverwrite, err
+}
+
+// EnableGroupModule 在群内重新启用模块，返回是否修改了设置
+func (s *StateManager) EnableGroupModule(groupCode int64, name string) (bool, error) {
+	_, err := s.Delete(s.GroupModuleKey(groupCode, name))
+	if localdb.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CheckGroupModuleDisabled 检查模块是否在群内禁用
+func (s *StateManager) CheckGroupModuleDisabled(groupCode int64, name string) bool {
+	return s.Exist(s.GroupModuleKey(groupCode, name))
+}
@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testModule struct {
+	inited  int
+	started int
+	stopped int
+	err     error
+}
+
+func (m *testModule) Module(name string, commands ...string) *Module {
+	return &Module{
+		Name:     name,
+		Commands: commands,
+		Init: func() error {
+			m.inited++
+			return nil
+		},
+		Start: func() error {
+			if m.err != nil {
+				return m.err
+			}
+			m.started++
+			return nil
+		},
+		Stop: func() {
+			m.stopped++
+		},
+	}
+}
+
+func TestModuleRegistry(t *testing.T) {
+	defer config.GlobalConfig.Set("module", nil)
+
+	var m1, m2 = new(testModule), new(testModule)
+	r := NewModuleRegistry()
+	r.Register(m1.Module(test.Site1))
+	r.Register(m2.Module(CheckinModule, CheckinCommand))
+	assert.Panics(t, func() {
+		r.Register(m1.Module(test.Site1))
+	})
+
+	assert.Equal(t, []string{test.Site1, CheckinModule}, r.Names())
+	assert.Nil(t, r.Get(test.Site2))
+	assert.Equal(t, CheckinModule, r.GetByCommand(CheckinCommand).Name)
+	assert.Nil(t, r.GetByCommand(WatchCommand))
+
+	// Apply 之前按照配置判断
+	config.GlobalConfig.Set("module.disable", []string{CheckinModule})
+	assert.True(t, r.Enabled(test.Site1))
+	assert.False(t, r.Enabled(CheckinModule))
+
+	r.Apply()
+	assert.Equal(t, testModule{inited: 1, started: 1}, *m1)
+	assert.Equal(t, testModule{stopped: 1}, *m2)
+	assert.True(t, r.Enabled(test.Site1))
+	assert.False(t, r.Enabled(CheckinModule))
+
+	// 状态没有变化时不会重复启动或停止
+	r.Apply()
+	assert.Equal(t, testModule{inited: 1, started: 1}, *m1)
+	assert.Equal(t, testModule{stopped: 1}, *m2)
+
+	// 重新加载配置
+	config.GlobalConfig.Set("module.disable", []string{test.Site1})
+	r.Apply()
+	assert.Equal(t, testModule{inited: 1, started: 1, stopped: 1}, *m1)
+	assert.Equal(t, testModule{inited: 1, started: 1, stopped: 1}, *m2)
+	assert.False(t, r.Enabled(test.Site1))
+	assert.True(t, r.Enabled(CheckinModule))
+
+	// Init 只会调用一次
+	config.GlobalConfig.Set("module.disable", nil)
+	r.Apply()
+	assert.Equal(t, testModule{inited: 1, started: 2, stopped: 1}, *m1)
+	assert.True(t, r.Enabled(test.Site1))
+
+	r.StopAll()
+	assert.Equal(t, testModule{inited: 1, started: 2, stopped: 2}, *m1)
+	assert.Equal(t, testModule{inited: 1, started: 1, stopped: 2}, *m2)
+	assert.False(t, r.Enabled(test.Site1))
+	assert.False(t, r.Enabled(CheckinModule))
+
+	// 已经停止的模块不会重复停止
+	r.StopAll()
+	assert.Equal(t, 2, m1.stopped)
+}
+
+func TestModuleRegistry_StartFailed(t *testing.T) {
+	var m = &testModule{err: errors.New("error")}
+	r := NewModuleRegistry()
+	r.Register(m.Module(test.Site1))
+
+	r.Apply()
+	assert.False(t, r.Enabled(test.Site1))
+	assert.Equal(t, 1, m.inited)
+
+	// 下一次 Apply 时重新尝试启动
+	m.err = nil
+	r.Apply()
+	assert.True(t, r.Enabled(test.Site1))
+	assert.Equal(t, testModule{inited: 1, started: 1}, *m)
+
+	r.StopAll()
+	assert.Equal(t, 1, m.stopped)
+}
+
+func TestGroupModule(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	var m = new(testModule)
+	Instance.modules = NewModuleRegistry()
+	defer func() { Instance.modules = nil }()
+	Instance.modules.Register(m.Module(SearchModule, SearchCommand))
+	Instance.modules.Apply()
+
+	assert.True(t, Instance.ModuleEnabled(test.G1, SearchModule))
+
+	changed, err := Instance.LspStateManager.DisableGroupModule(test.G1, SearchModule)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	changed, err = Instance.LspStateManager.DisableGroupModule(test.G1, SearchModule)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	assert.False(t, Instance.ModuleEnabled(test.G1, SearchModule))
+	assert.True(t, Instance.ModuleEnabled(test.G2, SearchModule))
+	assert.True(t, Instance.ModuleEnabled(0, SearchModule))
+	// 与同名的命令开关互不影响
+	assert.False(t, Instance.PermissionStateManager.CheckGroupCommandDisabled(test.G1, SearchCommand))
+
+	changed, err = Instance.LspStateManager.EnableGroupModule(test.G1, SearchModule)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	changed, err = Instance.LspStateManager.EnableGroupModule(test.G1, SearchModule)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.True(t, Instance.ModuleEnabled(test.G1, SearchModule))
+
+	// 在配置中禁用后所有群都不可用
+	config.GlobalConfig.Set("module.disable", []string{SearchModule})
+	defer config.GlobalConfig.Set("module", nil)
+	Instance.modules.Apply()
+	assert.False(t, Instance.ModuleEnabled(test.G1, SearchModule))
+	assert.False(t, Instance.ModuleEnabled(0, SearchModule))
+}
@@ -0,0 +1,156 @@
+package netease
+
+import (
+	"fmt"
+	"github.com/guonaihong/gout"
+)
+
+// codeNotFound 歌手或者电台不存在时返回的code
+const codeNotFound = 404
+
+type ArtistAlbumsResponse struct {
+	Code   int32  `json:"code"`
+	Msg    string `json:"msg"`
+	Artist *struct {
+		Id     int64  `json:"id"`
+		Name   string `json:"name"`
+		PicUrl string `json:"picUrl"`
+	} `json:"artist"`
+	HotAlbums []*struct {
+		Id     int64  `json:"id"`
+		Name   string `json:"name"`
+		PicUrl string `json:"picUrl"`
+		// Type 专辑 / EP/Single / 合集 等
+		Type string `json:"type"`
+		Size int32  `json:"size"`
+		// PublishTime 毫秒时间戳
+		PublishTime int64 `json:"publishTime"`
+	} `json:"hotAlbums"`
+}
+
+type RadioDetailResponse struct {
+	Code    int32  `json:"code"`
+	Msg     string `json:"msg"`
+	DjRadio *struct {
+		Id     int64  `json:"id"`
+		Name   string `json:"name"`
+		PicUrl string `json:"picUrl"`
+		Dj     struct {
+			Nickname string `json:"nickname"`
+		} `json:"dj"`
+	} `json:"djRadio"`
+}
+
+type RadioProgramsResponse struct {
+	Code     int32  `json:"code"`
+	Msg      string `json:"msg"`
+	Programs []*struct {
+		Id          int64  `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		CoverUrl    string `json:"coverUrl"`
+		// CreateTime 毫秒时间戳
+		CreateTime int64 `json:"createTime"`
+		Radio      struct {
+			Id   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"radio"`
+	} `json:"programs"`
+}
+
+// GetArtistAlbums 查询歌手信息以及最新的专辑和单曲，按发布时间从新到旧排列
+func GetArtistAlbums(artistId int64) (*UserInfo, []*ReleaseInfo, error) {
+	var resp = new(ArtistAlbumsResponse)
+	err := neteaseGet(NeteasePath(fmt.Sprintf(PathArtistAlbums, artistId)), gout.H{
+		"offset": 0,
+		"limit":  10,
+	}, resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Code == codeNotFound {
+		return nil, nil, ErrNotExist
+	}
+	if resp.Code != 200 {
+		return nil, nil, fmt.Errorf("code %v - %v", resp.Code, resp.Msg)
+	}
+	if resp.Artist == nil || resp.Artist.Id == 0 {
+		return nil, nil, ErrNotExist
+	}
+	userInfo := &UserInfo{
+		Id:     fmt.Sprint(resp.Artist.Id),
+		Name:   resp.Artist.Name,
+		Avatar: resp.Artist.PicUrl,
+	}
+	var result []*ReleaseInfo
+	for _, album := range resp.HotAlbums {
+		result = append(result, &ReleaseInfo{
+			UserInfo:    *userInfo,
+			ReleaseId:   album.Id,
+			Title:       album.Name,
+			Kind:        album.Type,
+			Cover:       album.PicUrl,
+			Size:        album.Size,
+			PublishTime: album.PublishTime / 1000,
+			ctype:       News,
+		})
+	}
+	return userInfo, result, nil
+}
+
+// GetRadioDetail 查询电台信息
+func GetRadioDetail(radioId int64) (*UserInfo, error) {
+	var resp = new(RadioDetailResponse)
+	err := neteaseGet(NeteasePath(PathRadioDetail), gout.H{"id": radioId}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code == codeNotFound {
+		return nil, ErrNotExist
+	}
+	if resp.Code != 200 {
+		return nil, fmt.Errorf("code %v - %v", resp.Code, resp.Msg)
+	}
+	if resp.DjRadio == nil || resp.DjRadio.Id == 0 {
+		return nil, ErrNotExist
+	}
+	return &UserInfo{
+		Id:     RadioId(resp.DjRadio.Id),
+		Name:   resp.DjRadio.Name,
+		Avatar: resp.DjRadio.PicUrl,
+		DjName: resp.DjRadio.Dj.Nickname,
+	}, nil
+}
+
+// GetRadioPrograms 查询电台最新的节目，按发布时间从新到旧排列
+func GetRadioPrograms(userInfo *UserInfo, radioId int64) ([]*ReleaseInfo, error) {
+	var resp = new(RadioProgramsResponse)
+	err := neteaseGet(NeteasePath(PathRadioPrograms), gout.H{
+		"radioId": radioId,
+		"limit":   10,
+		"offset":  0,
+		"asc":     false,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code == codeNotFound {
+		return nil, ErrNotExist
+	}
+	if resp.Code != 200 {
+		return nil, fmt.Errorf("code %v - %v", resp.Code, resp.Msg)
+	}
+	var result []*ReleaseInfo
+	for _, program := range resp.Programs {
+		result = append(result, &ReleaseInfo{
+			UserInfo:    *userInfo,
+			ReleaseId:   program.Id,
+			Title:       program.Name,
+			Description: program.Description,
+			Cover:       program.CoverUrl,
+			PublishTime: program.CreateTime / 1000,
+			ctype:       Radio,
+		})
+	}
+	return result, nil
+}
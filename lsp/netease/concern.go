@@ -0,0 +1,271 @@
+package netease
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var logger = utils.GetModuleLogger("netease-concern")
+
+var (
+	artistIdRegexp = regexp.MustCompile(`^\d+$`)
+	radioIdRegexp  = regexp.MustCompile(`^` + radioIdPrefix + `\d+$`)
+	// urlIdRegexp 匹配歌手或电台主页链接，例如 https://music.163.com/#/artist?id=12345
+	urlIdRegexp = regexp.MustCompile(`music\.163\.com/(?:#/)?(?:m/)?(artist|djradio)\?(?:.*&)?id=(\d+)`)
+)
+
+const (
+	// News 歌手发布的新专辑和单曲
+	News concern_type.Type = "news"
+	// Radio 电台更新的节目
+	Radio concern_type.Type = "radio"
+)
+
+func IsArtistId(id string) bool {
+	return artistIdRegexp.MatchString(id)
+}
+
+func IsRadioId(id string) bool {
+	return radioIdRegexp.MatchString(id)
+}
+
+// parseNumberId 返回歌手或电台在网易云音乐中的数字id
+func parseNumberId(id string) (int64, error) {
+	return strconv.ParseInt(strings.TrimPrefix(id, radioIdPrefix), 10, 64)
+}
+
+type Concern struct {
+	*StateManager
+}
+
+func (c *Concern) Site() string {
+	return Site
+}
+
+func (c *Concern) Types() []concern_type.Type {
+	return []concern_type.Type{News, Radio}
+}
+
+// ParseId 歌手使用数字id，电台使用 dj 加数字id，也支持直接输入歌手或电台的主页链接
+func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if sub := urlIdRegexp.FindStringSubmatch(s); sub != nil {
+		if sub[1] == "djradio" {
+			return radioIdPrefix + sub[2], nil
+		}
+		return sub[2], nil
+	}
+	s = strings.ToLower(s)
+	if IsArtistId(s) || IsRadioId(s) {
+		return s, nil
+	}
+	return nil, fmt.Errorf("无效的id，歌手请使用数字id，电台请使用 %v 加数字id", radioIdPrefix)
+}
+
+func (c *Concern) GetStateManager() concern.IStateManager {
+	return c.StateManager
+}
+
+func (c *Concern) Stop() {
+	logger.Trace("正在停止netease concern")
+	logger.Trace("正在停止netease StateManager")
+	c.StateManager.Stop()
+	logger.Trace("netease StateManager已停止")
+	logger.Trace("netease concern已停止")
+}
+
+func (c *Concern) Start() error {
+	c.UseEmitQueue()
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(c.fresh())
+	return c.StateManager.Start()
+}
+
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	var err error
+	id := _id.(string)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
+
+	if (ctype.ContainAny(News) && !IsArtistId(id)) || (ctype.ContainAny(Radio) && !IsRadioId(id)) {
+		return nil, ErrTypeMismatch
+	}
+
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := c.FindOrLoadUser(id)
+	if err != nil {
+		log.Errorf("FindOrLoadUser error %v", err)
+		return nil, fmt.Errorf("查询歌手或电台信息失败 %v - %v", id, err)
+	}
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(id, userInfo.GetName()), nil
+}
+
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	id := _id.(string)
+	identity, _ := c.Get(id)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
+		allCtype, err := c.GetConcern(id)
+		if err != nil {
+			return err
+		}
+		if allCtype.Empty() {
+			return c.DeleteUserInfo(id)
+		}
+		return nil
+	})
+	return identity, err
+}
+
+func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
+	userInfo, err := c.GetUserInfo(id.(string))
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(userInfo.Id, userInfo.GetName()), nil
+}
+
+func (c *Concern) FindOrLoadUser(id string) (*UserInfo, error) {
+	info, _ := c.GetUserInfo(id)
+	if info != nil {
+		return info, nil
+	}
+	numberId, err := parseNumberId(id)
+	if err != nil {
+		return nil, err
+	}
+	if IsRadioId(id) {
+		info, err = GetRadioDetail(numberId)
+	} else {
+		info, _, err = GetArtistAlbums(numberId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddUserInfo(info)
+	return info, nil
+}
+
+func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
+		switch info := event.(type) {
+		case *ReleaseInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("release notify")
+			return []concern.Notify{NewConcernReleaseNotify(target, info)}
+		default:
+			logger.Errorf("unknown EventType %+v", event)
+			return nil
+		}
+	}
+}
+
+// filterNewReleases 返回发布时间晚于lastTime的专辑或节目，按发布时间从旧到新排列
+func filterNewReleases(releases []*ReleaseInfo, lastTime int64) []*ReleaseInfo {
+	var result []*ReleaseInfo
+	for _, release := range releases {
+		if release.PublishTime > lastTime {
+			result = append(result, release)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PublishTime < result[j].PublishTime
+	})
+	return result
+}
+
+func (c *Concern) loadReleases(id string) ([]*ReleaseInfo, error) {
+	numberId, err := parseNumberId(id)
+	if err != nil {
+		return nil, err
+	}
+	if !IsRadioId(id) {
+		userInfo, releases, err := GetArtistAlbums(numberId)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.AddUserInfo(userInfo)
+		return releases, nil
+	}
+	userInfo, err := c.GetUserInfo(id)
+	if err != nil {
+		userInfo, err = GetRadioDetail(numberId)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.AddUserInfo(userInfo)
+	}
+	return GetRadioPrograms(userInfo, numberId)
+}
+
+// freshRelease 第一次刷新时只记录当前最新发布的时间，不推送
+func (c *Concern) freshRelease(id string) ([]*ReleaseInfo, error) {
+	releases, err := c.loadReleases(id)
+	if err != nil {
+		return nil, err
+	}
+	lastTime, err := c.GetLastReleaseTime(id)
+	firstFresh := err == localdb.ErrNotFound
+	if err != nil && !firstFresh {
+		return nil, err
+	}
+	var result []*ReleaseInfo
+	for _, release := range filterNewReleases(releases, lastTime) {
+		lastTime = release.PublishTime
+		replaced, err := c.MarkRelease(release.Type(), release.ReleaseId)
+		if err != nil || replaced || firstFresh {
+			continue
+		}
+		result = append(result, release)
+	}
+	if err = c.SetLastReleaseTime(id, lastTime); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Concern) fresh() concern.FreshFunc {
+	return c.EmitQueueFresher(func(ctype concern_type.Type, _id interface{}) ([]concern.Event, error) {
+		id := _id.(string)
+		releases, err := c.freshRelease(id)
+		if err == ErrNotExist {
+			userInfo, _ := c.GetUserInfo(id)
+			logger.WithFields(logrus.Fields{
+				"Id":   id,
+				"Name": userInfo.GetName(),
+			}).Warn("歌手或电台不存在，订阅将失效")
+			c.RemoveAllById(id)
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load release failed %v", err)
+		}
+		var result []concern.Event
+		for _, release := range releases {
+			result = append(result, release)
+		}
+		return result, nil
+	})
+}
+
+func NewConcern(notify chan<- concern.Notify) *Concern {
+	c := &Concern{
+		StateManager: NewStateManager(notify),
+	}
+	return c
+}
@@ -0,0 +1,190 @@
+package netease
+
+import (
+	"context"
+	"fmt"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcern_ParseId(t *testing.T) {
+	c := NewConcern(nil)
+	for s, expected := range map[string]string{
+		"12345":   "12345",
+		" 12345 ": "12345",
+		"dj67890": "dj67890",
+		"DJ67890": "dj67890",
+		"https://music.163.com/#/artist?id=12345":      "12345",
+		"https://music.163.com/artist?id=12345&a=b":    "12345",
+		"https://music.163.com/#/djradio?id=67890":     "dj67890",
+		"https://music.163.com/m/djradio?a=b&id=67890": "dj67890",
+	} {
+		id, err := c.ParseId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, id, s)
+	}
+	for _, s := range []string{"", "abc", "dj", "12a", "https://music.163.com/#/album?id=1"} {
+		_, err := c.ParseId(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestFilterNewReleases(t *testing.T) {
+	releases := []*ReleaseInfo{
+		{ReleaseId: 3, PublishTime: 300},
+		{ReleaseId: 2, PublishTime: 200},
+		{ReleaseId: 1, PublishTime: 100},
+	}
+	result := filterNewReleases(releases, 100)
+	assert.Len(t, result, 2)
+	assert.EqualValues(t, 2, result[0].ReleaseId)
+	assert.EqualValues(t, 3, result[1].ReleaseId)
+
+	assert.Empty(t, filterNewReleases(releases, 300))
+	assert.Len(t, filterNewReleases(releases, 0), 3)
+}
+
+func TestConcern_Fresh(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var albumCount int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/artist/albums/12345":
+			var albums = `{"id":1,"name":"album1","type":"专辑","publishTime":1000000}`
+			if atomic.LoadInt32(&albumCount) > 1 {
+				albums = `{"id":2,"name":"album2","type":"EP/Single","publishTime":2000000},` + albums
+			}
+			fmt.Fprintf(w, `{"code":200,"artist":{"id":12345,"name":"%v"},"hotAlbums":[%v]}`, test.NAME1, albums)
+		case "/api/djradio/get":
+			fmt.Fprintf(w, `{"code":200,"djRadio":{"id":%v,"name":"%v","dj":{"nickname":"%v"}}}`,
+				r.URL.Query().Get("id"), test.NAME2, test.NAME1)
+		case "/api/dj/program/byradio":
+			fmt.Fprint(w, `{"code":200,"programs":[{"id":10,"name":"program","createTime":3000000}]}`)
+		default:
+			fmt.Fprint(w, `{"code":404,"msg":"not found"}`)
+		}
+	}))
+	defer ts.Close()
+	oldHost := Host
+	Host = ts.URL
+	defer func() { Host = oldHost }()
+
+	c := NewConcern(nil)
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1))
+
+	_, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), "dj67890", News)
+	assert.Equal(t, ErrTypeMismatch, err)
+	_, err = c.Add(nil, mmsg.NewGroupTargetId(test.G1), "12345", Radio)
+	assert.Equal(t, ErrTypeMismatch, err)
+
+	identity, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), "12345", News)
+	assert.Nil(t, err)
+	assert.Equal(t, test.NAME1, identity.GetName())
+	identity, err = c.Add(nil, mmsg.NewGroupTargetId(test.G1), "dj67890", Radio)
+	assert.Nil(t, err)
+	assert.Equal(t, test.NAME2, identity.GetName())
+	_, err = c.Add(nil, mmsg.NewGroupTargetId(test.G1), "54321", News)
+	assert.NotNil(t, err)
+
+	// 第一次刷新不推送
+	releases, err := c.freshRelease("12345")
+	assert.Nil(t, err)
+	assert.Empty(t, releases)
+	releases, err = c.freshRelease("12345")
+	assert.Nil(t, err)
+	assert.Empty(t, releases)
+
+	atomic.StoreInt32(&albumCount, 2)
+	releases, err = c.freshRelease("12345")
+	assert.Nil(t, err)
+	if assert.Len(t, releases, 1) {
+		assert.EqualValues(t, 2, releases[0].ReleaseId)
+		assert.Equal(t, News, releases[0].Type())
+		assert.EqualValues(t, 2000, releases[0].PublishTime)
+	}
+	releases, err = c.freshRelease("12345")
+	assert.Nil(t, err)
+	assert.Empty(t, releases)
+
+	releases, err = c.freshRelease("dj67890")
+	assert.Nil(t, err)
+	assert.Empty(t, releases)
+	programs, err := c.loadReleases("dj67890")
+	assert.Nil(t, err)
+	if assert.Len(t, programs, 1) {
+		assert.Equal(t, Radio, programs[0].Type())
+		assert.Equal(t, test.NAME1, programs[0].DjName)
+	}
+
+	_, err = c.freshRelease("54321")
+	assert.Equal(t, ErrNotExist, err)
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), "12345", News)
+	assert.Nil(t, err)
+	_, err = c.GetUserInfo("12345")
+	assert.NotNil(t, err)
+}
+
+func TestConcern(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify)
+
+	c := NewConcern(testNotifyChan)
+	assert.NotNil(t, c.GetStateManager())
+	assert.Equal(t, Site, c.Site())
+
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(func(ctx context.Context, eventChan chan<- concern.Event) {
+		for {
+			select {
+			case e := <-testEventChan:
+				if e != nil {
+					eventChan <- e
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	assert.Nil(t, c.StateManager.Start())
+	defer c.Stop()
+	defer close(testEventChan)
+
+	userInfo := &UserInfo{Id: "12345", Name: test.NAME1}
+	assert.Nil(t, c.AddUserInfo(userInfo))
+	_, err := c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), "12345", News)
+	assert.Nil(t, err)
+
+	identity, err := c.Get("12345")
+	assert.Nil(t, err)
+	assert.Equal(t, "12345", identity.GetUid())
+	assert.Equal(t, test.NAME1, identity.GetName())
+
+	testEventChan <- &ReleaseInfo{
+		UserInfo:  *userInfo,
+		ReleaseId: 100,
+		Title:     test.NAME2,
+		ctype:     News,
+	}
+
+	select {
+	case notify := <-testNotifyChan:
+		assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+		assert.Equal(t, "12345", notify.GetUid())
+		assert.Equal(t, News, notify.Type())
+	case <-time.After(time.Second):
+		assert.Fail(t, "no notify received")
+	}
+}
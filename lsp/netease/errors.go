@@ -0,0 +1,8 @@
+package netease
+
+import "errors"
+
+var (
+	ErrNotExist     = errors.New("歌手或电台不存在")
+	ErrTypeMismatch = errors.New("歌手只支持订阅news，电台只支持订阅radio")
+)
@@ -0,0 +1,9 @@
+package netease
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern"
+)
+
+func init() {
+	concern.RegisterConcern(NewConcern(concern.GetNotifyChan()))
+}
@@ -0,0 +1,52 @@
+package netease
+
+import (
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
+
+type keySet struct {
+}
+
+func (l *keySet) GroupAtAllMarkKey(keys ...interface{}) string {
+	return buntdb.NeteaseGroupAtAllMarkKey(keys...)
+}
+
+func (l *keySet) GroupConcernConfigKey(keys ...interface{}) string {
+	return buntdb.NeteaseGroupConcernConfigKey(keys...)
+}
+
+func (l *keySet) GroupConcernStateKey(keys ...interface{}) string {
+	return buntdb.NeteaseGroupConcernStateKey(keys...)
+}
+
+func (l *keySet) FreshKey(keys ...interface{}) string {
+	return buntdb.NeteaseFreshKey(keys...)
+}
+
+func (l *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	return concern.ParseConcernStateKeyWithString(key)
+}
+
+type extraKey struct{}
+
+func (k extraKey) UserInfoKey(keys ...interface{}) string {
+	return buntdb.NeteaseUserInfoKey(keys...)
+}
+
+func (k extraKey) ReleaseKey(keys ...interface{}) string {
+	return buntdb.NeteaseReleaseKey(keys...)
+}
+
+func (k extraKey) LastReleaseTimeKey(keys ...interface{}) string {
+	return buntdb.NeteaseLastReleaseTimeKey(keys...)
+}
+
+func NewExtraKey() *extraKey {
+	return &extraKey{}
+}
+
+func NewKeySet() *keySet {
+	return &keySet{}
+}
@@ -0,0 +1,142 @@
+package netease
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// UserInfo 歌手或者电台的信息，电台的Id带有 dj 前缀
+type UserInfo struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+	// DjName 电台主播的昵称，歌手为空
+	DjName string `json:"dj_name"`
+}
+
+func (u *UserInfo) GetUid() interface{} {
+	return u.Id
+}
+
+func (u *UserInfo) GetName() string {
+	if u == nil {
+		return ""
+	}
+	return u.Name
+}
+
+// ReleaseInfo 歌手发布的专辑单曲，或者电台更新的节目
+type ReleaseInfo struct {
+	UserInfo
+	ReleaseId   int64  `json:"release_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Kind 专辑的类型，例如 专辑 EP/Single，节目为空
+	Kind        string `json:"kind"`
+	Cover       string `json:"cover"`
+	Size        int32  `json:"size"`
+	PublishTime int64  `json:"publish_time"`
+
+	ctype    concern_type.Type
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (r *ReleaseInfo) Type() concern_type.Type {
+	return r.ctype
+}
+
+func (r *ReleaseInfo) Site() string {
+	return Site
+}
+
+func (r *ReleaseInfo) Url() string {
+	if r.ctype == Radio {
+		return ProgramUrl(r.ReleaseId)
+	}
+	return AlbumUrl(r.ReleaseId)
+}
+
+// DedupKeys 实现 concern.NotifyDedupExt ，多个歌手合作的专辑会出现在每个歌手的主页
+func (r *ReleaseInfo) DedupKeys() []string {
+	return []string{fmt.Sprintf("%v:%v", r.ctype, r.ReleaseId)}
+}
+
+func (r *ReleaseInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":      Site,
+		"Id":        r.Id,
+		"Name":      r.Name,
+		"Type":      r.ctype.String(),
+		"ReleaseId": r.ReleaseId,
+		"Title":     r.Title,
+	})
+}
+
+func (r *ReleaseInfo) GetMSG() *mmsg.MSG {
+	r.once.Do(func() {
+		var cover = r.Cover
+		if len(cover) == 0 {
+			cover = r.Avatar
+		}
+		var data = map[string]interface{}{
+			"name":  r.Name,
+			"title": r.Title,
+			"url":   r.Url(),
+			"cover": cover,
+			"time":  localutils.TimestampFormat(r.PublishTime),
+		}
+		var name string
+		if r.ctype == Radio {
+			name = "notify.group.netease.radio.tmpl"
+			data["dj"] = r.DjName
+			data["desc"] = r.Description
+		} else {
+			name = "notify.group.netease.news.tmpl"
+			data["kind"] = r.Kind
+			data["size"] = r.Size
+		}
+		var err error
+		r.msgCache, err = template.LoadAndExec(name, data)
+		if err != nil {
+			logger.Errorf("netease: ReleaseInfo LoadAndExec error %v", err)
+		}
+		return
+	})
+	return r.msgCache
+}
+
+type ConcernReleaseNotify struct {
+	*ReleaseInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernReleaseNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernReleaseNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.ReleaseInfo.GetMSG()
+}
+
+func (notify *ConcernReleaseNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.ReleaseInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernReleaseNotify(target mmsg.TargetId, r *ReleaseInfo) *ConcernReleaseNotify {
+	if r == nil {
+		return nil
+	}
+	return &ConcernReleaseNotify{
+		r,
+		target,
+	}
+}
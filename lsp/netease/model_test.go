@@ -0,0 +1,51 @@
+package netease
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReleaseInfo(t *testing.T) {
+	r := &ReleaseInfo{
+		UserInfo: UserInfo{
+			Id:   "12345",
+			Name: test.NAME1,
+		},
+		ReleaseId:   100,
+		Title:       test.NAME2,
+		Kind:        "EP/Single",
+		PublishTime: 1600000000,
+		ctype:       News,
+	}
+	assert.Equal(t, Site, r.Site())
+	assert.Equal(t, "12345", r.GetUid())
+	assert.Equal(t, test.NAME1, r.GetName())
+	assert.Equal(t, News, r.Type())
+	assert.Equal(t, AlbumUrl(100), r.Url())
+	assert.EqualValues(t, []string{"news:100"}, r.DedupKeys())
+	notify := NewConcernReleaseNotify(mmsg.NewGroupTargetId(test.G1), r)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, News, notify.Type())
+	assert.NotNil(t, notify.ToMessage())
+
+	p := &ReleaseInfo{
+		UserInfo: UserInfo{
+			Id:     RadioId(678),
+			Name:   test.NAME1,
+			DjName: test.NAME2,
+		},
+		ReleaseId:   200,
+		Title:       test.NAME2,
+		Description: test.NAME1,
+		ctype:       Radio,
+	}
+	assert.Equal(t, Radio, p.Type())
+	assert.Equal(t, ProgramUrl(200), p.Url())
+	assert.NotNil(t, NewConcernReleaseNotify(mmsg.NewGroupTargetId(test.G2), p).ToMessage())
+
+	assert.Nil(t, NewConcernReleaseNotify(mmsg.NewGroupTargetId(test.G1), nil))
+}
@@ -0,0 +1,68 @@
+package netease
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+const (
+	Site = "netease"
+
+	// radioIdPrefix 电台与歌手的id都是数字，电台的id使用这个前缀区分
+	radioIdPrefix = "dj"
+)
+
+// Host 网易云音乐的接口地址，测试时可以替换
+var Host = "https://music.163.com"
+
+const (
+	PathArtistAlbums  = "/api/artist/albums/%v"
+	PathRadioDetail   = "/api/djradio/get"
+	PathRadioPrograms = "/api/dj/program/byradio"
+)
+
+func NeteasePath(path string) string {
+	return Host + path
+}
+
+func ArtistUrl(artistId int64) string {
+	return fmt.Sprintf("https://music.163.com/#/artist?id=%v", artistId)
+}
+
+func AlbumUrl(albumId int64) string {
+	return fmt.Sprintf("https://music.163.com/#/album?id=%v", albumId)
+}
+
+func RadioUrl(radioId int64) string {
+	return fmt.Sprintf("https://music.163.com/#/djradio?id=%v", radioId)
+}
+
+func ProgramUrl(programId int64) string {
+	return fmt.Sprintf("https://music.163.com/#/program?id=%v", programId)
+}
+
+// RadioId 返回电台在订阅中使用的id
+func RadioId(radioId int64) string {
+	return fmt.Sprintf("%v%v", radioIdPrefix, radioId)
+}
+
+// neteaseGet 请求网易云音乐的网页接口，这些接口不需要加密参数，但是需要携带Referer
+func neteaseGet(url string, params interface{}, out interface{}) error {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
+		requests.AddUAOption(),
+		requests.HeaderOption("Referer", "https://music.163.com/"),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}
+	return requests.Get(url, params, out, opts...)
+}
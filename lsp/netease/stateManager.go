@@ -0,0 +1,74 @@
+package netease
+
+import (
+	"errors"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"time"
+)
+
+type StateManager struct {
+	*concern.StateManager
+	*extraKey
+}
+
+func (c *StateManager) AddUserInfo(userInfo *UserInfo) error {
+	if userInfo == nil {
+		return errors.New("nil UserInfo")
+	}
+	return c.SetJson(c.UserInfoKey(userInfo.Id), userInfo)
+}
+
+func (c *StateManager) GetUserInfo(id string) (*UserInfo, error) {
+	var userInfo = &UserInfo{}
+	err := c.GetJson(c.UserInfoKey(id), userInfo, localdb.GetCacheOpt())
+	if err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
+
+// DeleteUserInfo 删除歌手或电台的信息以及推送进度
+func (c *StateManager) DeleteUserInfo(id string) error {
+	return c.RWCover(func() error {
+		var err error
+		for _, key := range []string{
+			c.UserInfoKey(id),
+			c.LastReleaseTimeKey(id),
+		} {
+			_, err = c.Delete(key, localdb.IgnoreNotFoundOpt())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkRelease 标记专辑或节目已经推送过，返回的replaced为true时说明之前已经标记过
+func (c *StateManager) MarkRelease(ctype concern_type.Type, releaseId int64) (replaced bool, err error) {
+	err = c.Set(c.ReleaseKey(ctype.String(), releaseId), "",
+		localdb.SetExpireOpt(time.Hour*24*30), localdb.SetGetIsOverwriteOpt(&replaced))
+	return
+}
+
+func (c *StateManager) SetLastReleaseTime(id string, ts int64) error {
+	return c.SetInt64(c.LastReleaseTimeKey(id), ts)
+}
+
+func (c *StateManager) GetLastReleaseTime(id string) (int64, error) {
+	return c.GetInt64(c.LastReleaseTimeKey(id))
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
+}
+
+func NewStateManager(notify chan<- concern.Notify) *StateManager {
+	sm := &StateManager{}
+	sm.extraKey = NewExtraKey()
+	sm.StateManager = concern.NewStateManagerWithCustomKey(Site, NewKeySet(), notify)
+	return sm
+}
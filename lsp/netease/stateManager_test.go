@@ -0,0 +1,69 @@
+package netease
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initStateManager(t *testing.T) *StateManager {
+	sm := NewStateManager(nil)
+	assert.NotNil(t, sm)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	return sm
+}
+
+func TestStateManager_UserInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1))
+
+	_, err := sm.GetUserInfo("12345")
+	assert.NotNil(t, err)
+	assert.NotNil(t, sm.AddUserInfo(nil))
+
+	expected := &UserInfo{
+		Id:   "12345",
+		Name: test.NAME1,
+	}
+	assert.Nil(t, sm.AddUserInfo(expected))
+	actual, err := sm.GetUserInfo("12345")
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+
+	assert.Nil(t, sm.SetLastReleaseTime("12345", 100))
+	assert.Nil(t, sm.DeleteUserInfo("12345"))
+	_, err = sm.GetUserInfo("12345")
+	assert.NotNil(t, err)
+	_, err = sm.GetLastReleaseTime("12345")
+	assert.NotNil(t, err)
+}
+
+func TestStateManager_MarkRelease(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	replaced, err := sm.MarkRelease(News, 100)
+	assert.Nil(t, err)
+	assert.False(t, replaced)
+	replaced, err = sm.MarkRelease(News, 100)
+	assert.Nil(t, err)
+	assert.True(t, replaced)
+	// 专辑和节目的id互不影响
+	replaced, err = sm.MarkRelease(Radio, 100)
+	assert.Nil(t, err)
+	assert.False(t, replaced)
+
+	_, err = sm.GetLastReleaseTime("12345")
+	assert.NotNil(t, err)
+	assert.Nil(t, sm.SetLastReleaseTime("12345", 100))
+	ts, err := sm.GetLastReleaseTime("12345")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 100, ts)
+}
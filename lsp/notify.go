@@ -1,13 +1,13 @@
 package lsp
 
 import (
-	"context"
 	"fmt"
 	"github.com/Mrs4s/MiraiGo/message"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	"github.com/Sora233/DDBOT/lsp/translate"
 	"github.com/Sora233/DDBOT/utils"
-	"github.com/Sora233/DDBOT/utils/msgstringer"
 	"github.com/sirupsen/logrus"
 	"runtime/debug"
 	"time"
@@ -24,138 +24,312 @@ func (l *Lsp) ConcernNotify() {
 	defer l.wg.Done()
 	for {
 		select {
-		case _inotify, ok := <-l.concernNotify:
+		case inotify, ok := <-l.concernNotify:
 			if !ok {
 				return
 			}
-			if _inotify == nil {
+			if inotify == nil {
 				continue
 			}
-			var inotify = _inotify
-			target := mmsg.NewGroupTarget(inotify.GetGroupCode())
-			nLogger := inotify.Logger()
+			concern.Publish(&concern.BusEvent{
+				Topic:    concern.TopicNotify,
+				Notifies: []concern.Notify{inotify},
+			})
+		}
+	}
+}
 
-			if l.LspStateManager.IsMuted(inotify.GetGroupCode(), utils.GetBot().GetUin()) {
-				nLogger.Info("BOT群内被禁言，跳过本次推送")
-				continue
-			}
+// onNotifyEvent 是QQ推送在事件总线中的订阅者
+func (l *Lsp) onNotifyEvent(e *concern.BusEvent) {
+	for _, inotify := range e.Notifies {
+		l.pushNotify(inotify)
+	}
+}
 
-			c, err := concern.GetConcernBySiteAndType(inotify.Site(), inotify.Type())
-			if err != nil {
-				nLogger.Errorf("GetConcernBySiteAndType error %v", err)
-				continue
-			}
-			cfg := c.GetStateManager().GetGroupConcernConfig(inotify.GetGroupCode(), inotify.GetUid())
-			cfg.NotifyBeforeCallback(inotify)
+// pushNotify 按照群配置生成推送内容，处理@全体成员与@特定成员，然后加入推送队列
+func (l *Lsp) pushNotify(inotify concern.Notify) {
+	target := inotify.GetTarget()
+	nLogger := inotify.Logger()
 
-			// 注意notify可能会缓存MSG
-			var m = l.NotifyMessage(inotify).Clone()
+	if target.TargetType().IsPrivate() && utils.GetBot().FindFriend(target.TargetCode()) == nil {
+		nLogger.Info("私聊订阅的QQ号已不是BOT的好友，跳过本次推送")
+		return
+	}
 
-			// atConfig
-			var atBeforeHook = cfg.AtBeforeHook(inotify)
-			if !atBeforeHook.Pass {
-				nLogger.WithField("Reason", atBeforeHook.Reason).Debug("notify @at filtered by hook AtBeforeHook")
-			} else {
-				// 有@全体成员 或者 @Someone
-				var qqadmin = atBeforeHook.Pass &&
-					l.PermissionStateManager.CheckGroupAdministrator(inotify.GetGroupCode(), utils.GetBot().GetUin())
-				var checkAtAll = qqadmin &&
-					cfg.GetGroupConcernAt().CheckAtAll(inotify.Type())
-				var atAllMark = checkAtAll &&
-					c.GetStateManager().CheckAndSetAtAllMark(inotify.GetGroupCode(), inotify.GetUid())
-				nLogger.WithFields(logrus.Fields{
-					"qqAdmin":    qqadmin,
-					"checkAtAll": checkAtAll,
-					"atMark":     atAllMark,
-				}).Trace("at_all condition")
-				if atBeforeHook.Pass && qqadmin && checkAtAll && atAllMark {
-					nLogger = nLogger.WithField("at_all", true)
-					newAtAllMsg(m)
-				} else {
-					ids := cfg.GetGroupConcernAt().GetAtSomeoneList(inotify.Type())
-					nLogger = nLogger.WithField("at_QQ", ids)
-					newAtIdsMsg(m, ids)
-				}
-			}
+	if target.TargetType().IsTelegram() && GetNotifySender(mmsg.TargetTelegram) == nil {
+		nLogger.Debug("没有配置telegram.token，跳过本次Telegram推送")
+		return
+	}
 
-			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-			if err := l.msgLimit.Acquire(ctx, 1); err != nil {
-				cancel()
-				nLogger.WithField("Content", msgstringer.MsgToString(m.Elements())).
-					Errorf("BOT负载过高，推送已积压超过一分钟，将舍弃本次推送。")
-				continue
-			}
-			cancel()
-			l.notifyWg.Add(1)
-			nLogger.Info("notify")
-			go func() {
-				defer l.notifyWg.Done()
-				defer func() {
-					l.msgLimit.Release(1)
-					if e := recover(); e != nil {
-						nLogger.WithField("stack", string(debug.Stack())).
-							Errorf("notify panic recovered: %v", e)
+	var muted = target.TargetType().IsGroup() && l.isGroupMuted(target.TargetCode())
+
+	// 模块只能在QQ群内禁用，其他推送目标只检查模块是否在运行
+	if !l.ModuleEnabled(target.GroupCode(), inotify.Site()) {
+		nLogger.Debug("订阅模块在本群已禁用，跳过本次推送")
+		return
+	}
+
+	c, err := concern.GetConcernBySiteAndType(inotify.Site(), inotify.Type())
+	if err != nil {
+		nLogger.Errorf("GetConcernBySiteAndType error %v", err)
+		return
+	}
+	cfg := c.GetStateManager().GetGroupConcernConfig(target, inotify.GetUid())
+	cfg.NotifyBeforeCallback(inotify)
+
+	// 注意notify可能会缓存MSG
+	var m = l.NotifyMessage(inotify).Clone()
+	if lm := l.localizeNotifyMessage(inotify); lm != nil {
+		m = lm
+	}
+	l.translateNotifyMessage(inotify, cfg, m)
+	if m = l.groupTemplateMessage(inotify, cfg, m); m == nil {
+		nLogger.Debug("notify skipped by group concern template")
+		return
+	}
+	if m = l.transformNotifyMessage(inotify, m); m == nil {
+		nLogger.Debug("notify skipped by custom notify template")
+		return
+	}
+	if l.blocklistNotify(inotify, m) {
+		return
+	}
+	// 去重在会丢弃推送的过滤之后检查，被丢弃的推送不会占用去重的时间窗口，
+	// 在汇总推送和免打扰暂存之前检查，重复的推送不会被暂存后一起发送
+	if l.isDuplicateNotify(inotify) {
+		nLogger.Info("相同内容已经推送过，跳过本次推送")
+		return
+	}
+
+	// 汇总推送和免打扰时段内暂存的推送不@任何人，也不会调用 NotifyAfterCallback
+	if l.digestNotify(inotify, m) {
+		return
+	}
+	if l.quietNotify(target, m) {
+		return
+	}
+	// 被禁言时保存推送，禁言解除后自动重新发送
+	if muted {
+		nLogger.Info("BOT群内被禁言，推送已保存，禁言解除后重新发送")
+		l.recordFailedPush(target, inotify.Site(), FailedReasonMuted, m)
+		return
+	}
+
+	// atConfig
+	var atBeforeHook = cfg.AtBeforeHook(inotify)
+	if target.TargetType().IsPrivate() {
+		// 私聊中没有@
+		atBeforeHook = &concern.HookResult{Reason: "private target"}
+	}
+	if target.TargetType().IsTelegram() {
+		atBeforeHook = &concern.HookResult{Reason: "telegram target"}
+	}
+	var mentionMode = cfg.GetGroupConcernAt().GetMentionMode(inotify.Type())
+	if atBeforeHook.Pass && mentionMode == concern.MentionNone {
+		atBeforeHook = &concern.HookResult{Reason: "mention none"}
+	}
+	if !atBeforeHook.Pass {
+		nLogger.WithField("Reason", atBeforeHook.Reason).Debug("notify @at filtered by hook AtBeforeHook")
+	} else {
+		// 有@全体成员 或者 @Someone
+		var qqadmin = atBeforeHook.Pass &&
+			l.PermissionStateManager.CheckGroupAdministrator(target.TargetCode(), utils.GetBot().GetUin())
+		var checkAtAll = qqadmin && mentionMode == concern.MentionAll
+		var atAllMark = checkAtAll &&
+			c.GetStateManager().CheckAndSetAtAllMark(target, inotify.GetUid())
+		nLogger.WithFields(logrus.Fields{
+			"qqAdmin":    qqadmin,
+			"checkAtAll": checkAtAll,
+			"atMark":     atAllMark,
+		}).Trace("at_all condition")
+		if atBeforeHook.Pass && qqadmin && checkAtAll && atAllMark {
+			nLogger = nLogger.WithField("at_all", true)
+			newAtAllMsg(m)
+		} else {
+			ids := l.mentionIds(inotify, cfg)
+			nLogger = nLogger.WithField("at_QQ", ids)
+			newAtIdsMsg(m, ids)
+		}
+	}
+
+	nLogger.Info("notify")
+	l.pushQueue.Push(&PushItem{
+		Target:   target,
+		Site:     inotify.Site(),
+		Priority: NotifyPushPriority(inotify),
+		MSG:      m,
+		Callback: func(msgs []*message.GroupMessage) {
+			// 去掉@全体成员之后发送成功时不算发送失败
+			var failed = len(msgs) == 0 || msgs[0].Id == -1
+			defer func() {
+				if failed {
+					l.recordFailedPush(target, inotify.Site(), FailedReasonSend, m)
+				}
+			}()
+			if len(msgs) > 0 {
+				cfg.NotifyAfterCallback(inotify, msgs[0])
+				if msgs[0].Id != -1 {
+					if err := l.LspStateManager.SetLastPush(target, inotify.Site(), inotify.GetUid(), time.Now()); err != nil {
+						nLogger.Errorf("SetLastPush error %v", err)
 					}
-				}()
-				msgs := l.GM(l.SendMsg(m, target))
-				if len(msgs) > 0 {
-					cfg.NotifyAfterCallback(inotify, msgs[0])
-				} else {
-					cfg.NotifyAfterCallback(inotify, nil)
 				}
-				if atBeforeHook.Pass {
-					var atIdsOnce bool
-					for _, msg := range msgs {
-						if msg.Id == -1 {
-							// 检查有没有@全体成员
-							e := utils.MessageFilter(msg.Elements, func(element message.IMessageElement) bool {
-								return element.Type() == message.At && element.(*message.AtElement).Target == 0
-							})
-							if len(e) == 0 {
-								continue
-							}
-							// 2022/09/24 现在@全员不会再作为单独一条消息
-							// 有@全体成员的消息应该去掉之后重试
-							secondM := mmsg.NewMSGFromGroupMessage(msg)
-							secondM.Drop(func(e message.IMessageElement, _ int) bool {
-								return e.Type() == message.At && e.(*message.AtElement).Target == 0
-							})
-
-							secondRes := l.GM(l.SendMsg(secondM, target))
-							// secondRes一定是一条
-							if len(secondRes) != 1 {
-								panic(fmt.Sprintf("INTERNAL: len(secondRes) is %v", len(secondRes)))
-							}
-							if secondRes[0].Id == -1 {
-								// 去掉@全员还是发送失败
-								continue
-							}
-							if !atIdsOnce {
-								// 去掉@全员之后发送成功，可能是次数到了，尝试@列表
-								atIdsOnce = true
-							}
+			} else {
+				cfg.NotifyAfterCallback(inotify, nil)
+			}
+			if atBeforeHook.Pass {
+				var atIdsOnce bool
+				for _, msg := range msgs {
+					if msg.Id == -1 {
+						// 检查有没有@全体成员
+						e := utils.MessageFilter(msg.Elements, func(element message.IMessageElement) bool {
+							return element.Type() == message.At && element.(*message.AtElement).Target == 0
+						})
+						if len(e) == 0 {
+							continue
 						}
-					}
-					if atIdsOnce {
-						ids := cfg.GetGroupConcernAt().GetAtSomeoneList(inotify.Type())
-						if len(ids) != 0 {
-							nLogger = nLogger.WithField("at_QQ", ids)
-							nLogger.Debug("notify atAll failed, try at someone")
-							l.SendMsg(newAtIdsMsg(mmsg.NewMSG(), ids), target)
-						} else {
-							nLogger.Debug("notify atAll failed, at someone not config")
+						// 2022/09/24 现在@全员不会再作为单独一条消息
+						// 有@全体成员的消息应该去掉之后重试
+						secondM := mmsg.NewMSGFromGroupMessage(msg)
+						secondM.Drop(func(e message.IMessageElement, _ int) bool {
+							return e.Type() == message.At && e.(*message.AtElement).Target == 0
+						})
+
+						secondRes := l.GM(l.SendMsg(secondM, target))
+						// secondRes一定是一条
+						if len(secondRes) != 1 {
+							panic(fmt.Sprintf("INTERNAL: len(secondRes) is %v", len(secondRes)))
+						}
+						if secondRes[0].Id == -1 {
+							// 去掉@全员还是发送失败
+							continue
+						}
+						if msg == msgs[0] {
+							failed = false
+						}
+						if !atIdsOnce {
+							// 去掉@全员之后发送成功，可能是次数到了，尝试@列表
+							atIdsOnce = true
 						}
 					}
 				}
-			}()
-		}
+				if atIdsOnce {
+					ids := l.mentionIds(inotify, cfg)
+					if len(ids) != 0 {
+						nLogger = nLogger.WithField("at_QQ", ids)
+						nLogger.Debug("notify atAll failed, try at someone")
+						l.SendMsg(newAtIdsMsg(mmsg.NewMSG(), ids), target)
+					} else {
+						nLogger.Debug("notify atAll failed, at someone not config")
+					}
+				}
+			}
+		},
+	})
+}
+
+// sendNotifyMsg 使用 NotifySender 发送推送到订阅的推送目标
+func (l *Lsp) sendNotifyMsg(target mmsg.TargetId, m *mmsg.MSG) []*message.GroupMessage {
+	sender := GetNotifySender(target.TargetType())
+	if sender == nil && !target.TargetType().IsTelegram() {
+		sender = &qqNotifySender{l}
 	}
+	if sender == nil {
+		logger.WithField("TargetType", target.TargetType()).Errorf("没有找到推送渠道，跳过本次推送")
+		return []*message.GroupMessage{{Id: -1, GroupCode: target.TargetCode()}}
+	}
+	return sender.Send(target, m)
 }
 
 func (l *Lsp) NotifyMessage(inotify concern.Notify) *mmsg.MSG {
 	return inotify.ToMessage()
 }
 
+// translateNotifyMessage 如果群内为这个订阅开启了翻译，并且推送实现了 concern.NotifyTranslateExt ，
+// 则把外语正文的翻译附加在推送的末尾，翻译失败时保持原本的推送内容
+func (l *Lsp) translateNotifyMessage(inotify concern.Notify, cfg concern.IConfig, m *mmsg.MSG) {
+	if !cfg.GetGroupConcernNotify().CheckTranslate() {
+		return
+	}
+	ext, ok := inotify.(concern.NotifyTranslateExt)
+	if !ok {
+		return
+	}
+	result, err := translate.Translate(ext.TranslateText())
+	if err != nil {
+		inotify.Logger().Errorf("translate error %v", err)
+		return
+	}
+	if result != "" {
+		m.Text(l.groupT(inotify.GetTarget().GroupCode(), "notify.translate", result))
+	}
+}
+
+// groupTemplateMessage 如果群内为这个订阅设置了自定义推送模板，则使用模板的结果作为推送内容，
+// 模板数据除了通用的 .msg .group_code .target .site .type .uid 外，还包含 concern.NotifyTemplateData 提供的内容。
+// 模板结果为空时返回nil，表示跳过本次推送；模板解析或执行失败时仍然使用原本的推送内容。
+func (l *Lsp) groupTemplateMessage(inotify concern.Notify, cfg concern.IConfig, m *mmsg.MSG) *mmsg.MSG {
+	kind, text := cfg.GetGroupConcernTemplate().GetTemplate(inotify)
+	if text == "" {
+		return m
+	}
+	name := concern.NotifyTemplateName(kind)
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		inotify.Logger().Errorf("group concern template %v parse error %v", name, err)
+		return m
+	}
+	var data = map[string]interface{}{
+		"msg":        m,
+		"group_code": inotify.GetTarget().GroupCode(),
+		"target":     inotify.GetTarget().Key(),
+		"site":       inotify.Site(),
+		"type":       inotify.Type().String(),
+		"uid":        inotify.GetUid(),
+	}
+	if ext, ok := inotify.(concern.NotifyTemplateData); ok {
+		for k, v := range ext.TemplateData() {
+			if _, found := data[k]; !found {
+				data[k] = v
+			}
+		}
+	}
+	var result = mmsg.NewMSG()
+	if err = t.Execute(result, data); err != nil {
+		inotify.Logger().Errorf("group concern template %v execute error %v", name, err)
+		return m
+	}
+	if len(result.Elements()) == 0 {
+		return nil
+	}
+	return result
+}
+
+// transformNotifyMessage 如果存在自定义推送模板 custom.notify.group.<site>.<type>.tmpl ，
+// 则使用模板的结果作为推送内容，原本的推送内容可以在模板中通过 .msg 引用。
+// 模板结果为空时返回nil，表示跳过本次推送；模板执行失败时仍然使用原本的推送内容。
+func (l *Lsp) transformNotifyMessage(inotify concern.Notify, m *mmsg.MSG) *mmsg.MSG {
+	name := fmt.Sprintf("custom.notify.group.%v.%v.tmpl", inotify.Site(), inotify.Type())
+	if template.LoadTemplate(name) == nil {
+		return m
+	}
+	result, err := template.LoadAndExec(name, map[string]interface{}{
+		"msg":        m,
+		"group_code": inotify.GetTarget().GroupCode(),
+		"target":     inotify.GetTarget().Key(),
+		"site":       inotify.Site(),
+		"type":       inotify.Type().String(),
+		"uid":        inotify.GetUid(),
+	})
+	if err != nil {
+		inotify.Logger().Errorf("custom notify template %v error %v", name, err)
+		return m
+	}
+	if len(result.Elements()) == 0 {
+		return nil
+	}
+	return result
+}
+
 func newAtAllMsg(m *mmsg.MSG) *mmsg.MSG {
 	return m.AtAll(true)
 }
@@ -0,0 +1,57 @@
+package lsp
+
+import (
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"sync"
+)
+
+// NotifySender 推送的发送渠道，每种 mmsg.TargetType 对应一个 NotifySender ，
+// QQ群与QQ私聊没有注册时使用QQ发送，其他渠道需要通过 RegisterNotifySender 注册
+type NotifySender interface {
+	// Send 发送推送，返回值至少包含一个元素，Id为-1表示发送失败
+	Send(target mmsg.Target, m *mmsg.MSG) []*message.GroupMessage
+}
+
+type notifySenders struct {
+	mu      sync.RWMutex
+	senders map[mmsg.TargetType]NotifySender
+}
+
+var senders = &notifySenders{senders: make(map[mmsg.TargetType]NotifySender)}
+
+// RegisterNotifySender 注册推送渠道，会覆盖同一个 mmsg.TargetType 已经注册的渠道
+func RegisterNotifySender(tp mmsg.TargetType, sender NotifySender) {
+	senders.mu.Lock()
+	defer senders.mu.Unlock()
+	senders.senders[tp] = sender
+}
+
+// GetNotifySender 返回 mmsg.TargetType 对应的推送渠道，没有注册时返回nil
+func GetNotifySender(tp mmsg.TargetType) NotifySender {
+	senders.mu.RLock()
+	defer senders.mu.RUnlock()
+	return senders.senders[tp]
+}
+
+// qqNotifySender 通过QQ发送推送，私聊推送的结果也会转换成 message.GroupMessage 方便统一处理
+type qqNotifySender struct {
+	l *Lsp
+}
+
+func (q *qqNotifySender) Send(target mmsg.Target, m *mmsg.MSG) []*message.GroupMessage {
+	if target.TargetType().IsGroup() {
+		return q.l.GM(q.l.SendMsg(m, target))
+	}
+	var result []*message.GroupMessage
+	for _, pm := range q.l.PM(q.l.SendMsg(m, target)) {
+		result = append(result, &message.GroupMessage{
+			Id:        pm.Id,
+			GroupCode: target.TargetCode(),
+			Sender:    pm.Sender,
+			Time:      pm.Time,
+			Elements:  pm.Elements,
+		})
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package lsp
+
+import (
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testNotifySender struct {
+	targets []mmsg.Target
+}
+
+func (s *testNotifySender) Send(target mmsg.Target, m *mmsg.MSG) []*message.GroupMessage {
+	s.targets = append(s.targets, target)
+	return []*message.GroupMessage{{Id: 1, GroupCode: target.TargetCode()}}
+}
+
+func TestSendNotifyMsg(t *testing.T) {
+	defer RegisterNotifySender(mmsg.TargetTelegram, nil)
+
+	target := mmsg.NewTelegramTargetId(-100123)
+
+	RegisterNotifySender(mmsg.TargetTelegram, nil)
+	msgs := Instance.sendNotifyMsg(target, mmsg.NewText("test"))
+	if assert.Len(t, msgs, 1) {
+		assert.EqualValues(t, -1, msgs[0].Id)
+	}
+
+	sender := new(testNotifySender)
+	RegisterNotifySender(mmsg.TargetTelegram, sender)
+	msgs = Instance.sendNotifyMsg(target, mmsg.NewText("test"))
+	if assert.Len(t, msgs, 1) {
+		assert.EqualValues(t, 1, msgs[0].Id)
+		assert.EqualValues(t, -100123, msgs[0].GroupCode)
+	}
+	if assert.Len(t, sender.targets, 1) {
+		assert.True(t, sender.targets[0].TargetType().IsTelegram())
+		assert.EqualValues(t, -100123, sender.targets[0].TargetCode())
+	}
+}
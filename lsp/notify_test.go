@@ -3,14 +3,21 @@ package lsp
 import (
 	"github.com/Mrs4s/MiraiGo/message"
 	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/translate"
 	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/semaphore"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestLsp_ConcernNotify(t *testing.T) {
@@ -27,6 +34,17 @@ func TestLsp_ConcernNotify(t *testing.T) {
 	defer close(testNotifyChan)
 
 	Instance.concernNotify = testNotifyChan
+	var pushed = make(chan int64, 10)
+	Instance.pushQueue = NewPushQueue(Instance.LspStateManager, semaphore.NewWeighted(1),
+		func(target mmsg.TargetId, m *mmsg.MSG) []*message.GroupMessage {
+			pushed <- target.TargetCode()
+			return []*message.GroupMessage{{Id: 1, GroupCode: target.TargetCode()}}
+		})
+	Instance.pushQueue.Start()
+	defer Instance.pushQueue.Stop()
+
+	unsubscribe := concern.Subscribe("qq", Instance.onNotifyEvent, concern.TopicNotify)
+	defer unsubscribe()
 
 	var result *mmsg.MSG
 	msgChan := make(chan *mmsg.MSG, 10)
@@ -40,19 +58,28 @@ func TestLsp_ConcernNotify(t *testing.T) {
 	concern.RegisterConcern(tc1)
 	defer tc1.Stop()
 
-	IWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	IWatch(ctx, test.G2, test.NAME1, test.Site1, test.T1, false)
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G2), test.NAME1, test.Site1, test.T1, false)
 	result = <-msgChan
 	assert.Contains(t, msgstringer.MsgToString(result.ToCombineMessage(target).Elements), success)
 
-	testEventChan <- tc1.NewTestEvent(test.T1, 0, test.NAME1)
+	testEventChan <- tc1.NewTestEvent(test.T1, mmsg.TargetId{}, test.NAME1)
 
 	go Instance.ConcernNotify()
-	Instance.notifyWg.Wait()
-	Instance.wg.Wait()
+
+	var groups []int64
+	for i := 0; i < 2; i++ {
+		select {
+		case groupCode := <-pushed:
+			groups = append(groups, groupCode)
+		case <-time.After(time.Second * 5):
+			assert.Fail(t, "notify not pushed")
+		}
+	}
+	assert.ElementsMatch(t, []int64{test.G1, test.G2}, groups)
 
 	close(testEventChan)
 }
@@ -74,3 +101,103 @@ func TestNewAtIdsMsg(t *testing.T) {
 	assert.NotNil(t, msg)
 	assert.Len(t, msg.Elements(), 2)
 }
+
+type testTemplateNotify struct {
+	*tc.TestEvent
+}
+
+func (t *testTemplateNotify) TemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"name": test.NAME2,
+		"site": "overwrite",
+	}
+}
+
+func TestLsp_groupTemplateMessage(t *testing.T) {
+	var cfg = new(concern.GroupConcernConfig)
+	var notify = &testTemplateNotify{
+		TestEvent: tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1}).NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1),
+	}
+	var m = mmsg.NewText(test.NAME1)
+
+	assert.Equal(t, m, Instance.groupTemplateMessage(notify, cfg, m))
+
+	cfg.GetGroupConcernTemplate().News = "{{ .name }}|{{ .site }}|{{ .msg }}"
+	result := Instance.groupTemplateMessage(notify, cfg, m)
+	assert.NotNil(t, result)
+	assert.EqualValues(t, test.NAME2+"|"+notify.Site()+"|"+test.NAME1,
+		msgstringer.MsgToString(result.ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements))
+
+	cfg.GetGroupConcernTemplate().News = "{{ if false }}{{ end }}"
+	assert.Nil(t, Instance.groupTemplateMessage(notify, cfg, m))
+
+	cfg.GetGroupConcernTemplate().News = "{{ .name "
+	assert.Equal(t, m, Instance.groupTemplateMessage(notify, cfg, m))
+}
+
+type testTranslateNotify struct {
+	*tc.TestEvent
+	text string
+}
+
+func (t *testTranslateNotify) TranslateText() string {
+	return t.text
+}
+
+func TestLsp_translateNotifyMessage(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"translations":[{"translatedText":"今晚直播"}]}}`))
+	}))
+	defer ts.Close()
+	var googleUrl = translate.GoogleUrl
+	translate.GoogleUrl = ts.URL
+	defer func() {
+		translate.GoogleUrl = googleUrl
+		config.GlobalConfig.Set("translate", nil)
+	}()
+	config.GlobalConfig.Set("translate.provider", "google")
+	config.GlobalConfig.Set("translate.google.apiKey", "key")
+
+	var cfg = new(concern.GroupConcernConfig)
+	var notify = &testTranslateNotify{
+		TestEvent: tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1}).NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1),
+		text:      "今夜配信します",
+	}
+	var toString = func(m *mmsg.MSG) string {
+		return msgstringer.MsgToString(m.ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements)
+	}
+
+	// 没有开启翻译
+	var m = mmsg.NewText(test.NAME1)
+	Instance.translateNotifyMessage(notify, cfg, m)
+	assert.Equal(t, test.NAME1, toString(m))
+
+	cfg.GetGroupConcernNotify().Translate = true
+	Instance.translateNotifyMessage(notify, cfg, m)
+	assert.Equal(t, test.NAME1+"\n翻译：今晚直播", toString(m))
+
+	// 中文不翻译
+	m = mmsg.NewText(test.NAME1)
+	notify.text = "今晚直播"
+	Instance.translateNotifyMessage(notify, cfg, m)
+	assert.Equal(t, test.NAME1, toString(m))
+}
+
+func TestLsp_sendNotifyMsg(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	// bot不在线时发送失败，但会返回对应的结果
+	msgs := Instance.sendNotifyMsg(mmsg.NewGroupTargetId(test.G1), mmsg.NewTextf("group"))
+	assert.Len(t, msgs, 1)
+	assert.EqualValues(t, -1, msgs[0].Id)
+
+	msgs = Instance.sendNotifyMsg(mmsg.NewPrivateTargetId(test.UID1), mmsg.NewTextf("private"))
+	assert.Len(t, msgs, 1)
+	assert.EqualValues(t, -1, msgs[0].Id)
+	assert.EqualValues(t, test.UID1, msgs[0].GroupCode)
+	assert.EqualValues(t, "private", msgstringer.MsgToString(msgs[0].Elements))
+}
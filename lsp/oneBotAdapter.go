@@ -0,0 +1,60 @@
+package lsp
+
+import (
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/onebot"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+// oneBotAccount 通过OneBot协议收发消息的主账号
+type oneBotAccount struct {
+	*onebot.Client
+}
+
+func (o *oneBotAccount) SendGroupMessage(groupCode int64, m *message.SendingMessage) *message.GroupMessage {
+	id, err := o.SendGroupMsg(groupCode, m.Elements)
+	if err != nil {
+		logger.WithFields(localutils.GroupLogFields(groupCode)).Errorf("OneBot发送群消息失败 %v", err)
+		id = -1
+	}
+	return &message.GroupMessage{
+		Id:        id,
+		GroupCode: groupCode,
+		Sender:    &message.Sender{Uin: o.GetUin()},
+		Time:      int32(time.Now().Unix()),
+		Elements:  m.Elements,
+	}
+}
+
+func (o *oneBotAccount) SendPrivateMessage(uin int64, m *message.SendingMessage) *message.PrivateMessage {
+	id, err := o.SendPrivateMsg(uin, m.Elements)
+	if err != nil {
+		logger.WithFields(localutils.FriendLogFields(uin)).Errorf("OneBot发送私聊消息失败 %v", err)
+		id = -1
+	}
+	return &message.PrivateMessage{
+		Id:       id,
+		Self:     o.GetUin(),
+		Target:   uin,
+		Time:     int32(time.Now().Unix()),
+		Sender:   &message.Sender{Uin: o.GetUin()},
+		Elements: m.Elements,
+	}
+}
+
+// ConnectOneBot 通过OneBot v11正向websocket连接go-cqhttp等实现，代替MiraiGo收发消息，
+// 订阅、推送与数据库的逻辑不受影响
+func (l *Lsp) ConnectOneBot() error {
+	cli := onebot.NewClient(cfg.GetOneBotAddr(), cfg.GetOneBotAccessToken())
+	cli.OnGroupMessage(l.onGroupMessage)
+	cli.OnPrivateMessage(l.onPrivateMessage)
+	if err := cli.Connect(); err != nil {
+		return err
+	}
+	localutils.GetBot().SetAdapter(cli)
+	l.accounts.SetMain(&oneBotAccount{cli})
+	l.oneBot = cli
+	return nil
+}
@@ -0,0 +1,519 @@
+package onebot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/client"
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/atomic"
+	"golang.org/x/net/websocket"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var logger = utils.GetModuleLogger("onebot")
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const (
+	ActionGetLoginInfo       = "get_login_info"
+	ActionGetGroupList       = "get_group_list"
+	ActionGetGroupMemberList = "get_group_member_list"
+	ActionGetFriendList      = "get_friend_list"
+	ActionSendGroupMsg       = "send_group_msg"
+	ActionSendPrivateMsg     = "send_private_msg"
+)
+
+var (
+	// CallTimeout 调用api等待响应的超时时间
+	CallTimeout = time.Second * 30
+	// ReconnectInterval 连接断开后重新连接的间隔
+	ReconnectInterval = time.Second * 5
+	// RefreshInterval 定期刷新群列表和好友列表的间隔
+	RefreshInterval = time.Minute * 30
+)
+
+var ErrOffline = errors.New("OneBot连接已断开")
+
+type request struct {
+	Action string      `json:"action"`
+	Params interface{} `json:"params"`
+	Echo   string      `json:"echo"`
+}
+
+type response struct {
+	Status  string              `json:"status"`
+	Retcode int                 `json:"retcode"`
+	Msg     string              `json:"msg"`
+	Wording string              `json:"wording"`
+	Data    jsoniter.RawMessage `json:"data"`
+	Echo    string              `json:"echo"`
+}
+
+type eventSender struct {
+	UserId   int64  `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Card     string `json:"card"`
+}
+
+type event struct {
+	PostType    string              `json:"post_type"`
+	MessageType string              `json:"message_type"`
+	NoticeType  string              `json:"notice_type"`
+	Time        int64               `json:"time"`
+	SelfId      int64               `json:"self_id"`
+	MessageId   int32               `json:"message_id"`
+	GroupId     int64               `json:"group_id"`
+	UserId      int64               `json:"user_id"`
+	Message     jsoniter.RawMessage `json:"message"`
+	Sender      *eventSender        `json:"sender"`
+}
+
+// segments 返回事件中的消息段，兼容array与string两种上报格式
+func (e *event) segments() []*Segment {
+	if len(e.Message) == 0 {
+		return nil
+	}
+	if e.Message[0] == '"' {
+		var s string
+		if err := json.Unmarshal(e.Message, &s); err != nil {
+			return nil
+		}
+		return ParseCQCode(s)
+	}
+	var result []*Segment
+	if err := json.Unmarshal(e.Message, &result); err != nil {
+		logger.Errorf("OneBot消息解析失败 %v", err)
+		return nil
+	}
+	return result
+}
+
+// Client 通过OneBot v11正向websocket连接go-cqhttp等实现，提供收发消息和查询群、好友的功能
+type Client struct {
+	addr  string
+	token string
+
+	connMu sync.Mutex
+	conn   *websocket.Conn
+	sendMu sync.Mutex
+
+	echo    atomic.Int64
+	pending sync.Map
+
+	online   atomic.Bool
+	uin      atomic.Int64
+	listMu   sync.RWMutex
+	groups   []*client.GroupInfo
+	friends  []*client.FriendInfo
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	groupMessageHandler   func(msg *message.GroupMessage)
+	privateMessageHandler func(msg *message.PrivateMessage)
+}
+
+// NewClient addr为正向websocket地址，例如 ws://127.0.0.1:6700，token为空时不鉴权
+func NewClient(addr string, token string) *Client {
+	return &Client{
+		addr:  addr,
+		token: token,
+		stop:  make(chan struct{}),
+	}
+}
+
+// OnGroupMessage 设置收到群消息时的回调，需要在 Connect 之前设置
+func (c *Client) OnGroupMessage(f func(msg *message.GroupMessage)) {
+	c.groupMessageHandler = f
+}
+
+// OnPrivateMessage 设置收到私聊消息时的回调，需要在 Connect 之前设置
+func (c *Client) OnPrivateMessage(f func(msg *message.PrivateMessage)) {
+	c.privateMessageHandler = f
+}
+
+// Connect 建立连接并获取bot的信息、群列表和好友列表，之后连接断开时会自动重连
+func (c *Client) Connect() error {
+	done, err := c.dial()
+	if err != nil {
+		return err
+	}
+	go c.reconnectLoop(done)
+	go c.refreshLoop()
+	return nil
+}
+
+// Close 断开连接并停止重连
+func (c *Client) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		c.connMu.Lock()
+		if c.conn != nil {
+			c.conn.Close()
+		}
+		c.connMu.Unlock()
+	})
+}
+
+// dial 建立连接，返回的channel在连接断开后关闭
+func (c *Client) dial() (chan struct{}, error) {
+	config, err := websocket.NewConfig(c.addr, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	if len(c.token) > 0 {
+		config.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
+	c.online.Store(true)
+	var done = make(chan struct{})
+	go c.readLoop(conn, done)
+
+	var info struct {
+		UserId   int64  `json:"user_id"`
+		Nickname string `json:"nickname"`
+	}
+	if err = c.call(ActionGetLoginInfo, nil, &info); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("get_login_info error %v", err)
+	}
+	c.uin.Store(info.UserId)
+	logger.WithField("Uin", info.UserId).Infof("OneBot连接成功：%v", info.Nickname)
+	if err = c.RefreshList(); err != nil {
+		logger.Errorf("OneBot刷新群列表和好友列表失败 %v", err)
+	}
+	return done, nil
+}
+
+func (c *Client) readLoop(conn *websocket.Conn, done chan struct{}) {
+	defer close(done)
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			select {
+			case <-c.stop:
+			default:
+				logger.Errorf("OneBot连接断开 %v", err)
+			}
+			break
+		}
+		c.handle(data)
+	}
+	c.online.Store(false)
+	conn.Close()
+	// 让正在等待响应的调用立即失败
+	c.pending.Range(func(key, value interface{}) bool {
+		c.pending.Delete(key)
+		close(value.(chan *response))
+		return true
+	})
+}
+
+func (c *Client) reconnectLoop(done chan struct{}) {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-done:
+		}
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(ReconnectInterval):
+			}
+			var err error
+			if done, err = c.dial(); err != nil {
+				logger.Errorf("OneBot重新连接失败 %v", err)
+				continue
+			}
+			break
+		}
+	}
+}
+
+func (c *Client) refreshLoop() {
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if !c.IsOnline() {
+				continue
+			}
+			if err := c.RefreshList(); err != nil {
+				logger.Errorf("OneBot刷新群列表和好友列表失败 %v", err)
+			}
+		}
+	}
+}
+
+func (c *Client) handle(data []byte) {
+	var e = new(event)
+	if err := json.Unmarshal(data, e); err != nil {
+		logger.Errorf("OneBot数据解析失败 %v", err)
+		return
+	}
+	if len(e.PostType) == 0 {
+		var resp = new(response)
+		if err := json.Unmarshal(data, resp); err != nil {
+			logger.Errorf("OneBot响应解析失败 %v", err)
+			return
+		}
+		if ch, found := c.pending.LoadAndDelete(resp.Echo); found {
+			ch.(chan *response) <- resp
+		}
+		return
+	}
+	switch e.PostType {
+	case "message":
+		c.handleMessage(e)
+	case "notice":
+		switch e.NoticeType {
+		case "group_increase", "group_decrease", "friend_add":
+			if e.UserId == e.SelfId || e.NoticeType == "friend_add" {
+				go func() {
+					if err := c.RefreshList(); err != nil {
+						logger.Errorf("OneBot刷新群列表和好友列表失败 %v", err)
+					}
+				}()
+			}
+		}
+	}
+}
+
+func (c *Client) handleMessage(e *event) {
+	var sender = &message.Sender{Uin: e.UserId}
+	if e.Sender != nil {
+		sender.Nickname = e.Sender.Nickname
+		sender.CardName = e.Sender.Card
+	}
+	switch e.MessageType {
+	case "group":
+		if c.groupMessageHandler == nil {
+			return
+		}
+		var msg = &message.GroupMessage{
+			Id:        e.MessageId,
+			GroupCode: e.GroupId,
+			Sender:    sender,
+			Time:      int32(e.Time),
+			Elements:  ToElements(e.segments(), true),
+		}
+		if gi := c.FindGroup(e.GroupId); gi != nil {
+			msg.GroupName = gi.Name
+		}
+		c.groupMessageHandler(msg)
+	case "private":
+		if c.privateMessageHandler == nil {
+			return
+		}
+		sender.IsFriend = c.FindFriend(e.UserId) != nil
+		c.privateMessageHandler(&message.PrivateMessage{
+			Id:       e.MessageId,
+			Self:     e.SelfId,
+			Target:   e.SelfId,
+			Time:     int32(e.Time),
+			Sender:   sender,
+			Elements: ToElements(e.segments(), false),
+		})
+	}
+}
+
+// call 调用api并等待响应，out不为nil时解析响应中的data
+func (c *Client) call(action string, params interface{}, out interface{}) error {
+	c.connMu.Lock()
+	conn := c.conn
+	c.connMu.Unlock()
+	if conn == nil || !c.online.Load() {
+		return ErrOffline
+	}
+	echo := strconv.FormatInt(c.echo.Inc(), 10)
+	ch := make(chan *response, 1)
+	c.pending.Store(echo, ch)
+	defer c.pending.Delete(echo)
+
+	b, err := json.Marshal(&request{Action: action, Params: params, Echo: echo})
+	if err != nil {
+		return err
+	}
+	c.sendMu.Lock()
+	err = websocket.Message.Send(conn, string(b))
+	c.sendMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrOffline
+		}
+		if resp.Status == "failed" || resp.Retcode != 0 {
+			return fmt.Errorf("%v failed: retcode %v %v %v", action, resp.Retcode, resp.Msg, resp.Wording)
+		}
+		if out != nil && len(resp.Data) > 0 {
+			return json.Unmarshal(resp.Data, out)
+		}
+		return nil
+	case <-time.After(CallTimeout):
+		return fmt.Errorf("%v timeout", action)
+	}
+}
+
+// RefreshList 重新获取群列表、群成员列表和好友列表
+func (c *Client) RefreshList() error {
+	var groupList []struct {
+		GroupId        int64  `json:"group_id"`
+		GroupName      string `json:"group_name"`
+		MemberCount    uint32 `json:"member_count"`
+		MaxMemberCount uint32 `json:"max_member_count"`
+	}
+	if err := c.call(ActionGetGroupList, nil, &groupList); err != nil {
+		return err
+	}
+	var groups []*client.GroupInfo
+	for _, g := range groupList {
+		gi := &client.GroupInfo{
+			Uin:            g.GroupId,
+			Code:           g.GroupId,
+			Name:           g.GroupName,
+			MemberCount:    uint16(g.MemberCount),
+			MaxMemberCount: uint16(g.MaxMemberCount),
+		}
+		var memberList []struct {
+			UserId   int64  `json:"user_id"`
+			Nickname string `json:"nickname"`
+			Card     string `json:"card"`
+			Role     string `json:"role"`
+		}
+		if err := c.call(ActionGetGroupMemberList, map[string]interface{}{"group_id": g.GroupId}, &memberList); err != nil {
+			logger.WithField("GroupCode", g.GroupId).Errorf("get_group_member_list error %v", err)
+		}
+		for _, m := range memberList {
+			var permission = client.Member
+			switch m.Role {
+			case "owner":
+				permission = client.Owner
+				gi.OwnerUin = m.UserId
+			case "admin":
+				permission = client.Administrator
+			}
+			gi.Members = append(gi.Members, &client.GroupMemberInfo{
+				Group:      gi,
+				Uin:        m.UserId,
+				Nickname:   m.Nickname,
+				CardName:   m.Card,
+				Permission: permission,
+			})
+		}
+		// GroupInfo.FindMember 使用二分查找
+		sort.Slice(gi.Members, func(i, j int) bool {
+			return gi.Members[i].Uin < gi.Members[j].Uin
+		})
+		groups = append(groups, gi)
+	}
+
+	var friendList []struct {
+		UserId   int64  `json:"user_id"`
+		Nickname string `json:"nickname"`
+		Remark   string `json:"remark"`
+	}
+	if err := c.call(ActionGetFriendList, nil, &friendList); err != nil {
+		return err
+	}
+	var friends []*client.FriendInfo
+	for _, f := range friendList {
+		friends = append(friends, &client.FriendInfo{
+			Uin:      f.UserId,
+			Nickname: f.Nickname,
+			Remark:   f.Remark,
+		})
+	}
+
+	c.listMu.Lock()
+	c.groups = groups
+	c.friends = friends
+	c.listMu.Unlock()
+	logger.Debugf("OneBot已加载%v个群，%v个好友", len(groups), len(friends))
+	return nil
+}
+
+func (c *Client) GetUin() int64 {
+	return c.uin.Load()
+}
+
+func (c *Client) IsOnline() bool {
+	return c.online.Load()
+}
+
+func (c *Client) FindFriend(uin int64) *client.FriendInfo {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+	for _, f := range c.friends {
+		if f.Uin == uin {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *Client) FindGroup(code int64) *client.GroupInfo {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+	for _, g := range c.groups {
+		if g.Code == code {
+			return g
+		}
+	}
+	return nil
+}
+
+func (c *Client) GetGroupList() []*client.GroupInfo {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+	return c.groups
+}
+
+func (c *Client) GetFriendList() []*client.FriendInfo {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+	return c.friends
+}
+
+func (c *Client) sendMsg(action string, params map[string]interface{}, elements []message.IMessageElement) (int32, error) {
+	segments := ToSegments(elements)
+	if len(segments) == 0 {
+		return 0, errors.New("empty message")
+	}
+	params["message"] = segments
+	var result struct {
+		MessageId int32 `json:"message_id"`
+	}
+	if err := c.call(action, params, &result); err != nil {
+		return 0, err
+	}
+	return result.MessageId, nil
+}
+
+// SendGroupMsg 发送群消息，返回消息id
+func (c *Client) SendGroupMsg(groupCode int64, elements []message.IMessageElement) (int32, error) {
+	return c.sendMsg(ActionSendGroupMsg, map[string]interface{}{"group_id": groupCode}, elements)
+}
+
+// SendPrivateMsg 发送私聊消息，返回消息id
+func (c *Client) SendPrivateMsg(uin int64, elements []message.IMessageElement) (int32, error) {
+	return c.sendMsg(ActionSendPrivateMsg, map[string]interface{}{"user_id": uin}, elements)
+}
@@ -0,0 +1,182 @@
+package onebot
+
+import (
+	"github.com/Mrs4s/MiraiGo/client"
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer 模拟go-cqhttp的正向websocket
+func newTestServer(t *testing.T, token string) (*httptest.Server, chan map[string]interface{}, chan string) {
+	var sent = make(chan map[string]interface{}, 10)
+	var push = make(chan string, 10)
+	s := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		if len(token) > 0 && ws.Request().Header.Get("Authorization") != "Bearer "+token {
+			return
+		}
+		var mu sync.Mutex
+		reply := func(echo string, data string) {
+			mu.Lock()
+			defer mu.Unlock()
+			websocket.Message.Send(ws, `{"status":"ok","retcode":0,"data":`+data+`,"echo":"`+echo+`"}`)
+		}
+		go func() {
+			for p := range push {
+				mu.Lock()
+				websocket.Message.Send(ws, p)
+				mu.Unlock()
+			}
+		}()
+		for {
+			var data string
+			if err := websocket.Message.Receive(ws, &data); err != nil {
+				return
+			}
+			var req map[string]interface{}
+			assert.Nil(t, json.UnmarshalFromString(data, &req))
+			echo := req["echo"].(string)
+			switch req["action"] {
+			case ActionGetLoginInfo:
+				reply(echo, `{"user_id":1,"nickname":"bot"}`)
+			case ActionGetGroupList:
+				reply(echo, `[{"group_id":10,"group_name":"g10","member_count":3,"max_member_count":200}]`)
+			case ActionGetGroupMemberList:
+				reply(echo, `[{"user_id":300,"nickname":"c","role":"member"},{"user_id":100,"nickname":"a","role":"owner"},{"user_id":200,"nickname":"b","card":"bb","role":"admin"}]`)
+			case ActionGetFriendList:
+				reply(echo, `[{"user_id":100,"nickname":"a","remark":"ra"}]`)
+			case ActionSendGroupMsg, ActionSendPrivateMsg:
+				sent <- req
+				reply(echo, `{"message_id":123}`)
+			default:
+				reply(echo, `null`)
+			}
+		}
+	}))
+	return s, sent, push
+}
+
+func TestClient(t *testing.T) {
+	s, sent, push := newTestServer(t, "token")
+	defer s.Close()
+	defer close(push)
+
+	addr := "ws" + strings.TrimPrefix(s.URL, "http")
+
+	assert.NotNil(t, NewClient(addr, "wrong").Connect())
+
+	c := NewClient(addr, "token")
+	var groupMsg = make(chan *message.GroupMessage, 1)
+	var privateMsg = make(chan *message.PrivateMessage, 1)
+	c.OnGroupMessage(func(msg *message.GroupMessage) {
+		groupMsg <- msg
+	})
+	c.OnPrivateMessage(func(msg *message.PrivateMessage) {
+		privateMsg <- msg
+	})
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+
+	assert.True(t, c.IsOnline())
+	assert.EqualValues(t, 1, c.GetUin())
+	assert.Len(t, c.GetGroupList(), 1)
+	assert.Len(t, c.GetFriendList(), 1)
+	assert.Nil(t, c.FindGroup(test.G1))
+	assert.Nil(t, c.FindFriend(test.UID1))
+
+	gi := c.FindGroup(10)
+	if assert.NotNil(t, gi) {
+		assert.Equal(t, "g10", gi.Name)
+		assert.EqualValues(t, 100, gi.OwnerUin)
+		if assert.NotNil(t, gi.FindMember(200)) {
+			assert.Equal(t, client.Administrator, gi.FindMember(200).Permission)
+			assert.Equal(t, "bb", gi.FindMember(200).CardName)
+		}
+		assert.NotNil(t, gi.FindMember(300))
+	}
+	assert.Equal(t, "ra", c.FindFriend(100).Remark)
+
+	id, err := c.SendGroupMsg(10, []message.IMessageElement{message.NewText("hello"), message.NewAt(0)})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 123, id)
+	select {
+	case req := <-sent:
+		params := req["params"].(map[string]interface{})
+		assert.EqualValues(t, 10, params["group_id"])
+		assert.Len(t, params["message"], 2)
+	case <-time.After(time.Second):
+		assert.Fail(t, "send_group_msg not received")
+	}
+
+	_, err = c.SendPrivateMsg(100, nil)
+	assert.NotNil(t, err)
+	_, err = c.SendPrivateMsg(100, []message.IMessageElement{message.NewText("hi")})
+	assert.Nil(t, err)
+	<-sent
+
+	push <- `{"post_type":"message","message_type":"group","time":1,"self_id":1,"message_id":5,"group_id":10,"user_id":200,"message":[{"type":"text","data":{"text":"/help"}}],"sender":{"user_id":200,"nickname":"b","card":"bb"}}`
+	select {
+	case msg := <-groupMsg:
+		assert.EqualValues(t, 10, msg.GroupCode)
+		assert.Equal(t, "g10", msg.GroupName)
+		assert.EqualValues(t, 200, msg.Sender.Uin)
+		assert.Equal(t, "bb", msg.Sender.CardName)
+		if assert.Len(t, msg.Elements, 1) {
+			assert.Equal(t, "/help", msg.Elements[0].(*message.TextElement).Content)
+		}
+	case <-time.After(time.Second):
+		assert.Fail(t, "group message not received")
+	}
+
+	push <- `{"post_type":"message","message_type":"private","time":1,"self_id":1,"message_id":6,"user_id":100,"message":"/watch [CQ:face,id=1]","sender":{"user_id":100,"nickname":"a"}}`
+	select {
+	case msg := <-privateMsg:
+		assert.EqualValues(t, 100, msg.Sender.Uin)
+		assert.True(t, msg.Sender.IsFriend)
+		assert.Len(t, msg.Elements, 2)
+	case <-time.After(time.Second):
+		assert.Fail(t, "private message not received")
+	}
+}
+
+func TestSegments(t *testing.T) {
+	segments := ToSegments([]message.IMessageElement{
+		message.NewText("a"),
+		message.NewAt(test.UID1),
+		message.NewAt(0),
+		message.NewFace(1),
+		&message.GroupImageElement{Url: "base64://xx"},
+		&message.ReplyElement{ReplySeq: 3},
+		&message.VoiceElement{},
+	})
+	if assert.Len(t, segments, 6) {
+		assert.Equal(t, "all", segments[2].Get("qq"))
+		assert.Equal(t, "base64://xx", segments[4].Get("file"))
+		assert.Equal(t, "3", segments[5].Get("id"))
+	}
+
+	elements := ToElements(segments, false)
+	if assert.Len(t, elements, 6) {
+		assert.EqualValues(t, test.UID1, elements[1].(*message.AtElement).Target)
+		assert.EqualValues(t, 0, elements[2].(*message.AtElement).Target)
+		assert.EqualValues(t, 1, elements[3].(*message.FaceElement).Index)
+		assert.Equal(t, "base64://xx", elements[4].(*message.FriendImageElement).Url)
+		assert.EqualValues(t, 3, elements[5].(*message.ReplyElement).ReplySeq)
+	}
+
+	segments = ParseCQCode("a&#91;b[CQ:at,qq=123]c[CQ:image,file=x.jpg,url=http://a/b?c&amp;d]")
+	if assert.Len(t, segments, 4) {
+		assert.Equal(t, "a[b", segments[0].Get("text"))
+		assert.Equal(t, "at", segments[1].Type)
+		assert.Equal(t, "123", segments[1].Get("qq"))
+		assert.Equal(t, "c", segments[2].Get("text"))
+		assert.Equal(t, "http://a/b?c&d", segments[3].Get("url"))
+	}
+	assert.Len(t, ParseCQCode("[CQ:at"), 1)
+}
@@ -0,0 +1,135 @@
+package onebot
+
+import (
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/message"
+	"strconv"
+	"strings"
+)
+
+// Segment OneBot v11的消息段，例如 {"type":"text","data":{"text":"hello"}}
+type Segment struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
+
+func newSegment(tp string, kv ...string) *Segment {
+	var s = &Segment{Type: tp, Data: make(map[string]interface{})}
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.Data[kv[i]] = kv[i+1]
+	}
+	return s
+}
+
+// Get 返回消息段中的参数，数字类型的参数也转成字符串返回
+func (s *Segment) Get(key string) string {
+	v, found := s.Data[key]
+	if !found || v == nil {
+		return ""
+	}
+	switch o := v.(type) {
+	case string:
+		return o
+	case float64:
+		return strconv.FormatFloat(o, 'f', -1, 64)
+	default:
+		return fmt.Sprint(o)
+	}
+}
+
+func (s *Segment) getInt64(key string) int64 {
+	i, _ := strconv.ParseInt(s.Get(key), 10, 64)
+	return i
+}
+
+// ToSegments 把MiraiGo的消息转换成OneBot的消息段，不支持的消息类型会被忽略
+func ToSegments(elements []message.IMessageElement) []*Segment {
+	var result []*Segment
+	for _, e := range elements {
+		switch o := e.(type) {
+		case *message.TextElement:
+			result = append(result, newSegment("text", "text", o.Content))
+		case *message.AtElement:
+			if o.Target == 0 {
+				result = append(result, newSegment("at", "qq", "all"))
+			} else {
+				result = append(result, newSegment("at", "qq", strconv.FormatInt(o.Target, 10)))
+			}
+		case *message.FaceElement:
+			result = append(result, newSegment("face", "id", strconv.FormatInt(int64(o.Index), 10)))
+		case *message.GroupImageElement:
+			result = append(result, newSegment("image", "file", o.Url))
+		case *message.FriendImageElement:
+			result = append(result, newSegment("image", "file", o.Url))
+		case *message.ReplyElement:
+			result = append(result, newSegment("reply", "id", strconv.FormatInt(int64(o.ReplySeq), 10)))
+		default:
+			logger.WithField("Type", e.Type()).Debug("OneBot不支持的消息类型，已忽略")
+		}
+	}
+	return result
+}
+
+// ToElements 把OneBot的消息段转换成MiraiGo的消息，group为true时图片转换为群图片
+func ToElements(segments []*Segment, group bool) []message.IMessageElement {
+	var result []message.IMessageElement
+	for _, s := range segments {
+		switch s.Type {
+		case "text":
+			result = append(result, message.NewText(s.Get("text")))
+		case "at":
+			if s.Get("qq") == "all" {
+				result = append(result, &message.AtElement{Target: 0, Display: "@全体成员"})
+			} else {
+				result = append(result, message.NewAt(s.getInt64("qq")))
+			}
+		case "face":
+			result = append(result, message.NewFace(int32(s.getInt64("id"))))
+		case "image":
+			url := s.Get("url")
+			if len(url) == 0 {
+				url = s.Get("file")
+			}
+			if group {
+				result = append(result, &message.GroupImageElement{Url: url})
+			} else {
+				result = append(result, &message.FriendImageElement{Url: url})
+			}
+		case "reply":
+			result = append(result, &message.ReplyElement{ReplySeq: int32(s.getInt64("id"))})
+		}
+	}
+	return result
+}
+
+var cqUnescaper = strings.NewReplacer("&#91;", "[", "&#93;", "]", "&#44;", ",", "&amp;", "&")
+
+// ParseCQCode 解析字符串格式的消息，例如 "hello[CQ:at,qq=123]"
+func ParseCQCode(s string) []*Segment {
+	var result []*Segment
+	for len(s) > 0 {
+		start := strings.Index(s, "[CQ:")
+		if start < 0 {
+			result = append(result, newSegment("text", "text", cqUnescaper.Replace(s)))
+			break
+		}
+		end := strings.Index(s[start:], "]")
+		if end < 0 {
+			result = append(result, newSegment("text", "text", cqUnescaper.Replace(s)))
+			break
+		}
+		if start > 0 {
+			result = append(result, newSegment("text", "text", cqUnescaper.Replace(s[:start])))
+		}
+		parts := strings.Split(s[start+len("[CQ:"):start+end], ",")
+		var seg = newSegment(parts[0])
+		for _, part := range parts[1:] {
+			if k, v, found := strings.Cut(part, "="); found {
+				seg.Data[k] = cqUnescaper.Replace(v)
+			}
+		}
+		result = append(result, seg)
+		s = s[start+end+1:]
+	}
+	return result
+}
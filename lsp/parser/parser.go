@@ -15,6 +15,8 @@ type Parser struct {
 	AtTarget int64
 	// AtArgs 记录命令后的@
 	AtArgs []int64
+	// RawText 记录所有文本消息拼接后的原始内容，未经过分割
+	RawText string
 
 	commandName   string
 	commandPrefix string
@@ -47,12 +49,13 @@ func (p *Parser) Parse(elems []message.IMessageElement) {
 			}
 		}
 	}
-	var buf strings.Builder
+	var buf, raw strings.Builder
 	textElems := utils.MessageFilter(elems, func(element message.IMessageElement) bool {
 		return element.Type() == message.Text
 	})
 	for _, element := range textElems {
 		if te, ok := element.(*message.TextElement); ok {
+			raw.WriteString(te.Content)
 			text := strings.TrimSpace(strings.Replace(te.Content, " ", " ", -1))
 			if text == "" {
 				continue
@@ -61,6 +64,7 @@ func (p *Parser) Parse(elems []message.IMessageElement) {
 			buf.WriteString(" ")
 		}
 	}
+	p.RawText = raw.String()
 	splitStr := utils.ArgSplit(strings.TrimSpace(buf.String()))
 	if len(splitStr) >= 1 {
 		p.Command = strings.TrimSpace(splitStr[0])
@@ -79,6 +83,16 @@ func (p *Parser) GetArgs() []string {
 	return p.Args
 }
 
+// GetRawTextAfterFirstLine 返回原始文本中第一行之后的内容，没有换行时返回空字符串
+// 用于需要保留引号、换行等原始格式的参数，例如自定义模板
+func (p *Parser) GetRawTextAfterFirstLine() string {
+	pos := strings.Index(p.RawText, "\n")
+	if pos < 0 {
+		return ""
+	}
+	return strings.TrimSpace(p.RawText[pos+1:])
+}
+
 func (p *Parser) GetAtArgs() []int64 {
 	return p.AtArgs
 }
@@ -58,3 +58,17 @@ func TestParser_Parse2(t *testing.T) {
 	assert.EqualValues(t, []string{"/a", "-b", "1", "-c", "2", "-d", "3", "-e", "4"}, p.GetCmdArgs())
 	assert.EqualValues(t, []int64{test.UID1, test.UID2}, p.GetAtArgs())
 }
+
+func TestParser_GetRawTextAfterFirstLine(t *testing.T) {
+	p := NewParser()
+	p.Parse([]message.IMessageElement{message.NewText("/config template 1 live set")})
+	assert.EqualValues(t, "", p.GetRawTextAfterFirstLine())
+
+	p = NewParser()
+	p.Parse([]message.IMessageElement{
+		message.NewText("/config template 1 live set\n"),
+		message.NewText("{{ .name }}\n\"开播了\""),
+	})
+	assert.EqualValues(t, "/config", p.GetCmd())
+	assert.EqualValues(t, "{{ .name }}\n\"开播了\"", p.GetRawTextAfterFirstLine())
+}
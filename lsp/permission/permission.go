@@ -1,6 +1,7 @@
 package permission
 
 import (
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -13,6 +14,12 @@ const (
 	Admin RoleType = 1 << iota
 	GroupAdmin
 	User
+	// GroupOwner 群内拥有 GroupAdmin 的全部权限，并且可以授予和撤销群内其他角色
+	GroupOwner
+	// GroupManager 群内可以订阅、取消订阅和修改订阅配置
+	GroupManager
+	// GroupSubscriber 群内只能订阅和取消订阅
+	GroupSubscriber
 )
 
 const Enable = "enable"
@@ -26,6 +33,12 @@ func (t RoleType) String() string {
 		return "GroupAdmin"
 	case User:
 		return "User"
+	case GroupOwner:
+		return "GroupOwner"
+	case GroupManager:
+		return "GroupManager"
+	case GroupSubscriber:
+		return "GroupSubscriber"
 	default:
 		return ""
 	}
@@ -39,6 +52,12 @@ func NewRoleFromString(s string) RoleType {
 		return GroupAdmin
 	case "User":
 		return User
+	case "GroupOwner":
+		return GroupOwner
+	case "GroupManager":
+		return GroupManager
+	case "GroupSubscriber":
+		return GroupSubscriber
 	default:
 		return Unknown
 	}
@@ -75,7 +94,7 @@ type groupAdminRoleRequireOption struct {
 func (g *groupAdminRoleRequireOption) Validate(s *StateManager) bool {
 	uin := g.uin
 	groupCode := g.groupCode
-	if s.CheckGroupRole(groupCode, uin, GroupAdmin) {
+	if s.CheckGroupRole(groupCode, uin, GroupAdmin) || s.CheckGroupRole(groupCode, uin, GroupOwner) {
 		logger.WithFields(localutils.GroupLogFields(groupCode)).
 			WithFields(logrus.Fields{
 				"type": "GroupAdminRole",
@@ -86,10 +105,41 @@ func (g *groupAdminRoleRequireOption) Validate(s *StateManager) bool {
 	return false
 }
 
+// GroupAdminRoleRequireOption uin 在群内拥有 GroupAdmin 或 GroupOwner 角色时通过
 func GroupAdminRoleRequireOption(groupCode int64, uin int64) RequireOption {
 	return &groupAdminRoleRequireOption{groupCode: groupCode, uin: uin}
 }
 
+type groupRoleRequireOption struct {
+	groupCode int64
+	uin       int64
+	roles     []RoleType
+}
+
+func (g *groupRoleRequireOption) Validate(s *StateManager) bool {
+	for _, role := range g.roles {
+		if s.CheckGroupRole(g.groupCode, g.uin, role) {
+			logger.WithFields(localutils.GroupLogFields(g.groupCode)).
+				WithFields(logrus.Fields{
+					"type": "GroupRole",
+					"uin":  g.uin,
+					"role": role.String(),
+				}).Debug("groupRole permission pass")
+			return true
+		}
+	}
+	return false
+}
+
+// GroupRoleRequireOption uin 在群内拥有 roles 中任意一个角色时通过
+func GroupRoleRequireOption(groupCode int64, uin int64, roles ...RoleType) RequireOption {
+	return &groupRoleRequireOption{
+		groupCode: groupCode,
+		uin:       uin,
+		roles:     roles,
+	}
+}
+
 type qqAdminRequireOption struct {
 	groupCode int64
 	uin       int64
@@ -145,3 +195,27 @@ func GroupCommandRequireOption(groupCode int64, uin int64, command string) Requi
 		command:   command,
 	}
 }
+
+type privateConcernOwnerRequireOption struct {
+	target mmsg.TargetId
+	uin    int64
+}
+
+func (p *privateConcernOwnerRequireOption) Validate(s *StateManager) bool {
+	if p.target == mmsg.NewPrivateTargetId(p.uin) {
+		logger.WithFields(logrus.Fields{
+			"type": "PrivateConcernOwner",
+			"uin":  p.uin,
+		}).Debug("privateConcernOwner permission pass")
+		return true
+	}
+	return false
+}
+
+// PrivateConcernOwnerRequireOption target 为 uin 本人的私聊订阅时通过
+func PrivateConcernOwnerRequireOption(target mmsg.TargetId, uin int64) RequireOption {
+	return &privateConcernOwnerRequireOption{
+		target: target,
+		uin:    uin,
+	}
+}
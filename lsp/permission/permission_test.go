@@ -10,12 +10,18 @@ func TestRoleType_String(t *testing.T) {
 		Admin,
 		GroupAdmin,
 		User,
+		GroupOwner,
+		GroupManager,
+		GroupSubscriber,
 		Unknown,
 	}
 	var expected = []string{
 		"Admin",
 		"GroupAdmin",
 		"User",
+		"GroupOwner",
+		"GroupManager",
+		"GroupSubscriber",
 		"",
 	}
 	assert.Equal(t, len(expected), len(testCase))
@@ -29,12 +35,18 @@ func TestFromString(t *testing.T) {
 		"Admin",
 		"GroupAdmin",
 		"User",
+		"GroupOwner",
+		"GroupManager",
+		"GroupSubscriber",
 		"",
 	}
 	var expected = []RoleType{
 		Admin,
 		GroupAdmin,
 		User,
+		GroupOwner,
+		GroupManager,
+		GroupSubscriber,
 		Unknown,
 	}
 	assert.Equal(t, len(expected), len(testCase))
@@ -7,7 +7,6 @@ import (
 	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/MiraiGo-Template/utils"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/buntdb"
 	"strconv"
 	"strings"
 	"time"
@@ -129,7 +128,7 @@ func (c *StateManager) CheckGlobalCommandDisabled(command string) bool {
 
 func (c *StateManager) CheckGroupCommandFunc(groupCode int64, command string, f func(val string, exist bool) bool) bool {
 	var result bool
-	err := c.RCoverTx(func(tx *buntdb.Tx) error {
+	err := c.RCoverTx(func(tx localdb.Tx) error {
 		val, err := c.Get(c.GroupEnabledKey(groupCode, command))
 		if err != nil && !localdb.IsNotFound(err) {
 			return err
@@ -148,7 +147,7 @@ func (c *StateManager) CheckGroupCommandFunc(groupCode int64, command string, f
 
 func (c *StateManager) CheckGlobalCommandFunc(command string, f func(val string, exist bool) bool) bool {
 	var result bool
-	err := c.RCoverTx(func(tx *buntdb.Tx) error {
+	err := c.RCoverTx(func(tx localdb.Tx) error {
 		val, err := c.Get(c.GlobalEnabledKey(command))
 		if err != nil && !localdb.IsNotFound(err) {
 			return err
@@ -247,7 +246,7 @@ func (c *StateManager) CheckNoAdmin() bool {
 
 func (c *StateManager) ListAdmin() []int64 {
 	var result []int64
-	err := c.RCoverTx(func(tx *buntdb.Tx) error {
+	err := c.RCoverTx(func(tx localdb.Tx) error {
 		return tx.Ascend(c.PermissionKey(), func(key, value string) bool {
 			splits := strings.Split(key, ":")
 			if len(splits) != 3 {
@@ -272,14 +271,19 @@ func (c *StateManager) ListAdmin() []int64 {
 }
 
 func (c *StateManager) ListGroupAdmin(groupCode int64) []int64 {
+	return c.ListGroupRole(groupCode, GroupAdmin)
+}
+
+// ListGroupRole 返回群内拥有 role 角色的所有qq号
+func (c *StateManager) ListGroupRole(groupCode int64, role RoleType) []int64 {
 	var result []int64
-	err := c.RCoverTx(func(tx *buntdb.Tx) error {
+	err := c.RCoverTx(func(tx localdb.Tx) error {
 		return tx.Ascend(c.GroupPermissionKey(groupCode), func(key, value string) bool {
 			splits := strings.Split(key, ":")
 			if len(splits) != 4 {
 				return true
 			}
-			if NewRoleFromString(splits[3]) == GroupAdmin {
+			if NewRoleFromString(splits[3]) == role {
 				i, err := strconv.ParseInt(splits[2], 0, 64)
 				if err != nil {
 					logger.WithField("Key", key).Errorf("Parse GroupPermissionKey error %v", err)
@@ -292,7 +296,7 @@ func (c *StateManager) ListGroupAdmin(groupCode int64) []int64 {
 	})
 	if err != nil {
 		result = nil
-		logger.Errorf("ListGroupAdmin error %v", err)
+		logger.WithField("Role", role.String()).Errorf("ListGroupRole error %v", err)
 	}
 	return result
 }
@@ -364,6 +368,16 @@ func (c *StateManager) RemoveAllByGroupCode(groupCode int64) ([]string, error) {
 	return localdb.RemoveByPrefixAndIndex(prefixKey, indexKey)
 }
 
+// GroupKeyPrefix 返回group内权限，命令开关，沉默设置的key前缀
+func (c *StateManager) GroupKeyPrefix(groupCode int64) []string {
+	return []string{
+		c.GroupPermissionKey(groupCode),
+		c.PermissionKey(groupCode),
+		c.GroupEnabledKey(groupCode),
+		c.GroupSilenceKey(groupCode),
+	}
+}
+
 func (c *StateManager) FreshIndex() {
 	for _, pattern := range []localdb.KeyPatternFunc{c.PermissionKey, c.GroupPermissionKey, c.GroupEnabledKey} {
 		c.CreatePatternIndex(pattern, nil)
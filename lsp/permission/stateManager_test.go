@@ -2,6 +2,7 @@ package permission
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -129,6 +130,17 @@ func TestStateManager_CheckRole(t *testing.T) {
 	assert.NotNil(t, c.UngrantRole(test.UID2, Admin))
 }
 
+func TestPrivateConcernOwnerRequireOption(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	c := initStateManager(t)
+
+	assert.True(t, PrivateConcernOwnerRequireOption(mmsg.NewPrivateTargetId(test.UID1), test.UID1).Validate(c))
+	assert.False(t, PrivateConcernOwnerRequireOption(mmsg.NewPrivateTargetId(test.UID2), test.UID1).Validate(c))
+	assert.False(t, PrivateConcernOwnerRequireOption(mmsg.NewGroupTargetId(test.G1), test.UID1).Validate(c))
+	assert.False(t, PrivateConcernOwnerRequireOption(mmsg.NewGroupTargetId(test.UID1), test.UID1).Validate(c))
+}
+
 func TestStateManager_CheckGroupRole(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
@@ -173,6 +185,40 @@ func TestStateManager_CheckGroupRole(t *testing.T) {
 	assert.Empty(t, ids)
 }
 
+func TestStateManager_GroupRole(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	c := initStateManager(t)
+
+	gadminOpt := GroupAdminRoleRequireOption(test.G1, test.UID1)
+	managerOpt := GroupRoleRequireOption(test.G1, test.UID1, GroupManager)
+	watchOpt := GroupRoleRequireOption(test.G1, test.UID1, GroupManager, GroupSubscriber)
+
+	assert.Nil(t, c.GrantGroupRole(test.G1, test.UID1, GroupSubscriber))
+	assert.False(t, gadminOpt.Validate(c))
+	assert.False(t, managerOpt.Validate(c))
+	assert.True(t, watchOpt.Validate(c))
+	assert.False(t, GroupRoleRequireOption(test.G2, test.UID1, GroupSubscriber).Validate(c))
+
+	assert.Nil(t, c.GrantGroupRole(test.G1, test.UID1, GroupManager))
+	assert.True(t, managerOpt.Validate(c))
+	assert.False(t, gadminOpt.Validate(c))
+
+	// GroupOwner 拥有 GroupAdmin 的权限
+	assert.Nil(t, c.GrantGroupRole(test.G1, test.UID2, GroupOwner))
+	assert.True(t, GroupAdminRoleRequireOption(test.G1, test.UID2).Validate(c))
+	assert.False(t, c.CheckGroupAdmin(test.G1, test.UID2))
+
+	assert.EqualValues(t, []int64{test.UID1}, c.ListGroupRole(test.G1, GroupSubscriber))
+	assert.EqualValues(t, []int64{test.UID1}, c.ListGroupRole(test.G1, GroupManager))
+	assert.EqualValues(t, []int64{test.UID2}, c.ListGroupRole(test.G1, GroupOwner))
+	assert.Empty(t, c.ListGroupAdmin(test.G1))
+
+	assert.Nil(t, c.UngrantGroupRole(test.G1, test.UID1, GroupSubscriber))
+	assert.Empty(t, c.ListGroupRole(test.G1, GroupSubscriber))
+	assert.True(t, watchOpt.Validate(c))
+}
+
 func TestStateManager_CheckGroupCommandPermission(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
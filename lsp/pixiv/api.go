@@ -0,0 +1,173 @@
+package pixiv
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/guonaihong/gout"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type apiUser struct {
+	UserId   string `json:"userId"`
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	ImageBig string `json:"imageBig"`
+}
+
+type apiWorks struct {
+	// Illusts 和 Manga 的key为作品id，没有作品时pixiv返回空数组而不是空对象
+	Illusts json.RawMessage `json:"illusts"`
+	Manga   json.RawMessage `json:"manga"`
+}
+
+type apiIllust struct {
+	Id          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	IllustType  int      `json:"illustType"`
+	XRestrict   int      `json:"xRestrict"`
+	AiType      int      `json:"aiType"`
+	Tags        []string `json:"tags"`
+	UserId      string   `json:"userId"`
+	UserName    string   `json:"userName"`
+	PageCount   int      `json:"pageCount"`
+	CreateDate  string   `json:"createDate"`
+}
+
+type apiPage struct {
+	Urls struct {
+		Small    string `json:"small"`
+		Regular  string `json:"regular"`
+		Original string `json:"original"`
+	} `json:"urls"`
+}
+
+// workIds 解析作品id，忽略空数组
+func workIds(raw json.RawMessage) []int64 {
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	var result []int64
+	for id := range m {
+		if i, err := strconv.ParseInt(id, 10, 64); err == nil {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// GetUserInfo 查询画师的信息
+func GetUserInfo(uid int64) (*UserInfo, error) {
+	var user = new(apiUser)
+	err := pixivGet(BasePath(fmt.Sprintf(PathUserInfo, uid)), gout.H{"full": 0}, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.UserId) == 0 {
+		return nil, ErrNotExist
+	}
+	return &UserInfo{
+		Uid:    uid,
+		Name:   user.Name,
+		Avatar: user.ImageBig,
+	}, nil
+}
+
+// GetWorkIds 返回画师所有插画和漫画的id，按从新到旧排列
+func GetWorkIds(uid int64) ([]int64, error) {
+	var works = new(apiWorks)
+	err := pixivGet(BasePath(fmt.Sprintf(PathUserWorks, uid)), nil, works)
+	if err != nil {
+		return nil, err
+	}
+	var result = append(workIds(works.Illusts), workIds(works.Manga)...)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] > result[j]
+	})
+	return result, nil
+}
+
+// GetIllusts 查询画师的作品的详细信息，ids中查询不到的作品会被忽略
+func GetIllusts(userInfo *UserInfo, ids []int64) ([]*IllustInfo, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	// ids[] 需要重复多次，所以手动拼接参数
+	var params = url.Values{
+		"work_category": []string{"illustManga"},
+		"is_first_page": []string{"0"},
+	}
+	for _, id := range ids {
+		params.Add("ids[]", strconv.FormatInt(id, 10))
+	}
+	var body = new(struct {
+		Works map[string]*apiIllust `json:"works"`
+	})
+	err := pixivGet(BasePath(fmt.Sprintf(PathUserIllusts, userInfo.Uid))+"?"+params.Encode(), nil, body)
+	if err != nil {
+		return nil, err
+	}
+	var result []*IllustInfo
+	for _, id := range ids {
+		work, found := body.Works[strconv.FormatInt(id, 10)]
+		if !found || work == nil {
+			continue
+		}
+		var illust = &IllustInfo{
+			UserInfo:    *userInfo,
+			IllustId:    work.Id,
+			Title:       work.Title,
+			Description: work.Description,
+			IllustType:  work.IllustType,
+			XRestrict:   work.XRestrict,
+			AiType:      work.AiType,
+			Tags:        work.Tags,
+			PageCount:   work.PageCount,
+		}
+		if len(work.UserName) > 0 {
+			illust.Name = work.UserName
+		}
+		if createDate, err := time.Parse(time.RFC3339, work.CreateDate); err == nil {
+			illust.CreateDate = createDate.Unix()
+		}
+		result = append(result, illust)
+	}
+	return result, nil
+}
+
+// GetIllustPages 返回作品每一页的图片地址，最多返回 maxImages 个
+func GetIllustPages(illustId string) ([]string, error) {
+	var pages []*apiPage
+	err := pixivGet(BasePath(fmt.Sprintf(PathIllustPages, illustId)), nil, &pages)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, page := range pages {
+		if len(result) >= maxImages {
+			break
+		}
+		if len(page.Urls.Regular) > 0 {
+			result = append(result, page.Urls.Regular)
+		} else if len(page.Urls.Small) > 0 {
+			result = append(result, page.Urls.Small)
+		}
+	}
+	return result, nil
+}
+
+// CheckLogin 检查当前的cookie是否已经登陆
+func CheckLogin() error {
+	return pixivGet(BasePath(PathUserLoggedIn), nil, nil)
+}
+
+// parseCookieUid PHPSESSID 的格式为 uid_xxx ，返回其中的uid
+func parseCookieUid(phpSessId string) int64 {
+	uid, _ := strconv.ParseInt(strings.SplitN(phpSessId, "_", 2)[0], 10, 64)
+	return uid
+}
@@ -0,0 +1,264 @@
+package pixiv
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var logger = utils.GetModuleLogger("pixiv-concern")
+
+// urlUidRegexp 匹配画师主页链接，例如 https://www.pixiv.net/users/11 或者 member.php?id=11
+var urlUidRegexp = regexp.MustCompile(`pixiv\.net/(?:(?:[a-z]{2}/)?users/|member(?:_illust)?\.php\?id=)(\d+)`)
+
+const (
+	// News 画师发布的新作品
+	News concern_type.Type = "news"
+)
+
+type Concern struct {
+	*StateManager
+}
+
+func (c *Concern) Site() string {
+	return Site
+}
+
+func (c *Concern) Types() []concern_type.Type {
+	return []concern_type.Type{News}
+}
+
+// ParseId 使用画师的数字id，也支持直接输入画师主页链接
+func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if sub := urlUidRegexp.FindStringSubmatch(s); sub != nil {
+		s = sub[1]
+	}
+	uid, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || uid <= 0 {
+		return nil, fmt.Errorf("无效的pixiv用户id")
+	}
+	return uid, nil
+}
+
+func (c *Concern) GetStateManager() concern.IStateManager {
+	return c.StateManager
+}
+
+func (c *Concern) Stop() {
+	logger.Trace("正在停止pixiv concern")
+	logger.Trace("正在停止pixiv StateManager")
+	c.StateManager.Stop()
+	logger.Trace("pixiv StateManager已停止")
+	logger.Trace("pixiv concern已停止")
+}
+
+func (c *Concern) Start() error {
+	c.initCookie()
+	c.UseEmitQueue()
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(c.fresh())
+	return c.StateManager.Start()
+}
+
+// initCookie 保存配置中的cookie，并在后台检查是否已经登陆，没有配置时清除之前保存的cookie
+func (c *Concern) initCookie() {
+	var cookie = cfg.GetPixivCookie()
+	if len(cookie) == 0 {
+		if err := ClearCookieInfo(); err != nil {
+			logger.Errorf("ClearCookieInfo error %v", err)
+		}
+		return
+	}
+	var cookieInfo = &CookieInfo{
+		PHPSESSID: cookie,
+		Uid:       parseCookieUid(cookie),
+	}
+	if err := SetCookieInfo(cookieInfo); err != nil {
+		logger.Errorf("SetCookieInfo error %v", err)
+		return
+	}
+	go func() {
+		if err := CheckLogin(); err != nil {
+			logger.Warnf("pixiv cookie未登陆，将无法获取R-18作品 - %v", err)
+			return
+		}
+		cookieInfo.Login = true
+		if err := SetCookieInfo(cookieInfo); err != nil {
+			logger.Errorf("SetCookieInfo error %v", err)
+		}
+		logger.WithField("uid", cookieInfo.Uid).Debug("pixiv cookie已登陆")
+	}()
+}
+
+// getCookie 返回保存的cookie，没有时返回空字符串
+func getCookie() string {
+	cookieInfo, err := GetCookieInfo()
+	if err != nil || cookieInfo == nil {
+		return ""
+	}
+	return cookieInfo.PHPSESSID
+}
+
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	var err error
+	uid := _id.(int64)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("uid", uid)
+
+	err = c.StateManager.CheckGroupConcern(target, uid, ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := c.FindOrLoadUser(uid)
+	if err != nil {
+		log.Errorf("FindOrLoadUser error %v", err)
+		return nil, fmt.Errorf("查询pixiv用户信息失败 %v - %v", uid, err)
+	}
+	_, err = c.StateManager.AddGroupConcern(target, uid, ctype)
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(uid, userInfo.GetName()), nil
+}
+
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	uid := _id.(int64)
+	identity, _ := c.Get(uid)
+	_, err := c.StateManager.RemoveGroupConcern(target, uid, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
+		allCtype, err := c.GetConcern(uid)
+		if err != nil {
+			return err
+		}
+		if allCtype.Empty() {
+			return c.DeleteUserInfo(uid)
+		}
+		return nil
+	})
+	return identity, err
+}
+
+func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
+	userInfo, err := c.GetUserInfo(id.(int64))
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(userInfo.Uid, userInfo.GetName()), nil
+}
+
+func (c *Concern) FindOrLoadUser(uid int64) (*UserInfo, error) {
+	info, _ := c.GetUserInfo(uid)
+	if info != nil {
+		return info, nil
+	}
+	info, err := GetUserInfo(uid)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddUserInfo(info)
+	return info, nil
+}
+
+func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
+		switch info := event.(type) {
+		case *IllustInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("illust notify")
+			return []concern.Notify{NewConcernIllustNotify(target, info)}
+		default:
+			logger.Errorf("unknown EventType %+v", event)
+			return nil
+		}
+	}
+}
+
+// freshIllusts 返回画师新发布的作品，按从旧到新排列。
+// 作品的id是递增的，所以只需要记录最大的id，第一次刷新时只记录，不推送
+func (c *Concern) freshIllusts(uid int64) ([]*IllustInfo, error) {
+	ids, err := GetWorkIds(uid)
+	if err != nil {
+		return nil, err
+	}
+	lastId, err := c.GetLastIllustId(uid)
+	firstFresh := err == localdb.ErrNotFound
+	if err != nil && !firstFresh {
+		return nil, err
+	}
+	if len(ids) > maxFreshWorks {
+		ids = ids[:maxFreshWorks]
+	}
+	var maxId = lastId
+	var newIds []int64
+	for _, id := range ids {
+		if id > maxId {
+			maxId = id
+		}
+		if !firstFresh && id > lastId {
+			newIds = append(newIds, id)
+		}
+	}
+	var result []*IllustInfo
+	if len(newIds) > 0 {
+		userInfo, err := c.GetUserInfo(uid)
+		if err != nil {
+			userInfo = &UserInfo{Uid: uid}
+		}
+		illusts, err := GetIllusts(userInfo, newIds)
+		if err != nil {
+			return nil, err
+		}
+		// 按从旧到新的顺序推送
+		for i := len(illusts) - 1; i >= 0; i-- {
+			illust := illusts[i]
+			illust.Images, err = GetIllustPages(illust.IllustId)
+			if err != nil {
+				illust.Logger().Errorf("GetIllustPages error %v", err)
+			}
+			result = append(result, illust)
+		}
+	}
+	if err = c.SetLastIllustId(uid, maxId); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Concern) fresh() concern.FreshFunc {
+	return c.EmitQueueFresher(func(ctype concern_type.Type, _id interface{}) ([]concern.Event, error) {
+		uid := _id.(int64)
+		illusts, err := c.freshIllusts(uid)
+		if err == ErrNotExist {
+			userInfo, _ := c.GetUserInfo(uid)
+			logger.WithFields(logrus.Fields{
+				"Uid":  uid,
+				"Name": userInfo.GetName(),
+			}).Warn("pixiv用户不存在，订阅将失效")
+			c.RemoveAllById(uid)
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load illusts failed %v", err)
+		}
+		var result []concern.Event
+		for _, illust := range illusts {
+			result = append(result, illust)
+		}
+		return result, nil
+	})
+}
+
+func NewConcern(notify chan<- concern.Notify) *Concern {
+	c := &Concern{
+		StateManager: NewStateManager(notify),
+	}
+	return c
+}
@@ -0,0 +1,137 @@
+package pixiv
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcern_ParseId(t *testing.T) {
+	c := NewConcern(nil)
+	for s, expected := range map[string]int64{
+		"11":                             11,
+		" 11 ":                           11,
+		"https://www.pixiv.net/users/11": 11,
+		"https://www.pixiv.net/en/users/11/artworks":    11,
+		"pixiv.net/member.php?id=11":                    11,
+		"https://www.pixiv.net/member_illust.php?id=11": 11,
+	} {
+		id, err := c.ParseId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, id, s)
+	}
+	for _, s := range []string{"", "abc", "-1", "0", "https://example.com/users/11"} {
+		_, err := c.ParseId(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestConcern_Fresh(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var workCount int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, Referer, r.Header.Get("Referer"))
+		switch r.URL.Path {
+		case "/ajax/user/11":
+			fmt.Fprintf(w, `{"error":false,"message":"","body":{"userId":"11","name":"%v","imageBig":"avatar"}}`, test.NAME1)
+		case "/ajax/user/11/profile/all":
+			if atomic.LoadInt32(&workCount) > 1 {
+				fmt.Fprint(w, `{"error":false,"message":"","body":{"illusts":{"100":null,"300":null},"manga":{"200":null}}}`)
+			} else {
+				fmt.Fprint(w, `{"error":false,"message":"","body":{"illusts":{"100":null},"manga":[]}}`)
+			}
+		case "/ajax/user/11/profile/illusts":
+			assert.EqualValues(t, []string{"300", "200"}, r.URL.Query()["ids[]"])
+			fmt.Fprint(w, `{"error":false,"message":"","body":{"works":{
+"200":{"id":"200","title":"manga","illustType":1,"xRestrict":0,"tags":["a"],"userName":"new name","pageCount":1,"createDate":"2006-01-02T15:04:05+09:00"},
+"300":{"id":"300","title":"illust","illustType":0,"xRestrict":1,"tags":["b"],"userName":"new name","pageCount":5,"createDate":"2006-01-02T15:04:05+09:00"}
+}}}`)
+		case "/ajax/illust/200/pages":
+			fmt.Fprint(w, `{"error":false,"message":"","body":[{"urls":{"regular":"img200"}}]}`)
+		case "/ajax/illust/300/pages":
+			fmt.Fprint(w, `{"error":false,"message":"","body":[
+{"urls":{"regular":"img1"}},{"urls":{"regular":"img2"}},{"urls":{"small":"img3"}},{"urls":{"regular":"img4"}}
+]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":true,"message":"not found","body":[]}`)
+		}
+	}))
+	defer ts.Close()
+	oldHost := BaseHost
+	BaseHost = ts.URL
+	defer func() { BaseHost = oldHost }()
+
+	c := NewConcern(nil)
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1))
+
+	identity, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), int64(11), News)
+	assert.Nil(t, err)
+	assert.Equal(t, test.NAME1, identity.GetName())
+	_, err = c.Add(nil, mmsg.NewGroupTargetId(test.G1), int64(12), News)
+	assert.NotNil(t, err)
+
+	// 第一次刷新不推送
+	illusts, err := c.freshIllusts(11)
+	assert.Nil(t, err)
+	assert.Empty(t, illusts)
+
+	atomic.StoreInt32(&workCount, 2)
+	illusts, err = c.freshIllusts(11)
+	assert.Nil(t, err)
+	if assert.Len(t, illusts, 2) {
+		assert.Equal(t, "200", illusts[0].IllustId)
+		assert.Equal(t, IllustTypeManga, illusts[0].IllustType)
+		assert.Equal(t, "new name", illusts[0].Name)
+		assert.EqualValues(t, 11, illusts[0].Uid)
+		assert.Equal(t, []string{"img200"}, illusts[0].Images)
+		assert.NotZero(t, illusts[0].CreateDate)
+		assert.Equal(t, "300", illusts[1].IllustId)
+		assert.Equal(t, R18, illusts[1].Restrict())
+		assert.Equal(t, []string{"img1", "img2", "img3"}, illusts[1].Images)
+	}
+	illusts, err = c.freshIllusts(11)
+	assert.Nil(t, err)
+	assert.Empty(t, illusts)
+
+	lastId, err := c.GetLastIllustId(11)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 300, lastId)
+
+	_, err = c.freshIllusts(12)
+	assert.Equal(t, ErrNotExist, err)
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), int64(11), News)
+	assert.Nil(t, err)
+	_, err = c.GetUserInfo(11)
+	assert.NotNil(t, err)
+}
+
+func TestIllustInfo_DownloadImages(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if r.Header.Get("Referer") != Referer {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, "image")
+	}))
+	defer ts.Close()
+
+	illust := &IllustInfo{
+		IllustId: "100",
+		Images:   []string{ts.URL + "/1.jpg", ts.URL + "/2.jpg"},
+	}
+	images := illust.downloadImages()
+	assert.Len(t, images, 2)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&count))
+	assert.NotNil(t, illust.GetMSG())
+}
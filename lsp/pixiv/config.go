@@ -0,0 +1,146 @@
+package pixiv
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"strings"
+)
+
+type GroupConcernConfig struct {
+	concern.IConfig
+}
+
+func (g *GroupConcernConfig) Validate() error {
+	if !g.GetGroupConcernFilter().Empty() {
+		switch g.GetGroupConcernFilter().Type {
+		case concern.FilterTypeNotType, concern.FilterTypeType:
+			filterByType, err := g.GetGroupConcernFilter().GetFilterByType()
+			if err != nil {
+				return err
+			}
+			var invalid = CheckTypeDefine(filterByType.Type)
+			if len(invalid) != 0 {
+				return fmt.Errorf("未定义的类型：\n%v", strings.Join(invalid, " "))
+			}
+			return nil
+		}
+	}
+	return g.IConfig.Validate()
+}
+
+// allowRestrict 只有在 type 过滤中明确配置了R-18或者R-18G时，才会推送对应分级的作品
+func (g *GroupConcernConfig) allowRestrict(restrict string) bool {
+	if restrict == AllAges {
+		return true
+	}
+	if g.GetGroupConcernFilter().Type != concern.FilterTypeType {
+		return false
+	}
+	typeFilter, err := g.GetGroupConcernFilter().GetFilterByType()
+	if err != nil {
+		return false
+	}
+	for _, tp := range typeFilter.Type {
+		if tp == restrict {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterHook 默认不推送R-18和R-18G作品，在默认的text过滤之外，支持按作品类型和分级过滤
+func (g *GroupConcernConfig) FilterHook(notify concern.Notify) (hook *concern.HookResult) {
+	hook = new(concern.HookResult)
+	switch n := notify.(type) {
+	case *ConcernIllustNotify:
+		logger := notify.Logger().WithField("FilterType", g.GetGroupConcernFilter().Type)
+		if !g.allowRestrict(n.Restrict()) {
+			logger.WithField("Restrict", n.Restrict()).Debug("illust notify FilterHook filtered")
+			hook.Reason = "filtered by restrict"
+			return
+		}
+		switch g.GetGroupConcernFilter().Type {
+		case concern.FilterTypeType, concern.FilterTypeNotType:
+			typeFilter, err := g.GetGroupConcernFilter().GetFilterByType()
+			if err != nil {
+				logger.WithField("GroupConcernFilterConfig", g.GetGroupConcernFilter().Config).
+					Errorf("get type filter error %v", err)
+				hook.Pass = true
+				return
+			}
+			var match bool
+			for _, tp := range typeFilter.Type {
+				if f := PredefinedType[tp]; f != nil && f(n.IllustInfo) {
+					match = true
+					break
+				}
+			}
+			var ok = match
+			if g.GetGroupConcernFilter().Type == concern.FilterTypeNotType {
+				ok = !match
+			}
+			if ok {
+				logger.Debugf("illust notify FilterHook pass")
+				hook.Pass = true
+			} else {
+				logger.WithField("TypeFilter", typeFilter.Type).
+					Debug("illust notify FilterHook filtered")
+				hook.Reason = "filtered by TypeFilter"
+			}
+		default:
+			hook = g.IConfig.FilterHook(notify)
+		}
+		return
+	default:
+		hook.Reason = "unknown notify type"
+		return
+	}
+}
+
+func NewGroupConcernConfig(g concern.IConfig) *GroupConcernConfig {
+	return &GroupConcernConfig{g}
+}
+
+const (
+	Chahua  = "插画"
+	Manhua  = "漫画"
+	Dongtu  = "动图"
+	AI      = "AI"
+	AllAges = "全年龄"
+	R18     = "R-18"
+	R18G    = "R-18G"
+)
+
+// PredefinedType 作品类型过滤时可以使用的类型
+var PredefinedType = map[string]func(illust *IllustInfo) bool{
+	Chahua: func(illust *IllustInfo) bool {
+		return illust.IllustType == IllustTypeIllust
+	},
+	Manhua: func(illust *IllustInfo) bool {
+		return illust.IllustType == IllustTypeManga
+	},
+	Dongtu: func(illust *IllustInfo) bool {
+		return illust.IllustType == IllustTypeUgoira
+	},
+	AI: func(illust *IllustInfo) bool {
+		return illust.AiType == AiTypeAi
+	},
+	AllAges: func(illust *IllustInfo) bool {
+		return illust.Restrict() == AllAges
+	},
+	R18: func(illust *IllustInfo) bool {
+		return illust.Restrict() == R18
+	},
+	R18G: func(illust *IllustInfo) bool {
+		return illust.Restrict() == R18G
+	},
+}
+
+func CheckTypeDefine(types []string) (invalid []string) {
+	for _, t := range types {
+		if PredefinedType[t] == nil {
+			invalid = append(invalid, t)
+		}
+	}
+	return
+}
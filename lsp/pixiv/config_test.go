@@ -0,0 +1,66 @@
+package pixiv
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newIllustNotify(illustType int, xRestrict int) *ConcernIllustNotify {
+	return NewConcernIllustNotify(mmsg.NewGroupTargetId(test.G1), &IllustInfo{
+		UserInfo:   UserInfo{Uid: test.UID1, Name: test.NAME1},
+		IllustId:   "100",
+		IllustType: illustType,
+		XRestrict:  xRestrict,
+	})
+}
+
+func TestGroupConcernConfig_Validate(t *testing.T) {
+	g := NewGroupConcernConfig(new(concern.GroupConcernConfig))
+	assert.Nil(t, g.Validate())
+
+	g.GetGroupConcernFilter().Type = concern.FilterTypeType
+	g.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: []string{R18, AllAges}}).ToString()
+	assert.Nil(t, g.Validate())
+
+	g.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: []string{R18, "小说"}}).ToString()
+	assert.NotNil(t, g.Validate())
+
+	assert.EqualValues(t, []string{"小说"}, CheckTypeDefine([]string{Chahua, Manhua, Dongtu, AI, R18, R18G, "小说"}))
+}
+
+func TestGroupConcernConfig_FilterHook(t *testing.T) {
+	var notifies = []*ConcernIllustNotify{
+		newIllustNotify(IllustTypeIllust, XRestrictAll),
+		newIllustNotify(IllustTypeManga, XRestrictAll),
+		newIllustNotify(IllustTypeIllust, XRestrictR18),
+		newIllustNotify(IllustTypeIllust, XRestrictR18G),
+	}
+	g := NewGroupConcernConfig(new(concern.GroupConcernConfig))
+	// 默认不推送R-18和R-18G
+	for idx, expected := range []bool{true, true, false, false} {
+		assert.Equal(t, expected, g.FilterHook(notifies[idx]).Pass, idx)
+	}
+
+	var testCase = []struct {
+		filterType string
+		types      []string
+		expected   []bool
+	}{
+		{concern.FilterTypeType, []string{Manhua}, []bool{false, true, false, false}},
+		{concern.FilterTypeType, []string{R18}, []bool{false, false, true, false}},
+		{concern.FilterTypeType, []string{AllAges, R18, R18G}, []bool{true, true, true, true}},
+		{concern.FilterTypeType, []string{Chahua, R18}, []bool{true, false, true, false}},
+		{concern.FilterTypeNotType, []string{Manhua}, []bool{true, false, false, false}},
+		{concern.FilterTypeNotType, []string{R18}, []bool{true, true, false, false}},
+	}
+	for _, tc := range testCase {
+		g.GetGroupConcernFilter().Type = tc.filterType
+		g.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: tc.types}).ToString()
+		for idx, notify := range notifies {
+			assert.Equal(t, tc.expected[idx], g.FilterHook(notify).Pass, "%v %v %v", tc.filterType, tc.types, idx)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package pixiv
+
+import "errors"
+
+var (
+	ErrNotExist = errors.New("pixiv用户不存在")
+)
@@ -0,0 +1,12 @@
+package pixiv
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+)
+
+func init() {
+	concern.RegisterConcern(NewConcern(concern.GetNotifyChan()))
+	localdb.RegisterJsonType(localdb.PixivUserInfoKey, func() interface{} { return new(UserInfo) })
+	localdb.RegisterJsonType(localdb.PixivCookieInfoKey, func() interface{} { return new(CookieInfo) })
+}
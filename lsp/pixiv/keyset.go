@@ -0,0 +1,48 @@
+package pixiv
+
+import (
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
+
+type keySet struct {
+}
+
+func (l *keySet) GroupAtAllMarkKey(keys ...interface{}) string {
+	return buntdb.PixivGroupAtAllMarkKey(keys...)
+}
+
+func (l *keySet) GroupConcernConfigKey(keys ...interface{}) string {
+	return buntdb.PixivGroupConcernConfigKey(keys...)
+}
+
+func (l *keySet) GroupConcernStateKey(keys ...interface{}) string {
+	return buntdb.PixivGroupConcernStateKey(keys...)
+}
+
+func (l *keySet) FreshKey(keys ...interface{}) string {
+	return buntdb.PixivFreshKey(keys...)
+}
+
+func (l *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	return concern.ParseConcernStateKeyWithInt64(key)
+}
+
+type extraKey struct{}
+
+func (k extraKey) UserInfoKey(keys ...interface{}) string {
+	return buntdb.PixivUserInfoKey(keys...)
+}
+
+func (k extraKey) LastIllustIdKey(keys ...interface{}) string {
+	return buntdb.PixivLastIllustIdKey(keys...)
+}
+
+func NewExtraKey() *extraKey {
+	return &extraKey{}
+}
+
+func NewKeySet() *keySet {
+	return &keySet{}
+}
@@ -0,0 +1,190 @@
+package pixiv
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/image_cache"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	"github.com/Sora233/DDBOT/requests"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	IllustTypeIllust = 0
+	IllustTypeManga  = 1
+	IllustTypeUgoira = 2
+
+	XRestrictAll  = 0
+	XRestrictR18  = 1
+	XRestrictR18G = 2
+
+	// AiTypeAi 作者标记为AI生成的作品
+	AiTypeAi = 2
+)
+
+var (
+	brRegexp  = regexp.MustCompile(`(?i)<br\s*/?>`)
+	tagRegexp = regexp.MustCompile(`<[^>]*>`)
+)
+
+// UserInfo pixiv画师的信息
+type UserInfo struct {
+	Uid    int64  `json:"uid"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+func (u *UserInfo) GetUid() interface{} {
+	return u.Uid
+}
+
+func (u *UserInfo) GetName() string {
+	if u == nil {
+		return ""
+	}
+	return u.Name
+}
+
+// IllustInfo 画师发布的一个作品
+type IllustInfo struct {
+	UserInfo
+	IllustId string `json:"illust_id"`
+	Title    string `json:"title"`
+	// Description 作品简介，是html格式
+	Description string   `json:"description"`
+	IllustType  int      `json:"illust_type"`
+	XRestrict   int      `json:"x_restrict"`
+	AiType      int      `json:"ai_type"`
+	Tags        []string `json:"tags"`
+	PageCount   int      `json:"page_count"`
+	CreateDate  int64    `json:"create_date"`
+	// Images 作品的前几页图片，最多 maxImages 张
+	Images []string `json:"images"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (i *IllustInfo) Type() concern_type.Type {
+	return News
+}
+
+func (i *IllustInfo) Site() string {
+	return Site
+}
+
+func (i *IllustInfo) Url() string {
+	return IllustUrl(i.IllustId)
+}
+
+// Restrict 返回作品的分级：全年龄、R-18或者R-18G
+func (i *IllustInfo) Restrict() string {
+	switch i.XRestrict {
+	case XRestrictR18:
+		return R18
+	case XRestrictR18G:
+		return R18G
+	default:
+		return AllAges
+	}
+}
+
+// Desc 返回去掉html标签的作品简介
+func (i *IllustInfo) Desc() string {
+	desc := brRegexp.ReplaceAllString(i.Description, "\n")
+	desc = tagRegexp.ReplaceAllString(desc, "")
+	return strings.TrimSpace(html.UnescapeString(desc))
+}
+
+// TranslateText 实现 concern.NotifyTranslateExt
+func (i *IllustInfo) TranslateText() string {
+	return i.Title
+}
+
+func (i *IllustInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":     Site,
+		"Uid":      i.Uid,
+		"Name":     i.Name,
+		"Type":     i.Type().String(),
+		"IllustId": i.IllustId,
+	})
+}
+
+// downloadImages 通过图片缓存下载作品的图片，并压缩为QQ可以接受的大小，下载失败的图片会被跳过
+func (i *IllustInfo) downloadImages() [][]byte {
+	var result [][]byte
+	for _, url := range i.Images {
+		b, err := image_cache.Get(url, requests.HeaderOption("Referer", Referer))
+		if err != nil {
+			i.Logger().WithField("url", url).Errorf("download image error %v", err)
+			continue
+		}
+		result = append(result, image_cache.Normalize(b))
+	}
+	return result
+}
+
+// TemplateData 返回作品推送模板使用的数据，images为已经下载的图片
+func (i *IllustInfo) TemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     i.Name,
+		"uid":      i.Uid,
+		"title":    i.Title,
+		"desc":     i.Desc(),
+		"tags":     i.Tags,
+		"restrict": i.Restrict(),
+		"ai":       i.AiType == AiTypeAi,
+		"pages":    i.PageCount,
+		"url":      i.Url(),
+		"time":     localutils.TimestampFormat(i.CreateDate),
+		"images":   i.downloadImages(),
+	}
+}
+
+func (i *IllustInfo) GetMSG() *mmsg.MSG {
+	i.once.Do(func() {
+		var err error
+		i.msgCache, err = template.LoadAndExec("notify.group.pixiv.news.tmpl", i.TemplateData())
+		if err != nil {
+			logger.Errorf("pixiv: IllustInfo LoadAndExec error %v", err)
+		}
+		return
+	})
+	return i.msgCache
+}
+
+type ConcernIllustNotify struct {
+	*IllustInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernIllustNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernIllustNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.IllustInfo.GetMSG()
+}
+
+func (notify *ConcernIllustNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.IllustInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernIllustNotify(target mmsg.TargetId, i *IllustInfo) *ConcernIllustNotify {
+	if i == nil {
+		return nil
+	}
+	return &ConcernIllustNotify{
+		i,
+		target,
+	}
+}
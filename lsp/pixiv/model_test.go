@@ -0,0 +1,40 @@
+package pixiv
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIllustInfo(t *testing.T) {
+	illust := &IllustInfo{
+		UserInfo: UserInfo{
+			Uid:  test.UID1,
+			Name: test.NAME1,
+		},
+		IllustId:    "100",
+		Title:       "title",
+		Description: "a<br />b &amp; <a href=\"x\">c</a>",
+		Tags:        []string{"tag1", "tag2"},
+		XRestrict:   XRestrictR18,
+		CreateDate:  1600000000,
+	}
+	assert.Equal(t, Site, illust.Site())
+	assert.Equal(t, test.UID1, illust.GetUid())
+	assert.Equal(t, test.NAME1, illust.GetName())
+	assert.Equal(t, News, illust.Type())
+	assert.Equal(t, IllustUrl("100"), illust.Url())
+	assert.Equal(t, R18, illust.Restrict())
+	assert.Equal(t, "a\nb & c", illust.Desc())
+	assert.Equal(t, "title", illust.TranslateText())
+
+	notify := NewConcernIllustNotify(mmsg.NewGroupTargetId(test.G1), illust)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, News, notify.Type())
+	assert.NotNil(t, notify.ToMessage())
+
+	assert.Nil(t, NewConcernIllustNotify(mmsg.NewGroupTargetId(test.G1), nil))
+}
@@ -0,0 +1,97 @@
+package pixiv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"net/http"
+	"time"
+)
+
+const (
+	Site = "pixiv"
+
+	// Referer 下载pixiv的图片时必须带上这个Referer，否则会返回403
+	Referer = "https://www.pixiv.net/"
+)
+
+// BaseHost pixiv网页接口的地址，测试时可以替换
+var BaseHost = "https://www.pixiv.net"
+
+const (
+	PathUserInfo     = "/ajax/user/%v"
+	PathUserWorks    = "/ajax/user/%v/profile/all"
+	PathUserIllusts  = "/ajax/user/%v/profile/illusts"
+	PathIllustPages  = "/ajax/illust/%v/pages"
+	PathUserLoggedIn = "/ajax/user/extra"
+)
+
+const (
+	// maxFreshWorks 每次刷新最多检查的最新作品数量
+	maxFreshWorks = 10
+	// maxImages 每个作品最多推送的图片数量
+	maxImages = 3
+)
+
+func BasePath(path string) string {
+	return BaseHost + path
+}
+
+func UserUrl(uid int64) string {
+	return fmt.Sprintf("https://www.pixiv.net/users/%v", uid)
+}
+
+func IllustUrl(illustId string) string {
+	return fmt.Sprintf("https://www.pixiv.net/artworks/%v", illustId)
+}
+
+// Response pixiv网页接口的通用返回格式，出错时body为空数组
+type Response struct {
+	Error   bool            `json:"error"`
+	Message string          `json:"message"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// pixivGet 请求pixiv的网页接口，并把body解析到out，设置了cookie时会带上cookie
+func pixivGet(url string, params interface{}, out interface{}) error {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var code int
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferOversea),
+		requests.SiteOption(Site),
+		requests.AddUAOption(),
+		requests.HeaderOption("Referer", Referer),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+		requests.HttpCodeOption(&code),
+	}
+	if cookie := getCookie(); len(cookie) > 0 {
+		opts = append(opts, requests.CookieOption("PHPSESSID", cookie))
+	}
+	var resp = new(Response)
+	err := requests.Get(url, params, resp, opts...)
+	if code == http.StatusNotFound {
+		return ErrNotExist
+	}
+	// 出错时http code通常也不是200，优先使用pixiv返回的错误信息
+	if resp.Error {
+		if len(resp.Message) == 0 {
+			return errors.New("pixiv返回了未知错误")
+		}
+		return errors.New(resp.Message)
+	}
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Body, out)
+}
@@ -0,0 +1,90 @@
+package pixiv
+
+import (
+	"errors"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+)
+
+type StateManager struct {
+	*concern.StateManager
+	*extraKey
+}
+
+func (c *StateManager) AddUserInfo(userInfo *UserInfo) error {
+	if userInfo == nil {
+		return errors.New("nil UserInfo")
+	}
+	return c.SetJson(c.UserInfoKey(userInfo.Uid), userInfo)
+}
+
+func (c *StateManager) GetUserInfo(uid int64) (*UserInfo, error) {
+	var userInfo = &UserInfo{}
+	err := c.GetJson(c.UserInfoKey(uid), userInfo, localdb.GetCacheOpt())
+	if err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
+
+// DeleteUserInfo 删除画师的信息以及推送进度
+func (c *StateManager) DeleteUserInfo(uid int64) error {
+	return c.RWCover(func() error {
+		var err error
+		for _, key := range []string{
+			c.UserInfoKey(uid),
+			c.LastIllustIdKey(uid),
+		} {
+			_, err = c.Delete(key, localdb.IgnoreNotFoundOpt())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *StateManager) SetLastIllustId(uid int64, illustId int64) error {
+	return c.SetInt64(c.LastIllustIdKey(uid), illustId)
+}
+
+func (c *StateManager) GetLastIllustId(uid int64) (int64, error) {
+	return c.GetInt64(c.LastIllustIdKey(uid))
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
+}
+
+// CookieInfo 配置的pixiv cookie以及对应的帐号
+type CookieInfo struct {
+	PHPSESSID string `json:"phpsessid"`
+	Uid       int64  `json:"uid"`
+	// Login 保存时cookie是否已经登陆
+	Login bool `json:"login"`
+}
+
+func SetCookieInfo(cookieInfo *CookieInfo) error {
+	if cookieInfo == nil {
+		return errors.New("<nil> cookieInfo")
+	}
+	return localdb.SetJson(localdb.PixivCookieInfoKey(), cookieInfo)
+}
+
+func GetCookieInfo() (cookieInfo *CookieInfo, err error) {
+	err = localdb.GetJson(localdb.PixivCookieInfoKey(), &cookieInfo)
+	return
+}
+
+func ClearCookieInfo() error {
+	_, err := localdb.Delete(localdb.PixivCookieInfoKey(), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+func NewStateManager(notify chan<- concern.Notify) *StateManager {
+	sm := &StateManager{}
+	sm.extraKey = NewExtraKey()
+	sm.StateManager = concern.NewStateManagerWithCustomKey(Site, NewKeySet(), notify)
+	return sm
+}
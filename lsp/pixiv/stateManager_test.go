@@ -0,0 +1,60 @@
+package pixiv
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initStateManager(t *testing.T) *StateManager {
+	sm := NewStateManager(nil)
+	assert.NotNil(t, sm)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	return sm
+}
+
+func TestStateManager_UserInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.UID1))
+
+	_, err := sm.GetUserInfo(test.UID1)
+	assert.NotNil(t, err)
+	assert.NotNil(t, sm.AddUserInfo(nil))
+
+	expected := &UserInfo{
+		Uid:  test.UID1,
+		Name: test.NAME1,
+	}
+	assert.Nil(t, sm.AddUserInfo(expected))
+	actual, err := sm.GetUserInfo(test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+
+	assert.Nil(t, sm.SetLastIllustId(test.UID1, 100))
+	assert.Nil(t, sm.DeleteUserInfo(test.UID1))
+	_, err = sm.GetUserInfo(test.UID1)
+	assert.NotNil(t, err)
+	_, err = sm.GetLastIllustId(test.UID1)
+	assert.NotNil(t, err)
+}
+
+func TestCookieInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	assert.Empty(t, getCookie())
+	assert.NotNil(t, SetCookieInfo(nil))
+	assert.Nil(t, SetCookieInfo(&CookieInfo{PHPSESSID: "123_abc", Uid: parseCookieUid("123_abc")}))
+	assert.Equal(t, "123_abc", getCookie())
+	cookieInfo, err := GetCookieInfo()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 123, cookieInfo.Uid)
+	assert.Nil(t, ClearCookieInfo())
+	assert.Empty(t, getCookie())
+	assert.Zero(t, parseCookieUid("abc"))
+}
@@ -3,26 +3,37 @@ package lsp
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Mrs4s/MiraiGo/message"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/i18n"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/recorder"
 	"github.com/Sora233/DDBOT/lsp/template"
+	"github.com/Sora233/DDBOT/requests"
 	localutils "github.com/Sora233/DDBOT/utils"
-	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/Sora233/DDBOT/utils/qrcode"
 	"github.com/Sora233/sliceutil"
 	"github.com/alecthomas/kong"
 	"github.com/sirupsen/logrus"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// qrcodeLoginTimeout 扫码登陆的等待时间，b站的二维码有效期为3分钟
+const qrcodeLoginTimeout = time.Minute * 3
+
 type LspPrivateCommand struct {
 	msg *message.PrivateMessage
 
@@ -63,6 +74,15 @@ func (c *LspPrivateCommand) Execute() {
 		return
 	}
 
+	if !c.requireCooldown(c.CommandName()) {
+		return
+	}
+
+	if m := c.l.modules.GetByCommand(c.CommandName()); m != nil && !c.l.ModuleEnabled(0, m.Name) {
+		c.textReplyF("失败 - %v模块已在配置中禁用", m.Name)
+		return
+	}
+
 	log.Debug("execute command")
 
 	// all permission will be checked later
@@ -87,6 +107,14 @@ func (c *LspPrivateCommand) Execute() {
 		c.LogCommand()
 	case ListCommand:
 		c.ListCommand()
+	case RecentCommand:
+		c.RecentCommand()
+	case StatsCommand:
+		c.StatsCommand()
+	case TagCommand:
+		c.TagCommand()
+	case UnwatchTagCommand:
+		c.UnwatchTagCommand()
 	case SysinfoCommand:
 		c.SysinfoCommand()
 	case ConfigCommand:
@@ -105,12 +133,54 @@ func (c *LspPrivateCommand) Execute() {
 		c.AdminCommand()
 	case SilenceCommand:
 		c.SilenceCommand()
+	case QuietCommand:
+		c.QuietCommand()
+	case DigestCommand:
+		c.DigestCommand()
+	case ReminderCommand:
+		c.ReminderCommand()
+	case LocaleCommand:
+		c.LocaleCommand()
+	case FindCommand:
+		c.FindCommand()
 	case NoUpdateCommand:
 		c.NoUpdateCommand()
 	case AbnormalConcernCheck:
 		c.AbnormalConcernCheckCommand()
 	case CleanConcern:
 		c.CleanConcernCommand()
+	case PurgeGroupCommand:
+		c.PurgeGroupCommand()
+	case MigrateCommand:
+		c.MigrateCommand()
+	case FailedCommand:
+		c.FailedCommand()
+	case ResendCommand:
+		c.ResendCommand()
+	case LoginCommand:
+		c.LoginCommand()
+	case BackupCommand:
+		c.BackupCommand()
+	case RecordCommand:
+		c.RecordCommand()
+	case ExportCommand:
+		c.ExportCommand()
+	case ImportCommand:
+		c.ImportCommand()
+	case WebhookCommand:
+		c.WebhookCommand()
+	case ShutdownCommand:
+		c.ShutdownCommand()
+	case AuditLogCommand:
+		c.AuditLogCommand()
+	case HttpCommand:
+		c.HttpCommand()
+	case StatusCommand:
+		c.StatusCommand()
+	case ReloadCommand:
+		c.ReloadCommand()
+	case BlocklistCommand:
+		c.BlocklistCommand()
 	default:
 		if CheckCustomPrivateCommand(c.CommandName()) {
 			func() {
@@ -264,8 +334,9 @@ func (c *LspPrivateCommand) ListCommand() {
 	defer func() { log.Infof("%v command end", c.CommandName()) }()
 
 	var listCmd struct {
-		Group int64  `optional:"" short:"g" help:"要操作的QQ群号码"`
-		Site  string `optional:"" short:"s" help:"网站参数"`
+		Group    int64  `optional:"" short:"g" help:"要操作的QQ群号码，不指定时查看自己的私聊订阅"`
+		Telegram int64  `optional:"" name:"tg" help:"要操作的Telegram chat id，仅bot管理员可用"`
+		Site     string `optional:"" short:"s" help:"网站参数"`
 	}
 	_, output := c.parseCommandSyntax(&listCmd, c.CommandName())
 	if output != "" {
@@ -275,13 +346,164 @@ func (c *LspPrivateCommand) ListCommand() {
 		return
 	}
 
-	groupCode := listCmd.Group
-	if err := c.checkGroupCode(groupCode); err != nil {
+	target, err := c.checkConcernTarget(listCmd.Group, listCmd.Telegram)
+	if err != nil {
+		c.textReply(err.Error())
+		return
+	}
+	log = log.WithFields(mmsg.TargetLogFields(target))
+	IList(c.NewMessageContext(log), target, listCmd.Site)
+}
+
+func (c *LspPrivateCommand) RecentCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var recentCmd struct {
+		Group    int64  `optional:"" short:"g" help:"要操作的QQ群号码，不指定时查看自己的私聊订阅"`
+		Telegram int64  `optional:"" name:"tg" help:"要操作的Telegram chat id，仅bot管理员可用"`
+		Site     string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Count    int    `optional:"" short:"n" default:"3" help:"查看的动态数量"`
+		Id       string `arg:"" help:"已订阅的id"`
+	}
+	_, output := c.parseCommandSyntax(&recentCmd, c.CommandName())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if recentCmd.Count <= 0 || recentCmd.Count > 10 {
+		c.textReply("失败 - 动态数量需要在1到10之间")
+		return
+	}
+
+	target, err := c.checkConcernTarget(recentCmd.Group, recentCmd.Telegram)
+	if err != nil {
+		c.textReply(err.Error())
+		return
+	}
+	log = log.WithFields(mmsg.TargetLogFields(target)).
+		WithField("site", recentCmd.Site).WithField("id", recentCmd.Id)
+	IRecent(c.NewMessageContext(log), target, recentCmd.Id, recentCmd.Site, recentCmd.Count)
+}
+
+func (c *LspPrivateCommand) StatsCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var statsCmd struct {
+		Group    int64  `optional:"" short:"g" help:"要操作的QQ群号码，不指定时查看自己的私聊订阅"`
+		Telegram int64  `optional:"" name:"tg" help:"要操作的Telegram chat id，仅bot管理员可用"`
+		Site     string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Days     int    `optional:"" short:"d" default:"7" help:"查看最近几天的统计"`
+		Id       string `arg:"" help:"已订阅的id"`
+	}
+	_, output := c.parseCommandSyntax(&statsCmd, c.CommandName(),
+		kong.Description("查看已订阅的账号最近的粉丝数、直播人气和直播时长"),
+	)
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if statsCmd.Days <= 0 || statsCmd.Days > maxStatsDays {
+		c.textReplyF("失败 - 天数需要在1到%v之间", maxStatsDays)
+		return
+	}
+
+	target, err := c.checkConcernTarget(statsCmd.Group, statsCmd.Telegram)
+	if err != nil {
+		c.textReply(err.Error())
+		return
+	}
+	log = log.WithFields(mmsg.TargetLogFields(target)).
+		WithField("site", statsCmd.Site).WithField("id", statsCmd.Id)
+	IStats(c.NewMessageContext(log), target, statsCmd.Id, statsCmd.Site, statsCmd.Days)
+}
+
+func (c *LspPrivateCommand) TagCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var tagCmd struct {
+		Add struct {
+			Site string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id   string   `arg:"" help:"已订阅的id"`
+			Tags []string `arg:"" help:"标签，可以一次填多个"`
+		} `cmd:"" help:"给订阅添加标签" name:"add"`
+		Remove struct {
+			Site string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id   string   `arg:"" help:"已订阅的id"`
+			Tags []string `arg:"" help:"标签，可以一次填多个"`
+		} `cmd:"" help:"删除订阅的标签" name:"remove"`
+		List struct {
+			Tag string `arg:"" optional:"" help:"标签，不填时列出所有标签"`
+		} `cmd:"" help:"查看标签以及标签下的订阅" name:"list"`
+		Group int64 `optional:"" short:"g" help:"要操作的QQ群号码，不指定时操作自己的私聊订阅"`
+	}
+	kongCtx, output := c.parseCommandSyntax(&tagCmd, c.CommandName(),
+		kong.Description("管理订阅的标签，可以使用/unwatchtag取消标签下的所有订阅，或者在/config中使用#标签代替id批量配置"),
+	)
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit || len(kongCtx.Path) <= 1 {
+		return
+	}
+
+	target, err := c.checkConcernGroupCode(tagCmd.Group)
+	if err != nil {
+		c.textReply(err.Error())
+		return
+	}
+
+	cmd := strings.Split(kongCtx.Command(), " ")[0]
+	log = log.WithFields(mmsg.TargetLogFields(target)).WithField("sub_command", cmd)
+
+	switch cmd {
+	case "add":
+		log = log.WithField("site", tagCmd.Add.Site).WithField("id", tagCmd.Add.Id).WithField("tags", tagCmd.Add.Tags)
+		ITagAdd(c.NewMessageContext(log), target, tagCmd.Add.Id, tagCmd.Add.Site, tagCmd.Add.Tags)
+	case "remove":
+		log = log.WithField("site", tagCmd.Remove.Site).WithField("id", tagCmd.Remove.Id).WithField("tags", tagCmd.Remove.Tags)
+		ITagRemove(c.NewMessageContext(log), target, tagCmd.Remove.Id, tagCmd.Remove.Site, tagCmd.Remove.Tags)
+	case "list":
+		log = log.WithField("tag", tagCmd.List.Tag)
+		ITagList(c.NewMessageContext(log), target, tagCmd.List.Tag)
+	}
+}
+
+func (c *LspPrivateCommand) UnwatchTagCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var unwatchTagCmd struct {
+		Group int64  `optional:"" short:"g" help:"要操作的QQ群号码，不指定时操作自己的私聊订阅"`
+		Tag   string `arg:"" help:"标签"`
+	}
+	_, output := c.parseCommandSyntax(&unwatchTagCmd, c.CommandName())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	target, err := c.checkConcernGroupCode(unwatchTagCmd.Group)
+	if err != nil {
 		c.textReply(err.Error())
 		return
 	}
-	log = log.WithFields(localutils.GroupLogFields(groupCode))
-	IList(c.NewMessageContext(log), groupCode, listCmd.Site)
+	log = log.WithFields(mmsg.TargetLogFields(target)).WithField("tag", unwatchTagCmd.Tag)
+	IUnwatchTag(c.NewMessageContext(log), target, unwatchTagCmd.Tag)
 }
 
 func (c *LspPrivateCommand) ConfigCommand() {
@@ -301,16 +523,55 @@ func (c *LspPrivateCommand) ConfigCommand() {
 			Id     string `arg:"" help:"配置的主播id"`
 			Switch string `arg:"" default:"on" enum:"on,off" help:"on / off"`
 		} `cmd:"" help:"配置推送时@全体成员，默认关闭，需要管理员权限" name:"at_all"`
+		Mention struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Type   string `optional:"" short:"t" help:"推送类型，默认为网站的第一个类型，例如b站为live"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Action string `arg:"" enum:"none,member,all,show" help:"none（不@） / member（@配置的成员和/subme订阅的成员） / all（@全体成员） / show"`
+		} `cmd:"" help:"按推送类型配置推送时的@方式" name:"mention"`
 		TitleNotify struct {
 			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
 			Id     string `arg:"" help:"配置的主播id"`
 			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
 		} `cmd:"" help:"配置直播间标题发生变化时是否进行推送，默认不推送" name:"title_notify"`
+		LiveChangeNotify struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
+		} `cmd:"" help:"配置直播中更换封面或者分区时是否进行推送，默认不推送，目前支持b站和斗鱼" name:"live_change_notify"`
 		OfflineNotify struct {
 			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
 			Id     string `arg:"" help:"配置的主播id"`
 			Switch string `arg:"" default:"off" enum:"on,off," help:"on / off"`
 		} `cmd:"" help:"配置下播时是否进行推送，默认不推送" name:"offline_notify"`
+		Danmaku struct {
+			Site    string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id      string   `arg:"" help:"配置的主播id"`
+			Action  string   `arg:"" enum:"on,off,show" help:"on / off / show"`
+			Keyword []string `arg:"" optional:"" help:"需要转发的弹幕关键字，醒目留言及上舰消息总是转发"`
+		} `cmd:"" help:"配置直播期间转发弹幕到群内，默认关闭，目前仅支持b站" name:"danmaku"`
+		DanmakuAlert struct {
+			Site    string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			User    []string `optional:"" short:"u" help:"需要提醒的用户，可以是uid或者用户名"`
+			Id      string   `arg:"" help:"配置的主播id"`
+			Action  string   `arg:"" enum:"on,off,show" help:"on / off / show"`
+			Keyword []string `arg:"" optional:"" help:"需要提醒的弹幕关键字"`
+		} `cmd:"" help:"配置直播期间弹幕包含关键字或者指定用户发言时在群内提醒，默认关闭，目前仅支持b站" name:"danmaku_alert"`
+		Record struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
+		} `cmd:"" help:"配置开播时是否录制直播，默认关闭，目前支持b站和斗鱼" name:"record"`
+		ScheduleRemind struct {
+			Site    string `optional:"" short:"s" default:"huya" help:"网站参数"`
+			Id      string `arg:"" help:"配置的主播id"`
+			Minutes int    `arg:"" default:"0" help:"在预告的开播时间之前多少分钟提醒，0表示关闭"`
+		} `cmd:"" help:"配置开播预告的提醒，需要订阅schedule类型，默认关闭，目前仅支持虎牙" name:"schedule_remind"`
+		Translate struct {
+			Site   string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id     string `arg:"" help:"配置的主播id"`
+			Switch string `arg:"" default:"off" enum:"on,off" help:"on / off"`
+		} `cmd:"" help:"配置是否在动态推送的末尾附加外语正文的翻译，默认关闭，目前支持b站和微博" name:"translate"`
 		Filter struct {
 			Site string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
 			Type struct {
@@ -325,6 +586,18 @@ func (c *LspPrivateCommand) ConfigCommand() {
 				Id      string   `arg:"" help:"配置的主播id"`
 				Keyword []string `arg:"" optional:"" help:"指定的关键字"`
 			} `cmd:"" help:"当动态内容里出现关键字时进行推送" name:"text" group:"filter"`
+			NotText struct {
+				Id      string   `arg:"" help:"配置的主播id"`
+				Keyword []string `arg:"" optional:"" help:"指定的关键字"`
+			} `cmd:"" help:"当动态内容里出现关键字时不推送" name:"not_text" group:"filter"`
+			Regex struct {
+				Id    string   `arg:"" help:"配置的主播id"`
+				Regex []string `arg:"" optional:"" help:"指定的正则表达式"`
+			} `cmd:"" help:"当动态内容匹配正则表达式时进行推送" name:"regex" group:"filter"`
+			NotRegex struct {
+				Id    string   `arg:"" help:"配置的主播id"`
+				Regex []string `arg:"" optional:"" help:"指定的正则表达式"`
+			} `cmd:"" help:"当动态内容匹配正则表达式时不推送" name:"not_regex" group:"filter"`
 			Clear struct {
 				Id string `arg:"" help:"配置的主播id"`
 			} `cmd:"" help:"清除过滤器" name:"clear" group:"filter"`
@@ -332,11 +605,18 @@ func (c *LspPrivateCommand) ConfigCommand() {
 				Id string `arg:"" help:"配置的主播id"`
 			} `cmd:"" help:"查看当前过滤器" name:"show" group:"filter"`
 		} `cmd:"" help:"配置动态过滤器" name:"filter"`
-		Group int64 `optional:"" short:"g" help:"要操作的QQ群号码"`
+		Template struct {
+			Site     string   `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+			Id       string   `arg:"" help:"配置的主播id"`
+			Kind     string   `arg:"" enum:"live,title,news" help:"live（开播/下播） / title（改标题） / news（动态）"`
+			Action   string   `arg:"" enum:"set,clear,show" help:"set / clear / show"`
+			Template []string `arg:"" optional:"" passthrough:"" help:"模板内容，建议换行后输入"`
+		} `cmd:"" help:"配置自定义推送模板，默认使用内置的推送格式" name:"template"`
+		Group int64 `optional:"" short:"g" help:"要操作的QQ群号码，不指定时配置自己的私聊订阅"`
 	}
 
 	kongCtx, output := c.parseCommandSyntax(&configCmd, c.CommandName(),
-		kong.Description("管理BOT的配置，目前支持配置@成员、@全体成员、开启下播推送、开启标题推送、推送过滤"),
+		kong.Description("管理BOT的配置，目前支持配置@成员、@全体成员、@方式、开启下播推送、开启标题推送、弹幕转发、直播录制、开播预告提醒、推送过滤、推送模板"),
 	)
 	if output != "" {
 		c.textReply(output)
@@ -345,8 +625,8 @@ func (c *LspPrivateCommand) ConfigCommand() {
 		return
 	}
 
-	groupCode := configCmd.Group
-	if err := c.checkGroupCode(groupCode); err != nil {
+	target, err := c.checkConcernGroupCode(configCmd.Group)
+	if err != nil {
 		c.textReply(err.Error())
 		return
 	}
@@ -354,7 +634,7 @@ func (c *LspPrivateCommand) ConfigCommand() {
 	kongPath := strings.Split(kongCtx.Command(), " ")
 
 	cmd := kongPath[0]
-	log = log.WithFields(localutils.GroupLogFields(groupCode)).WithField("sub_command", cmd)
+	log = log.WithFields(mmsg.TargetLogFields(target)).WithField("sub_command", cmd)
 
 	switch cmd {
 	case "at":
@@ -365,7 +645,7 @@ func (c *LspPrivateCommand) ConfigCommand() {
 			return
 		}
 		log = log.WithField("site", site).WithField("id", configCmd.At.Id).WithField("action", configCmd.At.Action).WithField("QQ", configCmd.At.QQ)
-		IConfigAtCmd(c.NewMessageContext(log), groupCode, configCmd.At.Id, site, ctype, configCmd.At.Action, configCmd.At.QQ)
+		IConfigAtCmd(c.NewMessageContext(log), target, configCmd.At.Id, site, ctype, configCmd.At.Action, configCmd.At.QQ)
 	case "at_all":
 		site, ctype, err := c.ParseRawSiteAndType(configCmd.AtAll.Site, "live")
 		if err != nil {
@@ -375,7 +655,16 @@ func (c *LspPrivateCommand) ConfigCommand() {
 		}
 		var on = localutils.Switch2Bool(configCmd.AtAll.Switch)
 		log = log.WithField("site", site).WithField("id", configCmd.AtAll.Id).WithField("on", on)
-		IConfigAtAllCmd(c.NewMessageContext(log), groupCode, configCmd.AtAll.Id, site, ctype, on)
+		IConfigAtAllCmd(c.NewMessageContext(log), target, configCmd.AtAll.Id, site, ctype, on)
+	case "mention":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.Mention.Site, configCmd.Mention.Type)
+		if err != nil {
+			log.WithField("site", configCmd.Mention.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.Mention.Id).WithField("action", configCmd.Mention.Action)
+		IConfigMentionCmd(c.NewMessageContext(log), target, configCmd.Mention.Id, site, ctype, configCmd.Mention.Action)
 	case "title_notify":
 		site, ctype, err := c.ParseRawSiteAndType(configCmd.TitleNotify.Site, "live")
 		if err != nil {
@@ -385,7 +674,17 @@ func (c *LspPrivateCommand) ConfigCommand() {
 		}
 		var on = localutils.Switch2Bool(configCmd.TitleNotify.Switch)
 		log = log.WithField("site", site).WithField("id", configCmd.TitleNotify.Id).WithField("on", on)
-		IConfigTitleNotifyCmd(c.NewMessageContext(log), groupCode, configCmd.TitleNotify.Id, site, ctype, on)
+		IConfigTitleNotifyCmd(c.NewMessageContext(log), target, configCmd.TitleNotify.Id, site, ctype, on)
+	case "live_change_notify":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.LiveChangeNotify.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.LiveChangeNotify.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		var on = localutils.Switch2Bool(configCmd.LiveChangeNotify.Switch)
+		log = log.WithField("site", site).WithField("id", configCmd.LiveChangeNotify.Id).WithField("on", on)
+		IConfigLiveChangeNotifyCmd(c.NewMessageContext(log), target, configCmd.LiveChangeNotify.Id, site, ctype, on)
 	case "offline_notify":
 		site, ctype, err := c.ParseRawSiteAndType(configCmd.OfflineNotify.Site, "live")
 		if err != nil {
@@ -395,7 +694,59 @@ func (c *LspPrivateCommand) ConfigCommand() {
 		}
 		var on = localutils.Switch2Bool(configCmd.OfflineNotify.Switch)
 		log = log.WithField("site", site).WithField("id", configCmd.OfflineNotify.Id).WithField("on", on)
-		IConfigOfflineNotifyCmd(c.NewMessageContext(log), groupCode, configCmd.OfflineNotify.Id, site, ctype, on)
+		IConfigOfflineNotifyCmd(c.NewMessageContext(log), target, configCmd.OfflineNotify.Id, site, ctype, on)
+	case "danmaku":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.Danmaku.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.Danmaku.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.Danmaku.Id).WithField("action", configCmd.Danmaku.Action).WithField("keyword", configCmd.Danmaku.Keyword)
+		IConfigDanmakuRelayCmd(c.NewMessageContext(log), target, configCmd.Danmaku.Id, site, ctype, configCmd.Danmaku.Action, configCmd.Danmaku.Keyword)
+	case "danmaku_alert":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.DanmakuAlert.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.DanmakuAlert.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.DanmakuAlert.Id).WithField("action", configCmd.DanmakuAlert.Action).
+			WithField("keyword", configCmd.DanmakuAlert.Keyword).WithField("user", configCmd.DanmakuAlert.User)
+		IConfigDanmakuAlertCmd(c.NewMessageContext(log), target, configCmd.DanmakuAlert.Id, site, ctype, configCmd.DanmakuAlert.Action, configCmd.DanmakuAlert.Keyword, configCmd.DanmakuAlert.User)
+	case "record":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.Record.Site, "live")
+		if err != nil {
+			log.WithField("site", configCmd.Record.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		var on = localutils.Switch2Bool(configCmd.Record.Switch)
+		log = log.WithField("site", site).WithField("id", configCmd.Record.Id).WithField("on", on)
+		IConfigRecordCmd(c.NewMessageContext(log), target, configCmd.Record.Id, site, ctype, on)
+	case "translate":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.Translate.Site, "news")
+		if err != nil {
+			log.WithField("site", configCmd.Translate.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		var on = localutils.Switch2Bool(configCmd.Translate.Switch)
+		log = log.WithField("site", site).WithField("id", configCmd.Translate.Id).WithField("on", on)
+		IConfigTranslateCmd(c.NewMessageContext(log), target, configCmd.Translate.Id, site, ctype, on)
+	case "schedule_remind":
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.ScheduleRemind.Site, "schedule")
+		if err != nil {
+			log.WithField("site", configCmd.ScheduleRemind.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		if configCmd.ScheduleRemind.Minutes < 0 {
+			c.textSend("失败 - 提醒时间不能小于0")
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.ScheduleRemind.Id).WithField("minutes", configCmd.ScheduleRemind.Minutes)
+		IConfigScheduleRemindCmd(c.NewMessageContext(log), target, configCmd.ScheduleRemind.Id, site, ctype, configCmd.ScheduleRemind.Minutes)
 	case "filter":
 		filterCmd := kongPath[1]
 		site, ctype, err := c.ParseRawSiteAndType(configCmd.Filter.Site, "news")
@@ -406,19 +757,38 @@ func (c *LspPrivateCommand) ConfigCommand() {
 		}
 		switch filterCmd {
 		case "type":
-			IConfigFilterCmdType(c.NewMessageContext(log), groupCode, configCmd.Filter.Type.Id, site, ctype, configCmd.Filter.Type.Type)
+			IConfigFilterCmdType(c.NewMessageContext(log), target, configCmd.Filter.Type.Id, site, ctype, configCmd.Filter.Type.Type)
 		case "not_type":
-			IConfigFilterCmdNotType(c.NewMessageContext(log), groupCode, configCmd.Filter.NotType.Id, site, ctype, configCmd.Filter.NotType.Type)
+			IConfigFilterCmdNotType(c.NewMessageContext(log), target, configCmd.Filter.NotType.Id, site, ctype, configCmd.Filter.NotType.Type)
 		case "text":
-			IConfigFilterCmdText(c.NewMessageContext(log), groupCode, configCmd.Filter.Text.Id, site, ctype, configCmd.Filter.Text.Keyword)
+			IConfigFilterCmdText(c.NewMessageContext(log), target, configCmd.Filter.Text.Id, site, ctype, configCmd.Filter.Text.Keyword)
+		case "not_text":
+			IConfigFilterCmdNotText(c.NewMessageContext(log), target, configCmd.Filter.NotText.Id, site, ctype, configCmd.Filter.NotText.Keyword)
+		case "regex":
+			IConfigFilterCmdRegex(c.NewMessageContext(log), target, configCmd.Filter.Regex.Id, site, ctype, configCmd.Filter.Regex.Regex)
+		case "not_regex":
+			IConfigFilterCmdNotRegex(c.NewMessageContext(log), target, configCmd.Filter.NotRegex.Id, site, ctype, configCmd.Filter.NotRegex.Regex)
 		case "clear":
-			IConfigFilterCmdClear(c.NewMessageContext(log), groupCode, configCmd.Filter.Clear.Id, site, ctype)
+			IConfigFilterCmdClear(c.NewMessageContext(log), target, configCmd.Filter.Clear.Id, site, ctype)
 		case "show":
-			IConfigFilterCmdShow(c.NewMessageContext(log), groupCode, configCmd.Filter.Show.Id, site, ctype)
+			IConfigFilterCmdShow(c.NewMessageContext(log), target, configCmd.Filter.Show.Id, site, ctype)
 		default:
 			log.WithField("filter_cmd", filterCmd).Errorf("unknown filter command")
 			c.textSend("未知的filter子命令")
 		}
+	case "template":
+		var rawType = "live"
+		if configCmd.Template.Kind == concern.TemplateKindNews {
+			rawType = "news"
+		}
+		site, ctype, err := c.ParseRawSiteAndType(configCmd.Template.Site, rawType)
+		if err != nil {
+			log.WithField("site", configCmd.Template.Site).Errorf("ParseRawSiteAndType failed %v", err)
+			c.textSend(fmt.Sprintf("失败 - %v", err.Error()))
+			return
+		}
+		log = log.WithField("site", site).WithField("id", configCmd.Template.Id).WithField("kind", configCmd.Template.Kind).WithField("action", configCmd.Template.Action)
+		IConfigTemplateCmd(c.NewMessageContext(log), target, configCmd.Template.Id, site, ctype, configCmd.Template.Kind, configCmd.Template.Action, c.templateText(configCmd.Template.Template))
 	default:
 		c.textSend("暂未支持，你可以催作者GKD")
 	}
@@ -437,10 +807,11 @@ func (c *LspPrivateCommand) WatchCommand(remove bool) {
 	)
 
 	var watchCmd struct {
-		Site  string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
-		Type  string `optional:"" short:"t" default:"" help:"类型参数"`
-		Group int64  `optional:"" short:"g" help:"要操作的QQ群号码"`
-		Id    string `arg:""`
+		Site     string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+		Type     string `optional:"" short:"t" default:"" help:"类型参数"`
+		Group    int64  `optional:"" short:"g" help:"要操作的QQ群号码，不指定时操作自己的私聊订阅"`
+		Telegram int64  `optional:"" name:"tg" help:"要推送的Telegram chat id，仅bot管理员可用"`
+		Id       string `arg:""`
 	}
 
 	_, output := c.parseCommandSyntax(&watchCmd, c.CommandName())
@@ -461,16 +832,15 @@ func (c *LspPrivateCommand) WatchCommand(remove bool) {
 	log = log.WithField("site", site).WithField("type", watchType)
 
 	id := watchCmd.Id
-	groupCode := watchCmd.Group
-
-	if err := c.checkGroupCode(groupCode); err != nil {
+	target, err := c.checkConcernTarget(watchCmd.Group, watchCmd.Telegram)
+	if err != nil {
 		c.textReply(err.Error())
 		return
 	}
 
-	log = log.WithFields(localutils.GroupLogFields(groupCode))
+	log = log.WithFields(mmsg.TargetLogFields(target))
 
-	IWatch(c.NewMessageContext(log), groupCode, id, site, watchType, remove)
+	IWatch(c.NewMessageContext(log), target, id, site, watchType, remove)
 }
 
 func (c *LspPrivateCommand) EnableCommand(disable bool) {
@@ -482,6 +852,7 @@ func (c *LspPrivateCommand) EnableCommand(disable bool) {
 		Group   int64  `optional:"" short:"g" help:"要操作的QQ群号码"`
 		Command string `arg:"" optional:"" help:"命令名"`
 		Global  bool   `optional:"" help:"系统级操作，对所有群生效"`
+		Module  bool   `optional:"" short:"m" help:"操作模块，例如 -m bilibili"`
 	}
 
 	_, output := c.parseCommandSyntax(&enableCmd, c.CommandName())
@@ -492,6 +863,21 @@ func (c *LspPrivateCommand) EnableCommand(disable bool) {
 		return
 	}
 
+	if enableCmd.Module {
+		if enableCmd.Global {
+			c.textReply("失败 - 请在配置文件中通过 module.enable 与 module.disable 启用或禁用模块")
+			return
+		}
+		groupCode := enableCmd.Group
+		if err := c.checkGroupCode(groupCode); err != nil {
+			c.textReply(err.Error())
+			return
+		}
+		log = log.WithFields(localutils.GroupLogFields(groupCode)).WithField("targetModule", enableCmd.Command)
+		IEnableModule(c.NewMessageContext(log), groupCode, enableCmd.Command, disable)
+		return
+	}
+
 	if len(enableCmd.Command) == 0 {
 		c.textReply("失败 - 没有指定要操作的命令名")
 		log.Errorf("empty command")
@@ -558,7 +944,7 @@ func (c *LspPrivateCommand) GrantCommand() {
 	var grantCmd struct {
 		Group   int64  `optional:"" short:"g" help:"要操作的QQ群号码"`
 		Command string `required:"" short:"c" xor:"1" help:"命令名"`
-		Role    string `required:"" short:"r" xor:"1" enum:"Admin,GroupAdmin" help:"Admin / GroupAdmin"`
+		Role    string `required:"" short:"r" xor:"1" enum:"Admin,GroupAdmin,GroupOwner,GroupManager,GroupSubscriber" help:"Admin / GroupAdmin / GroupOwner / GroupManager / GroupSubscriber"`
 		Delete  bool   `short:"d" help:"删除模式，执行删除权限操作"`
 		Target  int64  `arg:"" help:"目标qq号"`
 	}
@@ -806,7 +1192,7 @@ func (c *LspPrivateCommand) QuitCommand() {
 	c.textSend(fmt.Sprintf("已清除群【%v】的数据", displayName))
 }
 
-func (c *LspPrivateCommand) ModeCommand() {
+func (c *LspPrivateCommand) PurgeGroupCommand() {
 	log := c.DefaultLoggerWithCommand(c.CommandName())
 	log.Infof("run %v command", c.CommandName())
 	defer func() { log.Infof("%v command end", c.CommandName()) }()
@@ -818,58 +1204,111 @@ func (c *LspPrivateCommand) ModeCommand() {
 		return
 	}
 
-	var modeCmd struct {
-		Mode string `arg:"" optional:"" help:"指定切换模式，支持<公开> <私人> <审核>，默认为公开"`
+	var purgeCmd struct {
+		GroupCode int64 `arg:"" help:"要清除数据的群号"`
+		DryRun    bool  `optional:"" short:"n" help:"只显示将会被清除的数据，不执行清除"`
 	}
 
-	_, output := c.parseCommandSyntax(&modeCmd, c.CommandName(), kong.Description("切换BOT模式"), kong.UsageOnError())
+	_, output := c.parseCommandSyntax(&purgeCmd, c.CommandName())
 	if output != "" {
-		c.textReply(output)
+		c.textSend(output)
 	}
 	if c.exit {
 		return
 	}
 
-	if modeCmd.Mode == "" {
-		mode := c.l.LspStateManager.GetCurrentMode()
-		log.Infof("当前Mode为%v", mode)
-		switch c.l.LspStateManager.GetCurrentMode() {
-		case PublicMode:
-			c.textReply("当前模式为公开")
-		case PrivateMode:
-			c.textReply("当前模式为私人")
-		case ProtectMode:
-			c.textReply("当前模式为审核")
+	log = log.WithField("TargetGroupCode", purgeCmd.GroupCode).WithField("DryRun", purgeCmd.DryRun)
+
+	keys, err := c.l.PurgeGroup(purgeCmd.GroupCode, purgeCmd.DryRun)
+	if err != nil {
+		log.Errorf("PurgeGroup error %v", err)
+		c.textSend("失败 - 内部错误")
+		return
+	}
+
+	// 按数据类型统计数量
+	var count = make(map[string]int)
+	var names []string
+	for _, key := range keys {
+		name := strings.SplitN(key, ":", 2)[0]
+		if count[name] == 0 {
+			names = append(names, name)
 		}
+		count[name]++
+	}
+	sort.Strings(names)
+
+	m := mmsg.NewMSG()
+	if purgeCmd.DryRun {
+		m.Textf("将会清除群【%v】的%v条数据", purgeCmd.GroupCode, len(keys))
+	} else {
+		m.Textf("已清除群【%v】的%v条数据", purgeCmd.GroupCode, len(keys))
+	}
+	for _, name := range names {
+		m.Textf("\n%v：%v", name, count[name])
+	}
+	log.WithField("Count", len(keys)).Info("purge group")
+	c.sendChain(m)
+}
+
+func (c *LspPrivateCommand) MigrateCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
 		return
 	}
 
-	log = log.WithField("Mode", modeCmd.Mode)
+	var migrateCmd struct {
+		OldGroupCode int64 `arg:"" help:"原来的群号"`
+		NewGroupCode int64 `arg:"" help:"新的群号"`
+	}
 
-	var err error
+	_, output := c.parseCommandSyntax(&migrateCmd, c.CommandName(),
+		kong.Description("把原来的群的所有订阅和配置复制到新的群，新的群中已有的订阅和配置不会被覆盖"))
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
 
-	switch modeCmd.Mode {
-	case "公开":
-		err = c.l.LspStateManager.SetMode(PublicMode)
-	case "私人":
-		err = c.l.LspStateManager.SetMode(PrivateMode)
-	case "审核":
-		err = c.l.LspStateManager.SetMode(ProtectMode)
-	default:
-		log.Errorf("未知的模式")
-		c.textSend(fmt.Sprintf("未知的模式【%v】，仅支持<公开> <私人> <审核>，请查看命令文档", modeCmd.Mode))
+	log = log.WithField("OldGroupCode", migrateCmd.OldGroupCode).
+		WithField("NewGroupCode", migrateCmd.NewGroupCode)
+
+	if migrateCmd.OldGroupCode == migrateCmd.NewGroupCode {
+		c.textSend("失败 - 新旧群号相同")
 		return
 	}
+
+	copied, skipped, err := c.l.MigrateGroup(migrateCmd.OldGroupCode, migrateCmd.NewGroupCode)
 	if err != nil {
-		log.Errorf("切换模式失败 %v", err)
-		c.textReply(fmt.Sprintf("切换模式失败 - %v", err))
-	} else {
-		log.Infof("切换到%v模式", modeCmd.Mode)
-		c.textReply(fmt.Sprintf("成功 - 切换到%v模式", modeCmd.Mode))
+		log.Errorf("MigrateGroup error %v", err)
+		c.textSend("失败 - 内部错误")
+		return
+	}
+	if len(copied) == 0 && len(skipped) == 0 {
+		c.textSend(fmt.Sprintf("群【%v】中没有可以迁移的数据", migrateCmd.OldGroupCode))
+		return
+	}
+
+	m := mmsg.NewMSG()
+	m.Textf("已将群【%v】的%v条数据迁移到群【%v】", migrateCmd.OldGroupCode, len(copied), migrateCmd.NewGroupCode)
+	if len(skipped) > 0 {
+		m.Textf("，其中%v条数据在新群中已存在，没有覆盖", len(skipped))
 	}
+	if c.bot.FindGroup(migrateCmd.NewGroupCode) == nil {
+		m.Textf("\n注意：bot尚未加入群【%v】，加入后才会推送", migrateCmd.NewGroupCode)
+	}
+	log.WithField("Copied", len(copied)).WithField("Skipped", len(skipped)).Info("migrate group")
+	c.sendChain(m)
 }
 
-func (c *LspPrivateCommand) GroupRequestCommand() {
+func (c *LspPrivateCommand) FailedCommand() {
 	log := c.DefaultLoggerWithCommand(c.CommandName())
 	log.Infof("run %v command", c.CommandName())
 	defer func() { log.Infof("%v command end", c.CommandName()) }()
@@ -881,37 +1320,498 @@ func (c *LspPrivateCommand) GroupRequestCommand() {
 		return
 	}
 
-	var groupRequestCmd struct {
-		RequestId int64    `arg:"" optional:"" help:"要处理的请求的RequestId"`
-		Reject    bool     `optional:"" short:"r" help:"拒绝请求"`
-		All       bool     `optional:"" short:"a" help:"处理全部"`
-		Message   []string `arg:"" optional:"" help:"拒绝理由"`
+	var failedCmd struct {
+		N int `arg:"" optional:"" default:"10" help:"显示最近的几条，默认为10"`
 	}
 
-	_, output := c.parseCommandSyntax(&groupRequestCmd, c.CommandName(), kong.Description("处理群邀请"), kong.UsageOnError())
+	_, output := c.parseCommandSyntax(&failedCmd, c.CommandName(),
+		kong.Description("查看最近发送失败的推送，被禁言时的推送会在禁言解除后自动重新发送，其他推送可以使用 /resend 重新发送"))
 	if output != "" {
-		c.textReply(output)
+		c.textSend(output)
 	}
 	if c.exit {
 		return
 	}
+	if failedCmd.N <= 0 {
+		c.textSend("失败 - 条数必须大于0")
+		return
+	}
 
-	rmsg := strings.Join(groupRequestCmd.Message, " ")
+	records, err := c.l.LspStateManager.ListFailedPush()
+	if err != nil {
+		log.Errorf("ListFailedPush error %v", err)
+		c.textSend("失败 - 内部错误")
+		return
+	}
+	if len(records) > failedCmd.N {
+		records = records[:failedCmd.N]
+	}
+	c.textSend(formatFailedPush(records))
+}
 
-	log = log.WithFields(logrus.Fields{
-		"Requestid": groupRequestCmd.RequestId,
-		"Reject":    groupRequestCmd.Reject,
-		"All":       groupRequestCmd.All,
-		"Message":   rmsg,
-	})
+func (c *LspPrivateCommand) ResendCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
 
-	if groupRequestCmd.RequestId == 0 {
-		requests, err := c.l.LspStateManager.ListGroupInvitedRequest()
-		if err != nil {
-			log.Errorf("ListGroupInvitedRequest error - %v", err)
-			c.textReply(fmt.Sprintf("失败 - %v", err))
-			return
-		}
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var resendCmd struct {
+		Id int64 `arg:"" help:"发送失败的推送的id，可以使用 /failed 查看"`
+	}
+
+	_, output := c.parseCommandSyntax(&resendCmd, c.CommandName(),
+		kong.Description("重新发送一条发送失败的推送"))
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	log = log.WithField("FailedId", resendCmd.Id)
+	err := c.l.ResendFailedPush(resendCmd.Id)
+	switch {
+	case err == ErrFailedPushNotFound, err == ErrFailedPushGroupNotFound:
+		c.textSend(fmt.Sprintf("失败 - %v", err))
+	case err != nil:
+		log.Errorf("ResendFailedPush error %v", err)
+		c.textSend("失败 - 内部错误")
+	default:
+		c.textSend("已重新加入推送队列，再次失败时可以使用 /failed 查看")
+	}
+}
+
+func (c *LspPrivateCommand) LoginCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var loginCmd struct {
+		Site string `optional:"" short:"s" default:"bilibili" help:"网站参数"`
+	}
+
+	_, output := c.parseCommandSyntax(&loginCmd, c.CommandName())
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	cm, err := concern.GetConcernByParseSite(loginCmd.Site)
+	if err != nil {
+		c.textReplyF("失败 - %v", err)
+		return
+	}
+	loginExt, ok := cm.(concern.QRCodeLoginExt)
+	if !ok {
+		c.textReplyF("失败 - %v不支持扫码登陆", cm.Site())
+		return
+	}
+	log = log.WithField("Site", cm.Site())
+
+	content, wait, err := loginExt.QRCodeLogin()
+	if err != nil {
+		log.Errorf("QRCodeLogin error %v", err)
+		c.textSend("失败 - 获取登陆二维码失败")
+		return
+	}
+	img, err := qrcode.PNG(content, 8)
+	if err != nil {
+		log.Errorf("qrcode.PNG error %v", err)
+		c.textSend("失败 - 生成登陆二维码失败")
+		return
+	}
+	m := mmsg.NewMSG()
+	m.Textf("请在%v内使用%v手机客户端扫描二维码登陆：\n", qrcodeLoginTimeout, cm.Site())
+	m.Image(img, "")
+	c.sendChain(m)
+
+	target := mmsg.NewPrivateTarget(c.uin())
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), qrcodeLoginTimeout)
+		defer cancel()
+		if err := wait(ctx); err != nil {
+			log.Errorf("QRCodeLogin wait error %v", err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				c.l.SendMsg(mmsg.NewText("扫码登陆超时，请重新获取二维码"), target)
+			} else {
+				c.l.SendMsg(mmsg.NewTextf("扫码登陆失败 - %v", err), target)
+			}
+			return
+		}
+		log.Info("QRCodeLogin success")
+		c.l.SendMsg(mmsg.NewTextf("%v扫码登陆成功，登陆信息已保存，并且会在过期前自动刷新", cm.Site()), target)
+	}()
+}
+
+func (c *LspPrivateCommand) BackupCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var backupCmd struct {
+		Upload bool `optional:"" short:"u" help:"备份完成后通过私聊发送备份文件"`
+	}
+
+	_, output := c.parseCommandSyntax(&backupCmd, c.CommandName())
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	path, err := doBackup()
+	if err != nil {
+		c.textReplyF("失败 - 备份数据库失败 %v", err)
+		return
+	}
+	c.textReplyF("成功 - 数据库已备份到%v", path)
+	if !backupCmd.Upload {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Errorf("open backup error %v", err)
+		c.textSend("失败 - 读取备份文件失败")
+		return
+	}
+	defer f.Close()
+	if err = localutils.UploadPrivateFile(c.uin(), filepath.Base(path), f); err != nil {
+		log.Errorf("UploadPrivateFile error %v", err)
+		c.textReplyF("失败 - 发送备份文件失败 %v", err)
+		return
+	}
+}
+
+func (c *LspPrivateCommand) WebhookCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var webhookCmd struct {
+		Test  bool `optional:"" short:"t" help:"向所有全局webhook地址发送一条测试事件"`
+		Count int  `optional:"" short:"n" default:"10" help:"显示最近几次投递的结果"`
+	}
+
+	_, output := c.parseCommandSyntax(&webhookCmd, c.CommandName())
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if webhookCmd.Test {
+		count, err := c.l.webhook.Ping()
+		if err != nil {
+			c.textReplyF("失败 - %v", err)
+			return
+		}
+		c.textReplyF("成功 - 已向%v个地址发送测试事件，稍后可以使用 /%v 查看结果", count, c.CommandName())
+		return
+	}
+	if webhookCmd.Count <= 0 {
+		webhookCmd.Count = 10
+	}
+	c.textSend(formatWebhookStatus(c.l.webhook.History(webhookCmd.Count)))
+}
+
+func (c *LspPrivateCommand) ExportCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var exportCmd struct {
+		Group  int64  `optional:"" short:"g" help:"只导出指定群的订阅，默认导出所有群"`
+		Format string `optional:"" short:"f" default:"json" enum:"json,yaml" help:"json / yaml"`
+		Upload bool   `optional:"" short:"u" help:"导出完成后通过私聊发送导出文件"`
+	}
+
+	_, output := c.parseCommandSyntax(&exportCmd, c.CommandName())
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	path, err := doExport(exportCmd.Group, exportCmd.Format)
+	if err != nil {
+		log.Errorf("doExport error %v", err)
+		c.textReplyF("失败 - 导出订阅失败 %v", err)
+		return
+	}
+	c.textReplyF("成功 - 订阅已导出到%v，可以在新的bot中使用<%v>命令导入", path, c.l.CommandShowName(ImportCommand))
+	if !exportCmd.Upload {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Errorf("open export error %v", err)
+		c.textSend("失败 - 读取导出文件失败")
+		return
+	}
+	defer f.Close()
+	if err = localutils.UploadPrivateFile(c.uin(), filepath.Base(path), f); err != nil {
+		log.Errorf("UploadPrivateFile error %v", err)
+		c.textReplyF("失败 - 发送导出文件失败 %v", err)
+		return
+	}
+}
+
+func (c *LspPrivateCommand) ImportCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var importCmd struct {
+		Path string `arg:"" help:"导出文件的路径，支持json和yaml格式"`
+	}
+
+	_, output := c.parseCommandSyntax(&importCmd, c.CommandName())
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	c.textReply("正在导入订阅，订阅较多时可能需要一段时间")
+	result, err := c.l.doImport(importCmd.Path)
+	if err != nil {
+		log.Errorf("doImport error %v", err)
+		c.textReplyF("失败 - 导入订阅失败 %v", err)
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("导入完成 - 成功%v个，已存在%v个，失败%v个", result.Added, result.Skipped, len(result.Failed)))
+	for _, f := range result.Failed {
+		sb.WriteString("\n")
+		sb.WriteString(f)
+	}
+	c.textReply(sb.String())
+}
+
+func (c *LspPrivateCommand) RecordCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var recordCmd struct {
+		Action string `arg:"" optional:"" default:"list" enum:"list,fetch" help:"list（列出录制文件） / fetch（通过私聊发送录制文件）"`
+		Index  int    `arg:"" optional:"" help:"fetch时指定的录制文件序号"`
+	}
+
+	_, output := c.parseCommandSyntax(&recordCmd, c.CommandName())
+	if output != "" {
+		c.textSend(output)
+	}
+	if c.exit {
+		return
+	}
+
+	records, err := recorder.List()
+	if err != nil {
+		log.Errorf("recorder.List error %v", err)
+		c.textReplyF("失败 - 读取录制文件失败 %v", err)
+		return
+	}
+	switch recordCmd.Action {
+	case "list":
+		if len(records) == 0 {
+			c.textReply("当前没有录制文件")
+			return
+		}
+		sb := strings.Builder{}
+		sb.WriteString("录制文件列表：")
+		for idx, record := range records {
+			sb.WriteString(fmt.Sprintf("\n%v. %v %v %v %.1fMB", idx+1, record.Site, record.Id, record.Name, float64(record.Size)/(1<<20)))
+			if record.Recording {
+				sb.WriteString(" （正在录制）")
+			}
+		}
+		c.textSend(sb.String())
+	case "fetch":
+		if recordCmd.Index <= 0 || recordCmd.Index > len(records) {
+			c.textReply("失败 - 序号不正确，请先使用list查看录制文件")
+			return
+		}
+		record := records[recordCmd.Index-1]
+		if record.Recording {
+			c.textReply("失败 - 该文件正在录制")
+			return
+		}
+		f, err := os.Open(record.Path)
+		if err != nil {
+			log.Errorf("open record error %v", err)
+			c.textReply("失败 - 读取录制文件失败")
+			return
+		}
+		defer f.Close()
+		if err = localutils.UploadPrivateFile(c.uin(), record.Name, f); err != nil {
+			log.Errorf("UploadPrivateFile error %v", err)
+			c.textReplyF("失败 - 发送录制文件失败 %v", err)
+			return
+		}
+	}
+}
+
+func (c *LspPrivateCommand) ModeCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var modeCmd struct {
+		Mode string `arg:"" optional:"" help:"指定切换模式，支持<公开> <私人> <审核>，默认为公开"`
+	}
+
+	_, output := c.parseCommandSyntax(&modeCmd, c.CommandName(), kong.Description("切换BOT模式"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if modeCmd.Mode == "" {
+		mode := c.l.LspStateManager.GetCurrentMode()
+		log.Infof("当前Mode为%v", mode)
+		switch c.l.LspStateManager.GetCurrentMode() {
+		case PublicMode:
+			c.textReply("当前模式为公开")
+		case PrivateMode:
+			c.textReply("当前模式为私人")
+		case ProtectMode:
+			c.textReply("当前模式为审核")
+		}
+		return
+	}
+
+	log = log.WithField("Mode", modeCmd.Mode)
+
+	var err error
+
+	switch modeCmd.Mode {
+	case "公开":
+		err = c.l.LspStateManager.SetMode(PublicMode)
+	case "私人":
+		err = c.l.LspStateManager.SetMode(PrivateMode)
+	case "审核":
+		err = c.l.LspStateManager.SetMode(ProtectMode)
+	default:
+		log.Errorf("未知的模式")
+		c.textSend(fmt.Sprintf("未知的模式【%v】，仅支持<公开> <私人> <审核>，请查看命令文档", modeCmd.Mode))
+		return
+	}
+	if err != nil {
+		log.Errorf("切换模式失败 %v", err)
+		c.textReply(fmt.Sprintf("切换模式失败 - %v", err))
+	} else {
+		log.Infof("切换到%v模式", modeCmd.Mode)
+		c.textReply(fmt.Sprintf("成功 - 切换到%v模式", modeCmd.Mode))
+	}
+}
+
+func (c *LspPrivateCommand) GroupRequestCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var groupRequestCmd struct {
+		RequestId int64    `arg:"" optional:"" help:"要处理的请求的RequestId"`
+		Reject    bool     `optional:"" short:"r" help:"拒绝请求"`
+		All       bool     `optional:"" short:"a" help:"处理全部"`
+		Message   []string `arg:"" optional:"" help:"拒绝理由"`
+	}
+
+	_, output := c.parseCommandSyntax(&groupRequestCmd, c.CommandName(), kong.Description("处理群邀请"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	rmsg := strings.Join(groupRequestCmd.Message, " ")
+
+	log = log.WithFields(logrus.Fields{
+		"Requestid": groupRequestCmd.RequestId,
+		"Reject":    groupRequestCmd.Reject,
+		"All":       groupRequestCmd.All,
+		"Message":   rmsg,
+	})
+
+	if groupRequestCmd.RequestId == 0 {
+		requests, err := c.l.LspStateManager.ListGroupInvitedRequest()
+		if err != nil {
+			log.Errorf("ListGroupInvitedRequest error - %v", err)
+			c.textReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
 
 		if len(requests) == 0 {
 			log.Infof("没有查询到加群邀请")
@@ -1204,27 +2104,118 @@ func (c *LspPrivateCommand) AdminCommand() {
 			}
 		}
 	}
-	c.send(m)
+	c.send(m)
+}
+
+func (c *LspPrivateCommand) SilenceCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	if !c.l.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.uin()),
+	) {
+		c.noPermission()
+		return
+	}
+
+	var silenceCmd struct {
+		Group  int64 `optional:"" short:"g" help:"要操作的QQ群号码"`
+		Delete bool  `optional:"" short:"d" help:"取消设置"`
+	}
+
+	_, output := c.parseCommandSyntax(&silenceCmd, c.CommandName(), kong.Description("设置沉默模式"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	ISilenceCmd(c.NewMessageContext(log), silenceCmd.Group, silenceCmd.Delete)
+}
+
+func (c *LspPrivateCommand) QuietCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var quietCmd struct {
+		Group  int64    `optional:"" short:"g" help:"要操作的QQ群号码"`
+		Action string   `arg:"" enum:"add,remove,clear,show,drop,digest" default:"show" help:"add / remove / clear / show / drop（丢弃时段内的推送） / digest（时段结束后汇总发送，默认）"`
+		Range  []string `arg:"" optional:"" help:"免打扰时段，格式为 HH:MM-HH:MM ，例如 23:00-07:00"`
+	}
+
+	_, output := c.parseCommandSyntax(&quietCmd, c.CommandName(), kong.Description("设置免打扰时段"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	IQuietCmd(c.NewMessageContext(log), quietCmd.Group, quietCmd.Action, quietCmd.Range)
+}
+
+func (c *LspPrivateCommand) DigestCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var digestCmd struct {
+		Group    int64  `optional:"" short:"g" help:"要操作的QQ群号码"`
+		Action   string `arg:"" enum:"set,off,show" default:"show" help:"set / off / show"`
+		Interval int    `arg:"" optional:"" help:"汇总的间隔，单位为分钟"`
+	}
+
+	_, output := c.parseCommandSyntax(&digestCmd, c.CommandName(), kong.Description("设置汇总推送，开启后直播以外的推送会定期汇总成一条发送"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	IDigestCmd(c.NewMessageContext(log), digestCmd.Group, digestCmd.Action, digestCmd.Interval)
 }
 
-func (c *LspPrivateCommand) SilenceCommand() {
+func (c *LspPrivateCommand) ReminderCommand() {
 	log := c.DefaultLoggerWithCommand(c.CommandName())
 	log.Infof("run %v command", c.CommandName())
 	defer func() { log.Infof("%v command end", c.CommandName()) }()
 
-	if !c.l.PermissionStateManager.RequireAny(
-		permission.AdminRoleRequireOption(c.uin()),
-	) {
-		c.noPermission()
+	var reminderCmd struct {
+		Group  int64    `optional:"" short:"g" help:"要操作的QQ群号码"`
+		Action string   `arg:"" enum:"add,list,delete" default:"list" help:"add / list / delete"`
+		Args   []string `arg:"" optional:"" help:"add时为cron表达式和提醒内容，例如 \"0 20 * * 5\" 今晚8点直播；delete时为提醒id"`
+	}
+
+	_, output := c.parseCommandSyntax(&reminderCmd, c.CommandName(), kong.Description("设置定时提醒，提醒内容支持模板语法"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
 		return
 	}
 
-	var silenceCmd struct {
-		Group  int64 `optional:"" short:"g" help:"要操作的QQ群号码"`
-		Delete bool  `optional:"" short:"d" help:"取消设置"`
+	var text string
+	if len(reminderCmd.Args) > 0 {
+		text = c.templateText(reminderCmd.Args[1:])
 	}
+	IReminderCmd(c.NewMessageContext(log), reminderCmd.Group, reminderCmd.Action, reminderCmd.Args, text)
+}
 
-	_, output := c.parseCommandSyntax(&silenceCmd, c.CommandName(), kong.Description("设置沉默模式"), kong.UsageOnError())
+func (c *LspPrivateCommand) LocaleCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var localeCmd struct {
+		Group  int64  `optional:"" short:"g" help:"要操作的QQ群号码"`
+		Locale string `arg:"" optional:"" help:"要设置的语言，例如 zh-CN / en-US ，为空时查看当前的语言"`
+	}
+
+	_, output := c.parseCommandSyntax(&localeCmd, c.CommandName(), kong.Description("设置BOT在群内回复和推送使用的语言"), kong.UsageOnError())
 	if output != "" {
 		c.textReply(output)
 	}
@@ -1232,7 +2223,28 @@ func (c *LspPrivateCommand) SilenceCommand() {
 		return
 	}
 
-	ISilenceCmd(c.NewMessageContext(log), silenceCmd.Group, silenceCmd.Delete)
+	ILocaleCmd(c.NewMessageContext(log), localeCmd.Group, localeCmd.Locale)
+}
+
+func (c *LspPrivateCommand) FindCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var findCmd struct {
+		Site    string `optional:"" short:"s" help:"网站参数，不指定时查找所有网站"`
+		Keyword string `arg:"" help:"账号名字的关键字"`
+	}
+
+	_, output := c.parseCommandSyntax(&findCmd, c.CommandName(), kong.Description("通过关键字查找账号的id"), kong.UsageOnError())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	IFind(c.NewMessageContext(log.WithField("keyword", findCmd.Keyword)), findCmd.Site, findCmd.Keyword)
 }
 
 func (c *LspPrivateCommand) PingCommand() {
@@ -1288,7 +2300,7 @@ func (c *LspPrivateCommand) SysinfoCommand() {
 	m.Textf("当前群组数：%v\n", len(c.bot.GetGroupList()))
 	for index, cm := range concern.ListConcern() {
 		_, ids, ctypes, err := cm.GetStateManager().ListConcernState(
-			func(groupCode int64, id interface{}, p concern_type.Type) bool {
+			func(target mmsg.TargetId, id interface{}, p concern_type.Type) bool {
 				return true
 			})
 		if index > 0 {
@@ -1304,10 +2316,165 @@ func (c *LspPrivateCommand) SysinfoCommand() {
 	c.send(m)
 }
 
+// StatusCommand 自我诊断，查看各个网站的刷新情况、数据库、推送队列及内存占用
+func (c *LspPrivateCommand) StatusCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	_, output := c.parseCommandSyntax(&struct{}{}, c.CommandName())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if !c.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(c.uin())) {
+		c.noPermission()
+		return
+	}
+
+	c.textReply(c.l.Diagnose().String())
+}
+
+// ReloadCommand 重新加载配置文件，检查失败时会回复不合法的配置项并继续使用原来的配置
+func (c *LspPrivateCommand) ReloadCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	_, output := c.parseCommandSyntax(&struct{}{}, c.CommandName(),
+		kong.Description("重新加载配置文件，配置检查失败时会继续使用原来的配置"),
+	)
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if !c.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(c.uin())) {
+		c.noPermission()
+		return
+	}
+
+	if err := cfg.Reload(); err != nil {
+		log.Errorf("reload config error %v", err)
+		c.textReply(fmt.Sprintf("失败 - 配置检查失败，将继续使用原来的配置：\n%v", err))
+		return
+	}
+	c.textReply("成功 - 已重新加载配置")
+}
+
+// BlocklistCommand 管理全局的禁止订阅id和屏蔽关键词
+func (c *LspPrivateCommand) BlocklistCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var blocklistCmd struct {
+		List struct {
+		} `cmd:"" help:"查看禁止订阅的id和屏蔽关键词" name:"list"`
+		Id struct {
+			Site   string `arg:"" help:"网站参数"`
+			Id     string `arg:"" help:"要禁止订阅的id"`
+			Delete bool   `optional:"" short:"d" help:"解除禁止订阅"`
+		} `cmd:"" help:"禁止订阅网站的一个id，已经存在的订阅不会再推送" name:"id"`
+		Keyword struct {
+			Keyword string `arg:"" help:"屏蔽关键词"`
+			Delete  bool   `optional:"" short:"d" help:"删除屏蔽关键词"`
+		} `cmd:"" help:"添加屏蔽关键词，包含关键词的推送不会发送" name:"keyword"`
+	}
+	kongCtx, output := c.parseCommandSyntax(&blocklistCmd, c.CommandName(),
+		kong.Description("管理全局的黑名单，禁止订阅指定的id，并屏蔽包含指定关键词的推送"),
+	)
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit || len(kongCtx.Path) <= 1 {
+		return
+	}
+
+	if !c.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(c.uin())) {
+		c.noPermission()
+		return
+	}
+
+	cmd := strings.Split(kongCtx.Command(), " ")[0]
+	log = log.WithField("sub_command", cmd)
+	sm := c.l.LspStateManager
+
+	var err error
+	switch cmd {
+	case "list":
+		ids, err := sm.ListBlocklistId()
+		if err != nil {
+			log.Errorf("ListBlocklistId error %v", err)
+			c.textReplyF("失败 - %v", err)
+			return
+		}
+		keywords, err := sm.ListBlocklistKeyword()
+		if err != nil {
+			log.Errorf("ListBlocklistKeyword error %v", err)
+			c.textReplyF("失败 - %v", err)
+			return
+		}
+		c.textReply(formatBlocklist(ids, keywords))
+		return
+	case "id":
+		site, err := concern.ParseRawSite(blocklistCmd.Id.Site)
+		if err != nil {
+			c.textReplyF("失败 - %v", err)
+			return
+		}
+		cm, err := concern.GetConcernBySite(site)
+		if err != nil {
+			c.textReplyF("失败 - %v", err)
+			return
+		}
+		id, err := cm.ParseId(blocklistCmd.Id.Id)
+		if err != nil {
+			c.textReplyF("失败 - 解析%v id格式错误", site)
+			return
+		}
+		log = log.WithField("site", site).WithField("id", id)
+		if blocklistCmd.Id.Delete {
+			err = sm.DeleteBlocklistId(site, id)
+		} else {
+			err = sm.AddBlocklistId(site, id)
+		}
+	case "keyword":
+		keyword := strings.TrimSpace(blocklistCmd.Keyword.Keyword)
+		if len(keyword) == 0 {
+			c.textReply("失败 - 屏蔽关键词不能为空")
+			return
+		}
+		log = log.WithField("keyword", keyword)
+		if blocklistCmd.Keyword.Delete {
+			err = sm.DeleteBlocklistKeyword(keyword)
+		} else {
+			err = sm.AddBlocklistKeyword(keyword)
+		}
+	}
+	switch {
+	case localdb.IsNotFound(err):
+		c.textReply("失败 - 黑名单中没有这一项")
+	case err == localdb.ErrKeyExist:
+		c.textReply("失败 - 已经在黑名单中了")
+	case err != nil:
+		log.Errorf("blocklist error %v", err)
+		c.textReplyF("失败 - %v", err)
+	default:
+		log.Info("blocklist updated")
+		c.textReply("成功")
+	}
+}
+
 func (c *LspPrivateCommand) DebugCheck() bool {
 	var ok bool
 	if c.debug {
-		if sliceutil.Contains(config.GlobalConfig.GetStringSlice("debug.uin"), c.msg.Sender) {
+		if sliceutil.Contains(cfg.GetDebugUins(), c.msg.Sender) {
 			ok = true
 		}
 	} else {
@@ -1325,15 +2492,15 @@ func (c *LspPrivateCommand) DefaultLoggerWithCommand(command string) *logrus.Ent
 }
 
 func (c *LspPrivateCommand) noPermission() *message.PrivateMessage {
-	return c.textReply("权限不够")
+	return c.textReply(i18n.T(i18n.DefaultLocale(), "reply.no_permission"))
 }
 
 func (c *LspPrivateCommand) globalDisabledReply() *message.PrivateMessage {
-	return c.textReply("无法操作该命令，该命令已被管理员禁用")
+	return c.textReply(i18n.T(i18n.DefaultLocale(), "reply.global_disabled"))
 }
 
 func (c *LspPrivateCommand) disabledReply() *message.PrivateMessage {
-	return c.textSend("该命令已被设置为disable，请设置enable后重试")
+	return c.textSend(i18n.T(i18n.DefaultLocale(), "reply.disabled"))
 }
 
 func (c *LspPrivateCommand) notImplReply() *message.PrivateMessage {
@@ -1417,9 +2584,39 @@ func (c *LspPrivateCommand) NewMessageContext(log *logrus.Entry) *MessageContext
 		return c.globalDisabledReply()
 	}
 	ctx.Sender = c.sender()
+	ctx.Command = strings.Join(c.GetCmdArgs(), " ")
 	return ctx
 }
 
+// checkConcernGroupCode 检查订阅相关命令的 groupCode ，没有指定QQ群时操作自己的私聊订阅，
+// 私聊订阅仅BOT的好友可以使用，返回订阅的推送目标
+func (c *LspPrivateCommand) checkConcernGroupCode(groupCode int64) (mmsg.TargetId, error) {
+	if groupCode < 0 {
+		return mmsg.TargetId{}, fmt.Errorf("QQ群号码<%v>错误", groupCode)
+	}
+	if groupCode == 0 && c.bot.FindFriend(c.uin()) != nil {
+		return mmsg.NewPrivateTargetId(c.uin()), nil
+	}
+	return mmsg.NewGroupTargetId(groupCode), c.checkGroupCode(groupCode)
+}
+
+// checkConcernTarget 指定了Telegram chat id时返回Telegram的推送目标，否则与 checkConcernGroupCode 相同
+func (c *LspPrivateCommand) checkConcernTarget(groupCode int64, chatId int64) (mmsg.TargetId, error) {
+	if chatId == 0 {
+		return c.checkConcernGroupCode(groupCode)
+	}
+	if groupCode != 0 {
+		return mmsg.TargetId{}, fmt.Errorf("不能同时指定QQ群号码与Telegram chat id")
+	}
+	if !c.l.PermissionStateManager.CheckRole(c.uin(), permission.Admin) {
+		return mmsg.TargetId{}, fmt.Errorf("Telegram订阅仅bot管理员可以操作")
+	}
+	if GetNotifySender(mmsg.TargetTelegram) == nil {
+		return mmsg.TargetId{}, fmt.Errorf("没有配置telegram.token，无法使用Telegram推送")
+	}
+	return mmsg.NewTelegramTargetId(chatId), nil
+}
+
 func (c *LspPrivateCommand) checkGroupCode(groupCode int64) error {
 	if groupCode == 0 {
 		return fmt.Errorf("没有指定QQ群号码，请使用-g参数指定QQ群，例如对QQ群123456进行操作：%v %v %v", c.GetCmd(), "-g 123456", strings.Join(c.GetArgs(), " "))
@@ -1443,3 +2640,253 @@ func (c *LspPrivateCommand) checkGroupCode(groupCode int64) error {
 	}
 	return nil
 }
+
+// AuditLogCommand 查看最近的订阅、配置、权限等修改记录
+func (c *LspPrivateCommand) AuditLogCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var auditLogCmd struct {
+		N int `arg:"" optional:"" default:"10" help:"查看的条数，最多50条"`
+	}
+	_, output := c.parseCommandSyntax(&auditLogCmd, c.CommandName())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if !c.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(c.uin())) {
+		c.noPermission()
+		return
+	}
+
+	if auditLogCmd.N <= 0 {
+		auditLogCmd.N = 10
+	}
+	if auditLogCmd.N > maxAuditLogLimit {
+		auditLogCmd.N = maxAuditLogLimit
+	}
+	logs, err := c.l.LspStateManager.ListAuditLog(auditLogCmd.N)
+	if err != nil {
+		log.Errorf("ListAuditLog error %v", err)
+		c.textReply("失败 - 内部错误")
+		return
+	}
+	c.textReply(formatAuditLog(logs))
+}
+
+// HttpCommand 运行时修改每个网站的http请求配置，包括代理列表、超时、重试、UA和header
+func (c *LspPrivateCommand) HttpCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	var httpCmd struct {
+		Show struct {
+			Site string `arg:"" optional:"" help:"网站参数，不填时列出所有配置过的网站"`
+		} `cmd:"" help:"查看网站的http请求配置和代理状态" name:"show"`
+		Proxy struct {
+			Site    string   `arg:"" help:"网站参数"`
+			Proxies []string `arg:"" optional:"" help:"代理地址，可以一次填多个，每次请求轮流使用，不填时清空"`
+		} `cmd:"" help:"设置网站使用的代理列表" name:"proxy"`
+		Timeout struct {
+			Site    string        `arg:"" help:"网站参数"`
+			Timeout time.Duration `arg:"" help:"超时时间，例如10s，设置为0时使用默认值"`
+		} `cmd:"" help:"设置网站的请求超时时间" name:"timeout"`
+		Retry struct {
+			Site  string `arg:"" help:"网站参数"`
+			Retry int    `arg:"" help:"重试次数，设置为0时使用默认值"`
+		} `cmd:"" help:"设置网站的请求重试次数" name:"retry"`
+		UA struct {
+			Site string `arg:"" help:"网站参数"`
+			UA   string `arg:"" optional:"" help:"User-Agent，不填时使用默认值"`
+		} `cmd:"" help:"设置网站请求的User-Agent" name:"ua"`
+		Header struct {
+			Site  string `arg:"" help:"网站参数"`
+			Key   string `arg:"" help:"header名字"`
+			Value string `arg:"" optional:"" help:"header的值，不填时删除这个header"`
+		} `cmd:"" help:"设置网站请求的header" name:"header"`
+		Check struct {
+			Site string `arg:"" help:"网站参数"`
+			Url  string `arg:"" optional:"" default:"https://www.baidu.com" help:"用于检查的地址"`
+		} `cmd:"" help:"检查网站的每个代理是否可用" name:"check"`
+		Reset struct {
+			Site string `arg:"" help:"网站参数"`
+		} `cmd:"" help:"删除通过命令修改的配置，恢复为配置文件中的配置" name:"reset"`
+	}
+	kongCtx, output := c.parseCommandSyntax(&httpCmd, c.CommandName(),
+		kong.Description("管理每个网站的http请求配置，修改立即生效，并覆盖配置文件中同一个网站的配置"),
+	)
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit || len(kongCtx.Path) <= 1 {
+		return
+	}
+
+	if !c.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(c.uin())) {
+		c.noPermission()
+		return
+	}
+
+	cmd := strings.Split(kongCtx.Command(), " ")[0]
+	log = log.WithField("sub_command", cmd)
+
+	var rawSite string
+	switch cmd {
+	case "show":
+		rawSite = httpCmd.Show.Site
+	case "proxy":
+		rawSite = httpCmd.Proxy.Site
+	case "timeout":
+		rawSite = httpCmd.Timeout.Site
+	case "retry":
+		rawSite = httpCmd.Retry.Site
+	case "ua":
+		rawSite = httpCmd.UA.Site
+	case "header":
+		rawSite = httpCmd.Header.Site
+	case "check":
+		rawSite = httpCmd.Check.Site
+	case "reset":
+		rawSite = httpCmd.Reset.Site
+	}
+
+	if cmd == "show" && len(rawSite) == 0 {
+		sites := requests.ListSiteConfig()
+		if len(sites) == 0 {
+			c.textReply("没有网站配置过http请求参数")
+			return
+		}
+		var result []string
+		for _, site := range sites {
+			result = append(result, formatHttpSiteConfig(site, requests.GetSiteConfig(site), requests.GetSiteProxyStatus(site)))
+		}
+		c.textReply(strings.Join(result, "\n\n"))
+		return
+	}
+
+	site, err := concern.ParseRawSite(rawSite)
+	if err != nil {
+		c.textReplyF("失败 - %v", err)
+		return
+	}
+	log = log.WithField("site", site)
+
+	var update func(config *requests.SiteConfig)
+	switch cmd {
+	case "show":
+		c.textReply(formatHttpSiteConfig(site, requests.GetSiteConfig(site), requests.GetSiteProxyStatus(site)))
+		return
+	case "check":
+		result, err := requests.CheckSiteProxy(site, httpCmd.Check.Url)
+		if err != nil {
+			c.textReplyF("失败 - %v", err)
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%v代理检查结果：", site))
+		for _, p := range requests.GetSiteProxyStatus(site) {
+			err, found := result[p.Proxy]
+			if !found {
+				continue
+			}
+			if err != nil {
+				sb.WriteString(fmt.Sprintf("\n%v 失败 - %v", p.Proxy, err))
+			} else {
+				sb.WriteString(fmt.Sprintf("\n%v 可用", p.Proxy))
+			}
+		}
+		c.textReply(sb.String())
+		return
+	case "reset":
+		if err := c.l.LspStateManager.SetHttpSiteConfig(site, nil); err != nil {
+			log.Errorf("SetHttpSiteConfig error %v", err)
+			c.textReply("失败 - 内部错误")
+			return
+		}
+		c.l.loadHttpSiteConfig()
+		c.NewMessageContext(log).audit(0)
+		c.textReply("成功 - 已恢复为配置文件中的配置\n" +
+			formatHttpSiteConfig(site, requests.GetSiteConfig(site), requests.GetSiteProxyStatus(site)))
+		return
+	case "proxy":
+		update = func(config *requests.SiteConfig) {
+			config.Proxies = httpCmd.Proxy.Proxies
+		}
+	case "timeout":
+		if httpCmd.Timeout.Timeout < 0 {
+			c.textReply("失败 - 超时时间不能小于0")
+			return
+		}
+		update = func(config *requests.SiteConfig) {
+			config.Timeout = httpCmd.Timeout.Timeout
+		}
+	case "retry":
+		if httpCmd.Retry.Retry < 0 {
+			c.textReply("失败 - 重试次数不能小于0")
+			return
+		}
+		update = func(config *requests.SiteConfig) {
+			config.Retry = httpCmd.Retry.Retry
+		}
+	case "ua":
+		update = func(config *requests.SiteConfig) {
+			config.UA = httpCmd.UA.UA
+		}
+	case "header":
+		update = func(config *requests.SiteConfig) {
+			for k := range config.Header {
+				if strings.EqualFold(k, httpCmd.Header.Key) {
+					delete(config.Header, k)
+				}
+			}
+			if len(httpCmd.Header.Value) > 0 {
+				if config.Header == nil {
+					config.Header = make(map[string]string)
+				}
+				config.Header[httpCmd.Header.Key] = httpCmd.Header.Value
+			}
+		}
+	}
+
+	config, err := c.l.updateHttpSiteConfig(site, update)
+	if err != nil {
+		log.Errorf("updateHttpSiteConfig error %v", err)
+		c.textReply("失败 - 内部错误")
+		return
+	}
+	c.NewMessageContext(log).audit(0)
+	c.textReply("成功\n" + formatHttpSiteConfig(site, config, requests.GetSiteProxyStatus(site)))
+}
+
+// ShutdownCommand 让bot退出，退出前会等待推送队列发送完毕并整理数据库，
+// 与收到SIGTERM时的流程相同，适合在容器中配合自动重启使用
+func (c *LspPrivateCommand) ShutdownCommand() {
+	log := c.DefaultLoggerWithCommand(c.CommandName())
+	log.Infof("run %v command", c.CommandName())
+	defer func() { log.Infof("%v command end", c.CommandName()) }()
+
+	_, output := c.parseCommandSyntax(&struct{}{}, c.CommandName())
+	if output != "" {
+		c.textReply(output)
+	}
+	if c.exit {
+		return
+	}
+
+	if !c.l.PermissionStateManager.RequireAny(permission.AdminRoleRequireOption(c.uin())) {
+		c.noPermission()
+		return
+	}
+
+	if n := c.l.pushQueue.Len(); n > 0 {
+		c.textReplyF("bot正在退出，将等待推送队列中的%v条推送发送完毕", n)
+	} else {
+		c.textReply("bot正在退出")
+	}
+	c.l.Shutdown(fmt.Sprintf("管理员%v使用了%v命令", c.uin(), c.CommandName()))
+}
@@ -0,0 +1,437 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/metrics"
+	"golang.org/x/sync/semaphore"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// PushPriority 推送优先级，数值越大越先发送
+type PushPriority int
+
+const (
+	PushPriorityLow PushPriority = iota
+	PushPriorityNormal
+	PushPriorityHigh
+)
+
+const (
+	pushQueueMaxRetryInterval = time.Minute * 10
+	pushQueueItemExpire       = time.Hour * 24
+)
+
+// PushItem 推送队列中的一条消息
+type PushItem struct {
+	Id     string
+	Target mmsg.TargetId
+	// Site 推送所属的网站，重启后恢复的消息发送失败时用于记录失败的推送
+	Site     string
+	Priority PushPriority
+	MSG      *mmsg.MSG
+	Retry    int
+	// Callback 在消息发送成功或者放弃重试后调用，不会被持久化，
+	// 重启后恢复的消息使用 PushQueue.restoreCallback ，只会记录发送失败的推送，不会调用 NotifyAfterCallback 也不会更新最后推送时间
+	Callback func(msgs []*message.GroupMessage)
+
+	seq      int64
+	nextTime time.Time
+}
+
+// pushItemRecord 是 PushItem 持久化到buntdb中的格式，只保存文字、@、图片以及分段
+type pushItemRecord struct {
+	Id       string               `json:"id"`
+	Target   mmsg.TargetId        `json:"target"`
+	Site     string               `json:"site,omitempty"`
+	Priority PushPriority         `json:"priority"`
+	Retry    int                  `json:"retry"`
+	Elements []*pushElementRecord `json:"elements"`
+}
+
+type pushElementRecord struct {
+	Type    string `json:"type"`
+	Content string `json:"content,omitempty"`
+	Target  int64  `json:"target,omitempty"`
+	Image   []byte `json:"image,omitempty"`
+}
+
+const (
+	pushElementText  = "text"
+	pushElementAt    = "at"
+	pushElementImage = "image"
+	pushElementCut   = "cut"
+)
+
+func newPushItemRecord(item *PushItem) *pushItemRecord {
+	var record = &pushItemRecord{
+		Id:       item.Id,
+		Target:   item.Target,
+		Site:     item.Site,
+		Priority: item.Priority,
+		Retry:    item.Retry,
+	}
+	for _, e := range item.MSG.Elements() {
+		switch elem := e.(type) {
+		case *message.TextElement:
+			record.Elements = append(record.Elements, &pushElementRecord{Type: pushElementText, Content: elem.Content})
+		case *mmsg.AtElement:
+			record.Elements = append(record.Elements, &pushElementRecord{Type: pushElementAt, Target: elem.Target, Content: elem.Display})
+		case *message.AtElement:
+			record.Elements = append(record.Elements, &pushElementRecord{Type: pushElementAt, Target: elem.Target, Content: elem.Display})
+		case *mmsg.ImageBytesElement:
+			record.Elements = append(record.Elements, &pushElementRecord{Type: pushElementImage, Image: elem.Buf})
+		case *mmsg.CutElement:
+			record.Elements = append(record.Elements, &pushElementRecord{Type: pushElementCut})
+		default:
+			logger.WithFields(mmsg.TargetLogFields(item.Target)).
+				Debugf("push queue: element type %T can not be persisted, skip", e)
+		}
+	}
+	return record
+}
+
+func (r *pushItemRecord) toPushItem() *PushItem {
+	var m = mmsg.NewMSG()
+	for _, e := range r.Elements {
+		switch e.Type {
+		case pushElementText:
+			m.Text(e.Content)
+		case pushElementAt:
+			m.Append(mmsg.NewAt(e.Target, e.Content))
+		case pushElementImage:
+			m.Append(mmsg.NewImage(e.Image))
+		case pushElementCut:
+			m.Cut()
+		}
+	}
+	return &PushItem{
+		Id:       r.Id,
+		Target:   r.Target,
+		Site:     r.Site,
+		Priority: r.Priority,
+		MSG:      m,
+		Retry:    r.Retry,
+	}
+}
+
+// PushQueue 是所有推送共用的发送队列：
+// 按优先级发送，同一个推送目标两次发送之间至少间隔 groupInterval，
+// 发送失败时按指数退避重试，加入队列时消息会保存在buntdb中，发送成功或者放弃重试后删除，
+// 即使异常退出，未发送的消息也会在重启后继续发送。
+type PushQueue struct {
+	sm    *StateManager
+	limit *semaphore.Weighted
+	send  func(target mmsg.TargetId, m *mmsg.MSG) []*message.GroupMessage
+	// restoreCallback 重启后恢复的消息在发送成功或者放弃重试后调用
+	restoreCallback func(item *PushItem, msgs []*message.GroupMessage)
+
+	groupInterval time.Duration
+	retryInterval time.Duration
+	maxRetry      int
+
+	mu       sync.Mutex
+	items    []*PushItem
+	lastSend map[mmsg.TargetId]time.Time
+	sending  map[mmsg.TargetId]bool
+	seq      int64
+
+	wakeup    chan struct{}
+	stop      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// Push 添加一条消息到队列中
+func (q *PushQueue) Push(item *PushItem) {
+	if item == nil || item.MSG == nil {
+		return
+	}
+	q.mu.Lock()
+	q.seq++
+	item.seq = q.seq
+	if item.Id == "" {
+		item.Id = fmt.Sprintf("%v-%v", time.Now().UnixNano(), item.seq)
+	}
+	q.mu.Unlock()
+	q.persist(item)
+	q.enqueue(item)
+}
+
+// Len 返回队列中等待发送的消息数量，不包括正在发送的消息
+func (q *PushQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Start 恢复buntdb中未发送的消息，并开始发送，重复调用无效
+func (q *PushQueue) Start() {
+	q.startOnce.Do(q.start)
+}
+
+func (q *PushQueue) start() {
+	records, err := q.sm.ListPushItem()
+	if err != nil {
+		logger.Errorf("push queue: ListPushItem error %v", err)
+	}
+	var restored int
+	q.mu.Lock()
+	// Start 之前 Push 的消息已经在队列中了，跳过
+	var queued = make(map[string]bool)
+	for _, item := range q.items {
+		queued[item.Id] = true
+	}
+	for _, record := range records {
+		if queued[record.Id] {
+			continue
+		}
+		item := record.toPushItem()
+		if q.restoreCallback != nil {
+			item.Callback = func(msgs []*message.GroupMessage) {
+				q.restoreCallback(item, msgs)
+			}
+		}
+		q.seq++
+		item.seq = q.seq
+		q.items = append(q.items, item)
+		restored++
+	}
+	q.mu.Unlock()
+	if restored > 0 {
+		logger.Infof("推送队列恢复了%v条未发送的推送", restored)
+	}
+	q.wg.Add(1)
+	go q.run()
+}
+
+// Stop 停止发送并等待正在发送的消息完成，未发送的消息会在下次启动时继续发送
+func (q *PushQueue) Stop() {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+	})
+	q.wg.Wait()
+}
+
+// Drain 等待队列中的消息发送完毕，最多等待timeout，返回是否已经全部发送。
+// 等待期间到了重试时间的消息会继续发送，超时后剩余的消息仍然保存在buntdb中，下次启动时继续发送
+func (q *PushQueue) Drain(timeout time.Duration) bool {
+	var deadline = time.Now().Add(timeout)
+	for {
+		q.mu.Lock()
+		var remain = len(q.items) + len(q.sending)
+		q.mu.Unlock()
+		if remain == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+}
+
+func (q *PushQueue) enqueue(item *PushItem) {
+	q.mu.Lock()
+	q.items = append(q.items, item)
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *PushQueue) notify() {
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// persist 保存未发送的消息，重试时会更新重试次数
+func (q *PushQueue) persist(item *PushItem) {
+	if err := q.sm.SavePushItem(newPushItemRecord(item), pushQueueItemExpire); err != nil {
+		logger.WithFields(mmsg.TargetLogFields(item.Target)).Errorf("push queue: SavePushItem error %v", err)
+	}
+}
+
+// next 返回当前可以发送的优先级最高的消息，如果没有，返回需要等待的时间
+func (q *PushQueue) next(now time.Time) (*PushItem, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var (
+		best    = -1
+		minWait = time.Minute
+	)
+	for index, item := range q.items {
+		if q.sending[item.Target] {
+			continue
+		}
+		ready := item.nextTime
+		if last, found := q.lastSend[item.Target]; found && last.Add(q.groupInterval).After(ready) {
+			ready = last.Add(q.groupInterval)
+		}
+		if ready.After(now) {
+			if wait := ready.Sub(now); wait < minWait {
+				minWait = wait
+			}
+			continue
+		}
+		if best < 0 || item.Priority > q.items[best].Priority ||
+			(item.Priority == q.items[best].Priority && item.seq < q.items[best].seq) {
+			best = index
+		}
+	}
+	if best < 0 {
+		return nil, minWait
+	}
+	item := q.items[best]
+	q.items = append(q.items[:best], q.items[best+1:]...)
+	q.sending[item.Target] = true
+	return item, 0
+}
+
+func (q *PushQueue) run() {
+	defer q.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-q.stop
+		cancel()
+	}()
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+		item, wait := q.next(time.Now())
+		if item == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-q.stop:
+				timer.Stop()
+				return
+			case <-q.wakeup:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+		if err := q.limit.Acquire(ctx, 1); err != nil {
+			q.mu.Lock()
+			delete(q.sending, item.Target)
+			q.items = append(q.items, item)
+			q.mu.Unlock()
+			return
+		}
+		q.wg.Add(1)
+		go q.process(item)
+	}
+}
+
+func (q *PushQueue) process(item *PushItem) {
+	log := logger.WithFields(mmsg.TargetLogFields(item.Target)).
+		WithField("push_id", item.Id).WithField("retry", item.Retry)
+	var msgs []*message.GroupMessage
+	defer func() {
+		if e := recover(); e != nil {
+			log.WithField("stack", string(debug.Stack())).Errorf("push queue: send panic recovered %v", e)
+			q.finish(item, msgs)
+		}
+		q.limit.Release(1)
+		q.wg.Done()
+		q.notify()
+	}()
+	msgs = q.send(item.Target, item.MSG)
+	if q.shouldRetry(item, msgs) {
+		metrics.ObservePush(item.Target.Key(), metrics.PushResultRetry)
+		item.Retry++
+		backoff := q.retryInterval << (item.Retry - 1)
+		if backoff <= 0 || backoff > pushQueueMaxRetryInterval {
+			backoff = pushQueueMaxRetryInterval
+		}
+		log.Debugf("push queue: send failed, retry after %v", backoff)
+		item.nextTime = time.Now().Add(backoff)
+		q.persist(item)
+		q.mu.Lock()
+		q.lastSend[item.Target] = time.Now()
+		delete(q.sending, item.Target)
+		q.items = append(q.items, item)
+		q.mu.Unlock()
+		return
+	}
+	if len(msgs) > 0 && msgs[0].Id != -1 {
+		metrics.ObservePush(item.Target.Key(), metrics.PushResultSuccess)
+	} else {
+		metrics.ObservePush(item.Target.Key(), metrics.PushResultFail)
+	}
+	q.finish(item, msgs)
+}
+
+func (q *PushQueue) finish(item *PushItem, msgs []*message.GroupMessage) {
+	q.mu.Lock()
+	q.lastSend[item.Target] = time.Now()
+	delete(q.sending, item.Target)
+	q.mu.Unlock()
+	if err := q.sm.DeletePushItem(item.Id); err != nil {
+		logger.WithField("push_id", item.Id).Errorf("push queue: DeletePushItem error %v", err)
+	}
+	if item.Callback == nil {
+		return
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			logger.WithField("push_id", item.Id).WithField("stack", string(debug.Stack())).
+				Errorf("push queue: callback panic recovered %v", e)
+		}
+	}()
+	item.Callback(msgs)
+}
+
+// shouldRetry 第一条消息发送失败时重试，
+// 包含@全体成员的消息失败时不重试，由推送自行处理（例如@全体成员次数用完）
+func (q *PushQueue) shouldRetry(item *PushItem, msgs []*message.GroupMessage) bool {
+	if item.Retry >= q.maxRetry {
+		return false
+	}
+	if len(msgs) > 0 && msgs[0].Id != -1 {
+		return false
+	}
+	for _, e := range item.MSG.Elements() {
+		if at, ok := e.(*mmsg.AtElement); ok && at.AtElement != nil && at.Target == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// NotifyPushPriority 返回推送的优先级，开播推送为 PushPriorityHigh，其他为 PushPriorityNormal
+func NotifyPushPriority(notify concern.Notify) PushPriority {
+	if liveExt, ok := notify.(concern.NotifyLiveExt); ok && liveExt.IsLive() {
+		if liveExt.Living() && liveExt.LiveStatusChanged() {
+			return PushPriorityHigh
+		}
+	}
+	return PushPriorityNormal
+}
+
+// NewPushQueue 创建推送队列，limit 限制同时发送的消息数量，send 为实际发送消息的函数
+func NewPushQueue(sm *StateManager, limit *semaphore.Weighted, send func(target mmsg.TargetId, m *mmsg.MSG) []*message.GroupMessage) *PushQueue {
+	return &PushQueue{
+		sm:            sm,
+		limit:         limit,
+		send:          send,
+		groupInterval: cfg.GetPushQueueGroupInterval(),
+		retryInterval: cfg.GetPushQueueRetryInterval(),
+		maxRetry:      cfg.GetPushQueueMaxRetry(),
+		lastSend:      make(map[mmsg.TargetId]time.Time),
+		sending:       make(map[mmsg.TargetId]bool),
+		wakeup:        make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+	}
+}
@@ -0,0 +1,219 @@
+package lsp
+
+import (
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/semaphore"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testPushG3 int64 = 111111
+
+type testPushSender struct {
+	mu     sync.Mutex
+	fail   map[int64]int
+	result []string
+}
+
+func (s *testPushSender) send(target mmsg.TargetId, m *mmsg.MSG) []*message.GroupMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	groupCode := target.TargetCode()
+	elems := m.ToCombineMessage(target).Elements
+	if s.fail[groupCode] > 0 {
+		s.fail[groupCode]--
+		return []*message.GroupMessage{{Id: -1, GroupCode: groupCode, Elements: elems}}
+	}
+	s.result = append(s.result, msgstringer.MsgToString(elems))
+	return []*message.GroupMessage{{Id: 1, GroupCode: groupCode, Elements: elems}}
+}
+
+func (s *testPushSender) Result() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.result...)
+}
+
+func newTestPushQueue(t *testing.T, sender *testPushSender) *PushQueue {
+	q := NewPushQueue(newStateManager(t), semaphore.NewWeighted(1), sender.send)
+	q.groupInterval = time.Millisecond * 50
+	q.retryInterval = time.Millisecond * 10
+	q.maxRetry = 2
+	return q
+}
+
+func TestPushQueue_Priority(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	q := newTestPushQueue(t, sender)
+
+	var wg sync.WaitGroup
+	var callback = func(msgs []*message.GroupMessage) {
+		assert.Len(t, msgs, 1)
+		wg.Done()
+	}
+	wg.Add(3)
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), Priority: PushPriorityNormal, MSG: mmsg.NewTextf("news1"), Callback: callback})
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G2), Priority: PushPriorityNormal, MSG: mmsg.NewTextf("news2"), Callback: callback})
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), Priority: PushPriorityHigh, MSG: mmsg.NewTextf("live"), Callback: callback})
+	assert.EqualValues(t, 3, q.Len())
+
+	q.Start()
+	defer q.Stop()
+	wg.Wait()
+
+	assert.EqualValues(t, []string{"live", "news2", "news1"}, sender.Result())
+	assert.EqualValues(t, 0, q.Len())
+
+	records, err := q.sm.ListPushItem()
+	assert.Nil(t, err)
+	assert.Len(t, records, 0)
+}
+
+func TestPushQueue_GroupInterval(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	q := newTestPushQueue(t, sender)
+	q.groupInterval = time.Millisecond * 200
+
+	var wg sync.WaitGroup
+	var sendTime []time.Time
+	var mu sync.Mutex
+	var callback = func(msgs []*message.GroupMessage) {
+		mu.Lock()
+		sendTime = append(sendTime, time.Now())
+		mu.Unlock()
+		wg.Done()
+	}
+	wg.Add(2)
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewTextf("1"), Callback: callback})
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewTextf("2"), Callback: callback})
+	q.Start()
+	defer q.Stop()
+	wg.Wait()
+
+	assert.Len(t, sendTime, 2)
+	assert.GreaterOrEqual(t, sendTime[1].Sub(sendTime[0]), time.Millisecond*150)
+	assert.EqualValues(t, []string{"1", "2"}, sender.Result())
+}
+
+func TestPushQueue_Retry(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{test.G1: 1, test.G2: 5, testPushG3: 1}}
+	q := newTestPushQueue(t, sender)
+	q.groupInterval = time.Millisecond
+
+	var wg sync.WaitGroup
+	var result = make(map[int64]int64)
+	var mu sync.Mutex
+	var callback = func(msgs []*message.GroupMessage) {
+		assert.Len(t, msgs, 1)
+		mu.Lock()
+		result[msgs[0].GroupCode] = int64(msgs[0].Id)
+		mu.Unlock()
+		wg.Done()
+	}
+	wg.Add(3)
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewTextf("retry"), Callback: callback})
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G2), MSG: mmsg.NewTextf("fail"), Callback: callback})
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(testPushG3), MSG: mmsg.NewMSG().Append(mmsg.NewAt(0)).Text("at all"), Callback: callback})
+	q.Start()
+	defer q.Stop()
+	wg.Wait()
+
+	assert.EqualValues(t, 1, result[test.G1])
+	// 超过最大重试次数后放弃
+	assert.EqualValues(t, -1, result[test.G2])
+	assert.EqualValues(t, 2, sender.fail[test.G2])
+	// 包含@全体成员的消息不重试
+	assert.EqualValues(t, -1, result[testPushG3])
+}
+
+func TestPushQueue_Restore(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	q := newTestPushQueue(t, sender)
+	m := mmsg.NewMSG()
+	m.Textf("restore")
+	m.Append(mmsg.NewAt(test.UID1, "someone"))
+	m.Cut()
+	m.Append(mmsg.NewImage([]byte{1, 2, 3}))
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), Site: test.Site1, Priority: PushPriorityHigh, MSG: m})
+
+	// 加入队列时立即保存，异常退出（没有调用Stop）也不会丢失
+	records, err := q.sm.ListPushItem()
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	item := records[0].toPushItem()
+	assert.EqualValues(t, mmsg.NewGroupTargetId(test.G1), item.Target)
+	assert.EqualValues(t, test.Site1, item.Site)
+	assert.EqualValues(t, PushPriorityHigh, item.Priority)
+	assert.Len(t, item.MSG.Elements(), len(m.Elements()))
+
+	// 模拟重启
+	var restored = make(chan *PushItem, 1)
+	q2 := newTestPushQueue(t, sender)
+	q2.restoreCallback = func(item *PushItem, msgs []*message.GroupMessage) {
+		restored <- item
+	}
+	q2.Start()
+	defer q2.Stop()
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 1
+	}, time.Second*3, time.Millisecond*20)
+	// 发送成功后删除
+	assert.Eventually(t, func() bool {
+		records, err := q2.sm.ListPushItem()
+		return err == nil && len(records) == 0
+	}, time.Second*3, time.Millisecond*20)
+	select {
+	case item := <-restored:
+		assert.EqualValues(t, test.Site1, item.Site)
+	case <-time.After(time.Second):
+		assert.Fail(t, "restore callback not called")
+	}
+}
+
+func TestPushQueue_Drain(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	q := newTestPushQueue(t, sender)
+	assert.True(t, q.Drain(0))
+
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewTextf("news1")})
+	q.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewTextf("news2")})
+	// 没有启动时无法发送
+	assert.False(t, q.Drain(time.Millisecond*100))
+
+	q.Start()
+	assert.True(t, q.Drain(time.Second*3))
+	q.Stop()
+	assert.EqualValues(t, []string{"news1", "news2"}, sender.Result())
+
+	// 一直发送失败的消息在超时后保留在buntdb中
+	sender.fail[test.G2] = 100
+	q2 := newTestPushQueue(t, sender)
+	q2.retryInterval = time.Hour
+	q2.Start()
+	q2.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G2), MSG: mmsg.NewTextf("fail")})
+	assert.False(t, q2.Drain(time.Millisecond*200))
+	q2.Stop()
+	records, err := q2.sm.ListPushItem()
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+}
@@ -0,0 +1,165 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quietDigestInterval 检查免打扰时段是否结束的间隔
+const quietDigestInterval = time.Minute
+
+var ErrInvalidQuietRange = errors.New("时段格式错误，请使用 HH:MM-HH:MM ，例如 23:00-07:00")
+
+// QuietHoursConfig 群免打扰时段配置，时段内的推送默认暂存，结束后汇总成一条推送发送
+type QuietHoursConfig struct {
+	// Ranges 免打扰时段，格式为 HH:MM-HH:MM ，结束时间小于开始时间表示跨过零点
+	Ranges []string `json:"ranges"`
+	// Drop 为true时直接丢弃时段内的推送
+	Drop bool `json:"drop"`
+}
+
+// parseQuietRange 解析 HH:MM-HH:MM ，返回从零点开始的分钟数
+func parseQuietRange(s string) (start int, end int, err error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return 0, 0, ErrInvalidQuietRange
+	}
+	if start, err = parseClock(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseClock(parts[1]); err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, ErrInvalidQuietRange
+	}
+	return start, end, nil
+}
+
+func parseClock(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return 0, ErrInvalidQuietRange
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, ErrInvalidQuietRange
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, ErrInvalidQuietRange
+	}
+	return hour*60 + minute, nil
+}
+
+// InQuiet 判断t是否在任意一个免打扰时段内，时段包含开始时间，不包含结束时间
+func (q *QuietHoursConfig) InQuiet(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	for _, r := range q.Ranges {
+		start, end, err := parseQuietRange(r)
+		if err != nil {
+			continue
+		}
+		if start < end {
+			if now >= start && now < end {
+				return true
+			}
+		} else if now >= start || now < end {
+			return true
+		}
+	}
+	return false
+}
+
+// newQuietDigest 把免打扰时段内暂存的推送合并成汇总消息，推送较多时拆分成多条消息，暂存的推送中不包含@
+func newQuietDigest(records []*pushItemRecord) []*mmsg.MSG {
+	return newChunkedDigest("免打扰时段内共有%v条推送", records)
+}
+
+// quietNotify 如果当前处于群的免打扰时段，暂存或者丢弃这条推送，返回true表示推送已被处理，
+// 免打扰时段只能在QQ群内设置
+func (l *Lsp) quietNotify(target mmsg.TargetId, m *mmsg.MSG) bool {
+	if !target.TargetType().IsGroup() {
+		return false
+	}
+	quiet := l.LspStateManager.GetQuietHours(target.TargetCode())
+	if !quiet.InQuiet(time.Now()) {
+		return false
+	}
+	log := logger.WithFields(mmsg.TargetLogFields(target))
+	if quiet.Drop {
+		log.Info("免打扰时段内，丢弃本次推送")
+		return true
+	}
+	record := newPushItemRecord(&PushItem{
+		Id:       fmt.Sprintf("%v", time.Now().UnixNano()),
+		Target:   target,
+		Priority: PushPriorityNormal,
+		MSG:      m,
+	})
+	if err := l.LspStateManager.SaveQuietItem(record); err != nil {
+		log.Errorf("SaveQuietItem error %v", err)
+		return false
+	}
+	log.Info("免打扰时段内，推送已暂存")
+	return true
+}
+
+// QuietDigest 定期检查免打扰时段已经结束的群，把暂存的推送汇总后发送
+func (l *Lsp) QuietDigest() {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).Errorf("quiet digest recoverd %v", err)
+			go l.QuietDigest()
+		}
+	}()
+	ticker := time.NewTicker(quietDigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.sendQuietDigest(time.Now())
+		}
+	}
+}
+
+func (l *Lsp) sendQuietDigest(now time.Time) {
+	groups, err := l.LspStateManager.ListQuietGroup()
+	if err != nil {
+		logger.Errorf("ListQuietGroup error %v", err)
+		return
+	}
+	for _, groupCode := range groups {
+		if l.LspStateManager.GetQuietHours(groupCode).InQuiet(now) {
+			continue
+		}
+		records, err := l.LspStateManager.PopQuietItem(groupCode)
+		if err != nil {
+			logger.WithFields(localutils.GroupLogFields(groupCode)).Errorf("PopQuietItem error %v", err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+		logger.WithFields(localutils.GroupLogFields(groupCode)).
+			WithField("Size", len(records)).Info("免打扰时段结束，发送汇总推送")
+		for _, m := range newQuietDigest(records) {
+			l.pushQueue.Push(&PushItem{
+				Target:   mmsg.NewGroupTargetId(groupCode),
+				Priority: PushPriorityNormal,
+				MSG:      m,
+			})
+		}
+	}
+}
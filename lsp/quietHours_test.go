@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseQuietRange(t *testing.T) {
+	start, end, err := parseQuietRange("23:00-07:30")
+	assert.Nil(t, err)
+	assert.Equal(t, 23*60, start)
+	assert.Equal(t, 7*60+30, end)
+
+	for _, s := range []string{"", "23:00", "24:00-01:00", "01:60-02:00", "a:00-01:00", "01:00-01:00"} {
+		_, _, err = parseQuietRange(s)
+		assert.Equal(t, ErrInvalidQuietRange, err, s)
+	}
+}
+
+func TestQuietHoursConfig_InQuiet(t *testing.T) {
+	var at = func(hour, minute int) time.Time {
+		return time.Date(2021, 10, 15, hour, minute, 0, 0, time.Local)
+	}
+	var nilConfig *QuietHoursConfig
+	assert.False(t, nilConfig.InQuiet(at(0, 0)))
+
+	q := &QuietHoursConfig{Ranges: []string{"23:00-07:00", "12:00-13:00"}}
+	assert.True(t, q.InQuiet(at(23, 0)))
+	assert.True(t, q.InQuiet(at(3, 0)))
+	assert.False(t, q.InQuiet(at(7, 0)))
+	assert.True(t, q.InQuiet(at(12, 30)))
+	assert.False(t, q.InQuiet(at(13, 0)))
+	assert.False(t, q.InQuiet(at(18, 0)))
+}
+
+func TestStateManager_QuietHours(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.Empty(t, sm.GetQuietHours(test.G1).Ranges)
+	assert.Nil(t, sm.SetQuietHours(test.G1, &QuietHoursConfig{Ranges: []string{"23:00-07:00"}, Drop: true}))
+	q := sm.GetQuietHours(test.G1)
+	assert.EqualValues(t, []string{"23:00-07:00"}, q.Ranges)
+	assert.True(t, q.Drop)
+	assert.Nil(t, sm.SetQuietHours(test.G1, nil))
+	assert.Empty(t, sm.GetQuietHours(test.G1).Ranges)
+	assert.Nil(t, sm.SetQuietHours(test.G1, nil))
+
+	for _, id := range []string{"1", "2"} {
+		assert.Nil(t, sm.SaveQuietItem(&pushItemRecord{Id: id, Target: mmsg.NewGroupTargetId(test.G1)}))
+	}
+	assert.Nil(t, sm.SaveQuietItem(&pushItemRecord{Id: "3", Target: mmsg.NewGroupTargetId(test.G2)}))
+	groups, err := sm.ListQuietGroup()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int64{test.G1, test.G2}, groups)
+
+	records, err := sm.PopQuietItem(test.G1)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].Id)
+	records, err = sm.PopQuietItem(test.G1)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+	groups, err = sm.ListQuietGroup()
+	assert.Nil(t, err)
+	assert.EqualValues(t, []int64{test.G2}, groups)
+}
+
+func TestLsp_QuietNotify(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	l := &Lsp{LspStateManager: newStateManager(t)}
+	l.pushQueue = newTestPushQueue(t, sender)
+	l.pushQueue.Start()
+	defer l.pushQueue.Stop()
+
+	assert.False(t, l.quietNotify(mmsg.NewGroupTargetId(test.G1), mmsg.NewText("a")))
+
+	// 全天免打扰
+	assert.Nil(t, l.LspStateManager.SetQuietHours(test.G1, &QuietHoursConfig{Ranges: []string{"00:00-23:59"}}))
+	var now = time.Now()
+	if now.Hour() == 23 && now.Minute() == 59 {
+		t.Skip("not in quiet range")
+	}
+	assert.True(t, l.quietNotify(mmsg.NewGroupTargetId(test.G1), mmsg.NewText("a")))
+	assert.True(t, l.quietNotify(mmsg.NewGroupTargetId(test.G1), mmsg.NewText("b").Cut().Text("c")))
+
+	// 还在免打扰时段内，不发送
+	l.sendQuietDigest(now)
+	groups, err := l.LspStateManager.ListQuietGroup()
+	assert.Nil(t, err)
+	assert.EqualValues(t, []int64{test.G1}, groups)
+
+	assert.Nil(t, l.LspStateManager.SetQuietHours(test.G1, nil))
+	l.sendQuietDigest(now)
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, "免打扰时段内共有2条推送：\na\nbc", sender.Result()[0])
+
+	// drop模式直接丢弃
+	assert.Nil(t, l.LspStateManager.SetQuietHours(test.G1, &QuietHoursConfig{Ranges: []string{"00:00-23:59"}, Drop: true}))
+	assert.True(t, l.quietNotify(mmsg.NewGroupTargetId(test.G1), mmsg.NewText("a")))
+	groups, err = l.LspStateManager.ListQuietGroup()
+	assert.Nil(t, err)
+	assert.Empty(t, groups)
+}
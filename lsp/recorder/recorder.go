@@ -0,0 +1,404 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"go.uber.org/atomic"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = utils.GetModuleLogger("recorder")
+
+const (
+	// partSuffix 正在录制的文件后缀，录制结束后会去掉
+	partSuffix = ".part"
+	flvSuffix  = ".flv"
+
+	// quotaCheckBytes 每写入这么多数据检查一次空间上限
+	quotaCheckBytes = 32 << 20
+	// maxRetry 连续多少次没有录制到数据后放弃
+	maxRetry = 3
+)
+
+var ErrQuotaExceeded = errors.New("录制文件超过空间上限")
+
+// Task 一个录制任务
+type Task struct {
+	Site string
+	Id   string
+	Name string
+	// StreamUrl 每次连接前获取直播流地址，直播流地址一般有时效，断线重连时需要重新获取
+	StreamUrl func() (string, error)
+	// Header 请求直播流时附加的header，部分网站需要Referer
+	Header map[string]string
+}
+
+func (t *Task) key() string {
+	return t.Site + "/" + t.Id
+}
+
+// Record 一个录制文件
+type Record struct {
+	Site      string
+	Id        string
+	Name      string
+	Path      string
+	Size      int64
+	ModTime   time.Time
+	Recording bool
+}
+
+type recording struct {
+	task   *Task
+	cancel context.CancelFunc
+	path   string
+}
+
+// Recorder 管理所有正在进行的录制，同一个site和id同时只会有一个录制
+type Recorder struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	dir   string
+	quota int64
+	tasks map[string]*recording
+	wg    sync.WaitGroup
+
+	retryInterval time.Duration
+}
+
+// New 创建一个Recorder，quota的单位为字节，0表示不限制
+func New(dir string, quota int64) *Recorder {
+	return &Recorder{
+		client:        &http.Client{},
+		dir:           dir,
+		quota:         quota,
+		tasks:         make(map[string]*recording),
+		retryInterval: time.Second * 10,
+	}
+}
+
+// SetConfig 更新保存目录与空间上限，只对之后开始的录制生效
+func (r *Recorder) SetConfig(dir string, quota int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dir = dir
+	r.quota = quota
+}
+
+// Start 开始录制，如果已经在录制则返回false
+func (r *Recorder) Start(task *Task) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.tasks[task.key()]; found {
+		return false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := &recording{task: task, cancel: cancel}
+	r.tasks[task.key()] = rec
+	r.wg.Add(1)
+	go r.run(ctx, rec)
+	return true
+}
+
+// Stop 停止录制，如果没有在录制则返回false
+func (r *Recorder) Stop(site string, id string) bool {
+	r.mu.Lock()
+	rec, found := r.tasks[site+"/"+id]
+	r.mu.Unlock()
+	if !found {
+		return false
+	}
+	rec.cancel()
+	return true
+}
+
+// IsRecording 是否正在录制
+func (r *Recorder) IsRecording(site string, id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, found := r.tasks[site+"/"+id]
+	return found
+}
+
+// StopAll 停止所有录制并等待文件写入完毕
+func (r *Recorder) StopAll() {
+	r.mu.Lock()
+	for _, rec := range r.tasks {
+		rec.cancel()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+func (r *Recorder) run(ctx context.Context, rec *recording) {
+	defer r.wg.Done()
+	defer func() {
+		r.mu.Lock()
+		delete(r.tasks, rec.task.key())
+		r.mu.Unlock()
+	}()
+	log := logger.WithField("Site", rec.task.Site).
+		WithField("Id", rec.task.Id).
+		WithField("Name", rec.task.Name)
+	log.Info("开始录制直播")
+	defer log.Info("直播录制结束")
+
+	var failed int
+	for {
+		n, err := r.record(ctx, rec)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == ErrQuotaExceeded {
+			log.Errorf("录制文件超过空间上限，停止录制")
+			return
+		}
+		if err != nil {
+			log.Errorf("录制中断 %v", err)
+		}
+		if n == 0 {
+			failed++
+		} else {
+			failed = 0
+		}
+		if failed >= maxRetry {
+			log.Errorf("连续%v次没有录制到数据，停止录制", failed)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.retryInterval):
+		}
+	}
+}
+
+// record 录制到一个新文件，直到直播流结束或者ctx被取消，返回写入的字节数
+func (r *Recorder) record(ctx context.Context, rec *recording) (int64, error) {
+	r.mu.Lock()
+	dir := r.dir
+	r.mu.Unlock()
+
+	if err := r.checkQuota(); err != nil {
+		return 0, err
+	}
+	streamUrl, err := rec.task.StreamUrl()
+	if err != nil {
+		return 0, fmt.Errorf("获取直播流地址失败 %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamUrl, nil)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range rec.task.Header {
+		req.Header.Set(k, v)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("直播流返回了错误的状态码 %v", resp.StatusCode)
+	}
+
+	taskDir := filepath.Join(dir, rec.task.Site, rec.task.Id)
+	if err = os.MkdirAll(taskDir, 0755); err != nil {
+		return 0, err
+	}
+	path := filepath.Join(taskDir, time.Now().Format("20060102-150405")+flvSuffix)
+	f, err := os.Create(path + partSuffix)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	rec.path = path + partSuffix
+	r.mu.Unlock()
+
+	n, err := io.Copy(&quotaWriter{w: f, r: r}, resp.Body)
+	if ctx.Err() != nil {
+		// 主动停止时连接会被关闭，不算错误
+		err = nil
+	}
+	f.Close()
+
+	r.mu.Lock()
+	rec.path = ""
+	r.mu.Unlock()
+
+	if n == 0 {
+		os.Remove(path + partSuffix)
+		return 0, err
+	}
+	if rerr := os.Rename(path+partSuffix, path); rerr != nil && err == nil {
+		err = rerr
+	}
+	return n, err
+}
+
+// quotaWriter 每写入一定数据检查一次空间上限
+type quotaWriter struct {
+	w       io.Writer
+	r       *Recorder
+	written int64
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	n, err := q.w.Write(p)
+	q.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if q.written >= quotaCheckBytes {
+		q.written = 0
+		if err = q.r.checkQuota(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// checkQuota 超过空间上限时从最早的录制文件开始删除，正在录制的文件不会被删除，
+// 如果删除后仍然超过上限则返回 ErrQuotaExceeded
+func (r *Recorder) checkQuota() error {
+	r.mu.Lock()
+	quota := r.quota
+	r.mu.Unlock()
+	if quota <= 0 {
+		return nil
+	}
+	records, err := r.List()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, record := range records {
+		total += record.Size
+	}
+	// List按时间倒序，从最后开始删除
+	for i := len(records) - 1; i >= 0 && total > quota; i-- {
+		if records[i].Recording {
+			continue
+		}
+		if err := os.Remove(records[i].Path); err != nil {
+			logger.WithField("Path", records[i].Path).Errorf("删除录制文件失败 %v", err)
+			continue
+		}
+		logger.WithField("Path", records[i].Path).Info("超过空间上限，已删除最早的录制文件")
+		total -= records[i].Size
+	}
+	if total > quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// List 列出所有录制文件，按时间从新到旧排序
+func (r *Recorder) List() ([]*Record, error) {
+	r.mu.Lock()
+	dir := r.dir
+	var active = make(map[string]bool)
+	for _, rec := range r.tasks {
+		if rec.path != "" {
+			active[rec.path] = true
+		}
+	}
+	r.mu.Unlock()
+
+	var result []*Record
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, flvSuffix) && !strings.HasSuffix(path, flvSuffix+partSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 3 {
+			return nil
+		}
+		result = append(result, &Record{
+			Site:      parts[0],
+			Id:        parts[1],
+			Name:      parts[2],
+			Path:      path,
+			Size:      info.Size(),
+			ModTime:   info.ModTime(),
+			Recording: active[path],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ModTime.After(result[j].ModTime)
+	})
+	return result, nil
+}
+
+var (
+	defaultRecorder = New("", 0)
+	// paused 录制模块在配置中禁用时暂停，暂停期间不会开始新的录制
+	paused atomic.Bool
+)
+
+func getDefault() *Recorder {
+	defaultRecorder.SetConfig(cfg.GetRecordDir(), cfg.GetRecordQuota()<<20)
+	return defaultRecorder
+}
+
+// Start 使用配置文件中的 record 配置开始录制，没有开启 record.enable 或者已经暂停时不会录制
+func Start(task *Task) bool {
+	if !cfg.GetRecordEnable() || paused.Load() {
+		return false
+	}
+	return getDefault().Start(task)
+}
+
+// Stop 停止录制
+func Stop(site string, id string) bool {
+	return defaultRecorder.Stop(site, id)
+}
+
+// StopAll 停止所有录制
+func StopAll() {
+	defaultRecorder.StopAll()
+}
+
+// Pause 停止所有录制，并且在 Resume 之前不再开始新的录制
+func Pause() {
+	paused.Store(true)
+	defaultRecorder.StopAll()
+}
+
+// Resume 恢复录制，已经停止的录制会在下一次开播时重新开始
+func Resume() {
+	paused.Store(false)
+}
+
+// List 列出所有录制文件
+func List() ([]*Record, error) {
+	return getDefault().List()
+}
@@ -0,0 +1,135 @@
+package recorder
+
+import (
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTask(url string) *Task {
+	return &Task{
+		Site: "test",
+		Id:   "1",
+		Name: "name",
+		StreamUrl: func() (string, error) {
+			return url, nil
+		},
+		Header: map[string]string{"Referer": "test"},
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var release = make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "test", req.Header.Get("Referer"))
+		w.Write([]byte("FLV"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	dir := t.TempDir()
+	r := New(dir, 0)
+	r.retryInterval = time.Hour
+
+	assert.True(t, r.Start(newTask(server.URL)))
+	assert.False(t, r.Start(newTask(server.URL)))
+	assert.True(t, r.IsRecording("test", "1"))
+
+	assert.Eventually(t, func() bool {
+		records, err := r.List()
+		return err == nil && len(records) == 1 && records[0].Size == 3
+	}, time.Second*5, time.Millisecond*50)
+	records, err := r.List()
+	assert.Nil(t, err)
+	assert.True(t, records[0].Recording)
+	assert.Equal(t, "test", records[0].Site)
+	assert.Equal(t, "1", records[0].Id)
+
+	assert.True(t, r.Stop("test", "1"))
+	r.StopAll()
+	assert.False(t, r.IsRecording("test", "1"))
+	assert.False(t, r.Stop("test", "1"))
+
+	records, err = r.List()
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.False(t, records[0].Recording)
+	assert.Equal(t, flvSuffix, filepath.Ext(records[0].Path))
+}
+
+func TestRecorder_Retry(t *testing.T) {
+	var count int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	r := New(t.TempDir(), 0)
+	r.retryInterval = time.Millisecond
+	assert.True(t, r.Start(newTask(server.URL)))
+	r.wg.Wait()
+	assert.Equal(t, maxRetry, count)
+	assert.False(t, r.IsRecording("test", "1"))
+	records, err := r.List()
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+}
+
+func TestRecorder_CheckQuota(t *testing.T) {
+	dir := t.TempDir()
+	r := New(dir, 10)
+	assert.Nil(t, r.checkQuota())
+
+	var now = time.Now()
+	for idx, name := range []string{"a.flv", "b.flv", "c.flv"} {
+		path := filepath.Join(dir, "test", "1", name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte("1234"), 0644))
+		mt := now.Add(time.Duration(idx) * time.Minute)
+		assert.Nil(t, os.Chtimes(path, mt, mt))
+	}
+	assert.Nil(t, r.checkQuota())
+	records, err := r.List()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "c.flv", records[0].Name)
+	assert.Equal(t, "b.flv", records[1].Name)
+
+	r.SetConfig(dir, 1)
+	assert.Equal(t, ErrQuotaExceeded, func() error {
+		// 模拟正在录制的文件不会被删除
+		r.tasks["test/1"] = &recording{path: records[0].Path}
+		defer delete(r.tasks, "test/1")
+		return r.checkQuota()
+	}())
+	records, err = r.List()
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "c.flv", records[0].Name)
+}
+
+func TestPause(t *testing.T) {
+	config.GlobalConfig.Set("record.enable", true)
+	config.GlobalConfig.Set("record.dir", t.TempDir())
+	defer config.GlobalConfig.Set("record", nil)
+	defer StopAll()
+
+	Pause()
+	assert.False(t, Start(newTask("http://127.0.0.1:1")))
+	Resume()
+	assert.True(t, Start(newTask("http://127.0.0.1:1")))
+	Pause()
+	assert.False(t, defaultRecorder.IsRecording("test", "1"))
+	Resume()
+}
@@ -0,0 +1,276 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/lsp/template"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/robfig/cron/v3"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 每个群最多可以设置的定时提醒数量
+const maxGroupReminder = 20
+
+var ErrReminderNotFound = errors.New("定时提醒不存在")
+
+// Reminder 群内的定时提醒，按照cron表达式定时在群内发送模板生成的消息，例如每日公告、每周直播时间表
+type Reminder struct {
+	Id        int64 `json:"id"`
+	GroupCode int64 `json:"group_code"`
+	// Cron 标准的5段cron表达式，例如 "0 20 * * 5" 表示每周五20点
+	Cron string `json:"cron"`
+	// Template 消息模板，语法与自定义模板相同
+	Template   string `json:"template"`
+	Creator    int64  `json:"creator"`
+	CreateTime int64  `json:"create_time"`
+}
+
+// AddReminder 保存一个新的定时提醒并分配id
+func (s *StateManager) AddReminder(reminder *Reminder) error {
+	return s.RWCover(func() error {
+		reminders, err := s.ListReminder(reminder.GroupCode)
+		if err != nil {
+			return err
+		}
+		if len(reminders) >= maxGroupReminder {
+			return fmt.Errorf("每个群最多设置%v个定时提醒", maxGroupReminder)
+		}
+		id, err := s.SeqNext(s.GroupReminderSeqKey())
+		if err != nil {
+			return err
+		}
+		reminder.Id = id
+		if reminder.CreateTime == 0 {
+			reminder.CreateTime = time.Now().Unix()
+		}
+		return s.SetJson(s.GroupReminderKey(reminder.GroupCode, reminder.Id), reminder)
+	})
+}
+
+// GetReminder 返回群内的一个定时提醒，不存在时返回 ErrReminderNotFound
+func (s *StateManager) GetReminder(groupCode int64, id int64) (*Reminder, error) {
+	var reminder = new(Reminder)
+	err := s.GetJson(s.GroupReminderKey(groupCode, id), reminder)
+	if localdb.IsNotFound(err) {
+		return nil, ErrReminderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+// DeleteReminder 删除群内的一个定时提醒，不存在时返回 ErrReminderNotFound
+func (s *StateManager) DeleteReminder(groupCode int64, id int64) error {
+	_, err := s.Delete(s.GroupReminderKey(groupCode, id))
+	if localdb.IsNotFound(err) {
+		return ErrReminderNotFound
+	}
+	return err
+}
+
+// ListReminder 按id顺序返回群内的所有定时提醒，groupCode为0时返回所有群的定时提醒
+func (s *StateManager) ListReminder(groupCode int64) ([]*Reminder, error) {
+	var pattern = s.GroupReminderKey(groupCode, "*")
+	if groupCode == 0 {
+		pattern = s.GroupReminderKey("*")
+	}
+	var result []*Reminder
+	err := s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(pattern, func(key, value string) bool {
+			var reminder = new(Reminder)
+			if iterErr = json.Unmarshal([]byte(value), reminder); iterErr != nil {
+				return false
+			}
+			result = append(result, reminder)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result, nil
+}
+
+func reminderTemplateName(id int64) string {
+	return fmt.Sprintf("reminder.%v.tmpl", id)
+}
+
+// checkReminder 检查cron表达式与模板是否可以正确解析
+func checkReminder(spec string, text string) error {
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return fmt.Errorf("cron表达式错误 %v", err)
+	}
+	if len(strings.TrimSpace(text)) == 0 {
+		return errors.New("提醒内容不能为空")
+	}
+	if _, err := template.New(reminderTemplateName(0)).Parse(text); err != nil {
+		return fmt.Errorf("模板解析失败 %v", err)
+	}
+	return nil
+}
+
+// newReminderMsg 执行提醒的模板，模板中可以使用 .group_code .group_name .id
+func newReminderMsg(reminder *Reminder) (*mmsg.MSG, error) {
+	t, err := template.New(reminderTemplateName(reminder.Id)).Parse(reminder.Template)
+	if err != nil {
+		return nil, err
+	}
+	var data = map[string]interface{}{
+		"id":         reminder.Id,
+		"group_code": reminder.GroupCode,
+		"group_name": "",
+	}
+	if gi := localutils.GetBot().FindGroup(reminder.GroupCode); gi != nil {
+		data["group_name"] = gi.Name
+	}
+	var m = mmsg.NewMSG()
+	if err = t.Execute(m, data); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type reminderJob struct {
+	l         *Lsp
+	groupCode int64
+	id        int64
+}
+
+func (r *reminderJob) Run() {
+	log := cronLog.WithFields(localutils.GroupLogFields(r.groupCode)).WithField("reminder_id", r.id)
+	// 每次执行时重新读取，群被清除或者提醒被删除后不再发送
+	reminder, err := r.l.LspStateManager.GetReminder(r.groupCode, r.id)
+	if err != nil {
+		if err != ErrReminderNotFound {
+			log.Errorf("GetReminder error %v", err)
+		}
+		return
+	}
+	m, err := newReminderMsg(reminder)
+	if err != nil {
+		log.Errorf("定时提醒模板执行失败 %v", err)
+		return
+	}
+	if len(m.Elements()) == 0 {
+		return
+	}
+	log.Info("发送定时提醒")
+	r.l.SendMsg(m, mmsg.NewGroupTarget(r.groupCode))
+}
+
+// reminderReload 添加所有群的定时提醒，需要在清空定时任务后调用
+func (l *Lsp) reminderReload() {
+	reminders, err := l.LspStateManager.ListReminder(0)
+	if err != nil {
+		cronLog.Errorf("ListReminder error %v", err)
+		return
+	}
+	for _, reminder := range reminders {
+		if _, err := l.cron.AddJob(reminder.Cron, &reminderJob{l: l, groupCode: reminder.GroupCode, id: reminder.Id}); err != nil {
+			cronLog.WithFields(localutils.GroupLogFields(reminder.GroupCode)).
+				WithField("reminder_id", reminder.Id).
+				WithField("cron_exp", reminder.Cron).
+				Errorf("添加定时提醒失败：%v", err)
+		}
+	}
+}
+
+func formatReminder(reminders []*Reminder) string {
+	if len(reminders) == 0 {
+		return "当前没有设置定时提醒"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("当前共有%v个定时提醒：", len(reminders)))
+	for _, reminder := range reminders {
+		sb.WriteString(fmt.Sprintf("\nid：%v cron：%v\n%v", reminder.Id, reminder.Cron, reminder.Template))
+	}
+	return sb.String()
+}
+
+// IReminderCmd 管理群的定时提醒，add时args[0]为cron表达式，delete时args为要删除的提醒id
+func IReminderCmd(c *MessageContext, groupCode int64, action string, args []string, text string) {
+	if groupCode == 0 {
+		c.TextReply("失败 - 请指定要操作的QQ群号码")
+		return
+	}
+	if !c.Lsp.PermissionStateManager.RequireAny(
+		permission.AdminRoleRequireOption(c.Sender.Uin),
+		permission.GroupAdminRoleRequireOption(groupCode, c.Sender.Uin),
+	) {
+		c.NoPermissionReply()
+		return
+	}
+	sm := c.Lsp.LspStateManager
+	switch action {
+	case "list":
+		reminders, err := sm.ListReminder(groupCode)
+		if err != nil {
+			c.Log.Errorf("ListReminder error %v", err)
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+		c.TextReply(formatReminder(reminders))
+		return
+	case "add":
+		if len(args) == 0 {
+			c.TextReply("失败 - 请输入cron表达式和提醒内容，cron表达式包含空格时需要使用引号")
+			return
+		}
+		if err := checkReminder(args[0], text); err != nil {
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+		var reminder = &Reminder{
+			GroupCode: groupCode,
+			Cron:      args[0],
+			Template:  text,
+			Creator:   c.Sender.Uin,
+		}
+		if err := sm.AddReminder(reminder); err != nil {
+			c.Log.Errorf("AddReminder error %v", err)
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+			return
+		}
+		c.Lsp.CronjobReload()
+		c.audit(groupCode)
+		c.TextReply(fmt.Sprintf("成功 - 定时提醒id为%v", reminder.Id))
+	case "delete":
+		if len(args) == 0 {
+			c.TextReply("失败 - 没有要删除的提醒id")
+			return
+		}
+		for _, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				c.TextReply(fmt.Sprintf("失败 - 无效的提醒id：%v", arg))
+				return
+			}
+			if err = sm.DeleteReminder(groupCode, id); err != nil {
+				c.TextReply(fmt.Sprintf("失败 - %v：%v", arg, err))
+				return
+			}
+		}
+		c.Lsp.CronjobReload()
+		c.audit(groupCode)
+		c.TextReply("成功")
+	default:
+		c.Log.Errorf("unknown action")
+		c.TextReply("失败 - 未知操作")
+	}
+}
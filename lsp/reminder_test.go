@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateManager_Reminder(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+
+	reminders, err := sm.ListReminder(test.G1)
+	assert.Nil(t, err)
+	assert.Empty(t, reminders)
+	assert.Equal(t, "当前没有设置定时提醒", formatReminder(reminders))
+
+	r1 := &Reminder{GroupCode: test.G1, Cron: "0 20 * * 5", Template: "a"}
+	r2 := &Reminder{GroupCode: test.G2, Cron: "0 9 * * *", Template: "b"}
+	r3 := &Reminder{GroupCode: test.G1, Cron: "@daily", Template: "c"}
+	assert.Nil(t, sm.AddReminder(r1))
+	assert.Nil(t, sm.AddReminder(r2))
+	assert.Nil(t, sm.AddReminder(r3))
+	assert.EqualValues(t, 1, r1.Id)
+	assert.EqualValues(t, 3, r3.Id)
+	assert.NotZero(t, r1.CreateTime)
+
+	reminders, err = sm.ListReminder(test.G1)
+	assert.Nil(t, err)
+	if assert.Len(t, reminders, 2) {
+		assert.Equal(t, "a", reminders[0].Template)
+		assert.Equal(t, "c", reminders[1].Template)
+	}
+	assert.Contains(t, formatReminder(reminders), "0 20 * * 5")
+
+	reminders, err = sm.ListReminder(0)
+	assert.Nil(t, err)
+	assert.Len(t, reminders, 3)
+
+	r, err := sm.GetReminder(test.G2, r2.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", r.Template)
+	_, err = sm.GetReminder(test.G1, r2.Id)
+	assert.Equal(t, ErrReminderNotFound, err)
+
+	assert.Equal(t, ErrReminderNotFound, sm.DeleteReminder(test.G1, r2.Id))
+	assert.Nil(t, sm.DeleteReminder(test.G1, r1.Id))
+	reminders, err = sm.ListReminder(test.G1)
+	assert.Nil(t, err)
+	assert.Len(t, reminders, 1)
+
+	for i := 0; i < maxGroupReminder-1; i++ {
+		assert.Nil(t, sm.AddReminder(&Reminder{GroupCode: test.G1, Cron: "@daily", Template: "d"}))
+	}
+	assert.NotNil(t, sm.AddReminder(&Reminder{GroupCode: test.G1, Cron: "@daily", Template: "d"}))
+
+	assert.Contains(t, sm.GroupKeyPrefix(test.G1), sm.GroupReminderKey(test.G1))
+}
+
+func TestCheckReminder(t *testing.T) {
+	assert.Nil(t, checkReminder("0 20 * * 5", "直播"))
+	assert.NotNil(t, checkReminder("0 20 * *", "直播"))
+	assert.NotNil(t, checkReminder("0 20 * * 5", " "))
+	assert.NotNil(t, checkReminder("0 20 * * 5", "{{ .a "))
+
+	m, err := newReminderMsg(&Reminder{Id: 1, GroupCode: test.G1, Template: "{{ .group_code }} {{ .id }}"})
+	assert.Nil(t, err)
+	assert.Equal(t, "123456 1", msgstringer.MsgToString(m.Elements()))
+}
+
+func TestIReminderCmd(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	ctx.Command = "/remind add"
+
+	IReminderCmd(ctx, test.G1, "list", nil, "")
+	assert.Equal(t, "no permission", msgstringer.MsgToString((<-msgChan).Elements()))
+
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+
+	IReminderCmd(ctx, 0, "list", nil, "")
+	assert.Contains(t, msgstringer.MsgToString((<-msgChan).Elements()), "请指定")
+
+	IReminderCmd(ctx, test.G1, "add", []string{"bad cron"}, "a")
+	assert.Contains(t, msgstringer.MsgToString((<-msgChan).Elements()), "cron表达式错误")
+
+	IReminderCmd(ctx, test.G1, "add", []string{"0 20 * * 5", "直播"}, "直播")
+	assert.Contains(t, msgstringer.MsgToString((<-msgChan).Elements()), "成功")
+	assert.Len(t, Instance.cron.Entries(), 1)
+
+	IReminderCmd(ctx, test.G1, "list", nil, "")
+	assert.Contains(t, msgstringer.MsgToString((<-msgChan).Elements()), "直播")
+
+	IReminderCmd(ctx, test.G1, "delete", []string{"x"}, "")
+	assert.Contains(t, msgstringer.MsgToString((<-msgChan).Elements()), "无效")
+
+	IReminderCmd(ctx, test.G1, "delete", []string{"1"}, "")
+	assert.Equal(t, "成功", msgstringer.MsgToString((<-msgChan).Elements()))
+	assert.Empty(t, Instance.cron.Entries())
+
+	logs, err := Instance.LspStateManager.ListAuditLog(10)
+	assert.Nil(t, err)
+	assert.Len(t, logs, 2)
+}
@@ -0,0 +1,194 @@
+package lsp
+
+import (
+	"errors"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrScoreNotEnough = errors.New("积分不足")
+
+// 连续签到的额外奖励上限，签到获得的积分为 min(连续签到天数, maxCheckinGain)
+const maxCheckinGain = 5
+
+const scoreLedgerRetention = time.Hour * 24 * 30
+
+// ScoreLedger 积分流水
+type ScoreLedger struct {
+	Time    int64  `json:"time"`
+	Delta   int64  `json:"delta"`
+	Balance int64  `json:"balance"`
+	Reason  string `json:"reason"`
+}
+
+// ScoreRank 积分排行
+type ScoreRank struct {
+	Uin   int64
+	Score int64
+}
+
+// CheckinResult 签到结果，Success 为 false 表示今天已经签到过
+type CheckinResult struct {
+	Success bool
+	Score   int64
+	Gain    int64
+	Streak  int64
+}
+
+// Checkin 在群内签到，每天只有第一次签到成功
+func (s *StateManager) Checkin(groupCode int64, uin int64) (*CheckinResult, error) {
+	return s.checkin(groupCode, uin, time.Now())
+}
+
+func (s *StateManager) checkin(groupCode int64, uin int64, now time.Time) (*CheckinResult, error) {
+	var result = new(CheckinResult)
+	err := s.RWCover(func() error {
+		var err error
+		date := now.Format("20060102")
+		yesterday := now.AddDate(0, 0, -1).Format("20060102")
+		dateMarker := s.ScoreDateKey(groupCode, uin, date)
+
+		result.Score, err = s.GetInt64(s.ScoreKey(groupCode, uin), localdb.IgnoreNotFoundOpt())
+		if err != nil {
+			return err
+		}
+		result.Streak, err = s.GetInt64(s.ScoreStreakKey(groupCode, uin), localdb.IgnoreNotFoundOpt())
+		if err != nil {
+			return err
+		}
+		if s.Exist(dateMarker) {
+			return nil
+		}
+		if s.Exist(s.ScoreDateKey(groupCode, uin, yesterday)) {
+			result.Streak += 1
+		} else {
+			result.Streak = 1
+		}
+		result.Gain = result.Streak
+		if result.Gain > maxCheckinGain {
+			result.Gain = maxCheckinGain
+		}
+		result.Score, err = s.changeScore(groupCode, uin, result.Gain, "签到", now)
+		if err != nil {
+			return err
+		}
+		err = s.SetInt64(s.ScoreStreakKey(groupCode, uin), result.Streak)
+		if err != nil {
+			return err
+		}
+		err = s.Set(dateMarker, "", localdb.SetExpireOpt(time.Hour*24*3))
+		if err != nil {
+			return err
+		}
+		result.Success = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetScore 查询积分
+func (s *StateManager) GetScore(groupCode int64, uin int64) (int64, error) {
+	return s.GetInt64(s.ScoreKey(groupCode, uin), localdb.IgnoreNotFoundOpt())
+}
+
+// SpendScore 消耗积分，积分不足时返回 ErrScoreNotEnough
+func (s *StateManager) SpendScore(groupCode int64, uin int64, cost int64, reason string) (int64, error) {
+	var score int64
+	err := s.RWCover(func() error {
+		var err error
+		score, err = s.GetScore(groupCode, uin)
+		if err != nil {
+			return err
+		}
+		if score < cost {
+			return ErrScoreNotEnough
+		}
+		score, err = s.changeScore(groupCode, uin, -cost, reason, time.Now())
+		return err
+	})
+	return score, err
+}
+
+func (s *StateManager) changeScore(groupCode int64, uin int64, delta int64, reason string, now time.Time) (int64, error) {
+	var score int64
+	err := s.RWCover(func() error {
+		var err error
+		score, err = s.IncInt64(s.ScoreKey(groupCode, uin), delta)
+		if err != nil {
+			return err
+		}
+		return s.SetJson(s.ScoreLedgerKey(groupCode, uin, now.UnixNano()), &ScoreLedger{
+			Time:    now.Unix(),
+			Delta:   delta,
+			Balance: score,
+			Reason:  reason,
+		}, localdb.SetExpireOpt(scoreLedgerRetention))
+	})
+	return score, err
+}
+
+// ListScoreLedger 按时间从新到旧列出至多limit条积分流水
+func (s *StateManager) ListScoreLedger(groupCode int64, uin int64, limit int) (result []*ScoreLedger, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.DescendKeys(s.ScoreLedgerKey(groupCode, uin, "*"), func(key, value string) bool {
+			if len(result) >= limit {
+				return false
+			}
+			var item = new(ScoreLedger)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			result = append(result, item)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// ListScoreRank 列出群内积分最高的至多limit个成员
+func (s *StateManager) ListScoreRank(groupCode int64, limit int) (result []*ScoreRank, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(s.ScoreKey(groupCode, "*"), func(key, value string) bool {
+			splits := strings.Split(key, ":")
+			if len(splits) != 3 {
+				return true
+			}
+			uin, err := strconv.ParseInt(splits[2], 10, 64)
+			if err != nil {
+				logger.WithField("Key", key).Errorf("Parse ScoreKey error %v", err)
+				return true
+			}
+			score, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				logger.WithField("Key", key).Errorf("Parse Score error %v", err)
+				return true
+			}
+			result = append(result, &ScoreRank{Uin: uin, Score: score})
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStateManager_Checkin(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.NotNil(t, sm)
+
+	now := time.Unix(test.TIMESTAMP1, 0)
+
+	result, err := sm.checkin(test.G1, test.UID1, now)
+	assert.Nil(t, err)
+	assert.True(t, result.Success)
+	assert.EqualValues(t, 1, result.Streak)
+	assert.EqualValues(t, 1, result.Gain)
+	assert.EqualValues(t, 1, result.Score)
+
+	result, err = sm.checkin(test.G1, test.UID1, now)
+	assert.Nil(t, err)
+	assert.False(t, result.Success)
+	assert.EqualValues(t, 1, result.Score)
+
+	for i := 1; i <= 6; i++ {
+		result, err = sm.checkin(test.G1, test.UID1, now.AddDate(0, 0, i))
+		assert.Nil(t, err)
+		assert.True(t, result.Success)
+		assert.EqualValues(t, i+1, result.Streak)
+	}
+	// 1+2+3+4+5+5+5
+	assert.EqualValues(t, maxCheckinGain, result.Gain)
+	assert.EqualValues(t, 25, result.Score)
+
+	// 断签后重新计算
+	result, err = sm.checkin(test.G1, test.UID1, now.AddDate(0, 0, 8))
+	assert.Nil(t, err)
+	assert.True(t, result.Success)
+	assert.EqualValues(t, 1, result.Streak)
+	assert.EqualValues(t, 26, result.Score)
+
+	score, err := sm.GetScore(test.G1, test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 26, score)
+
+	score, err = sm.GetScore(test.G2, test.UID1)
+	assert.Nil(t, err)
+	assert.Zero(t, score)
+
+	ledgers, err := sm.ListScoreLedger(test.G1, test.UID1, 3)
+	assert.Nil(t, err)
+	assert.Len(t, ledgers, 3)
+	assert.EqualValues(t, 1, ledgers[0].Delta)
+	assert.EqualValues(t, 26, ledgers[0].Balance)
+	assert.EqualValues(t, 5, ledgers[1].Delta)
+}
+
+func TestStateManager_SpendScore(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.NotNil(t, sm)
+
+	_, err := sm.SpendScore(test.G1, test.UID1, 1, test.CMD1)
+	assert.Equal(t, ErrScoreNotEnough, err)
+
+	_, err = sm.Checkin(test.G1, test.UID1)
+	assert.Nil(t, err)
+
+	score, err := sm.SpendScore(test.G1, test.UID1, 1, test.CMD1)
+	assert.Nil(t, err)
+	assert.Zero(t, score)
+
+	_, err = sm.SpendScore(test.G1, test.UID1, 1, test.CMD1)
+	assert.Equal(t, ErrScoreNotEnough, err)
+
+	ledgers, err := sm.ListScoreLedger(test.G1, test.UID1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, ledgers, 2)
+	assert.EqualValues(t, -1, ledgers[0].Delta)
+	assert.Equal(t, test.CMD1, ledgers[0].Reason)
+}
+
+func TestStateManager_ListScoreRank(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.NotNil(t, sm)
+
+	ranks, err := sm.ListScoreRank(test.G1, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, ranks)
+
+	now := time.Unix(test.TIMESTAMP1, 0)
+	_, err = sm.checkin(test.G1, test.UID1, now)
+	assert.Nil(t, err)
+	_, err = sm.checkin(test.G1, test.UID2, now)
+	assert.Nil(t, err)
+	_, err = sm.checkin(test.G1, test.UID2, now.AddDate(0, 0, 1))
+	assert.Nil(t, err)
+	_, err = sm.checkin(test.G2, test.UID3, now)
+	assert.Nil(t, err)
+
+	ranks, err = sm.ListScoreRank(test.G1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, ranks, 2)
+	assert.Equal(t, test.UID2, ranks[0].Uin)
+	assert.EqualValues(t, 3, ranks[0].Score)
+	assert.Equal(t, test.UID1, ranks[1].Uin)
+
+	ranks, err = sm.ListScoreRank(test.G1, 1)
+	assert.Nil(t, err)
+	assert.Len(t, ranks, 1)
+}
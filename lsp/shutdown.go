@@ -0,0 +1,44 @@
+package lsp
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"time"
+)
+
+var shutdownLog = utils.GetModuleLogger("shutdown")
+
+// Shutdown 请求bot退出，退出流程与收到SIGTERM相同，重复调用只有第一次生效
+func (l *Lsp) Shutdown(reason string) {
+	l.shutdownOnce.Do(func() {
+		shutdownLog.Infof("收到退出请求：%v", reason)
+		close(l.shutdown)
+	})
+}
+
+// ShutdownRequested 返回的channel在调用 Shutdown 后关闭
+func (l *Lsp) ShutdownRequested() <-chan struct{} {
+	return l.shutdown
+}
+
+// drainPushQueue 等待推送队列发送完毕，超时后未发送的推送会在下次启动后继续发送
+func (l *Lsp) drainPushQueue() {
+	var timeout = cfg.GetShutdownDrainTimeout()
+	shutdownLog.Debugf("等待推送队列发送完毕，最多等待%v", timeout)
+	if l.pushQueue.Drain(timeout) {
+		shutdownLog.Debug("推送队列已全部发送")
+	} else {
+		shutdownLog.Infof("推送队列还有%v条推送未发送，将在下次启动后继续发送", l.pushQueue.Len())
+	}
+}
+
+// shrinkDB 在关闭数据库前重写数据库文件，减少下次启动时加载的数据
+func (l *Lsp) shrinkDB() {
+	var start = time.Now()
+	if err := localdb.Shrink(); err != nil {
+		shutdownLog.Errorf("shrink数据库失败 %v", err)
+		return
+	}
+	shutdownLog.Debugf("shrink数据库完成，耗时%v", time.Since(start))
+}
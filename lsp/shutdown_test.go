@@ -0,0 +1,47 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/semaphore"
+	"testing"
+	"time"
+)
+
+func TestLsp_Shutdown(t *testing.T) {
+	l := &Lsp{shutdown: make(chan struct{})}
+	select {
+	case <-l.ShutdownRequested():
+		assert.Fail(t, "shutdown should not be requested")
+	default:
+	}
+	l.Shutdown("test")
+	l.Shutdown("test again")
+	select {
+	case <-l.ShutdownRequested():
+	case <-time.After(time.Second):
+		assert.Fail(t, "shutdown should be requested")
+	}
+}
+
+func TestLsp_ShutdownDrain(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	config.GlobalConfig.Set("shutdown.drainTimeout", "3s")
+	defer config.GlobalConfig.Set("shutdown", nil)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	l := &Lsp{}
+	l.pushQueue = NewPushQueue(newStateManager(t), semaphore.NewWeighted(1), sender.send)
+	l.pushQueue.groupInterval = time.Millisecond
+	l.pushQueue.Start()
+	l.pushQueue.Push(&PushItem{Target: mmsg.NewGroupTargetId(test.G1), MSG: mmsg.NewTextf("news")})
+	l.drainPushQueue()
+	l.pushQueue.Stop()
+	assert.EqualValues(t, []string{"news"}, sender.Result())
+
+	l.shrinkDB()
+}
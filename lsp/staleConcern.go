@@ -0,0 +1,141 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"runtime/debug"
+	"time"
+)
+
+// staleCheckInterval 检查失效订阅的间隔
+const staleCheckInterval = time.Hour
+
+// staleConcern 连续刷新失败次数超过 cfg.GetStaleThreshold 的订阅
+type staleConcern struct {
+	id         interface{}
+	name       string
+	errorCount int64
+	targets    []mmsg.TargetId
+	ctypes     []concern_type.Type
+}
+
+// StaleConcernCheck 定期检查连续刷新失败的订阅，通常是因为订阅的账号已经注销或者被封禁
+func (l *Lsp) StaleConcernCheck() {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).Errorf("stale concern check recoverd %v", err)
+			go l.StaleConcernCheck()
+		}
+	}()
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.checkStaleConcern()
+		}
+	}
+}
+
+func (l *Lsp) checkStaleConcern() {
+	threshold := cfg.GetStaleThreshold()
+	if threshold <= 0 {
+		return
+	}
+	for _, cm := range concern.ListConcern() {
+		l.checkStaleConcernSite(cm, threshold)
+	}
+}
+
+// checkStaleConcernSite 第一次发现失效的订阅时通知订阅的推送目标，
+// 如果开启了 staleCleanup.autoRemove ，下一次检查时仍然失效的订阅会被自动取消
+func (l *Lsp) checkStaleConcernSite(cm concern.Concern, threshold int64) {
+	site := cm.Site()
+	log := logger.WithField("Site", site)
+	sm := cm.GetStateManager()
+	targets, ids, ctypes, err := sm.ListConcernState(
+		func(mmsg.TargetId, interface{}, concern_type.Type) bool { return true })
+	if err != nil {
+		log.Errorf("ListConcernState error %v", err)
+		return
+	}
+	var staleMap = make(map[interface{}]*staleConcern)
+	var stales []*staleConcern
+	var total = make(map[interface{}]bool)
+	for index, id := range ids {
+		total[id] = true
+		s, found := staleMap[id]
+		if !found {
+			errorCount := sm.GetFreshErrorCount(id)
+			if errorCount < threshold {
+				// 刷新已经恢复正常
+				l.LspStateManager.ClearStaleConcern(site, id)
+				continue
+			}
+			s = &staleConcern{id: id, name: fmt.Sprintf("%v", id), errorCount: errorCount}
+			if info, err := cm.Get(id); err == nil && info.GetName() != "" {
+				s.name = info.GetName()
+			}
+			staleMap[id] = s
+			stales = append(stales, s)
+		}
+		s.targets = append(s.targets, targets[index])
+		s.ctypes = append(s.ctypes, ctypes[index])
+	}
+	if len(stales) == 0 {
+		return
+	}
+	// 所有订阅都刷新失败时更可能是网络或者网站本身的问题
+	if len(stales) == len(total) && len(total) > 1 {
+		log.WithField("Size", len(stales)).Warnf("所有订阅都连续刷新失败，可能是网络或者网站的问题，跳过本次失效订阅检查")
+		return
+	}
+	autoRemove := cfg.GetStaleAutoRemove()
+	for _, s := range stales {
+		log := log.WithField("Id", s.id).WithField("ErrorCount", s.errorCount)
+		notifiedCount, notified := l.LspStateManager.GetStaleConcern(site, s.id)
+		if !notified {
+			log.Info("发现失效订阅，通知订阅的群")
+			for _, target := range s.targets {
+				m := mmsg.NewTextf("订阅的%v用户 %v(%v) 已经连续%v次刷新失败，可能已经注销或者被封禁，可以使用%v取消订阅",
+					site, s.name, s.id, s.errorCount, l.CommandShowName(UnwatchCommand))
+				if autoRemove {
+					m.Text("\n如果下次检查时仍然刷新失败，将自动取消订阅")
+				}
+				l.pushQueue.Push(&PushItem{
+					Target:   target,
+					Priority: PushPriorityNormal,
+					MSG:      m,
+				})
+			}
+			if err := l.LspStateManager.MarkStaleConcern(site, s.id, s.errorCount); err != nil {
+				log.Errorf("MarkStaleConcern error %v", err)
+			}
+			continue
+		}
+		// 通知之后没有再刷新过时不能确认仍然失效
+		if !autoRemove || s.errorCount <= notifiedCount {
+			continue
+		}
+		log.Info("失效订阅仍然刷新失败，自动取消订阅")
+		for index, target := range s.targets {
+			if _, err := cm.Remove(nil, target, s.id, s.ctypes[index]); err != nil {
+				log.WithFields(mmsg.TargetLogFields(target)).Errorf("Remove error %v", err)
+				continue
+			}
+			l.pushQueue.Push(&PushItem{
+				Target:   target,
+				Priority: PushPriorityNormal,
+				MSG: mmsg.NewTextf("订阅的%v用户 %v(%v) 持续刷新失败，已自动取消订阅",
+					site, s.name, s.id),
+			})
+		}
+		l.LspStateManager.ClearStaleConcern(site, s.id)
+		sm.ClearFreshErrorCount(s.id)
+	}
+}
@@ -0,0 +1,103 @@
+package lsp
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLsp_CheckStaleConcern(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	config.GlobalConfig.Set("staleCleanup.autoRemove", true)
+	defer config.GlobalConfig.Set("staleCleanup.autoRemove", nil)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	l := &Lsp{LspStateManager: newStateManager(t)}
+	l.pushQueue = newTestPushQueue(t, sender)
+	l.pushQueue.Start()
+	defer l.pushQueue.Stop()
+
+	c := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	for _, id := range []string{test.NAME1, test.NAME2} {
+		_, err := c.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), id, test.T1)
+		assert.Nil(t, err)
+	}
+	_, err := c.AddGroupConcern(mmsg.NewGroupTargetId(test.G2), test.NAME1, test.T1)
+	assert.Nil(t, err)
+
+	var fail = func(id string, count int) {
+		for i := 0; i < count; i++ {
+			c.IncFreshErrorCount(id, errors.New("not found"))
+		}
+	}
+
+	// 所有订阅都失败时不处理
+	fail(test.NAME1, 3)
+	fail(test.NAME2, 3)
+	l.checkStaleConcernSite(c, 3)
+	_, notified := l.LspStateManager.GetStaleConcern(test.Site1, test.NAME1)
+	assert.False(t, notified)
+
+	assert.Nil(t, c.ClearFreshErrorCount(test.NAME2))
+	l.checkStaleConcernSite(c, 3)
+	_, notified = l.LspStateManager.GetStaleConcern(test.Site1, test.NAME1)
+	assert.True(t, notified)
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 2
+	}, time.Second, time.Millisecond*10)
+	assert.Contains(t, sender.Result()[0], "已经连续3次刷新失败")
+
+	// 通知后没有再刷新失败时不会取消订阅
+	l.checkStaleConcernSite(c, 3)
+	_, err = c.GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	assert.Nil(t, err)
+
+	fail(test.NAME1, 1)
+	l.checkStaleConcernSite(c, 3)
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 4
+	}, time.Second, time.Millisecond*10)
+	assert.Contains(t, sender.Result()[3], "已自动取消订阅")
+	_, err = c.GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	assert.NotNil(t, err)
+	_, err = c.GetGroupConcern(mmsg.NewGroupTargetId(test.G2), test.NAME1)
+	assert.NotNil(t, err)
+	_, err = c.GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME2)
+	assert.Nil(t, err)
+	_, notified = l.LspStateManager.GetStaleConcern(test.Site1, test.NAME1)
+	assert.False(t, notified)
+	assert.Zero(t, c.GetFreshErrorCount(test.NAME1))
+}
+
+func TestLsp_CheckStaleConcern_Recover(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	l := &Lsp{LspStateManager: newStateManager(t)}
+	l.pushQueue = newTestPushQueue(t, &testPushSender{fail: map[int64]int{}})
+
+	c := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	_, err := c.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1, test.T1)
+	assert.Nil(t, err)
+
+	c.IncFreshErrorCount(test.NAME1, errors.New("not found"))
+	l.checkStaleConcernSite(c, 1)
+	_, notified := l.LspStateManager.GetStaleConcern(test.Site1, test.NAME1)
+	assert.True(t, notified)
+
+	// 刷新恢复正常后清除通知记录
+	assert.Nil(t, c.ClearFreshErrorCount(test.NAME1))
+	l.checkStaleConcernSite(c, 1)
+	_, notified = l.LspStateManager.GetStaleConcern(test.Site1, test.NAME1)
+	assert.False(t, notified)
+}
@@ -1,14 +1,19 @@
 package lsp
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Mrs4s/MiraiGo/client"
 	"github.com/Mrs4s/MiraiGo/message"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/utils"
-	"github.com/tidwall/buntdb"
+	"hash/fnv"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type KeySet struct{}
@@ -17,6 +22,26 @@ func (KeySet) GroupMessageImageKey(keys ...interface{}) string {
 	return localdb.GroupMessageImageKey(keys...)
 }
 
+func (KeySet) GroupMessageArchiveKey(keys ...interface{}) string {
+	return localdb.GroupMessageArchiveKey(keys...)
+}
+
+func (KeySet) ScoreKey(keys ...interface{}) string {
+	return localdb.ScoreKey(keys...)
+}
+
+func (KeySet) ScoreDateKey(keys ...interface{}) string {
+	return localdb.ScoreDateKey(keys...)
+}
+
+func (KeySet) ScoreStreakKey(keys ...interface{}) string {
+	return localdb.ScoreStreakKey(keys...)
+}
+
+func (KeySet) ScoreLedgerKey(keys ...interface{}) string {
+	return localdb.ScoreLedgerKey(keys...)
+}
+
 func (KeySet) GroupMuteKey(keys ...interface{}) string {
 	return localdb.GroupMuteKey(keys...)
 }
@@ -33,6 +58,94 @@ func (KeySet) GroupInvitedKey(keys ...interface{}) string {
 	return localdb.GroupInvitedKey(keys...)
 }
 
+func (KeySet) PushQueueKey(keys ...interface{}) string {
+	return localdb.PushQueueKey(keys...)
+}
+
+func (KeySet) GroupQuietHoursKey(keys ...interface{}) string {
+	return localdb.GroupQuietHoursKey(keys...)
+}
+
+func (KeySet) QuietQueueKey(keys ...interface{}) string {
+	return localdb.QuietQueueKey(keys...)
+}
+
+func (KeySet) LastPushKey(keys ...interface{}) string {
+	return localdb.LastPushKey(keys...)
+}
+
+func (KeySet) StaleConcernKey(keys ...interface{}) string {
+	return localdb.StaleConcernKey(keys...)
+}
+
+func (KeySet) CommandCooldownKey(keys ...interface{}) string {
+	return localdb.CommandCooldownKey(keys...)
+}
+
+func (KeySet) ConcernTagKey(keys ...interface{}) string {
+	return localdb.ConcernTagKey(keys...)
+}
+
+func (KeySet) PushDedupKey(keys ...interface{}) string {
+	return localdb.PushDedupKey(keys...)
+}
+
+func (KeySet) AuditLogKey(keys ...interface{}) string {
+	return localdb.AuditLogKey(keys...)
+}
+
+func (KeySet) HttpSiteConfigKey(keys ...interface{}) string {
+	return localdb.HttpSiteConfigKey(keys...)
+}
+
+func (KeySet) GroupReminderKey(keys ...interface{}) string {
+	return localdb.GroupReminderKey(keys...)
+}
+
+func (KeySet) GroupReminderSeqKey() string {
+	return localdb.GroupReminderSeqKey()
+}
+
+func (KeySet) GroupLocaleKey(keys ...interface{}) string {
+	return localdb.GroupLocaleKey(keys...)
+}
+
+func (KeySet) MentionSubscriberKey(keys ...interface{}) string {
+	return localdb.MentionSubscriberKey(keys...)
+}
+
+func (KeySet) GroupDigestKey(keys ...interface{}) string {
+	return localdb.GroupDigestKey(keys...)
+}
+
+func (KeySet) DigestQueueKey(keys ...interface{}) string {
+	return localdb.DigestQueueKey(keys...)
+}
+
+func (KeySet) GroupModuleKey(keys ...interface{}) string {
+	return localdb.GroupModuleKey(keys...)
+}
+
+func (KeySet) BlocklistIdKey(keys ...interface{}) string {
+	return localdb.BlocklistIdKey(keys...)
+}
+
+func (KeySet) BlocklistKeywordKey(keys ...interface{}) string {
+	return localdb.BlocklistKeywordKey(keys...)
+}
+
+func (KeySet) FailedPushKey(keys ...interface{}) string {
+	return localdb.FailedPushKey(keys...)
+}
+
+func (KeySet) FailedPushSeqKey() string {
+	return localdb.FailedPushSeqKey()
+}
+
+func (KeySet) TrialWatchKey(keys ...interface{}) string {
+	return localdb.TrialWatchKey(keys...)
+}
+
 type StateManager struct {
 	*localdb.ShortCut
 	KeySet
@@ -70,8 +183,69 @@ func (s *StateManager) GetMessageImageUrl(groupCode int64, messageID int32) []st
 	return result
 }
 
+// ArchivedGroupMessage 存档的群消息
+type ArchivedGroupMessage struct {
+	MessageId int32  `json:"message_id"`
+	Uin       int64  `json:"uin"`
+	Name      string `json:"name"`
+	Time      int64  `json:"time"`
+	Content   string `json:"content"`
+}
+
+// ArchiveGroupMessage 存档一条群消息，retention 为保留时间，<=0 时不存档
+func (s *StateManager) ArchiveGroupMessage(msg *message.GroupMessage, retention time.Duration) error {
+	if msg == nil || retention <= 0 {
+		return nil
+	}
+	content := strings.TrimSpace(msg.ToString())
+	if len(content) == 0 {
+		return nil
+	}
+	var item = &ArchivedGroupMessage{
+		MessageId: msg.Id,
+		Time:      int64(msg.Time),
+		Content:   content,
+	}
+	if msg.Sender != nil {
+		item.Uin = msg.Sender.Uin
+		item.Name = msg.Sender.DisplayName()
+	}
+	return s.SetJson(s.GroupMessageArchiveKey(msg.GroupCode, item.Time, item.MessageId), item, localdb.SetExpireOpt(retention))
+}
+
+// SearchGroupMessage 按时间从新到旧搜索包含keyword的存档消息，返回第offset条开始的至多limit条，以及总匹配数
+func (s *StateManager) SearchGroupMessage(groupCode int64, keyword string, offset int, limit int) (result []*ArchivedGroupMessage, total int, err error) {
+	keyword = strings.ToLower(keyword)
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.DescendKeys(s.GroupMessageArchiveKey(groupCode, "*"), func(key, value string) bool {
+			var item = new(ArchivedGroupMessage)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			if !strings.Contains(strings.ToLower(item.Content), keyword) {
+				return true
+			}
+			if total >= offset && len(result) < limit {
+				result = append(result, item)
+			}
+			total++
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		result = nil
+		total = 0
+	}
+	return
+}
+
 func (s *StateManager) Muted(groupCode int64, uin int64, t int32) error {
-	return s.RWCoverTx(func(tx *buntdb.Tx) error {
+	return s.RWCoverTx(func(tx localdb.Tx) error {
 		var err error
 		key := s.GroupMuteKey(groupCode, uin)
 		if t == 0 {
@@ -102,6 +276,34 @@ func (s *StateManager) PopGroupInvitor(groupCode int64) (int64, error) {
 	return s.DeleteInt64(localdb.GroupInvitorKey(groupCode))
 }
 
+// GroupKeyPrefix 返回group内消息记录，积分，禁言等数据的key前缀
+func (s *StateManager) GroupKeyPrefix(groupCode int64) []string {
+	target := mmsg.NewGroupTargetId(groupCode)
+	return []string{
+		s.GroupMessageImageKey(groupCode),
+		s.GroupMessageArchiveKey(groupCode),
+		s.ScoreKey(groupCode),
+		s.ScoreDateKey(groupCode),
+		s.ScoreStreakKey(groupCode),
+		s.ScoreLedgerKey(groupCode),
+		s.GroupMuteKey(groupCode),
+		localdb.GroupInvitorKey(groupCode),
+		s.GroupQuietHoursKey(groupCode),
+		s.QuietQueueKey(target),
+		s.LastPushKey(target),
+		s.ConcernTagKey(target),
+		s.PushDedupKey(target),
+		s.GroupReminderKey(groupCode),
+		s.GroupLocaleKey(groupCode),
+		s.MentionSubscriberKey(groupCode),
+		s.GroupDigestKey(groupCode),
+		s.DigestQueueKey(target),
+		s.TrialWatchKey(groupCode),
+		s.FailedPushKey(target),
+		s.GroupModuleKey(groupCode),
+	}
+}
+
 func (s *StateManager) FreshIndex() {
 	for _, pattern := range []localdb.KeyPatternFunc{
 		s.NewFriendRequestKey, s.GroupInvitedKey,
@@ -163,7 +365,7 @@ func (s *StateManager) SaveNewFriendRequest(request *client.NewFriendRequest) er
 }
 
 func (s *StateManager) ListNewFriendRequest() (results []*client.NewFriendRequest, err error) {
-	err = s.RCoverTx(func(tx *buntdb.Tx) error {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
 		var (
 			iterErr, err error
 		)
@@ -188,7 +390,7 @@ func (s *StateManager) ListNewFriendRequest() (results []*client.NewFriendReques
 }
 
 func (s *StateManager) ListGroupInvitedRequest() (results []*client.GroupInvitedRequest, err error) {
-	err = s.RCoverTx(func(tx *buntdb.Tx) error {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
 		var (
 			iterErr, err error
 		)
@@ -232,6 +434,296 @@ func (s *StateManager) GetGroupInvitedRequest(requestId int64) (result *client.G
 	return
 }
 
+// SavePushItem 保存推送队列中未发送的消息
+func (s *StateManager) SavePushItem(record *pushItemRecord, expire time.Duration) error {
+	return s.SetJson(s.PushQueueKey(record.Id), record, localdb.SetExpireOpt(expire))
+}
+
+// DeletePushItem 删除推送队列中已发送的消息
+func (s *StateManager) DeletePushItem(id string) error {
+	_, err := s.Delete(s.PushQueueKey(id), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// ListPushItem 按添加顺序返回推送队列中所有未发送的消息
+func (s *StateManager) ListPushItem() (results []*pushItemRecord, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(s.PushQueueKey("*"), func(key, value string) bool {
+			var item = new(pushItemRecord)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			results = append(results, item)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	return
+}
+
+// GetQuietHours 返回群的免打扰时段配置，没有配置时返回一个空的配置
+func (s *StateManager) GetQuietHours(groupCode int64) *QuietHoursConfig {
+	var config = new(QuietHoursConfig)
+	if err := s.GetJson(s.GroupQuietHoursKey(groupCode), config); err != nil && !localdb.IsNotFound(err) {
+		logger.WithFields(utils.GroupLogFields(groupCode)).Errorf("GetQuietHours error %v", err)
+	}
+	return config
+}
+
+// SetQuietHours 保存群的免打扰时段配置，配置为空时删除
+func (s *StateManager) SetQuietHours(groupCode int64, config *QuietHoursConfig) error {
+	if config == nil || len(config.Ranges) == 0 {
+		_, err := s.Delete(s.GroupQuietHoursKey(groupCode), localdb.IgnoreNotFoundOpt())
+		return err
+	}
+	return s.SetJson(s.GroupQuietHoursKey(groupCode), config)
+}
+
+// SetLastPush 记录订阅在推送目标中最后一次推送成功的时间
+func (s *StateManager) SetLastPush(target mmsg.TargetId, site string, id interface{}, t time.Time) error {
+	return s.SetInt64(s.LastPushKey(target, site, id), t.Unix())
+}
+
+// GetLastPush 返回订阅在推送目标中最后一次推送成功的时间戳，没有推送过时返回0
+func (s *StateManager) GetLastPush(target mmsg.TargetId, site string, id interface{}) int64 {
+	ts, err := s.GetInt64(s.LastPushKey(target, site, id), localdb.IgnoreNotFoundOpt())
+	if err != nil {
+		logger.WithFields(mmsg.TargetLogFields(target)).Errorf("GetLastPush error %v", err)
+	}
+	return ts
+}
+
+// pushFingerprint 把网站和推送内容的指纹压缩成固定长度的字符串，避免过长的指纹占用数据库
+func pushFingerprint(site string, key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(site))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// MarkPushDedup 标记推送目标中推送过的内容指纹，标记在window后过期，
+// 返回的duplicate为true时说明window内已经推送过其中任意一个指纹，这时只会补充标记没有推送过的指纹，不会延长已有标记的时间
+func (s *StateManager) MarkPushDedup(target mmsg.TargetId, site string, keys []string, window time.Duration) (duplicate bool, err error) {
+	err = s.RWCover(func() error {
+		var missing []string
+		for _, key := range keys {
+			dedupKey := s.PushDedupKey(target, pushFingerprint(site, key))
+			if s.Exist(dedupKey) {
+				duplicate = true
+			} else {
+				missing = append(missing, dedupKey)
+			}
+		}
+		for _, dedupKey := range missing {
+			if err := s.Set(dedupKey, "", localdb.SetExpireOpt(window)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// MarkStaleConcern 记录已经通知过失效的订阅，以及通知时连续刷新失败的次数
+func (s *StateManager) MarkStaleConcern(site string, id interface{}, errorCount int64) error {
+	return s.SetInt64(s.StaleConcernKey(site, id), errorCount)
+}
+
+// GetStaleConcern 返回通知失效时连续刷新失败的次数，没有通知过时返回false
+func (s *StateManager) GetStaleConcern(site string, id interface{}) (int64, bool) {
+	errorCount, err := s.GetInt64(s.StaleConcernKey(site, id))
+	if err != nil {
+		return 0, false
+	}
+	return errorCount, true
+}
+
+// ClearStaleConcern 清除失效通知的记录
+func (s *StateManager) ClearStaleConcern(site string, id interface{}) error {
+	_, err := s.Delete(s.StaleConcernKey(site, id), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// SaveQuietItem 保存免打扰时段内暂存的推送，免打扰结束后会汇总发送
+func (s *StateManager) SaveQuietItem(record *pushItemRecord) error {
+	return s.SetJson(s.QuietQueueKey(record.Target, record.Id), record, localdb.SetExpireOpt(pushQueueItemExpire))
+}
+
+// PopQuietItem 取出并删除一个群内所有暂存的推送，按添加顺序返回
+func (s *StateManager) PopQuietItem(groupCode int64) (results []*pushItemRecord, err error) {
+	err = s.RWCoverTx(func(tx localdb.Tx) error {
+		var keys []string
+		var iterErr error
+		err := tx.AscendKeys(s.QuietQueueKey(groupCode, "*"), func(key, value string) bool {
+			var item = new(pushItemRecord)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			keys = append(keys, key)
+			results = append(results, item)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if iterErr != nil {
+			return iterErr
+		}
+		for _, key := range keys {
+			if _, err := tx.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// ListQuietGroup 返回有暂存推送的群
+func (s *StateManager) ListQuietGroup() (groups []int64, err error) {
+	var found = make(map[int64]bool)
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		return tx.AscendKeys(s.QuietQueueKey("*"), func(key, value string) bool {
+			groupCode, _, err := localdb.ParseConcernStateKeyWithString(key)
+			if err == nil && !found[groupCode] {
+				found[groupCode] = true
+				groups = append(groups, groupCode)
+			}
+			return true
+		})
+	})
+	return
+}
+
+// ConcernTag 群内订阅的标签，一个订阅可以有多个标签
+type ConcernTag struct {
+	Tag  string `json:"tag"`
+	Site string `json:"site"`
+	Id   string `json:"id"`
+}
+
+const maxConcernTagLength = 20
+
+// ParseConcernTag 检查并返回统一成小写的标签，标签不能包含空白字符以及 : * # 等特殊字符
+func ParseConcernTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if len(tag) == 0 {
+		return "", errors.New("标签不能为空")
+	}
+	if len([]rune(tag)) > maxConcernTagLength {
+		return "", fmt.Errorf("标签【%v】太长了，最多%v个字", tag, maxConcernTagLength)
+	}
+	if strings.ContainsAny(tag, ":*#?\"") || strings.IndexFunc(tag, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("标签【%v】不能包含空白字符以及 : * # ? \"", tag)
+	}
+	return tag, nil
+}
+
+// concernTagIndex 按标签查询订阅的索引，每个推送目标一个
+func (s *StateManager) concernTagIndex(target mmsg.TargetId) string {
+	s.CreatePatternIndex(s.ConcernTagKey, []interface{}{target}, localdb.IndexJSON("tag"))
+	return s.ConcernTagKey(target)
+}
+
+// AddConcernTag 给群内的订阅添加标签，tags需要先使用 ParseConcernTag 检查
+func (s *StateManager) AddConcernTag(target mmsg.TargetId, site string, id interface{}, tags ...string) error {
+	s.concernTagIndex(target)
+	return s.RWCover(func() error {
+		for _, tag := range tags {
+			err := s.SetJson(s.ConcernTagKey(target, site, id, tag), &ConcernTag{
+				Tag:  tag,
+				Site: site,
+				Id:   fmt.Sprint(id),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveConcernTag 删除群内订阅的标签，tags为空时删除这个订阅的所有标签
+func (s *StateManager) RemoveConcernTag(target mmsg.TargetId, site string, id interface{}, tags ...string) error {
+	return s.RWCoverTx(func(tx localdb.Tx) error {
+		var keys []string
+		if len(tags) == 0 {
+			err := tx.AscendKeys(s.ConcernTagKey(target, site, id, "*"), func(key, value string) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, tag := range tags {
+			keys = append(keys, s.ConcernTagKey(target, site, id, tag))
+		}
+		for _, key := range keys {
+			if _, err := tx.Delete(key); err != nil && err != localdb.ErrNotFound {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetConcernTag 返回群内订阅的所有标签
+func (s *StateManager) GetConcernTag(target mmsg.TargetId, site string, id interface{}) (tags []string, err error) {
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(s.ConcernTagKey(target, site, id, "*"), func(key, value string) bool {
+			var item = new(ConcernTag)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			tags = append(tags, item.Tag)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	sort.Strings(tags)
+	return
+}
+
+// ListConcernTag 返回群内带有标签的订阅，tag为空时返回所有标签，按标签排序
+func (s *StateManager) ListConcernTag(target mmsg.TargetId, tag string) (result []*ConcernTag, err error) {
+	index := s.concernTagIndex(target)
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		var iter = func(key, value string) bool {
+			var item = new(ConcernTag)
+			if iterErr = json.Unmarshal([]byte(value), item); iterErr != nil {
+				return false
+			}
+			result = append(result, item)
+			return true
+		}
+		var err error
+		if len(tag) == 0 {
+			err = tx.Ascend(index, iter)
+		} else {
+			err = tx.AscendEqual(index, fmt.Sprintf(`{"tag":%q}`, tag), iter)
+		}
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
 func (s *StateManager) saveRequest(requestId int64, request interface{}, keyFunc localdb.KeyPatternFunc) error {
 	return s.SetJson(keyFunc(requestId), request)
 }
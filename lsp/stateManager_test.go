@@ -5,10 +5,12 @@ import (
 	"github.com/Mrs4s/MiraiGo/message"
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func newStateManager(t *testing.T) *StateManager {
@@ -34,7 +36,7 @@ func TestStateManager_GetGroupInvitor(t *testing.T) {
 	assert.NotNil(t, sm)
 
 	_, err := sm.PopGroupInvitor(test.G1)
-	assert.EqualValues(t, buntdb.ErrNotFound, err)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
 
 	assert.Nil(t, sm.SaveGroupInvitor(test.G1, test.UID1))
 
@@ -45,7 +47,7 @@ func TestStateManager_GetGroupInvitor(t *testing.T) {
 	assert.Equal(t, test.UID1, target)
 
 	_, err = sm.PopGroupInvitor(test.G2)
-	assert.EqualValues(t, buntdb.ErrNotFound, err)
+	assert.EqualValues(t, localdb.ErrNotFound, err)
 }
 
 func TestStateManager_IsMuted(t *testing.T) {
@@ -89,6 +91,61 @@ func TestStateManager_GetMessageImageUrl(t *testing.T) {
 	assert.Len(t, sm.GetMessageImageUrl(test.G1, test.MessageID1), 3)
 }
 
+func TestStateManager_SearchGroupMessage(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	assert.NotNil(t, sm)
+
+	newMsg := func(id int32, ts int64, content string) *message.GroupMessage {
+		return &message.GroupMessage{
+			Id:        id,
+			GroupCode: test.G1,
+			Time:      int32(ts),
+			Sender:    &message.Sender{Uin: test.UID1, Nickname: test.NAME1},
+			Elements:  []message.IMessageElement{message.NewText(content)},
+		}
+	}
+
+	assert.Nil(t, sm.ArchiveGroupMessage(nil, time.Hour))
+	assert.Nil(t, sm.ArchiveGroupMessage(newMsg(test.MessageID1, test.TIMESTAMP1, "公告 https://a.com"), 0))
+
+	result, total, err := sm.SearchGroupMessage(test.G1, "公告", 0, 5)
+	assert.Nil(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, result)
+
+	assert.Nil(t, sm.ArchiveGroupMessage(newMsg(test.MessageID1, test.TIMESTAMP1, "公告 https://a.com"), time.Hour))
+	assert.Nil(t, sm.ArchiveGroupMessage(newMsg(test.MessageID2, test.TIMESTAMP2, "新公告 https://B.com"), time.Hour))
+	assert.Nil(t, sm.ArchiveGroupMessage(newMsg(test.MessageID2+1, test.TIMESTAMP2+1, "闲聊"), time.Hour))
+
+	result, total, err = sm.SearchGroupMessage(test.G1, "公告", 0, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, result, 2)
+	assert.Equal(t, test.MessageID2, result[0].MessageId)
+	assert.Equal(t, test.MessageID1, result[1].MessageId)
+	assert.Equal(t, test.UID1, result[0].Uin)
+	assert.Equal(t, test.NAME1, result[0].Name)
+
+	result, total, err = sm.SearchGroupMessage(test.G1, "公告", 1, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, result, 1)
+	assert.Equal(t, test.MessageID1, result[0].MessageId)
+
+	result, total, err = sm.SearchGroupMessage(test.G1, "b.com", 0, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, result, 1)
+
+	result, total, err = sm.SearchGroupMessage(test.G2, "公告", 0, 5)
+	assert.Nil(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, result)
+}
+
 func TestStateManager_GetCurrentMode(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
@@ -124,7 +181,7 @@ func TestStateManager_GetCurrentMode(t *testing.T) {
 	assert.True(t, sm.IsPrivateMode())
 	assert.Equal(t, PrivateMode, sm.GetCurrentMode())
 
-	err := localdb.RWCoverTx(func(tx *buntdb.Tx) error {
+	err := localdb.RWCoverTx(func(tx localdb.Tx) error {
 		key := localdb.ModeKey()
 		_, _, err := tx.Set(key, "wrong", nil)
 		return err
@@ -251,3 +308,91 @@ func TestStateManager_GetGroupInvitedRequest(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Empty(t, act)
 }
+
+func TestStateManager_ConcernTag(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+
+	tag, err := ParseConcernTag(" #HoloLive ")
+	assert.Nil(t, err)
+	assert.Equal(t, "hololive", tag)
+	for _, tag := range []string{"", "#", "a b", "a:b", "a*", strings.Repeat("a", maxConcernTagLength+1)} {
+		_, err = ParseConcernTag(tag)
+		assert.NotNil(t, err, tag)
+	}
+
+	assert.Nil(t, sm.AddConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID1, "b", "a"))
+	assert.Nil(t, sm.AddConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID2, "a"))
+	assert.Nil(t, sm.AddConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site2, test.NAME1, "a"))
+	assert.Nil(t, sm.AddConcernTag(mmsg.NewGroupTargetId(test.G2), test.Site1, test.UID1, "a"))
+
+	tags, err := sm.GetConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"a", "b"}, tags)
+
+	result, err := sm.ListConcernTag(mmsg.NewGroupTargetId(test.G1), "a")
+	assert.Nil(t, err)
+	assert.Len(t, result, 3)
+	for _, item := range result {
+		assert.Equal(t, "a", item.Tag)
+	}
+
+	result, err = sm.ListConcernTag(mmsg.NewGroupTargetId(test.G1), "")
+	assert.Nil(t, err)
+	assert.Len(t, result, 4)
+	assert.Equal(t, "b", result[3].Tag)
+
+	assert.Nil(t, sm.RemoveConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID1, "a"))
+	tags, err = sm.GetConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"b"}, tags)
+
+	assert.Nil(t, sm.RemoveConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID1))
+	tags, err = sm.GetConcernTag(mmsg.NewGroupTargetId(test.G1), test.Site1, test.UID1)
+	assert.Nil(t, err)
+	assert.Empty(t, tags)
+
+	result, err = sm.ListConcernTag(mmsg.NewGroupTargetId(test.G2), "a")
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+}
+
+func TestStateManager_MarkPushDedup(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+
+	duplicate, err := sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G1), test.Site1, []string{"a"}, time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, duplicate)
+
+	// 任意一个指纹相同即视为重复，同时补充标记新的指纹
+	duplicate, err = sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G1), test.Site1, []string{"b", "a"}, time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, duplicate)
+	duplicate, err = sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G1), test.Site1, []string{"b"}, time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, duplicate)
+
+	// 不同的群和网站互不影响
+	duplicate, err = sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G2), test.Site1, []string{"a"}, time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, duplicate)
+	duplicate, err = sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G1), test.Site2, []string{"a"}, time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, duplicate)
+
+	duplicate, err = sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G1), test.Site1, []string{"c"}, time.Millisecond*50)
+	assert.Nil(t, err)
+	assert.False(t, duplicate)
+	time.Sleep(time.Millisecond * 100)
+	duplicate, err = sm.MarkPushDedup(mmsg.NewGroupTargetId(test.G1), test.Site1, []string{"c"}, time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, duplicate)
+
+	assert.NotEqual(t, pushFingerprint(test.Site1, "a"), pushFingerprint(test.Site2, "a"))
+	assert.Contains(t, sm.GroupKeyPrefix(test.G1), sm.PushDedupKey(test.G1))
+}
@@ -0,0 +1,170 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"strings"
+	"time"
+)
+
+const (
+	// maxStatsDays /stats 最多可以查看的天数
+	maxStatsDays = 30
+	// statsRecentSessions /stats 显示的最近直播数量
+	statsRecentSessions = 5
+)
+
+// formatSignedInt 格式化为带正负号的数字
+func formatSignedInt(n int64) string {
+	if n >= 0 {
+		return fmt.Sprintf("+%v", n)
+	}
+	return fmt.Sprint(n)
+}
+
+// followerChange 返回since之后粉丝数的变化，没有足够的记录时返回false
+func followerChange(samples []*concern.StatSample, since time.Time) (int64, bool) {
+	var first, last *concern.StatSample
+	for _, sample := range samples {
+		if sample.Follower < 0 || sample.Time < since.Unix() {
+			continue
+		}
+		if first == nil {
+			first = sample
+		}
+		last = sample
+	}
+	if first == nil || first == last {
+		return 0, false
+	}
+	return last.Follower - first.Follower, true
+}
+
+// formatStats 格式化为 /stats 的回复内容，samples和sessions按时间从早到晚排列
+func formatStats(name string, days int, samples []*concern.StatSample, sessions []*concern.LiveSession, now time.Time) string {
+	if len(samples) == 0 && len(sessions) == 0 {
+		return fmt.Sprintf("%v最近%v天暂无统计数据，订阅后每隔一段时间会记录一次", name, days)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%v最近%v天的统计：", name, days))
+
+	var latest *concern.StatSample
+	var maxOnline int64
+	for _, sample := range samples {
+		if sample.Follower >= 0 {
+			latest = sample
+		}
+		if sample.Online > maxOnline {
+			maxOnline = sample.Online
+		}
+	}
+	if latest != nil {
+		sb.WriteString(fmt.Sprintf("\n粉丝数：%v", latest.Follower))
+		var changes []string
+		if change, ok := followerChange(samples, now.Add(-time.Hour*24)); ok {
+			changes = append(changes, "24小时内"+formatSignedInt(change))
+		}
+		if change, ok := followerChange(samples, now.AddDate(0, 0, -days)); ok && days > 1 {
+			changes = append(changes, fmt.Sprintf("%v天内%v", days, formatSignedInt(change)))
+		}
+		if len(changes) > 0 {
+			sb.WriteString("（" + strings.Join(changes, "，") + "）")
+		}
+	}
+
+	var total time.Duration
+	for _, session := range sessions {
+		end := now
+		if session.End > 0 {
+			end = time.Unix(session.End, 0)
+		}
+		total += end.Sub(time.Unix(session.Start, 0))
+		if session.MaxOnline > maxOnline {
+			maxOnline = session.MaxOnline
+		}
+	}
+	if len(sessions) == 0 {
+		sb.WriteString("\n直播：没有直播记录")
+	} else {
+		sb.WriteString(fmt.Sprintf("\n直播：共%v次，总时长%v，平均%v", len(sessions),
+			total.Truncate(time.Minute), (total / time.Duration(len(sessions))).Truncate(time.Minute)))
+	}
+	if maxOnline > 0 {
+		sb.WriteString(fmt.Sprintf("\n最高人气：%v", maxOnline))
+	}
+
+	if len(sessions) > 0 {
+		sb.WriteString("\n最近的直播：")
+		recent := sessions
+		if len(recent) > statsRecentSessions {
+			recent = recent[len(recent)-statsRecentSessions:]
+		}
+		for i := len(recent) - 1; i >= 0; i-- {
+			session := recent[i]
+			start := time.Unix(session.Start, 0)
+			var duration string
+			if session.End == 0 {
+				duration = fmt.Sprintf("直播中，已直播%v", now.Sub(start).Truncate(time.Minute))
+			} else {
+				duration = time.Unix(session.End, 0).Sub(start).Truncate(time.Minute).String()
+			}
+			sb.WriteString(fmt.Sprintf("\n[%v] %v", start.Format("01-02 15:04"), duration))
+			if len(session.Title) > 0 {
+				sb.WriteString(" " + session.Title)
+			}
+			if session.MaxOnline > 0 {
+				sb.WriteString(fmt.Sprintf("，最高人气%v", session.MaxOnline))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// IStats 查看已订阅的账号最近days天的粉丝数、直播人气和直播时长
+func IStats(c *MessageContext, target mmsg.TargetId, id string, site string, days int) {
+	log := c.Log
+
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(target.GroupCode(), StatsCommand) {
+		c.DisabledReply()
+		return
+	}
+
+	site, err := concern.ParseRawSite(site)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	cm, err := concern.GetConcernBySite(site)
+	if err != nil {
+		log.Errorf("GetConcernBySite error %v", err)
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	statsExt, ok := cm.(concern.StatsExt)
+	if !ok {
+		c.TextReply(fmt.Sprintf("失败 - %v暂不支持查看统计数据", site))
+		return
+	}
+	mid, err := cm.ParseId(id)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - 解析%v id格式错误", site))
+		return
+	}
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, mid); err != nil || ctype.Empty() {
+		c.TextReply("失败 - 该id尚未watch")
+		return
+	}
+	var now = time.Now()
+	samples, sessions, err := statsExt.GetStats(mid, now.AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("GetStats error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	var name = fmt.Sprint(mid)
+	if info, err := cm.Get(mid); err == nil && info != nil {
+		name = info.GetName()
+	}
+	c.TextReply(formatStats(name, days, samples, sessions, now))
+}
@@ -0,0 +1,34 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFormatStats(t *testing.T) {
+	now := time.Date(2021, 10, 15, 20, 0, 0, 0, time.Local)
+	assert.Contains(t, formatStats("a", 7, nil, nil, now), "暂无统计数据")
+
+	samples := []*concern.StatSample{
+		{Time: now.Add(-time.Hour * 72).Unix(), Follower: 100},
+		{Time: now.Add(-time.Hour * 12).Unix(), Follower: 150},
+		{Time: now.Add(-time.Hour * 2).Unix(), Follower: -1, Online: 300},
+		{Time: now.Add(-time.Hour).Unix(), Follower: 140, Online: 200},
+	}
+	sessions := []*concern.LiveSession{
+		{Start: now.Add(-time.Hour * 50).Unix(), End: now.Add(-time.Hour * 48).Unix(), Title: "x", MaxOnline: 500},
+		{Start: now.Add(-time.Hour * 2).Unix(), Title: "y"},
+	}
+	assert.Equal(t, "a最近7天的统计："+
+		"\n粉丝数：140（24小时内-10，7天内+40）"+
+		"\n直播：共2次，总时长4h0m0s，平均2h0m0s"+
+		"\n最高人气：500"+
+		"\n最近的直播："+
+		"\n[10-15 18:00] 直播中，已直播2h0m0s y"+
+		"\n[10-13 18:00] 2h0m0s x，最高人气500", formatStats("a", 7, samples, sessions, now))
+
+	assert.Equal(t, "a最近1天的统计：\n粉丝数：140\n直播：没有直播记录\n最高人气：200",
+		formatStats("a", 1, samples[3:], nil, now))
+}
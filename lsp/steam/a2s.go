@@ -0,0 +1,141 @@
+package steam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+)
+
+// A2STimeout 查询游戏服务器的超时时间
+var A2STimeout = time.Second * 5
+
+var ErrInvalidA2SResponse = errors.New("无效的A2S响应")
+
+const (
+	a2sHeaderInfo      = 0x49
+	a2sHeaderChallenge = 0x41
+)
+
+var a2sInfoRequest = append([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x54}, []byte("Source Engine Query\x00")...)
+
+// A2SInfo 是 A2S_INFO 响应中需要的部分
+// https://developer.valvesoftware.com/wiki/Server_queries#A2S_INFO
+type A2SInfo struct {
+	Name       string
+	Map        string
+	Folder     string
+	Game       string
+	Players    int
+	MaxPlayers int
+	Bots       int
+}
+
+// QueryA2SInfo 通过UDP向游戏服务器发送 A2S_INFO 查询，服务器要求challenge时会带上challenge重新查询一次
+func QueryA2SInfo(addr string) (*A2SInfo, error) {
+	conn, err := net.DialTimeout("udp", addr, A2STimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(A2STimeout)); err != nil {
+		return nil, err
+	}
+	var req = a2sInfoRequest
+	var buf = make([]byte, 1400)
+	for i := 0; i < 2; i++ {
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		data := buf[:n]
+		if len(data) < 5 || !bytes.Equal(data[:4], []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
+			// 分包的响应以 0xFFFFFFFE 开头，A2S_INFO 的响应通常不会分包，这里不支持
+			return nil, ErrInvalidA2SResponse
+		}
+		switch data[4] {
+		case a2sHeaderChallenge:
+			if len(data) < 9 {
+				return nil, ErrInvalidA2SResponse
+			}
+			req = make([]byte, 0, len(a2sInfoRequest)+4)
+			req = append(req, a2sInfoRequest...)
+			req = append(req, data[5:9]...)
+		case a2sHeaderInfo:
+			return parseA2SInfo(data[5:])
+		default:
+			return nil, ErrInvalidA2SResponse
+		}
+	}
+	return nil, ErrInvalidA2SResponse
+}
+
+type a2sReader struct {
+	data []byte
+	err  error
+}
+
+func (r *a2sReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 1 {
+		r.err = ErrInvalidA2SResponse
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *a2sReader) short() uint16 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 2 {
+		r.err = ErrInvalidA2SResponse
+		return 0
+	}
+	s := binary.LittleEndian.Uint16(r.data)
+	r.data = r.data[2:]
+	return s
+}
+
+func (r *a2sReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	idx := bytes.IndexByte(r.data, 0)
+	if idx < 0 {
+		r.err = ErrInvalidA2SResponse
+		return ""
+	}
+	s := string(r.data[:idx])
+	r.data = r.data[idx+1:]
+	return s
+}
+
+// parseA2SInfo 解析去掉header之后的 A2S_INFO 响应
+func parseA2SInfo(data []byte) (*A2SInfo, error) {
+	r := &a2sReader{data: data}
+	info := new(A2SInfo)
+	// protocol
+	r.byte()
+	info.Name = r.string()
+	info.Map = r.string()
+	info.Folder = r.string()
+	info.Game = r.string()
+	// app id
+	r.short()
+	info.Players = int(r.byte())
+	info.MaxPlayers = int(r.byte())
+	info.Bots = int(r.byte())
+	if r.err != nil {
+		return nil, r.err
+	}
+	return info, nil
+}
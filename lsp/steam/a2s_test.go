@@ -0,0 +1,73 @@
+package steam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func newA2SInfoResponse() []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, a2sHeaderInfo, 17})
+	for _, s := range []string{"test server", "de_dust2", "csgo", "Counter-Strike"} {
+		b.WriteString(s)
+		b.WriteByte(0)
+	}
+	binary.Write(&b, binary.LittleEndian, uint16(730))
+	b.Write([]byte{5, 10, 1})
+	return b.Bytes()
+}
+
+// newTestA2SServer 启动一个本地的A2S服务器，challenge不为空时要求先完成challenge
+func newTestA2SServer(t *testing.T, challenge []byte) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	go func() {
+		var buf = make([]byte, 1400)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:n]
+			if len(challenge) > 0 && !bytes.HasSuffix(req, challenge) {
+				conn.WriteToUDP(append([]byte{0xFF, 0xFF, 0xFF, 0xFF, a2sHeaderChallenge}, challenge...), addr)
+				continue
+			}
+			conn.WriteToUDP(newA2SInfoResponse(), addr)
+		}
+	}()
+	return conn
+}
+
+func TestParseA2SInfo(t *testing.T) {
+	info, err := parseA2SInfo(newA2SInfoResponse()[5:])
+	assert.Nil(t, err)
+	assert.Equal(t, "test server", info.Name)
+	assert.Equal(t, "de_dust2", info.Map)
+	assert.Equal(t, "csgo", info.Folder)
+	assert.Equal(t, "Counter-Strike", info.Game)
+	assert.Equal(t, 5, info.Players)
+	assert.Equal(t, 10, info.MaxPlayers)
+	assert.Equal(t, 1, info.Bots)
+
+	_, err = parseA2SInfo(newA2SInfoResponse()[5:20])
+	assert.Equal(t, ErrInvalidA2SResponse, err)
+}
+
+func TestQueryA2SInfo(t *testing.T) {
+	for _, challenge := range [][]byte{nil, {1, 2, 3, 4}} {
+		conn := newTestA2SServer(t, challenge)
+		info, err := QueryA2SInfo(conn.LocalAddr().String())
+		assert.Nil(t, err)
+		if assert.NotNil(t, info) {
+			assert.Equal(t, "test server", info.Name)
+			assert.Equal(t, 5, info.Players)
+		}
+		conn.Close()
+	}
+}
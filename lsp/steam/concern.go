@@ -0,0 +1,276 @@
+package steam
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"strings"
+)
+
+var logger = utils.GetModuleLogger("steam-concern")
+
+const (
+	// Server 游戏服务器，通过A2S查询在线状态和人数
+	Server concern_type.Type = "server"
+	// Status Steam用户的在线状态和正在玩的游戏，需要配置 steam.apiKey
+	Status concern_type.Type = "status"
+)
+
+// serverOfflineThreshold 游戏服务器连续查询失败多少次后视为离线，避免UDP丢包导致误报
+const serverOfflineThreshold = 3
+
+type Concern struct {
+	*StateManager
+}
+
+func (c *Concern) Site() string {
+	return Site
+}
+
+func (c *Concern) Types() []concern_type.Type {
+	return []concern_type.Type{Server, Status}
+}
+
+// ParseId 游戏服务器使用 地址:端口 作为id，Steam用户使用17位的SteamID作为id，也支持直接输入个人资料链接
+func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://", "http://", "steamcommunity.com/profiles/"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	s = strings.ToLower(strings.TrimSuffix(s, "/"))
+	if IsSteamId(s) || IsServerAddr(s) {
+		return s, nil
+	}
+	return nil, ErrInvalidId
+}
+
+func (c *Concern) GetStateManager() concern.IStateManager {
+	return c.StateManager
+}
+
+func (c *Concern) Stop() {
+	logger.Trace("正在停止steam concern")
+	logger.Trace("正在停止steam StateManager")
+	c.StateManager.Stop()
+	logger.Trace("steam StateManager已停止")
+	logger.Trace("steam concern已停止")
+}
+
+func (c *Concern) Start() error {
+	c.UseEmitQueue()
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(c.fresh())
+	return c.StateManager.Start()
+}
+
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	var err error
+	id := _id.(string)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
+
+	if (ctype.ContainAny(Server) && !IsServerAddr(id)) || (ctype.ContainAny(Status) && !IsSteamId(id)) {
+		return nil, ErrTypeMismatch
+	}
+
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	var identity concern.IdentityInfo
+	if ctype.ContainAny(Server) {
+		serverInfo, err := c.FindOrLoadServer(id)
+		if err != nil {
+			log.Errorf("FindOrLoadServer error %v", err)
+			return nil, fmt.Errorf("查询服务器信息失败 %v - %v", id, err)
+		}
+		identity = concern.NewIdentity(id, serverInfo.GetName())
+	} else {
+		userStatus, err := c.FindOrLoadUserStatus(id)
+		if err != nil {
+			log.Errorf("FindOrLoadUserStatus error %v", err)
+			return nil, fmt.Errorf("查询用户信息失败 %v - %v", id, err)
+		}
+		identity = concern.NewIdentity(id, userStatus.GetName())
+	}
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	id := _id.(string)
+	identity, _ := c.Get(id)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
+		allCtype, err := c.GetConcern(id)
+		if err != nil {
+			return err
+		}
+		if allCtype.Empty() {
+			if IsSteamId(id) {
+				err = c.DeleteUserStatus(id)
+			} else {
+				err = c.DeleteServerInfo(id)
+				_ = c.ClearServerOfflineCount(id)
+			}
+		}
+		return err
+	})
+	return identity, err
+}
+
+func (c *Concern) Get(_id interface{}) (concern.IdentityInfo, error) {
+	id := _id.(string)
+	if IsSteamId(id) {
+		userStatus, err := c.GetUserStatus(id)
+		if err != nil {
+			return nil, err
+		}
+		return concern.NewIdentity(id, userStatus.GetName()), nil
+	}
+	serverInfo, err := c.GetServerInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(id, serverInfo.GetName()), nil
+}
+
+func (c *Concern) FindOrLoadServer(addr string) (*ServerInfo, error) {
+	info, _ := c.GetServerInfo(addr)
+	if info != nil {
+		return info, nil
+	}
+	info, err := LoadServerInfo(addr)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddServerInfo(info)
+	return info, nil
+}
+
+func (c *Concern) FindOrLoadUserStatus(steamId string) (*UserStatus, error) {
+	status, _ := c.GetUserStatus(steamId)
+	if status != nil {
+		return status, nil
+	}
+	status, err := LoadUserStatus(steamId)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddUserStatus(status)
+	return status, nil
+}
+
+func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
+		switch info := event.(type) {
+		case *ServerInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("server notify")
+			return []concern.Notify{NewConcernServerNotify(target, info)}
+		case *UserStatus:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("status notify")
+			return []concern.Notify{NewConcernStatusNotify(target, info)}
+		default:
+			logger.Errorf("unknown EventType %+v", event)
+			return nil
+		}
+	}
+}
+
+func (c *Concern) fresh() concern.FreshFunc {
+	return c.EmitQueueFresher(func(ctype concern_type.Type, id interface{}) ([]concern.Event, error) {
+		var result []concern.Event
+		if ctype.ContainAll(Server) {
+			serverInfo, err := c.freshServer(id.(string))
+			if err != nil {
+				return nil, err
+			}
+			if serverInfo != nil {
+				result = append(result, serverInfo)
+			}
+		}
+		if ctype.ContainAll(Status) {
+			userStatus, err := c.freshUserStatus(id.(string))
+			if err != nil {
+				return nil, err
+			}
+			if userStatus != nil {
+				result = append(result, userStatus)
+			}
+		}
+		return result, nil
+	})
+}
+
+// freshServer 查询游戏服务器，在线状态或者人数变化时返回新的 ServerInfo ，没有变化时返回nil
+func (c *Concern) freshServer(addr string) (*ServerInfo, error) {
+	oldInfo, _ := c.GetServerInfo(addr)
+	newInfo, err := LoadServerInfo(addr)
+	if err != nil {
+		count := c.IncServerOfflineCount(addr)
+		if oldInfo == nil || !oldInfo.Online || count < serverOfflineThreshold {
+			return nil, fmt.Errorf("query server failed %v", err)
+		}
+		// 连续多次查询失败，视为离线
+		newInfo = &ServerInfo{
+			Address:    addr,
+			Name:       oldInfo.Name,
+			Map:        oldInfo.Map,
+			Game:       oldInfo.Game,
+			MaxPlayers: oldInfo.MaxPlayers,
+		}
+	} else {
+		_ = c.ClearServerOfflineCount(addr)
+	}
+	if oldInfo != nil {
+		if oldInfo.Online != newInfo.Online {
+			newInfo.onlineChanged = true
+		} else if newInfo.Online && oldInfo.Players != newInfo.Players {
+			newInfo.playersChanged = true
+		}
+	}
+	if err := c.AddServerInfo(newInfo); err != nil {
+		return nil, err
+	}
+	if newInfo.onlineChanged || newInfo.playersChanged {
+		return newInfo, nil
+	}
+	return nil, nil
+}
+
+// freshUserStatus 查询Steam用户状态，上线、下线或者游戏变化时返回新的 UserStatus ，没有变化时返回nil
+func (c *Concern) freshUserStatus(steamId string) (*UserStatus, error) {
+	oldStatus, _ := c.GetUserStatus(steamId)
+	newStatus, err := LoadUserStatus(steamId)
+	if err != nil {
+		return nil, fmt.Errorf("load user status failed %v", err)
+	}
+	if oldStatus != nil {
+		if oldStatus.Online != newStatus.Online {
+			newStatus.onlineChanged = true
+		}
+		if newStatus.Online && oldStatus.Game != newStatus.Game {
+			newStatus.gameChanged = true
+		}
+	}
+	if err := c.AddUserStatus(newStatus); err != nil {
+		return nil, err
+	}
+	if newStatus.onlineChanged || newStatus.gameChanged {
+		return newStatus, nil
+	}
+	return nil, nil
+}
+
+func NewConcern(notify chan<- concern.Notify) *Concern {
+	c := &Concern{
+		StateManager: NewStateManager(notify),
+	}
+	return c
+}
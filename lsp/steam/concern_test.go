@@ -0,0 +1,148 @@
+package steam
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSteamId = "76561197960287930"
+
+func TestConcern_ParseId(t *testing.T) {
+	c := NewConcern(nil)
+	for s, expected := range map[string]string{
+		testSteamId: testSteamId,
+		"https://steamcommunity.com/profiles/" + testSteamId + "/": testSteamId,
+		"127.0.0.1:27015":        "127.0.0.1:27015",
+		"Play.Example.com:27015": "play.example.com:27015",
+	} {
+		id, err := c.ParseId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, id, s)
+	}
+	for _, s := range []string{"", "127.0.0.1", "127.0.0.1:0", "127.0.0.1:70000", "12345", "https://steamcommunity.com/id/test"} {
+		_, err := c.ParseId(s)
+		assert.Equal(t, ErrInvalidId, err, s)
+	}
+}
+
+func TestConcern_Server(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	oldTimeout := A2STimeout
+	A2STimeout = time.Millisecond * 200
+	defer func() { A2STimeout = oldTimeout }()
+
+	c := NewConcern(nil)
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1))
+
+	_, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), testSteamId, Server)
+	assert.Equal(t, ErrTypeMismatch, err)
+
+	conn := newTestA2SServer(t, nil)
+	addr := conn.LocalAddr().String()
+	identity, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), addr, Server)
+	assert.Nil(t, err)
+	assert.Equal(t, "test server", identity.GetName())
+
+	// 没有变化时不推送
+	info, err := c.freshServer(addr)
+	assert.Nil(t, err)
+	assert.Nil(t, info)
+
+	// 人数变化
+	old, err := c.GetServerInfo(addr)
+	assert.Nil(t, err)
+	old.Players = 1
+	assert.Nil(t, c.AddServerInfo(old))
+	info, err = c.freshServer(addr)
+	assert.Nil(t, err)
+	if assert.NotNil(t, info) {
+		assert.True(t, info.PlayersChanged())
+		assert.False(t, info.OnlineChanged())
+		assert.Equal(t, 5, info.Players)
+	}
+
+	// 连续查询失败才视为离线
+	conn.Close()
+	for i := 1; i < serverOfflineThreshold; i++ {
+		info, err = c.freshServer(addr)
+		assert.NotNil(t, err)
+		assert.Nil(t, info)
+	}
+	info, err = c.freshServer(addr)
+	assert.Nil(t, err)
+	if assert.NotNil(t, info) {
+		assert.True(t, info.OnlineChanged())
+		assert.False(t, info.Online)
+		assert.Equal(t, "test server", info.GetName())
+	}
+	info, err = c.freshServer(addr)
+	assert.NotNil(t, err)
+	assert.Nil(t, info)
+
+	assert.False(t, c.GetState(addr).Living)
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), addr, Server)
+	assert.Nil(t, err)
+	_, err = c.GetServerInfo(addr)
+	assert.NotNil(t, err)
+}
+
+func TestConcern_Status(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var game = "Dota 2"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.URL.Query().Get("key"))
+		w.Write([]byte(`{"response":{"players":[{"steamid":"` + testSteamId + `","personaname":"name","personastate":1,"gameextrainfo":"` + game + `"}]}}`))
+	}))
+	defer s.Close()
+	oldHost := ApiHost
+	ApiHost = s.URL
+	defer func() { ApiHost = oldHost }()
+
+	c := NewConcern(nil)
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1))
+
+	_, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), testSteamId, Status)
+	assert.NotNil(t, err)
+
+	config.GlobalConfig.Set("steam.apiKey", "test-key")
+	defer config.GlobalConfig.Set("steam.apiKey", nil)
+
+	_, err = c.Add(nil, mmsg.NewGroupTargetId(test.G1), "127.0.0.1:27015", Status)
+	assert.Equal(t, ErrTypeMismatch, err)
+
+	identity, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), testSteamId, Status)
+	assert.Nil(t, err)
+	assert.Equal(t, "name", identity.GetName())
+
+	status, err := c.freshUserStatus(testSteamId)
+	assert.Nil(t, err)
+	assert.Nil(t, status)
+
+	game = "Counter-Strike 2"
+	status, err = c.freshUserStatus(testSteamId)
+	assert.Nil(t, err)
+	if assert.NotNil(t, status) {
+		assert.True(t, status.GameChanged())
+		assert.False(t, status.OnlineChanged())
+		assert.Equal(t, ProfilePath(testSteamId), status.ProfileUrl)
+	}
+	state := c.GetState(testSteamId)
+	assert.True(t, state.Living)
+	assert.Equal(t, game, state.Title)
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), testSteamId, Status)
+	assert.Nil(t, err)
+	_, err = c.GetUserStatus(testSteamId)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,10 @@
+package steam
+
+import "errors"
+
+var (
+	ErrUserNotExist  = errors.New("用户不存在")
+	ErrApiKeyMissing = errors.New("找不到 Steam 配置，订阅Steam用户状态需要填写 steam.apiKey")
+	ErrInvalidId     = errors.New("无效的id，游戏服务器请使用 地址:端口 ，Steam用户请使用17位的SteamID")
+	ErrTypeMismatch  = errors.New("id与订阅类型不匹配，游戏服务器请使用 server 类型，Steam用户请使用 status 类型")
+)
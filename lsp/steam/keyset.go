@@ -0,0 +1,62 @@
+package steam
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"strings"
+)
+
+type keySet struct {
+}
+
+func (l *keySet) GroupAtAllMarkKey(keys ...interface{}) string {
+	return buntdb.SteamGroupAtAllMarkKey(keys...)
+}
+
+func (l *keySet) GroupConcernConfigKey(keys ...interface{}) string {
+	return buntdb.SteamGroupConcernConfigKey(keys...)
+}
+
+func (l *keySet) GroupConcernStateKey(keys ...interface{}) string {
+	return buntdb.SteamGroupConcernStateKey(keys...)
+}
+
+func (l *keySet) FreshKey(keys ...interface{}) string {
+	return buntdb.SteamFreshKey(keys...)
+}
+
+// ParseGroupConcernStateKey 服务器地址中含有 : ，所以不能使用 concern.ParseConcernStateKeyWithString
+func (l *keySet) ParseGroupConcernStateKey(key string) (mmsg.TargetId, interface{}, error) {
+	keys := strings.SplitN(key, ":", 3)
+	if len(keys) != 3 {
+		return mmsg.TargetId{}, nil, errors.New("invalid key")
+	}
+	target, err := mmsg.ParseTargetId(keys[1])
+	if err != nil {
+		return mmsg.TargetId{}, nil, err
+	}
+	return target, keys[2], nil
+}
+
+type extraKey struct{}
+
+func (k extraKey) ServerInfoKey(keys ...interface{}) string {
+	return buntdb.SteamServerInfoKey(keys...)
+}
+
+func (k extraKey) ServerOfflineKey(keys ...interface{}) string {
+	return buntdb.SteamServerOfflineKey(keys...)
+}
+
+func (k extraKey) UserStatusKey(keys ...interface{}) string {
+	return buntdb.SteamUserStatusKey(keys...)
+}
+
+func NewExtraKey() *extraKey {
+	return &extraKey{}
+}
+
+func NewKeySet() *keySet {
+	return &keySet{}
+}
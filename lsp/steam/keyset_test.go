@@ -0,0 +1,23 @@
+package steam
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewKeySet(t *testing.T) {
+	s := NewKeySet()
+	assert.NotNil(t, s)
+	s.GroupAtAllMarkKey()
+	s.FreshKey()
+
+	target, id, err := s.ParseGroupConcernStateKey(s.GroupConcernStateKey(mmsg.NewGroupTargetId(test.G1), "127.0.0.1:27015"))
+	assert.Nil(t, err)
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), target)
+	assert.Equal(t, "127.0.0.1:27015", id)
+
+	_, _, err = s.ParseGroupConcernStateKey("invalid")
+	assert.NotNil(t, err)
+}
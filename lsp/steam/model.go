@@ -0,0 +1,214 @@
+package steam
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+type ServerInfo struct {
+	Address    string `json:"address"`
+	Name       string `json:"name"`
+	Map        string `json:"map"`
+	Game       string `json:"game"`
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"max_players"`
+	Online     bool   `json:"online"`
+
+	once           sync.Once
+	msgCache       *mmsg.MSG
+	onlineChanged  bool
+	playersChanged bool
+}
+
+func (s *ServerInfo) OnlineChanged() bool {
+	return s.onlineChanged
+}
+
+func (s *ServerInfo) PlayersChanged() bool {
+	return s.playersChanged
+}
+
+func (s *ServerInfo) GetUid() interface{} {
+	return s.Address
+}
+
+func (s *ServerInfo) GetName() string {
+	if s == nil {
+		return ""
+	}
+	if len(s.Name) == 0 {
+		return s.Address
+	}
+	return s.Name
+}
+
+func (s *ServerInfo) Type() concern_type.Type {
+	return Server
+}
+
+func (s *ServerInfo) Site() string {
+	return Site
+}
+
+func (s *ServerInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":    Site,
+		"Address": s.Address,
+		"Name":    s.Name,
+		"Online":  s.Online,
+		"Players": s.Players,
+	})
+}
+
+func (s *ServerInfo) GetMSG() *mmsg.MSG {
+	s.once.Do(func() {
+		var data = map[string]interface{}{
+			"name":           s.GetName(),
+			"address":        s.Address,
+			"map":            s.Map,
+			"game":           s.Game,
+			"players":        s.Players,
+			"max_players":    s.MaxPlayers,
+			"online":         s.Online,
+			"online_changed": s.onlineChanged,
+		}
+		var err error
+		s.msgCache, err = template.LoadAndExec("notify.group.steam.server.tmpl", data)
+		if err != nil {
+			logger.Errorf("steam: ServerInfo LoadAndExec error %v", err)
+		}
+	})
+	return s.msgCache
+}
+
+type UserStatus struct {
+	SteamId    string `json:"steam_id"`
+	Name       string `json:"name"`
+	Avatar     string `json:"avatar"`
+	ProfileUrl string `json:"profile_url"`
+	Online     bool   `json:"online"`
+	Game       string `json:"game"`
+
+	once          sync.Once
+	msgCache      *mmsg.MSG
+	onlineChanged bool
+	gameChanged   bool
+}
+
+func (u *UserStatus) OnlineChanged() bool {
+	return u.onlineChanged
+}
+
+func (u *UserStatus) GameChanged() bool {
+	return u.gameChanged
+}
+
+func (u *UserStatus) GetUid() interface{} {
+	return u.SteamId
+}
+
+func (u *UserStatus) GetName() string {
+	if u == nil {
+		return ""
+	}
+	return u.Name
+}
+
+func (u *UserStatus) Type() concern_type.Type {
+	return Status
+}
+
+func (u *UserStatus) Site() string {
+	return Site
+}
+
+func (u *UserStatus) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":    Site,
+		"SteamId": u.SteamId,
+		"Name":    u.Name,
+		"Online":  u.Online,
+		"Game":    u.Game,
+	})
+}
+
+func (u *UserStatus) GetMSG() *mmsg.MSG {
+	u.once.Do(func() {
+		var data = map[string]interface{}{
+			"name":   u.Name,
+			"online": u.Online,
+			"game":   u.Game,
+			"url":    u.ProfileUrl,
+			"avatar": u.Avatar,
+		}
+		var err error
+		u.msgCache, err = template.LoadAndExec("notify.group.steam.status.tmpl", data)
+		if err != nil {
+			logger.Errorf("steam: UserStatus LoadAndExec error %v", err)
+		}
+	})
+	return u.msgCache
+}
+
+type ConcernServerNotify struct {
+	*ServerInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernServerNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernServerNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.ServerInfo.GetMSG()
+}
+
+func (notify *ConcernServerNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.ServerInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernServerNotify(target mmsg.TargetId, s *ServerInfo) *ConcernServerNotify {
+	if s == nil {
+		return nil
+	}
+	return &ConcernServerNotify{
+		s,
+		target,
+	}
+}
+
+type ConcernStatusNotify struct {
+	*UserStatus
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernStatusNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernStatusNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.UserStatus.GetMSG()
+}
+
+func (notify *ConcernStatusNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.UserStatus.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernStatusNotify(target mmsg.TargetId, u *UserStatus) *ConcernStatusNotify {
+	if u == nil {
+		return nil
+	}
+	return &ConcernStatusNotify{
+		u,
+		target,
+	}
+}
@@ -0,0 +1,57 @@
+package steam
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestServerInfo(t *testing.T) {
+	s := &ServerInfo{
+		Address:    "127.0.0.1:27015",
+		Name:       test.NAME1,
+		Map:        "de_dust2",
+		Players:    5,
+		MaxPlayers: 10,
+		Online:     true,
+	}
+	assert.Equal(t, Site, s.Site())
+	assert.Equal(t, "127.0.0.1:27015", s.GetUid())
+	assert.Equal(t, test.NAME1, s.GetName())
+	assert.Equal(t, Server, s.Type())
+	notify := NewConcernServerNotify(mmsg.NewGroupTargetId(test.G1), s)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+
+	s.onlineChanged = true
+	m := msgstringer.MsgToString(notify.ToMessage().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements)
+	assert.Equal(t, "Steam服务器-"+test.NAME1+"上线了\n地图：de_dust2\n人数：5/10\n地址：127.0.0.1:27015", m)
+
+	s = &ServerInfo{Address: "127.0.0.1:27015", onlineChanged: true}
+	assert.Equal(t, "127.0.0.1:27015", s.GetName())
+	m = msgstringer.MsgToString(s.GetMSG().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements)
+	assert.Equal(t, "Steam服务器-127.0.0.1:27015离线了\n地址：127.0.0.1:27015", m)
+}
+
+func TestUserStatus(t *testing.T) {
+	u := &UserStatus{
+		SteamId:    testSteamId,
+		Name:       test.NAME1,
+		ProfileUrl: ProfilePath(testSteamId),
+		Online:     true,
+		Game:       "Dota 2",
+	}
+	assert.Equal(t, Site, u.Site())
+	assert.Equal(t, testSteamId, u.GetUid())
+	assert.Equal(t, test.NAME1, u.GetName())
+	assert.Equal(t, Status, u.Type())
+	notify := NewConcernStatusNotify(mmsg.NewGroupTargetId(test.G1), u)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	m := msgstringer.MsgToString(notify.ToMessage().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements)
+	assert.Contains(t, m, "Steam-"+test.NAME1+"正在玩【Dota 2】")
+}
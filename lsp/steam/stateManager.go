@@ -0,0 +1,103 @@
+package steam
+
+import (
+	"errors"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"time"
+)
+
+type StateManager struct {
+	*concern.StateManager
+	*extraKey
+}
+
+func (c *StateManager) GetServerInfo(addr string) (*ServerInfo, error) {
+	var serverInfo = &ServerInfo{}
+	err := c.GetJson(c.ServerInfoKey(addr), serverInfo)
+	if err != nil {
+		return nil, err
+	}
+	return serverInfo, nil
+}
+
+func (c *StateManager) AddServerInfo(serverInfo *ServerInfo) error {
+	if serverInfo == nil {
+		return errors.New("nil ServerInfo")
+	}
+	return c.SetJson(c.ServerInfoKey(serverInfo.Address), serverInfo, localdb.SetExpireOpt(time.Hour*24*7))
+}
+
+func (c *StateManager) DeleteServerInfo(addr string) error {
+	_, err := c.Delete(c.ServerInfoKey(addr), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// IncServerOfflineCount 记录一次服务器查询失败，返回连续失败的次数
+func (c *StateManager) IncServerOfflineCount(addr string) int64 {
+	result, err := c.SeqNext(c.ServerOfflineKey(addr))
+	if err != nil {
+		result = 0
+	}
+	return result
+}
+
+func (c *StateManager) ClearServerOfflineCount(addr string) error {
+	_, err := c.Delete(c.ServerOfflineKey(addr), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+func (c *StateManager) GetUserStatus(steamId string) (*UserStatus, error) {
+	var userStatus = &UserStatus{}
+	err := c.GetJson(c.UserStatusKey(steamId), userStatus)
+	if err != nil {
+		return nil, err
+	}
+	return userStatus, nil
+}
+
+func (c *StateManager) AddUserStatus(userStatus *UserStatus) error {
+	if userStatus == nil {
+		return errors.New("nil UserStatus")
+	}
+	return c.SetJson(c.UserStatusKey(userStatus.SteamId), userStatus, localdb.SetExpireOpt(time.Hour*24*7))
+}
+
+func (c *StateManager) DeleteUserStatus(steamId string) error {
+	_, err := c.Delete(c.UserStatusKey(steamId), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+// GetState 实现 concern.StateExt ，游戏服务器的Title为服务器名字，Steam用户的Title为正在玩的游戏
+func (c *StateManager) GetState(id interface{}) *concern.State {
+	if IsSteamId(id.(string)) {
+		userStatus, err := c.GetUserStatus(id.(string))
+		if err != nil {
+			return nil
+		}
+		return &concern.State{
+			Living: userStatus.Online,
+			Title:  userStatus.Game,
+		}
+	}
+	serverInfo, err := c.GetServerInfo(id.(string))
+	if err != nil {
+		return nil
+	}
+	return &concern.State{
+		Living: serverInfo.Online,
+		Title:  serverInfo.GetName(),
+	}
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
+}
+
+func NewStateManager(notify chan<- concern.Notify) *StateManager {
+	sm := &StateManager{}
+	sm.extraKey = NewExtraKey()
+	sm.StateManager = concern.NewStateManagerWithCustomKey(Site, NewKeySet(), notify)
+	return sm
+}
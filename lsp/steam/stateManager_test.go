@@ -0,0 +1,73 @@
+package steam
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initStateManager(t *testing.T) *StateManager {
+	sm := NewStateManager(nil)
+	assert.NotNil(t, sm)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	return sm
+}
+
+func TestStateManager_ServerInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1))
+
+	_, err := sm.GetServerInfo(test.NAME1)
+	assert.NotNil(t, err)
+	assert.Nil(t, sm.GetState(test.NAME1))
+
+	expected := &ServerInfo{
+		Address: test.NAME1,
+		Name:    test.NAME2,
+		Online:  true,
+	}
+	assert.Nil(t, sm.AddServerInfo(expected))
+	actual, err := sm.GetServerInfo(test.NAME1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+	assert.True(t, sm.GetState(test.NAME1).Living)
+
+	assert.EqualValues(t, 1, sm.IncServerOfflineCount(test.NAME1))
+	assert.EqualValues(t, 2, sm.IncServerOfflineCount(test.NAME1))
+	assert.Nil(t, sm.ClearServerOfflineCount(test.NAME1))
+	assert.EqualValues(t, 1, sm.IncServerOfflineCount(test.NAME1))
+
+	assert.Nil(t, sm.DeleteServerInfo(test.NAME1))
+	assert.Nil(t, sm.DeleteServerInfo(test.NAME1))
+	_, err = sm.GetServerInfo(test.NAME1)
+	assert.NotNil(t, err)
+}
+
+func TestStateManager_UserStatus(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	_, err := sm.GetUserStatus(testSteamId)
+	assert.NotNil(t, err)
+
+	expected := &UserStatus{
+		SteamId: testSteamId,
+		Name:    test.NAME1,
+		Online:  true,
+	}
+	assert.Nil(t, sm.AddUserStatus(expected))
+	actual, err := sm.GetUserStatus(testSteamId)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+
+	assert.Nil(t, sm.DeleteUserStatus(testSteamId))
+	_, err = sm.GetUserStatus(testSteamId)
+	assert.NotNil(t, err)
+}
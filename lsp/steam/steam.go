@@ -0,0 +1,138 @@
+package steam
+
+import (
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Site             = "steam"
+	CommunityHost    = "https://steamcommunity.com"
+	PathPlayerSummay = "/ISteamUser/GetPlayerSummaries/v2/"
+)
+
+// ApiHost Steam Web API的地址
+var ApiHost = "https://api.steampowered.com"
+
+var steamIdRegexp = regexp.MustCompile(`^7656119\d{10}$`)
+
+func ApiPath(path string) string {
+	return ApiHost + path
+}
+
+func ProfilePath(steamId string) string {
+	return CommunityHost + "/profiles/" + steamId
+}
+
+// IsSteamId 返回id是否是64位的SteamID
+func IsSteamId(id string) bool {
+	return steamIdRegexp.MatchString(id)
+}
+
+// IsServerAddr 返回id是否是 地址:端口 格式的服务器地址
+func IsServerAddr(id string) bool {
+	host, port, err := net.SplitHostPort(id)
+	if err != nil || len(host) == 0 {
+		return false
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	return err == nil && p > 0
+}
+
+type PlayerSummary struct {
+	SteamId       string `json:"steamid"`
+	PersonaName   string `json:"personaname"`
+	ProfileUrl    string `json:"profileurl"`
+	AvatarFull    string `json:"avatarfull"`
+	PersonaState  int    `json:"personastate"`
+	GameExtraInfo string `json:"gameextrainfo"`
+	GameId        string `json:"gameid"`
+}
+
+type PlayerSummariesResponse struct {
+	Response struct {
+		Players []*PlayerSummary `json:"players"`
+	} `json:"response"`
+}
+
+// GetPlayerSummary 通过 Steam Web API 查询用户的状态，需要配置 steam.apiKey
+func GetPlayerSummary(steamId string) (*PlayerSummary, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	if len(cfg.GetSteamApiKey()) == 0 {
+		return nil, ErrApiKeyMissing
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferOversea),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}
+	var resp = new(PlayerSummariesResponse)
+	err := requests.Get(ApiPath(PathPlayerSummay), gout.H{
+		"key":      cfg.GetSteamApiKey(),
+		"steamids": steamId,
+	}, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, player := range resp.Response.Players {
+		if player.SteamId == steamId {
+			return player, nil
+		}
+	}
+	return nil, ErrUserNotExist
+}
+
+// LoadServerInfo 查询游戏服务器的信息，查询失败时返回error，由调用方判断是否离线
+func LoadServerInfo(addr string) (*ServerInfo, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	info, err := QueryA2SInfo(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerInfo{
+		Address:    addr,
+		Name:       strings.TrimSpace(info.Name),
+		Map:        info.Map,
+		Game:       info.Game,
+		Players:    info.Players,
+		MaxPlayers: info.MaxPlayers,
+		Online:     true,
+	}, nil
+}
+
+// LoadUserStatus 查询Steam用户的在线状态和正在玩的游戏
+func LoadUserStatus(steamId string) (*UserStatus, error) {
+	summary, err := GetPlayerSummary(steamId)
+	if err != nil {
+		return nil, err
+	}
+	var url = summary.ProfileUrl
+	if len(url) == 0 {
+		url = ProfilePath(steamId)
+	}
+	return &UserStatus{
+		SteamId:    steamId,
+		Name:       summary.PersonaName,
+		Avatar:     summary.AvatarFull,
+		ProfileUrl: url,
+		Online:     summary.PersonaState != 0,
+		Game:       summary.GameExtraInfo,
+	}, nil
+}
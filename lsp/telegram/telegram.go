@@ -0,0 +1,170 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mrs4s/MiraiGo/message"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/guonaihong/gout"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var logger = utils.GetModuleLogger("telegram")
+
+// Host Telegram Bot API的地址，测试时可以替换
+var Host = "https://api.telegram.org"
+
+const (
+	MethodSendMessage = "sendMessage"
+	MethodSendPhoto   = "sendPhoto"
+
+	// captionLimit 图片说明的最大长度，超过时文字与图片分开发送
+	captionLimit = 1024
+)
+
+type Message struct {
+	MessageId int64 `json:"message_id"`
+	Date      int64 `json:"date"`
+}
+
+type Response struct {
+	Ok          bool     `json:"ok"`
+	Description string   `json:"description"`
+	Result      *Message `json:"result"`
+}
+
+// Sender 通过Telegram bot发送推送
+type Sender struct {
+	token string
+}
+
+func NewSender(token string) *Sender {
+	return &Sender{token: token}
+}
+
+func (s *Sender) apiUrl(method string) string {
+	return fmt.Sprintf("%v/bot%v/%v", Host, s.token, method)
+}
+
+func (s *Sender) call(method string, params gout.H) (*Message, error) {
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferOversea),
+		requests.TimeoutOption(time.Second * 30),
+		requests.RetryOption(2),
+	}
+	var resp = new(Response)
+	if err := requests.PostForm(s.apiUrl(method), params, resp, opts...); err != nil {
+		return nil, err
+	}
+	if !resp.Ok || resp.Result == nil {
+		return nil, errors.New(resp.Description)
+	}
+	return resp.Result, nil
+}
+
+// SendMessage 发送文字消息
+func (s *Sender) SendMessage(chatId int64, text string) (*Message, error) {
+	return s.call(MethodSendMessage, gout.H{
+		"chat_id": strconv.FormatInt(chatId, 10),
+		"text":    text,
+	})
+}
+
+// SendPhoto 发送图片，caption为图片说明，可以为空
+func (s *Sender) SendPhoto(chatId int64, photo []byte, caption string) (*Message, error) {
+	var params = gout.H{
+		"chat_id": strconv.FormatInt(chatId, 10),
+		"photo": gout.FormType{
+			FileName: "image.jpg",
+			File:     gout.FormMem(photo),
+		},
+	}
+	if len(caption) > 0 {
+		params["caption"] = caption
+	}
+	return s.call(MethodSendPhoto, params)
+}
+
+// segment 一段需要一起发送的内容，对应 mmsg.CutElement 分割出的一部分
+type segment struct {
+	text   string
+	images [][]byte
+}
+
+// split 把 mmsg.MSG 按 mmsg.CutElement 分割，只保留文字与图片，@等QQ特有的内容会被忽略
+func split(m *mmsg.MSG) []*segment {
+	var result []*segment
+	var sb strings.Builder
+	var cur = new(segment)
+	flush := func() {
+		cur.text = strings.TrimSpace(sb.String())
+		if len(cur.text) > 0 || len(cur.images) > 0 {
+			result = append(result, cur)
+		}
+		sb.Reset()
+		cur = new(segment)
+	}
+	for _, e := range m.Elements() {
+		switch o := e.(type) {
+		case *message.TextElement:
+			sb.WriteString(o.Content)
+		case *mmsg.ImageBytesElement:
+			if o != nil && len(o.Buf) > 0 {
+				cur.images = append(cur.images, o.Buf)
+			}
+		case *mmsg.CutElement:
+			flush()
+		}
+	}
+	flush()
+	return result
+}
+
+// Send 发送推送到 mmsg.TelegramTarget ，返回结果转换成 message.GroupMessage 方便与QQ推送统一处理
+func (s *Sender) Send(target mmsg.Target, m *mmsg.MSG) []*message.GroupMessage {
+	log := logger.WithField("ChatId", target.TargetCode())
+	var result []*message.GroupMessage
+	addResult := func(msg *Message, err error) bool {
+		if err != nil {
+			log.Errorf("发送消息失败 %v", err)
+			result = append(result, &message.GroupMessage{Id: -1, GroupCode: target.TargetCode()})
+			return false
+		}
+		result = append(result, &message.GroupMessage{
+			Id:        int32(msg.MessageId),
+			GroupCode: target.TargetCode(),
+			Time:      int32(msg.Date),
+		})
+		return true
+	}
+	for _, seg := range split(m) {
+		var images = seg.images
+		var text = seg.text
+		if len(images) > 0 && len([]rune(text)) <= captionLimit {
+			if !addResult(s.SendPhoto(target.TargetCode(), images[0], text)) {
+				return result
+			}
+			images = images[1:]
+			text = ""
+		}
+		if len(text) > 0 {
+			if !addResult(s.SendMessage(target.TargetCode(), text)) {
+				return result
+			}
+		}
+		for _, image := range images {
+			if !addResult(s.SendPhoto(target.TargetCode(), image, "")) {
+				return result
+			}
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, &message.GroupMessage{Id: -1, GroupCode: target.TargetCode()})
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"encoding/json"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type request struct {
+	method  string
+	chatId  string
+	text    string
+	caption string
+	photo   bool
+}
+
+func newTestServer(t *testing.T, fail bool) (*httptest.Server, *[]*request) {
+	var mu sync.Mutex
+	var requests []*request
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/bottoken/"))
+		assert.Nil(t, r.ParseMultipartForm(1<<20))
+		_, _, err := r.FormFile("photo")
+		mu.Lock()
+		requests = append(requests, &request{
+			method:  strings.TrimPrefix(r.URL.Path, "/bottoken/"),
+			chatId:  r.FormValue("chat_id"),
+			text:    r.FormValue("text"),
+			caption: r.FormValue("caption"),
+			photo:   err == nil,
+		})
+		id := len(requests)
+		mu.Unlock()
+		if fail {
+			json.NewEncoder(w).Encode(&Response{Ok: false, Description: "Bad Request: chat not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(&Response{Ok: true, Result: &Message{MessageId: int64(id), Date: 1}})
+	}))
+	return s, &requests
+}
+
+func TestSplit(t *testing.T) {
+	m := mmsg.NewMSG()
+	m.Text("a")
+	m.Image([]byte{1}, "")
+	m.At(123)
+	m.Cut()
+	m.Text(" b ")
+	m.Image(nil, "[图片]")
+	m.Cut()
+
+	segs := split(m)
+	if assert.Len(t, segs, 2) {
+		assert.Equal(t, "a", segs[0].text)
+		assert.Len(t, segs[0].images, 1)
+		assert.Equal(t, "b", segs[1].text)
+		assert.Len(t, segs[1].images, 0)
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	s, requests := newTestServer(t, false)
+	defer s.Close()
+	oldHost := Host
+	Host = s.URL
+	defer func() { Host = oldHost }()
+
+	sender := NewSender("token")
+	target := mmsg.NewTelegramTarget(-100123)
+
+	m := mmsg.NewMSG()
+	m.Text("title")
+	m.Image([]byte{1}, "")
+	m.Image([]byte{2}, "")
+	m.Cut()
+	m.Text("second")
+
+	msgs := sender.Send(target, m)
+	assert.Len(t, msgs, 3)
+	for _, msg := range msgs {
+		assert.NotEqual(t, int32(-1), msg.Id)
+		assert.Equal(t, int64(-100123), msg.GroupCode)
+	}
+	if assert.Len(t, *requests, 3) {
+		r := *requests
+		assert.Equal(t, MethodSendPhoto, r[0].method)
+		assert.Equal(t, "-100123", r[0].chatId)
+		assert.Equal(t, "title", r[0].caption)
+		assert.True(t, r[0].photo)
+		assert.Equal(t, MethodSendPhoto, r[1].method)
+		assert.Equal(t, "", r[1].caption)
+		assert.Equal(t, MethodSendMessage, r[2].method)
+		assert.Equal(t, "second", r[2].text)
+	}
+}
+
+func TestSender_SendFail(t *testing.T) {
+	s, requests := newTestServer(t, true)
+	defer s.Close()
+	oldHost := Host
+	Host = s.URL
+	defer func() { Host = oldHost }()
+
+	sender := NewSender("token")
+	msgs := sender.Send(mmsg.NewTelegramTarget(1), mmsg.NewText("a").Cut().Text("b"))
+	if assert.Len(t, msgs, 1) {
+		assert.EqualValues(t, -1, msgs[0].Id)
+	}
+	assert.Len(t, *requests, 1)
+
+	msgs = sender.Send(mmsg.NewTelegramTarget(1), mmsg.NewMSG())
+	if assert.Len(t, msgs, 1) {
+		assert.EqualValues(t, -1, msgs[0].Id)
+	}
+}
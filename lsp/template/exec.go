@@ -970,6 +970,12 @@ func indirectInterface(v reflect.Value) reflect.Value {
 // the template.
 func (s *state) printValue(n parse.Node, v reflect.Value) {
 	s.at(n)
+	if v.IsValid() && v.CanInterface() {
+		if m, ok := v.Interface().(*mmsg.MSG); ok {
+			s.wr.Append(m.Elements()...)
+			return
+		}
+	}
 	iface, ok := printableValue(v)
 	if !ok {
 		s.errorf("can't print %s of type %s", n, v.Type())
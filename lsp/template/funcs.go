@@ -77,6 +77,12 @@ func builtins() FuncMap {
 		"abort":    abort,
 		"fin":      fin,
 
+		// store
+		"storeGet": storeGet,
+		"storeSet": storeSet,
+		"storeInc": storeInc,
+		"storeDel": storeDel,
+
 		// cast
 		"float64": toFloat64,
 		"int":     toInt,
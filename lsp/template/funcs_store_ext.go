@@ -0,0 +1,64 @@
+package template
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"time"
+)
+
+// 模板存储的value长度上限，防止模板写入过大的数据
+const storeValueLimit = 4096
+
+// storeGet 读取一个值，不存在时返回空字符串
+// 模板只能读写 TemplateStore 下的key，无法访问bot的其他数据
+func storeGet(key string) string {
+	value, err := localdb.Get(localdb.TemplateStoreKey(key), localdb.IgnoreNotFoundOpt())
+	if err != nil {
+		logger.Errorf("template: storeGet <%v> error %v", key, err)
+		panic("INTERNAL: db error")
+	}
+	return value
+}
+
+// storeSet 写入一个值，可以指定过期时间，例如 "24h"
+func storeSet(key string, value interface{}, ttl ...string) string {
+	var v = strval(value)
+	if len(v) > storeValueLimit {
+		panic(fmt.Sprintf("storeSet: value is too long (%v > %v)", len(v), storeValueLimit))
+	}
+	var opts []localdb.OptionFunc
+	if len(ttl) > 0 && len(ttl[0]) > 0 {
+		d, err := time.ParseDuration(ttl[0])
+		if err != nil {
+			panic(fmt.Sprintf("ParseDuration: can not parse <%v>: %v", ttl[0], err))
+		}
+		opts = append(opts, localdb.SetExpireOpt(d))
+	}
+	if err := localdb.Set(localdb.TemplateStoreKey(key), v, opts...); err != nil {
+		logger.Errorf("template: storeSet <%v> error %v", key, err)
+		panic("INTERNAL: db error")
+	}
+	return ""
+}
+
+// storeInc 把一个值当作整数增加delta，默认为1，返回增加后的结果
+func storeInc(key string, delta ...interface{}) int64 {
+	var d int64 = 1
+	if len(delta) > 0 {
+		d = toInt64(delta[0])
+	}
+	result, err := localdb.IncInt64(localdb.TemplateStoreKey(key), d)
+	if err != nil {
+		logger.Errorf("template: storeInc <%v> error %v", key, err)
+		panic("INTERNAL: db error")
+	}
+	return result
+}
+
+func storeDel(key string) string {
+	if _, err := localdb.Delete(localdb.TemplateStoreKey(key), localdb.IgnoreNotFoundOpt()); err != nil {
+		logger.Errorf("template: storeDel <%v> error %v", key, err)
+		panic("INTERNAL: db error")
+	}
+	return ""
+}
@@ -312,6 +312,37 @@ false
 	assert.EqualValues(t, "true", s)
 }
 
+func TestTemplateStore(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	s, err := runTemplate(`{{- storeGet "k1" -}}`, nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", s)
+
+	s, err = runTemplate(`{{- storeSet "k1" "v1" -}}{{- storeGet "k1" -}}`, nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "v1", s)
+
+	s, err = runTemplate(`{{- storeInc "k2" }} {{ storeInc "k2" 5 -}}`, nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "1 6", s)
+
+	s, err = runTemplate(`{{- storeDel "k1" -}}{{- storeGet "k1" -}}`, nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", s)
+
+	_, err = runTemplate(`{{- storeSet "k1" "v1" "wrong" -}}`, nil)
+	assert.NotNil(t, err)
+}
+
+func TestTemplatePrintMSG(t *testing.T) {
+	s, err := runTemplate(`前缀 {{ .msg }}`, map[string]interface{}{
+		"msg": mmsg.NewText("推送内容"),
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "前缀 推送内容", s)
+}
+
 func TestAbort(t *testing.T) {
 	test.InitBuntdb(t)
 	defer test.CloseBuntdb(t)
@@ -0,0 +1,265 @@
+package translate
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/guonaihong/gout"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var logger = utils.GetModuleLogger("translate")
+
+// MaxTextLength 翻译的文本超过这个长度时会截断，避免一条长动态消耗过多的翻译额度
+const MaxTextLength = 2000
+
+var (
+	ErrNotConfigured        = errors.New("没有配置翻译服务")
+	ErrProviderNotSupported = errors.New("不支持的翻译服务")
+)
+
+// 各个翻译服务的地址，测试时会替换为本地的地址
+var (
+	BaiduUrl     = "https://fanyi-api.baidu.com/api/trans/vip/translate"
+	DeeplFreeUrl = "https://api-free.deepl.com/v2/translate"
+	DeeplProUrl  = "https://api.deepl.com/v2/translate"
+	GoogleUrl    = "https://translation.googleapis.com/language/translate/v2"
+)
+
+// Provider 翻译服务
+type Provider interface {
+	Name() string
+	// Translate 把text翻译为target语言，target使用 zh 、 en 、 ja 这样的语言代码
+	Translate(text string, target string) (string, error)
+}
+
+// GetProvider 根据配置文件中的 translate.provider 返回翻译服务，没有配置时返回 ErrNotConfigured
+func GetProvider() (Provider, error) {
+	switch name := cfg.GetTranslateProvider(); name {
+	case "":
+		return nil, ErrNotConfigured
+	case "baidu":
+		appId, secret := cfg.GetTranslateBaidu()
+		if appId == "" || secret == "" {
+			return nil, ErrNotConfigured
+		}
+		return &Baidu{AppId: appId, Secret: secret}, nil
+	case "deepl":
+		authKey := cfg.GetTranslateDeeplAuthKey()
+		if authKey == "" {
+			return nil, ErrNotConfigured
+		}
+		return &Deepl{AuthKey: authKey}, nil
+	case "google":
+		apiKey := cfg.GetTranslateGoogleApiKey()
+		if apiKey == "" {
+			return nil, ErrNotConfigured
+		}
+		return &Google{ApiKey: apiKey}, nil
+	default:
+		return nil, fmt.Errorf("%w %v", ErrProviderNotSupported, name)
+	}
+}
+
+var urlRegex = regexp.MustCompile(`https?://\S+`)
+
+// NeedTranslate 判断文本是否需要翻译：包含日文假名或者韩文时需要翻译，
+// 包含汉字但没有假名时认为是中文，不需要翻译，其他情况下包含足够多的拉丁字母时需要翻译
+func NeedTranslate(text string) bool {
+	text = urlRegex.ReplaceAllString(text, "")
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			return true
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	return han == 0 && latin >= 4
+}
+
+func textHash(text string) string {
+	h := sha1.Sum([]byte(text))
+	return hex.EncodeToString(h[:])
+}
+
+// Translate 使用配置的翻译服务把text翻译为 translate.target 配置的语言，
+// 不需要翻译时返回空字符串，翻译结果会缓存在数据库中
+func Translate(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if !NeedTranslate(text) {
+		return "", nil
+	}
+	provider, err := GetProvider()
+	if err != nil {
+		return "", err
+	}
+	if r := []rune(text); len(r) > MaxTextLength {
+		text = string(r[:MaxTextLength])
+	}
+	var (
+		target = cfg.GetTranslateTarget()
+		key    = localdb.TranslateCacheKey(provider.Name(), target, textHash(text))
+		log    = logger.WithField("provider", provider.Name())
+	)
+	if result, err := localdb.Get(key); err == nil {
+		return result, nil
+	} else if !localdb.IsNotFound(err) {
+		log.Errorf("get translate cache error %v", err)
+	}
+	result, err := provider.Translate(text, target)
+	if err != nil {
+		return "", err
+	}
+	result = strings.TrimSpace(result)
+	if result == "" {
+		return "", nil
+	}
+	if err := localdb.Set(key, result, localdb.SetExpireOpt(cfg.GetTranslateCacheTTL())); err != nil {
+		log.Errorf("set translate cache error %v", err)
+	}
+	return result, nil
+}
+
+// Baidu 百度翻译开放平台 https://fanyi-api.baidu.com/
+type Baidu struct {
+	AppId  string
+	Secret string
+}
+
+func (b *Baidu) Name() string {
+	return "baidu"
+}
+
+// 百度翻译部分语言代码与通用的不一样
+var baiduLang = map[string]string{
+	"ja": "jp",
+	"ko": "kor",
+	"fr": "fra",
+	"es": "spa",
+}
+
+func (b *Baidu) Translate(text string, target string) (string, error) {
+	if lang, found := baiduLang[target]; found {
+		target = lang
+	}
+	var salt = strconv.Itoa(rand.Int())
+	var sign = md5.Sum([]byte(b.AppId + text + salt + b.Secret))
+	var resp = new(struct {
+		ErrorCode   string `json:"error_code"`
+		ErrorMsg    string `json:"error_msg"`
+		TransResult []struct {
+			Src string `json:"src"`
+			Dst string `json:"dst"`
+		} `json:"trans_result"`
+	})
+	err := requests.PostWWWForm(BaiduUrl, gout.H{
+		"q":     text,
+		"from":  "auto",
+		"to":    target,
+		"appid": b.AppId,
+		"salt":  salt,
+		"sign":  hex.EncodeToString(sign[:]),
+	}, resp, requests.TimeoutOption(time.Second*10))
+	if err != nil {
+		return "", err
+	}
+	if resp.ErrorCode != "" && resp.ErrorCode != "52000" {
+		return "", fmt.Errorf("baidu error %v %v", resp.ErrorCode, resp.ErrorMsg)
+	}
+	var result []string
+	for _, r := range resp.TransResult {
+		result = append(result, r.Dst)
+	}
+	return strings.Join(result, "\n"), nil
+}
+
+// Deepl DeepL翻译 https://www.deepl.com/pro-api
+type Deepl struct {
+	AuthKey string
+}
+
+func (d *Deepl) Name() string {
+	return "deepl"
+}
+
+func (d *Deepl) Translate(text string, target string) (string, error) {
+	var url = DeeplProUrl
+	// 免费版的key以 :fx 结尾，需要使用单独的地址
+	if strings.HasSuffix(d.AuthKey, ":fx") {
+		url = DeeplFreeUrl
+	}
+	var resp = new(struct {
+		Translations []struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		} `json:"translations"`
+	})
+	err := requests.PostWWWForm(url, gout.H{
+		"text":        text,
+		"target_lang": strings.ToUpper(target),
+	}, resp,
+		requests.HeaderOption("Authorization", "DeepL-Auth-Key "+d.AuthKey),
+		requests.TimeoutOption(time.Second*10),
+	)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Translations) == 0 {
+		return "", errors.New("deepl empty translations")
+	}
+	return resp.Translations[0].Text, nil
+}
+
+// Google Google Cloud Translation https://cloud.google.com/translate
+type Google struct {
+	ApiKey string
+}
+
+func (g *Google) Name() string {
+	return "google"
+}
+
+func (g *Google) Translate(text string, target string) (string, error) {
+	var resp = new(struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText         string `json:"translatedText"`
+				DetectedSourceLanguage string `json:"detectedSourceLanguage"`
+			} `json:"translations"`
+		} `json:"data"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	})
+	err := requests.PostWWWForm(GoogleUrl+"?key="+g.ApiKey, gout.H{
+		"q":      text,
+		"target": target,
+		"format": "text",
+	}, resp, requests.TimeoutOption(time.Second*10))
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("google error %v %v", resp.Error.Code, resp.Error.Message)
+	}
+	if len(resp.Data.Translations) == 0 {
+		return "", errors.New("google empty translations")
+	}
+	return resp.Data.Translations[0].TranslatedText, nil
+}
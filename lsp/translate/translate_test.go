@@ -0,0 +1,157 @@
+package translate
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNeedTranslate(t *testing.T) {
+	assert.True(t, NeedTranslate("今日は配信します"))
+	assert.True(t, NeedTranslate("오늘 방송합니다"))
+	assert.True(t, NeedTranslate("Streaming tonight!"))
+	assert.False(t, NeedTranslate("今晚直播"))
+	assert.False(t, NeedTranslate("今晚直播 see you"))
+	assert.False(t, NeedTranslate("gm"))
+	assert.False(t, NeedTranslate("https://www.bilibili.com 123"))
+	assert.False(t, NeedTranslate(""))
+}
+
+func TestGetProvider(t *testing.T) {
+	defer func() {
+		config.GlobalConfig.Set("translate", nil)
+	}()
+	_, err := GetProvider()
+	assert.Equal(t, ErrNotConfigured, err)
+
+	config.GlobalConfig.Set("translate.provider", "baidu")
+	_, err = GetProvider()
+	assert.Equal(t, ErrNotConfigured, err)
+	config.GlobalConfig.Set("translate.baidu.appId", "id")
+	config.GlobalConfig.Set("translate.baidu.secret", "secret")
+	p, err := GetProvider()
+	assert.Nil(t, err)
+	assert.Equal(t, "baidu", p.Name())
+
+	config.GlobalConfig.Set("translate.provider", "DeepL")
+	config.GlobalConfig.Set("translate.deepl.authKey", "key")
+	p, err = GetProvider()
+	assert.Nil(t, err)
+	assert.Equal(t, "deepl", p.Name())
+
+	config.GlobalConfig.Set("translate.provider", "google")
+	config.GlobalConfig.Set("translate.google.apiKey", "key")
+	p, err = GetProvider()
+	assert.Nil(t, err)
+	assert.Equal(t, "google", p.Name())
+
+	config.GlobalConfig.Set("translate.provider", "unknown")
+	_, err = GetProvider()
+	assert.ErrorIs(t, err, ErrProviderNotSupported)
+}
+
+func TestProviders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		switch r.URL.Path {
+		case "/baidu":
+			assert.Equal(t, "jp", r.PostForm.Get("to"))
+			assert.NotEmpty(t, r.PostForm.Get("sign"))
+			w.Write([]byte(`{"from":"en","to":"jp","trans_result":[{"src":"a","dst":"b"},{"src":"c","dst":"d"}]}`))
+		case "/baidu_error":
+			w.Write([]byte(`{"error_code":"54001","error_msg":"Invalid Sign"}`))
+		case "/deepl":
+			assert.Equal(t, "DeepL-Auth-Key key:fx", r.Header.Get("Authorization"))
+			assert.Equal(t, "ZH", r.PostForm.Get("target_lang"))
+			w.Write([]byte(`{"translations":[{"detected_source_language":"JA","text":"翻译"}]}`))
+		case "/google":
+			assert.Equal(t, "key", r.URL.Query().Get("key"))
+			assert.Equal(t, "zh", r.PostForm.Get("target"))
+			w.Write([]byte(`{"data":{"translations":[{"translatedText":"翻译","detectedSourceLanguage":"en"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	var baiduUrl, deeplUrl, googleUrl = BaiduUrl, DeeplFreeUrl, GoogleUrl
+	defer func() {
+		BaiduUrl, DeeplFreeUrl, GoogleUrl = baiduUrl, deeplUrl, googleUrl
+	}()
+
+	BaiduUrl = ts.URL + "/baidu"
+	result, err := (&Baidu{AppId: "id", Secret: "secret"}).Translate("test", "ja")
+	assert.Nil(t, err)
+	assert.Equal(t, "b\nd", result)
+
+	BaiduUrl = ts.URL + "/baidu_error"
+	_, err = (&Baidu{AppId: "id", Secret: "secret"}).Translate("test", "zh")
+	assert.NotNil(t, err)
+
+	DeeplFreeUrl = ts.URL + "/deepl"
+	result, err = (&Deepl{AuthKey: "key:fx"}).Translate("test", "zh")
+	assert.Nil(t, err)
+	assert.Equal(t, "翻译", result)
+
+	GoogleUrl = ts.URL + "/google"
+	result, err = (&Google{ApiKey: "key"}).Translate("test", "zh")
+	assert.Nil(t, err)
+	assert.Equal(t, "翻译", result)
+
+	GoogleUrl = ts.URL + "/not_found"
+	_, err = (&Google{ApiKey: "key"}).Translate("test", "zh")
+	assert.NotNil(t, err)
+}
+
+func TestTranslate(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Write([]byte(`{"data":{"translations":[{"translatedText":"今晚直播"}]}}`))
+	}))
+	defer ts.Close()
+
+	var googleUrl = GoogleUrl
+	GoogleUrl = ts.URL
+	defer func() {
+		GoogleUrl = googleUrl
+		config.GlobalConfig.Set("translate", nil)
+	}()
+
+	// 没有配置翻译服务
+	_, err := Translate("今夜配信します")
+	assert.Equal(t, ErrNotConfigured, err)
+
+	config.GlobalConfig.Set("translate.provider", "google")
+	config.GlobalConfig.Set("translate.google.apiKey", "key")
+
+	// 中文不需要翻译
+	result, err := Translate("今晚直播")
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&count))
+
+	result, err = Translate("今夜配信します")
+	assert.Nil(t, err)
+	assert.Equal(t, "今晚直播", result)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&count))
+
+	// 第二次使用缓存
+	result, err = Translate("今夜配信します")
+	assert.Nil(t, err)
+	assert.Equal(t, "今晚直播", result)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&count))
+
+	// 目标语言不同时不使用缓存
+	config.GlobalConfig.Set("translate.target", "en")
+	_, err = Translate("今夜配信します")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&count))
+}
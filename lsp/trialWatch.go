@@ -0,0 +1,295 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+const (
+	// trialWatchCheckInterval 检查试用订阅是否到期的间隔
+	trialWatchCheckInterval = time.Minute
+	// trialWatchGrace 试用订阅的记录在到期后额外保留的时间，bot离线期间到期的试用订阅在重新上线后仍然可以被清理
+	trialWatchGrace = time.Hour * 24 * 7
+)
+
+var ErrTrialWatchLimit = errors.New("本群的试用订阅已达到上限")
+
+// TrialWatch 群成员添加的试用订阅，到期后自动取消并通知添加的成员，管理员使用 /watch 后转为正式订阅
+type TrialWatch struct {
+	GroupCode  int64             `json:"group_code"`
+	Site       string            `json:"site"`
+	Id         string            `json:"id"`
+	Type       concern_type.Type `json:"type"`
+	Requester  int64             `json:"requester"`
+	ExpireTime int64             `json:"expire_time"`
+}
+
+// AddTrialWatch 保存试用订阅，群内的试用订阅达到 cfg.GetTrialWatchMaxPerGroup 时返回 ErrTrialWatchLimit
+func (s *StateManager) AddTrialWatch(trial *TrialWatch) error {
+	return s.RWCover(func() error {
+		trials, err := s.ListTrialWatch(trial.GroupCode)
+		if err != nil {
+			return err
+		}
+		if len(trials) >= cfg.GetTrialWatchMaxPerGroup() {
+			return ErrTrialWatchLimit
+		}
+		expire := time.Until(time.Unix(trial.ExpireTime, 0)) + trialWatchGrace
+		return s.SetJson(s.TrialWatchKey(trial.GroupCode, trial.Site, trial.Id), trial, localdb.SetExpireOpt(expire))
+	})
+}
+
+// GetTrialWatch 返回群内的试用订阅，不存在时返回 localdb.ErrNotFound
+func (s *StateManager) GetTrialWatch(groupCode int64, site string, id interface{}) (*TrialWatch, error) {
+	var trial = new(TrialWatch)
+	if err := s.GetJson(s.TrialWatchKey(groupCode, site, id), trial); err != nil {
+		return nil, err
+	}
+	return trial, nil
+}
+
+// DeleteTrialWatch 删除群内的试用订阅记录，不会取消订阅，不存在时返回 localdb.ErrNotFound
+func (s *StateManager) DeleteTrialWatch(groupCode int64, site string, id interface{}) error {
+	_, err := s.Delete(s.TrialWatchKey(groupCode, site, id))
+	return err
+}
+
+// ListTrialWatch 按到期时间顺序返回群内的试用订阅，groupCode为0时返回所有群的试用订阅
+func (s *StateManager) ListTrialWatch(groupCode int64) (results []*TrialWatch, err error) {
+	var pattern = s.TrialWatchKey("*")
+	if groupCode != 0 {
+		pattern = s.TrialWatchKey(groupCode, "*")
+	}
+	err = s.RCoverTx(func(tx localdb.Tx) error {
+		var iterErr error
+		err := tx.AscendKeys(pattern, func(key, value string) bool {
+			var trial = new(TrialWatch)
+			if iterErr = json.Unmarshal([]byte(value), trial); iterErr != nil {
+				return false
+			}
+			results = append(results, trial)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return iterErr
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ExpireTime < results[j].ExpireTime
+	})
+	return
+}
+
+// ITrialWatch 群成员添加试用订阅，duration为0时使用 cfg.GetTrialWatchDuration
+func ITrialWatch(c *MessageContext, groupCode int64, id string, site string, watchType concern_type.Type, duration time.Duration) {
+	log := c.Log
+
+	if c.Lsp.PermissionStateManager.CheckGroupCommandDisabled(groupCode, TrialCommand) {
+		c.DisabledReply()
+		return
+	}
+	if !cfg.GetTrialWatchEnable() {
+		c.TextReply("失败 - 管理员没有开启试用订阅")
+		return
+	}
+	var maxDuration = cfg.GetTrialWatchDuration()
+	if duration == 0 {
+		duration = maxDuration
+	}
+	if duration < time.Minute || duration > maxDuration {
+		c.TextReply(fmt.Sprintf("失败 - 试用时间需要在1分钟到%v之间", maxDuration))
+		return
+	}
+
+	cm, err := concern.GetConcernBySiteAndType(site, watchType)
+	if err != nil {
+		log.Errorf("GetConcernManager error %v", err)
+		c.TextReply(fmt.Sprintf("失败 - %v", err))
+		return
+	}
+	if c.Lsp.LspStateManager.CheckGroupModuleDisabled(groupCode, cm.Site()) {
+		c.TextReply(fmt.Sprintf("失败 - %v订阅已在本群禁用", cm.Site()))
+		return
+	}
+	mid, err := cm.ParseId(id)
+	if err != nil {
+		c.TextReply(fmt.Sprintf("失败 - 解析%v id格式错误", cm.Site()))
+		return
+	}
+	log = log.WithField("mid", mid)
+	if c.Lsp.LspStateManager.CheckBlocklistId(cm.Site(), mid) {
+		c.TextReply("失败 - 该id已被管理员禁止订阅")
+		return
+	}
+	trials, err := c.Lsp.LspStateManager.ListTrialWatch(groupCode)
+	if err != nil {
+		log.Errorf("ListTrialWatch error %v", err)
+		c.TextReply("失败 - 内部错误")
+		return
+	}
+	if len(trials) >= cfg.GetTrialWatchMaxPerGroup() {
+		c.TextReply(fmt.Sprintf("失败 - %v", ErrTrialWatchLimit))
+		return
+	}
+	target := mmsg.NewGroupTargetId(groupCode)
+	userInfo, err := cm.Add(c, target, mid, watchType)
+	if err != nil {
+		if err == concern.ErrAlreadyExists {
+			c.TextReply("失败 - 已经watch过了")
+		} else {
+			log.Errorf("watch error %v", err)
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+		}
+		return
+	}
+	if userInfo == nil {
+		userInfo = concern.NewIdentity(mid, "未知")
+	}
+	var expireTime = time.Now().Add(duration)
+	err = c.Lsp.LspStateManager.AddTrialWatch(&TrialWatch{
+		GroupCode:  groupCode,
+		Site:       cm.Site(),
+		Id:         fmt.Sprint(mid),
+		Type:       watchType,
+		Requester:  c.Sender.Uin,
+		ExpireTime: expireTime.Unix(),
+	})
+	if err != nil {
+		log.Errorf("AddTrialWatch error %v", err)
+		if _, err := cm.Remove(c, target, mid, watchType); err != nil {
+			log.Errorf("rollback trial watch error %v", err)
+		}
+		if err == ErrTrialWatchLimit {
+			c.TextReply(fmt.Sprintf("失败 - %v", err))
+		} else {
+			c.TextReply("失败 - 内部错误")
+		}
+		return
+	}
+	log.WithField("name", userInfo.GetName()).WithField("expire", expireTime).Debug("trial watch success")
+	c.audit(groupCode)
+	c.TextReply(fmt.Sprintf("试用订阅成功 - %v用户 %v，将在%v自动取消，管理员可以使用%v转为正式订阅",
+		cm.Site(), userInfo.GetName(), expireTime.Format("2006-01-02 15:04"), c.Lsp.CommandShowName(WatchCommand)))
+}
+
+// convertTrialWatch 把群内的试用订阅转为正式订阅，返回是否存在试用订阅，试用订阅只存在于QQ群
+func convertTrialWatch(c *MessageContext, target mmsg.TargetId, site string, id interface{}) bool {
+	if !target.TargetType().IsGroup() {
+		return false
+	}
+	err := c.Lsp.LspStateManager.DeleteTrialWatch(target.TargetCode(), site, id)
+	if err != nil && !localdb.IsNotFound(err) {
+		c.GetLog().Errorf("DeleteTrialWatch error %v", err)
+	}
+	return err == nil
+}
+
+// clearTrialWatchIfRemoved 试用订阅的类型被 /unwatch 取消后删除试用订阅的记录，避免之后重新添加的正式订阅被自动取消
+func clearTrialWatchIfRemoved(c *MessageContext, target mmsg.TargetId, cm concern.Concern, id interface{}) {
+	if !target.TargetType().IsGroup() {
+		return
+	}
+	trial, err := c.Lsp.LspStateManager.GetTrialWatch(target.TargetCode(), cm.Site(), id)
+	if err != nil {
+		return
+	}
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, id); err == nil && ctype.ContainAll(trial.Type) {
+		return
+	}
+	if err := c.Lsp.LspStateManager.DeleteTrialWatch(target.TargetCode(), cm.Site(), id); err != nil {
+		c.GetLog().Errorf("DeleteTrialWatch error %v", err)
+	}
+}
+
+// TrialWatchCheck 定期检查到期的试用订阅，取消订阅并通知添加的成员
+func (l *Lsp) TrialWatchCheck() {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.WithField("stack", string(debug.Stack())).Errorf("trial watch check recoverd %v", err)
+			go l.TrialWatchCheck()
+		}
+	}()
+	ticker := time.NewTicker(trialWatchCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.expireTrialWatch(time.Now())
+		}
+	}
+}
+
+func (l *Lsp) expireTrialWatch(now time.Time) {
+	trials, err := l.LspStateManager.ListTrialWatch(0)
+	if err != nil {
+		logger.Errorf("ListTrialWatch error %v", err)
+		return
+	}
+	for _, trial := range trials {
+		if trial.ExpireTime > now.Unix() {
+			break
+		}
+		l.removeTrialWatch(trial)
+	}
+}
+
+// removeTrialWatch 取消到期的试用订阅，订阅已经被取消时只删除记录
+func (l *Lsp) removeTrialWatch(trial *TrialWatch) {
+	log := logger.WithFields(localutils.GroupLogFields(trial.GroupCode)).
+		WithField("Site", trial.Site).WithField("Id", trial.Id).WithField("Requester", trial.Requester)
+	defer func() {
+		if err := l.LspStateManager.DeleteTrialWatch(trial.GroupCode, trial.Site, trial.Id); err != nil && !localdb.IsNotFound(err) {
+			log.Errorf("DeleteTrialWatch error %v", err)
+		}
+	}()
+	cm, err := concern.GetConcernBySiteAndType(trial.Site, trial.Type)
+	if err != nil {
+		log.Errorf("GetConcernBySiteAndType error %v", err)
+		return
+	}
+	mid, err := cm.ParseId(trial.Id)
+	if err != nil {
+		log.Errorf("ParseId error %v", err)
+		return
+	}
+	target := mmsg.NewGroupTargetId(trial.GroupCode)
+	ctype, err := cm.GetStateManager().GetGroupConcern(target, mid)
+	if err != nil || !ctype.ContainAll(trial.Type) {
+		return
+	}
+	var name = trial.Id
+	if info, err := cm.Get(mid); err == nil && info != nil {
+		name = info.GetName()
+	}
+	if _, err = cm.Remove(nil, target, mid, trial.Type); err != nil {
+		log.Errorf("Remove error %v", err)
+		return
+	}
+	if ctype, err := cm.GetStateManager().GetGroupConcern(target, mid); err != nil || ctype.Empty() {
+		if err := l.LspStateManager.RemoveConcernTag(target, cm.Site(), mid); err != nil {
+			log.Errorf("RemoveConcernTag error %v", err)
+		}
+	}
+	log.Info("试用订阅已到期，自动取消订阅")
+	m := mmsg.NewMSG()
+	m.Append(mmsg.NewAt(trial.Requester))
+	m.Textf(" 你添加的%v用户 %v 的试用订阅已到期，已自动取消，如需继续订阅请联系管理员使用%v",
+		cm.Site(), name, l.CommandShowName(WatchCommand))
+	l.pushQueue.Push(&PushItem{
+		Target:   target,
+		Priority: PushPriorityNormal,
+		MSG:      m,
+	})
+}
@@ -0,0 +1,110 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/permission"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStateManager_TrialWatch(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := newStateManager(t)
+	var now = time.Now()
+	for index, id := range []string{test.NAME1, test.NAME2, "3"} {
+		assert.Nil(t, sm.AddTrialWatch(&TrialWatch{
+			GroupCode:  test.G1,
+			Site:       test.Site1,
+			Id:         id,
+			Type:       test.T1,
+			ExpireTime: now.Add(time.Hour * time.Duration(3-index)).Unix(),
+		}))
+	}
+	assert.Equal(t, ErrTrialWatchLimit, sm.AddTrialWatch(&TrialWatch{GroupCode: test.G1, Site: test.Site1, Id: "4"}))
+	assert.Nil(t, sm.AddTrialWatch(&TrialWatch{GroupCode: test.G2, Site: test.Site1, Id: "4"}))
+
+	trials, err := sm.ListTrialWatch(test.G1)
+	assert.Nil(t, err)
+	if assert.Len(t, trials, 3) {
+		assert.Equal(t, "3", trials[0].Id)
+	}
+	trials, err = sm.ListTrialWatch(0)
+	assert.Nil(t, err)
+	assert.Len(t, trials, 4)
+
+	trial, err := sm.GetTrialWatch(test.G1, test.Site1, test.NAME1)
+	assert.Nil(t, err)
+	assert.Equal(t, test.T1, trial.Type)
+	assert.Nil(t, sm.DeleteTrialWatch(test.G1, test.Site1, test.NAME1))
+	assert.True(t, localdb.IsNotFound(sm.DeleteTrialWatch(test.G1, test.Site1, test.NAME1)))
+}
+
+func TestITrialWatch(t *testing.T) {
+	initLsp(t)
+	defer closeLsp(t)
+
+	config.GlobalConfig.Set("trialWatch.enable", true)
+	defer config.GlobalConfig.Set("trialWatch.enable", nil)
+
+	sender := &testPushSender{fail: map[int64]int{}}
+	Instance.pushQueue = newTestPushQueue(t, sender)
+	Instance.pushQueue.Start()
+	defer Instance.pushQueue.Stop()
+
+	msgChan := make(chan *mmsg.MSG, 10)
+	target := mmsg.NewGroupTarget(test.G1)
+	ctx := NewCtx(t, msgChan, test.Sender1, target)
+	reply := func() string {
+		return msgstringer.MsgToString((<-msgChan).ToCombineMessage(target).Elements)
+	}
+
+	tc1 := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	concern.RegisterConcern(tc1)
+	defer concern.ClearConcern()
+
+	ITrialWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, time.Hour*48)
+	assert.Contains(t, reply(), "试用时间")
+
+	// 不需要管理员权限
+	ITrialWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, time.Hour)
+	assert.Contains(t, reply(), "试用订阅成功")
+	ITrialWatch(ctx, test.G1, test.NAME1, test.Site1, test.T1, time.Hour)
+	assert.Contains(t, reply(), "已经watch过了")
+	ITrialWatch(ctx, test.G1, test.NAME2, test.Site1, test.T1, time.Hour)
+	assert.Contains(t, reply(), "试用订阅成功")
+
+	// 管理员watch后转为正式订阅，不会到期
+	assert.Nil(t, Instance.PermissionStateManager.GrantRole(test.Sender1.Uin, permission.Admin))
+	IWatch(ctx, mmsg.NewGroupTargetId(test.G1), test.NAME2, test.Site1, test.T1, false)
+	assert.Contains(t, reply(), "转为正式订阅")
+
+	Instance.expireTrialWatch(time.Now())
+	trials, err := Instance.LspStateManager.ListTrialWatch(test.G1)
+	assert.Nil(t, err)
+	assert.Len(t, trials, 1)
+
+	Instance.expireTrialWatch(time.Now().Add(time.Hour * 2))
+	assert.Eventually(t, func() bool {
+		return len(sender.Result()) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Contains(t, sender.Result()[0], "试用订阅已到期")
+
+	_, err = tc1.GetStateManager().GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	assert.NotNil(t, err)
+	ctype, err := tc1.GetStateManager().GetGroupConcern(mmsg.NewGroupTargetId(test.G1), test.NAME2)
+	assert.Nil(t, err)
+	assert.True(t, ctype.ContainAll(test.T1))
+	trials, err = Instance.LspStateManager.ListTrialWatch(0)
+	assert.Nil(t, err)
+	assert.Empty(t, trials)
+}
@@ -63,8 +63,8 @@ type tcStateManager struct {
 	*concern.StateManager
 }
 
-func (tc *tcStateManager) GetGroupConcernConfig(groupCode int64, id interface{}) concern.IConfig {
-	return NewGroupConcernConfig(tc.StateManager.GetGroupConcernConfig(groupCode, id))
+func (tc *tcStateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) concern.IConfig {
+	return NewGroupConcernConfig(tc.StateManager.GetGroupConcernConfig(target, id))
 }
 
 type TwitCastConcern struct {
@@ -229,12 +229,12 @@ func (tc *TwitCastConcern) tcFresh() concern.FreshFunc {
 }
 
 func (tc *TwitCastConcern) tcNotifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, event concern.Event) []concern.Notify {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
 
 		if liveEvent, ok := event.(*LiveEvent); ok {
 			return []concern.Notify{
 				&LiveNotify{
-					groupCode,
+					target,
 					*liveEvent,
 				},
 			}
@@ -278,7 +278,7 @@ func (tc *TwitCastConcern) ParseId(s string) (interface{}, error) {
 	return strings.ReplaceAll(s, ":", "%"), nil
 }
 
-func (tc *TwitCastConcern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (tc *TwitCastConcern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 
 	userId := id.(string)
 
@@ -307,7 +307,7 @@ func (tc *TwitCastConcern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}
 
 	}
 
-	_, err := tc.GetStateManager().AddGroupConcern(groupCode, userId, ctype)
+	_, err := tc.GetStateManager().AddGroupConcern(target, userId, ctype)
 
 	if err != nil {
 		return nil, err
@@ -317,8 +317,8 @@ func (tc *TwitCastConcern) Add(ctx mmsg.IMsgCtx, groupCode int64, id interface{}
 }
 
 // Remove 实现删除一个订阅
-func (tc *TwitCastConcern) Remove(ctx mmsg.IMsgCtx, groupCode int64, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
-	_, err := tc.GetStateManager().RemoveGroupConcern(groupCode, id.(string), ctype)
+func (tc *TwitCastConcern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	_, err := tc.GetStateManager().RemoveGroupConcern(target, id.(string), ctype)
 	if err != nil {
 		return nil, err
 	}
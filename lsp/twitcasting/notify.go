@@ -6,7 +6,6 @@ import (
 	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"github.com/Sora233/DDBOT/proxy_pool"
 	"github.com/Sora233/DDBOT/requests"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/MiraiGo-Template/config"
 	"github.com/nobuf/cas"
 	"github.com/sirupsen/logrus"
@@ -38,12 +37,12 @@ func (e *LiveEvent) Logger() *logrus.Entry {
 }
 
 type LiveNotify struct {
-	groupCode int64
+	target mmsg.TargetId
 	LiveEvent
 }
 
-func (n *LiveNotify) GetGroupCode() int64 {
-	return n.groupCode
+func (n *LiveNotify) GetTarget() mmsg.TargetId {
+	return n.target
 }
 
 func (n *LiveNotify) ToMessage() *mmsg.MSG {
@@ -108,5 +107,5 @@ func (n *LiveNotify) ToMessage() *mmsg.MSG {
 }
 
 func (n *LiveNotify) Logger() *logrus.Entry {
-	return n.LiveEvent.Logger().WithFields(localutils.GroupLogFields(n.groupCode))
+	return n.LiveEvent.Logger().WithFields(mmsg.TargetLogFields(n.target))
 }
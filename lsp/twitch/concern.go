@@ -0,0 +1,198 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"strings"
+)
+
+var logger = utils.GetModuleLogger("twitch-concern")
+
+var loginRegexp = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+const (
+	Live concern_type.Type = "live"
+)
+
+type Concern struct {
+	*StateManager
+}
+
+func (c *Concern) Site() string {
+	return Site
+}
+
+func (c *Concern) Types() []concern_type.Type {
+	return []concern_type.Type{Live}
+}
+
+// ParseId 使用频道的登录名作为id，也支持直接输入频道链接
+func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://", "http://", "www.", "twitch.tv/"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	s = strings.ToLower(strings.TrimSuffix(s, "/"))
+	if !loginRegexp.MatchString(s) {
+		return nil, errors.New("无效的频道名")
+	}
+	return s, nil
+}
+
+func (c *Concern) GetStateManager() concern.IStateManager {
+	return c.StateManager
+}
+
+func (c *Concern) Stop() {
+	logger.Trace("正在停止twitch concern")
+	logger.Trace("正在停止twitch StateManager")
+	c.StateManager.Stop()
+	logger.Trace("twitch StateManager已停止")
+	logger.Trace("twitch concern已停止")
+}
+
+func (c *Concern) Start() error {
+	if len(cfg.GetTwitchClientId()) == 0 || len(cfg.GetTwitchClientSecret()) == 0 {
+		return ErrConfigMissing
+	}
+	c.UseEmitQueue()
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(c.fresh())
+	return c.StateManager.Start()
+}
+
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	var err error
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
+
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	liveInfo, err := c.FindOrLoadLive(id.(string))
+	if err != nil {
+		log.Errorf("FindOrLoadLive error %v", err)
+		return nil, fmt.Errorf("查询频道信息失败 %v - %v", id, err)
+	}
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+	return liveInfo, nil
+}
+
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	id := _id.(string)
+	identity, _ := c.Get(id)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
+		allCtype, err := c.GetConcern(id)
+		if err != nil {
+			return err
+		}
+		if allCtype.Empty() {
+			err = c.DeleteLiveInfo(id)
+		}
+		return err
+	})
+	return identity, err
+}
+
+func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
+	liveInfo, err := c.FindLive(id.(string), false)
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(liveInfo.Login, liveInfo.GetName()), nil
+}
+
+func (c *Concern) FindLive(login string, load bool) (*LiveInfo, error) {
+	if load {
+		liveInfo, err := LoadLiveInfo(login)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.StateManager.AddLiveInfo(liveInfo)
+		return liveInfo, nil
+	}
+	return c.StateManager.GetLiveInfo(login)
+}
+
+func (c *Concern) FindOrLoadLive(login string) (*LiveInfo, error) {
+	info, _ := c.FindLive(login, false)
+	if info == nil {
+		return c.FindLive(login, true)
+	}
+	return info, nil
+}
+
+func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
+		switch info := event.(type) {
+		case *LiveInfo:
+			if info.Living() {
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("living notify")
+			} else {
+				info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("noliving notify")
+			}
+			return []concern.Notify{NewConcernLiveNotify(target, info)}
+		default:
+			logger.Errorf("unknown EventType %+v", event)
+			return nil
+		}
+	}
+}
+
+func (c *Concern) fresh() concern.FreshFunc {
+	return c.EmitQueueFresher(func(ctype concern_type.Type, id interface{}) ([]concern.Event, error) {
+		var result []concern.Event
+		login := id.(string)
+		if ctype.ContainAll(Live) {
+			oldInfo, _ := c.FindLive(login, false)
+			liveInfo, err := c.FindLive(login, true)
+			if err == ErrUserNotExist {
+				logger.WithFields(logrus.Fields{
+					"Login": login,
+					"Name":  oldInfo.GetName(),
+				}).Warn("频道不存在或被封禁，订阅将失效")
+				c.RemoveAllById(id)
+				return nil, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("load liveinfo failed %v", err)
+			}
+			if oldInfo == nil {
+				liveInfo.liveStatusChanged = true
+			} else {
+				if oldInfo.Living() != liveInfo.Living() {
+					liveInfo.liveStatusChanged = true
+				}
+				if oldInfo.Living() && liveInfo.Living() && oldInfo.Title != liveInfo.Title {
+					liveInfo.liveTitleChanged = true
+				}
+				if !liveInfo.Living() {
+					// 下播后保留最后一次直播的标题
+					liveInfo.Title = oldInfo.Title
+				}
+			}
+			result = append(result, liveInfo)
+		}
+		return result, nil
+	})
+}
+
+func NewConcern(notify chan<- concern.Notify) *Concern {
+	c := &Concern{
+		StateManager: NewStateManager(notify),
+	}
+	return c
+}
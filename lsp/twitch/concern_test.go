@@ -0,0 +1,96 @@
+package twitch
+
+import (
+	"context"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const testLogin = "test_channel"
+
+func TestConcern_ParseId(t *testing.T) {
+	c := NewConcern(nil)
+	for _, s := range []string{
+		testLogin,
+		"Test_Channel",
+		"https://www.twitch.tv/test_channel",
+		"twitch.tv/test_channel/",
+	} {
+		id, err := c.ParseId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, testLogin, id, s)
+	}
+	for _, s := range []string{"", "a-b", "https://www.twitch.tv/", "012345678901234567890123456789"} {
+		_, err := c.ParseId(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestConcern(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	testEventChan := make(chan concern.Event, 16)
+	testNotifyChan := make(chan concern.Notify)
+
+	c := NewConcern(testNotifyChan)
+	assert.NotNil(t, c.GetStateManager())
+	assert.Equal(t, Site, c.Site())
+	assert.Equal(t, ErrConfigMissing, c.Start())
+
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(func(ctx context.Context, eventChan chan<- concern.Event) {
+		for {
+			select {
+			case e := <-testEventChan:
+				if e != nil {
+					eventChan <- e
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	assert.Nil(t, c.StateManager.Start())
+	defer c.Stop()
+	defer close(testEventChan)
+
+	liveInfo := &LiveInfo{
+		Login:    testLogin,
+		Name:     test.NAME1,
+		Title:    test.NAME2,
+		IsLiving: true,
+	}
+	assert.Nil(t, c.AddLiveInfo(liveInfo))
+	_, err := c.StateManager.AddGroupConcern(mmsg.NewGroupTargetId(test.G1), testLogin, Live)
+	assert.Nil(t, err)
+
+	identity, err := c.Get(testLogin)
+	assert.Nil(t, err)
+	assert.Equal(t, testLogin, identity.GetUid())
+	assert.Equal(t, test.NAME1, identity.GetName())
+
+	found, err := c.FindOrLoadLive(testLogin)
+	assert.Nil(t, err)
+	assert.EqualValues(t, liveInfo, found)
+
+	liveInfo.liveStatusChanged = true
+	testEventChan <- liveInfo
+
+	select {
+	case notify := <-testNotifyChan:
+		assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+		assert.Equal(t, testLogin, notify.GetUid())
+	case <-time.After(time.Second):
+		assert.Fail(t, "no notify received")
+	}
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), testLogin, Live)
+	assert.Nil(t, err)
+	_, err = c.GetLiveInfo(testLogin)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,8 @@
+package twitch
+
+import "errors"
+
+var (
+	ErrUserNotExist  = errors.New("用户不存在")
+	ErrConfigMissing = errors.New("找不到 Twitch 配置，请填写 twitch.clientId 和 twitch.clientSecret")
+)
@@ -0,0 +1,11 @@
+package twitch
+
+import (
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+)
+
+func init() {
+	concern.RegisterConcern(NewConcern(concern.GetNotifyChan()))
+	localdb.RegisterJsonType(localdb.TwitchCurrentLiveKey, func() interface{} { return new(LiveInfo) })
+}
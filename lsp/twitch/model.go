@@ -0,0 +1,123 @@
+package twitch
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+type LiveInfo struct {
+	Login    string `json:"login"`
+	Name     string `json:"name"`
+	Avatar   string `json:"avatar"`
+	Title    string `json:"title"`
+	GameName string `json:"game_name"`
+	Cover    string `json:"cover"`
+	IsLiving bool   `json:"living"`
+
+	once              sync.Once
+	msgCache          *mmsg.MSG
+	liveStatusChanged bool
+	liveTitleChanged  bool
+}
+
+func (m *LiveInfo) TitleChanged() bool {
+	return m.liveTitleChanged
+}
+
+func (m *LiveInfo) IsLive() bool {
+	return true
+}
+
+func (m *LiveInfo) Living() bool {
+	return m.IsLiving
+}
+
+func (m *LiveInfo) LiveStatusChanged() bool {
+	return m.liveStatusChanged
+}
+
+func (m *LiveInfo) GetUid() interface{} {
+	return m.Login
+}
+
+func (m *LiveInfo) GetName() string {
+	if m == nil {
+		return ""
+	}
+	return m.Name
+}
+
+func (m *LiveInfo) Type() concern_type.Type {
+	return Live
+}
+
+func (m *LiveInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":   Site,
+		"Name":   m.Name,
+		"Login":  m.Login,
+		"Title":  m.Title,
+		"Living": m.IsLiving,
+	})
+}
+
+func (m *LiveInfo) Site() string {
+	return Site
+}
+
+func (m *LiveInfo) GetMSG() *mmsg.MSG {
+	m.once.Do(func() {
+		var cover = m.Cover
+		if len(cover) == 0 {
+			cover = m.Avatar
+		}
+		var data = map[string]interface{}{
+			"title":  m.Title,
+			"name":   m.Name,
+			"game":   m.GameName,
+			"url":    TwitchPath(m.Login),
+			"cover":  cover,
+			"living": m.Living(),
+		}
+		var err error
+		m.msgCache, err = template.LoadAndExec("notify.group.twitch.live.tmpl", data)
+		if err != nil {
+			logger.Errorf("twitch: LiveInfo LoadAndExec error %v", err)
+		}
+		return
+	})
+	return m.msgCache
+}
+
+type ConcernLiveNotify struct {
+	*LiveInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernLiveNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernLiveNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.LiveInfo.GetMSG()
+}
+
+func (notify *ConcernLiveNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.LiveInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernLiveNotify(target mmsg.TargetId, l *LiveInfo) *ConcernLiveNotify {
+	if l == nil {
+		return nil
+	}
+	return &ConcernLiveNotify{
+		l,
+		target,
+	}
+}
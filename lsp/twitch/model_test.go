@@ -0,0 +1,34 @@
+package twitch
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLiveInfo(t *testing.T) {
+	l := &LiveInfo{
+		Login:    test.NAME1,
+		Name:     test.NAME2,
+		Title:    test.NAME2,
+		GameName: test.NAME1,
+	}
+	assert.Equal(t, Site, l.Site())
+	assert.Equal(t, test.NAME1, l.GetUid())
+	assert.Equal(t, test.NAME2, l.GetName())
+	assert.Equal(t, Live, l.Type())
+	notify := NewConcernLiveNotify(mmsg.NewGroupTargetId(test.G1), l)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, test.NAME1, notify.GetUid())
+	assert.Equal(t, Live, notify.Type())
+
+	m := notify.ToMessage()
+	assert.NotNil(t, m)
+
+	notify.IsLiving = true
+	m = notify.ToMessage()
+	assert.NotNil(t, m)
+}
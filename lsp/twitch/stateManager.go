@@ -0,0 +1,58 @@
+package twitch
+
+import (
+	"errors"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"time"
+)
+
+type StateManager struct {
+	*concern.StateManager
+	*extraKey
+}
+
+func (c *StateManager) GetLiveInfo(login string) (*LiveInfo, error) {
+	var liveInfo = &LiveInfo{}
+	err := c.GetJson(c.CurrentLiveKey(login), liveInfo, localdb.GetCacheOpt())
+	if err != nil {
+		return nil, err
+	}
+	return liveInfo, nil
+}
+
+// GetState 实现 concern.StateExt
+func (c *StateManager) GetState(id interface{}) *concern.State {
+	liveInfo, err := c.GetLiveInfo(id.(string))
+	if err != nil {
+		return nil
+	}
+	return &concern.State{
+		Living: liveInfo.Living(),
+		Title:  liveInfo.Title,
+	}
+}
+
+func (c *StateManager) AddLiveInfo(liveInfo *LiveInfo) error {
+	if liveInfo == nil {
+		return errors.New("nil LiveInfo")
+	}
+	return c.SetJson(c.CurrentLiveKey(liveInfo.Login), liveInfo, localdb.SetExpireOpt(time.Hour*24*7))
+}
+
+func (c *StateManager) DeleteLiveInfo(login string) error {
+	_, err := c.Delete(c.CurrentLiveKey(login), localdb.IgnoreNotFoundOpt())
+	return err
+}
+
+func (c *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(c.StateManager.GetGroupConcernConfig(target, id))
+}
+
+func NewStateManager(notify chan<- concern.Notify) *StateManager {
+	sm := &StateManager{}
+	sm.extraKey = NewExtraKey()
+	sm.StateManager = concern.NewStateManagerWithCustomKey(Site, NewKeySet(), notify)
+	return sm
+}
@@ -0,0 +1,43 @@
+package twitch
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initStateManager(t *testing.T) *StateManager {
+	sm := NewStateManager(nil)
+	assert.NotNil(t, sm)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	return sm
+}
+
+func TestStateManager_GetLiveInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1))
+
+	_, err := sm.GetLiveInfo(test.NAME1)
+	assert.NotNil(t, err)
+
+	expected := &LiveInfo{
+		Login:    test.NAME1,
+		Name:     test.NAME2,
+		Title:    test.NAME2,
+		IsLiving: true,
+	}
+	assert.Nil(t, sm.AddLiveInfo(expected))
+	actual, err := sm.GetLiveInfo(test.NAME1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+
+	assert.Nil(t, sm.DeleteLiveInfo(test.NAME1))
+	assert.Nil(t, sm.DeleteLiveInfo(test.NAME1))
+	_, err = sm.GetLiveInfo(test.NAME1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,196 @@
+package twitch
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"github.com/guonaihong/gout"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	Site      = "twitch"
+	Host      = "https://www.twitch.tv"
+	HelixHost = "https://api.twitch.tv/helix"
+	TokenUrl  = "https://id.twitch.tv/oauth2/token"
+)
+
+const (
+	PathUsers   = "/users"
+	PathStreams = "/streams"
+)
+
+func TwitchPath(login string) string {
+	return Host + "/" + login
+}
+
+func HelixPath(path string) string {
+	return HelixHost + path
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// appAccessToken 使用 client credentials 获取的 app access token，过期前会自动刷新
+var appAccessToken struct {
+	sync.Mutex
+	token  string
+	expire time.Time
+}
+
+func getAccessToken() (string, error) {
+	appAccessToken.Lock()
+	defer appAccessToken.Unlock()
+	if len(appAccessToken.token) > 0 && time.Now().Before(appAccessToken.expire) {
+		return appAccessToken.token, nil
+	}
+	if len(cfg.GetTwitchClientId()) == 0 || len(cfg.GetTwitchClientSecret()) == 0 {
+		return "", ErrConfigMissing
+	}
+	var opts = []requests.Option{
+		requests.ProxyOption(proxy_pool.PreferOversea),
+		requests.SiteOption(Site),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}
+	var resp = new(TokenResponse)
+	err := requests.PostWWWForm(TokenUrl, gout.H{
+		"client_id":     cfg.GetTwitchClientId(),
+		"client_secret": cfg.GetTwitchClientSecret(),
+		"grant_type":    "client_credentials",
+	}, resp, opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.AccessToken) == 0 {
+		return "", fmt.Errorf("empty access token")
+	}
+	appAccessToken.token = resp.AccessToken
+	// 提前一分钟刷新
+	appAccessToken.expire = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return appAccessToken.token, nil
+}
+
+func invalidateAccessToken() {
+	appAccessToken.Lock()
+	defer appAccessToken.Unlock()
+	appAccessToken.token = ""
+}
+
+// helixGet 请求 Helix API，token 失效时会刷新后重试一次
+func helixGet(path string, params gout.H, out interface{}) error {
+	for retry := 0; ; retry++ {
+		token, err := getAccessToken()
+		if err != nil {
+			return err
+		}
+		var code int
+		var opts = []requests.Option{
+			requests.HeaderOption("Client-Id", cfg.GetTwitchClientId()),
+			requests.HeaderOption("Authorization", "Bearer "+token),
+			requests.ProxyOption(proxy_pool.PreferOversea),
+			requests.SiteOption(Site),
+			requests.TimeoutOption(time.Second * 10),
+			requests.HttpCodeOption(&code),
+		}
+		err = requests.Get(HelixPath(path), params, out, opts...)
+		if code == http.StatusUnauthorized && retry == 0 {
+			invalidateAccessToken()
+			continue
+		}
+		return err
+	}
+}
+
+type User struct {
+	Id              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	ProfileImageUrl string `json:"profile_image_url"`
+}
+
+type GetUsersResponse struct {
+	Data []*User `json:"data"`
+}
+
+type Stream struct {
+	Id           string `json:"id"`
+	UserId       string `json:"user_id"`
+	UserLogin    string `json:"user_login"`
+	UserName     string `json:"user_name"`
+	GameName     string `json:"game_name"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	ThumbnailUrl string `json:"thumbnail_url"`
+}
+
+type GetStreamsResponse struct {
+	Data []*Stream `json:"data"`
+}
+
+func GetUser(login string) (*User, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(GetUsersResponse)
+	if err := helixGet(PathUsers, gout.H{"login": login}, resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, ErrUserNotExist
+	}
+	return resp.Data[0], nil
+}
+
+// GetStream 查询正在进行的直播，未开播时返回nil
+func GetStream(login string) (*Stream, error) {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	var resp = new(GetStreamsResponse)
+	if err := helixGet(PathStreams, gout.H{"user_login": login}, resp); err != nil {
+		return nil, err
+	}
+	for _, stream := range resp.Data {
+		if stream.Type == "live" {
+			return stream, nil
+		}
+	}
+	return nil, nil
+}
+
+// LoadLiveInfo 通过 Helix API 查询用户信息和直播状态
+func LoadLiveInfo(login string) (*LiveInfo, error) {
+	user, err := GetUser(login)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := GetStream(login)
+	if err != nil {
+		return nil, err
+	}
+	info := &LiveInfo{
+		Login:  user.Login,
+		Name:   user.DisplayName,
+		Avatar: user.ProfileImageUrl,
+	}
+	if stream != nil {
+		info.IsLiving = true
+		info.Title = stream.Title
+		info.GameName = stream.GameName
+		info.Cover = strings.NewReplacer("{width}", "640", "{height}", "360").Replace(stream.ThumbnailUrl)
+	}
+	return info, nil
+}
@@ -0,0 +1,175 @@
+package twitter
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/guonaihong/gout"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiBackend 使用推特官方API v2，需要配置 twitter.token
+type apiBackend struct{}
+
+type apiError struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Type   string `json:"type"`
+}
+
+type apiUser struct {
+	Id              string `json:"id"`
+	Name            string `json:"name"`
+	Username        string `json:"username"`
+	ProfileImageUrl string `json:"profile_image_url"`
+}
+
+type apiTweet struct {
+	Id               string `json:"id"`
+	Text             string `json:"text"`
+	AuthorId         string `json:"author_id"`
+	CreatedAt        string `json:"created_at"`
+	ReferencedTweets []struct {
+		Type string `json:"type"`
+		Id   string `json:"id"`
+	} `json:"referenced_tweets"`
+	Attachments struct {
+		MediaKeys []string `json:"media_keys"`
+	} `json:"attachments"`
+}
+
+type UserByUsernameResponse struct {
+	Data   *apiUser    `json:"data"`
+	Errors []*apiError `json:"errors"`
+}
+
+type UserTweetsResponse struct {
+	Data     []*apiTweet `json:"data"`
+	Includes struct {
+		Media []struct {
+			MediaKey        string `json:"media_key"`
+			Type            string `json:"type"`
+			Url             string `json:"url"`
+			PreviewImageUrl string `json:"preview_image_url"`
+		} `json:"media"`
+		Users  []*apiUser  `json:"users"`
+		Tweets []*apiTweet `json:"tweets"`
+	} `json:"includes"`
+	Errors []*apiError `json:"errors"`
+}
+
+func apiGet(url string, params interface{}, out interface{}) error {
+	if len(cfg.GetTwitterToken()) == 0 {
+		return errors.New("没有配置twitter.token")
+	}
+	var code int
+	err := twitterGet(url, params, out,
+		requests.HeaderOption("Authorization", "Bearer "+cfg.GetTwitterToken()),
+		requests.HttpCodeOption(&code),
+	)
+	if code == http.StatusNotFound {
+		return ErrNotExist
+	}
+	return err
+}
+
+func (a *apiBackend) GetUserInfo(username string) (*UserInfo, error) {
+	var resp = new(UserByUsernameResponse)
+	err := apiGet(ApiPath(fmt.Sprintf(PathUserByUsername, username)), gout.H{
+		"user.fields": "name,profile_image_url",
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data == nil || len(resp.Data.Id) == 0 {
+		return nil, ErrNotExist
+	}
+	return &UserInfo{
+		Id:       strings.ToLower(resp.Data.Username),
+		UserId:   resp.Data.Id,
+		Username: resp.Data.Username,
+		Name:     resp.Data.Name,
+		Avatar:   resp.Data.ProfileImageUrl,
+	}, nil
+}
+
+func (a *apiBackend) GetTweets(userInfo *UserInfo) ([]*TweetInfo, error) {
+	if len(userInfo.UserId) == 0 {
+		// 之前使用Nitter时保存的用户信息没有数字id
+		info, err := a.GetUserInfo(userInfo.Id)
+		if err != nil {
+			return nil, err
+		}
+		*userInfo = *info
+	}
+	var resp = new(UserTweetsResponse)
+	err := apiGet(ApiPath(fmt.Sprintf(PathUserTweets, userInfo.UserId)), gout.H{
+		"max_results":  10,
+		"exclude":      "replies",
+		"tweet.fields": "created_at,referenced_tweets,attachments,author_id",
+		"expansions":   "attachments.media_keys,referenced_tweets.id,referenced_tweets.id.author_id,referenced_tweets.id.attachments.media_keys",
+		"media.fields": "url,preview_image_url,type",
+		"user.fields":  "username",
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 && len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("%v - %v", resp.Errors[0].Title, resp.Errors[0].Detail)
+	}
+	var (
+		media  = make(map[string]string)
+		users  = make(map[string]string)
+		tweets = make(map[string]*apiTweet)
+	)
+	for _, m := range resp.Includes.Media {
+		if len(m.Url) > 0 {
+			media[m.MediaKey] = m.Url
+		} else if len(m.PreviewImageUrl) > 0 {
+			media[m.MediaKey] = m.PreviewImageUrl
+		}
+	}
+	for _, u := range resp.Includes.Users {
+		users[u.Id] = u.Username
+	}
+	for _, t := range resp.Includes.Tweets {
+		tweets[t.Id] = t
+	}
+	var images = func(t *apiTweet) []string {
+		var result []string
+		for _, key := range t.Attachments.MediaKeys {
+			if url, found := media[key]; found && len(result) < maxImages {
+				result = append(result, url)
+			}
+		}
+		return result
+	}
+	var result []*TweetInfo
+	for _, t := range resp.Data {
+		var tweet = &TweetInfo{
+			UserInfo: *userInfo,
+			TweetId:  t.Id,
+			Text:     t.Text,
+			Images:   images(t),
+		}
+		if createdAt, err := time.Parse(time.RFC3339, t.CreatedAt); err == nil {
+			tweet.CreatedAt = createdAt.Unix()
+		}
+		for _, ref := range t.ReferencedTweets {
+			if ref.Type != "retweeted" {
+				continue
+			}
+			tweet.OriginTweetId = ref.Id
+			if origin, found := tweets[ref.Id]; found {
+				tweet.Text = origin.Text
+				tweet.Images = images(origin)
+				tweet.OriginUsername = users[origin.AuthorId]
+			}
+		}
+		result = append(result, tweet)
+	}
+	return result, nil
+}
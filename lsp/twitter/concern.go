@@ -0,0 +1,223 @@
+package twitter
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/utils"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var logger = utils.GetModuleLogger("twitter-concern")
+
+var (
+	usernameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+	// urlUsernameRegexp 匹配用户主页链接，例如 https://x.com/username
+	urlUsernameRegexp = regexp.MustCompile(`(?:twitter\.com|x\.com)/([A-Za-z0-9_]{1,15})(?:[/?#]|$)`)
+)
+
+const (
+	// News 用户发布的推文
+	News concern_type.Type = "news"
+	// Retweet 用户的转推
+	Retweet concern_type.Type = "retweet"
+)
+
+type Concern struct {
+	*StateManager
+}
+
+func (c *Concern) Site() string {
+	return Site
+}
+
+func (c *Concern) Types() []concern_type.Type {
+	return []concern_type.Type{News, Retweet}
+}
+
+// ParseId 使用推特用户名，可以带有@前缀，也支持直接输入用户主页链接
+func (c *Concern) ParseId(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if sub := urlUsernameRegexp.FindStringSubmatch(s); sub != nil {
+		s = sub[1]
+	}
+	s = strings.TrimPrefix(s, "@")
+	if !usernameRegexp.MatchString(s) {
+		return nil, fmt.Errorf("无效的推特用户名")
+	}
+	return strings.ToLower(s), nil
+}
+
+func (c *Concern) GetStateManager() concern.IStateManager {
+	return c.StateManager
+}
+
+func (c *Concern) Stop() {
+	logger.Trace("正在停止twitter concern")
+	logger.Trace("正在停止twitter StateManager")
+	c.StateManager.Stop()
+	logger.Trace("twitter StateManager已停止")
+	logger.Trace("twitter concern已停止")
+}
+
+func (c *Concern) Start() error {
+	c.UseEmitQueue()
+	c.StateManager.UseNotifyGeneratorFunc(c.notifyGenerator())
+	c.StateManager.UseFreshFunc(c.fresh())
+	return c.StateManager.Start()
+}
+
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	var err error
+	id := _id.(string)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
+
+	err = c.StateManager.CheckGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := c.FindOrLoadUser(id)
+	if err != nil {
+		log.Errorf("FindOrLoadUser error %v", err)
+		return nil, fmt.Errorf("查询推特用户信息失败 %v - %v", id, err)
+	}
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(id, userInfo.GetName()), nil
+}
+
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+	id := _id.(string)
+	identity, _ := c.Get(id)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
+	_ = c.RWCoverTx(func(tx localdb.Tx) error {
+		allCtype, err := c.GetConcern(id)
+		if err != nil {
+			return err
+		}
+		if allCtype.Empty() {
+			return c.DeleteUserInfo(id)
+		}
+		return nil
+	})
+	return identity, err
+}
+
+func (c *Concern) Get(id interface{}) (concern.IdentityInfo, error) {
+	userInfo, err := c.GetUserInfo(id.(string))
+	if err != nil {
+		return nil, err
+	}
+	return concern.NewIdentity(userInfo.Id, userInfo.GetName()), nil
+}
+
+func (c *Concern) FindOrLoadUser(id string) (*UserInfo, error) {
+	info, _ := c.GetUserInfo(id)
+	if info != nil {
+		return info, nil
+	}
+	info, err := getBackend().GetUserInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddUserInfo(info)
+	return info, nil
+}
+
+func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
+	return func(target mmsg.TargetId, event concern.Event) []concern.Notify {
+		switch info := event.(type) {
+		case *TweetInfo:
+			info.Logger().WithFields(mmsg.TargetLogFields(target)).Trace("tweet notify")
+			return []concern.Notify{NewConcernTweetNotify(target, info)}
+		default:
+			logger.Errorf("unknown EventType %+v", event)
+			return nil
+		}
+	}
+}
+
+// freshTweets 第一次刷新时只标记当前的推文，不推送。
+// 推文的id是递增的，但Nitter中转推的id是原推文的id，所以转推只通过 MarkTweet 判断是否推送过
+func (c *Concern) freshTweets(id string) ([]*TweetInfo, error) {
+	userInfo, err := c.GetUserInfo(id)
+	if err != nil {
+		userInfo = &UserInfo{Id: id, Username: id}
+	}
+	tweets, err := getBackend().GetTweets(userInfo)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.AddUserInfo(userInfo)
+	lastId, err := c.GetLastTweetId(id)
+	firstFresh := err == localdb.ErrNotFound
+	if err != nil && !firstFresh {
+		return nil, err
+	}
+	var maxId = lastId
+	var result []*TweetInfo
+	// 按从旧到新的顺序处理
+	for i := len(tweets) - 1; i >= 0; i-- {
+		tweet := tweets[i]
+		tweetId, err := strconv.ParseInt(tweet.TweetId, 10, 64)
+		if err != nil {
+			continue
+		}
+		// Nitter中的转推没有自己的id，不能通过id判断是否是新的推文
+		ordered := !tweet.IsRetweet() || tweet.TweetId != tweet.OriginTweetId
+		if ordered && tweetId > maxId {
+			maxId = tweetId
+		}
+		replaced, err := c.MarkTweet(id, tweet.TweetId)
+		if err != nil || replaced || firstFresh {
+			continue
+		}
+		if ordered && tweetId <= lastId {
+			continue
+		}
+		result = append(result, tweet)
+	}
+	if err = c.SetLastTweetId(id, maxId); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Concern) fresh() concern.FreshFunc {
+	return c.EmitQueueFresher(func(ctype concern_type.Type, _id interface{}) ([]concern.Event, error) {
+		id := _id.(string)
+		tweets, err := c.freshTweets(id)
+		if err == ErrNotExist {
+			userInfo, _ := c.GetUserInfo(id)
+			logger.WithFields(logrus.Fields{
+				"Id":   id,
+				"Name": userInfo.GetName(),
+			}).Warn("推特用户不存在，订阅将失效")
+			c.RemoveAllById(id)
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load tweets failed %v", err)
+		}
+		var result []concern.Event
+		for _, tweet := range tweets {
+			result = append(result, tweet)
+		}
+		return result, nil
+	})
+}
+
+func NewConcern(notify chan<- concern.Notify) *Concern {
+	c := &Concern{
+		StateManager: NewStateManager(notify),
+	}
+	return c
+}
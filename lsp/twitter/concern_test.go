@@ -0,0 +1,183 @@
+package twitter
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcern_ParseId(t *testing.T) {
+	c := NewConcern(nil)
+	for s, expected := range map[string]string{
+		"Twitter":                          "twitter",
+		" @Twitter ":                       "twitter",
+		"https://x.com/Twitter":            "twitter",
+		"https://twitter.com/a_b/status/1": "a_b",
+		"x.com/abc?lang=en":                "abc",
+	} {
+		id, err := c.ParseId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, id, s)
+	}
+	for _, s := range []string{"", "@", "a-b", "abcdefghijklmnopq", "https://example.com/"} {
+		_, err := c.ParseId(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+const nitterRssFmt = `<?xml version="1.0" encoding="UTF-8"?>
+<rss xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/" version="2.0">
+<channel>
+<title>%v / @Abc</title>
+<image><url>https://nitter.test/pic/avatar.jpg</url></image>
+%v
+</channel>
+</rss>`
+
+const nitterItemFmt = `<item>
+<title>%v</title>
+<dc:creator>@%v</dc:creator>
+<description><![CDATA[<p>text</p><img src="https://nitter.test/pic/%v.jpg" style="max-width:250px;" />]]></description>
+<pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+<guid>https://nitter.test/%v/status/%v#m</guid>
+<link>https://nitter.test/%v/status/%v#m</link>
+</item>`
+
+func nitterItem(title string, creator string, id int) string {
+	return fmt.Sprintf(nitterItemFmt, title, creator, id, creator, id, creator, id)
+}
+
+func TestConcern_FreshNitter(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	var tweetCount int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/abc/rss" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var items = nitterItem("tweet1", "Abc", 100)
+		if atomic.LoadInt32(&tweetCount) > 1 {
+			items = nitterItem("tweet2", "Abc", 200) + nitterItem("RT by @Abc: old tweet", "other", 50) + items
+		}
+		fmt.Fprintf(w, nitterRssFmt, test.NAME1, items)
+	}))
+	defer ts.Close()
+	config.GlobalConfig.Set("twitter.nitter", ts.URL)
+	defer config.GlobalConfig.Set("twitter.nitter", nil)
+
+	c := NewConcern(nil)
+	c.FreshIndex(mmsg.NewGroupTargetId(test.G1))
+
+	identity, err := c.Add(nil, mmsg.NewGroupTargetId(test.G1), "abc", News)
+	assert.Nil(t, err)
+	assert.Equal(t, test.NAME1, identity.GetName())
+	_, err = c.Add(nil, mmsg.NewGroupTargetId(test.G1), "notexist", News)
+	assert.NotNil(t, err)
+
+	userInfo, err := c.GetUserInfo("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "Abc", userInfo.Username)
+	assert.Equal(t, "https://nitter.test/pic/avatar.jpg", userInfo.Avatar)
+
+	// 第一次刷新不推送
+	tweets, err := c.freshTweets("abc")
+	assert.Nil(t, err)
+	assert.Empty(t, tweets)
+
+	atomic.StoreInt32(&tweetCount, 2)
+	tweets, err = c.freshTweets("abc")
+	assert.Nil(t, err)
+	if assert.Len(t, tweets, 2) {
+		// 转推的原推文id比上次的推文更早，但仍然需要推送
+		assert.Equal(t, Retweet, tweets[0].Type())
+		assert.Equal(t, "old tweet", tweets[0].Text)
+		assert.Equal(t, "other", tweets[0].OriginUsername)
+		assert.Equal(t, TweetUrl("other", "50"), tweets[0].Url())
+		assert.Equal(t, News, tweets[1].Type())
+		assert.Equal(t, "tweet2", tweets[1].Text)
+		assert.Equal(t, []string{"https://nitter.test/pic/200.jpg"}, tweets[1].Images)
+		assert.NotZero(t, tweets[1].CreatedAt)
+	}
+	tweets, err = c.freshTweets("abc")
+	assert.Nil(t, err)
+	assert.Empty(t, tweets)
+
+	lastId, err := c.GetLastTweetId("abc")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 200, lastId)
+
+	_, err = c.freshTweets("notexist")
+	assert.Equal(t, ErrNotExist, err)
+
+	_, err = c.Remove(nil, mmsg.NewGroupTargetId(test.G1), "abc", News)
+	assert.Nil(t, err)
+	_, err = c.GetUserInfo("abc")
+	assert.NotNil(t, err)
+}
+
+func TestApiBackend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/2/users/by/username/abc":
+			fmt.Fprintf(w, `{"data":{"id":"1","name":"%v","username":"Abc","profile_image_url":"avatar"}}`, test.NAME1)
+		case "/2/users/1/tweets":
+			fmt.Fprint(w, `{
+"data":[
+  {"id":"300","text":"RT @other: hi","created_at":"2006-01-02T15:04:05.000Z","referenced_tweets":[{"type":"retweeted","id":"30"}]},
+  {"id":"200","text":"hello","created_at":"2006-01-02T15:04:05.000Z","attachments":{"media_keys":["m1","m2"]}}
+],
+"includes":{
+  "media":[{"media_key":"m1","type":"photo","url":"img1"},{"media_key":"m2","type":"video","preview_image_url":"img2"}],
+  "users":[{"id":"2","username":"other"}],
+  "tweets":[{"id":"30","text":"hi","author_id":"2"}]
+}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	oldHost := ApiHost
+	ApiHost = ts.URL
+	defer func() { ApiHost = oldHost }()
+
+	config.GlobalConfig.Set("twitter.token", "test-token")
+	defer config.GlobalConfig.Set("twitter.token", nil)
+
+	b := getBackend()
+	assert.IsType(t, new(apiBackend), b)
+
+	userInfo, err := b.GetUserInfo("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", userInfo.Id)
+	assert.Equal(t, "1", userInfo.UserId)
+	assert.Equal(t, test.NAME1, userInfo.Name)
+
+	_, err = b.GetUserInfo("notexist")
+	assert.Equal(t, ErrNotExist, err)
+
+	tweets, err := b.GetTweets(&UserInfo{Id: "abc"})
+	assert.Nil(t, err)
+	if assert.Len(t, tweets, 2) {
+		assert.True(t, tweets[0].IsRetweet())
+		assert.Equal(t, "hi", tweets[0].Text)
+		assert.Equal(t, "other", tweets[0].OriginUsername)
+		assert.Equal(t, "30", tweets[0].OriginTweetId)
+		assert.Equal(t, "1", tweets[0].UserId)
+		assert.False(t, tweets[1].IsRetweet())
+		assert.Equal(t, []string{"img1", "img2"}, tweets[1].Images)
+		assert.NotZero(t, tweets[1].CreatedAt)
+	}
+
+	config.GlobalConfig.Set("twitter.backend", BackendNitter)
+	defer config.GlobalConfig.Set("twitter.backend", nil)
+	assert.IsType(t, new(nitterBackend), getBackend())
+}
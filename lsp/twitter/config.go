@@ -0,0 +1,13 @@
+package twitter
+
+import (
+	"github.com/Sora233/DDBOT/lsp/concern"
+)
+
+type GroupConcernConfig struct {
+	concern.IConfig
+}
+
+func NewGroupConcernConfig(g concern.IConfig) *GroupConcernConfig {
+	return &GroupConcernConfig{g}
+}
@@ -0,0 +1,7 @@
+package twitter
+
+import "errors"
+
+var (
+	ErrNotExist = errors.New("推特用户不存在")
+)
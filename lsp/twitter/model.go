@@ -0,0 +1,155 @@
+package twitter
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/lsp/template"
+	localutils "github.com/Sora233/DDBOT/utils"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// UserInfo 推特用户的信息，Id为小写的用户名
+type UserInfo struct {
+	Id string `json:"id"`
+	// UserId 推特API使用的数字id，使用Nitter时为空
+	UserId   string `json:"user_id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Avatar   string `json:"avatar"`
+}
+
+func (u *UserInfo) GetUid() interface{} {
+	return u.Id
+}
+
+func (u *UserInfo) GetName() string {
+	if u == nil {
+		return ""
+	}
+	return u.Name
+}
+
+// TweetInfo 用户发布或者转推的一条推文
+type TweetInfo struct {
+	UserInfo
+	TweetId   string `json:"tweet_id"`
+	Text      string `json:"text"`
+	CreatedAt int64  `json:"created_at"`
+	// Images 推文附带的图片，视频为封面
+	Images []string `json:"images"`
+	// OriginUsername 转推时为原推文作者的用户名
+	OriginUsername string `json:"origin_username"`
+	// OriginTweetId 转推时为原推文的id
+	OriginTweetId string `json:"origin_tweet_id"`
+
+	once     sync.Once
+	msgCache *mmsg.MSG
+}
+
+func (t *TweetInfo) IsRetweet() bool {
+	return len(t.OriginTweetId) > 0
+}
+
+func (t *TweetInfo) Type() concern_type.Type {
+	if t.IsRetweet() {
+		return Retweet
+	}
+	return News
+}
+
+func (t *TweetInfo) Site() string {
+	return Site
+}
+
+func (t *TweetInfo) Url() string {
+	if t.IsRetweet() {
+		return TweetUrl(t.OriginUsername, t.OriginTweetId)
+	}
+	return TweetUrl(t.Username, t.TweetId)
+}
+
+// DedupKeys 实现 concern.NotifyDedupExt ，多个订阅的用户转推同一条推文时只推送一次
+func (t *TweetInfo) DedupKeys() []string {
+	if t.IsRetweet() {
+		return []string{fmt.Sprintf("tweet:%v", t.OriginTweetId)}
+	}
+	return []string{fmt.Sprintf("tweet:%v", t.TweetId)}
+}
+
+// TranslateText 实现 concern.NotifyTranslateExt
+func (t *TweetInfo) TranslateText() string {
+	return t.Text
+}
+
+func (t *TweetInfo) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"Site":    Site,
+		"Id":      t.Id,
+		"Name":    t.Name,
+		"Type":    t.Type().String(),
+		"TweetId": t.TweetId,
+	})
+}
+
+// TemplateData 返回推文推送模板使用的数据
+func (t *TweetInfo) TemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     t.Name,
+		"username": t.Username,
+		"text":     t.Text,
+		"url":      t.Url(),
+		"time":     localutils.TimestampFormat(t.CreatedAt),
+		"images":   t.Images,
+		"retweet":  t.IsRetweet(),
+		"origin":   t.OriginUsername,
+	}
+}
+
+func (t *TweetInfo) GetMSG() *mmsg.MSG {
+	t.once.Do(func() {
+		var data = t.TemplateData()
+		var name = "notify.group.twitter.news.tmpl"
+		if t.IsRetweet() {
+			name = "notify.group.twitter.retweet.tmpl"
+		}
+		var err error
+		t.msgCache, err = template.LoadAndExec(name, data)
+		if err != nil {
+			logger.Errorf("twitter: TweetInfo LoadAndExec error %v", err)
+		}
+		return
+	})
+	return t.msgCache
+}
+
+type ConcernTweetNotify struct {
+	*TweetInfo
+	Target mmsg.TargetId `json:"target"`
+}
+
+func (notify *ConcernTweetNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
+}
+
+func (notify *ConcernTweetNotify) ToMessage() (m *mmsg.MSG) {
+	return notify.TweetInfo.GetMSG()
+}
+
+func (notify *ConcernTweetNotify) Logger() *logrus.Entry {
+	if notify == nil {
+		return logger
+	}
+	return notify.TweetInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
+}
+
+func NewConcernTweetNotify(target mmsg.TargetId, t *TweetInfo) *ConcernTweetNotify {
+	if t == nil {
+		return nil
+	}
+	return &ConcernTweetNotify{
+		t,
+		target,
+	}
+}
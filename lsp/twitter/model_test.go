@@ -0,0 +1,48 @@
+package twitter
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTweetInfo(t *testing.T) {
+	tweet := &TweetInfo{
+		UserInfo: UserInfo{
+			Id:       "abc",
+			Username: "Abc",
+			Name:     test.NAME1,
+		},
+		TweetId:   "100",
+		Text:      "hello",
+		CreatedAt: 1600000000,
+	}
+	assert.Equal(t, Site, tweet.Site())
+	assert.Equal(t, "abc", tweet.GetUid())
+	assert.Equal(t, test.NAME1, tweet.GetName())
+	assert.Equal(t, News, tweet.Type())
+	assert.Equal(t, TweetUrl("Abc", "100"), tweet.Url())
+	assert.EqualValues(t, []string{"tweet:100"}, tweet.DedupKeys())
+	assert.Equal(t, "hello", tweet.TranslateText())
+	notify := NewConcernTweetNotify(mmsg.NewGroupTargetId(test.G1), tweet)
+	assert.NotNil(t, notify)
+	assert.NotNil(t, notify.Logger())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
+	assert.Equal(t, News, notify.Type())
+	assert.NotNil(t, notify.ToMessage())
+
+	retweet := &TweetInfo{
+		UserInfo:       tweet.UserInfo,
+		TweetId:        "200",
+		OriginUsername: "other",
+		OriginTweetId:  "50",
+		Images:         []string{"https://example.com/a.jpg"},
+	}
+	assert.Equal(t, Retweet, retweet.Type())
+	assert.Equal(t, TweetUrl("other", "50"), retweet.Url())
+	assert.EqualValues(t, []string{"tweet:50"}, retweet.DedupKeys())
+	assert.NotNil(t, NewConcernTweetNotify(mmsg.NewGroupTargetId(test.G2), retweet).ToMessage())
+
+	assert.Nil(t, NewConcernTweetNotify(mmsg.NewGroupTargetId(test.G1), nil))
+}
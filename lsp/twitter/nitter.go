@@ -0,0 +1,127 @@
+package twitter
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/requests"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// nitterBackend 通过Nitter实例的RSS获取推文，不需要推特账号，使用 twitter.nitter 配置实例地址
+type nitterBackend struct{}
+
+var (
+	nitterStatusRegexp = regexp.MustCompile(`/([A-Za-z0-9_]+)/status/(\d+)`)
+	nitterImgRegexp    = regexp.MustCompile(`<img src="([^"]+)"`)
+	// nitterTitleRegexp 匹配RSS频道的标题，例如 "Name / @username"
+	nitterTitleRegexp = regexp.MustCompile(`^(.*) / @([A-Za-z0-9_]+)$`)
+)
+
+type NitterRss struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Image struct {
+			Url string `xml:"url"`
+		} `xml:"image"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+			Guid        string `xml:"guid"`
+			Link        string `xml:"link"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func getNitterRss(username string) (*NitterRss, error) {
+	var (
+		body []byte
+		code int
+	)
+	err := twitterGet(cfg.GetTwitterNitter()+"/"+username+"/rss", nil, &body, requests.HttpCodeOption(&code))
+	if code == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rss = new(NitterRss)
+	if err = xml.Unmarshal(body, rss); err != nil {
+		return nil, err
+	}
+	return rss, nil
+}
+
+func (n *nitterBackend) parseUserInfo(username string, rss *NitterRss) (*UserInfo, error) {
+	sub := nitterTitleRegexp.FindStringSubmatch(strings.TrimSpace(rss.Channel.Title))
+	if sub == nil {
+		return nil, errors.New("无法解析Nitter的RSS")
+	}
+	return &UserInfo{
+		Id:       strings.ToLower(username),
+		Username: sub[2],
+		Name:     sub[1],
+		Avatar:   rss.Channel.Image.Url,
+	}, nil
+}
+
+func (n *nitterBackend) GetUserInfo(username string) (*UserInfo, error) {
+	rss, err := getNitterRss(username)
+	if err != nil {
+		return nil, err
+	}
+	return n.parseUserInfo(username, rss)
+}
+
+func (n *nitterBackend) GetTweets(userInfo *UserInfo) ([]*TweetInfo, error) {
+	rss, err := getNitterRss(userInfo.Id)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := n.parseUserInfo(userInfo.Id, rss); err == nil {
+		info.UserId = userInfo.UserId
+		*userInfo = *info
+	}
+	var result []*TweetInfo
+	for _, item := range rss.Channel.Items {
+		link := item.Link
+		if len(link) == 0 {
+			link = item.Guid
+		}
+		sub := nitterStatusRegexp.FindStringSubmatch(link)
+		if sub == nil {
+			continue
+		}
+		var tweet = &TweetInfo{
+			UserInfo: *userInfo,
+			TweetId:  sub[2],
+			Text:     item.Title,
+		}
+		if pubDate, err := time.Parse(time.RFC1123, item.PubDate); err == nil {
+			tweet.CreatedAt = pubDate.Unix()
+		}
+		// 转推的creator是原推文的作者，标题带有 "RT by @username: " 前缀
+		creator := strings.TrimPrefix(strings.TrimSpace(item.Creator), "@")
+		if len(creator) > 0 && !strings.EqualFold(creator, userInfo.Id) {
+			tweet.OriginUsername = creator
+			tweet.OriginTweetId = sub[2]
+			if idx := strings.Index(tweet.Text, ": "); strings.HasPrefix(tweet.Text, "RT by @") && idx >= 0 {
+				tweet.Text = tweet.Text[idx+2:]
+			}
+		}
+		for _, img := range nitterImgRegexp.FindAllStringSubmatch(item.Description, -1) {
+			if len(tweet.Images) >= maxImages {
+				break
+			}
+			tweet.Images = append(tweet.Images, html.UnescapeString(img[1]))
+		}
+		result = append(result, tweet)
+	}
+	return result, nil
+}
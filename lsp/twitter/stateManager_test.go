@@ -0,0 +1,63 @@
+package twitter
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initStateManager(t *testing.T) *StateManager {
+	sm := NewStateManager(nil)
+	assert.NotNil(t, sm)
+	sm.FreshIndex(mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2))
+	return sm
+}
+
+func TestStateManager_UserInfo(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	assert.NotNil(t, sm.GetGroupConcernConfig(mmsg.NewGroupTargetId(test.G1), test.NAME1))
+
+	_, err := sm.GetUserInfo("abc")
+	assert.NotNil(t, err)
+	assert.NotNil(t, sm.AddUserInfo(nil))
+
+	expected := &UserInfo{
+		Id:       "abc",
+		Username: "Abc",
+		Name:     test.NAME1,
+	}
+	assert.Nil(t, sm.AddUserInfo(expected))
+	actual, err := sm.GetUserInfo("abc")
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, actual)
+
+	assert.Nil(t, sm.SetLastTweetId("abc", 100))
+	assert.Nil(t, sm.DeleteUserInfo("abc"))
+	_, err = sm.GetUserInfo("abc")
+	assert.NotNil(t, err)
+	_, err = sm.GetLastTweetId("abc")
+	assert.NotNil(t, err)
+}
+
+func TestStateManager_MarkTweet(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+
+	sm := initStateManager(t)
+
+	replaced, err := sm.MarkTweet("abc", "100")
+	assert.Nil(t, err)
+	assert.False(t, replaced)
+	replaced, err = sm.MarkTweet("abc", "100")
+	assert.Nil(t, err)
+	assert.True(t, replaced)
+	// 不同用户转推同一条推文互不影响
+	replaced, err = sm.MarkTweet("def", "100")
+	assert.Nil(t, err)
+	assert.False(t, replaced)
+}
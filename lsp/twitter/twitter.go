@@ -0,0 +1,77 @@
+package twitter
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/proxy_pool"
+	"github.com/Sora233/DDBOT/requests"
+	"github.com/Sora233/DDBOT/utils"
+	"time"
+)
+
+const (
+	Site = "twitter"
+
+	BackendApi    = "api"
+	BackendNitter = "nitter"
+)
+
+// ApiHost 推特官方API的地址，测试时可以替换
+var ApiHost = "https://api.twitter.com"
+
+const (
+	PathUserByUsername = "/2/users/by/username/%v"
+	PathUserTweets     = "/2/users/%v/tweets"
+)
+
+// maxImages 推特每条推文最多附带4张图片
+const maxImages = 4
+
+func ApiPath(path string) string {
+	return ApiHost + path
+}
+
+func UserUrl(username string) string {
+	return fmt.Sprintf("https://x.com/%v", username)
+}
+
+func TweetUrl(username string, tweetId string) string {
+	return fmt.Sprintf("https://x.com/%v/status/%v", username, tweetId)
+}
+
+// backend 获取推特用户信息和推文的方式
+type backend interface {
+	GetUserInfo(username string) (*UserInfo, error)
+	// GetTweets 返回用户最新的推文，按发布时间从新到旧排列
+	GetTweets(userInfo *UserInfo) ([]*TweetInfo, error)
+}
+
+// getBackend 根据 twitter.backend 配置选择后端，没有配置时，设置了 twitter.token 则使用官方API，否则使用Nitter
+func getBackend() backend {
+	switch cfg.GetTwitterBackend() {
+	case BackendApi:
+		return new(apiBackend)
+	case BackendNitter:
+		return new(nitterBackend)
+	}
+	if len(cfg.GetTwitterToken()) > 0 {
+		return new(apiBackend)
+	}
+	return new(nitterBackend)
+}
+
+func twitterGet(url string, params interface{}, out interface{}, opts ...requests.Option) error {
+	st := time.Now()
+	defer func() {
+		ed := time.Now()
+		logger.WithField("FuncName", utils.FuncName()).Tracef("cost %v", ed.Sub(st))
+	}()
+	opts = append([]requests.Option{
+		requests.ProxyOption(proxy_pool.PreferOversea),
+		requests.SiteOption(Site),
+		requests.AddUAOption(),
+		requests.TimeoutOption(time.Second * 10),
+		requests.RetryOption(3),
+	}, opts...)
+	return requests.Get(url, params, out, opts...)
+}
@@ -2,4 +2,8 @@ package version
 
 import "errors"
 
-var ErrUnknownVersion = errors.New("version is unknown")
+var (
+	ErrUnknownVersion      = errors.New("version is unknown")
+	ErrVersionTooHigh      = errors.New("version is too high")
+	ErrMigrationIncomplete = errors.New("migration is incomplete")
+)
@@ -2,7 +2,6 @@ package version
 
 import (
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
-	"github.com/tidwall/buntdb"
 )
 
 // ChainMigration 将多个 MigrationFunc 组合成一个 MigrationFunc ，每个 MigrationFunc 会按顺序执行
@@ -58,7 +57,7 @@ func migrationByPattern(patternFunc localdb.KeyPatternFunc, keepOldOnChanged boo
 		if err := localdb.CreatePatternIndex(patternFunc, nil); err != nil {
 			return err
 		}
-		return localdb.RWCoverTx(func(tx *buntdb.Tx) error {
+		return localdb.RWCoverTx(func(tx localdb.Tx) error {
 			var data [][2]string
 			err := tx.Ascend(patternFunc(), func(key, value string) bool {
 				data = append(data, [2]string{key, value})
@@ -4,7 +4,6 @@ import (
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"testing"
 )
 
@@ -13,7 +12,7 @@ const testName = "test-mig"
 func v1() MigrationFunc {
 	return ChainMigration(
 		func() error {
-			return localdb.RWCoverTx(func(tx *buntdb.Tx) error {
+			return localdb.RWCoverTx(func(tx localdb.Tx) error {
 				_, _, err := tx.Set(localdb.BilibiliGroupConcernStateKey(test.G1, test.UID1), "3", nil)
 				if err != nil {
 					return err
@@ -75,7 +74,7 @@ func TestDoMigration(t *testing.T) {
 
 	assert.EqualValues(t, 99, GetCurrentVersion(testName))
 
-	err := localdb.RCoverTx(func(tx *buntdb.Tx) error {
+	err := localdb.RCoverTx(func(tx localdb.Tx) error {
 		val, err := tx.Get(localdb.BilibiliGroupConcernStateKey(test.G1, test.UID1))
 		if err != nil {
 			return err
@@ -98,7 +97,7 @@ func TestDoMigration(t *testing.T) {
 
 	assert.EqualValues(t, 100, GetCurrentVersion(testName))
 
-	err = localdb.RCoverTx(func(tx *buntdb.Tx) error {
+	err = localdb.RCoverTx(func(tx localdb.Tx) error {
 		assert.False(t, localdb.Exist(localdb.BilibiliGroupConcernStateKey(test.G1, test.UID1)))
 		assert.False(t, localdb.Exist(localdb.BilibiliGroupConcernStateKey(test.G1, test.UID2)))
 
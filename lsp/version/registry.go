@@ -0,0 +1,134 @@
+package version
+
+import (
+	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"sort"
+	"sync"
+)
+
+// Module 表示一个注册了数据库迁移的模块，例如 lsp bilibili douyu
+type Module struct {
+	Name           string
+	SupportVersion int64
+	Migrations     MigrationMap
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = make(map[string]*Module)
+)
+
+// Register 注册模块 name 的数据库迁移，supportVersion 为模块当前支持的最高版本，
+// 启动时会把数据库中该模块的版本迁移到 supportVersion。
+// 同一个 name 只能注册一次，重复注册会panic。
+func Register(name string, supportVersion int64, m MigrationMap) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if _, found := modules[name]; found {
+		panic(fmt.Sprintf("version: Migration <%v> already registered", name))
+	}
+	modules[name] = &Module{
+		Name:           name,
+		SupportVersion: supportVersion,
+		Migrations:     m,
+	}
+}
+
+// RegisterMigrationFunc 按顺序注册模块 name 的迁移，第i个 MigrationFunc 负责从版本i迁移到版本i+1，
+// 模块支持的最高版本即为 len(fn)
+func RegisterMigrationFunc(name string, fn ...MigrationFunc) {
+	Register(name, int64(len(fn)), NewMigrationMapFromList(fn...))
+}
+
+// NewMigrationMapFromList 使用有序的 MigrationFunc 创建 MigrationMap ，第i个 MigrationFunc 负责从版本i迁移到版本i+1
+func NewMigrationMapFromList(fn ...MigrationFunc) MigrationMap {
+	var m = make(map[int64]Migration)
+	for idx, f := range fn {
+		m[int64(idx)] = CreateSimpleMigration(int64(idx+1), f)
+	}
+	return NewMigrationMapFromMap(m)
+}
+
+// RegisteredModules 返回所有注册的模块，按名字排序
+func RegisteredModules() []*Module {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	var result []*Module
+	for _, m := range modules {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ClearModules 现阶段仅用于测试
+func ClearModules() {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	modules = make(map[string]*Module)
+}
+
+// InitVersion 将所有注册的模块设置为支持的最高版本，用于全新的数据库
+func InitVersion() error {
+	return localdb.RWCover(func() error {
+		for _, m := range RegisteredModules() {
+			if _, err := SetVersion(m.Name, m.SupportVersion); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PendingModules 返回数据库版本低于支持版本、需要迁移的模块。
+// 如果某个模块的版本无法识别，返回 ErrUnknownVersion ；
+// 如果某个模块的版本高于支持的版本，返回 ErrVersionTooHigh ，这种情况通常是降级了DDBOT。
+func PendingModules() ([]*Module, error) {
+	var result []*Module
+	for _, m := range RegisteredModules() {
+		curV := GetCurrentVersion(m.Name)
+		if curV < 0 {
+			return nil, fmt.Errorf("<%v> %w", m.Name, ErrUnknownVersion)
+		}
+		if curV > m.SupportVersion {
+			return nil, fmt.Errorf("<%v> %w：最高支持版本：%v，当前版本：%v", m.Name, ErrVersionTooHigh, m.SupportVersion, curV)
+		}
+		if curV < m.SupportVersion {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// MigrateAll 在同一个事务中对所有需要迁移的模块执行迁移，任意一个模块迁移失败时所有修改都会回滚。
+// dryRun 为true时，执行完所有迁移后回滚，可以用来检查迁移能否成功，不会修改数据库。
+func MigrateAll(dryRun bool) error {
+	if dryRun {
+		logger.Info("dry-run模式：迁移完成后所有修改都会回滚")
+	}
+	err := localdb.RWCover(func() error {
+		pending, err := PendingModules()
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			if err := DoMigration(m.Name, m.Migrations); err != nil {
+				return fmt.Errorf("<%v> %w", m.Name, err)
+			}
+			if curV := GetCurrentVersion(m.Name); curV != m.SupportVersion {
+				return fmt.Errorf("<%v> %w：迁移后版本为%v，支持版本为%v", m.Name, ErrMigrationIncomplete, curV, m.SupportVersion)
+			}
+		}
+		if dryRun {
+			return localdb.ErrRollback
+		}
+		return nil
+	})
+	if dryRun && localdb.IsRollback(err) {
+		return nil
+	}
+	return err
+}
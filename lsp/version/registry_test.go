@@ -0,0 +1,118 @@
+package version
+
+import (
+	"errors"
+	"github.com/Sora233/DDBOT/internal/test"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testModule1 = "test-module1"
+	testModule2 = "test-module2"
+)
+
+func TestRegister(t *testing.T) {
+	ClearModules()
+	defer ClearModules()
+
+	Register(testModule2, 2, NewMigrationMapFromMap(nil))
+	RegisterMigrationFunc(testModule1, v1(), v2())
+	assert.Panics(t, func() {
+		Register(testModule1, 1, nil)
+	})
+
+	modules := RegisteredModules()
+	assert.Len(t, modules, 2)
+	assert.EqualValues(t, testModule1, modules[0].Name)
+	assert.EqualValues(t, 2, modules[0].SupportVersion)
+	assert.EqualValues(t, testModule2, modules[1].Name)
+
+	m := modules[0].Migrations
+	assert.EqualValues(t, 1, m.From(0).TargetVersion())
+	assert.EqualValues(t, 2, m.From(1).TargetVersion())
+	assert.Nil(t, m.From(2))
+}
+
+func TestMigrateAll(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	ClearModules()
+	defer ClearModules()
+
+	RegisterMigrationFunc(testModule1, v1(), v2())
+	RegisterMigrationFunc(testModule2)
+
+	pending, err := PendingModules()
+	assert.Nil(t, err)
+	assert.Len(t, pending, 1)
+	assert.EqualValues(t, testModule1, pending[0].Name)
+
+	var getValue = func() string {
+		var val string
+		assert.Nil(t, localdb.RCoverTx(func(tx localdb.Tx) error {
+			var err error
+			val, err = tx.Get(localdb.BilibiliGroupConcernStateKey(test.G1, test.UID1))
+			if localdb.IsNotFound(err) {
+				err = nil
+			}
+			return err
+		}))
+		return val
+	}
+
+	// dry-run不会修改数据库
+	assert.Nil(t, MigrateAll(true))
+	assert.Zero(t, GetCurrentVersion(testModule1))
+	assert.Empty(t, getValue())
+
+	assert.Nil(t, MigrateAll(false))
+	assert.EqualValues(t, 2, GetCurrentVersion(testModule1))
+	assert.EqualValues(t, "live/news", getValue())
+
+	pending, err = PendingModules()
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+
+	_, err = SetVersion(testModule2, 1)
+	assert.Nil(t, err)
+	_, err = PendingModules()
+	assert.True(t, errors.Is(err, ErrVersionTooHigh))
+}
+
+func TestMigrateAll_Fail(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	ClearModules()
+	defer ClearModules()
+
+	var errTest = errors.New("test error")
+	RegisterMigrationFunc(testModule1, v1())
+	RegisterMigrationFunc(testModule2, func() error {
+		return errTest
+	})
+
+	err := MigrateAll(false)
+	assert.True(t, errors.Is(err, errTest))
+	// 失败时所有模块都会回滚
+	assert.Zero(t, GetCurrentVersion(testModule1))
+	assert.Zero(t, GetCurrentVersion(testModule2))
+
+	assert.True(t, errors.Is(MigrateAll(true), errTest))
+}
+
+func TestInitVersion(t *testing.T) {
+	test.InitBuntdb(t)
+	defer test.CloseBuntdb(t)
+	ClearModules()
+	defer ClearModules()
+
+	RegisterMigrationFunc(testModule1, v1(), v2())
+	assert.Nil(t, InitVersion())
+	assert.EqualValues(t, 2, GetCurrentVersion(testModule1))
+
+	pending, err := PendingModules()
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+}
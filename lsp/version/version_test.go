@@ -4,7 +4,6 @@ import (
 	"github.com/Sora233/DDBOT/internal/test"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/stretchr/testify/assert"
-	"github.com/tidwall/buntdb"
 	"testing"
 )
 
@@ -29,7 +28,7 @@ func TestGetCurrentVersion(t *testing.T) {
 		assert.Equal(t, expected[idx], old)
 	}
 
-	err := localdb.RWCoverTx(func(tx *buntdb.Tx) error {
+	err := localdb.RWCoverTx(func(tx localdb.Tx) error {
 		_, _, err := tx.Set(localdb.VersionKey(test.VersionName), "wrong", nil)
 		return err
 	})
@@ -0,0 +1,370 @@
+package lsp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/cfg"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/requests"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var webhookLogger = logger.WithField("sub_module", "webhook")
+
+const (
+	// webhookHistorySize 最多保留多少次投递结果用于 /webhook 命令查询
+	webhookHistorySize = 50
+	webhookWorker      = 4
+	// webhookQueueSize 等待投递的队列长度，队列满时新的投递会被丢弃并记录为失败
+	webhookQueueSize = 256
+	// webhookMaxRetry 每次投递最多尝试的次数，webhook.retry 超过时使用这个值
+	webhookMaxRetry = 10
+	// WebhookTopicPing 使用 /webhook -t 发送的测试事件
+	WebhookTopicPing concern.Topic = "ping"
+)
+
+// WebhookPayload 发送给webhook的JSON内容
+type WebhookPayload struct {
+	Id    string        `json:"id"`
+	Event concern.Topic `json:"event"`
+	Site  string        `json:"site,omitempty"`
+	Type  string        `json:"type,omitempty"`
+	Uid   interface{}   `json:"uid,omitempty"`
+	// GroupCode 群webhook收到的事件所属的群
+	GroupCode int64 `json:"group_code,omitempty"`
+	// Groups 全局webhook收到的事件，包含所有订阅了这个事件的群
+	Groups []int64 `json:"groups,omitempty"`
+	// Targets 全局webhook收到的事件，包含所有订阅了这个事件的推送目标，格式与 mmsg.TargetId.Key 相同
+	Targets []string    `json:"targets,omitempty"`
+	Time    int64       `json:"time"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// WebhookDelivery 一次webhook投递的结果
+type WebhookDelivery struct {
+	Id        string
+	Url       string
+	Event     concern.Topic
+	Site      string
+	GroupCode int64
+	Attempts  int
+	// Code 最后一次请求的http code，0表示没有收到响应
+	Code    int
+	Error   string
+	Success bool
+	Time    time.Time
+}
+
+type webhookTask struct {
+	url      string
+	body     []byte
+	delivery *WebhookDelivery
+}
+
+// Webhook 把订阅事件以JSON的格式POST到配置的地址，配置了 webhook.secret 时，
+// 会在 X-DDBOT-Signature 中携带 sha256=<HMAC-SHA256(secret, body)> 用于校验
+type Webhook struct {
+	queue chan *webhookTask
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	seq     int64
+	history []*WebhookDelivery
+
+	unsubscribe func()
+	// RetryInterval 发送失败后等待多久再重试，第n次重试等待n倍的时间
+	RetryInterval time.Duration
+}
+
+func NewWebhook() *Webhook {
+	return &Webhook{
+		queue:         make(chan *webhookTask, webhookQueueSize),
+		stop:          make(chan struct{}),
+		RetryInterval: time.Second * 5,
+	}
+}
+
+// Start 在事件总线中订阅开播、下播、直播标题更改、直播间变化与动态事件，没有配置任何webhook地址时也会订阅，修改配置后不需要重启，
+// Stop 之后可以再次 Start
+func (w *Webhook) Start() {
+	w.mu.Lock()
+	select {
+	case <-w.stop:
+		w.stop = make(chan struct{})
+	default:
+	}
+	stop := w.stop
+	w.mu.Unlock()
+	for i := 0; i < webhookWorker; i++ {
+		w.wg.Add(1)
+		go w.work(stop)
+	}
+	w.unsubscribe = concern.Subscribe("webhook", w.onEvent,
+		concern.TopicLiveStart, concern.TopicLiveStop, concern.TopicLiveTitleChange, concern.TopicLiveChange, concern.TopicNewDynamic)
+}
+
+// Stop 停止发送，还在队列中的投递会被丢弃
+func (w *Webhook) Stop() {
+	if w.unsubscribe != nil {
+		w.unsubscribe()
+		w.unsubscribe = nil
+	}
+	w.mu.Lock()
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+	// 丢弃队列中剩余的投递
+	for {
+		select {
+		case <-w.queue:
+		default:
+			return
+		}
+	}
+}
+
+func (w *Webhook) stopChan() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stop
+}
+
+func (w *Webhook) onEvent(e *concern.BusEvent) {
+	var base = WebhookPayload{
+		Event: e.Topic,
+		Site:  e.Site(),
+		Time:  e.Time.Unix(),
+	}
+	if e.Event != nil {
+		base.Type = e.Event.Type().String()
+		base.Uid = e.Event.GetUid()
+	}
+	if urls := cfg.GetWebhookUrls(); len(urls) > 0 {
+		var payload = base
+		for _, target := range e.Targets {
+			if target.TargetType().IsGroup() {
+				payload.Groups = append(payload.Groups, target.TargetCode())
+			}
+			payload.Targets = append(payload.Targets, target.Key())
+		}
+		payload.Data = webhookData(e.Event, e.Notifies...)
+		w.Send(urls, &payload)
+	}
+	// 群webhook只会收到QQ群的推送
+	for _, notify := range e.Notifies {
+		target := notify.GetTarget()
+		if !target.TargetType().IsGroup() {
+			continue
+		}
+		urls := cfg.GetWebhookGroupUrls(target.TargetCode())
+		if len(urls) == 0 {
+			continue
+		}
+		var payload = base
+		payload.GroupCode = target.TargetCode()
+		payload.Data = webhookData(e.Event, notify)
+		w.Send(urls, &payload)
+	}
+}
+
+// webhookData 优先使用 concern.NotifyTemplateData 提供的数据，都没有实现时使用原始的事件
+func webhookData(event concern.Event, notifies ...concern.Notify) interface{} {
+	if ext, ok := event.(concern.NotifyTemplateData); ok {
+		return ext.TemplateData()
+	}
+	for _, notify := range notifies {
+		if ext, ok := notify.(concern.NotifyTemplateData); ok {
+			return ext.TemplateData()
+		}
+	}
+	return event
+}
+
+// Send 把payload发送到所有地址，每个地址是一次单独的投递，不会阻塞
+func (w *Webhook) Send(urls []string, payload *WebhookPayload) {
+	for _, u := range urls {
+		var p = *payload
+		p.Id = w.nextId()
+		body, err := json.Marshal(&p)
+		if err != nil {
+			webhookLogger.Errorf("marshal webhook payload error %v", err)
+			return
+		}
+		var task = &webhookTask{
+			url:  u,
+			body: body,
+			delivery: &WebhookDelivery{
+				Id:        p.Id,
+				Url:       u,
+				Event:     p.Event,
+				Site:      p.Site,
+				GroupCode: p.GroupCode,
+				Time:      time.Now(),
+			},
+		}
+		w.enqueue(task)
+	}
+}
+
+// enqueue 把投递加入队列，不会阻塞，避免无法访问的地址阻塞事件总线，
+// 队列已满或者已经停止时丢弃并记录为投递失败
+func (w *Webhook) enqueue(task *webhookTask) {
+	select {
+	case <-w.stopChan():
+		task.delivery.Error = "bot已停止"
+		w.record(task.delivery)
+		return
+	default:
+	}
+	select {
+	case w.queue <- task:
+	default:
+		task.delivery.Error = "投递队列已满"
+		webhookLogger.WithField("url", webhookUrlString(task.url)).WithField("id", task.delivery.Id).
+			Warn("webhook投递队列已满，丢弃本次投递")
+		w.record(task.delivery)
+	}
+}
+
+// Ping 向所有全局webhook地址发送一条测试事件，返回地址的数量
+func (w *Webhook) Ping() (int, error) {
+	urls := cfg.GetWebhookUrls()
+	if len(urls) == 0 {
+		return 0, errors.New("没有配置全局webhook地址 webhook.urls")
+	}
+	w.Send(urls, &WebhookPayload{
+		Event: WebhookTopicPing,
+		Time:  time.Now().Unix(),
+	})
+	return len(urls), nil
+}
+
+func (w *Webhook) nextId() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seq++
+	return fmt.Sprintf("%v-%v", time.Now().Unix(), w.seq)
+}
+
+func (w *Webhook) work(stop chan struct{}) {
+	defer w.wg.Done()
+	for {
+		select {
+		case task := <-w.queue:
+			w.deliver(task)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// deliver 尝试投递一次，失败时在 RetryInterval 后重新加入队列，等待重试期间不占用worker
+func (w *Webhook) deliver(task *webhookTask) {
+	var (
+		d     = task.delivery
+		retry = cfg.GetWebhookRetry()
+		log   = webhookLogger.WithField("url", webhookUrlString(task.url)).WithField("id", d.Id)
+	)
+	if retry > webhookMaxRetry {
+		retry = webhookMaxRetry
+	}
+	var options = []requests.Option{
+		requests.TimeoutOption(time.Second * 10),
+		requests.HeaderOption("Content-Type", "application/json"),
+		requests.HeaderOption("X-DDBOT-Event", string(d.Event)),
+		requests.HeaderOption("X-DDBOT-Delivery", d.Id),
+		requests.HttpCodeOption(&d.Code),
+	}
+	if secret := cfg.GetWebhookSecret(); secret != "" {
+		options = append(options, requests.HeaderOption("X-DDBOT-Signature", "sha256="+WebhookSignature(secret, task.body)))
+	}
+	d.Attempts++
+	var resp []byte
+	err := requests.PostBody(task.url, task.body, &resp, options...)
+	if err == nil {
+		d.Success = true
+		d.Error = ""
+		w.record(d)
+		return
+	}
+	d.Error = err.Error()
+	if d.Attempts < retry {
+		log.Debugf("webhook deliver failed %v/%v: %v", d.Attempts, retry, err)
+		time.AfterFunc(w.RetryInterval*time.Duration(d.Attempts), func() {
+			w.enqueue(task)
+		})
+		return
+	}
+	log.Errorf("webhook投递失败 %v", d.Error)
+	w.record(d)
+}
+
+func (w *Webhook) record(d *WebhookDelivery) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.history = append(w.history, d)
+	if len(w.history) > webhookHistorySize {
+		w.history = w.history[len(w.history)-webhookHistorySize:]
+	}
+}
+
+// History 返回最近n次投递的结果，最新的在前面
+func (w *Webhook) History(n int) []*WebhookDelivery {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var result []*WebhookDelivery
+	for i := len(w.history) - 1; i >= 0 && len(result) < n; i-- {
+		result = append(result, w.history[i])
+	}
+	return result
+}
+
+// WebhookSignature 返回body使用secret计算的HMAC-SHA256，使用hex编码
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookUrlString 去掉地址中的参数，避免在日志和命令回复中泄漏token
+func webhookUrlString(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// formatWebhookStatus 返回 /webhook 命令的回复
+func formatWebhookStatus(history []*WebhookDelivery) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("全局webhook地址%v个，配置了webhook的群%v个", len(cfg.GetWebhookUrls()), cfg.GetWebhookGroupCount()))
+	if len(history) == 0 {
+		sb.WriteString("\n暂无投递记录")
+		return sb.String()
+	}
+	sb.WriteString("\n最近的投递：")
+	for idx, d := range history {
+		var status = "成功"
+		if !d.Success {
+			status = "失败 " + d.Error
+		}
+		sb.WriteString(fmt.Sprintf("\n%v. %v %v %v", idx+1, d.Time.Format("01-02 15:04:05"), d.Event, d.Site))
+		if d.GroupCode != 0 {
+			sb.WriteString(fmt.Sprintf(" 群%v", d.GroupCode))
+		}
+		sb.WriteString(fmt.Sprintf(" -> %v %v（尝试%v次）", webhookUrlString(d.Url), status, d.Attempts))
+	}
+	return sb.String()
+}
@@ -0,0 +1,174 @@
+package lsp
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	tc "github.com/Sora233/DDBOT/internal/test_concern"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/concern_type"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/MiraiGo-Template/config"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	assert.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		WebhookSignature("key", []byte("The quick brown fox jumps over the lazy dog")))
+}
+
+func TestWebhook_QueueFull(t *testing.T) {
+	config.GlobalConfig.Set("webhook.urls", []string{"http://127.0.0.1:1/global"})
+	defer config.GlobalConfig.Set("webhook", nil)
+
+	// 没有启动worker，队列满之后的投递直接丢弃，不会阻塞
+	w := NewWebhook()
+	for i := 0; i < webhookQueueSize; i++ {
+		_, err := w.Ping()
+		assert.Nil(t, err)
+	}
+	assert.Empty(t, w.History(10))
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Ping()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "Send blocked when queue is full")
+	}
+	if assert.Len(t, w.History(10), 1) {
+		assert.False(t, w.History(1)[0].Success)
+		assert.Contains(t, w.History(1)[0].Error, "队列已满")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []*WebhookPayload
+	var fail int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) > 0 {
+			atomic.AddInt32(&fail, -1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+WebhookSignature("secret", body), r.Header.Get("X-DDBOT-Signature"))
+		var p = new(WebhookPayload)
+		assert.Nil(t, json.Unmarshal(body, p))
+		assert.EqualValues(t, p.Event, r.Header.Get("X-DDBOT-Event"))
+		mu.Lock()
+		payloads = append(payloads, p)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	config.GlobalConfig.Set("webhook.secret", "secret")
+	config.GlobalConfig.Set("webhook.urls", []string{ts.URL + "/global?token=abc"})
+	config.GlobalConfig.Set("webhook.groups", map[string]interface{}{
+		"654321": []string{ts.URL + "/group"},
+	})
+	defer config.GlobalConfig.Set("webhook", nil)
+
+	w := NewWebhook()
+	w.RetryInterval = time.Millisecond
+	w.Start()
+	defer w.Stop()
+
+	testConcern := tc.NewTestConcern(nil, test.Site1, []concern_type.Type{test.T1})
+	event := testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G1), test.NAME1)
+	w.onEvent(&concern.BusEvent{
+		Topic:    concern.TopicNewDynamic,
+		Event:    event,
+		Targets:  []mmsg.TargetId{mmsg.NewGroupTargetId(test.G1), mmsg.NewGroupTargetId(test.G2)},
+		Notifies: []concern.Notify{testConcern.NewTestEvent(test.T1, mmsg.NewGroupTargetId(test.G2), test.NAME1)},
+		Time:     time.Now(),
+	})
+
+	assert.Eventually(t, func() bool {
+		return len(w.History(10)) == 2
+	}, time.Second*5, time.Millisecond*10)
+	mu.Lock()
+	assert.Len(t, payloads, 2)
+	for _, p := range payloads {
+		assert.EqualValues(t, concern.TopicNewDynamic, p.Event)
+		assert.Equal(t, test.Site1, p.Site)
+		if p.GroupCode == 0 {
+			assert.EqualValues(t, []int64{test.G1, test.G2}, p.Groups)
+		} else {
+			assert.EqualValues(t, test.G2, p.GroupCode)
+			assert.Empty(t, p.Groups)
+		}
+	}
+	mu.Unlock()
+
+	// 失败后重试
+	atomic.StoreInt32(&fail, 2)
+	count, err := w.Ping()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Eventually(t, func() bool {
+		return len(w.History(10)) == 3
+	}, time.Second*5, time.Millisecond*10)
+	last := w.History(1)[0]
+	assert.True(t, last.Success)
+	assert.Equal(t, 3, last.Attempts)
+	assert.EqualValues(t, WebhookTopicPing, last.Event)
+
+	// 全部失败
+	atomic.StoreInt32(&fail, 3)
+	_, err = w.Ping()
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return len(w.History(10)) == 4
+	}, time.Second*5, time.Millisecond*10)
+	last = w.History(1)[0]
+	assert.False(t, last.Success)
+	assert.Equal(t, http.StatusInternalServerError, last.Code)
+
+	status := formatWebhookStatus(w.History(10))
+	assert.Contains(t, status, "全局webhook地址1个，配置了webhook的群1个")
+	assert.Contains(t, status, ts.URL+"/global")
+	assert.False(t, strings.Contains(status, "token=abc"))
+
+	config.GlobalConfig.Set("webhook.urls", nil)
+	_, err = w.Ping()
+	assert.NotNil(t, err)
+}
+
+func TestWebhook_Restart(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+	}))
+	defer ts.Close()
+	config.GlobalConfig.Set("webhook.urls", []string{ts.URL})
+	defer config.GlobalConfig.Set("webhook", nil)
+
+	w := NewWebhook()
+	w.Start()
+	w.Stop()
+	// 停止期间的投递直接记录为失败
+	_, err := w.Ping()
+	assert.Nil(t, err)
+	if assert.Len(t, w.History(10), 1) {
+		assert.Contains(t, w.History(1)[0].Error, "bot已停止")
+	}
+
+	// 重新启动后可以继续投递
+	w.Start()
+	defer w.Stop()
+	_, err = w.Ping()
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&count) == 1
+	}, time.Second*5, time.Millisecond*10)
+}
@@ -3,12 +3,11 @@ package weibo
 import (
 	"errors"
 	"fmt"
+	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/MiraiGo-Template/utils"
-	"github.com/tidwall/buntdb"
 	"strconv"
 	"time"
 )
@@ -64,11 +63,11 @@ func (c *Concern) Stop() {
 	logger.Tracef("%v concern已停止", Site)
 }
 
-func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(int64)
-	log := logger.WithFields(localutils.GroupLogFields(groupCode)).WithField("id", id)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
 
-	err := c.StateManager.CheckGroupConcern(groupCode, id, ctype)
+	err := c.StateManager.CheckGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -99,17 +98,17 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype
 			return nil, fmt.Errorf("添加订阅失败 - 内部错误")
 		}
 	}
-	_, err = c.StateManager.AddGroupConcern(groupCode, id, ctype)
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
 	return info, nil
 }
 
-func (c *Concern) Remove(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(int64)
 	identity, _ := c.Get(id)
-	_, err := c.StateManager.RemoveGroupConcern(groupCode, id, ctype)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
 	if identity == nil {
 		identity = concern.NewIdentity(_id, "unknown")
 	}
@@ -143,7 +142,7 @@ func (c *Concern) freshNews(uid int64) (*NewsInfo, error) {
 	var lastTs int64
 	var newsInfo = &NewsInfo{UserInfo: userInfo}
 	oldNewsInfo, err := c.GetNewsInfo(uid)
-	if err == buntdb.ErrNotFound {
+	if err == localdb.ErrNotFound {
 		lastTs = time.Now().Unix()
 		newsInfo.LatestNewsTs = lastTs
 	} else {
@@ -180,12 +179,12 @@ func (c *Concern) freshNews(uid int64) (*NewsInfo, error) {
 }
 
 func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, ievent concern.Event) []concern.Notify {
+	return func(target mmsg.TargetId, ievent concern.Event) []concern.Notify {
 		var result []concern.Notify
 		switch news := ievent.(type) {
 		case *NewsInfo:
 			if len(news.Cards) > 0 {
-				for _, n := range NewConcernNewsNotify(groupCode, news) {
+				for _, n := range NewConcernNewsNotify(target, news) {
 					result = append(result, n)
 				}
 			}
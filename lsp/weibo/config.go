@@ -0,0 +1,109 @@
+package weibo
+
+import (
+	"fmt"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"strings"
+)
+
+type GroupConcernConfig struct {
+	concern.IConfig
+}
+
+func (g *GroupConcernConfig) Validate() error {
+	if !g.GetGroupConcernFilter().Empty() {
+		switch g.GetGroupConcernFilter().Type {
+		case concern.FilterTypeNotType, concern.FilterTypeType:
+			filterByType, err := g.GetGroupConcernFilter().GetFilterByType()
+			if err != nil {
+				return err
+			}
+			var invalid = CheckTypeDefine(filterByType.Type)
+			if len(invalid) != 0 {
+				return fmt.Errorf("未定义的类型：\n%v", strings.Join(invalid, " "))
+			}
+			return nil
+		}
+	}
+	return g.IConfig.Validate()
+}
+
+// FilterHook 在默认的text过滤之外，支持按微博类型过滤，例如不推送转发的微博
+func (g *GroupConcernConfig) FilterHook(notify concern.Notify) (hook *concern.HookResult) {
+	hook = new(concern.HookResult)
+	switch n := notify.(type) {
+	case *ConcernNewsNotify:
+		if g.GetGroupConcernFilter().Empty() {
+			hook.Pass = true
+			return
+		}
+		logger := notify.Logger().WithField("FilterType", g.GetGroupConcernFilter().Type)
+		switch g.GetGroupConcernFilter().Type {
+		case concern.FilterTypeType, concern.FilterTypeNotType:
+			typeFilter, err := g.GetGroupConcernFilter().GetFilterByType()
+			if err != nil {
+				logger.WithField("GroupConcernFilterConfig", g.GetGroupConcernFilter().Config).
+					Errorf("get type filter error %v", err)
+				hook.Pass = true
+				return
+			}
+			var match bool
+			for _, tp := range typeFilter.Type {
+				if f := PredefinedType[tp]; f != nil && f(n.Card.Card) {
+					match = true
+					break
+				}
+			}
+			var ok = match
+			if g.GetGroupConcernFilter().Type == concern.FilterTypeNotType {
+				ok = !match
+			}
+			if ok {
+				logger.Debugf("news notify FilterHook pass")
+				hook.Pass = true
+			} else {
+				logger.WithField("TypeFilter", typeFilter.Type).
+					Debug("news notify FilterHook filtered")
+				hook.Reason = "filtered by TypeFilter"
+			}
+		default:
+			hook = g.IConfig.FilterHook(notify)
+		}
+		return
+	default:
+		hook.Reason = "unknown notify type"
+		return
+	}
+}
+
+func NewGroupConcernConfig(g concern.IConfig) *GroupConcernConfig {
+	return &GroupConcernConfig{g}
+}
+
+const (
+	Zhuanfa    = "转发"
+	Yuanchuang = "原创"
+	Tupian     = "图片"
+)
+
+// PredefinedType 微博类型过滤时可以使用的类型
+var PredefinedType = map[string]func(card *Card) bool{
+	Zhuanfa: func(card *Card) bool {
+		return card.GetMblog().GetRetweetedStatus() != nil
+	},
+	Yuanchuang: func(card *Card) bool {
+		return card.GetMblog().GetRetweetedStatus() == nil
+	},
+	Tupian: func(card *Card) bool {
+		return len(card.GetMblog().GetPics()) > 0
+	},
+}
+
+func CheckTypeDefine(types []string) (invalid []string) {
+	for _, t := range types {
+		if PredefinedType[t] == nil {
+			invalid = append(invalid, t)
+		}
+	}
+	return
+}
@@ -0,0 +1,69 @@
+package weibo
+
+import (
+	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newNewsNotify(repost bool, pic bool) *ConcernNewsNotify {
+	card := &Card{Mblog: &Card_Mblog{}}
+	if repost {
+		card.Mblog.RetweetedStatus = &Card_Mblog{}
+	}
+	if pic {
+		card.Mblog.Pics = []*Card_Mblog_Pics{{}}
+	}
+	return &ConcernNewsNotify{
+		Target:   mmsg.NewGroupTargetId(test.G1),
+		UserInfo: &UserInfo{Uid: test.UID1, Name: test.NAME1},
+		Card:     NewCacheCard(card, test.NAME1),
+	}
+}
+
+func TestGroupConcernConfig_Validate(t *testing.T) {
+	g := NewGroupConcernConfig(new(concern.GroupConcernConfig))
+	assert.Nil(t, g.Validate())
+
+	g.GetGroupConcernFilter().Type = concern.FilterTypeNotType
+	g.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: []string{Zhuanfa}}).ToString()
+	assert.Nil(t, g.Validate())
+
+	g.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: []string{Zhuanfa, "视频"}}).ToString()
+	assert.NotNil(t, g.Validate())
+
+	assert.EqualValues(t, []string{"视频"}, CheckTypeDefine([]string{Zhuanfa, Yuanchuang, Tupian, "视频"}))
+}
+
+func TestGroupConcernConfig_FilterHook(t *testing.T) {
+	var notifies = []*ConcernNewsNotify{
+		newNewsNotify(true, false),
+		newNewsNotify(false, false),
+		newNewsNotify(false, true),
+	}
+	g := NewGroupConcernConfig(new(concern.GroupConcernConfig))
+	for _, notify := range notifies {
+		assert.True(t, g.FilterHook(notify).Pass)
+	}
+
+	var testCase = []struct {
+		filterType string
+		types      []string
+		expected   []bool
+	}{
+		{concern.FilterTypeNotType, []string{Zhuanfa}, []bool{false, true, true}},
+		{concern.FilterTypeType, []string{Zhuanfa}, []bool{true, false, false}},
+		{concern.FilterTypeType, []string{Tupian}, []bool{false, false, true}},
+		{concern.FilterTypeType, []string{Yuanchuang}, []bool{false, true, true}},
+		{concern.FilterTypeNotType, []string{Zhuanfa, Tupian}, []bool{false, true, false}},
+	}
+	for _, tc := range testCase {
+		g.GetGroupConcernFilter().Type = tc.filterType
+		g.GetGroupConcernFilter().Config = (&concern.GroupConcernFilterConfigByType{Type: tc.types}).ToString()
+		for idx, notify := range notifies {
+			assert.Equal(t, tc.expected[idx], g.FilterHook(notify).Pass, "%v %v %v", tc.filterType, tc.types, idx)
+		}
+	}
+}
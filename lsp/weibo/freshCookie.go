@@ -37,6 +37,7 @@ func genvisitor(externalOpts ...requests.Option) (*GenVisitorResponse, error) {
 	}
 	var opts = []requests.Option{
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.AddUAOption(),
 		requests.TimeoutOption(time.Second * 10),
 	}
@@ -68,6 +69,7 @@ func passportVisitor(params gout.H, externalOpts ...requests.Option) (*VisitorIn
 	}()
 	var opts = []requests.Option{
 		requests.ProxyOption(proxy_pool.PreferNone),
+		requests.SiteOption(Site),
 		requests.AddUAOption(),
 		requests.TimeoutOption(time.Second * 10),
 	}
@@ -59,7 +59,7 @@ func (n *NewsInfo) Logger() *logrus.Entry {
 }
 
 type ConcernNewsNotify struct {
-	GroupCode int64 `json:"group_code"`
+	Target mmsg.TargetId `json:"target"`
 	*UserInfo
 	Card *CacheCard
 }
@@ -68,25 +68,36 @@ func (c *ConcernNewsNotify) Type() concern_type.Type {
 	return News
 }
 
-func (c *ConcernNewsNotify) GetGroupCode() int64 {
-	return c.GroupCode
+func (c *ConcernNewsNotify) GetTarget() mmsg.TargetId {
+	return c.Target
 }
 
 func (c *ConcernNewsNotify) Logger() *logrus.Entry {
-	return c.UserInfo.Logger().WithFields(localutils.GroupLogFields(c.GroupCode))
+	return c.UserInfo.Logger().WithFields(mmsg.TargetLogFields(c.Target))
 }
 
 func (c *ConcernNewsNotify) ToMessage() (m *mmsg.MSG) {
 	return c.Card.GetMSG()
 }
 
-func NewConcernNewsNotify(groupCode int64, info *NewsInfo) []*ConcernNewsNotify {
+// TranslateText 返回微博的正文
+func (c *ConcernNewsNotify) TranslateText() string {
+	if c.Card.GetCardType() != CardType_Normal {
+		return ""
+	}
+	if len(c.Card.GetMblog().GetRawText()) > 0 {
+		return localutils.RemoveHtmlTag(c.Card.GetMblog().GetRawText())
+	}
+	return localutils.RemoveHtmlTag(c.Card.GetMblog().GetText())
+}
+
+func NewConcernNewsNotify(target mmsg.TargetId, info *NewsInfo) []*ConcernNewsNotify {
 	var result []*ConcernNewsNotify
 	for _, card := range info.Cards {
 		result = append(result, &ConcernNewsNotify{
-			GroupCode: groupCode,
-			UserInfo:  info.UserInfo,
-			Card:      NewCacheCard(card, info.GetName()),
+			Target:   target,
+			UserInfo: info.UserInfo,
+			Card:     NewCacheCard(card, info.GetName()),
 		})
 	}
 	return result
@@ -4,6 +4,7 @@ import (
 	"errors"
 	localdb "github.com/Sora233/DDBOT/lsp/buntdb"
 	"github.com/Sora233/DDBOT/lsp/concern"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
 	"time"
 )
 
@@ -12,6 +13,10 @@ type StateManager struct {
 	extraKeySet
 }
 
+func (s *StateManager) GetGroupConcernConfig(target mmsg.TargetId, id interface{}) (concernConfig concern.IConfig) {
+	return NewGroupConcernConfig(s.StateManager.GetGroupConcernConfig(target, id))
+}
+
 func NewStateManager(notify chan<- concern.Notify) *StateManager {
 	return &StateManager{
 		StateManager: concern.NewStateManagerWithInt64ID(Site, notify),
@@ -6,7 +6,6 @@ import (
 	"github.com/Sora233/DDBOT/lsp/concern"
 	"github.com/Sora233/DDBOT/lsp/concern_type"
 	"github.com/Sora233/DDBOT/lsp/mmsg"
-	localutils "github.com/Sora233/DDBOT/utils"
 	"github.com/Sora233/MiraiGo-Template/utils"
 )
 
@@ -21,7 +20,7 @@ func (c *Concern) Site() string {
 }
 
 func (c *Concern) Types() []concern_type.Type {
-	return []concern_type.Type{Live, Video}
+	return []concern_type.Type{Live, Video, Premiere, Member}
 }
 
 func (c *Concern) ParseId(s string) (interface{}, error) {
@@ -32,11 +31,11 @@ func (c *Concern) GetStateManager() concern.IStateManager {
 	return c.StateManager
 }
 
-func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Add(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(string)
-	log := logger.WithFields(localutils.GroupLogFields(groupCode)).WithField("id", id)
+	log := logger.WithFields(mmsg.TargetLogFields(target)).WithField("id", id)
 
-	err := c.StateManager.CheckGroupConcern(groupCode, id, ctype)
+	err := c.StateManager.CheckGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
@@ -48,17 +47,17 @@ func (c *Concern) Add(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype
 	for _, v := range info.VideoInfo {
 		c.StateManager.AddVideo(v)
 	}
-	_, err = c.StateManager.AddGroupConcern(groupCode, id, ctype)
+	_, err = c.StateManager.AddGroupConcern(target, id, ctype)
 	if err != nil {
 		return nil, err
 	}
 	return concern.NewIdentity(info.ChannelId, info.ChannelName), nil
 }
 
-func (c *Concern) Remove(ctx mmsg.IMsgCtx, groupCode int64, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
+func (c *Concern) Remove(ctx mmsg.IMsgCtx, target mmsg.TargetId, _id interface{}, ctype concern_type.Type) (concern.IdentityInfo, error) {
 	id := _id.(string)
 	identity, _ := c.Get(id)
-	_, err := c.StateManager.RemoveGroupConcern(groupCode, id, ctype)
+	_, err := c.StateManager.RemoveGroupConcern(target, id, ctype)
 	if identity == nil {
 		identity = concern.NewIdentity(_id, "unknown")
 	}
@@ -90,7 +89,7 @@ func (c *Concern) Start() error {
 
 func (c *Concern) fresh() concern.FreshFunc {
 	return c.EmitQueueFresher(func(ctype concern_type.Type, id interface{}) ([]concern.Event, error) {
-		if ctype.ContainAny(Live.Add(Video)) {
+		if ctype.ContainAny(Live.Add(Video, Premiere, Member)) {
 			channelId, ok := id.(string)
 			if !ok {
 				return nil, errors.New("canst fresh id to string failed")
@@ -107,7 +106,8 @@ func (c *Concern) fresh() concern.FreshFunc {
 				}
 				if getErr == nil {
 					if prev.VideoStatus == event.VideoStatus && prev.VideoType == event.VideoType &&
-						prev.VideoTimestamp == event.VideoTimestamp && prev.VideoTitle == event.VideoTitle {
+						prev.VideoTimestamp == event.VideoTimestamp && prev.VideoTitle == event.VideoTitle &&
+						prev.MemberOnly == event.MemberOnly {
 						continue
 					}
 				}
@@ -120,20 +120,20 @@ func (c *Concern) fresh() concern.FreshFunc {
 }
 
 func (c *Concern) notifyGenerator() concern.NotifyGeneratorFunc {
-	return func(groupCode int64, ievent concern.Event) []concern.Notify {
+	return func(target mmsg.TargetId, ievent concern.Event) []concern.Notify {
 		switch event := ievent.(type) {
 		case *VideoInfo:
 			log := event.Logger()
 			if event.IsVideo() {
-				log.WithFields(localutils.GroupLogFields(groupCode)).Debugf("video notify")
+				log.WithFields(mmsg.TargetLogFields(target)).Debugf("video notify")
 			} else if event.IsLive() {
 				if event.IsWaiting() {
-					log.WithFields(localutils.GroupLogFields(groupCode)).Debugf("live waiting notify")
+					log.WithFields(mmsg.TargetLogFields(target)).Debugf("live waiting notify")
 				} else if event.IsLiving() {
-					log.WithFields(localutils.GroupLogFields(groupCode)).Debugf("living notify")
+					log.WithFields(mmsg.TargetLogFields(target)).Debugf("living notify")
 				}
 			}
-			return []concern.Notify{NewConcernNotify(groupCode, event)}
+			return []concern.Notify{NewConcernNotify(target, event)}
 		default:
 			logger.Errorf("unknown EventType %+v", event)
 			return nil
@@ -65,6 +65,7 @@ func XFetchInfo(channelID string) ([]*VideoInfo, error) {
 		requests.HeaderOption("accept-language", "zh-CN"),
 		requests.AddUAOption(),
 		requests.ProxyOption(proxy_pool.PreferOversea),
+		requests.SiteOption(Site),
 		requests.TimeoutOption(time.Second * 10),
 		requests.RetryOption(3),
 	}
@@ -164,6 +165,7 @@ func XFetchInfo(channelID string) ([]*VideoInfo, error) {
 		default:
 			i.VideoStatus = VideoStatus_Upload
 		}
+		i.MemberOnly = isMemberOnly(videoJson)
 		i.ChannelId = channelID
 		i.ChannelName = channelName
 
@@ -185,3 +187,18 @@ func XFetchInfo(channelID string) ([]*VideoInfo, error) {
 	log.WithField("video_count", len(videoInfos)).Tracef("fetch info")
 	return videoInfos, nil
 }
+
+// isMemberOnly 通过视频的徽章判断是否为会员限定
+func isMemberOnly(videoJson *gabs.Container) bool {
+	for _, badge := range videoJson.S("badges").Children() {
+		switch strings.Trim(badge.S("metadataBadgeRenderer", "style").String(), `"`) {
+		case "BADGE_STYLE_TYPE_MEMBERS_ONLY":
+			return true
+		}
+		switch strings.Trim(badge.S("metadataBadgeRenderer", "label").String(), `"`) {
+		case "Members only", "会员专享", "メンバー限定":
+			return true
+		}
+	}
+	return false
+}
@@ -27,3 +27,19 @@ func TestXFetchInfo(t *testing.T) {
 		assert.NotNil(t, vi)
 	}
 }
+
+func TestIsMemberOnly(t *testing.T) {
+	var testCase = []string{
+		`{"videoId":"1"}`,
+		`{"videoId":"2","badges":[{"metadataBadgeRenderer":{"style":"BADGE_STYLE_TYPE_MEMBERS_ONLY","label":"Members only"}}]}`,
+		`{"videoId":"3","badges":[{"metadataBadgeRenderer":{"style":"BADGE_STYLE_TYPE_SIMPLE","label":"会员专享"}}]}`,
+		`{"videoId":"4","badges":[{"metadataBadgeRenderer":{"style":"BADGE_STYLE_TYPE_SIMPLE","label":"New"}}]}`,
+	}
+	var expected = []bool{false, true, true, false}
+	assert.Equal(t, len(testCase), len(expected))
+	for index := range testCase {
+		videoJson, err := gabs.ParseJSON([]byte(testCase[index]))
+		assert.Nil(t, err)
+		assert.Equal(t, expected[index], isMemberOnly(videoJson))
+	}
+}
@@ -25,6 +25,10 @@ func (ui *UserInfo) GetChannelName() string {
 const (
 	Video concern_type.Type = "news"
 	Live  concern_type.Type = "live"
+	// Premiere 首播，首播的直播预约与开始都会使用这个类型推送
+	Premiere concern_type.Type = "premiere"
+	// Member 会员限定直播
+	Member concern_type.Type = "member"
 )
 
 // VideoInfo may be a video or a live, depend on the VideoType
@@ -36,6 +40,7 @@ type VideoInfo struct {
 	VideoType      VideoType   `json:"video_type"`
 	VideoStatus    VideoStatus `json:"video_status"`
 	VideoTimestamp int64       `json:"video_timestamp"`
+	MemberOnly     bool        `json:"member_only"`
 
 	once              sync.Once
 	msgCache          *mmsg.MSG
@@ -75,10 +80,16 @@ func (v *VideoInfo) Logger() *logrus.Entry {
 	})
 }
 
+// Type 会员限定直播为 Member，首播为 Premiere，其他直播为 Live，视频为 Video
 func (v *VideoInfo) Type() concern_type.Type {
-	if v.IsLive() {
+	switch {
+	case v.IsMemberOnly():
+		return Member
+	case v.IsPremiere():
+		return Premiere
+	case v.IsLive():
 		return Live
-	} else {
+	default:
 		return Video
 	}
 }
@@ -104,6 +115,22 @@ func (v *VideoInfo) IsWaiting() bool {
 	return v.IsLive() && v.VideoStatus == VideoStatus_Waiting
 }
 
+// IsPremiere 是否为首播
+func (v *VideoInfo) IsPremiere() bool {
+	if v == nil {
+		return false
+	}
+	return v.VideoType == VideoType_FirstLive
+}
+
+// IsMemberOnly 是否为会员限定直播，会员限定的视频仍然作为 Video 处理
+func (v *VideoInfo) IsMemberOnly() bool {
+	if v == nil {
+		return false
+	}
+	return v.IsLive() && v.MemberOnly
+}
+
 func (v *VideoInfo) IsVideo() bool {
 	if v == nil {
 		return false
@@ -115,11 +142,17 @@ func (v *VideoInfo) GetMSG() *mmsg.MSG {
 	v.once.Do(func() {
 		m := mmsg.NewMSG()
 		if v.IsLive() {
+			var liveName = "直播"
+			if v.IsMemberOnly() {
+				liveName = "会员限定直播"
+			} else if v.IsPremiere() {
+				liveName = "首播"
+			}
 			if v.IsLiving() {
-				m.Textf("YTB-%v正在直播：\n%v\n", v.ChannelName, v.VideoTitle)
+				m.Textf("YTB-%v正在%v：\n%v\n", v.ChannelName, liveName, v.VideoTitle)
 			} else {
-				m.Textf("YTB-%v发布了直播预约：\n%v\n时间：%v\n",
-					v.ChannelName, v.VideoTitle, localutils.TimestampFormat(v.VideoTimestamp))
+				m.Textf("YTB-%v发布了%v预约：\n%v\n时间：%v\n",
+					v.ChannelName, liveName, v.VideoTitle, localutils.TimestampFormat(v.VideoTimestamp))
 			}
 		} else if v.IsVideo() {
 			m.Textf("YTB-%s发布了新视频：\n%v\n", v.ChannelName, v.VideoTitle)
@@ -156,11 +189,11 @@ func NewInfo(vinfo []*VideoInfo) *Info {
 
 type ConcernNotify struct {
 	*VideoInfo
-	GroupCode int64 `json:"group_code"`
+	Target mmsg.TargetId `json:"target"`
 }
 
-func (notify *ConcernNotify) GetGroupCode() int64 {
-	return notify.GroupCode
+func (notify *ConcernNotify) GetTarget() mmsg.TargetId {
+	return notify.Target
 }
 
 func (notify *ConcernNotify) ToMessage() (m *mmsg.MSG) {
@@ -171,16 +204,16 @@ func (notify *ConcernNotify) Logger() *logrus.Entry {
 	if notify == nil {
 		return logger
 	}
-	return notify.VideoInfo.Logger().WithFields(localutils.GroupLogFields(notify.GroupCode))
+	return notify.VideoInfo.Logger().WithFields(mmsg.TargetLogFields(notify.Target))
 }
 
-func NewConcernNotify(groupCode int64, info *VideoInfo) *ConcernNotify {
+func NewConcernNotify(target mmsg.TargetId, info *VideoInfo) *ConcernNotify {
 	if info == nil {
 		return nil
 	}
 	return &ConcernNotify{
 		VideoInfo: info,
-		GroupCode: groupCode,
+		Target:    target,
 	}
 }
 
@@ -2,6 +2,8 @@ package youtube
 
 import (
 	"github.com/Sora233/DDBOT/internal/test"
+	"github.com/Sora233/DDBOT/lsp/mmsg"
+	"github.com/Sora233/DDBOT/utils/msgstringer"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -20,9 +22,9 @@ func TestVideoInfo(t *testing.T) {
 	info := NewInfo([]*VideoInfo{vi})
 	assert.NotNil(t, info)
 
-	notify := NewConcernNotify(test.G1, vi)
+	notify := NewConcernNotify(mmsg.NewGroupTargetId(test.G1), vi)
 	assert.NotNil(t, notify)
-	assert.Equal(t, test.G1, notify.GetGroupCode())
+	assert.Equal(t, mmsg.NewGroupTargetId(test.G1), notify.GetTarget())
 	assert.Equal(t, test.NAME1, notify.GetUid())
 	assert.NotNil(t, notify.Logger())
 	assert.Equal(t, Video, notify.Type())
@@ -41,3 +43,40 @@ func TestVideoInfo(t *testing.T) {
 	assert.NotNil(t, m)
 
 }
+
+func TestVideoInfo_Type(t *testing.T) {
+	vi := &VideoInfo{
+		UserInfo:       *NewUserInfo(test.NAME1, test.NAME2),
+		VideoId:        test.BVID1,
+		VideoType:      VideoType_FirstLive,
+		VideoStatus:    VideoStatus_Waiting,
+		VideoTimestamp: 1700000000,
+	}
+	assert.True(t, vi.IsPremiere())
+	assert.False(t, vi.IsMemberOnly())
+	assert.Equal(t, Premiere, vi.Type())
+	assert.Contains(t, msgstringer.MsgToString(vi.GetMSG().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements), "首播预约")
+
+	vi = &VideoInfo{
+		UserInfo:    *NewUserInfo(test.NAME1, test.NAME2),
+		VideoId:     test.BVID1,
+		VideoType:   VideoType_Live,
+		VideoStatus: VideoStatus_Living,
+		MemberOnly:  true,
+	}
+	assert.True(t, vi.IsMemberOnly())
+	assert.Equal(t, Member, vi.Type())
+	assert.Contains(t, msgstringer.MsgToString(vi.GetMSG().ToCombineMessage(mmsg.NewGroupTarget(test.G1)).Elements), "会员限定直播")
+
+	vi = &VideoInfo{
+		VideoType:  VideoType_Video,
+		MemberOnly: true,
+	}
+	assert.False(t, vi.IsMemberOnly())
+	assert.Equal(t, Video, vi.Type())
+
+	vi = &VideoInfo{
+		VideoType: VideoType_Live,
+	}
+	assert.Equal(t, Live, vi.Type())
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+var (
+	// HttpRequestDuration 访问各个网站接口的耗时，code为0表示请求没有收到响应
+	HttpRequestDuration = NewHistogramVec("ddbot_http_request_duration_seconds",
+		"访问网站接口的耗时", nil, "host", "code")
+
+	// ConcernFreshDuration 每次刷新订阅的耗时
+	ConcernFreshDuration = NewHistogramVec("ddbot_concern_fresh_duration_seconds",
+		"刷新订阅的耗时", nil, "site")
+
+	// ConcernFreshErrors 刷新订阅失败的次数
+	ConcernFreshErrors = NewCounterVec("ddbot_concern_fresh_errors_total",
+		"刷新订阅失败的次数", "site")
+
+	// ConcernLastFresh 最后一次成功刷新订阅的时间戳，长时间没有变化说明刷新卡住了
+	ConcernLastFresh = NewGaugeVec("ddbot_concern_last_fresh_timestamp_seconds",
+		"最后一次成功刷新订阅的时间戳", "site")
+
+	// ConcernEventTotal 订阅模块产生的事件数量，topic为 live_start live_stop live_title_change new_dynamic
+	ConcernEventTotal = NewCounterVec("ddbot_concern_events_total",
+		"订阅模块产生的事件数量", "site", "topic")
+
+	// PushTotal 推送发送的结果，target为推送目标，QQ群为群号码，其他目标为 类型_号码 ，result为 success fail retry
+	PushTotal = NewCounterVec("ddbot_push_total",
+		"推送发送的次数", "target", "result")
+)
+
+const (
+	PushResultSuccess = "success"
+	PushResultFail    = "fail"
+	PushResultRetry   = "retry"
+)
+
+// ObserveHttpRequest 记录一次网站接口请求
+func ObserveHttpRequest(host string, code int, start time.Time) {
+	HttpRequestDuration.Observe(time.Since(start).Seconds(), host, strconv.Itoa(code))
+}
+
+// ObserveFresh 记录一次订阅刷新，err为nil时同时更新最后一次成功刷新的时间
+func ObserveFresh(site string, start time.Time, err error) {
+	ConcernFreshDuration.Observe(time.Since(start).Seconds(), site)
+	if err != nil {
+		ConcernFreshErrors.Inc(site)
+		return
+	}
+	ConcernLastFresh.Set(float64(time.Now().Unix()), site)
+}
+
+// ObserveConcernEvent 记录一个订阅模块产生的事件
+func ObserveConcernEvent(site string, topic string) {
+	ConcernEventTotal.Inc(site, topic)
+}
+
+// ObservePush 记录一次推送的发送结果
+func ObservePush(target string, result string) {
+	PushTotal.Inc(target, result)
+}
@@ -0,0 +1,367 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 一个简单的指标实现，输出格式兼容 Prometheus text exposition format 0.0.4 ，
+// 只实现了DDBOT需要的 counter gauge histogram 三种类型
+
+// DefaultBuckets 默认的histogram分桶，单位为秒
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry 保存所有注册的指标
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建一个空的 Registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// DefaultRegistry 默认的 Registry ，这个包中的 New* 函数都会注册到这里
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.collectors[c.name()]; found {
+		panic(fmt.Sprintf("metrics: %v already registered", c.name()))
+	}
+	r.collectors[c.name()] = c
+}
+
+// Write 按名字顺序输出所有指标
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	var collectors []collector
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.RUnlock()
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	bw.Flush()
+}
+
+// Handler 返回输出所有指标的 http.Handler
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Handler 返回输出 DefaultRegistry 中所有指标的 http.Handler
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+type desc struct {
+	metricName string
+	help       string
+	typ        string
+	labels     []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %v %v\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %v %v\n", d.metricName, d.typ)
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %v expect %v label values, got %v", d.metricName, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+func (d *desc) labelPairs(labelValues []string, extra ...string) string {
+	var pairs []string
+	for idx, l := range d.labels {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, l, escapeLabel(labelValues[idx])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type value struct {
+	labelValues []string
+	v           float64
+}
+
+// valueVec 是 CounterVec 与 GaugeVec 共用的实现
+type valueVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*value
+}
+
+func (v *valueVec) add(delta float64, labelValues []string) {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	val, found := v.values[key]
+	if !found {
+		val = &value{labelValues: append([]string(nil), labelValues...)}
+		v.values[key] = val
+	}
+	val.v += delta
+}
+
+func (v *valueVec) set(x float64, labelValues []string) {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	val, found := v.values[key]
+	if !found {
+		val = &value{labelValues: append([]string(nil), labelValues...)}
+		v.values[key] = val
+	}
+	val.v = x
+}
+
+func (v *valueVec) get(labelValues []string) float64 {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if val, found := v.values[key]; found {
+		return val.v
+	}
+	return 0
+}
+
+func (v *valueVec) write(w io.Writer) {
+	v.writeHeader(w)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range sortedKeys(v.values) {
+		val := v.values[key]
+		fmt.Fprintf(w, "%v%v %v\n", v.metricName, v.labelPairs(val.labelValues), formatFloat(val.v))
+	}
+}
+
+// CounterVec 只增不减的计数器
+type CounterVec struct {
+	valueVec
+}
+
+// Inc 计数器加1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+// Add 计数器增加delta，delta不能为负数
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %v can not decrease", c.metricName))
+	}
+	c.add(delta, labelValues)
+}
+
+// Get 返回当前的值
+func (c *CounterVec) Get(labelValues ...string) float64 {
+	return c.get(labelValues)
+}
+
+// NewCounterVec 创建并注册一个 CounterVec
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{valueVec{
+		desc:   desc{metricName: name, help: help, typ: "counter", labels: labels},
+		values: make(map[string]*value),
+	}}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// GaugeVec 可以任意设置的值
+type GaugeVec struct {
+	valueVec
+}
+
+// Set 设置当前的值
+func (g *GaugeVec) Set(x float64, labelValues ...string) {
+	g.set(x, labelValues)
+}
+
+// Add 当前值增加delta，delta可以为负数
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.add(delta, labelValues)
+}
+
+// Get 返回当前的值
+func (g *GaugeVec) Get(labelValues ...string) float64 {
+	return g.get(labelValues)
+}
+
+// NewGaugeVec 创建并注册一个 GaugeVec
+func NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{valueVec{
+		desc:   desc{metricName: name, help: help, typ: "gauge", labels: labels},
+		values: make(map[string]*value),
+	}}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// GaugeFunc 在输出时调用函数获取值的gauge
+type GaugeFunc struct {
+	desc
+	f func() float64
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%v %v\n", g.metricName, formatFloat(g.f()))
+}
+
+// NewGaugeFunc 创建并注册一个 GaugeFunc
+func NewGaugeFunc(name string, help string, f func() float64) *GaugeFunc {
+	g := &GaugeFunc{
+		desc: desc{metricName: name, help: help, typ: "gauge"},
+		f:    f,
+	}
+	DefaultRegistry.register(g)
+	return g
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// HistogramVec 分桶统计，例如请求耗时
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(x float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	val, found := h.values[key]
+	if !found {
+		val = &histogramValue{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = val
+	}
+	for idx, upper := range h.buckets {
+		if x <= upper {
+			val.counts[idx]++
+		}
+	}
+	val.count++
+	val.sum += x
+}
+
+// Count 返回观测的次数
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if val, found := h.values[key]; found {
+		return val.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		val := h.values[key]
+		for idx, upper := range h.buckets {
+			fmt.Fprintf(w, "%v_bucket%v %v\n", h.metricName,
+				h.labelPairs(val.labelValues, "le", formatFloat(upper)), val.counts[idx])
+		}
+		fmt.Fprintf(w, "%v_bucket%v %v\n", h.metricName, h.labelPairs(val.labelValues, "le", "+Inf"), val.count)
+		fmt.Fprintf(w, "%v_sum%v %v\n", h.metricName, h.labelPairs(val.labelValues), formatFloat(val.sum))
+		fmt.Fprintf(w, "%v_count%v %v\n", h.metricName, h.labelPairs(val.labelValues), val.count)
+	}
+}
+
+// NewHistogramVec 创建并注册一个 HistogramVec ，buckets为空时使用 DefaultBuckets
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		desc:    desc{metricName: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
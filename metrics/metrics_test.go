@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func output() string {
+	var buf bytes.Buffer
+	DefaultRegistry.Write(&buf)
+	return buf.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_counter_total", "测试\ncounter", "a", "b")
+	c.Inc("1", `"2"`)
+	c.Add(2, "1", `"2"`)
+	c.Inc("3", "4")
+	assert.EqualValues(t, 3, c.Get("1", `"2"`))
+	assert.EqualValues(t, 0, c.Get("5", "6"))
+	assert.Panics(t, func() {
+		c.Add(-1, "1", "2")
+	})
+	assert.Panics(t, func() {
+		c.Inc("1")
+	})
+	assert.Panics(t, func() {
+		NewCounterVec("test_counter_total", "")
+	})
+
+	out := output()
+	assert.Contains(t, out, "# HELP test_counter_total 测试\\ncounter\n")
+	assert.Contains(t, out, "# TYPE test_counter_total counter\n")
+	assert.Contains(t, out, `test_counter_total{a="1",b="\"2\""} 3`+"\n")
+	assert.Contains(t, out, `test_counter_total{a="3",b="4"} 1`+"\n")
+}
+
+func TestGaugeVec(t *testing.T) {
+	g := NewGaugeVec("test_gauge", "gauge", "a")
+	g.Set(10, "x")
+	g.Add(-2.5, "x")
+	assert.EqualValues(t, 7.5, g.Get("x"))
+
+	NewGaugeFunc("test_gauge_func", "gauge func", func() float64 {
+		return 42
+	})
+
+	out := output()
+	assert.Contains(t, out, `test_gauge{a="x"} 7.5`+"\n")
+	assert.Contains(t, out, "test_gauge_func 42\n")
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_histogram_seconds", "histogram", []float64{1, 0.1}, "a")
+	h.Observe(0.05, "x")
+	h.Observe(0.5, "x")
+	h.Observe(5, "x")
+	assert.EqualValues(t, 3, h.Count("x"))
+	assert.EqualValues(t, 0, h.Count("y"))
+
+	out := output()
+	assert.Contains(t, out, "# TYPE test_histogram_seconds histogram\n")
+	assert.Contains(t, out, `test_histogram_seconds_bucket{a="x",le="0.1"} 1`+"\n")
+	assert.Contains(t, out, `test_histogram_seconds_bucket{a="x",le="1"} 2`+"\n")
+	assert.Contains(t, out, `test_histogram_seconds_bucket{a="x",le="+Inf"} 3`+"\n")
+	assert.Contains(t, out, `test_histogram_seconds_sum{a="x"} 5.55`+"\n")
+	assert.Contains(t, out, `test_histogram_seconds_count{a="x"} 3`+"\n")
+	// 按名字排序输出
+	assert.Less(t, strings.Index(out, "test_counter_total"), strings.Index(out, "test_histogram_seconds"))
+}
+
+func TestObserve(t *testing.T) {
+	start := time.Now()
+	ObserveHttpRequest("api.bilibili.com", 200, start)
+	assert.EqualValues(t, 1, HttpRequestDuration.Count("api.bilibili.com", "200"))
+
+	ObserveFresh("test-site", start, nil)
+	ObserveFresh("test-site", start, errors.New("error"))
+	assert.EqualValues(t, 2, ConcernFreshDuration.Count("test-site"))
+	assert.EqualValues(t, 1, ConcernFreshErrors.Get("test-site"))
+	assert.NotZero(t, ConcernLastFresh.Get("test-site"))
+
+	ObservePush("123", PushResultSuccess)
+	assert.EqualValues(t, 1, PushTotal.Get("123", PushResultSuccess))
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.EqualValues(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), "# TYPE ddbot_push_total counter")
+}
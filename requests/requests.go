@@ -2,6 +2,7 @@ package requests
 
 import (
 	"fmt"
+	"github.com/Sora233/DDBOT/metrics"
 	"github.com/Sora233/DDBOT/proxy_pool"
 	"github.com/Sora233/MiraiGo-Template/utils"
 	"github.com/guonaihong/gout"
@@ -32,6 +33,10 @@ type option struct {
 	ResponseMiddleware  []middler.ResponseMiddler
 	AutoHeaderHost      bool
 	NotIgnoreEmpty      bool
+	// Site 使用 SiteConfig 中的配置
+	Site string
+
+	siteProxy *siteProxy
 }
 
 func (o *option) getGout() *gout.Client {
@@ -195,19 +200,19 @@ func Do(f func(*gout.Client) *dataflow.DataFlow, out interface{}, options ...Opt
 	for _, o := range options {
 		o(opt)
 	}
+	opt.applySite()
 	if opt.ProxyCallbackOption != nil && len(opt.Proxy) > 0 {
 		defer func() {
 			opt.ProxyCallbackOption(out, opt.Proxy)
 		}()
 	}
 	var df = f(opt.getGout())
+	var host, _ = df.GetHost()
 	if opt.Debug {
 		df.Debug(true)
 	}
-	if opt.AutoHeaderHost {
-		if h, err := df.GetHost(); err == nil {
-			opt.Header["host"] = h
-		}
+	if opt.AutoHeaderHost && len(host) > 0 {
+		opt.Header["host"] = host
 	}
 	if len(opt.Cookies) > 0 {
 		df.SetCookies(opt.Cookies...)
@@ -232,11 +237,16 @@ func Do(f func(*gout.Client) *dataflow.DataFlow, out interface{}, options ...Opt
 	default:
 		df.BindJSON(out)
 	}
+	var start = time.Now()
 	if opt.Retry > 0 {
 		err = df.F().Retry().Attempt(opt.Retry).Do()
 	} else {
 		err = df.Do()
 	}
+	metrics.ObserveHttpRequest(host, code, start)
+	if opt.siteProxy != nil {
+		opt.siteProxy.report(err == nil && code < http.StatusInternalServerError)
+	}
 	if opt.HttpCode != nil {
 		*opt.HttpCode = code
 	}
@@ -244,11 +254,20 @@ func Do(f func(*gout.Client) *dataflow.DataFlow, out interface{}, options ...Opt
 		return err
 	}
 	if code >= http.StatusBadRequest {
-		return fmt.Errorf("http code error %v", code)
+		return &HttpCodeError{Code: code}
 	}
 	return nil
 }
 
+// HttpCodeError 响应的http code大于等于400时返回
+type HttpCodeError struct {
+	Code int
+}
+
+func (e *HttpCodeError) Error() string {
+	return fmt.Sprintf("http code error %v", e.Code)
+}
+
 func Get(url string, params interface{}, out interface{}, options ...Option) error {
 	return Do(func(gcli *gout.Client) *dataflow.DataFlow {
 		return gcli.GET(url).SetQuery(params)
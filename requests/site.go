@@ -0,0 +1,254 @@
+package requests
+
+import (
+	"errors"
+	"github.com/guonaihong/gout"
+	"github.com/guonaihong/gout/dataflow"
+	"go.uber.org/atomic"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 代理连续失败这么多次后暂时不再使用
+	proxyMaxFail = 3
+	// 不可用的代理在这段时间之后重新尝试
+	proxyDownDuration = time.Minute * 5
+)
+
+// SiteConfig 单个网站的http请求配置，未设置的字段使用请求本身的参数
+type SiteConfig struct {
+	// Proxies 代理列表，每次请求轮流使用，连续失败的代理会暂时跳过
+	Proxies []string          `json:"proxies,omitempty" yaml:"proxies"`
+	Timeout time.Duration     `json:"timeout,omitempty" yaml:"timeout"`
+	Retry   int               `json:"retry,omitempty" yaml:"retry"`
+	UA      string            `json:"ua,omitempty" yaml:"ua"`
+	Header  map[string]string `json:"header,omitempty" yaml:"header"`
+}
+
+// Empty 没有任何配置时返回true
+func (s *SiteConfig) Empty() bool {
+	return s == nil || (len(s.Proxies) == 0 && s.Timeout == 0 && s.Retry == 0 && len(s.UA) == 0 && len(s.Header) == 0)
+}
+
+// ProxyStatus 代理的健康状态
+type ProxyStatus struct {
+	Proxy string
+	// Fail 连续失败的次数
+	Fail int32
+	// DownUntil 不为零时表示代理暂时不可用，到这个时间后重新尝试
+	DownUntil time.Time
+}
+
+type siteProxy struct {
+	proxy     string
+	fail      atomic.Int32
+	downUntil atomic.Int64
+}
+
+func (p *siteProxy) available(now time.Time) bool {
+	return p.downUntil.Load() <= now.UnixNano()
+}
+
+// report 记录一次请求的结果，连续失败 proxyMaxFail 次后暂停使用 proxyDownDuration
+func (p *siteProxy) report(success bool) {
+	if success {
+		p.fail.Store(0)
+		p.downUntil.Store(0)
+		return
+	}
+	if p.fail.Add(1) >= proxyMaxFail {
+		p.downUntil.Store(time.Now().Add(proxyDownDuration).UnixNano())
+		logger.WithField("proxy", p.proxy).Warnf("代理连续失败%v次，%v内不再使用", proxyMaxFail, proxyDownDuration)
+	}
+}
+
+func (p *siteProxy) status() *ProxyStatus {
+	var s = &ProxyStatus{
+		Proxy: p.proxy,
+		Fail:  p.fail.Load(),
+	}
+	if d := p.downUntil.Load(); d > time.Now().UnixNano() {
+		s.DownUntil = time.Unix(0, d)
+	}
+	return s
+}
+
+type siteClient struct {
+	config  *SiteConfig
+	proxies []*siteProxy
+	index   atomic.Uint32
+}
+
+// nextProxy 轮流选择一个可用的代理，所有代理都不可用时仍然按顺序选择，没有配置代理时返回nil
+func (s *siteClient) nextProxy() *siteProxy {
+	if len(s.proxies) == 0 {
+		return nil
+	}
+	now := time.Now()
+	start := s.index.Add(1)
+	for i := 0; i < len(s.proxies); i++ {
+		p := s.proxies[(int(start)+i)%len(s.proxies)]
+		if p.available(now) {
+			return p
+		}
+	}
+	return s.proxies[int(start)%len(s.proxies)]
+}
+
+var (
+	siteMutex   sync.RWMutex
+	siteClients = make(map[string]*siteClient)
+)
+
+// SetSiteConfig 设置网站的http请求配置，立即对之后的请求生效，config为空时删除配置
+func SetSiteConfig(site string, config *SiteConfig) {
+	siteMutex.Lock()
+	defer siteMutex.Unlock()
+	if config.Empty() {
+		delete(siteClients, site)
+		return
+	}
+	var client = &siteClient{config: config}
+	var old = siteClients[site]
+	for _, proxy := range config.Proxies {
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+		var p *siteProxy
+		if old != nil {
+			// 保留原来代理的健康状态
+			for _, oldProxy := range old.proxies {
+				if oldProxy.proxy == proxy {
+					p = oldProxy
+					break
+				}
+			}
+		}
+		if p == nil {
+			p = &siteProxy{proxy: proxy}
+		}
+		client.proxies = append(client.proxies, p)
+	}
+	siteClients[site] = client
+}
+
+// GetSiteConfig 返回网站的http请求配置，没有配置时返回nil
+func GetSiteConfig(site string) *SiteConfig {
+	siteMutex.RLock()
+	defer siteMutex.RUnlock()
+	if client, found := siteClients[site]; found {
+		return client.config
+	}
+	return nil
+}
+
+// ListSiteConfig 返回所有配置过的网站，按名字排序
+func ListSiteConfig() []string {
+	siteMutex.RLock()
+	defer siteMutex.RUnlock()
+	var result []string
+	for site := range siteClients {
+		result = append(result, site)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetSiteProxyStatus 返回网站每个代理的健康状态
+func GetSiteProxyStatus(site string) []*ProxyStatus {
+	siteMutex.RLock()
+	defer siteMutex.RUnlock()
+	client, found := siteClients[site]
+	if !found {
+		return nil
+	}
+	var result []*ProxyStatus
+	for _, p := range client.proxies {
+		result = append(result, p.status())
+	}
+	return result
+}
+
+func getSiteClient(site string) *siteClient {
+	siteMutex.RLock()
+	defer siteMutex.RUnlock()
+	return siteClients[site]
+}
+
+// SiteOption 使用网站的http请求配置，配置中的代理、超时、重试、UA和header会覆盖请求本身的参数，
+// 与其他 Option 的顺序无关，网站没有配置时不影响请求
+func SiteOption(site string) Option {
+	return func(o *option) {
+		o.Site = site
+	}
+}
+
+// applySite 在所有 Option 生效之后应用网站配置
+func (o *option) applySite() {
+	if len(o.Site) == 0 {
+		return
+	}
+	client := getSiteClient(o.Site)
+	if client == nil {
+		return
+	}
+	config := client.config
+	if config.Timeout > 0 {
+		o.Timeout = config.Timeout
+	}
+	if config.Retry > 0 {
+		o.Retry = config.Retry
+	}
+	if len(config.UA) > 0 {
+		o.setHeader("user-agent", config.UA)
+	}
+	for k, v := range config.Header {
+		o.setHeader(k, v)
+	}
+	if p := client.nextProxy(); p != nil {
+		o.Proxy = p.proxy
+		o.siteProxy = p
+	}
+}
+
+// setHeader 设置header并删除大小写不同的同名header，保证网站配置覆盖请求本身的参数
+func (o *option) setHeader(key, value string) {
+	for k := range o.Header {
+		if strings.EqualFold(k, key) {
+			delete(o.Header, k)
+		}
+	}
+	HeaderOption(key, value)(o)
+}
+
+// CheckSiteProxy 通过网站的每个代理请求一次url，检查代理是否可用，并更新代理的健康状态，
+// 返回每个代理的检查结果，可用的代理对应的error为nil
+func CheckSiteProxy(site string, url string) (map[string]error, error) {
+	client := getSiteClient(site)
+	if client == nil || len(client.proxies) == 0 {
+		return nil, errors.New("该网站没有配置代理")
+	}
+	var result = make(map[string]error)
+	for _, p := range client.proxies {
+		var opts = []Option{
+			RawProxyOption(p.proxy),
+			TimeoutOption(time.Second * 10),
+			AddUAOption(),
+		}
+		var code int
+		err := Do(func(gcli *gout.Client) *dataflow.DataFlow {
+			return gcli.GET(url)
+		}, new([]byte), append(opts, HttpCodeOption(&code))...)
+		var httpCodeError *HttpCodeError
+		if errors.As(err, &httpCodeError) && code < 500 {
+			// 能拿到响应说明代理是可用的
+			err = nil
+		}
+		p.report(err == nil)
+		result[p.proxy] = err
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package requests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSiteOption(t *testing.T) {
+	const site = "test-site"
+	var proxyHit int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 作为http代理收到的是完整的url
+		if r.URL.Path == "/path" {
+			proxyHit++
+			assert.Equal(t, "site-ua", r.Header.Get("User-Agent"))
+			assert.Equal(t, "v", r.Header.Get("X-Test"))
+		}
+		w.Write([]byte(`{"url":"` + r.URL.String() + `"}`))
+	}))
+	defer proxy.Close()
+	deadProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadProxy.Close()
+
+	// 没有配置时不影响请求
+	assert.Nil(t, GetSiteConfig(site))
+	SetSiteConfig(site, &SiteConfig{})
+	assert.Nil(t, GetSiteConfig(site))
+
+	SetSiteConfig(site, &SiteConfig{
+		Proxies: []string{deadProxy.URL, strings.TrimPrefix(proxy.URL, "http://")},
+		Timeout: time.Second,
+		UA:      "site-ua",
+		Header:  map[string]string{"X-Test": "v"},
+	})
+	defer SetSiteConfig(site, nil)
+	assert.Contains(t, ListSiteConfig(), site)
+	assert.Len(t, GetSiteProxyStatus(site), 2)
+
+	var success int
+	for i := 0; i < 10; i++ {
+		var out struct {
+			Url string `json:"url"`
+		}
+		err := Get("http://ddbot.invalid/path", nil, &out,
+			SiteOption(site), AddUAOption(), HeaderOption("User-Agent", "other"), TimeoutOption(time.Minute))
+		if err == nil {
+			success++
+			assert.Equal(t, "http://ddbot.invalid/path", out.Url)
+		}
+	}
+	// 失败的代理连续失败后不再使用
+	assert.Equal(t, 10-proxyMaxFail, success)
+	assert.Equal(t, success, proxyHit)
+	for _, status := range GetSiteProxyStatus(site) {
+		if status.Proxy == deadProxy.URL {
+			assert.EqualValues(t, proxyMaxFail, status.Fail)
+			assert.False(t, status.DownUntil.IsZero())
+		} else {
+			assert.EqualValues(t, 0, status.Fail)
+			assert.True(t, status.DownUntil.IsZero())
+		}
+	}
+
+	result, err := CheckSiteProxy(site, "http://ddbot.invalid/")
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.NotNil(t, result[deadProxy.URL])
+	assert.Nil(t, result[proxy.URL])
+
+	// 重新设置时保留代理的健康状态
+	SetSiteConfig(site, &SiteConfig{Proxies: []string{deadProxy.URL}})
+	assert.False(t, GetSiteProxyStatus(site)[0].DownUntil.IsZero())
+
+	_, err = CheckSiteProxy("unknown-site", "http://ddbot.invalid/")
+	assert.NotNil(t, err)
+}
@@ -22,6 +22,8 @@ func NewEmitE(id interface{}, t concern_type.Type) *EmitE {
 
 type EmitQueue struct {
 	TimeInterval time.Duration
+	// Jitter 每次发射间隔的随机抖动比例，为0时不抖动
+	Jitter float64
 
 	stopped   atomic.Bool
 	stop      chan interface{}
@@ -98,6 +100,29 @@ func (q *EmitQueue) Delete(id interface{}) {
 	}
 }
 
+// Next 把id移动到队列中下一个发射的位置，不存在时返回false
+func (q *EmitQueue) Next(id interface{}) bool {
+	if id == nil {
+		return false
+	}
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for head := q.eqlist.Front(); head != nil; head = head.Next() {
+		if head.Value.(*EmitE).Id != id {
+			continue
+		}
+		if q.eqlistCur == nil {
+			q.eqlist.MoveToFront(head)
+		} else if q.eqlistCur != head {
+			q.eqlist.MoveBefore(head, q.eqlistCur)
+		}
+		q.eqlistCur = head
+		return true
+	}
+	return false
+}
+
 func (q *EmitQueue) core() {
 	q.wg.Add(1)
 	defer q.wg.Done()
@@ -130,7 +155,7 @@ func (q *EmitQueue) core() {
 		} else {
 			q.cond.L.Unlock()
 		}
-		q.waitTimer.Reset(q.TimeInterval)
+		q.waitTimer.Reset(Jitter(q.TimeInterval, q.Jitter))
 	}
 }
 
@@ -104,3 +104,31 @@ LOOP:
 		}
 	}
 }
+
+func TestEmitQueue_Next(t *testing.T) {
+	c := make(chan *EmitE)
+	eq := NewEmitQueue(c, time.Millisecond*50)
+
+	assert.False(t, eq.Next(nil))
+	assert.False(t, eq.Next(1))
+
+	eq.Add(NewEmitE(1, test.DouyuLive))
+	eq.Add(NewEmitE(2, test.DouyuLive))
+	eq.Add(NewEmitE(3, test.DouyuLive))
+
+	assert.True(t, eq.Next(3))
+	assert.False(t, eq.Next(4))
+
+	eq.Start()
+	defer eq.Stop()
+
+	var expected = []interface{}{3, 1, 2, 3}
+	for _, id := range expected {
+		select {
+		case item := <-c:
+			assert.EqualValues(t, id, item.Id)
+		case <-time.After(time.Second * 5):
+			assert.Fail(t, "no item received")
+		}
+	}
+}
@@ -5,11 +5,33 @@ import (
 	miraiBot "github.com/Sora233/MiraiGo-Template/bot"
 )
 
+// BotAdapter 代替MiraiGo提供bot的基本信息，例如通过OneBot协议连接的go-cqhttp
+type BotAdapter interface {
+	GetUin() int64
+	IsOnline() bool
+	FindFriend(uin int64) *client.FriendInfo
+	FindGroup(code int64) *client.GroupInfo
+	GetGroupList() []*client.GroupInfo
+	GetFriendList() []*client.FriendInfo
+}
+
 // HackedBot 拦截一些方法方便测试
 type HackedBot struct {
-	Bot        **miraiBot.Bot
-	testGroups []*client.GroupInfo
-	testUin    int64
+	Bot         **miraiBot.Bot
+	adapter     BotAdapter
+	testGroups  []*client.GroupInfo
+	testFriends []*client.FriendInfo
+	testUin     int64
+}
+
+// SetAdapter 设置后bot的基本信息都从adapter获取，不再使用MiraiGo
+func (h *HackedBot) SetAdapter(adapter BotAdapter) {
+	h.adapter = adapter
+}
+
+// Adapter 返回当前使用的adapter，使用MiraiGo时返回nil
+func (h *HackedBot) Adapter() BotAdapter {
+	return h.adapter
 }
 
 func (h *HackedBot) valid() bool {
@@ -20,13 +42,24 @@ func (h *HackedBot) valid() bool {
 }
 
 func (h *HackedBot) FindFriend(uin int64) *client.FriendInfo {
+	if h.adapter != nil {
+		return h.adapter.FindFriend(uin)
+	}
 	if !h.valid() {
+		for _, fi := range h.testFriends {
+			if fi.Uin == uin {
+				return fi
+			}
+		}
 		return nil
 	}
 	return (*h.Bot).FindFriend(uin)
 }
 
 func (h *HackedBot) FindGroup(code int64) *client.GroupInfo {
+	if h.adapter != nil {
+		return h.adapter.FindGroup(code)
+	}
 	if !h.valid() {
 		for _, gi := range h.testGroups {
 			if gi.Code == code {
@@ -53,6 +86,9 @@ func (h *HackedBot) SolveGroupJoinRequest(i interface{}, accept, block bool, rea
 }
 
 func (h *HackedBot) GetGroupList() []*client.GroupInfo {
+	if h.adapter != nil {
+		return h.adapter.GetGroupList()
+	}
 	if !h.valid() {
 		return h.testGroups
 	}
@@ -60,17 +96,26 @@ func (h *HackedBot) GetGroupList() []*client.GroupInfo {
 }
 
 func (h *HackedBot) GetFriendList() []*client.FriendInfo {
+	if h.adapter != nil {
+		return h.adapter.GetFriendList()
+	}
 	if !h.valid() {
-		return nil
+		return h.testFriends
 	}
 	return (*h.Bot).FriendList
 }
 
 func (h *HackedBot) IsOnline() bool {
+	if h.adapter != nil {
+		return h.adapter.IsOnline()
+	}
 	return h.valid()
 }
 
 func (h *HackedBot) GetUin() int64 {
+	if h.adapter != nil {
+		return h.adapter.GetUin()
+	}
 	if !h.valid() {
 		return h.testUin
 	}
@@ -101,6 +146,18 @@ func (h *HackedBot) TESTAddGroup(groupCode int64) {
 	})
 }
 
+// TESTAddFriend 仅可用于测试
+func (h *HackedBot) TESTAddFriend(uin int64) {
+	for _, f := range h.testFriends {
+		if f.Uin == uin {
+			return
+		}
+	}
+	h.testFriends = append(h.testFriends, &client.FriendInfo{
+		Uin: uin,
+	})
+}
+
 // TESTAddMember 仅可用于测试
 func (h *HackedBot) TESTAddMember(groupCode int64, uin int64, permission client.MemberPermission) {
 	h.TESTAddGroup(groupCode)
@@ -124,5 +181,6 @@ func (h *HackedBot) TESTAddMember(groupCode int64, uin int64, permission client.
 // TESTReset 仅可用于测试
 func (h *HackedBot) TESTReset() {
 	h.testGroups = nil
+	h.testFriends = nil
 	h.testUin = 0
 }
@@ -29,8 +29,14 @@ func TestGetBot(t *testing.T) {
 	bot.TESTAddMember(test.G1, test.UID1, client.Administrator)
 	bot.TESTAddMember(test.G2, test.UID2, client.Administrator)
 	assert.Len(t, bot.GetGroupList(), 3)
+	bot.TESTAddFriend(test.UID1)
+	bot.TESTAddFriend(test.UID1)
+	assert.Len(t, bot.GetFriendList(), 1)
+	assert.NotNil(t, bot.FindFriend(test.UID1))
+	assert.Nil(t, bot.FindFriend(test.UID2))
 	bot.TESTReset()
 	assert.Empty(t, bot.GetGroupList())
+	assert.Empty(t, bot.GetFriendList())
 
 	test.InitMirai()
 	defer test.CloseMirai()
@@ -39,3 +45,44 @@ func TestGetBot(t *testing.T) {
 	(*hackedBot.Bot).Online.Store(true)
 	assert.True(t, hackedBot.IsOnline())
 }
+
+type testAdapter struct {
+	groups []*client.GroupInfo
+}
+
+func (a *testAdapter) GetUin() int64                           { return test.UID2 }
+func (a *testAdapter) IsOnline() bool                          { return true }
+func (a *testAdapter) FindFriend(uin int64) *client.FriendInfo { return nil }
+func (a *testAdapter) FindGroup(code int64) *client.GroupInfo {
+	for _, g := range a.groups {
+		if g.Code == code {
+			return g
+		}
+	}
+	return nil
+}
+func (a *testAdapter) GetGroupList() []*client.GroupInfo   { return a.groups }
+func (a *testAdapter) GetFriendList() []*client.FriendInfo { return nil }
+
+func TestHackedBot_SetAdapter(t *testing.T) {
+	defer GetBot().TESTReset()
+	defer GetBot().SetAdapter(nil)
+
+	bot := GetBot()
+	bot.TESTSetUin(test.UID1)
+	assert.Nil(t, bot.Adapter())
+	assert.EqualValues(t, test.UID1, bot.GetUin())
+
+	bot.SetAdapter(&testAdapter{groups: []*client.GroupInfo{{Code: test.G1}}})
+	assert.NotNil(t, bot.Adapter())
+	assert.EqualValues(t, test.UID2, bot.GetUin())
+	assert.True(t, bot.IsOnline())
+	assert.NotNil(t, bot.FindGroup(test.G1))
+	assert.Nil(t, bot.FindGroup(test.G2))
+	assert.Len(t, bot.GetGroupList(), 1)
+
+	img, err := UploadGroupImage(test.G1, []byte("img"), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "base64://aW1n", img.Url)
+	assert.NotNil(t, UploadPrivateFile(test.UID1, "a.txt", nil))
+}
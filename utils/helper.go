@@ -6,6 +6,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
 	"io/fs"
+	"math/rand"
 	"net/url"
 	"path/filepath"
 	"reflect"
@@ -191,6 +192,17 @@ func Retry(count int, interval time.Duration, f func() bool) bool {
 	return false
 }
 
+// Jitter 在d的基础上增加±ratio比例的随机抖动，ratio小于等于0时原样返回，大于1时按1处理
+func Jitter(d time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 || d <= 0 {
+		return d
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return time.Duration(float64(d) * (1 + ratio*(rand.Float64()*2-1)))
+}
+
 func ArgSplit(str string) (result []string) {
 	r := regexp.MustCompile(`[^\s"]+|"([^"]*)"`)
 	match := r.FindAllString(str, -1)
@@ -65,6 +65,17 @@ func TestJoinInt64(t *testing.T) {
 	assert.EqualValues(t, "1", result)
 }
 
+func TestJitter(t *testing.T) {
+	assert.EqualValues(t, time.Second, Jitter(time.Second, 0))
+	assert.EqualValues(t, 0, Jitter(0, 0.5))
+	for i := 0; i < 100; i++ {
+		d := Jitter(time.Second, 0.2)
+		assert.True(t, d >= time.Millisecond*800 && d <= time.Millisecond*1200, d)
+		d = Jitter(time.Second, 2)
+		assert.True(t, d >= 0 && d <= time.Second*2, d)
+	}
+}
+
 func TestRetry(t *testing.T) {
 	var i = 0
 	Retry(10, time.Millisecond*50, func() bool {
@@ -2,10 +2,13 @@ package utils
 
 import (
 	"bytes"
+	"encoding/base64"
 	"errors"
+	"github.com/Mrs4s/MiraiGo/client"
 	"github.com/Mrs4s/MiraiGo/message"
 	"github.com/Sora233/MiraiGo-Template/bot"
 	"github.com/samber/lo"
+	"io"
 )
 
 func MessageFilter(msg []message.IMessageElement, filter func(message.IMessageElement) bool) []message.IMessageElement {
@@ -32,6 +35,9 @@ func UploadGroupImage(groupCode int64, img []byte, isNorm bool) (image *message.
 	if !GetBot().IsOnline() {
 		return nil, errors.New("bot offline")
 	}
+	if GetBot().Adapter() != nil {
+		return &message.GroupImageElement{Url: base64ImageUrl(img)}, nil
+	}
 	e, err := bot.Instance.UploadImage(message.Source{SourceType: message.SourceGroup, PrimaryID: groupCode}, bytes.NewReader(img))
 	if err != nil {
 		return nil, err
@@ -50,6 +56,9 @@ func UploadPrivateImage(uin int64, img []byte, isNorm bool) (*message.FriendImag
 	if !GetBot().IsOnline() {
 		return nil, errors.New("bot offline")
 	}
+	if GetBot().Adapter() != nil {
+		return &message.FriendImageElement{Url: base64ImageUrl(img)}, nil
+	}
 	e, err := bot.Instance.UploadImage(message.Source{SourceType: message.SourcePrivate, PrimaryID: uin}, bytes.NewReader(img))
 	if err != nil {
 		return nil, err
@@ -57,6 +66,25 @@ func UploadPrivateImage(uin int64, img []byte, isNorm bool) (*message.FriendImag
 	return e.(*message.FriendImageElement), nil
 }
 
+// UploadPrivateFile 通过私聊发送文件
+func UploadPrivateFile(uin int64, name string, body io.ReadSeeker) error {
+	if !GetBot().IsOnline() {
+		return errors.New("bot offline")
+	}
+	if GetBot().Adapter() != nil {
+		return errors.New("当前协议不支持发送文件")
+	}
+	return bot.Instance.UploadFile(message.Source{SourceType: message.SourcePrivate, PrimaryID: uin}, &client.LocalFile{
+		FileName: name,
+		Body:     body,
+	})
+}
+
+// base64ImageUrl 使用adapter时图片不需要上传，直接以base64的形式放在消息中发送
+func base64ImageUrl(img []byte) string {
+	return "base64://" + base64.StdEncoding.EncodeToString(img)
+}
+
 const (
 	internalMsgTypeGroup = "group"
 )
@@ -0,0 +1,59 @@
+package qrcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// 标准中 01234567 1-M 的例子
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	ecc := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	assert.EqualValues(t, []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}, ecc)
+}
+
+func TestEncodeData(t *testing.T) {
+	data := encodeData([]byte("ab"), 1)
+	assert.Len(t, data, 16)
+	assert.EqualValues(t, []byte{0x40, 0x26, 0x16, 0x20, 0xEC, 0x11}, data[:6])
+}
+
+func TestEncode(t *testing.T) {
+	q, err := Encode([]byte("hello"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, q.Version)
+	assert.EqualValues(t, 21, q.Size)
+	// 定位图案
+	for _, p := range [][2]int{{0, 0}, {q.Size - 7, 0}, {0, q.Size - 7}} {
+		assert.True(t, q.Modules[p[1]][p[0]])
+		assert.True(t, q.Modules[p[1]+3][p[0]+3])
+		assert.False(t, q.Modules[p[1]+1][p[0]+1])
+	}
+	// dark module
+	assert.True(t, q.Modules[q.Size-8][8])
+
+	q, err = Encode([]byte(strings.Repeat("a", 150)))
+	assert.Nil(t, err)
+	assert.EqualValues(t, 8, q.Version)
+	// 版本8的版本信息为 001000010110111100 ，最低位在左上
+	assert.False(t, q.Modules[0][q.Size-11])
+	assert.False(t, q.Modules[q.Size-11][0])
+	assert.True(t, q.Modules[0][q.Size-9])
+
+	_, err = Encode([]byte(strings.Repeat("a", 214)))
+	assert.Equal(t, ErrTooLong, err)
+	q, err = Encode([]byte(strings.Repeat("a", 213)))
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, q.Version)
+}
+
+func TestPNG(t *testing.T) {
+	b, err := PNG("https://www.bilibili.com", 4)
+	assert.Nil(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.EqualValues(t, (25+8)*4, img.Bounds().Dx())
+}